@@ -0,0 +1,110 @@
+package errors
+
+import (
+	"database/sql"
+	"encoding/json"
+	"net"
+)
+
+// Adopt converts a foreign error into the closest typed equivalent from this
+// package, so errors from the standard library and drivers get consistent
+// stack traces and classification. Use it at service boundaries where
+// errors from third-party code enter your own.
+//
+// Conversions:
+//   - nil - nil
+//   - errors already carrying a type from this package - unchanged
+//   - context errors - the original error with a stack trace attached
+//   - net.Error timeouts - TimeoutError
+//   - *net.DNSError - NetworkError (transient only if temporary or timeout)
+//   - *net.OpError - NetworkError (transient)
+//   - encoding/json errors - SerializationError with Format "json"
+//   - sql.ErrNoRows - NotFoundError for a "record"
+//   - Postgres driver errors carrying a SQLSTATE - DatabaseError (see
+//     ClassifyDBError)
+//   - anything else - the original error with a stack trace attached
+//
+// The original error is always preserved as the cause, so errors.Is and
+// errors.As keep working.
+//
+// Example:
+//
+//	row := db.QueryRowContext(ctx, query, id)
+//	if err := row.Scan(&user); err != nil {
+//	    return Adopt(err) // sql.ErrNoRows becomes a 404
+//	}
+func Adopt(err error) error {
+	if err == nil {
+		return nil
+	}
+
+	if hasTypedError(err) {
+		return err
+	}
+
+	// context.DeadlineExceeded satisfies net.Error with Timeout() true, but it
+	// means the caller gave up, not that a dependency timed out.
+	if IsContextError(err) {
+		return WithStack(err)
+	}
+
+	var netErr net.Error
+	if As(err, &netErr) && netErr.Timeout() {
+		return NewTimeoutError("operation timed out", "", 0, WithCause(err))
+	}
+
+	var dnsErr *net.DNSError
+	if As(err, &dnsErr) {
+		return NewNetworkError("DNS lookup failed", "",
+			WithTransient(dnsErr.IsTemporary || dnsErr.IsTimeout),
+			WithCause(err))
+	}
+
+	var opErr *net.OpError
+	if As(err, &opErr) {
+		return NewNetworkError("network operation failed", "", WithCause(err))
+	}
+
+	if isJSONError(err) {
+		return NewSerializationError("invalid JSON", "", "json", WithCause(err))
+	}
+
+	if Is(err, sql.ErrNoRows) {
+		return NewNotFoundError("record", "", WithCause(err))
+	}
+
+	if state := sqlStateOf(err); state != "" {
+		return NewDatabaseError("", "", WithCause(err), WithSQLState(state))
+	}
+
+	return WithStack(err)
+}
+
+// hasTypedError reports whether any error in err's chain is one of this
+// package's typed errors.
+func hasTypedError(err error) bool {
+	found := false
+	walkChain(err, func(node error, _ int) bool {
+		_, found = node.(chainFormatter)
+		return !found
+	})
+	return found
+}
+
+// isJSONError reports whether err's chain contains an encoding/json error.
+func isJSONError(err error) bool {
+	var (
+		syntaxErr      *json.SyntaxError
+		typeErr        *json.UnmarshalTypeError
+		invalidErr     *json.InvalidUnmarshalError
+		unsupportedTyp *json.UnsupportedTypeError
+		unsupportedVal *json.UnsupportedValueError
+		marshalerErr   *json.MarshalerError
+	)
+	return As(err, &syntaxErr) ||
+		As(err, &typeErr) ||
+		As(err, &invalidErr) ||
+		As(err, &unsupportedTyp) ||
+		As(err, &unsupportedVal) ||
+		As(err, &marshalerErr)
+}
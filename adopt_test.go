@@ -0,0 +1,175 @@
+package errors
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"reflect"
+	"syscall"
+	"testing"
+	"time"
+)
+
+// TestAdoptConversionMatrix tests Adopt over a corpus of representative foreign errors
+func TestAdoptConversionMatrix(t *testing.T) {
+	var syntaxErr error = json.Unmarshal([]byte("{"), &struct{}{})
+	var typeErr error = json.Unmarshal([]byte(`{"n":"x"}`), &struct{ N int }{})
+	typed := NewValidationError("invalid", "email")
+
+	tests := []struct {
+		name     string
+		err      error
+		wantType string
+		class    ErrorClass
+		status   int
+	}{
+		{
+			name:     "dial timeout",
+			err:      &net.OpError{Op: "dial", Net: "tcp", Err: os.ErrDeadlineExceeded},
+			wantType: "*errors.TimeoutError",
+			class:    ClassTransient,
+			status:   504,
+		},
+		{
+			name:     "DNS timeout",
+			err:      &net.DNSError{Err: "i/o timeout", Name: "api.example.com", IsTimeout: true},
+			wantType: "*errors.TimeoutError",
+			class:    ClassTransient,
+			status:   504,
+		},
+		{
+			name:     "DNS NXDOMAIN",
+			err:      &net.DNSError{Err: "no such host", Name: "nope.example.com", IsNotFound: true},
+			wantType: "*errors.NetworkError",
+			class:    ClassPermanent,
+			status:   502,
+		},
+		{
+			name:     "DNS temporary failure",
+			err:      &net.DNSError{Err: "server misbehaving", Name: "api.example.com", IsTemporary: true},
+			wantType: "*errors.NetworkError",
+			class:    ClassTransient,
+			status:   502,
+		},
+		{
+			name:     "connection refused",
+			err:      &net.OpError{Op: "dial", Net: "tcp", Err: os.NewSyscallError("connect", syscall.ECONNREFUSED)},
+			wantType: "*errors.NetworkError",
+			class:    ClassTransient,
+			status:   502,
+		},
+		{
+			name:     "json syntax error",
+			err:      syntaxErr,
+			wantType: "*errors.SerializationError",
+			class:    ClassUnknown,
+			status:   500,
+		},
+		{
+			name:     "wrapped json type error",
+			err:      fmt.Errorf("decoding order: %w", typeErr),
+			wantType: "*errors.SerializationError",
+			class:    ClassUnknown,
+			status:   500,
+		},
+		{
+			name:     "sql.ErrNoRows",
+			err:      fmt.Errorf("loading user: %w", sql.ErrNoRows),
+			wantType: "*errors.NotFoundError",
+			class:    ClassPermanent,
+			status:   404,
+		},
+		{
+			name:     "context canceled",
+			err:      context.Canceled,
+			wantType: "*withstack.withStack",
+			class:    ClassContext,
+			status:   500,
+		},
+		{
+			name:     "context deadline",
+			err:      context.DeadlineExceeded,
+			wantType: "*withstack.withStack",
+			class:    ClassContext,
+			status:   504,
+		},
+		{
+			name:     "unknown error",
+			err:      io.ErrClosedPipe,
+			wantType: "*withstack.withStack",
+			class:    ClassUnknown,
+			status:   500,
+		},
+		{
+			name:     "already typed",
+			err:      typed,
+			wantType: "*errors.ValidationError",
+			class:    ClassPermanent,
+			status:   400,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			adopted := Adopt(tt.err)
+
+			if got := reflect.TypeOf(adopted).String(); got != tt.wantType {
+				t.Errorf("type = %s, want %s", got, tt.wantType)
+			}
+			if got := Classify(adopted); got != tt.class {
+				t.Errorf("Classify() = %s, want %s", got, tt.class)
+			}
+			if got := HTTPStatus(adopted); got != tt.status {
+				t.Errorf("HTTPStatus() = %d, want %d", got, tt.status)
+			}
+			if !Is(adopted, tt.err) {
+				t.Error("original error should remain in the chain")
+			}
+			if tt.wantType == "*withstack.withStack" && !HasStackTrace(adopted) {
+				t.Error("passthrough errors should carry a stack trace")
+			}
+		})
+	}
+
+	if Adopt(nil) != nil {
+		t.Error("Adopt(nil) should be nil")
+	}
+	if Adopt(typed) != typed {
+		t.Error("already typed errors should be returned unchanged")
+	}
+}
+
+// TestHTTPStatus tests HTTP status mapping
+func TestHTTPStatus(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want int
+	}{
+		{"nil", nil, 200},
+		{"HTTPError", NewHTTPError(418, "teapot", nil), 418},
+		{"wrapped HTTPError", Wrap(NewHTTPError(503, "down", nil), "outer"), 503},
+		{"validation", NewValidationError("invalid", "email"), 400},
+		{"rate limit", NewRateLimitError("slow down", "Call", time.Second), 429},
+		{"rate limit sentinel", Wrap(ErrRateLimited, "outer"), 429},
+		{"timeout", NewTimeoutError("slow", "Fetch", time.Second), 504},
+		{"circuit", NewCircuitBreakerError("open", "Call", "open"), 503},
+		{"network", NewNetworkError("down", "Dial"), 502},
+		{"not found sentinel", ErrActivityNotFound, 404},
+		{"outermost typed wins", NewValidationError("bad", "id", WithCause(NewHTTPError(502, "x", nil))), 400},
+		{"processing delegates", NewProcessingError("failed", "Run", WithCause(NewHTTPError(409, "conflict", nil))), 409},
+		{"generic", fmt.Errorf("oops"), 500},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := HTTPStatus(tt.err); got != tt.want {
+				t.Errorf("HTTPStatus() = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
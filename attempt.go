@@ -0,0 +1,92 @@
+package errors
+
+import "fmt"
+
+// Attempt bucket label values for LabelAttempt (see MetricLabels).
+const (
+	AttemptFirst  = "first"
+	AttemptMiddle = "middle"
+	AttemptLast   = "last"
+)
+
+// GetAttempt returns the retry attempt recorded with WithAttempt on the
+// outermost typed error in err's chain that has one, and the maximum
+// attempts (0 if unknown). Returns false if no attempt was recorded.
+//
+// Example:
+//
+//	if n, max, ok := errors.GetAttempt(err); ok && n < max {
+//	    logger.Warn("attempt failed, retrying", "attempt", n, "max", max)
+//	}
+func GetAttempt(err error) (n, maxAttempts int, ok bool) {
+	walkChain(err, func(node error, _ int) bool {
+		if attempt, maxPtr := attemptFields(node); attempt != nil && *attempt > 0 {
+			n, maxAttempts, ok = *attempt, *maxPtr, true
+			return false
+		}
+		return true
+	})
+	return n, maxAttempts, ok
+}
+
+// StampAttempt returns err with attempt n of maxAttempts recorded on its
+// outermost typed error (see WithAttempt), for retry executors whose
+// operations build their own errors. err itself is never modified: the
+// typed error and the wrappers above it are copied as ReplaceCause copies
+// them. Returns err unchanged when its outermost typed error doesn't record
+// attempts or already has one.
+//
+// Example:
+//
+//	err = errors.StampAttempt(op(ctx), attempt, maxAttempts)
+func StampAttempt(err error, n, maxAttempts int) error {
+	typed := outermostTyped(err)
+	if attempt, _ := attemptFields(typed); attempt == nil || *attempt > 0 {
+		return err
+	}
+	stamped := cloneNode(typed)
+	WithAttempt(n, maxAttempts)(stamped)
+	replaced, _ := replaceCause(err, func(node error) bool { return node == typed }, stamped, 0)
+	return replaced
+}
+
+// attemptFields returns pointers to the Attempt and MaxAttempts fields of a
+// typed error, or nils if err has none.
+func attemptFields(err any) (attempt, maxAttempts *int) {
+	switch e := err.(type) {
+	case *HTTPError:
+		return &e.Attempt, &e.MaxAttempts
+	case *TimeoutError:
+		return &e.Attempt, &e.MaxAttempts
+	case *ProcessingError:
+		return &e.Attempt, &e.MaxAttempts
+	case *NetworkError:
+		return &e.Attempt, &e.MaxAttempts
+	}
+	return nil, nil
+}
+
+// attemptSuffix renders an attempt for Error(), such as " (attempt 4/5)",
+// or "" when none was recorded.
+func attemptSuffix(attempt, maxAttempts int) string {
+	switch {
+	case attempt <= 0:
+		return ""
+	case maxAttempts <= 0:
+		return fmt.Sprintf(" (attempt %d)", attempt)
+	}
+	return fmt.Sprintf(" (attempt %d/%d)", attempt, maxAttempts)
+}
+
+// attemptBucket buckets an attempt into AttemptFirst, AttemptMiddle or
+// AttemptLast. The final attempt is AttemptLast even when it is also the
+// first, since nothing retries after it.
+func attemptBucket(attempt, maxAttempts int) string {
+	switch {
+	case maxAttempts > 0 && attempt >= maxAttempts:
+		return AttemptLast
+	case attempt == 1:
+		return AttemptFirst
+	}
+	return AttemptMiddle
+}
@@ -0,0 +1,96 @@
+package errors
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestWithAttempt tests recording and rendering the retry attempt that produced an error
+func TestWithAttempt(t *testing.T) {
+	tests := []struct {
+		name       string
+		err        error
+		wantString string
+		wantN      int
+		wantMax    int
+		wantBucket string
+	}{
+		{
+			name:       "http error",
+			err:        NewHTTPError(503, "Service Unavailable", nil, WithAttempt(4, 5)),
+			wantString: "HTTP 503: Service Unavailable (attempt 4/5)",
+			wantN:      4, wantMax: 5, wantBucket: AttemptMiddle,
+		},
+		{
+			name:       "timeout error",
+			err:        NewTimeoutError("Deadline hit", "FetchQuote", time.Second, WithAttempt(1, 3)),
+			wantString: "timeout in FetchQuote after 1s (attempt 1/3): Deadline hit",
+			wantN:      1, wantMax: 3, wantBucket: AttemptFirst,
+		},
+		{
+			name:       "processing error",
+			err:        NewProcessingError("Failed to index", "Index", WithItemID("doc-7"), WithAttempt(3, 3)),
+			wantString: "Failed to index: Index failed for item doc-7 (not retryable) (attempt 3/3)",
+			wantN:      3, wantMax: 3, wantBucket: AttemptLast,
+		},
+		{
+			name:       "network error without a cap",
+			err:        Wrap(NewNetworkError("Connection reset", "Fetch", WithAttempt(2, 0)), "loading profile"),
+			wantString: "loading profile: network error in Fetch (transient) (attempt 2): Connection reset",
+			wantN:      2, wantBucket: AttemptMiddle,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.err.Error(); got != tt.wantString {
+				t.Errorf("Error() = %q, want %q", got, tt.wantString)
+			}
+			n, maxAttempts, ok := GetAttempt(tt.err)
+			if !ok || n != tt.wantN || maxAttempts != tt.wantMax {
+				t.Errorf("GetAttempt() = %d, %d, %v, want %d, %d, true", n, maxAttempts, ok, tt.wantN, tt.wantMax)
+			}
+			if got := MetricLabels(tt.err)[LabelAttempt]; got != tt.wantBucket {
+				t.Errorf("attempt label = %q, want %q", got, tt.wantBucket)
+			}
+			if got := ExtractErrorInfo(tt.err)["attempt"]; got != tt.wantN {
+				t.Errorf("info[attempt] = %v, want %d", got, tt.wantN)
+			}
+		})
+	}
+
+	t.Run("not recorded", func(t *testing.T) {
+		err := NewHTTPError(503, "Service Unavailable", nil)
+		if _, _, ok := GetAttempt(err); ok {
+			t.Error("GetAttempt() reported an attempt that was never recorded")
+		}
+		if _, ok := MetricLabels(err)[LabelAttempt]; ok || strings.Contains(err.Error(), "attempt") {
+			t.Errorf("unrecorded attempt leaked into labels or %q", err.Error())
+		}
+	})
+}
+
+// TestStampAttempt tests that StampAttempt records the attempt on a copy, keeping any attempt already recorded
+func TestStampAttempt(t *testing.T) {
+	original := NewHTTPError(503, "Service Unavailable", nil)
+	wrapped := Wrap(original, "loading quote")
+
+	stamped := StampAttempt(wrapped, 2, 3)
+	if n, maxAttempts, ok := GetAttempt(stamped); !ok || n != 2 || maxAttempts != 3 {
+		t.Errorf("GetAttempt() = %d, %d, %v, want 2, 3, true", n, maxAttempts, ok)
+	}
+	if got := stamped.Error(); got != "loading quote: HTTP 503: Service Unavailable (attempt 2/3)" {
+		t.Errorf("Error() = %q", got)
+	}
+	if _, _, ok := GetAttempt(wrapped); ok {
+		t.Error("StampAttempt() modified its argument")
+	}
+	if n, _, _ := GetAttempt(StampAttempt(stamped, 3, 3)); n != 2 {
+		t.Errorf("restamped attempt = %d, want the recorded 2", n)
+	}
+	if plain := fmt.Errorf("boom"); StampAttempt(plain, 1, 3) != plain {
+		t.Error("StampAttempt() changed an error without attempt fields")
+	}
+}
@@ -0,0 +1,179 @@
+package errors
+
+import (
+	"container/list"
+	"math"
+	"sync"
+	"time"
+)
+
+// BackoffPolicy describes an exponential backoff curve.
+// The delay for attempt n (starting at 0) is Initial * Multiplier^n,
+// capped at Max, with up to Jitter (0.0-1.0) of the delay randomised away.
+// The first Immediate attempts have no delay, and the curve starts after
+// them.
+type BackoffPolicy struct {
+	Initial    time.Duration
+	Max        time.Duration
+	Multiplier float64
+	Jitter     float64
+	Immediate  int
+}
+
+// DefaultBackoffPolicy is a general-purpose curve: 100ms doubling up to 30s
+// with 20% jitter.
+var DefaultBackoffPolicy = BackoffPolicy{
+	Initial:    100 * time.Millisecond,
+	Max:        30 * time.Second,
+	Multiplier: 2,
+	Jitter:     0.2,
+}
+
+// Delay returns the backoff delay before retry attempt n (0-based).
+func (p BackoffPolicy) Delay(attempt int) time.Duration {
+	if p.Initial <= 0 || attempt < p.Immediate {
+		return 0
+	}
+	attempt -= p.Immediate
+
+	multiplier := p.Multiplier
+	if multiplier < 1 {
+		multiplier = 1
+	}
+
+	delay := float64(p.Initial) * math.Pow(multiplier, float64(attempt))
+	if p.Max > 0 && delay > float64(p.Max) {
+		delay = float64(p.Max)
+	}
+	if p.Jitter > 0 {
+		delay -= delay * min(p.Jitter, 1) * randFloat64()
+	}
+	return time.Duration(delay)
+}
+
+// defaultMaxBackoffKeys bounds how many keys a BackoffRegistry tracks.
+const defaultMaxBackoffKeys = 10000
+
+// BackoffRegistry keeps independent backoff state per key, so one tenant or
+// endpoint failing doesn't slow retries for everyone else. Keys idle for
+// longer than the TTL are forgotten, and the least recently used keys are
+// evicted once the key limit is reached. Safe for concurrent use.
+type BackoffRegistry struct {
+	policy  BackoffPolicy
+	ttl     time.Duration
+	maxKeys int
+	clock   Clock
+
+	mu    sync.Mutex
+	keys  map[string]*list.Element
+	order *list.List // front = most recently used
+}
+
+type backoffState struct {
+	key      string
+	attempts int
+	lastSeen time.Time
+}
+
+// NewBackoffRegistry creates a registry applying policy to each key.
+// Supports WithClock and WithMaxKeys options.
+//
+// Example:
+//
+//	backoff := NewBackoffRegistry(DefaultBackoffPolicy, 10*time.Minute)
+//
+//	if err := callTenant(ctx, tenantID); err != nil {
+//	    time.Sleep(backoff.NextDelay(tenantID, err))
+//	} else {
+//	    backoff.Reset(tenantID)
+//	}
+func NewBackoffRegistry(policy BackoffPolicy, ttl time.Duration, opts ...Option) *BackoffRegistry {
+	r := &BackoffRegistry{
+		policy:  policy,
+		ttl:     ttl,
+		maxKeys: defaultMaxBackoffKeys,
+		clock:   packageClock{},
+		keys:    make(map[string]*list.Element),
+		order:   list.New(),
+	}
+	for _, opt := range opts {
+		opt(r)
+	}
+	return r
+}
+
+// NextDelay records a failure for key and returns how long to wait before
+// retrying it. A retry-after hint in err (see GetRetryAfter) overrides the
+// key's local backoff, since the server knows better when it will recover.
+func (r *BackoffRegistry) NextDelay(key string, err error) time.Duration {
+	r.mu.Lock()
+	now := r.clock.Now()
+	r.evictExpired(now)
+
+	state := r.touch(key, now)
+	attempt := state.attempts
+	state.attempts++
+	r.mu.Unlock()
+
+	if hint, ok := GetRetryAfter(err); ok {
+		return hint
+	}
+	return r.policy.Delay(attempt)
+}
+
+// Reset clears the backoff state for key, typically after a success.
+func (r *BackoffRegistry) Reset(key string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if elem, ok := r.keys[key]; ok {
+		r.order.Remove(elem)
+		delete(r.keys, key)
+	}
+}
+
+// Len returns the number of keys currently tracked.
+func (r *BackoffRegistry) Len() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return len(r.keys)
+}
+
+// touch returns the state for key, creating it if needed, and marks it most
+// recently used. Must be called with r.mu held.
+func (r *BackoffRegistry) touch(key string, now time.Time) *backoffState {
+	if elem, ok := r.keys[key]; ok {
+		r.order.MoveToFront(elem)
+		state := elem.Value.(*backoffState)
+		state.lastSeen = now
+		return state
+	}
+
+	state := &backoffState{key: key, lastSeen: now}
+	r.keys[key] = r.order.PushFront(state)
+
+	for r.maxKeys > 0 && len(r.keys) > r.maxKeys {
+		r.removeOldest()
+	}
+	return state
+}
+
+// evictExpired drops keys idle for longer than the TTL. Must be called with
+// r.mu held.
+func (r *BackoffRegistry) evictExpired(now time.Time) {
+	if r.ttl <= 0 {
+		return
+	}
+	for oldest := r.order.Back(); oldest != nil; oldest = r.order.Back() {
+		if now.Sub(oldest.Value.(*backoffState).lastSeen) <= r.ttl {
+			return
+		}
+		r.removeOldest()
+	}
+}
+
+func (r *BackoffRegistry) removeOldest() {
+	oldest := r.order.Back()
+	r.order.Remove(oldest)
+	delete(r.keys, oldest.Value.(*backoffState).key)
+}
@@ -0,0 +1,248 @@
+package errors
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeClock is a manually advanced Clock for tests. errtest.FakeClock
+// can't be used here: errtest imports this package.
+type fakeClock struct {
+	mu  sync.Mutex
+	now time.Time
+}
+
+func newFakeClock() *fakeClock {
+	return &fakeClock{now: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)}
+}
+
+func (c *fakeClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+func (c *fakeClock) Since(t time.Time) time.Duration {
+	return c.Now().Sub(t)
+}
+
+func (c *fakeClock) Advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = c.now.Add(d)
+}
+
+// Sleep advances the clock instead of blocking, like errtest.FakeClock.
+func (c *fakeClock) Sleep(ctx context.Context, d time.Duration) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	c.Advance(max(d, 0))
+	return nil
+}
+
+var testBackoffPolicy = BackoffPolicy{
+	Initial:    100 * time.Millisecond,
+	Max:        time.Second,
+	Multiplier: 2,
+}
+
+// TestBackoffPolicyDelay tests the exponential curve and its cap
+func TestBackoffPolicyDelay(t *testing.T) {
+	tests := []struct {
+		attempt int
+		want    time.Duration
+	}{
+		{attempt: 0, want: 100 * time.Millisecond},
+		{attempt: 1, want: 200 * time.Millisecond},
+		{attempt: 3, want: 800 * time.Millisecond},
+		{attempt: 4, want: time.Second},
+		{attempt: 50, want: time.Second},
+	}
+
+	for _, tt := range tests {
+		t.Run(fmt.Sprintf("attempt %d", tt.attempt), func(t *testing.T) {
+			if got := testBackoffPolicy.Delay(tt.attempt); got != tt.want {
+				t.Errorf("Delay(%d) = %v, want %v", tt.attempt, got, tt.want)
+			}
+		})
+	}
+
+	t.Run("jitter stays within bounds", func(t *testing.T) {
+		policy := testBackoffPolicy
+		policy.Jitter = 0.5
+		for range 100 {
+			if got := policy.Delay(1); got < 100*time.Millisecond || got > 200*time.Millisecond {
+				t.Fatalf("Delay(1) = %v, want within [100ms, 200ms]", got)
+			}
+		}
+	})
+}
+
+// TestBackoffRegistry tests per-key backoff state
+func TestBackoffRegistry(t *testing.T) {
+	failure := NewNetworkError("connection reset", "Call")
+
+	t.Run("keys back off independently", func(t *testing.T) {
+		clock := newFakeClock()
+		registry := NewBackoffRegistry(testBackoffPolicy, time.Minute, WithClock(clock))
+
+		// tenant-a fails on every call, tenant-b fails once every third call.
+		var delaysA, delaysB []time.Duration
+		for i := range 6 {
+			delaysA = append(delaysA, registry.NextDelay("tenant-a", failure))
+			if i%3 == 0 {
+				delaysB = append(delaysB, registry.NextDelay("tenant-b", failure))
+			} else {
+				registry.Reset("tenant-b")
+			}
+			clock.Advance(time.Second)
+		}
+
+		wantA := []time.Duration{100, 200, 400, 800, 1000, 1000}
+		for i, want := range wantA {
+			if delaysA[i] != want*time.Millisecond {
+				t.Errorf("tenant-a delay %d = %v, want %v", i, delaysA[i], want*time.Millisecond)
+			}
+		}
+		for i, got := range delaysB {
+			if got != 100*time.Millisecond {
+				t.Errorf("tenant-b delay %d = %v, want 100ms", i, got)
+			}
+		}
+	})
+
+	t.Run("reset restarts the curve", func(t *testing.T) {
+		registry := NewBackoffRegistry(testBackoffPolicy, time.Minute, WithClock(newFakeClock()))
+		registry.NextDelay("endpoint", failure)
+		registry.NextDelay("endpoint", failure)
+		registry.Reset("endpoint")
+
+		if got := registry.NextDelay("endpoint", failure); got != 100*time.Millisecond {
+			t.Errorf("NextDelay() after Reset = %v, want 100ms", got)
+		}
+	})
+
+	t.Run("server hint overrides local state", func(t *testing.T) {
+		registry := NewBackoffRegistry(testBackoffPolicy, time.Minute, WithClock(newFakeClock()))
+		registry.NextDelay("endpoint", failure)
+
+		hinted := Wrap(NewRateLimitError("Too many requests", "Call", 5*time.Second), "calling api")
+		if got := registry.NextDelay("endpoint", hinted); got != 5*time.Second {
+			t.Errorf("NextDelay() = %v, want server hint 5s", got)
+		}
+		if got := registry.NextDelay("endpoint", failure); got != 400*time.Millisecond {
+			t.Errorf("NextDelay() = %v, want local state to keep advancing", got)
+		}
+	})
+
+	t.Run("idle keys expire", func(t *testing.T) {
+		clock := newFakeClock()
+		registry := NewBackoffRegistry(testBackoffPolicy, time.Minute, WithClock(clock))
+		registry.NextDelay("stale", failure)
+		registry.NextDelay("stale", failure)
+
+		clock.Advance(2 * time.Minute)
+		registry.NextDelay("fresh", failure)
+
+		if registry.Len() != 1 {
+			t.Errorf("Len() = %d, want 1", registry.Len())
+		}
+		if got := registry.NextDelay("stale", failure); got != 100*time.Millisecond {
+			t.Errorf("expired key delay = %v, want 100ms", got)
+		}
+	})
+
+	t.Run("key count is bounded", func(t *testing.T) {
+		registry := NewBackoffRegistry(testBackoffPolicy, time.Minute, WithClock(newFakeClock()), WithMaxKeys(3))
+		for i := range 10 {
+			registry.NextDelay(fmt.Sprintf("key-%d", i), failure)
+		}
+		registry.NextDelay("key-9", failure)
+
+		if registry.Len() != 3 {
+			t.Errorf("Len() = %d, want 3", registry.Len())
+		}
+		if got := registry.NextDelay("key-9", failure); got != 400*time.Millisecond {
+			t.Errorf("recent key delay = %v, want 400ms", got)
+		}
+	})
+
+	t.Run("concurrent use", func(t *testing.T) {
+		registry := NewBackoffRegistry(DefaultBackoffPolicy, time.Minute)
+		var wg sync.WaitGroup
+		for i := range 8 {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				key := fmt.Sprintf("key-%d", i%2)
+				for range 100 {
+					registry.NextDelay(key, failure)
+					if i%4 == 0 {
+						registry.Reset(key)
+					}
+				}
+			}()
+		}
+		wg.Wait()
+	})
+}
+
+// quotaError is a foreign error type carrying a retry-after hint.
+type quotaError struct{ resetIn time.Duration }
+
+func (e quotaError) Error() string             { return "quota exhausted" }
+func (e quotaError) RetryAfter() time.Duration { return e.resetIn }
+
+// TestGetRetryAfter tests extracting retry-after hints from a chain
+func TestGetRetryAfter(t *testing.T) {
+	tests := []struct {
+		name   string
+		err    error
+		want   time.Duration
+		wantOk bool
+	}{
+		{name: "nil", err: nil},
+		{name: "no hint", err: fmt.Errorf("boom")},
+		{name: "rate limit", err: NewRateLimitError("slow down", "Call", 2*time.Second), want: 2 * time.Second, wantOk: true},
+		{
+			name:   "wrapped retryable",
+			err:    Wrap(NewRetryableError("busy", "Call", time.Second), "outer"),
+			want:   time.Second,
+			wantOk: true,
+		},
+		{
+			name:   "longest hint wins",
+			err:    NewRetryableError("busy", "Call", time.Second, WithCause(NewRateLimitError("slow down", "Call", 3*time.Second))),
+			want:   3 * time.Second,
+			wantOk: true,
+		},
+		{name: "zero hint", err: NewRateLimitError("slow down", "Call", 0)},
+		{
+			name:   "zero hint beside a positive one",
+			err:    NewRateLimitError("slow down", "Call", 0, WithCause(NewRetryableError("busy", "Call", time.Second))),
+			want:   time.Second,
+			wantOk: true,
+		},
+		{name: "custom hint", err: quotaError{resetIn: 4 * time.Second}, want: 4 * time.Second, wantOk: true},
+		{
+			name:   "wrapped custom hint beside a shorter one",
+			err:    fmt.Errorf("listing: %w", NewProcessingError("failed", "List", WithCause(quotaError{resetIn: 5 * time.Second}), WithAdditionalCause(NewRateLimitError("slow down", "List", time.Second)))),
+			want:   5 * time.Second,
+			wantOk: true,
+		},
+		{name: "zero custom hint", err: Wrap(quotaError{}, "listing")},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := GetRetryAfter(tt.err)
+			if got != tt.want || ok != tt.wantOk {
+				t.Errorf("GetRetryAfter() = %v, %v, want %v, %v", got, ok, tt.want, tt.wantOk)
+			}
+		})
+	}
+}
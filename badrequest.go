@@ -0,0 +1,109 @@
+package errors
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+
+	"github.com/cockroachdb/errors"
+)
+
+// maxFieldViolations caps how many violations ToBadRequest encodes, keeping
+// the status details within gRPC's metadata size limits.
+const maxFieldViolations = 50
+
+// ReasonMoreViolations is the Reason of the synthetic last violation
+// ToBadRequest adds when it drops violations over the cap.
+const ReasonMoreViolations = "MORE_VIOLATIONS"
+
+var moreViolationsPattern = regexp.MustCompile(`^\+(\d+) more$`)
+
+// BadRequest mirrors google.rpc.BadRequest from
+// google.golang.org/genproto/googleapis/rpc/errdetails without the
+// dependency. Fields correspond one to one, so gRPC adapters copy it into
+// an errdetails.BadRequest for status details and back.
+type BadRequest struct {
+	FieldViolations []FieldViolation `json:"field_violations"`
+}
+
+// FieldViolation mirrors google.rpc.BadRequest.FieldViolation.
+type FieldViolation struct {
+	Field       string `json:"field"` // path to the field, such as "items[2].sku"
+	Description string `json:"description"`
+	Reason      string `json:"reason,omitempty"` // the ValidationError's code
+}
+
+// ToBadRequest collects every ValidationError in err's chain, including
+// those joined with errors.Join or attached with WithAdditionalCause, into a
+// BadRequest with one FieldViolation each, in chain order. Returns nil when
+// the chain holds no ValidationError.
+//
+// Field paths are passed through unchanged, so nested paths such as
+// "items[2].sku" survive. The Description is the ValidationError's Message
+// and the Reason its Code. Values are never encoded, so sensitive input
+// can't reach the wire. Beyond 50 violations the rest are dropped and a
+// last violation with Reason ReasonMoreViolations and a Description such as
+// "+3 more" is added.
+//
+// Example:
+//
+//	if br := errors.ToBadRequest(err); br != nil {
+//	    details := &errdetails.BadRequest{}
+//	    for _, v := range br.FieldViolations {
+//	        details.FieldViolations = append(details.FieldViolations,
+//	            &errdetails.BadRequest_FieldViolation{Field: v.Field, Description: v.Description, Reason: v.Reason})
+//	    }
+//	    st, _ = st.WithDetails(details)
+//	}
+func ToBadRequest(err error) *BadRequest {
+	var violations []FieldViolation
+	walkChain(err, func(node error, _ int) bool {
+		if e, ok := node.(*ValidationError); ok {
+			violations = append(violations, FieldViolation{Field: e.Field, Description: e.Message, Reason: e.Code})
+		}
+		return true
+	})
+	if len(violations) == 0 {
+		return nil
+	}
+	if dropped := len(violations) - maxFieldViolations; dropped > 0 {
+		violations = append(violations[:maxFieldViolations], FieldViolation{
+			Description: fmt.Sprintf("+%d more", dropped),
+			Reason:      ReasonMoreViolations,
+		})
+	}
+	return &BadRequest{FieldViolations: violations}
+}
+
+// FromBadRequest converts a BadRequest received in gRPC status details back
+// into ValidationErrors, one per violation, so IsValidation and
+// ToBadRequest work on the client. A single violation is returned as its
+// ValidationError; several are joined with errors.Join. The synthetic
+// violation counting dropped entries is kept as a "dropped_violations"
+// metadata entry on the last ValidationError. Returns nil for a nil or empty
+// BadRequest.
+func FromBadRequest(br *BadRequest) error {
+	if br == nil {
+		return nil
+	}
+	var errs []error
+	var last *ValidationError
+	for _, v := range br.FieldViolations {
+		if v.Reason == ReasonMoreViolations && last != nil {
+			if m := moreViolationsPattern.FindStringSubmatch(v.Description); m != nil {
+				dropped, _ := strconv.Atoi(m[1])
+				last.Metadata = map[string]any{"dropped_violations": dropped}
+				continue
+			}
+		}
+		last = &ValidationError{Message: v.Description, Field: v.Field, Code: v.Reason}
+		errs = append(errs, last)
+	}
+	switch len(errs) {
+	case 0:
+		return nil
+	case 1:
+		return errs[0]
+	}
+	return errors.Join(errs...)
+}
@@ -0,0 +1,99 @@
+package errors
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+// TestToBadRequest tests that validation collections become one violation per entry
+func TestToBadRequest(t *testing.T) {
+	collection := Wrap(fmt.Errorf("%w; %w; %w",
+		NewValidationError("SKU is required", "items[2].sku", WithCode("REQUIRED")),
+		NewValidationError("Password is too short", "account.password",
+			WithValue("hunter2"), WithCode("MIN_LENGTH")),
+		NewValidationError("Quantity must be positive", "items[0].quantity",
+			WithAdditionalCause(NewValidationError("Price must be positive", "items[0].price"))),
+	), "validating order")
+
+	br := ToBadRequest(collection)
+	want := []FieldViolation{
+		{Field: "items[2].sku", Description: "SKU is required", Reason: "REQUIRED"},
+		{Field: "account.password", Description: "Password is too short", Reason: "MIN_LENGTH"},
+		{Field: "items[0].quantity", Description: "Quantity must be positive"},
+		{Field: "items[0].price", Description: "Price must be positive"},
+	}
+	if br == nil || fmt.Sprint(br.FieldViolations) != fmt.Sprint(want) {
+		t.Fatalf("ToBadRequest() = %+v, want %+v", br, want)
+	}
+	if strings.Contains(fmt.Sprint(br), "hunter2") {
+		t.Error("validation values must not be encoded")
+	}
+
+	if br := ToBadRequest(NewNotFoundError("order", "42")); br != nil {
+		t.Errorf("ToBadRequest() = %+v, want nil without a ValidationError", br)
+	}
+}
+
+// TestBadRequestRoundTrip tests that a collection converts back into ValidationErrors
+func TestBadRequestRoundTrip(t *testing.T) {
+	tests := []struct {
+		name      string
+		count     int
+		wantCount int
+		wantMore  int
+	}{
+		{"single", 1, 1, 0},
+		{"several", 3, 3, 0},
+		{"at cap", 50, 50, 0},
+		{"over cap", 53, 50, 3},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var more []Option
+			for i := 1; i < tt.count; i++ {
+				more = append(more, WithAdditionalCause(NewValidationError("is invalid", fmt.Sprintf("items[%d].sku", i))))
+			}
+			br := ToBadRequest(NewValidationError("is invalid", "items[0].sku", more...))
+
+			wantEncoded := tt.wantCount
+			if tt.wantMore > 0 {
+				wantEncoded++
+				last := br.FieldViolations[len(br.FieldViolations)-1]
+				if last.Reason != ReasonMoreViolations || last.Description != fmt.Sprintf("+%d more", tt.wantMore) {
+					t.Errorf("last violation = %+v, want a %q entry", last, ReasonMoreViolations)
+				}
+			}
+			if len(br.FieldViolations) != wantEncoded {
+				t.Fatalf("encoded %d violations, want %d", len(br.FieldViolations), wantEncoded)
+			}
+
+			decoded := FromBadRequest(br)
+			if !IsValidation(decoded) {
+				t.Fatalf("FromBadRequest() = %v, want ValidationErrors", decoded)
+			}
+			again := ToBadRequest(decoded)
+			if len(again.FieldViolations) != tt.wantCount || again.FieldViolations[0].Field != "items[0].sku" {
+				t.Errorf("re-encoded %+v, want the %d decoded violations", again, tt.wantCount)
+			}
+			if tt.wantMore > 0 {
+				last := again.FieldViolations[len(again.FieldViolations)-1]
+				var validationErr *ValidationError
+				walkChain(decoded, func(node error, _ int) bool {
+					if e, ok := node.(*ValidationError); ok && e.Field == last.Field {
+						validationErr = e
+					}
+					return true
+				})
+				if got, _ := GetMetadata(validationErr, "dropped_violations"); got != tt.wantMore {
+					t.Errorf("dropped_violations = %v, want %d", got, tt.wantMore)
+				}
+			}
+		})
+	}
+
+	if FromBadRequest(nil) != nil || FromBadRequest(&BadRequest{}) != nil {
+		t.Error("FromBadRequest() of an empty BadRequest should be nil")
+	}
+}
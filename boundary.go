@@ -0,0 +1,96 @@
+package errors
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"slices"
+	"sync/atomic"
+
+	"github.com/cockroachdb/errors"
+)
+
+// Metadata keys recorded by Boundary on the internal error it returns.
+const (
+	MetadataUnexpectedType  = "unexpected_type"
+	MetadataUnexpectedClass = "unexpected_class"
+)
+
+var strictBoundaries atomic.Bool
+
+// Boundary is BoundaryCtx with a background context.
+func Boundary(err error, allowed ...ErrorClass) error {
+	return BoundaryCtx(context.Background(), err, allowed...)
+}
+
+// BoundaryCtx asserts that err, about to leave an API layer, has one of
+// the allowed classes (see Classify). Allowed errors, and nil, are returned
+// as is. Anything else is a programming bug: it is wrapped in a 500
+// internal error, marked non-retryable since retrying a bug can't help, at
+// SeverityCritical, with its type and class recorded under
+// "unexpected_type" and "unexpected_class" metadata, and passed to Report
+// with ctx so someone gets paged, while the client still gets a clean 500.
+// Without allowed classes, every class but ClassUnknown is allowed. Errors
+// already wrapped by a boundary pass through unchanged.
+//
+// With EnableStrictBoundaries, the wrapped error is panicked instead, so
+// integration tests catch these before production does.
+//
+// Example:
+//
+//	if err := svc.CreateOrder(ctx, req); err != nil {
+//	    errors.WriteProblem(w, errors.BoundaryCtx(ctx, err))
+//	    return
+//	}
+func BoundaryCtx(ctx context.Context, err error, allowed ...ErrorClass) error {
+	if err == nil {
+		return nil
+	}
+	if _, wrapped := GetMetadata(err, MetadataUnexpectedType); wrapped {
+		return err
+	}
+
+	class := Classify(err)
+	if len(allowed) == 0 && class != ClassUnknown || slices.Contains(allowed, class) {
+		return err
+	}
+
+	internal := NewHTTPError(http.StatusInternalServerError, http.StatusText(http.StatusInternalServerError), err,
+		WithRetryableOverride(false),
+		WithSeverity(SeverityCritical),
+		WithMetadata(MetadataUnexpectedType, unexpectedType(err)),
+		WithMetadata(MetadataUnexpectedClass, string(class)))
+	if strictBoundaries.Load() {
+		panic(internal)
+	}
+	Report(ctx, internal)
+	return internal
+}
+
+// EnableStrictBoundaries makes Boundary and BoundaryCtx panic on
+// unexpected errors instead of converting them. Intended for integration
+// tests.
+//
+// Example:
+//
+//	func TestMain(m *testing.M) {
+//	    errors.EnableStrictBoundaries()
+//	    os.Exit(m.Run())
+//	}
+func EnableStrictBoundaries() {
+	strictBoundaries.Store(true)
+}
+
+// DisableStrictBoundaries turns strict boundaries off again.
+func DisableStrictBoundaries() {
+	strictBoundaries.Store(false)
+}
+
+// unexpectedType names err for MetadataUnexpectedType: its outermost typed
+// error's type, or the Go type of its root cause.
+func unexpectedType(err error) string {
+	if typed := outermostTyped(err); typed != nil {
+		return typeName(typed.(chainFormatter))
+	}
+	return fmt.Sprintf("%T", errors.UnwrapAll(err))
+}
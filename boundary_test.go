@@ -0,0 +1,106 @@
+package errors
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"testing"
+)
+
+// unwrappedDriverError stands in for a raw error from a third-party driver
+type unwrappedDriverError struct{}
+
+func (unwrappedDriverError) Error() string { return "pq: relation \"orders\" does not exist" }
+
+// TestBoundary tests which errors pass an API boundary and which become internal errors
+func TestBoundary(t *testing.T) {
+	validation := NewValidationError("Invalid email", "email")
+	tests := []struct {
+		name      string
+		err       error
+		allowed   []ErrorClass
+		wantPass  bool
+		wantType  string
+		wantClass string
+	}{
+		{name: "nil", err: nil, wantPass: true},
+		{name: "classified error", err: validation, wantPass: true},
+		{name: "allowed class", err: validation, allowed: []ErrorClass{ClassPermanent}, wantPass: true},
+		{
+			name: "disallowed class", err: NewNetworkError("Connection reset", "Fetch"),
+			allowed: []ErrorClass{ClassPermanent}, wantType: "NetworkError", wantClass: "transient",
+		},
+		{
+			name: "raw driver error", err: fmt.Errorf("loading order: %w", unwrappedDriverError{}),
+			wantType: "errors.unwrappedDriverError", wantClass: "unknown",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			defer ResetHooks()
+			var reported error
+			RegisterHook(func(_ context.Context, err error) { reported = err }, HookFilter{MinSeverity: SeverityCritical})
+
+			got := Boundary(tt.err, tt.allowed...)
+			if tt.wantPass {
+				if got != tt.err || reported != nil {
+					t.Fatalf("Boundary() = %v (reported %v), want %v passed through", got, reported, tt.err)
+				}
+				return
+			}
+
+			if HTTPStatus(got) != http.StatusInternalServerError || got.Error() == tt.err.Error() {
+				t.Errorf("Boundary() = %q with status %d, want an opaque 500", got, HTTPStatus(got))
+			}
+			if IsRetryable(got) || Classify(got) != ClassPermanent {
+				t.Errorf("IsRetryable() = %v, Classify() = %v; a bug shouldn't be retried", IsRetryable(got), Classify(got))
+			}
+			if GetSeverity(got) != SeverityCritical || reported != got {
+				t.Errorf("severity %v, reported %v; want the internal error reported at critical", GetSeverity(got), reported)
+			}
+			if typ, _ := GetMetadata(got, MetadataUnexpectedType); typ != tt.wantType {
+				t.Errorf("unexpected_type = %v, want %q", typ, tt.wantType)
+			}
+			if class, _ := GetMetadata(got, MetadataUnexpectedClass); class != tt.wantClass {
+				t.Errorf("unexpected_class = %v, want %q", class, tt.wantClass)
+			}
+			if !Is(got, tt.err) {
+				t.Errorf("internal error lost its cause %v", tt.err)
+			}
+			if again := Boundary(got, tt.allowed...); again != got {
+				t.Errorf("second Boundary() = %v, want the internal error unchanged", again)
+			}
+		})
+	}
+}
+
+// TestStrictBoundaries tests that strict mode panics on unexpected errors
+func TestStrictBoundaries(t *testing.T) {
+	EnableStrictBoundaries()
+	defer DisableStrictBoundaries()
+
+	if err := Boundary(NewValidationError("Invalid email", "email")); err == nil {
+		t.Fatal("Boundary() = nil, want the validation error")
+	}
+
+	defer func() {
+		r := recover()
+		if err, ok := r.(error); !ok || HTTPStatus(err) != http.StatusInternalServerError {
+			t.Errorf("recovered %v, want the internal error", r)
+		}
+	}()
+	_ = Boundary(unwrappedDriverError{})
+	t.Error("Boundary() returned in strict mode")
+}
+
+// TestWithSeverity tests overriding the default severity of a typed error
+func TestWithSeverity(t *testing.T) {
+	err := NewValidationError("Invalid email", "email", WithSeverity(SeverityCritical))
+	if got := GetSeverity(Wrap(err, "signing up")); got != SeverityCritical {
+		t.Errorf("GetSeverity() = %v, want critical", got)
+	}
+	if got := GetSeverity(NewValidationError("Invalid email", "email")); got != SeverityWarning {
+		t.Errorf("GetSeverity() without override = %v, want warning", got)
+	}
+}
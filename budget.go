@@ -0,0 +1,148 @@
+package errors
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"sync/atomic"
+)
+
+// HeaderRetryBudget carries the number of retries still allowed for the
+// user request an outgoing call belongs to.
+const HeaderRetryBudget = "X-Retry-Budget"
+
+// RetryError reasons recorded when retries stop before MaxAttempts because
+// the retry budget reached zero.
+const (
+	// RetryReasonBudgetExhausted means a budget started by this service ran out.
+	RetryReasonBudgetExhausted = "budget_exhausted"
+
+	// RetryReasonBudgetExhaustedUpstream means a budget propagated by a
+	// caller ran out.
+	RetryReasonBudgetExhaustedUpstream = "budget_exhausted_upstream"
+)
+
+// RetryBudget is the number of retries left for one user request, shared by
+// every hop that request fans out to. When service A retries B which
+// retries C, each hop spending from the same budget caps the total number
+// of attempts instead of multiplying them. Safe for concurrent use.
+type RetryBudget struct {
+	remaining  atomic.Int64
+	propagated bool
+}
+
+// NewRetryBudget returns a budget allowing the given number of retries.
+func NewRetryBudget(retries int) *RetryBudget {
+	b := &RetryBudget{}
+	b.remaining.Store(int64(max(retries, 0)))
+	return b
+}
+
+// Remaining returns the number of retries left.
+func (b *RetryBudget) Remaining() int {
+	return int(b.remaining.Load())
+}
+
+// Propagated reports whether the budget was received from a caller (see
+// BudgetFromHeader) rather than started by this service.
+func (b *RetryBudget) Propagated() bool {
+	return b.propagated
+}
+
+// Take spends one retry. Returns false, spending nothing, when the budget
+// is exhausted.
+func (b *RetryBudget) Take() bool {
+	for {
+		n := b.remaining.Load()
+		if n <= 0 {
+			return false
+		}
+		if b.remaining.CompareAndSwap(n, n-1) {
+			return true
+		}
+	}
+}
+
+// Limit lowers the budget to at most remaining, such as after a downstream
+// service reports how much of the budget it left.
+func (b *RetryBudget) Limit(remaining int) {
+	for {
+		n := b.remaining.Load()
+		if int64(remaining) >= n {
+			return
+		}
+		if b.remaining.CompareAndSwap(n, int64(max(remaining, 0))) {
+			return
+		}
+	}
+}
+
+type retryBudgetKey struct{}
+
+// ContextWithRetryBudget returns a copy of ctx carrying budget, which
+// IsSafeToRetry and retrying transports spend from.
+//
+// Example:
+//
+//	ctx = errors.ContextWithRetryBudget(ctx, errors.NewRetryBudget(3))
+func ContextWithRetryBudget(ctx context.Context, budget *RetryBudget) context.Context {
+	return context.WithValue(ctx, retryBudgetKey{}, budget)
+}
+
+// RetryBudgetFromContext returns the budget stored by ContextWithRetryBudget.
+func RetryBudgetFromContext(ctx context.Context) (*RetryBudget, bool) {
+	budget, ok := ctx.Value(retryBudgetKey{}).(*RetryBudget)
+	return budget, ok && budget != nil
+}
+
+// WriteBudgetHeader records budget's remaining retries in h, for an
+// outgoing request or for a response reporting what is left.
+//
+// Example:
+//
+//	errors.WriteBudgetHeader(req.Header, budget)
+func WriteBudgetHeader(h http.Header, budget *RetryBudget) {
+	h.Set(HeaderRetryBudget, strconv.Itoa(budget.Remaining()))
+}
+
+// BudgetFromHeader returns the budget recorded in h by WriteBudgetHeader,
+// marked as propagated. Returns false if the header is missing or
+// malformed.
+//
+// Example:
+//
+//	if budget, ok := errors.BudgetFromHeader(r.Header); ok {
+//	    ctx = errors.ContextWithRetryBudget(ctx, budget)
+//	}
+func BudgetFromHeader(h http.Header) (*RetryBudget, bool) {
+	n, err := strconv.Atoi(h.Get(HeaderRetryBudget))
+	if err != nil || n < 0 {
+		return nil, false
+	}
+	budget := NewRetryBudget(n)
+	budget.propagated = true
+	return budget, true
+}
+
+// IsSafeToRetry reports whether err is retryable (see IsRetryable) and the
+// retry budget on ctx, if any, has retries left. It doesn't spend from the
+// budget; callers retrying call Take.
+//
+// Example:
+//
+//	for attempt := 1; ; attempt++ {
+//	    err := call(ctx)
+//	    if err == nil || !errors.IsSafeToRetry(ctx, err) {
+//	        return err
+//	    }
+//	    budget.Take()
+//	}
+func IsSafeToRetry(ctx context.Context, err error) bool {
+	if !IsRetryable(err) {
+		return false
+	}
+	if budget, ok := RetryBudgetFromContext(ctx); ok {
+		return budget.Remaining() > 0
+	}
+	return true
+}
@@ -0,0 +1,106 @@
+package errors
+
+import (
+	"context"
+	"net/http"
+	"testing"
+)
+
+// TestRetryBudget tests spending and lowering a retry budget
+func TestRetryBudget(t *testing.T) {
+	budget := NewRetryBudget(2)
+	if !budget.Take() || !budget.Take() {
+		t.Fatal("Take() should succeed while retries remain")
+	}
+	if budget.Take() {
+		t.Error("Take() should fail once the budget is exhausted")
+	}
+
+	budget = NewRetryBudget(5)
+	budget.Limit(7)
+	if got := budget.Remaining(); got != 5 {
+		t.Errorf("Limit() raised the budget to %d", got)
+	}
+	budget.Limit(1)
+	if got := budget.Remaining(); got != 1 {
+		t.Errorf("Remaining() = %d after Limit(1), want 1", got)
+	}
+}
+
+// TestBudgetHeader tests encoding and decoding the retry budget header
+func TestBudgetHeader(t *testing.T) {
+	tests := []struct {
+		name   string
+		header string
+		want   int
+		wantOK bool
+	}{
+		{name: "valid", header: "3", want: 3, wantOK: true},
+		{name: "zero", header: "0", want: 0, wantOK: true},
+		{name: "missing", header: ""},
+		{name: "negative", header: "-1"},
+		{name: "malformed", header: "lots"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := http.Header{}
+			if tt.header != "" {
+				h.Set(HeaderRetryBudget, tt.header)
+			}
+
+			budget, ok := BudgetFromHeader(h)
+			if ok != tt.wantOK {
+				t.Fatalf("BudgetFromHeader() ok = %v, want %v", ok, tt.wantOK)
+			}
+			if ok && (budget.Remaining() != tt.want || !budget.Propagated()) {
+				t.Errorf("got %d retries (propagated %v), want %d propagated", budget.Remaining(), budget.Propagated(), tt.want)
+			}
+		})
+	}
+
+	t.Run("round trip", func(t *testing.T) {
+		h := http.Header{}
+		WriteBudgetHeader(h, NewRetryBudget(4))
+		if budget, ok := BudgetFromHeader(h); !ok || budget.Remaining() != 4 {
+			t.Errorf("BudgetFromHeader() = %v, %v, want 4 retries", budget, ok)
+		}
+	})
+}
+
+// TestIsSafeToRetry tests that an exhausted budget stops retries of retryable errors
+func TestIsSafeToRetry(t *testing.T) {
+	retryable := NewHTTPError(503, "unavailable", nil)
+	exhausted := ContextWithRetryBudget(context.Background(), NewRetryBudget(0))
+
+	tests := []struct {
+		name string
+		ctx  context.Context
+		err  error
+		want bool
+	}{
+		{name: "no budget", ctx: context.Background(), err: retryable, want: true},
+		{name: "budget left", ctx: ContextWithRetryBudget(context.Background(), NewRetryBudget(1)), err: retryable, want: true},
+		{name: "budget exhausted", ctx: exhausted, err: retryable},
+		{name: "not retryable", ctx: context.Background(), err: NewValidationError("bad", "field")},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := IsSafeToRetry(tt.ctx, tt.err); got != tt.want {
+				t.Errorf("IsSafeToRetry() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	t.Run("reason survives the envelope", func(t *testing.T) {
+		err := NewRetryError(1, 3, retryable, nil, WithReason(RetryReasonBudgetExhaustedUpstream))
+		var decoded *RetryError
+		if !As(Decode(Encode(err)), &decoded) || decoded.Reason != RetryReasonBudgetExhaustedUpstream {
+			t.Errorf("Decode() = %v, want reason %q", decoded, RetryReasonBudgetExhaustedUpstream)
+		}
+		if want := "retry exhausted after 1/3 attempts (budget_exhausted_upstream): HTTP 503: unavailable"; err.Error() != want {
+			t.Errorf("Error() = %q, want %q", err.Error(), want)
+		}
+	})
+}
@@ -0,0 +1,215 @@
+package errors
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+)
+
+// maxBatchErrorItems caps how many failures BatchError.Error lists.
+const maxBatchErrorItems = 3
+
+// BulkResult is the outcome of a bulk operation where each item succeeds or
+// fails on its own, such as inserting a batch of rows. Items are identified
+// by caller-chosen IDs.
+//
+// Example:
+//
+//	var result errors.BulkResult
+//	for _, row := range rows {
+//	    if err := insert(ctx, row); err != nil {
+//	        result.Fail(row.ID, err)
+//	        continue
+//	    }
+//	    result.Succeed(row.ID)
+//	}
+//	errors.WriteBulkResult(w, result)
+type BulkResult struct {
+	Succeeded []string
+	Failed    map[string]error
+}
+
+// Succeed records id as succeeded.
+func (r *BulkResult) Succeed(id string) {
+	r.Succeeded = append(r.Succeeded, id)
+}
+
+// Fail records id as failed with err. A nil err records id as succeeded,
+// so Fail(row.ID, insert(ctx, row)) is safe.
+func (r *BulkResult) Fail(id string, err error) {
+	if err == nil {
+		r.Succeed(id)
+		return
+	}
+	if r.Failed == nil {
+		r.Failed = make(map[string]error)
+	}
+	r.Failed[id] = err
+}
+
+// FailedIDs returns the IDs of the failed items, sorted.
+func (r BulkResult) FailedIDs() []string {
+	ids := make([]string, 0, len(r.Failed))
+	for id := range r.Failed {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	return ids
+}
+
+// AsError returns nil when no item failed, otherwise a *BatchError holding
+// the failures.
+func (r BulkResult) AsError() error {
+	if len(r.Failed) == 0 {
+		return nil
+	}
+	failed := make(map[string]error, len(r.Failed))
+	for id, err := range r.Failed {
+		failed[id] = err
+	}
+	return &BatchError{Failed: failed, Total: len(r.Succeeded) + len(r.Failed)}
+}
+
+// AnyRetryable reports whether any failure is retryable (see IsRetryable),
+// in which case the client should resubmit the failed subset.
+func (r BulkResult) AnyRetryable() bool {
+	for _, err := range r.Failed {
+		if IsRetryable(err) {
+			return true
+		}
+	}
+	return false
+}
+
+// AllPermanent reports whether there are failures and none of them is
+// retryable, so resubmitting any of them would fail again.
+func (r BulkResult) AllPermanent() bool {
+	return len(r.Failed) > 0 && !r.AnyRetryable()
+}
+
+// bulkItem is one item in a BulkResult's JSON form.
+type bulkItem struct {
+	ID     string          `json:"id"`
+	Status int             `json:"status"`
+	Error  *ProblemDetails `json:"error,omitempty"`
+}
+
+// MarshalJSON renders the result as per-item statuses, succeeded items
+// first in the order recorded, then failed items sorted by ID with each
+// failure rendered as user-safe problem details (see ToProblemDetails):
+//
+//	{"succeeded":1,"failed":1,"items":[
+//	  {"id":"a","status":200},
+//	  {"id":"b","status":400,"error":{"type":"about:blank","title":"Bad Request",...}}]}
+func (r BulkResult) MarshalJSON() ([]byte, error) {
+	items := make([]bulkItem, 0, len(r.Succeeded)+len(r.Failed))
+	for _, id := range r.Succeeded {
+		items = append(items, bulkItem{ID: id, Status: http.StatusOK})
+	}
+	for _, id := range r.FailedIDs() {
+		problem := ToProblemDetails(r.Failed[id])
+		items = append(items, bulkItem{ID: id, Status: problem.Status, Error: problem})
+	}
+	return json.Marshal(struct {
+		Succeeded int        `json:"succeeded"`
+		Failed    int        `json:"failed"`
+		Items     []bulkItem `json:"items"`
+	}{len(r.Succeeded), len(r.Failed), items})
+}
+
+// Status returns the HTTP status for the whole result: 200 when nothing
+// failed, 207 when some items succeeded, and when every item failed the
+// status they share, or 400 if they are all client errors with different
+// statuses. Failures mixing client and server errors get 207 so the client
+// reads the per-item statuses.
+func (r BulkResult) Status() int {
+	if len(r.Failed) == 0 {
+		return http.StatusOK
+	}
+	if len(r.Succeeded) > 0 {
+		return http.StatusMultiStatus
+	}
+
+	shared, clientOnly := 0, true
+	for _, err := range r.Failed {
+		status := HTTPStatus(err)
+		if shared == 0 {
+			shared = status
+		} else if status != shared {
+			shared = -1
+		}
+		clientOnly = clientOnly && status < http.StatusInternalServerError
+	}
+	switch {
+	case shared > 0:
+		return shared
+	case clientOnly:
+		return http.StatusBadRequest
+	}
+	return http.StatusMultiStatus
+}
+
+// WriteBulkResult writes result to w as JSON (see BulkResult.MarshalJSON)
+// with the status from BulkResult.Status.
+//
+// Example:
+//
+//	result := svc.ImportRows(r.Context(), rows)
+//	errors.WriteBulkResult(w, result)
+func WriteBulkResult(w http.ResponseWriter, result BulkResult) {
+	body, err := json.Marshal(result)
+	if err != nil {
+		WriteProblem(w, NewEncodeError("encoding bulk result", "WriteBulkResult", "json", WithCause(err)))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(result.Status())
+	_, _ = w.Write(body)
+}
+
+// BatchError is the error returned by BulkResult.AsError. errors.Is and
+// errors.As see every failure. It is retryable when any failure is, so
+// retry loops resubmit the failed subset.
+type BatchError struct {
+	Failed map[string]error
+	Total  int
+}
+
+func (e *BatchError) Error() string {
+	if e == nil {
+		return "<nil BatchError>"
+	}
+	ids := BulkResult{Failed: e.Failed}.FailedIDs()
+	listed := make([]string, 0, maxBatchErrorItems+1)
+	for _, id := range ids[:min(len(ids), maxBatchErrorItems)] {
+		listed = append(listed, id+": "+e.Failed[id].Error())
+	}
+	if more := len(ids) - maxBatchErrorItems; more > 0 {
+		listed = append(listed, fmt.Sprintf("and %d more", more))
+	}
+	return fmt.Sprintf("%d of %d items failed: %s", len(ids), e.Total, strings.Join(listed, "; "))
+}
+
+// Unwrap returns the failures sorted by item ID.
+func (e *BatchError) Unwrap() []error {
+	if e == nil {
+		return nil
+	}
+	ids := BulkResult{Failed: e.Failed}.FailedIDs()
+	errs := make([]error, len(ids))
+	for i, id := range ids {
+		errs[i] = e.Failed[id]
+	}
+	return errs
+}
+
+// IsRetryable reports whether any failure is retryable.
+func (e *BatchError) IsRetryable() bool {
+	if e == nil {
+		return false
+	}
+	return BulkResult{Failed: e.Failed}.AnyRetryable()
+}
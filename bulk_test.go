@@ -0,0 +1,146 @@
+package errors
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestBulkResultStatus tests the overall status picked for bulk results
+func TestBulkResultStatus(t *testing.T) {
+	invalid := NewValidationError("Invalid email", "email")
+	conflict := NewHTTPError(http.StatusConflict, "Duplicate", nil)
+	unavailable := NewNetworkError("Connection reset", "Insert")
+
+	tests := []struct {
+		name      string
+		succeeded []string
+		failed    map[string]error
+		want      int
+	}{
+		{name: "nothing failed", succeeded: []string{"a", "b"}, want: http.StatusOK},
+		{name: "empty", want: http.StatusOK},
+		{name: "partial", succeeded: []string{"a"}, failed: map[string]error{"b": invalid}, want: http.StatusMultiStatus},
+		{name: "all failed alike", failed: map[string]error{"a": invalid, "b": invalid}, want: http.StatusBadRequest},
+		{name: "all failed with conflicts", failed: map[string]error{"a": conflict}, want: http.StatusConflict},
+		{name: "all failed with mixed client errors", failed: map[string]error{"a": invalid, "b": conflict}, want: http.StatusBadRequest},
+		{name: "all failed with server errors", failed: map[string]error{"a": invalid, "b": unavailable}, want: http.StatusMultiStatus},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := BulkResult{Succeeded: tt.succeeded, Failed: tt.failed}
+			if got := result.Status(); got != tt.want {
+				t.Errorf("Status() = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+// TestBulkResultClassification tests deciding whether to resubmit the failed subset
+func TestBulkResultClassification(t *testing.T) {
+	var result BulkResult
+	result.Succeed("a")
+	if result.AsError() != nil || result.AnyRetryable() || result.AllPermanent() {
+		t.Fatal("a result without failures should be neither retryable nor permanent")
+	}
+
+	result.Fail("c", NewValidationError("Invalid email", "email"))
+	if !result.AllPermanent() || result.AnyRetryable() {
+		t.Error("validation failures should be permanent")
+	}
+
+	result.Fail("b", NewRateLimitError("Slow down", "Insert", time.Second))
+	if result.AllPermanent() || !result.AnyRetryable() {
+		t.Error("a rate limited item should make the result retryable")
+	}
+
+	err := result.AsError()
+	var batchErr *BatchError
+	if !As(err, &batchErr) || batchErr.Total != 3 || len(batchErr.Failed) != 2 {
+		t.Fatalf("AsError() = %#v, want a BatchError with 2 of 3 failed", err)
+	}
+	if !IsRetryable(err) || !Is(err, ErrRateLimited) || !IsValidation(err) {
+		t.Error("BatchError should expose every failure")
+	}
+	if got := err.Error(); !strings.HasPrefix(got, "2 of 3 items failed: b: ") {
+		t.Errorf("Error() = %q", got)
+	}
+
+	result.Fail("d", NewValidationError("Invalid name", "name"))
+	result.Fail("e", NewValidationError("Invalid age", "age"))
+	if got := result.AsError().Error(); !strings.HasSuffix(got, "; and 1 more") {
+		t.Errorf("Error() = %q, want the list capped", got)
+	}
+}
+
+// TestBulkResultFailNil tests that failing an item with a nil error records it as succeeded
+func TestBulkResultFailNil(t *testing.T) {
+	var result BulkResult
+	result.Fail("a", nil)
+	result.Fail("b", NewValidationError("Invalid email", "email"))
+
+	if len(result.Succeeded) != 1 || result.Succeeded[0] != "a" || len(result.Failed) != 1 {
+		t.Fatalf("result = %+v, want a succeeded and b failed", result)
+	}
+	if got := result.AsError().Error(); !strings.HasPrefix(got, "1 of 2 items failed: b: ") {
+		t.Errorf("Error() = %q", got)
+	}
+	if _, err := json.Marshal(result); err != nil {
+		t.Errorf("MarshalJSON() error = %v", err)
+	}
+}
+
+// TestWriteBulkResult tests the per-item JSON written for bulk results
+func TestWriteBulkResult(t *testing.T) {
+	var result BulkResult
+	result.Succeed("row-1")
+	result.Fail("row-3", NewNetworkError("dial tcp 10.0.0.7:5432: connection refused", "Insert"))
+	result.Fail("row-2", NewValidationError("Invalid email", "email"))
+
+	rec := httptest.NewRecorder()
+	WriteBulkResult(rec, result)
+
+	if rec.Code != http.StatusMultiStatus {
+		t.Errorf("status = %d, want 207", rec.Code)
+	}
+
+	var body struct {
+		Succeeded int `json:"succeeded"`
+		Failed    int `json:"failed"`
+		Items     []struct {
+			ID     string         `json:"id"`
+			Status int            `json:"status"`
+			Error  map[string]any `json:"error"`
+		} `json:"items"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+		t.Fatal(err)
+	}
+	if body.Succeeded != 1 || body.Failed != 2 || len(body.Items) != 3 {
+		t.Fatalf("body = %s", rec.Body)
+	}
+
+	wantItems := []struct {
+		id     string
+		status int
+	}{{"row-1", 200}, {"row-2", 400}, {"row-3", 502}}
+	for i, want := range wantItems {
+		item := body.Items[i]
+		if item.ID != want.id || item.Status != want.status {
+			t.Errorf("items[%d] = %s %d, want %s %d", i, item.ID, item.Status, want.id, want.status)
+		}
+		if (item.Error == nil) != (want.status == 200) {
+			t.Errorf("items[%d].error = %v", i, item.Error)
+		}
+	}
+	if detail := body.Items[1].Error[ProblemDetail]; detail != "Invalid email" {
+		t.Errorf("client error detail = %v, want the message", detail)
+	}
+	if strings.Contains(rec.Body.String(), "10.0.0.7") {
+		t.Errorf("server error leaked internals: %s", rec.Body)
+	}
+}
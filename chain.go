@@ -0,0 +1,211 @@
+package errors
+
+import (
+	"fmt"
+	"reflect"
+)
+
+// maxCauseDepth caps how many nested causes Error() will render before
+// giving up with an ellipsis. Real chains are a handful of levels deep;
+// anything beyond this is almost certainly a bug in the caller.
+const maxCauseDepth = 32
+
+// maxChainNodes caps how many errors walkChain will visit in total,
+// including every branch of joined errors.
+const maxChainNodes = 256
+
+// truncatedCause is rendered in place of causes beyond maxCauseDepth.
+const truncatedCause = "…"
+
+// chainFormatter is implemented by the typed errors in this package so that
+// Error() can render a node's own message with a cause string computed by
+// the caller, rather than recursing into the cause's Error() directly.
+type chainFormatter interface {
+	formatWithCause(cause string) string
+	causeError() error
+}
+
+// typeName returns the type name of one of this package's typed errors,
+// such as "HTTPError".
+func typeName(err chainFormatter) string {
+	return reflect.TypeOf(err).Elem().Name()
+}
+
+// walkChain visits err and every error reachable from it through Unwrap()
+// error and Unwrap() []error, depth first. visit returns false to stop the
+// walk early. Pointer errors are visited at most once, so cyclic chains
+// terminate. Typed nils (see NotNil) are skipped, having no fields to read.
+//
+// It returns true when the walk was cut short by a cycle or by the depth and
+// node limits, which callers use to detect pathological chains.
+func walkChain(err error, visit func(err error, depth int) bool) (truncated bool) {
+	if err == nil {
+		return false
+	}
+
+	type frame struct {
+		err   error
+		depth int
+	}
+
+	seen := make(map[chainKey]struct{})
+	stack := []frame{{err: err}}
+	visited := 0
+
+	for len(stack) > 0 {
+		f := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+
+		if f.depth > maxCauseDepth || visited >= maxChainNodes {
+			truncated = true
+			continue
+		}
+		if isTypedNil(f.err) {
+			continue
+		}
+
+		if key, ok := identity(f.err); ok {
+			if _, dup := seen[key]; dup {
+				truncated = true
+				continue
+			}
+			seen[key] = struct{}{}
+		}
+
+		visited++
+		if !visit(f.err, f.depth) {
+			return truncated
+		}
+
+		switch u := f.err.(type) {
+		case interface{ Unwrap() []error }:
+			children := u.Unwrap()
+			// Push in reverse so the first branch is visited first.
+			for i := len(children) - 1; i >= 0; i-- {
+				if children[i] != nil {
+					stack = append(stack, frame{err: children[i], depth: f.depth + 1})
+				}
+			}
+		case interface{ Unwrap() error }:
+			if next := u.Unwrap(); next != nil {
+				stack = append(stack, frame{err: next, depth: f.depth + 1})
+			}
+		}
+	}
+
+	return truncated
+}
+
+// chainKey identifies a pointer error by type and address.
+type chainKey struct {
+	typ reflect.Type
+	ptr uintptr
+}
+
+// identity returns a key for pointer-shaped errors. Value errors cannot form
+// cycles on their own, so they are not tracked.
+func identity(err error) (chainKey, bool) {
+	v := reflect.ValueOf(err)
+	if v.Kind() != reflect.Pointer || v.IsNil() {
+		return chainKey{}, false
+	}
+	return chainKey{typ: v.Type(), ptr: v.Pointer()}, true
+}
+
+// isPathological reports whether err's chain contains a cycle or is deeper
+// than limit.
+func isPathological(err error, limit int) bool {
+	tooDeep := false
+	truncated := walkChain(err, func(_ error, depth int) bool {
+		if depth > limit {
+			tooDeep = true
+			return false
+		}
+		return true
+	})
+	return truncated || tooDeep
+}
+
+// formatCause renders cause for inclusion in a typed error's Error() output.
+// It returns "" for a nil cause.
+func formatCause(cause error) string {
+	return formatCauseDepth(cause, 1)
+}
+
+// formatCauses renders a typed error's primary cause followed by a count of
+// its additional causes, such as "disk full (+1 more cause)". When there is
+// no primary cause the first additional cause is rendered in its place.
+func formatCauses(primary error, additional []error) string {
+	return formatCausesDepth(primary, additional, 1)
+}
+
+func formatCausesDepth(primary error, additional []error, depth int) string {
+	causes := causeList(primary, additional)
+	if len(causes) == 0 {
+		return ""
+	}
+
+	rendered := formatCauseDepth(causes[0], depth)
+	switch more := len(causes) - 1; more {
+	case 0:
+		return rendered
+	case 1:
+		return rendered + " (+1 more cause)"
+	default:
+		return fmt.Sprintf("%s (+%d more causes)", rendered, more)
+	}
+}
+
+// causeList returns primary followed by the non-nil additional causes,
+// omitting primary when it is nil.
+func causeList(primary error, additional []error) []error {
+	var causes []error
+	if primary != nil {
+		causes = append(causes, primary)
+	}
+	for _, c := range additional {
+		if c != nil {
+			causes = append(causes, c)
+		}
+	}
+	return causes
+}
+
+// formatCauseDepth renders cause at the given nesting depth. Typed errors are
+// rendered through chainFormatter so the depth is tracked across levels;
+// foreign errors are rendered with Error() unless their chain is cyclic or
+// too deep, in which case they are skipped in favour of what they wrap.
+func formatCauseDepth(cause error, depth int) string {
+	for cause != nil {
+		if depth > maxCauseDepth {
+			return truncatedCause
+		}
+
+		if f, ok := cause.(chainFormatter); ok {
+			var additional []error
+			if field := additionalCausesField(f); field != nil {
+				additional = *field
+			}
+			return f.formatWithCause(formatCausesDepth(f.causeError(), additional, depth+1))
+		}
+
+		if !isPathological(cause, maxCauseDepth-depth) {
+			return cause.Error()
+		}
+
+		// The foreign wrapper would recurse without bound; its own prefix
+		// can't be separated from its cause, so render what it wraps.
+		next := Unwrap(cause)
+		if next == nil {
+			if multi, ok := cause.(interface{ Unwrap() []error }); ok && len(multi.Unwrap()) > 0 {
+				next = multi.Unwrap()[0]
+			}
+		}
+		if next == nil {
+			return cause.Error()
+		}
+		cause = next
+		depth++
+	}
+	return ""
+}
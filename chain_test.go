@@ -0,0 +1,111 @@
+package errors
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestErrorPathologicalChains tests that Error() terminates on cyclic and very deep chains
+func TestErrorPathologicalChains(t *testing.T) {
+	t.Run("self-referencing cycle", func(t *testing.T) {
+		outer := &ProcessingError{Message: "outer", Operation: "Process"}
+		inner := &ProcessingError{Message: "inner", Operation: "Step", Err: outer}
+		outer.Err = inner
+
+		msg := outer.Error()
+		if !strings.HasSuffix(msg, truncatedCause) {
+			t.Errorf("expected truncated message, got: %s", msg)
+		}
+		if n := strings.Count(msg, "failed"); n > maxCauseDepth+1 {
+			t.Errorf("rendered %d nested causes, want at most %d", n, maxCauseDepth+1)
+		}
+	})
+
+	t.Run("cycle through foreign wrapper", func(t *testing.T) {
+		procErr := &ProcessingError{Message: "outer", Operation: "Process"}
+		procErr.Err = Wrap(procErr, "retrying")
+
+		done := make(chan string, 1)
+		go func() { done <- procErr.Error() }()
+
+		select {
+		case msg := <-done:
+			if !strings.Contains(msg, truncatedCause) {
+				t.Errorf("expected truncated message, got: %s", msg)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatal("Error() did not return")
+		}
+	})
+
+	t.Run("deep chain is capped", func(t *testing.T) {
+		var err error = fmt.Errorf("root cause")
+		for i := 0; i < 100; i++ {
+			err = NewProcessingError(fmt.Sprintf("level %d", i), "Process", WithCause(err))
+		}
+
+		msg := err.Error()
+		if !strings.HasSuffix(msg, truncatedCause) {
+			t.Errorf("expected truncated message, got suffix: %s", msg[len(msg)-40:])
+		}
+		if strings.Contains(msg, "root cause") {
+			t.Error("root cause beyond the depth cap should not be rendered")
+		}
+	})
+
+	t.Run("normal chain is unchanged", func(t *testing.T) {
+		cause := fmt.Errorf("connection refused")
+		err := NewHTTPError(503, "Service Unavailable", NewNetworkError("dial failed", "Connect", WithCause(cause)))
+
+		want := "HTTP 503: Service Unavailable: network error in Connect (transient): dial failed: connection refused"
+		if got := err.Error(); got != want {
+			t.Errorf("got %q, want %q", got, want)
+		}
+	})
+}
+
+// TestWalkChain tests the shared chain walker
+func TestWalkChain(t *testing.T) {
+	t.Run("visits joined branches in order", func(t *testing.T) {
+		a := fmt.Errorf("a")
+		b := fmt.Errorf("b")
+		joined := fmt.Errorf("outer: %w", fmt.Errorf("%w; %w", a, b))
+
+		var seen []string
+		truncated := walkChain(joined, func(err error, _ int) bool {
+			seen = append(seen, err.Error())
+			return true
+		})
+
+		if truncated {
+			t.Error("finite chain should not be truncated")
+		}
+		if len(seen) != 4 || seen[2] != "a" || seen[3] != "b" {
+			t.Errorf("unexpected visit order: %v", seen)
+		}
+	})
+
+	t.Run("reports cycles", func(t *testing.T) {
+		procErr := &ProcessingError{Message: "loop", Operation: "Process"}
+		procErr.Err = procErr
+
+		if !walkChain(procErr, func(error, int) bool { return true }) {
+			t.Error("cyclic chain should be reported as truncated")
+		}
+	})
+
+	t.Run("stops when visit returns false", func(t *testing.T) {
+		err := Wrap(Wrap(fmt.Errorf("root"), "mid"), "top")
+
+		visits := 0
+		walkChain(err, func(error, int) bool {
+			visits++
+			return false
+		})
+		if visits != 1 {
+			t.Errorf("got %d visits, want 1", visits)
+		}
+	})
+}
@@ -0,0 +1,335 @@
+package errors
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// ChangeKind says how a node differs between the two chains of a ChainDiff.
+type ChangeKind string
+
+// Change kinds reported by DiffChains.
+const (
+	NodeUnchanged ChangeKind = "unchanged"
+	NodeChanged   ChangeKind = "changed"
+	NodeAdded     ChangeKind = "added"   // only in b
+	NodeRemoved   ChangeKind = "removed" // only in a
+)
+
+// ChainDiff is the difference between two error chains (see DiffChains).
+// Nodes lists every node of both chains in chain order, with nodes only in
+// a before those only in b at the same position.
+type ChainDiff struct {
+	Nodes []NodeDiff
+}
+
+// NodeDiff is one node of a ChainDiff.
+type NodeDiff struct {
+	Type    string // envelope type, such as "HTTPError"
+	Message string // b's message, or a's for a removed node
+	Depth   int    // nesting in the chain: causes and joined branches are one deeper
+	Change  ChangeKind
+	Fields  []FieldDiff // for changed nodes, the fields that differ
+}
+
+// FieldDiff is one field that differs between two aligned nodes. Fields
+// are named by their envelope member, such as "status_code". InA and InB
+// report whether the field was set on each side; a field set on one side
+// only has the zero value on the other.
+type FieldDiff struct {
+	Field string
+	A, B  any
+	InA   bool
+	InB   bool
+}
+
+// Equal reports whether the two chains had no differences.
+func (d ChainDiff) Equal() bool {
+	for _, node := range d.Nodes {
+		if node.Change != NodeUnchanged {
+			return false
+		}
+	}
+	return true
+}
+
+// String renders the diff one node per line, indented by depth and marked
+// " " unchanged, "~" changed, "-" only in a and "+" only in b, for test
+// failure output and incident notes.
+//
+// Example:
+//
+//	  Error "charging card"
+//	~ HTTPError: status_code 502 → 504; retry_after_ms only in b (30000)
+//	- NetworkError "connection reset"
+//	+ TimeoutError "upstream timed out"
+func (d ChainDiff) String() string {
+	var b strings.Builder
+	for _, node := range d.Nodes {
+		marker := " "
+		switch node.Change {
+		case NodeChanged:
+			marker = "~"
+		case NodeAdded:
+			marker = "+"
+		case NodeRemoved:
+			marker = "-"
+		}
+		b.WriteString(marker)
+		b.WriteString(" ")
+		b.WriteString(strings.Repeat("  ", node.Depth))
+		b.WriteString(node.Type)
+		if node.Change == NodeChanged {
+			fields := make([]string, len(node.Fields))
+			for i, f := range node.Fields {
+				fields[i] = f.String()
+			}
+			b.WriteString(": " + strings.Join(fields, "; "))
+		} else {
+			fmt.Fprintf(&b, " %q", node.Message)
+		}
+		b.WriteString("\n")
+	}
+	return b.String()
+}
+
+// String renders the field as "name a → b", or "name only in a (value)"
+// when it was set on one side.
+func (f FieldDiff) String() string {
+	switch {
+	case f.InA && !f.InB:
+		return fmt.Sprintf("%s only in a (%s)", f.Field, diffValue(f.A))
+	case f.InB && !f.InA:
+		return fmt.Sprintf("%s only in b (%s)", f.Field, diffValue(f.B))
+	}
+	return fmt.Sprintf("%s %s → %s", f.Field, diffValue(f.A), diffValue(f.B))
+}
+
+// DiffChains compares two errors node by node, such as two failures of
+// the "same" flaky operation. Both are encoded (see Encode) and their
+// chains aligned by node type, so a wrapper present in only one of them
+// shows up as one added or removed node instead of shifting every node
+// after it. Aligned nodes are compared field by field; additional causes
+// and joined branches are aligned and compared the same way.
+//
+// Example:
+//
+//	diff := errors.DiffChains(first, second)
+//	if !diff.Equal() {
+//	    t.Errorf("failures differ:\n%s", diff)
+//	}
+func DiffChains(a, b error) ChainDiff {
+	return DiffEnvelopes(Encode(a), Encode(b))
+}
+
+// DiffEnvelopes compares two encoded errors like DiffChains, for failures
+// recorded as envelopes (see Encode and StoredError).
+func DiffEnvelopes(a, b *Envelope) ChainDiff {
+	var d ChainDiff
+	d.diffSequence(envelopeSequence(a), envelopeSequence(b), 0)
+	return d
+}
+
+// envelopeSequence flattens env's primary chain, following Cause.
+func envelopeSequence(env *Envelope) []*Envelope {
+	var seq []*Envelope
+	for ; env != nil; env = env.Cause {
+		seq = append(seq, env)
+	}
+	return seq
+}
+
+// diffSequence aligns two chains by node type and records their nodes,
+// starting at depth.
+func (d *ChainDiff) diffSequence(a, b []*Envelope, depth int) {
+	pairs := alignEnvelopes(a, b)
+	i, j := 0, 0
+	for _, p := range append(pairs, [2]int{len(a), len(b)}) {
+		for ; i < p[0]; i++ {
+			d.addSubtree(a[i], depth+i, NodeRemoved)
+		}
+		for ; j < p[1]; j++ {
+			d.addSubtree(b[j], depth+j, NodeAdded)
+		}
+		if i == len(a) && j == len(b) {
+			break
+		}
+		d.diffNode(a[i], b[j], depth+max(i, j))
+		i, j = i+1, j+1
+	}
+}
+
+// diffNode records two aligned nodes and compares their branches.
+func (d *ChainDiff) diffNode(a, b *Envelope, depth int) {
+	node := NodeDiff{Type: b.Type, Message: ownMessage(b), Depth: depth, Change: NodeUnchanged}
+	if node.Fields = envelopeFieldDiffs(a, b); len(node.Fields) > 0 {
+		node.Change = NodeChanged
+	}
+	d.Nodes = append(d.Nodes, node)
+
+	pairs := alignEnvelopes(a.Causes, b.Causes)
+	i, j := 0, 0
+	for _, p := range append(pairs, [2]int{len(a.Causes), len(b.Causes)}) {
+		for ; i < p[0]; i++ {
+			d.diffSequence(envelopeSequence(a.Causes[i]), nil, depth+1)
+		}
+		for ; j < p[1]; j++ {
+			d.diffSequence(nil, envelopeSequence(b.Causes[j]), depth+1)
+		}
+		if i == len(a.Causes) && j == len(b.Causes) {
+			break
+		}
+		d.diffSequence(envelopeSequence(a.Causes[i]), envelopeSequence(b.Causes[j]), depth+1)
+		i, j = i+1, j+1
+	}
+}
+
+// addSubtree records env and its branches as present on one side only.
+func (d *ChainDiff) addSubtree(env *Envelope, depth int, change ChangeKind) {
+	d.Nodes = append(d.Nodes, NodeDiff{Type: env.Type, Message: ownMessage(env), Depth: depth, Change: change})
+	for _, branch := range env.Causes {
+		for k, node := range envelopeSequence(branch) {
+			d.addSubtree(node, depth+1+k, change)
+		}
+	}
+}
+
+// alignEnvelopes returns the index pairs of a longest common subsequence
+// of a and b by alignment key, in order.
+func alignEnvelopes(a, b []*Envelope) [][2]int {
+	// lcs[i][j] is the length of the longest common subsequence of a[i:]
+	// and b[j:].
+	lcs := make([][]int, len(a)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(b)+1)
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if alignKey(a[i]) == alignKey(b[j]) {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else {
+				lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+			}
+		}
+	}
+
+	var pairs [][2]int
+	for i, j := 0, 0; i < len(a) && j < len(b); {
+		switch {
+		case alignKey(a[i]) == alignKey(b[j]):
+			pairs = append(pairs, [2]int{i, j})
+			i, j = i+1, j+1
+		case lcs[i+1][j] >= lcs[i][j+1]:
+			i++
+		default:
+			j++
+		}
+	}
+	return pairs
+}
+
+// ownMessage returns env's message without the text of its causes. A
+// foreign wrapper's message is its whole Error() text, and a join's is its
+// branches' texts, so without this a change anywhere below would show up
+// as a change to every node above.
+func ownMessage(env *Envelope) string {
+	if env.Type != envelopeForeign {
+		return env.Message
+	}
+	if env.Cause != nil {
+		if own, ok := strings.CutSuffix(env.Message, ": "+Decode(env.Cause).Error()); ok {
+			return own
+		}
+		return env.Message
+	}
+	if len(env.Causes) > 0 {
+		branches := make([]string, len(env.Causes))
+		for i, branch := range env.Causes {
+			branches[i] = Decode(branch).Error()
+		}
+		if env.Message == strings.Join(branches, "\n") {
+			return ""
+		}
+	}
+	return env.Message
+}
+
+// alignKey is what two nodes must share to be aligned: their type, and
+// for sentinels, which sentinel.
+func alignKey(env *Envelope) string {
+	if env.Type == envelopeSentinel {
+		return env.Type + ":" + env.Message
+	}
+	return env.Type
+}
+
+// envelopeFieldDiffs compares the members of two aligned nodes, other than
+// their causes and version.
+func envelopeFieldDiffs(a, b *Envelope) []FieldDiff {
+	var diffs []FieldDiff
+	va, vb := reflect.ValueOf(a).Elem(), reflect.ValueOf(b).Elem()
+	t := va.Type()
+	for i := range t.NumField() {
+		f := t.Field(i)
+		switch f.Name {
+		case "Version", "Cause", "Causes":
+			continue
+		}
+		name, opts, _ := strings.Cut(f.Tag.Get("json"), ",")
+		if name == "" || name == "-" {
+			name = f.Name
+		}
+		fa, fb := diffField(va.Field(i)), diffField(vb.Field(i))
+		if f.Name == "Message" {
+			fa, fb = ownMessage(a), ownMessage(b)
+		}
+		if reflect.DeepEqual(fa, fb) {
+			continue
+		}
+		optional := strings.Contains(opts, "omitempty") || name == f.Name
+		diffs = append(diffs, FieldDiff{
+			Field: name,
+			A:     fa,
+			B:     fb,
+			InA:   !optional || !va.Field(i).IsZero(),
+			InB:   !optional || !vb.Field(i).IsZero(),
+		})
+	}
+	return diffs
+}
+
+// diffField returns a member's value for comparison: times by value,
+// nested envelopes (AllErrors) by their messages, and unset members as
+// nil.
+func diffField(v reflect.Value) any {
+	if v.IsZero() {
+		if v.Kind() == reflect.Bool {
+			return false
+		}
+		return nil
+	}
+	switch x := v.Interface().(type) {
+	case []*Envelope:
+		messages := make([]string, len(x))
+		for i, env := range x {
+			messages[i] = env.Message
+		}
+		return messages
+	}
+	if v.Kind() == reflect.Pointer {
+		return v.Elem().Interface()
+	}
+	return v.Interface()
+}
+
+// diffValue renders a field value for ChainDiff.String.
+func diffValue(v any) string {
+	switch x := v.(type) {
+	case nil:
+		return "none"
+	case string:
+		return fmt.Sprintf("%q", x)
+	}
+	return fmt.Sprint(v)
+}
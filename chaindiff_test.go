@@ -0,0 +1,121 @@
+package errors
+
+import (
+	"encoding/json"
+	stderrors "errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestDiffChains tests alignment by type and the field differences reported
+func TestDiffChains(t *testing.T) {
+	badGateway := NewHTTPError(502, "Bad Gateway", NewNetworkError("connection reset", "Dial"), WithOperation("Charge"))
+	gatewayTimeout := NewHTTPError(504, "Gateway Timeout", NewNetworkError("connection reset", "Dial"), WithOperation("Charge"))
+
+	tests := []struct {
+		name    string
+		a, b    error
+		changes []ChangeKind
+		want    []string // lines of String() expected to be present
+	}{
+		{
+			name:    "identical",
+			a:       Wrap(badGateway, "charging card"),
+			b:       Wrap(badGateway, "charging card"),
+			changes: []ChangeKind{NodeUnchanged, NodeUnchanged, NodeUnchanged},
+		},
+		{
+			name:    "changed status",
+			a:       Wrap(badGateway, "charging card"),
+			b:       Wrap(gatewayTimeout, "charging card"),
+			changes: []ChangeKind{NodeUnchanged, NodeChanged, NodeUnchanged},
+			want:    []string{`~   HTTPError: message "Bad Gateway" → "Gateway Timeout"; status_code 502 → 504`},
+		},
+		{
+			name:    "field only in b",
+			a:       NewTimeoutError("too slow", "Quote", time.Second),
+			b:       NewTimeoutError("too slow", "Quote", time.Second, WithCode("QUOTE_SLOW")),
+			changes: []ChangeKind{NodeChanged},
+			want:    []string{`~ TimeoutError: code only in b ("QUOTE_SLOW")`},
+		},
+		{
+			name:    "extra wrapper",
+			a:       Wrap(badGateway, "charging card"),
+			b:       Wrap(NewRetryableError("retrying", "Charge", 0, WithCause(badGateway)), "charging card"),
+			changes: []ChangeKind{NodeUnchanged, NodeAdded, NodeUnchanged, NodeUnchanged},
+			want:    []string{`+   RetryableError "retrying"`},
+		},
+		{
+			name:    "different leaf",
+			a:       Wrap(NewNetworkError("connection reset", "Dial"), "charging card"),
+			b:       Wrap(NewTimeoutError("upstream timed out", "Dial", time.Second), "charging card"),
+			changes: []ChangeKind{NodeUnchanged, NodeRemoved, NodeAdded},
+			want:    []string{`-   NetworkError "connection reset"`, `+   TimeoutError "upstream timed out"`},
+		},
+		{
+			name:    "joined branches",
+			a:       stderrors.Join(ErrConnectionError, NewValidationError("bad id", "id")),
+			b:       stderrors.Join(ErrConnectionError, NewValidationError("bad id", "order_id"), ErrRateLimited),
+			changes: []ChangeKind{NodeUnchanged, NodeUnchanged, NodeChanged, NodeAdded},
+			want:    []string{`~   ValidationError: field "id" → "order_id"`, `+   Sentinel "rate limited"`},
+		},
+		{
+			name:    "nil side",
+			a:       nil,
+			b:       ErrRateLimited,
+			changes: []ChangeKind{NodeAdded},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			diff := DiffChains(tt.a, tt.b)
+			var changes []ChangeKind
+			for _, node := range diff.Nodes {
+				changes = append(changes, node.Change)
+			}
+			if strings.Join(kindStrings(changes), ",") != strings.Join(kindStrings(tt.changes), ",") {
+				t.Errorf("changes = %v, want %v\n%s", changes, tt.changes, diff)
+			}
+			if diff.Equal() != (tt.name == "identical") {
+				t.Errorf("Equal() = %v\n%s", diff.Equal(), diff)
+			}
+			for _, line := range tt.want {
+				if !strings.Contains(diff.String(), line+"\n") {
+					t.Errorf("String() =\n%s\nwant a line %q", diff, line)
+				}
+			}
+		})
+	}
+}
+
+// TestDiffEnvelopes tests diffing envelopes recorded as JSON
+func TestDiffEnvelopes(t *testing.T) {
+	record := func(err error) *Envelope {
+		data, _ := json.Marshal(Encode(err))
+		var env Envelope
+		if jsonErr := json.Unmarshal(data, &env); jsonErr != nil {
+			t.Fatal(jsonErr)
+		}
+		return &env
+	}
+	a := record(NewRateLimitError("slow down", "Search", 0))
+	b := record(NewRateLimitError("slow down", "Search", 30*time.Second))
+
+	diff := DiffEnvelopes(a, b)
+	if len(diff.Nodes) != 1 || len(diff.Nodes[0].Fields) != 1 {
+		t.Fatalf("DiffEnvelopes() =\n%s\nwant one changed field", diff)
+	}
+	if got := diff.Nodes[0].Fields[0]; got.Field != WireRetryAfterMS || got.InA || !got.InB {
+		t.Errorf("field = %+v, want retry_after_ms only in b", got)
+	}
+}
+
+func kindStrings(kinds []ChangeKind) []string {
+	s := make([]string, len(kinds))
+	for i, k := range kinds {
+		s[i] = string(k)
+	}
+	return s
+}
@@ -0,0 +1,199 @@
+package errors
+
+import (
+	"container/list"
+	"sync"
+	"time"
+)
+
+// maxCircuitKeys bounds how many operations ObserveCircuitError tracks the
+// state of.
+const maxCircuitKeys = 10000
+
+// CircuitState is a circuit breaker state as reported in
+// CircuitBreakerError.State.
+type CircuitState string
+
+// Circuit breaker states.
+const (
+	CircuitClosed   CircuitState = "closed"
+	CircuitOpen     CircuitState = "open"
+	CircuitHalfOpen CircuitState = "half-open"
+)
+
+// CircuitObserver is notified of circuit breaker state transitions.
+// Observers run synchronously on the caller's goroutine, so they must be
+// fast and must not block.
+type CircuitObserver func(operation, component string, from, to CircuitState, counts CircuitCounts)
+
+var (
+	circuitMu        sync.RWMutex
+	circuitObservers []CircuitObserver
+	lastCircuitState = make(map[circuitKey]*list.Element)
+	circuitOrder     = list.New() // front = most recently seen
+)
+
+type circuitKey struct {
+	operation string
+	component string
+}
+
+type circuitEntry struct {
+	key   circuitKey
+	state CircuitState
+}
+
+// RegisterCircuitObserver adds an observer for circuit state transitions.
+//
+// Example (Prometheus):
+//
+//	transitions := prometheus.NewCounterVec(prometheus.CounterOpts{
+//	    Name: "circuit_breaker_transitions_total",
+//	}, []string{"operation", "component", "from", "to"})
+//
+//	errors.RegisterCircuitObserver(func(op, component string, from, to errors.CircuitState, _ errors.CircuitCounts) {
+//	    transitions.WithLabelValues(op, component, string(from), string(to)).Inc()
+//	})
+func RegisterCircuitObserver(fn CircuitObserver) {
+	circuitMu.Lock()
+	defer circuitMu.Unlock()
+	circuitObservers = append(circuitObservers, fn)
+}
+
+// ResetCircuitObservers removes all registered observers and forgets the
+// states tracked by ObserveCircuitError. Intended for tests.
+func ResetCircuitObservers() {
+	circuitMu.Lock()
+	defer circuitMu.Unlock()
+	circuitObservers = nil
+	lastCircuitState = make(map[circuitKey]*list.Element)
+	circuitOrder = list.New()
+}
+
+// touchCircuit returns the tracked state for key, CircuitClosed for a new
+// key, and marks it most recently seen. The least recently seen keys are
+// forgotten past maxCircuitKeys. Must be called with circuitMu held.
+func touchCircuit(key circuitKey) *circuitEntry {
+	if elem, ok := lastCircuitState[key]; ok {
+		circuitOrder.MoveToFront(elem)
+		return elem.Value.(*circuitEntry)
+	}
+
+	entry := &circuitEntry{key: key, state: CircuitClosed}
+	lastCircuitState[key] = circuitOrder.PushFront(entry)
+	for len(lastCircuitState) > maxCircuitKeys {
+		oldest := circuitOrder.Back()
+		circuitOrder.Remove(oldest)
+		delete(lastCircuitState, oldest.Value.(*circuitEntry).key)
+	}
+	return entry
+}
+
+// NotifyCircuitTransition reports a state transition to every registered
+// observer. Circuit breaker implementations call this from their
+// state-change hook. Calls where from equals to are ignored.
+//
+// Example:
+//
+//	gobreaker.Settings{
+//	    OnStateChange: func(name string, from, to gobreaker.State) {
+//	        errors.NotifyCircuitTransition(name, "payments",
+//	            errors.CircuitState(from.String()), errors.CircuitState(to.String()),
+//	            errors.CircuitCounts{})
+//	    },
+//	}
+func NotifyCircuitTransition(operation, component string, from, to CircuitState, counts CircuitCounts) {
+	if from == to {
+		return
+	}
+
+	circuitMu.Lock()
+	touchCircuit(circuitKey{operation: operation, component: component}).state = to
+	observers := circuitObservers
+	circuitMu.Unlock()
+
+	for _, fn := range observers {
+		fn(operation, component, from, to, counts)
+	}
+}
+
+// ObserveCircuitError derives transitions from the CircuitBreakerErrors an
+// application sees. When err contains a CircuitBreakerError whose state
+// differs from the last state seen for the same operation and component,
+// the transition is reported to the observers. The first error seen for an
+// operation is reported as a transition from CircuitClosed. Only the
+// 10000 most recently seen operations are remembered; one forgotten starts
+// from CircuitClosed again. Returns true if a transition was reported.
+func ObserveCircuitError(err error) bool {
+	var cbErr *CircuitBreakerError
+	if !As(err, &cbErr) || cbErr.State == "" {
+		return false
+	}
+
+	key := circuitKey{operation: cbErr.Operation, component: cbErr.Component}
+	to := CircuitState(cbErr.State)
+
+	circuitMu.Lock()
+	entry := touchCircuit(key)
+	from := entry.state
+	if from == to {
+		circuitMu.Unlock()
+		return false
+	}
+	entry.state = to
+	observers := circuitObservers
+	circuitMu.Unlock()
+
+	for _, fn := range observers {
+		fn(cbErr.Operation, cbErr.Component, from, to, cbErr.Counts)
+	}
+	return true
+}
+
+// CircuitTransition is a state transition recorded by CircuitRecorder.
+type CircuitTransition struct {
+	Operation string
+	Component string
+	From      CircuitState
+	To        CircuitState
+	Counts    CircuitCounts
+	At        time.Time
+}
+
+// CircuitRecorder is an in-memory CircuitObserver that keeps every
+// transition it sees, for tests and debugging endpoints. Safe for
+// concurrent use.
+//
+// Example:
+//
+//	recorder := &errors.CircuitRecorder{}
+//	errors.RegisterCircuitObserver(recorder.Observe)
+//	...
+//	for _, t := range recorder.Snapshot() {
+//	    fmt.Printf("%s: %s -> %s\n", t.Operation, t.From, t.To)
+//	}
+type CircuitRecorder struct {
+	mu          sync.Mutex
+	transitions []CircuitTransition
+}
+
+// Observe records a transition. Its signature matches CircuitObserver.
+func (r *CircuitRecorder) Observe(operation, component string, from, to CircuitState, counts CircuitCounts) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.transitions = append(r.transitions, CircuitTransition{
+		Operation: operation,
+		Component: component,
+		From:      from,
+		To:        to,
+		Counts:    counts,
+		At:        now(),
+	})
+}
+
+// Snapshot returns the recorded transitions in order.
+func (r *CircuitRecorder) Snapshot() []CircuitTransition {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]CircuitTransition(nil), r.transitions...)
+}
@@ -0,0 +1,130 @@
+package errors
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+)
+
+// TestCircuitObservers tests reporting of circuit breaker state transitions
+func TestCircuitObservers(t *testing.T) {
+	t.Run("explicit notification", func(t *testing.T) {
+		defer ResetCircuitObservers()
+		recorder := &CircuitRecorder{}
+		RegisterCircuitObserver(recorder.Observe)
+
+		counts := CircuitCounts{ConsecutiveFailures: 5}
+		NotifyCircuitTransition("Charge", "payments", CircuitClosed, CircuitOpen, counts)
+		NotifyCircuitTransition("Charge", "payments", CircuitOpen, CircuitOpen, counts)
+
+		got := recorder.Snapshot()
+		if len(got) != 1 {
+			t.Fatalf("got %d transitions, want 1: %+v", len(got), got)
+		}
+		if got[0].Operation != "Charge" || got[0].Component != "payments" ||
+			got[0].From != CircuitClosed || got[0].To != CircuitOpen || got[0].Counts != counts {
+			t.Errorf("unexpected transition: %+v", got[0])
+		}
+		if got[0].At.IsZero() {
+			t.Error("transition should be timestamped")
+		}
+	})
+
+	t.Run("derived from successive errors", func(t *testing.T) {
+		defer ResetCircuitObservers()
+		recorder := &CircuitRecorder{}
+		RegisterCircuitObserver(recorder.Observe)
+
+		sequence := []struct {
+			op    string
+			state string
+			want  bool
+		}{
+			{op: "Charge", state: "open", want: true},
+			{op: "Charge", state: "open", want: false},
+			{op: "Refund", state: "open", want: true},
+			{op: "Charge", state: "half-open", want: true},
+			{op: "Charge", state: "open", want: true},
+		}
+		for i, step := range sequence {
+			err := Wrap(NewCircuitBreakerError("breaker tripped", step.op, step.state, WithComponent("payments")), "calling payments")
+			if got := ObserveCircuitError(err); got != step.want {
+				t.Errorf("step %d: ObserveCircuitError() = %v, want %v", i, got, step.want)
+			}
+		}
+
+		want := []CircuitTransition{
+			{Operation: "Charge", From: CircuitClosed, To: CircuitOpen},
+			{Operation: "Refund", From: CircuitClosed, To: CircuitOpen},
+			{Operation: "Charge", From: CircuitOpen, To: CircuitHalfOpen},
+			{Operation: "Charge", From: CircuitHalfOpen, To: CircuitOpen},
+		}
+		got := recorder.Snapshot()
+		if len(got) != len(want) {
+			t.Fatalf("got %d transitions, want %d: %+v", len(got), len(want), got)
+		}
+		for i := range want {
+			if got[i].Operation != want[i].Operation || got[i].From != want[i].From || got[i].To != want[i].To {
+				t.Errorf("transition %d = %+v, want %+v", i, got[i], want[i])
+			}
+		}
+	})
+
+	t.Run("notification updates derived state", func(t *testing.T) {
+		defer ResetCircuitObservers()
+		recorder := &CircuitRecorder{}
+		RegisterCircuitObserver(recorder.Observe)
+
+		NotifyCircuitTransition("Charge", "", CircuitClosed, CircuitOpen, CircuitCounts{})
+		if ObserveCircuitError(NewCircuitBreakerError("open", "Charge", "open")) {
+			t.Error("state already reported by the breaker should not be reported again")
+		}
+	})
+
+	t.Run("forgets the least recently seen operations", func(t *testing.T) {
+		defer ResetCircuitObservers()
+
+		ObserveCircuitError(NewCircuitBreakerError("open", "Charge", "open"))
+		for i := range maxCircuitKeys {
+			ObserveCircuitError(NewCircuitBreakerError("open", fmt.Sprintf("op-%d", i), "open"))
+		}
+
+		if n := len(lastCircuitState); n != maxCircuitKeys {
+			t.Errorf("tracking %d operations, want %d", n, maxCircuitKeys)
+		}
+		if !ObserveCircuitError(NewCircuitBreakerError("open", "Charge", "open")) {
+			t.Error("an evicted operation should start from closed again")
+		}
+		if ObserveCircuitError(NewCircuitBreakerError("open", fmt.Sprintf("op-%d", maxCircuitKeys-1), "open")) {
+			t.Error("a recently seen operation should keep its state")
+		}
+	})
+
+	t.Run("ignores other errors", func(t *testing.T) {
+		defer ResetCircuitObservers()
+		if ObserveCircuitError(NewNetworkError("dial failed", "Connect")) || ObserveCircuitError(nil) {
+			t.Error("only CircuitBreakerErrors should produce transitions")
+		}
+	})
+
+	t.Run("concurrent observation reports each transition once", func(t *testing.T) {
+		defer ResetCircuitObservers()
+		recorder := &CircuitRecorder{}
+		RegisterCircuitObserver(recorder.Observe)
+
+		err := NewCircuitBreakerError("open", "Charge", "open")
+		var wg sync.WaitGroup
+		for range 20 {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				ObserveCircuitError(err)
+			}()
+		}
+		wg.Wait()
+
+		if n := len(recorder.Snapshot()); n != 1 {
+			t.Errorf("got %d transitions, want 1", n)
+		}
+	})
+}
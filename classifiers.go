@@ -0,0 +1,81 @@
+package errors
+
+import (
+	"sync"
+	"sync/atomic"
+)
+
+// RetryableClassifier decides whether err is retryable, for error types an
+// application can't give an IsRetryable method, such as those of a
+// vendored SDK. It reports matched=false for errors it doesn't recognize,
+// leaving them to the next classifier.
+//
+// IsRetryable is called in tight retry loops, so a classifier must be fast:
+// no I/O, no locks held for long and no allocation on the common path.
+type RetryableClassifier func(err error) (retryable, matched bool)
+
+var (
+	// classifiers is read on every IsRetryable call that reaches them, so
+	// readers load it without locking; writers replace it under
+	// classifierMu.
+	classifiers  atomic.Pointer[[]RetryableClassifier]
+	classifierMu sync.Mutex
+)
+
+// RegisterRetryableClassifier adds a classifier consulted by IsRetryable
+// after its built-in rules and before the message fallback (see
+// SetStringFallbacks). Classifiers run in registration order until one
+// matches; a match that isn't retryable also skips the fallback. It is
+// safe to call concurrently with IsRetryable.
+//
+// Example:
+//
+//	errors.RegisterRetryableClassifier(func(err error) (bool, bool) {
+//	    var apiErr *vendorsdk.APIError
+//	    if !errors.As(err, &apiErr) {
+//	        return false, false
+//	    }
+//	    return apiErr.Kind == vendorsdk.KindThrottled, true
+//	})
+func RegisterRetryableClassifier(classifier RetryableClassifier) {
+	classifierMu.Lock()
+	defer classifierMu.Unlock()
+	var next []RetryableClassifier
+	if current := classifiers.Load(); current != nil {
+		next = append(next, *current...)
+	}
+	next = append(next, classifier)
+	classifiers.Store(&next)
+}
+
+// UnregisterAll removes all registered classifiers. Intended for tests.
+//
+// Example:
+//
+//	t.Cleanup(errors.UnregisterAll)
+func UnregisterAll() {
+	classifierMu.Lock()
+	defer classifierMu.Unlock()
+	classifiers.Store(nil)
+}
+
+// ResetRetryableClassifiers is UnregisterAll, named like this package's
+// other Reset functions.
+func ResetRetryableClassifiers() {
+	UnregisterAll()
+}
+
+// classifyRetryable runs the registered classifiers, returning the
+// decision of the first that matches and its 1-based position.
+func classifyRetryable(err error) (retryable bool, position int, matched bool) {
+	current := classifiers.Load()
+	if current == nil {
+		return false, 0, false
+	}
+	for i, classifier := range *current {
+		if retryable, matched := classifier(err); matched {
+			return retryable, i + 1, true
+		}
+	}
+	return false, 0, false
+}
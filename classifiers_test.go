@@ -0,0 +1,97 @@
+package errors
+
+import (
+	stderrors "errors"
+	"fmt"
+	"sync"
+	"testing"
+)
+
+// vendorError stands in for an SDK error type without an IsRetryable method
+type vendorError struct{ kind string }
+
+func (e *vendorError) Error() string { return "vendor: " + e.kind }
+
+// TestRegisterRetryableClassifier tests classifier order, precedence over the message fallback and the built-in rules
+func TestRegisterRetryableClassifier(t *testing.T) {
+	t.Cleanup(UnregisterAll)
+
+	var calls []string
+	RegisterRetryableClassifier(func(err error) (bool, bool) {
+		calls = append(calls, "vendor")
+		var v *vendorError
+		if !stderrors.As(err, &v) {
+			return false, false
+		}
+		return v.kind == "throttled", true
+	})
+	RegisterRetryableClassifier(func(err error) (bool, bool) {
+		calls = append(calls, "catch-all")
+		return true, true
+	})
+
+	tests := []struct {
+		name          string
+		err           error
+		wantRetryable bool
+		wantCalls     []string
+		wantReason    string
+	}{
+		{name: "first matches", err: Wrap(&vendorError{kind: "throttled"}, "calling vendor"), wantRetryable: true, wantCalls: []string{"vendor"}, wantReason: "RetryableClassifier 1 reported true"},
+		{name: "match skips fallback", err: &vendorError{kind: "rate limit disabled"}, wantCalls: []string{"vendor"}, wantReason: "RetryableClassifier 1 reported false"},
+		{name: "second matches", err: fmt.Errorf("boom"), wantRetryable: true, wantCalls: []string{"vendor", "catch-all"}, wantReason: "RetryableClassifier 2 reported true"},
+		{name: "built-in rules first", err: NewValidationError("bad", "email"), wantReason: "ValidationError.IsRetryable() reported false"},
+		{name: "joined errors first", err: fmt.Errorf("op: %w", stderrors.Join(ErrServerError, nil, ErrDeadlock)), wantRetryable: true, wantReason: "joined error 1 of 2: IsRetryable reported true"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			calls = nil
+			if got := IsRetryable(tt.err); got != tt.wantRetryable {
+				t.Errorf("IsRetryable() = %v, want %v", got, tt.wantRetryable)
+			}
+			if fmt.Sprint(calls) != fmt.Sprint(tt.wantCalls) {
+				t.Errorf("classifiers called %v, want %v", calls, tt.wantCalls)
+			}
+			if _, reason := ExplainRetryable(tt.err); reason != tt.wantReason {
+				t.Errorf("ExplainRetryable() reason = %q, want %q", reason, tt.wantReason)
+			}
+		})
+	}
+
+	UnregisterAll()
+	if IsRetryable(&vendorError{kind: "throttled"}) {
+		t.Error("IsRetryable() should not consult classifiers after UnregisterAll")
+	}
+
+	RegisterRetryableClassifier(func(error) (bool, bool) { return true, true })
+	ResetRetryableClassifiers()
+	if IsRetryable(&vendorError{kind: "throttled"}) {
+		t.Error("IsRetryable() should not consult classifiers after ResetRetryableClassifiers")
+	}
+}
+
+// TestRegisterRetryableClassifierConcurrent tests classifying while classifiers are registered
+func TestRegisterRetryableClassifierConcurrent(t *testing.T) {
+	t.Cleanup(ResetRetryableClassifiers)
+	err := NewHTTPError(503, "down", nil)
+
+	var wg sync.WaitGroup
+	for range 4 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for range 1000 {
+				IsRetryable(fmt.Errorf("boom"))
+				if !IsRetryable(err) {
+					t.Error("503 should stay retryable while classifiers change")
+					return
+				}
+			}
+		}()
+	}
+	for range 100 {
+		RegisterRetryableClassifier(func(error) (bool, bool) { return false, false })
+	}
+	wg.Wait()
+}
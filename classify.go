@@ -0,0 +1,155 @@
+package errors
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/cockroachdb/errors"
+)
+
+// ErrorClass is a coarse, low-cardinality classification of an error.
+// Every error maps to exactly one class, which makes it suitable for metric
+// labels and for retry frameworks that need a single answer.
+type ErrorClass string
+
+const (
+	// ClassTransient indicates a temporary failure that is safe to retry.
+	ClassTransient ErrorClass = "transient"
+
+	// ClassPermanent indicates a failure that will not succeed on retry.
+	ClassPermanent ErrorClass = "permanent"
+
+	// ClassContext indicates the operation was abandoned because its
+	// context was canceled or its deadline was exceeded.
+	ClassContext ErrorClass = "context"
+
+	// ClassUnknown indicates an error with no classification information.
+	ClassUnknown ErrorClass = "unknown"
+)
+
+// Classify returns the class of err. Returns "" for a nil error.
+//
+// Decision order:
+//  0. Joined errors (errors.Join or fmt.Errorf with several %w) - each
+//     branch is classified and the strongest class wins: context, then
+//     permanent, then transient (see joinedBranches)
+//  1. Permanent/Transient overrides (see IsForced) - the forced class
+//  2. Context errors (DeadlineExceeded, Canceled) - ClassContext
+//  3. Errors decoded from a transport - the class the sender computed (see
+//     PreclassifiedClass), unless IgnorePreclassification is given
+//  4. RemoteError from a newer sender - the class it was sent with
+//  5. IsRetryable(err) - ClassTransient
+//  6. Permanent by type or status (validation, not found, unsupported,
+//     config, encoding, circuit open, a 4xx or 501 HTTP status, a TLS
+//     certificate verification error, a DNS name that doesn't exist) -
+//     ClassPermanent
+//  7. Anything else - ClassUnknown
+func Classify(err error, opts ...Option) ErrorClass {
+	class, _ := ExplainClassification(err, opts...)
+	return class
+}
+
+// classifyConfig holds the options accepted by Classify.
+type classifyConfig struct {
+	ignorePreclassified bool
+}
+
+// ExplainClassification returns the class of err, as Classify does, along
+// with a short reason naming the rule that decided it. Intended for
+// debugging unexpected retry decisions.
+//
+// When the sender's classification is used, the reason also gives the class
+// this package's own rules would have chosen, so disagreements caused by
+// version skew can be audited.
+//
+// Example:
+//
+//	class, reason := ExplainClassification(errors.Permanent(rateLimitErr))
+//	// class = ClassPermanent, reason = "forced permanent by Permanent()"
+//
+//	class, reason = ExplainClassification(received)
+//	// class = ClassTransient, reason = "preclassified transient by sender (local rules: permanent)"
+func ExplainClassification(err error, opts ...Option) (ErrorClass, string) {
+	if err == nil {
+		return "", "nil error"
+	}
+
+	cfg := &classifyConfig{}
+	for _, opt := range opts {
+		opt(cfg)
+	}
+
+	if branches := joinedBranches(err); branches != nil {
+		return explainJoined(branches, opts)
+	}
+
+	if class, by, ok := forcedBy(err); ok {
+		return class, "forced " + string(class) + " by " + by
+	}
+
+	switch {
+	case errors.Is(err, context.DeadlineExceeded):
+		return ClassContext, "context.DeadlineExceeded in chain"
+	case errors.Is(err, context.Canceled):
+		return ClassContext, "context.Canceled in chain"
+	}
+
+	if class, ok := PreclassifiedClass(err); ok && !cfg.ignorePreclassified {
+		local := Classify(err, IgnorePreclassification())
+		return class, fmt.Sprintf("preclassified %s by sender (local rules: %s)", class, local)
+	}
+
+	if remoteErr, ok := IsRemoteError(err); ok && remoteErr != nil && remoteErr.Class != "" {
+		return remoteErr.Class, "class preserved from remote " + remoteErr.Type
+	}
+
+	switch {
+	case isRetryable(err, !cfg.ignorePreclassified):
+		return ClassTransient, "IsRetryable reported true"
+	case isPermanent(err):
+		return ClassPermanent, reasonPermanentRules
+	default:
+		return ClassUnknown, "no classification information"
+	}
+}
+
+// PreclassifiedClass returns the class the sender computed for an error
+// decoded from a transport (see Decode), which Classify and IsRetryable
+// prefer over re-running their rules so that version skew can't change the
+// answer. Only the outermost typed or decoded error counts: an error the
+// receiver wraps in its own typed error is classified by local rules, which
+// consult the preclassified cause as usual.
+func PreclassifiedClass(err error) (ErrorClass, bool) {
+	var class ErrorClass
+	walkChain(err, func(node error, _ int) bool {
+		field := preclassField(node)
+		if field == nil {
+			if _, typed := node.(chainFormatter); !typed {
+				return true
+			}
+		} else {
+			class = *field
+		}
+		return false
+	})
+	return class, class != ""
+}
+
+// WasPreclassified reports whether err carries a classification computed
+// by its sender (see PreclassifiedClass).
+func WasPreclassified(err error) bool {
+	_, ok := PreclassifiedClass(err)
+	return ok
+}
+
+// preclassField returns a pointer to the class recorded by Decode on a
+// typed or decoded error, or nil if err's type doesn't record one.
+func preclassField(err any) *ErrorClass {
+	if e, ok := err.(*decodedError); ok {
+		return &e.preclassified
+	}
+	if state := stateField(err); state != nil {
+		return &state.preclassified
+	}
+	return nil
+}
@@ -0,0 +1,172 @@
+package errors
+
+import (
+	"context"
+	stderrors "errors"
+	"fmt"
+	"testing"
+	"time"
+)
+
+// TestClassifyDecisionTable tests the class of every typed error, sentinel, context error and wrapped combination, and that the boolean helpers agree with it
+func TestClassifyDecisionTable(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want ErrorClass
+	}{
+		// typed errors
+		{name: "HTTPError 503", err: NewHTTPError(503, "down", nil), want: ClassTransient},
+		{name: "HTTPError 404", err: NewHTTPError(404, "missing", nil), want: ClassPermanent},
+		{name: "HTTPError 302", err: NewHTTPError(302, "moved", nil), want: ClassUnknown},
+		{name: "ValidationError", err: NewValidationError("bad", "email"), want: ClassPermanent},
+		{name: "TimeoutError", err: NewTimeoutError("slow", "Quote", time.Second), want: ClassTransient},
+		{name: "RateLimitError", err: NewRateLimitError("slow down", "Quote", time.Second), want: ClassTransient},
+		{name: "RetryableError", err: NewRetryableError("again", "Quote", time.Second), want: ClassTransient},
+		{name: "ProcessingError", err: NewProcessingError("failed", "Run"), want: ClassUnknown},
+		{name: "ProcessingError retryable", err: NewProcessingError("failed", "Run", WithRetryable(true)), want: ClassTransient},
+		{name: "NetworkError transient", err: NewNetworkError("refused", "Dial"), want: ClassTransient},
+		{name: "NetworkError persistent", err: NewNetworkError("refused", "Dial", WithTransient(false)), want: ClassUnknown},
+		{name: "SerializationError", err: NewDecodeError("bad json", "Load", "json"), want: ClassPermanent},
+		{name: "CircuitBreakerError open", err: NewCircuitBreakerError("tripped", "Charge", "open"), want: ClassPermanent},
+		{name: "RetryError", err: NewRetryError(3, 3, NewHTTPError(503, "down", nil), nil), want: ClassUnknown},
+		{name: "NotImplementedError", err: NewNotImplementedError("export"), want: ClassPermanent},
+		{name: "UnsupportedError", err: NewUnsupportedError("xml", "json"), want: ClassPermanent},
+		{name: "ConsistencyError", err: NewConsistencyError("order/42", "17", "15", time.Second), want: ClassTransient},
+		{name: "NotFoundError", err: NewNotFoundError("order", "42"), want: ClassPermanent},
+		{name: "ConflictError", err: NewConflictError("order", "42"), want: ClassPermanent},
+		{name: "DatabaseError deadlock", err: NewDatabaseError("Update", "orders", WithSQLState("40P01")), want: ClassTransient},
+		{name: "DatabaseError unique violation", err: NewDatabaseError("Insert", "orders", WithSQLState("23505")), want: ClassUnknown},
+		{name: "ProviderError 402", err: NewProviderError("stripe", WithStatusCode(402)), want: ClassPermanent},
+		{name: "ProviderError 503", err: NewProviderError("stripe", WithStatusCode(503)), want: ClassTransient},
+		{name: "ConfigError", err: NewConfigError("must be positive", "max"), want: ClassPermanent},
+		{name: "PanicError", err: NewPanicError("boom"), want: ClassUnknown},
+
+		// sentinels
+		{name: "ErrRateLimited", err: ErrRateLimited, want: ClassTransient},
+		{name: "ErrNetworkTimeout", err: ErrNetworkTimeout, want: ClassTransient},
+		{name: "ErrServerError", err: ErrServerError, want: ClassTransient},
+		{name: "ErrConnectionError", err: ErrConnectionError, want: ClassTransient},
+		{name: "ErrDeadlock", err: ErrDeadlock, want: ClassTransient},
+		{name: "ErrCircuitOpen", err: ErrCircuitOpen, want: ClassTransient},
+		{name: "ErrInvalidResponse", err: ErrInvalidResponse, want: ClassUnknown},
+		{name: "ErrNotImplemented", err: ErrNotImplemented, want: ClassPermanent},
+		{name: "ErrUnsupported", err: ErrUnsupported, want: ClassPermanent},
+		{name: "ErrActivityNotFound", err: ErrActivityNotFound, want: ClassPermanent},
+
+		// context errors
+		{name: "context.Canceled", err: context.Canceled, want: ClassContext},
+		{name: "context.DeadlineExceeded", err: context.DeadlineExceeded, want: ClassContext},
+		{name: "timeout caused by deadline", err: NewTimeoutError("slow", "Quote", time.Second, WithCause(context.DeadlineExceeded)), want: ClassContext},
+
+		// untyped and wrapped combinations
+		{name: "fmt.Errorf", err: fmt.Errorf("something broke"), want: ClassUnknown},
+		{name: "Wrap transient", err: Wrap(NewHTTPError(503, "down", nil), "calling billing"), want: ClassTransient},
+		{name: "fmt %w permanent", err: fmt.Errorf("loading: %w", NewNotFoundError("order", "42")), want: ClassPermanent},
+		{name: "Permanent over transient", err: Permanent(NewHTTPError(503, "down", nil)), want: ClassPermanent},
+		{name: "Transient over permanent", err: Transient(NewValidationError("bad", "email")), want: ClassTransient},
+		{name: "Transient over context", err: Transient(context.Canceled), want: ClassContext},
+		{name: "join transient and unknown", err: stderrors.Join(ErrServerError, fmt.Errorf("other")), want: ClassTransient},
+		{name: "join transient and permanent", err: stderrors.Join(ErrServerError, NewNotFoundError("order", "42")), want: ClassPermanent},
+		{name: "join with context", err: stderrors.Join(ErrServerError, context.Canceled), want: ClassContext},
+		{name: "processing caused by transient", err: NewProcessingError("failed", "Run", WithCause(ErrServerError)), want: ClassTransient},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := Classify(tt.err)
+			if got != tt.want {
+				t.Errorf("Classify() = %q, want %q", got, tt.want)
+			}
+
+			retryable, transient, permanent := IsRetryable(tt.err), IsTransientError(tt.err), IsPermanentError(tt.err)
+			if transient != (got == ClassTransient) || retryable != transient {
+				t.Errorf("IsRetryable() = %v, IsTransientError() = %v, want both %v for class %q",
+					retryable, transient, got == ClassTransient, got)
+			}
+			if permanent != (got == ClassPermanent || got == ClassContext) {
+				t.Errorf("IsPermanentError() = %v for class %q", permanent, got)
+			}
+			if transient && permanent {
+				t.Error("IsTransientError and IsPermanentError both true")
+			}
+		})
+	}
+}
+
+// TestExplainRetryable tests the decision and reason for each retryable rule
+func TestExplainRetryable(t *testing.T) {
+	tests := []struct {
+		name       string
+		err        error
+		want       bool
+		wantReason string
+	}{
+		{name: "nil", err: nil, want: false, wantReason: "nil error"},
+		{name: "deadline", err: NewTimeoutError("slow", "Quote", time.Second, WithCause(context.DeadlineExceeded)), want: false, wantReason: "context.DeadlineExceeded in chain"},
+		{name: "canceled", err: fmt.Errorf("op: %w", context.Canceled), want: false, wantReason: "context.Canceled in chain"},
+		{name: "forced permanent", err: Permanent(NewHTTPError(503, "down", nil)), want: false, wantReason: "forced permanent by Permanent()"},
+		{name: "forced transient", err: Transient(fmt.Errorf("flaky")), want: true, wantReason: "forced transient by Transient()"},
+		{name: "joined", err: stderrors.Join(fmt.Errorf("other"), ErrServerError), want: true, wantReason: "joined error 2 of 2: IsRetryable reported true"},
+		{name: "retry exhausted", err: NewRetryError(3, 3, ErrServerError, nil), want: false, wantReason: "RetryError: retries exhausted"},
+		{name: "http status", err: Wrap(NewHTTPError(503, "down", nil), "calling billing"), want: true, wantReason: "HTTPError status 503"},
+		{name: "http override", err: NewHTTPError(500, "down", nil, WithRetryableOverride(false)), want: false, wantReason: "HTTPError WithRetryableOverride(false)"},
+		{name: "typed", err: NewTimeoutError("slow", "Quote", time.Second), want: true, wantReason: "TimeoutError.IsRetryable() reported true"},
+		{name: "sentinel", err: fmt.Errorf("calling: %w", ErrRateLimited), want: true, wantReason: "sentinel ErrRateLimited in chain"},
+		{name: "string fallback", err: fmt.Errorf("API rate limit exceeded"), want: true, wantReason: `matched "rate limit" string fallback`},
+		{name: "nothing matched", err: fmt.Errorf("boom"), want: false, wantReason: "no retryable rule matched"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, reason := ExplainRetryable(tt.err)
+			if got != tt.want || reason != tt.wantReason {
+				t.Errorf("ExplainRetryable() = %v, %q, want %v, %q", got, reason, tt.want, tt.wantReason)
+			}
+			if got != IsRetryable(tt.err) {
+				t.Errorf("ExplainRetryable() = %v disagrees with IsRetryable()", got)
+			}
+		})
+	}
+}
+
+// TestExplainPermanent tests the decision and reason for each permanent rule
+func TestExplainPermanent(t *testing.T) {
+	tests := []struct {
+		name       string
+		err        error
+		want       bool
+		wantReason string
+	}{
+		{name: "validation", err: Wrap(NewValidationError("bad", "email"), "outer"), want: true, wantReason: "ValidationError"},
+		{name: "not found", err: NewNotFoundError("order", "42"), want: true, wantReason: "NotFoundError"},
+		{name: "http status", err: NewHTTPError(404, "missing", nil), want: true, wantReason: "HTTPError status 404"},
+		{name: "provider status", err: NewProviderError("stripe", WithStatusCode(402)), want: true, wantReason: "ProviderError status 402"},
+		{name: "circuit open", err: NewCircuitBreakerError("tripped", "Charge", "open"), want: true, wantReason: "circuit open"},
+		{name: "forced", err: Permanent(NewValidationError("bad", "email")), want: true, wantReason: "forced permanent by Permanent()"},
+		{name: "context", err: context.Canceled, want: true, wantReason: "context.Canceled in chain"},
+		{name: "transient", err: NewHTTPError(503, "down", nil), want: false, wantReason: "IsRetryable reported true"},
+		{name: "unknown", err: fmt.Errorf("boom"), want: false, wantReason: "no classification information"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, reason := ExplainPermanent(tt.err)
+			if got != tt.want || reason != tt.wantReason {
+				t.Errorf("ExplainPermanent() = %v, %q, want %v, %q", got, reason, tt.want, tt.wantReason)
+			}
+			if got != IsPermanentError(tt.err) {
+				t.Errorf("ExplainPermanent() = %v disagrees with IsPermanentError()", got)
+			}
+		})
+	}
+}
+
+// TestIsRetryableAllocations tests that IsRetryable doesn't pay for the reasons ExplainRetryable builds
+func TestIsRetryableAllocations(t *testing.T) {
+	err := Wrap(NewHTTPError(503, "down", nil), "calling billing")
+	// The one allocation is errors.As's target escaping.
+	if allocs := testing.AllocsPerRun(100, func() { IsRetryable(err) }); allocs > 1 {
+		t.Errorf("IsRetryable() allocates %v times, want at most 1", allocs)
+	}
+}
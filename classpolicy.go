@@ -0,0 +1,113 @@
+package errors
+
+import (
+	"net/http"
+	"sync"
+	"time"
+)
+
+// FailureKind names the failure families PolicyForClass picks a backoff
+// preset for.
+type FailureKind string
+
+const (
+	// FailureRateLimit is a rate limit (ErrRateLimited, RateLimitError).
+	FailureRateLimit FailureKind = "rate_limit"
+
+	// FailureCircuitOpen is a call rejected by a circuit breaker.
+	FailureCircuitOpen FailureKind = "circuit_open"
+
+	// FailureOverload is a server shedding load (see IsOverload).
+	FailureOverload FailureKind = "overload"
+
+	// FailureDeadlock is a database deadlock (ErrDeadlock).
+	FailureDeadlock FailureKind = "deadlock"
+
+	// FailureNetwork is a network blip: NetworkError, net.Error,
+	// ErrConnectionError or ErrNetworkTimeout.
+	FailureNetwork FailureKind = "network"
+
+	// FailureOther is everything else.
+	FailureOther FailureKind = "other"
+)
+
+// defaultClassPolicies are the presets returned by PolicyForClass. Rate
+// limits and open circuits rarely use theirs: ExplainRetryPlan waits for
+// the server's hint or the circuit's reopen time when the error carries
+// one, and only falls back to the curve when it doesn't.
+func defaultClassPolicies() map[FailureKind]BackoffPolicy {
+	return map[FailureKind]BackoffPolicy{
+		FailureRateLimit:   {Initial: time.Second, Max: time.Minute, Multiplier: 2, Jitter: 0.1},
+		FailureCircuitOpen: {Initial: 5 * time.Second, Max: time.Minute, Multiplier: 2, Jitter: 0.2},
+		FailureOverload:    {Initial: time.Second, Max: time.Minute, Multiplier: 2, Jitter: 0.3},
+		FailureDeadlock:    {Initial: 10 * time.Millisecond, Max: time.Second, Multiplier: 2, Jitter: 0.5, Immediate: 1},
+		FailureNetwork:     {Initial: 50 * time.Millisecond, Max: 5 * time.Second, Multiplier: 2, Jitter: 0.2},
+		FailureOther:       DefaultBackoffPolicy,
+	}
+}
+
+var (
+	classPoliciesMu sync.RWMutex
+	classPolicies   = defaultClassPolicies()
+)
+
+// RegisterClassPolicy replaces the backoff preset PolicyForClass returns
+// for kind.
+//
+// Example:
+//
+//	errors.RegisterClassPolicy(errors.FailureNetwork, errors.BackoffPolicy{
+//	    Initial: 20 * time.Millisecond, Max: time.Second, Multiplier: 2,
+//	})
+func RegisterClassPolicy(kind FailureKind, policy BackoffPolicy) {
+	classPoliciesMu.Lock()
+	defer classPoliciesMu.Unlock()
+	classPolicies[kind] = policy
+}
+
+// ResetClassPolicies restores the built-in presets. Intended for tests.
+func ResetClassPolicies() {
+	classPoliciesMu.Lock()
+	defer classPoliciesMu.Unlock()
+	classPolicies = defaultClassPolicies()
+}
+
+// PolicyForClass returns the backoff preset for err's failure kind (see
+// FailureKindOf), for retry executors whose caller didn't choose a policy:
+//   - rate limits - 1s doubling to 1m; the server's hint wins when present
+//   - open circuits - 5s doubling to 1m; the reopen time wins when present
+//   - overload - 1s doubling to 1m, scaled further by SuggestedBackoff
+//   - deadlocks - one immediate retry, then 10ms doubling to 1s
+//   - network blips - 50ms doubling to 5s
+//   - anything else - DefaultBackoffPolicy
+//
+// Example:
+//
+//	plan := errors.ExplainRetryPlan(err, attempt, errors.PolicyForClass(err))
+//	time.Sleep(plan.Delay)
+func PolicyForClass(err error) BackoffPolicy {
+	classPoliciesMu.RLock()
+	defer classPoliciesMu.RUnlock()
+	if policy, ok := classPolicies[FailureKindOf(err)]; ok {
+		return policy
+	}
+	return DefaultBackoffPolicy
+}
+
+// FailureKindOf returns the failure family of err used by PolicyForClass.
+func FailureKindOf(err error) FailureKind {
+	httpErr, isHTTP := IsHTTPError(err)
+	switch {
+	case Is(err, ErrRateLimited), isHTTP && httpErr.StatusCode == http.StatusTooManyRequests:
+		return FailureRateLimit
+	case Is(err, ErrCircuitOpen), Is(err, ErrCircuitHalfOpen):
+		return FailureCircuitOpen
+	case IsOverload(err):
+		return FailureOverload
+	case Is(err, ErrDeadlock):
+		return FailureDeadlock
+	case IsNetworkError(err), Is(err, ErrConnectionError), Is(err, ErrNetworkTimeout):
+		return FailureNetwork
+	}
+	return FailureOther
+}
@@ -0,0 +1,74 @@
+package errors
+
+import (
+	"testing"
+	"time"
+)
+
+// TestPolicyForClass tests the backoff preset picked for each failure kind
+func TestPolicyForClass(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want FailureKind
+	}{
+		{name: "rate limit", err: NewRateLimitError("Slow down", "Search", 2*time.Second), want: FailureRateLimit},
+		{name: "429 without hint", err: NewHTTPError(429, "Too Many Requests", nil), want: FailureRateLimit},
+		{name: "open circuit", err: NewCircuitBreakerError("open", "Charge", "open"), want: FailureCircuitOpen},
+		{name: "overload", err: NewHTTPError(503, "Shedding", nil, WithOverloaded()), want: FailureOverload},
+		{name: "deadlock", err: Wrap(ErrDeadlock, "updating order"), want: FailureDeadlock},
+		{name: "network blip", err: NewNetworkError("Connection reset", "Fetch"), want: FailureNetwork},
+		{name: "other", err: NewHTTPError(500, "Internal Server Error", nil), want: FailureOther},
+	}
+
+	presets := defaultClassPolicies()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := FailureKindOf(tt.err); got != tt.want {
+				t.Fatalf("FailureKindOf() = %q, want %q", got, tt.want)
+			}
+			if got := PolicyForClass(tt.err); got != presets[tt.want] {
+				t.Errorf("PolicyForClass() = %+v, want the %s preset", got, tt.want)
+			}
+		})
+	}
+
+	t.Run("registered override", func(t *testing.T) {
+		defer ResetClassPolicies()
+		custom := BackoffPolicy{Initial: 20 * time.Millisecond, Multiplier: 3}
+		RegisterClassPolicy(FailureNetwork, custom)
+		if got := PolicyForClass(NewNetworkError("reset", "Fetch")); got != custom {
+			t.Errorf("PolicyForClass() = %+v, want %+v", got, custom)
+		}
+	})
+}
+
+// TestClassPolicyDelays tests the waits the presets produce across attempts
+func TestClassPolicyDelays(t *testing.T) {
+	t.Run("rate limits honor the server hint exactly", func(t *testing.T) {
+		err := NewRateLimitError("Slow down", "Search", 2*time.Second)
+		for attempt := 1; attempt <= 6; attempt++ {
+			plan := ExplainRetryPlan(err, attempt, PolicyForClass(err))
+			if plan.Delay != 2*time.Second || plan.Source != DelaySourceServer {
+				t.Errorf("attempt %d: waited %v from %s, want 2s from the server", attempt, plan.Delay, plan.Source)
+			}
+		}
+	})
+
+	t.Run("deadlocks retry once immediately then back off", func(t *testing.T) {
+		policy := PolicyForClass(ErrDeadlock)
+		policy.Jitter = 0
+		want := []time.Duration{0, 10 * time.Millisecond, 20 * time.Millisecond, 40 * time.Millisecond}
+		for attempt, wantDelay := range want {
+			if got := policy.Delay(attempt); got != wantDelay {
+				t.Errorf("Delay(%d) = %v, want %v", attempt, got, wantDelay)
+			}
+		}
+	})
+
+	t.Run("network blips retry fast", func(t *testing.T) {
+		if got := PolicyForClass(NewNetworkError("reset", "Fetch")).Delay(0); got > 50*time.Millisecond {
+			t.Errorf("first delay = %v, want at most 50ms", got)
+		}
+	})
+}
@@ -0,0 +1,106 @@
+package errors
+
+import (
+	"io"
+	"strings"
+)
+
+// cleanupError attaches the failures of compensations and closers to the
+// error that made them run. It unwraps to the primary error only, so
+// errors.Is, errors.As, classification and transport mappings judge the
+// operation by what actually went wrong, not by a rollback that also failed.
+type cleanupError struct {
+	err     error
+	cleanup []error
+}
+
+func (e *cleanupError) Error() string {
+	failures := make([]string, len(e.cleanup))
+	for i, c := range e.cleanup {
+		failures[i] = c.Error()
+	}
+	return e.err.Error() + " (cleanup failed: " + strings.Join(failures, "; ") + ")"
+}
+
+func (e *cleanupError) Unwrap() error {
+	return e.err
+}
+
+// CombineWithCleanup returns primary with the failures of the cleanup that
+// ran because of it attached as secondary causes, so a failed rollback
+// doesn't clobber the error that triggered it. The result's message leads
+// with primary, and classification, HTTP status and errors.Is and
+// errors.As see primary alone; CleanupErrors returns the cleanup failures.
+//
+// Nil cleanup errors are skipped, and primary is returned unchanged when
+// none failed. When primary is nil the first cleanup failure becomes the
+// primary error, since the operation itself failed only in cleaning up.
+//
+// Example:
+//
+//	if err := chargeCard(ctx, order); err != nil {
+//	    return errors.CombineWithCleanup(err,
+//	        releaseHold(ctx, order),
+//	        restock(ctx, order))
+//	}
+func CombineWithCleanup(primary error, cleanupErrs ...error) error {
+	var failed []error
+	for _, c := range cleanupErrs {
+		if c != nil {
+			failed = append(failed, c)
+		}
+	}
+	if len(failed) == 0 {
+		return primary
+	}
+	if primary == nil {
+		primary, failed = failed[0], failed[1:]
+		if len(failed) == 0 {
+			return primary
+		}
+	}
+
+	if existing, ok := primary.(*cleanupError); ok {
+		combined := append(append([]error(nil), existing.cleanup...), failed...)
+		return &cleanupError{err: existing.err, cleanup: combined}
+	}
+	return &cleanupError{err: primary, cleanup: failed}
+}
+
+// CleanupErrors returns the cleanup failures attached anywhere in err's
+// chain by CombineWithCleanup or DeferClose, outermost first.
+//
+// Example:
+//
+//	for _, cleanupErr := range errors.CleanupErrors(err) {
+//	    logger.Warn("compensation failed", "error", cleanupErr)
+//	}
+func CleanupErrors(err error) []error {
+	var failures []error
+	walkChain(err, func(node error, _ int) bool {
+		if e, ok := node.(*cleanupError); ok {
+			failures = append(failures, e.cleanup...)
+		}
+		return true
+	})
+	return failures
+}
+
+// DeferClose closes closer and attaches its error to *errp as a cleanup
+// failure (see CombineWithCleanup) rather than overwriting it, for use in
+// a defer with a named error result. A close error becomes the result only
+// when the function otherwise succeeded.
+//
+// Example:
+//
+//	func export(path string) (err error) {
+//	    f, err := os.Create(path)
+//	    if err != nil {
+//	        return err
+//	    }
+//	    defer errors.DeferClose(&err, f)
+//	    ...
+//	}
+func DeferClose(errp *error, closer io.Closer) {
+	*errp = CombineWithCleanup(*errp, closer.Close())
+}
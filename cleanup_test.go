@@ -0,0 +1,89 @@
+package errors
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// closeFunc adapts a function to io.Closer.
+type closeFunc func() error
+
+func (f closeFunc) Close() error { return f() }
+
+// TestCombineWithCleanup tests that the primary error stays dominant over cleanup failures
+func TestCombineWithCleanup(t *testing.T) {
+	primary := NewValidationError("card declined", "card")
+	rollback := NewNetworkError("connection reset", "ReleaseHold")
+	restock := fmt.Errorf("restock failed")
+
+	tests := []struct {
+		name        string
+		primary     error
+		cleanup     []error
+		wantPrimary error
+		wantCleanup []error
+	}{
+		{"primary and failing cleanup", primary, []error{rollback, nil, restock}, primary, []error{rollback, restock}},
+		{"nil primary with failing cleanup", nil, []error{nil, rollback, restock}, rollback, []error{restock}},
+		{"primary with cleanup that succeeded", primary, []error{nil}, primary, nil},
+		{"nothing failed", nil, []error{nil}, nil, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := CombineWithCleanup(tt.primary, tt.cleanup...)
+			if tt.wantPrimary == nil {
+				if err != nil {
+					t.Fatalf("CombineWithCleanup() = %v, want nil", err)
+				}
+				return
+			}
+
+			if !strings.HasPrefix(err.Error(), tt.wantPrimary.Error()) {
+				t.Errorf("Error() = %q, want it to lead with %q", err.Error(), tt.wantPrimary.Error())
+			}
+			if !Is(err, tt.wantPrimary) || Classify(err) != Classify(tt.wantPrimary) || IsRetryable(err) != IsRetryable(tt.wantPrimary) {
+				t.Errorf("%v should be classified as its primary %v", err, tt.wantPrimary)
+			}
+			if got := CleanupErrors(err); !reflect.DeepEqual(got, tt.wantCleanup) {
+				t.Errorf("CleanupErrors() = %v, want %v", got, tt.wantCleanup)
+			}
+			for _, c := range tt.wantCleanup {
+				if Is(err, c) || !strings.Contains(err.Error(), c.Error()) {
+					t.Errorf("cleanup failure %v should be in the message but not the chain", c)
+				}
+			}
+		})
+	}
+}
+
+// TestDeferClose tests that a closer's error is attached rather than overwriting the result
+func TestDeferClose(t *testing.T) {
+	closeErr := fmt.Errorf("flush failed")
+	failingClose := closeFunc(func() error { return closeErr })
+	primary := NewProcessingError("write failed", "Export")
+
+	export := func(writeErr error, closer closeFunc) (err error) {
+		defer DeferClose(&err, closer)
+		defer DeferClose(&err, closer)
+		return writeErr
+	}
+
+	err := export(primary, failingClose)
+	if !Is(err, primary) || len(CleanupErrors(err)) != 2 {
+		t.Errorf("export() = %v, want the write failure with both close failures attached", err)
+	}
+	if err := export(nil, failingClose); !Is(err, closeErr) || len(CleanupErrors(err)) != 1 {
+		t.Errorf("export() = %v, want the first close failure as the result", err)
+	}
+	if err := export(nil, func() error { return nil }); err != nil {
+		t.Errorf("export() = %v, want nil", err)
+	}
+
+	info := ExtractErrorInfo(Wrap(err, "outer"))
+	if got, _ := info["cleanup_errors"].([]string); len(got) != 2 {
+		t.Errorf(`info["cleanup_errors"] = %v, want both close failures`, info["cleanup_errors"])
+	}
+}
@@ -0,0 +1,149 @@
+package errors
+
+import (
+	"context"
+	"math/rand/v2"
+	"sync"
+	"time"
+)
+
+// Clock abstracts time so that time-dependent helpers can be driven
+// deterministically in tests.
+type Clock interface {
+	Now() time.Time
+	Since(t time.Time) time.Duration
+}
+
+// Sleeper is implemented by clocks that control waiting as well as
+// reading time. Sleep uses it when the package clock provides it, so a
+// fake clock can advance instead of blocking.
+type Sleeper interface {
+	Sleep(ctx context.Context, d time.Duration) error
+}
+
+// realClock reads the system clock.
+type realClock struct{}
+
+func (realClock) Now() time.Time {
+	return time.Now()
+}
+
+func (realClock) Since(t time.Time) time.Duration {
+	return time.Since(t)
+}
+
+// packageClock reads whichever clock SetClock installed at call time. It is
+// the default for helpers that also accept WithClock.
+type packageClock struct{}
+
+func (packageClock) Now() time.Time {
+	return now()
+}
+
+func (packageClock) Since(t time.Time) time.Duration {
+	return now().Sub(t)
+}
+
+var (
+	clockMu      sync.RWMutex
+	currentClock Clock = realClock{}
+
+	randMu     sync.Mutex
+	randSource *rand.Rand
+)
+
+// SetClock replaces the clock read by every time-dependent helper in the
+// package: Retry-After and RateLimit-Reset arithmetic, circuit reopen
+// times, BackoffRegistry TTLs, transition timestamps and Sleep. A nil
+// clock restores the system clock. Intended for tests.
+//
+// Example:
+//
+//	clock := errtest.NewFakeClock(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+//	errors.SetClock(clock)
+//	defer errors.SetClock(nil)
+func SetClock(clock Clock) {
+	if clock == nil {
+		clock = realClock{}
+	}
+	clockMu.Lock()
+	defer clockMu.Unlock()
+	currentClock = clock
+}
+
+// SetRandSource replaces the randomness behind backoff jitter with src,
+// making delays reproducible. A nil source restores the default
+// runtime-seeded generator. Intended for tests.
+//
+// Example:
+//
+//	errors.SetRandSource(rand.NewPCG(1, 2))
+//	defer errors.SetRandSource(nil)
+func SetRandSource(src rand.Source) {
+	randMu.Lock()
+	defer randMu.Unlock()
+	if src == nil {
+		randSource = nil
+		return
+	}
+	randSource = rand.New(src)
+}
+
+// Sleep waits for d or until ctx is done, returning ctx's error in the
+// latter case. When the package clock implements Sleeper, it does the
+// waiting instead, which lets a fake clock skip ahead.
+//
+// Example:
+//
+//	if err := errors.Sleep(ctx, plan.Delay); err != nil {
+//	    return err
+//	}
+func Sleep(ctx context.Context, d time.Duration) error {
+	clockMu.RLock()
+	sleeper, ok := currentClock.(Sleeper)
+	clockMu.RUnlock()
+	if ok {
+		return sleeper.Sleep(ctx, d)
+	}
+
+	if d <= 0 {
+		return ctx.Err()
+	}
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Now returns the current time on the package clock (see SetClock), so
+// executors outside this package measure elapsed time on the same clock
+// that Sleep waits on.
+func Now() time.Time {
+	return now()
+}
+
+// now reads the package clock.
+func now() time.Time {
+	clockMu.RLock()
+	defer clockMu.RUnlock()
+	return currentClock.Now()
+}
+
+// until returns the time remaining until t on the package clock.
+func until(t time.Time) time.Duration {
+	return t.Sub(now())
+}
+
+// randFloat64 returns a number in [0, 1) from the package rand source.
+func randFloat64() float64 {
+	randMu.Lock()
+	defer randMu.Unlock()
+	if randSource == nil {
+		return rand.Float64()
+	}
+	return randSource.Float64()
+}
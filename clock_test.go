@@ -0,0 +1,82 @@
+package errors
+
+import (
+	"context"
+	"math/rand/v2"
+	"net/http"
+	"testing"
+	"time"
+)
+
+// TestSetClock tests that time-dependent helpers read the installed clock
+func TestSetClock(t *testing.T) {
+	clock := newFakeClock()
+	SetClock(clock)
+	defer SetClock(nil)
+
+	t.Run("rate limit reset counts down", func(t *testing.T) {
+		err := NewRateLimitError("Slow down", "Search", time.Second,
+			WithRateLimitPolicy(10, 0, clock.Now().Add(30*time.Second)))
+		h := http.Header{}
+		RetryAfterHeader(h, err)
+		if got := h.Get(HeaderRateLimitReset); got != "30" {
+			t.Errorf("RateLimit-Reset = %q, want 30", got)
+		}
+		clock.Advance(20 * time.Second)
+		RetryAfterHeader(h, err)
+		if got := h.Get(HeaderRateLimitReset); got != "10" {
+			t.Errorf("RateLimit-Reset after 20s = %q, want 10", got)
+		}
+	})
+
+	t.Run("circuit reopen time", func(t *testing.T) {
+		err := NewCircuitBreakerError("open", "Charge", "open", WithReopenAt(clock.Now().Add(3*time.Second)))
+		if plan := ExplainRetryPlan(Transient(err), 1, DefaultBackoffPolicy); plan.Delay != 3*time.Second {
+			t.Errorf("Delay = %v, want 3s", plan.Delay)
+		}
+	})
+
+	t.Run("sleep advances instead of blocking", func(t *testing.T) {
+		start := clock.Now()
+		if err := Sleep(context.Background(), time.Hour); err != nil {
+			t.Fatalf("Sleep() error = %v", err)
+		}
+		if waited := clock.Since(start); waited != time.Hour {
+			t.Errorf("clock advanced %v, want 1h", waited)
+		}
+	})
+}
+
+// TestSleep tests waiting on the system clock
+func TestSleep(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := Sleep(ctx, time.Hour); err != context.Canceled {
+		t.Errorf("Sleep() on a canceled context = %v, want context.Canceled", err)
+	}
+	if err := Sleep(context.Background(), 0); err != nil {
+		t.Errorf("Sleep(0) = %v, want nil", err)
+	}
+}
+
+// TestSetRandSource tests that a seeded source makes jitter reproducible
+func TestSetRandSource(t *testing.T) {
+	defer SetRandSource(nil)
+	policy := BackoffPolicy{Initial: time.Second, Multiplier: 2, Jitter: 0.5}
+
+	delays := func() []time.Duration {
+		SetRandSource(rand.NewPCG(1, 2))
+		var got []time.Duration
+		for attempt := range 5 {
+			got = append(got, policy.Delay(attempt))
+		}
+		return got
+	}
+
+	first, second := delays(), delays()
+	for i := range first {
+		if first[i] != second[i] {
+			t.Fatalf("attempt %d: %v then %v, want identical jitter from the same seed", i, first[i], second[i])
+		}
+	}
+}
@@ -0,0 +1,82 @@
+// Command errwire checks error payloads against the jp-go-errors wire
+// contract, for test suites of services written in other languages.
+//
+// Usage:
+//
+//	errwire payload.json [more.json ...]
+//	produce-error | errwire
+//
+// Each argument is a file holding one error envelope or problem details
+// body; with no arguments the payload is read from stdin. Problems are
+// printed one per line and the exit status is 1 if any payload is invalid.
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+
+	errors "github.com/JohnPlummer/jp-go-errors"
+)
+
+func main() {
+	os.Exit(run(os.Args[1:], os.Stdin, os.Stderr))
+}
+
+func run(paths []string, stdin io.Reader, stderr io.Writer) int {
+	if len(paths) == 0 {
+		data, err := io.ReadAll(stdin)
+		if err != nil {
+			fmt.Fprintf(stderr, "stdin: %v\n", err)
+			return 1
+		}
+		return report(stderr, "stdin", data)
+	}
+
+	status := 0
+	for _, path := range paths {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			fmt.Fprintf(stderr, "%s: %v\n", path, err)
+			status = 1
+			continue
+		}
+		status = max(status, report(stderr, path, data))
+	}
+	return status
+}
+
+func report(stderr io.Writer, name string, data []byte) int {
+	err := errors.ValidateWirePayload(data)
+	if err == nil {
+		return 0
+	}
+
+	if errors.IsSerialization(err) {
+		fmt.Fprintf(stderr, "%s: %v\n", name, err)
+		return 1
+	}
+
+	for _, problem := range problemsOf(err) {
+		var validationErr *errors.ValidationError
+		if errors.As(problem, &validationErr) {
+			fmt.Fprintf(stderr, "%s: %s: %s\n", name, validationErr.Field, validationErr.Message)
+		} else {
+			fmt.Fprintf(stderr, "%s: %v\n", name, problem)
+		}
+	}
+	return 1
+}
+
+// problemsOf splits the joined problems returned by ValidateWirePayload.
+func problemsOf(err error) []error {
+	for e := err; e != nil; e = errors.Unwrap(e) {
+		if _, ok := e.(*errors.ValidationError); ok {
+			return []error{e}
+		}
+		if joined, ok := e.(interface{ Unwrap() []error }); ok {
+			return joined.Unwrap()
+		}
+	}
+	return []error{err}
+}
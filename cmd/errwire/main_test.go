@@ -0,0 +1,38 @@
+package main
+
+import (
+	"bytes"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// TestRun tests the exit status and output for valid and invalid payloads
+func TestRun(t *testing.T) {
+	tests := []struct {
+		name       string
+		paths      []string
+		stdin      string
+		wantStatus int
+		wantOutput string
+	}{
+		{name: "valid fixtures", paths: []string{filepath.Join("..", "..", "testdata", "wire", "envelope", "http_error.json"), filepath.Join("..", "..", "testdata", "wire", "problem", "http_error.json")}},
+		{name: "valid stdin", stdin: `{"type":"HTTPError","message":"x"}`},
+		{name: "invalid stdin", stdin: `{"type":"HTTPError","statusCode":502}`, wantStatus: 1, wantOutput: `stdin: $.statusCode: unknown member "statusCode"`},
+		{name: "missing file", paths: []string{"missing.json"}, wantStatus: 1, wantOutput: "missing.json:"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var stderr bytes.Buffer
+			status := run(tt.paths, strings.NewReader(tt.stdin), &stderr)
+
+			if status != tt.wantStatus {
+				t.Errorf("run() = %d, want %d (output %q)", status, tt.wantStatus, stderr.String())
+			}
+			if !strings.Contains(stderr.String(), tt.wantOutput) {
+				t.Errorf("output %q, want it to contain %q", stderr.String(), tt.wantOutput)
+			}
+		})
+	}
+}
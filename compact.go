@@ -0,0 +1,131 @@
+package errors
+
+import (
+	"encoding/json"
+	"reflect"
+	"sort"
+)
+
+// Limits applied by Compact.
+const (
+	maxCompactMetadataKeys = 16
+	maxCompactValueBytes   = 256
+)
+
+// metadataAttemptClasses is the metadata key under which Compact records a
+// RetryError's attempts, counted by class, in place of AllErrors.
+const metadataAttemptClasses = "attempts_by_class"
+
+// Compact returns an equivalent of err that is cheap to store long term,
+// such as on a job record. It keeps every node's type, message, fields and
+// classification, sentinels and the messages of the whole cause chain, and
+// drops the rest:
+//   - stack traces
+//   - RetryError.AllErrors, replaced by counts per class under the
+//     "attempts_by_class" metadata key
+//   - metadata beyond 16 keys per error, and values, including
+//     ValidationError.Value, whose JSON exceeds 256 bytes, which are cut
+//     down to a string
+//
+// Returns nil for a nil error. Use CompactJSON for the storage form.
+//
+// Example:
+//
+//	job.LastError = errors.Compact(retryErr)
+func Compact(err error) error {
+	return Decode(compactEnvelope(Encode(err)))
+}
+
+// CompactJSON returns the envelope JSON of Compact(err), ready to store and
+// read back with UnmarshalError. Returns nil for a nil error.
+//
+// Example:
+//
+//	_, err := db.ExecContext(ctx, "UPDATE jobs SET error = $1 WHERE id = $2",
+//	    errors.CompactJSON(jobErr), jobID)
+func CompactJSON(err error) []byte {
+	env := compactEnvelope(Encode(err))
+	if env == nil {
+		return nil
+	}
+	// Envelopes hold only sanitized values, so marshaling can't fail.
+	data, _ := json.Marshal(env)
+	return data
+}
+
+// compactEnvelope trims env and its causes in place.
+func compactEnvelope(env *Envelope) *Envelope {
+	if env == nil {
+		return nil
+	}
+
+	env.Metadata = compactMetadata(env.Metadata)
+	env.Value = compactValue(env.Value)
+	for name, raw := range env.RawExtensions {
+		if len(raw) > maxCompactValueBytes {
+			delete(env.RawExtensions, name)
+		}
+	}
+
+	if len(env.AllErrors) > 0 {
+		byClass := make(map[string]int)
+		for _, attempt := range env.AllErrors {
+			if attempt != nil {
+				byClass[attempt.Class]++
+			}
+		}
+		if env.Metadata == nil {
+			env.Metadata = make(map[string]any)
+		}
+		env.Metadata[metadataAttemptClasses] = byClass
+		env.AllErrors = nil
+	}
+
+	compactEnvelope(env.Cause)
+	for _, c := range env.Causes {
+		compactEnvelope(c)
+	}
+	return env
+}
+
+// compactMetadata keeps the first maxCompactMetadataKeys keys in sorted
+// order, with each value cut down by compactValue.
+func compactMetadata(m map[string]any) map[string]any {
+	if len(m) == 0 {
+		return m
+	}
+
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	if len(keys) > maxCompactMetadataKeys {
+		keys = keys[:maxCompactMetadataKeys]
+	}
+
+	compacted := make(map[string]any, len(keys))
+	for _, k := range keys {
+		compacted[k] = compactValue(m[k])
+	}
+	return compacted
+}
+
+// compactValue returns v unchanged if its JSON fits in maxCompactValueBytes,
+// and otherwise its JSON text cut down to that size.
+func compactValue(v any) any {
+	if v == nil {
+		return nil
+	}
+	data, err := json.Marshal(v)
+	if err != nil {
+		return placeholder(reflect.TypeOf(v))
+	}
+	if len(data) <= maxCompactValueBytes {
+		return v
+	}
+	if s, ok := v.(string); ok {
+		return truncateString(s, maxCompactValueBytes)
+	}
+	return truncateString(string(data), maxCompactValueBytes)
+}
@@ -0,0 +1,116 @@
+package errors
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+)
+
+// newAttemptError creates a realistic failed attempt with a stack and a response body
+func newAttemptError(i int) error {
+	body := strings.Repeat(fmt.Sprintf(`{"error":"upstream overloaded","attempt":%d}`, i), 50)
+	return NewHTTPError(503, "Service Unavailable",
+		Wrap(New("connection reset by peer"), "calling inventory"),
+		WithComponent("inventory-client"),
+		WithMetadata("response_body", body),
+		WithMetadata("request_id", fmt.Sprintf("req-%d", i)))
+}
+
+// TestCompact tests that compacted errors keep their meaning
+func TestCompact(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+	}{
+		{"http error with stack", newAttemptError(1)},
+		{"wrapped sentinel", fmt.Errorf("saving order: %w", ErrDeadlock)},
+		{"context error", NewProcessingError("gave up", "Sync", WithCause(context.DeadlineExceeded))},
+		{"forced permanent", Permanent(NewRetryableError("busy", "Lock", time.Second))},
+		{"validation", NewValidationError("too long", "bio", WithValue(strings.Repeat("x", 5000)))},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			compacted := Compact(tt.err)
+
+			if compacted.Error() != tt.err.Error() && !IsValidation(tt.err) {
+				t.Errorf("Error() = %q, want %q", compacted.Error(), tt.err.Error())
+			}
+			if got, want := Classify(compacted), Classify(tt.err); got != want {
+				t.Errorf("Classify() = %q, want %q", got, want)
+			}
+			if got, want := HTTPStatus(compacted), HTTPStatus(tt.err); got != want {
+				t.Errorf("HTTPStatus() = %d, want %d", got, want)
+			}
+			if HasStackTrace(compacted) {
+				t.Error("compacted error should not carry a stack trace")
+			}
+		})
+	}
+
+	t.Run("sentinels keep identity", func(t *testing.T) {
+		if !Is(Compact(fmt.Errorf("saving order: %w", ErrDeadlock)), ErrDeadlock) {
+			t.Error("Is(ErrDeadlock) = false after Compact")
+		}
+	})
+
+	t.Run("metadata and values capped", func(t *testing.T) {
+		opts := []Option{WithValue(strings.Repeat("x", 5000))}
+		for i := range 40 {
+			opts = append(opts, WithMetadata(fmt.Sprintf("key%02d", i), i))
+		}
+		var validationErr *ValidationError
+		if !As(Compact(NewValidationError("bad", "bio", opts...)), &validationErr) {
+			t.Fatal("expected ValidationError")
+		}
+		if len(validationErr.Metadata) != maxCompactMetadataKeys {
+			t.Errorf("kept %d metadata keys, want %d", len(validationErr.Metadata), maxCompactMetadataKeys)
+		}
+		if s, _ := validationErr.Value.(string); len(s) > maxCompactValueBytes+len(truncatedCause) {
+			t.Errorf("value not capped: %d bytes", len(s))
+		}
+	})
+
+	t.Run("nil", func(t *testing.T) {
+		if Compact(nil) != nil || CompactJSON(nil) != nil {
+			t.Error("Compact(nil) and CompactJSON(nil) should be nil")
+		}
+	})
+}
+
+// TestCompactRetryError tests size reduction and attempt summaries for a realistic RetryError
+func TestCompactRetryError(t *testing.T) {
+	var attempts []error
+	for i := range 20 {
+		attempts = append(attempts, newAttemptError(i))
+	}
+	attempts = append(attempts, context.DeadlineExceeded)
+	err := NewRetryError(21, 21, attempts[len(attempts)-2], attempts, WithOperation("ReserveStock"))
+
+	full, marshalErr := MarshalError(err)
+	if marshalErr != nil {
+		t.Fatalf("MarshalError() error = %v", marshalErr)
+	}
+	compact := CompactJSON(err)
+	if len(compact)*10 > len(full) {
+		t.Errorf("CompactJSON() = %d bytes, want at most a tenth of %d", len(compact), len(full))
+	}
+
+	decoded, unmarshalErr := UnmarshalError(compact)
+	if unmarshalErr != nil {
+		t.Fatalf("UnmarshalError() error = %v", unmarshalErr)
+	}
+	var retryErr *RetryError
+	if !As(decoded, &retryErr) {
+		t.Fatalf("expected RetryError, got %T", decoded)
+	}
+	if retryErr.Attempts != 21 || retryErr.Error() != err.Error() || len(retryErr.AllErrors) != 0 {
+		t.Errorf("RetryError not preserved: %v", retryErr)
+	}
+	byClass, _ := retryErr.Metadata[metadataAttemptClasses].(map[string]any)
+	if byClass["transient"] != float64(20) || byClass["context"] != float64(1) {
+		t.Errorf("attempts_by_class = %v, want 20 transient and 1 context", retryErr.Metadata[metadataAttemptClasses])
+	}
+}
@@ -0,0 +1,119 @@
+package errors
+
+import (
+	"fmt"
+
+	"github.com/cockroachdb/errors"
+	"github.com/cockroachdb/errors/errbase"
+)
+
+// ConfigError represents configuration that can't be used as given, such
+// as a malformed flag, environment variable or retry spec. Key names the
+// setting and Value holds what was given. Configuration stays wrong until
+// someone changes it, so ConfigError is never retryable, and a service
+// reports it as a 500: the caller didn't cause it.
+// Automatically includes stack trace from creation point.
+type ConfigError struct {
+	Key              string // setting that is invalid, such as "backoff"
+	Value            any    // value that was given (optional)
+	Message          string
+	Operation        string
+	Component        string
+	Code             string
+	Owner            string
+	Err              error
+	AdditionalCauses []error
+	Metadata         map[string]any
+
+	state errorState
+}
+
+func (e *ConfigError) Error() string {
+	if e == nil {
+		return "<nil ConfigError>"
+	}
+	return e.formatWithCause(formatCauses(e.Err, e.AdditionalCauses))
+}
+
+func (e *ConfigError) formatWithCause(cause string) string {
+	if e == nil {
+		return "<nil ConfigError>"
+	}
+	key := ""
+	if e.Key != "" {
+		key = fmt.Sprintf("for '%s'", e.Key)
+	}
+	value := ""
+	if e.Value != nil {
+		value = fmt.Sprintf("(value: %s)", FormatValue(e.Value))
+	}
+	head := joinWords("invalid configuration", clause("in", e.Operation), key, value)
+	return joinDetails(e.Component, head, e.Message, cause)
+}
+
+func (e *ConfigError) causeError() error {
+	if e == nil {
+		return nil
+	}
+	return e.Err
+}
+
+func (e *ConfigError) Unwrap() []error {
+	if e == nil {
+		return nil
+	}
+	return causeList(e.Err, e.AdditionalCauses)
+}
+
+// StackTrace returns the stack from where the error was created. It
+// implements the cockroachdb/errors stack trace provider interface.
+func (e *ConfigError) StackTrace() errbase.StackTrace {
+	if e == nil {
+		return nil
+	}
+	return e.state.stackTrace()
+}
+
+// Is implements value matching for errors.Is: target matches when it is a
+// *ConfigError whose non-zero Key and Code equal e's, so
+// errors.Is(err, &ConfigError{Key: "backoff"}) matches any bad backoff.
+func (e *ConfigError) Is(target error) bool {
+	t, ok := target.(*ConfigError)
+	if e == nil || !ok || t == nil {
+		return false
+	}
+	return (t.Key == "" || t.Key == e.Key) &&
+		(t.Code == "" || t.Code == e.Code)
+}
+
+// IsRetryable always returns false: configuration doesn't fix itself.
+func (e *ConfigError) IsRetryable() bool {
+	return false
+}
+
+// NewConfigError creates a ConfigError for the setting key with automatic
+// stack trace. Set the offending value with WithValue.
+//
+// Example:
+//
+//	if n < 1 {
+//	    return errors.NewConfigError("must be at least 1", "max",
+//	        errors.WithValue(n), errors.WithOperation("ParseRetrySpec"))
+//	}
+func NewConfigError(message, key string, opts ...Option) error {
+	err := &ConfigError{
+		Message: message,
+		Key:     key,
+	}
+	applyOptions(err, opts)
+	return err
+}
+
+// IsConfigError checks if err is a ConfigError and returns it.
+func IsConfigError(err error) (*ConfigError, bool) {
+	var configErr *ConfigError
+	if errors.As(err, &configErr) && configErr != nil {
+		return configErr, true
+	}
+	return nil, false
+}
@@ -0,0 +1,145 @@
+package errors
+
+import (
+	"fmt"
+
+	"github.com/cockroachdb/errors"
+	"github.com/cockroachdb/errors/errbase"
+)
+
+// ConflictError represents a write that clashed with the current state of a
+// resource, such as an optimistic-concurrency version mismatch or a
+// uniqueness violation. It is permanent unless Retryable is set: callers
+// whose transaction re-reads before writing can mark it retryable with
+// WithRetryable(true). Automatically includes stack trace from creation
+// point.
+//
+// Maps to 409 and gRPC Aborted.
+type ConflictError struct {
+	Resource         string // kind of thing written, such as "order"
+	ID               string // identifier of the resource; empty when unknown
+	ExpectedVersion  string // version the writer based its change on
+	ActualVersion    string // version found at write time
+	Message          string
+	Component        string
+	Code             string
+	Owner            string
+	Retryable        bool
+	Err              error
+	AdditionalCauses []error
+	Metadata         map[string]any
+
+	state errorState
+}
+
+func (e *ConflictError) Error() string {
+	if e == nil {
+		return "<nil ConflictError>"
+	}
+	return e.formatWithCause(formatCauses(e.Err, e.AdditionalCauses))
+}
+
+func (e *ConflictError) formatWithCause(cause string) string {
+	if e == nil {
+		return "<nil ConflictError>"
+	}
+	versions := ""
+	if e.ExpectedVersion != "" || e.ActualVersion != "" {
+		versions = fmt.Sprintf("(expected version %s, actual %s)", e.ExpectedVersion, e.ActualVersion)
+	}
+	head := joinWords("conflict", clause("on", joinWords(e.Resource, e.ID)), versions)
+	return joinDetails(e.Component, head, e.Message, cause)
+}
+
+func (e *ConflictError) causeError() error {
+	if e == nil {
+		return nil
+	}
+	return e.Err
+}
+
+func (e *ConflictError) Unwrap() []error {
+	if e == nil {
+		return nil
+	}
+	return causeList(e.Err, e.AdditionalCauses)
+}
+
+// StackTrace returns the stack from where the error was created. It
+// implements the cockroachdb/errors stack trace provider interface.
+func (e *ConflictError) StackTrace() errbase.StackTrace {
+	if e == nil {
+		return nil
+	}
+	return e.state.stackTrace()
+}
+
+// Is implements value matching for errors.Is: target matches when it is a
+// *ConflictError whose non-zero Resource, ID, Message, Component and Code
+// all equal e's, so errors.Is(err, &ConflictError{Resource: "order"})
+// matches any conflict on an order. Versions, causes and metadata are not
+// compared.
+func (e *ConflictError) Is(target error) bool {
+	t, ok := target.(*ConflictError)
+	if e == nil || !ok || t == nil {
+		return false
+	}
+	return (t.Resource == "" || t.Resource == e.Resource) &&
+		(t.ID == "" || t.ID == e.ID) &&
+		(t.Message == "" || t.Message == e.Message) &&
+		(t.Component == "" || t.Component == e.Component) &&
+		(t.Code == "" || t.Code == e.Code)
+}
+
+// IsRetryable returns the Retryable flag. The cause isn't consulted: a
+// conflict only resolves by retrying when the caller re-reads first.
+func (e *ConflictError) IsRetryable() bool {
+	if e == nil {
+		return false
+	}
+	return e.Retryable
+}
+
+// NewConflictError creates a ConflictError with automatic stack trace.
+// id may be empty when the conflicting resource isn't known by ID, such as
+// a uniqueness violation on an email address.
+//
+// Example:
+//
+//	if rows == 0 {
+//	    return errors.NewConflictError("order", order.ID,
+//	        errors.WithVersions(order.Version, current.Version),
+//	        errors.WithRetryable(true))
+//	}
+func NewConflictError(resource, id string, opts ...Option) error {
+	err := &ConflictError{
+		Resource: resource,
+		ID:       id,
+	}
+	applyOptions(err, opts)
+	return err
+}
+
+// IsConflictError checks if err is a ConflictError and returns it.
+func IsConflictError(err error) (*ConflictError, bool) {
+	var conflictErr *ConflictError
+	if errors.As(err, &conflictErr) && conflictErr != nil {
+		return conflictErr, true
+	}
+	return nil, false
+}
+
+// IsConflict checks if an error represents a conflict with the current
+// state of a resource. Returns true for a ConflictError anywhere in the
+// chain and for an HTTPError with status code 409.
+func IsConflict(err error) bool {
+	if _, ok := IsConflictError(err); ok {
+		return true
+	}
+
+	if httpErr, ok := IsHTTPError(err); ok {
+		return httpErr.StatusCode == 409
+	}
+
+	return false
+}
@@ -0,0 +1,112 @@
+package errors
+
+import (
+	"fmt"
+	"testing"
+)
+
+// TestConflictError tests messages, mappings and the retryable opt-in
+func TestConflictError(t *testing.T) {
+	tests := []struct {
+		name          string
+		err           error
+		wantMessage   string
+		wantRetryable bool
+		wantClass     ErrorClass
+	}{
+		{
+			name:        "duplicate key",
+			err:         NewConflictError("user", "", WithMessage("email already registered")),
+			wantMessage: "conflict on user: email already registered",
+			wantClass:   ClassPermanent,
+		},
+		{
+			name:        "version mismatch",
+			err:         NewConflictError("order", "42", WithVersions("7", "8"), WithComponent("orders")),
+			wantMessage: "orders: conflict on order 42 (expected version 7, actual 8)",
+			wantClass:   ClassPermanent,
+		},
+		{
+			name:          "retryable optimistic transaction",
+			err:           Wrap(NewConflictError("order", "42", WithVersions("7", "8"), WithRetryable(true)), "saving order"),
+			wantMessage:   "saving order: conflict on order 42 (expected version 7, actual 8)",
+			wantRetryable: true,
+			wantClass:     ClassTransient,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.err.Error(); got != tt.wantMessage {
+				t.Errorf("Error() = %q, want %q", got, tt.wantMessage)
+			}
+			if HTTPStatus(tt.err) != 409 || ToGRPCStatus(tt.err) != GRPCAborted {
+				t.Errorf("HTTPStatus() = %d, ToGRPCStatus() = %d, want 409 and Aborted", HTTPStatus(tt.err), ToGRPCStatus(tt.err))
+			}
+			if !IsConflict(tt.err) {
+				t.Error("IsConflict() = false, want true")
+			}
+			if got := IsRetryable(tt.err); got != tt.wantRetryable {
+				t.Errorf("IsRetryable() = %v, want %v", got, tt.wantRetryable)
+			}
+			if got := IsPermanentError(tt.err); got == tt.wantRetryable {
+				t.Errorf("IsPermanentError() = %v, want %v", got, !tt.wantRetryable)
+			}
+			if got := Classify(tt.err); got != tt.wantClass {
+				t.Errorf("Classify() = %q, want %q", got, tt.wantClass)
+			}
+		})
+	}
+}
+
+// TestConflictErrorInfo tests that ExtractErrorInfo shows what clashed
+func TestConflictErrorInfo(t *testing.T) {
+	info := ExtractErrorInfo(NewConflictError("order", "42", WithVersions("7", "8")))
+	want := map[string]any{
+		"type": "ConflictError", "resource": "order", "resource_id": "42",
+		"expected_version": "7", "actual_version": "8", "retryable": false,
+	}
+	for key, value := range want {
+		if info[key] != value {
+			t.Errorf("info[%q] = %v, want %v", key, info[key], value)
+		}
+	}
+
+	info = ExtractErrorInfo(NewConflictError("user", ""))
+	for _, key := range []string{"resource_id", "expected_version", "actual_version"} {
+		if _, ok := info[key]; ok {
+			t.Errorf("info[%q] should be omitted when unset", key)
+		}
+	}
+}
+
+// TestIsConflict tests conflict detection across the chain
+func TestIsConflict(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{"nil error", nil, false},
+		{"unrelated error", fmt.Errorf("other"), false},
+		{"conflict error", fmt.Errorf("save: %w", NewConflictError("order", "42")), true},
+		{"http 409", NewHTTPError(409, "Conflict", nil), true},
+		{"http 412", NewHTTPError(412, "Precondition Failed", nil), false},
+		{"not found", NewNotFoundError("order", "42"), false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := IsConflict(tt.err); got != tt.want {
+				t.Errorf("IsConflict(%v) = %v, want %v", tt.err, got, tt.want)
+			}
+		})
+	}
+
+	if !Is(NewConflictError("order", "42", WithVersions("7", "8")), &ConflictError{Resource: "order"}) {
+		t.Error("a target with only Resource set should match any conflict on that resource")
+	}
+	decoded := Decode(Encode(NewConflictError("order", "42", WithVersions("7", "8"), WithRetryable(true))))
+	if conflictErr, ok := IsConflictError(decoded); !ok || conflictErr.ActualVersion != "8" || !IsRetryable(decoded) {
+		t.Errorf("decoded %v should keep versions and the retryable flag", decoded)
+	}
+}
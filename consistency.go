@@ -0,0 +1,156 @@
+package errors
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/cockroachdb/errors"
+	"github.com/cockroachdb/errors/errbase"
+)
+
+// ConsistencyError represents a read served by a replica that hasn't caught
+// up with a write the caller already made, breaking read-your-own-writes.
+// It is always retryable: against the primary when RetryAgainstPrimary is
+// set (see WithRetryAgainstPrimary), otherwise after LagEstimate, which is
+// its retry-after hint (see GetRetryAfter).
+// Automatically includes stack trace from creation point.
+//
+// Maps to 409 when the caller can route to the primary and 503 when it has
+// to wait, to gRPC FailedPrecondition either way, and to the
+// "replica_lag" metric class.
+type ConsistencyError struct {
+	Resource            string
+	MinVersion          string        // version the caller needs to see
+	ObservedVersion     string        // version the replica served
+	LagEstimate         time.Duration // how far behind the replica is thought to be
+	RetryAgainstPrimary bool          // the read can be re-routed to the primary
+	Message             string
+	Operation           string
+	Component           string
+	Code                string
+	Owner               string
+	Err                 error
+	AdditionalCauses    []error
+	Metadata            map[string]any
+
+	state errorState
+}
+
+func (e *ConsistencyError) Error() string {
+	if e == nil {
+		return "<nil ConsistencyError>"
+	}
+	return e.formatWithCause(formatCauses(e.Err, e.AdditionalCauses))
+}
+
+func (e *ConsistencyError) formatWithCause(cause string) string {
+	if e == nil {
+		return "<nil ConsistencyError>"
+	}
+	versions := fmt.Sprintf("(want version %s, observed %s", e.MinVersion, e.ObservedVersion)
+	if e.LagEstimate > 0 {
+		versions += fmt.Sprintf(", lag ~%v", e.LagEstimate)
+	}
+	head := joinWords("stale read", clause("of", e.Resource), versions+")")
+	return joinDetails(e.Component, head, e.Message, cause)
+}
+
+func (e *ConsistencyError) causeError() error {
+	if e == nil {
+		return nil
+	}
+	return e.Err
+}
+
+func (e *ConsistencyError) Unwrap() []error {
+	if e == nil {
+		return nil
+	}
+	return causeList(e.Err, e.AdditionalCauses)
+}
+
+// StackTrace returns the stack from where the error was created. It
+// implements the cockroachdb/errors stack trace provider interface.
+func (e *ConsistencyError) StackTrace() errbase.StackTrace {
+	if e == nil {
+		return nil
+	}
+	return e.state.stackTrace()
+}
+
+// IsRetryable returns true - the replica catches up, or the read moves to
+// the primary.
+func (e *ConsistencyError) IsRetryable() bool {
+	return e != nil
+}
+
+// NewConsistencyError creates a ConsistencyError with automatic stack
+// trace. lagEstimate may be zero when the lag is unknown.
+//
+// Example:
+//
+//	if row.Version < token.Version {
+//	    return errors.NewConsistencyError("order/42", token.Version, row.Version,
+//	        replica.Lag(), errors.WithRetryAgainstPrimary())
+//	}
+func NewConsistencyError(resource, minVersion, observedVersion string, lagEstimate time.Duration, opts ...Option) error {
+	err := &ConsistencyError{
+		Resource:        resource,
+		MinVersion:      minVersion,
+		ObservedVersion: observedVersion,
+		LagEstimate:     lagEstimate,
+	}
+	applyOptions(err, opts)
+	return err
+}
+
+// IsConsistencyError checks if err is a ConsistencyError and returns it.
+func IsConsistencyError(err error) (*ConsistencyError, bool) {
+	var consistencyErr *ConsistencyError
+	if errors.As(err, &consistencyErr) && consistencyErr != nil {
+		return consistencyErr, true
+	}
+	return nil, false
+}
+
+func isConsistencyError(err error) bool {
+	_, ok := IsConsistencyError(err)
+	return ok
+}
+
+// ShouldRetryAgainstPrimary reports whether err's chain holds a
+// ConsistencyError whose read can be re-routed to the primary. Retry loops
+// pass the hint to the next attempt with ContextWithPrimaryRead.
+//
+// Example:
+//
+//	if errors.ShouldRetryAgainstPrimary(err) {
+//	    ctx = errors.ContextWithPrimaryRead(ctx)
+//	}
+func ShouldRetryAgainstPrimary(err error) bool {
+	consistencyErr, ok := IsConsistencyError(err)
+	return ok && consistencyErr.RetryAgainstPrimary
+}
+
+type primaryReadKey struct{}
+
+// ContextWithPrimaryRead returns a context asking the operation to read
+// from the primary rather than a replica (see PrimaryReadRequested).
+func ContextWithPrimaryRead(ctx context.Context) context.Context {
+	return context.WithValue(ctx, primaryReadKey{}, true)
+}
+
+// PrimaryReadRequested reports whether ctx asks for reads from the primary,
+// as set by ContextWithPrimaryRead after a ConsistencyError.
+//
+// Example:
+//
+//	db := replica
+//	if errors.PrimaryReadRequested(ctx) {
+//	    db = primary
+//	}
+func PrimaryReadRequested(ctx context.Context) bool {
+	requested, _ := ctx.Value(primaryReadKey{}).(bool)
+	return requested
+}
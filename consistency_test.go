@@ -0,0 +1,83 @@
+package errors
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+// TestConsistencyError tests the mappings chosen by whether the read can move to the primary
+func TestConsistencyError(t *testing.T) {
+	tests := []struct {
+		name        string
+		err         error
+		wantMessage string
+		wantStatus  int
+		wantPrimary bool
+	}{
+		{
+			name:        "wait for replication",
+			err:         NewConsistencyError("order/42", "17", "15", 2*time.Second),
+			wantMessage: "stale read of order/42 (want version 17, observed 15, lag ~2s)",
+			wantStatus:  503,
+		},
+		{
+			name: "retry against primary",
+			err: NewConsistencyError("order/42", "17", "15", 0,
+				WithRetryAgainstPrimary(), WithComponent("orders")),
+			wantMessage: "orders: stale read of order/42 (want version 17, observed 15)",
+			wantStatus:  409, wantPrimary: true,
+		},
+		{
+			name:        "wrapped",
+			err:         Wrap(NewConsistencyError("cart/7", "3", "2", time.Second, WithRetryAgainstPrimary()), "load cart"),
+			wantMessage: "load cart: stale read of cart/7 (want version 3, observed 2, lag ~1s)",
+			wantStatus:  409, wantPrimary: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.err.Error(); got != tt.wantMessage {
+				t.Errorf("Error() = %q, want %q", got, tt.wantMessage)
+			}
+			if got := HTTPStatus(tt.err); got != tt.wantStatus {
+				t.Errorf("HTTPStatus() = %d, want %d", got, tt.wantStatus)
+			}
+			if got := ToGRPCStatus(tt.err); got != GRPCFailedPrecondition {
+				t.Errorf("ToGRPCStatus() = %d, want FailedPrecondition", got)
+			}
+			if got := ShouldRetryAgainstPrimary(tt.err); got != tt.wantPrimary {
+				t.Errorf("ShouldRetryAgainstPrimary() = %v, want %v", got, tt.wantPrimary)
+			}
+			if !IsRetryable(tt.err) {
+				t.Error("IsRetryable() = false, want true")
+			}
+			if got := MetricLabels(tt.err)[LabelErrorClass]; got != ClassReplicaLag {
+				t.Errorf("error_class = %q, want %q", got, ClassReplicaLag)
+			}
+		})
+	}
+}
+
+// TestConsistencyErrorRetryAfter tests that the lag estimate is the retry hint
+func TestConsistencyErrorRetryAfter(t *testing.T) {
+	err := NewConsistencyError("order/42", "17", "15", 1500*time.Millisecond)
+	if wait, ok := GetRetryAfter(err); !ok || wait != 1500*time.Millisecond {
+		t.Errorf("GetRetryAfter() = %v, %v, want 1.5s", wait, ok)
+	}
+	if _, ok := GetRetryAfter(NewConsistencyError("order/42", "17", "15", 0)); ok {
+		t.Error("GetRetryAfter() found a hint for an unknown lag")
+	}
+}
+
+// TestPrimaryReadContext tests carrying the retry-against-primary hint on a context
+func TestPrimaryReadContext(t *testing.T) {
+	ctx := context.Background()
+	if PrimaryReadRequested(ctx) {
+		t.Error("PrimaryReadRequested() = true for a plain context")
+	}
+	if !PrimaryReadRequested(ContextWithPrimaryRead(ctx)) {
+		t.Error("PrimaryReadRequested() = false after ContextWithPrimaryRead")
+	}
+}
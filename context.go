@@ -0,0 +1,288 @@
+package errors
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// Metadata keys populated from context by the built-in trace enricher.
+const (
+	MetadataTraceID = "trace_id"
+	MetadataSpanID  = "span_id"
+)
+
+// ContextEnricher extracts metadata from a context. Ctx-aware constructors
+// (WithContext, NewHTTPErrorCtx, WrapCtx) run every registered enricher and
+// store the results in the new error's metadata. Enrichers run on the error
+// path, so they must be fast and must not block.
+type ContextEnricher func(ctx context.Context) map[string]any
+
+var (
+	enrichersMu sync.RWMutex
+	enrichers   = defaultEnrichers()
+)
+
+func defaultEnrichers() []ContextEnricher {
+	return []ContextEnricher{traceEnricher}
+}
+
+// RegisterContextEnricher adds an enricher run by ctx-aware constructors.
+// Enrichers run in registration order; later enrichers overwrite keys set
+// by earlier ones. Tracing adapters use this to record the active span.
+//
+// Example:
+//
+//	errors.RegisterContextEnricher(func(ctx context.Context) map[string]any {
+//	    if tenant, ok := ctx.Value(tenantKey{}).(string); ok {
+//	        return map[string]any{"tenant": tenant}
+//	    }
+//	    return nil
+//	})
+func RegisterContextEnricher(fn ContextEnricher) {
+	enrichersMu.Lock()
+	defer enrichersMu.Unlock()
+	enrichers = append(enrichers, fn)
+}
+
+// ResetContextEnrichers removes all registered enrichers, restoring the
+// built-in ones. Intended for tests.
+func ResetContextEnrichers() {
+	enrichersMu.Lock()
+	defer enrichersMu.Unlock()
+	enrichers = defaultEnrichers()
+}
+
+// enrich collects metadata from every registered enricher.
+func enrich(ctx context.Context) map[string]any {
+	if ctx == nil {
+		return nil
+	}
+
+	enrichersMu.RLock()
+	defer enrichersMu.RUnlock()
+
+	var metadata map[string]any
+	for _, fn := range enrichers {
+		for k, v := range fn(ctx) {
+			if metadata == nil {
+				metadata = make(map[string]any)
+			}
+			metadata[k] = v
+		}
+	}
+	return metadata
+}
+
+type traceContextKey struct{}
+
+type traceContext struct {
+	traceID string
+	spanID  string
+}
+
+// ContextWithTrace returns a copy of ctx carrying the given trace and span
+// IDs, which ctx-aware constructors record as the error's origin trace.
+// Tracing adapters can register their own enricher instead.
+func ContextWithTrace(ctx context.Context, traceID, spanID string) context.Context {
+	return context.WithValue(ctx, traceContextKey{}, traceContext{traceID: traceID, spanID: spanID})
+}
+
+// ContextWithTraceID returns a copy of ctx carrying the request's trace ID
+// without a span ID, for services that correlate logs by a request or
+// correlation ID rather than a tracing span. Ctx-aware constructors record
+// it as the error's trace ID (see GetTraceID).
+//
+// Example:
+//
+//	ctx = errors.ContextWithTraceID(r.Context(), r.Header.Get("X-Request-ID"))
+func ContextWithTraceID(ctx context.Context, traceID string) context.Context {
+	return ContextWithTrace(ctx, traceID, "")
+}
+
+func traceEnricher(ctx context.Context) map[string]any {
+	tc, ok := ctx.Value(traceContextKey{}).(traceContext)
+	if !ok || tc.traceID == "" {
+		return nil
+	}
+	metadata := map[string]any{MetadataTraceID: tc.traceID}
+	if tc.spanID != "" {
+		metadata[MetadataSpanID] = tc.spanID
+	}
+	return metadata
+}
+
+type componentContextKey struct{}
+
+// ContextWithComponent returns a copy of ctx naming the component handling
+// it. Ctx-aware constructors set it as the error's Component unless the
+// error already has one (see WithComponent).
+//
+// Example:
+//
+//	ctx = errors.ContextWithComponent(ctx, "billing")
+//	err := errors.NewProcessingErrorCtx(ctx, "Failed to charge", "Charge")
+//	errors.GetComponent(err) // "billing"
+func ContextWithComponent(ctx context.Context, component string) context.Context {
+	return context.WithValue(ctx, componentContextKey{}, component)
+}
+
+// componentFromContext returns the component set with ContextWithComponent.
+func componentFromContext(ctx context.Context) string {
+	if ctx == nil {
+		return ""
+	}
+	component, _ := ctx.Value(componentContextKey{}).(string)
+	return component
+}
+
+// WithContext records metadata from ctx (via the registered enrichers) on
+// the error, such as the originating trace and span IDs, sets the component
+// from ctx (see ContextWithComponent) unless one is set, and records the
+// finished error on ctx's span when span recording is enabled (see
+// EnableSpanRecording).
+// Applies to all error types that have a Metadata field.
+//
+// Example:
+//
+//	err := NewProcessingError("Failed to charge card", "Charge",
+//	    WithContext(ctx))
+func WithContext(ctx context.Context) Option {
+	return func(err any) {
+		if cfg, ok := err.(*constructConfig); ok {
+			cfg.ctx = ctx
+			return
+		}
+		for k, v := range enrich(ctx) {
+			setMetadata(err, k, v)
+		}
+		if e, ok := err.(error); ok && componentOf(e) == "" {
+			if component := componentFromContext(ctx); component != "" {
+				WithComponent(component)(err)
+			}
+		}
+	}
+}
+
+// WithTraceID records traceID as the error's trace ID, for callers that
+// have the ID at hand rather than in a context (see ContextWithTraceID).
+// Applies to all error types that have a Metadata field.
+//
+// Example:
+//
+//	err := NewProcessingError("Failed to charge card", "Charge",
+//	    WithTraceID(job.TraceID))
+func WithTraceID(traceID string) Option {
+	return func(err any) {
+		if traceID != "" {
+			setMetadata(err, MetadataTraceID, traceID)
+		}
+	}
+}
+
+// GetTraceID returns the trace ID recorded outermost in err's chain, which
+// is the trace of the request that last handled the error, or "" when none
+// was recorded. GetOriginTrace returns the innermost one instead.
+//
+// Example:
+//
+//	ctx = errors.ContextWithTraceID(ctx, "4bf92f35")
+//	err := errors.WrapCtx(ctx, repo.Load(id), "loading order")
+//	errors.GetTraceID(err) // "4bf92f35"
+func GetTraceID(err error) string {
+	value, _ := GetMetadata(err, MetadataTraceID)
+	traceID, _ := value.(string)
+	return traceID
+}
+
+// NewHTTPErrorCtx creates an HTTPError carrying metadata from ctx.
+func NewHTTPErrorCtx(ctx context.Context, statusCode int, message string, cause error) error {
+	err := NewHTTPError(statusCode, message, cause)
+	WithContext(ctx)(err)
+	if goroutineCaptureRequested(err) {
+		captureGoroutine(ctx, err)
+	}
+	recordTypedOnSpan(ctx, err)
+	return err
+}
+
+// NewProcessingErrorCtx creates a ProcessingError carrying the trace ID,
+// component and other metadata from ctx, like NewProcessingError with
+// WithContext(ctx) as its first option.
+//
+// Example:
+//
+//	err := errors.NewProcessingErrorCtx(ctx, "Failed to enrich activity", "EnrichActivity",
+//	    errors.WithItemID(activity.ID))
+func NewProcessingErrorCtx(ctx context.Context, message, operation string, opts ...Option) error {
+	return NewProcessingError(message, operation, withContextFirst(ctx, opts)...)
+}
+
+// NewValidationErrorCtx creates a ValidationError carrying metadata from
+// ctx, like NewValidationError with WithContext(ctx) as its first option.
+func NewValidationErrorCtx(ctx context.Context, message, field string, opts ...Option) error {
+	return NewValidationError(message, field, withContextFirst(ctx, opts)...)
+}
+
+// NewTimeoutErrorCtx creates a TimeoutError carrying metadata from ctx,
+// like NewTimeoutError with WithContext(ctx) as its first option.
+func NewTimeoutErrorCtx(ctx context.Context, message, operation string, duration time.Duration, opts ...Option) error {
+	return NewTimeoutError(message, operation, duration, withContextFirst(ctx, opts)...)
+}
+
+// NewNetworkErrorCtx creates a NetworkError carrying metadata from ctx,
+// like NewNetworkError with WithContext(ctx) as its first option.
+func NewNetworkErrorCtx(ctx context.Context, message, operation string, opts ...Option) error {
+	return NewNetworkError(message, operation, withContextFirst(ctx, opts)...)
+}
+
+// withContextFirst puts WithContext(ctx) ahead of opts, so explicit options
+// such as WithTraceID win over what ctx carries.
+func withContextFirst(ctx context.Context, opts []Option) []Option {
+	return append([]Option{WithContext(ctx)}, opts...)
+}
+
+// WrapCtx annotates err with a message and stack trace, like Wrap, and
+// records metadata from ctx. When span recording is enabled and err hasn't
+// been recorded yet, the result is recorded on ctx's span. Returns nil if
+// err is nil.
+func WrapCtx(ctx context.Context, err error, message string) error {
+	wrapped := Wrap(err, message)
+	if wrapped == nil {
+		return nil
+	}
+
+	metadata := enrich(ctx)
+	if len(metadata) == 0 && (!spanRecordingActive() || spanRecordedIn(wrapped)) {
+		return wrapped
+	}
+
+	result := &metadataError{cause: wrapped, metadata: metadata}
+	result.spanRecorded = recordOnSpan(ctx, result)
+	return result
+}
+
+// GetOriginTrace returns the trace and span IDs recorded when the innermost
+// error carrying them was created, so a service handling an error minted
+// elsewhere can link back to the originating span. Returns ok=false when no
+// error in the chain was created with trace context.
+//
+// Example:
+//
+//	if traceID, spanID, ok := GetOriginTrace(err); ok {
+//	    span.AddLink(linkFor(traceID, spanID))
+//	}
+func GetOriginTrace(err error) (traceID, spanID string, ok bool) {
+	walkChain(err, func(node error, _ int) bool {
+		field := metadataField(node)
+		if field == nil {
+			return true
+		}
+		if id, isString := (*field)[MetadataTraceID].(string); isString && id != "" {
+			traceID, ok = id, true
+			spanID, _ = (*field)[MetadataSpanID].(string)
+		}
+		return true
+	})
+	return traceID, spanID, ok
+}
@@ -0,0 +1,78 @@
+package errors
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+)
+
+// TestGetTraceID tests trace IDs set by option and by context, and that the outermost one wins
+func TestGetTraceID(t *testing.T) {
+	ctx := ContextWithTraceID(context.Background(), "req-1")
+
+	tests := []struct {
+		name string
+		err  error
+		want string
+	}{
+		{name: "nil error", err: nil, want: ""},
+		{name: "no trace", err: NewNetworkError("down", "Dial"), want: ""},
+		{name: "option", err: NewNetworkError("down", "Dial", WithTraceID("req-2")), want: "req-2"},
+		{name: "empty option ignored", err: NewNetworkError("down", "Dial", WithTraceID("")), want: ""},
+		{name: "processing ctx", err: NewProcessingErrorCtx(ctx, "failed", "Run"), want: "req-1"},
+		{name: "validation ctx", err: NewValidationErrorCtx(ctx, "bad", "email"), want: "req-1"},
+		{name: "timeout ctx", err: NewTimeoutErrorCtx(ctx, "slow", "Quote", time.Second), want: "req-1"},
+		{name: "network ctx", err: NewNetworkErrorCtx(ctx, "down", "Dial"), want: "req-1"},
+		{name: "explicit option beats ctx", err: NewProcessingErrorCtx(ctx, "failed", "Run", WithTraceID("req-2")), want: "req-2"},
+		{name: "wrapped", err: Wrap(NewNetworkErrorCtx(ctx, "down", "Dial"), "outer"), want: "req-1"},
+		{name: "wrapped with ctx", err: WrapCtx(ctx, fmt.Errorf("plain"), "outer"), want: "req-1"},
+		{
+			name: "outermost wins",
+			err: NewProcessingError("failed", "Run", WithTraceID("req-outer"),
+				WithCause(NewNetworkError("down", "Dial", WithTraceID("req-inner")))),
+			want: "req-outer",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := GetTraceID(tt.err); got != tt.want {
+				t.Errorf("GetTraceID() = %q, want %q", got, tt.want)
+			}
+			info := ExtractErrorInfo(tt.err)
+			if got, ok := info["trace_id"]; ok != (tt.want != "") || (ok && got != tt.want) {
+				t.Errorf("ExtractErrorInfo()[trace_id] = %v, want %q", got, tt.want)
+			}
+		})
+	}
+
+	if _, ok := GetMetadata(NewNetworkErrorCtx(ctx, "down", "Dial"), MetadataSpanID); ok {
+		t.Error("ContextWithTraceID should not record an empty span ID")
+	}
+}
+
+// TestContextWithComponent tests that ctx-aware constructors take the component from the context unless one is set
+func TestContextWithComponent(t *testing.T) {
+	ctx := ContextWithComponent(context.Background(), "billing")
+
+	tests := []struct {
+		name string
+		err  error
+		want string
+	}{
+		{name: "from ctx", err: NewProcessingErrorCtx(ctx, "failed", "Charge"), want: "billing"},
+		{name: "option after ctx wins", err: NewProcessingErrorCtx(ctx, "failed", "Charge", WithComponent("ledger")), want: "ledger"},
+		{name: "option before ctx wins", err: NewNetworkError("down", "Dial", WithComponent("ledger"), WithContext(ctx)), want: "ledger"},
+		{name: "http ctx", err: NewHTTPErrorCtx(ctx, 502, "Bad Gateway", nil), want: "billing"},
+		{name: "no component in ctx", err: NewProcessingErrorCtx(context.Background(), "failed", "Charge"), want: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := GetComponent(tt.err); got != tt.want {
+				t.Errorf("GetComponent() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
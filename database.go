@@ -0,0 +1,180 @@
+package errors
+
+import (
+	"fmt"
+
+	"github.com/cockroachdb/errors"
+	"github.com/cockroachdb/errors/errbase"
+)
+
+// SQLSTATE codes ClassifyDBError and DatabaseError treat as retryable: the
+// transaction lost a race and succeeds when run again.
+const (
+	SQLStateSerializationFailure = "40001"
+	SQLStateDeadlockDetected     = "40P01"
+	SQLStateLockNotAvailable     = "55P03"
+)
+
+var retryableSQLStates = map[string]bool{
+	SQLStateSerializationFailure: true,
+	SQLStateDeadlockDetected:     true,
+	SQLStateLockNotAvailable:     true,
+}
+
+// sqlStateError is implemented by Postgres driver errors, both
+// *pgconn.PgError and *pq.Error, so SQLSTATE codes can be read without
+// depending on a driver.
+type sqlStateError interface {
+	error
+	SQLState() string
+}
+
+// DatabaseError represents a failed database statement. Retryability
+// follows the SQLSTATE: serialization failures, deadlocks and lock
+// timeouts are retryable and match ErrDeadlock for errors.Is, everything
+// else is not. Automatically includes stack trace from creation point.
+type DatabaseError struct {
+	Message          string
+	Operation        string
+	Table            string
+	SQLState         string // five-character SQLSTATE code, such as "40P01"
+	Component        string
+	Code             string
+	Owner            string
+	Err              error
+	AdditionalCauses []error
+	Metadata         map[string]any
+
+	state errorState
+}
+
+func (e *DatabaseError) Error() string {
+	if e == nil {
+		return "<nil DatabaseError>"
+	}
+	return e.formatWithCause(formatCauses(e.Err, e.AdditionalCauses))
+}
+
+func (e *DatabaseError) formatWithCause(cause string) string {
+	if e == nil {
+		return "<nil DatabaseError>"
+	}
+	msgStr := e.Message
+	if msgStr == "" {
+		msgStr = "database error"
+	}
+	sqlState := ""
+	if e.SQLState != "" {
+		sqlState = fmt.Sprintf("(SQLSTATE %s)", e.SQLState)
+	}
+	head := joinWords(msgStr, clause("in", e.Operation), clause("on", e.Table), sqlState)
+	return joinDetails(e.Component, head, cause)
+}
+
+func (e *DatabaseError) causeError() error {
+	if e == nil {
+		return nil
+	}
+	return e.Err
+}
+
+func (e *DatabaseError) Unwrap() []error {
+	if e == nil {
+		return nil
+	}
+	return causeList(e.Err, e.AdditionalCauses)
+}
+
+// StackTrace returns the stack from where the error was created. It
+// implements the cockroachdb/errors stack trace provider interface.
+func (e *DatabaseError) StackTrace() errbase.StackTrace {
+	if e == nil {
+		return nil
+	}
+	return e.state.stackTrace()
+}
+
+// Is reports whether target is ErrDeadlock and the SQLSTATE is retryable,
+// so code checking errors.Is(err, ErrDeadlock) keeps working for typed
+// database errors.
+func (e *DatabaseError) Is(target error) bool {
+	return e != nil && target == ErrDeadlock && retryableSQLStates[e.SQLState]
+}
+
+// IsRetryable returns true only for serialization failures (40001),
+// deadlocks (40P01) and lock timeouts (55P03). The cause isn't consulted,
+// so a driver message mentioning a timeout doesn't make a constraint
+// violation retryable.
+func (e *DatabaseError) IsRetryable() bool {
+	if e == nil {
+		return false
+	}
+	return retryableSQLStates[e.SQLState]
+}
+
+// NewDatabaseError creates a DatabaseError with automatic stack trace. When
+// WithSQLState isn't given, the SQLSTATE is read from a driver error in the
+// cause's chain (see ClassifyDBError).
+//
+// Example:
+//
+//	if _, err := tx.Exec(ctx, query, args...); err != nil {
+//	    return errors.NewDatabaseError("UpdateOrder", "orders", errors.WithCause(err))
+//	}
+func NewDatabaseError(operation, table string, opts ...Option) error {
+	err := &DatabaseError{
+		Operation: operation,
+		Table:     table,
+	}
+	applyOptions(err, opts)
+	if err.SQLState == "" {
+		err.SQLState = sqlStateOf(err.Err)
+	}
+	return err
+}
+
+// IsDatabaseError checks if err is a DatabaseError and returns it.
+func IsDatabaseError(err error) (*DatabaseError, bool) {
+	var dbErr *DatabaseError
+	if errors.As(err, &dbErr) && dbErr != nil {
+		return dbErr, true
+	}
+	return nil, false
+}
+
+// ClassifyDBError converts a Postgres driver error into a DatabaseError
+// carrying its SQLSTATE, so deadlocks and serialization failures are
+// retryable without matching on "deadlock detected". Errors from pgx
+// (*pgconn.PgError) and lib/pq (*pq.Error) are recognized through their
+// SQLState method, anywhere in err's chain. Returns nil for nil, and err
+// unchanged when it already holds a DatabaseError or no driver error.
+//
+// Example:
+//
+//	err := pool.QueryRow(ctx, query, id).Scan(&order)
+//	if err != nil {
+//	    return errors.ClassifyDBError(err) // 40P01 is now retryable
+//	}
+func ClassifyDBError(err error) error {
+	if err == nil {
+		return nil
+	}
+	if _, ok := IsDatabaseError(err); ok {
+		return err
+	}
+	state := sqlStateOf(err)
+	if state == "" {
+		return err
+	}
+	return NewDatabaseError("", "", WithCause(err), WithSQLState(state))
+}
+
+// sqlStateOf returns the SQLSTATE of the first driver error in err's
+// chain, or "" if there is none.
+func sqlStateOf(err error) string {
+	var driverErr sqlStateError
+	if err == nil || !errors.As(err, &driverErr) {
+		return ""
+	}
+	return driverErr.SQLState()
+}
@@ -0,0 +1,111 @@
+package errors
+
+import (
+	"fmt"
+	"testing"
+)
+
+// fakePgError has the shape of *pgconn.PgError: a string Code and a SQLState method.
+type fakePgError struct {
+	Severity string
+	Code     string
+	Message  string
+}
+
+func (e *fakePgError) Error() string {
+	return e.Severity + ": " + e.Message + " (SQLSTATE " + e.Code + ")"
+}
+
+func (e *fakePgError) SQLState() string { return e.Code }
+
+// fakePqErrorCode mirrors pq.ErrorCode.
+type fakePqErrorCode string
+
+// fakePqError has the shape of *pq.Error: a named Code type and a SQLState method.
+type fakePqError struct {
+	Code    fakePqErrorCode
+	Message string
+}
+
+func (e *fakePqError) Error() string    { return "pq: " + e.Message }
+func (e *fakePqError) SQLState() string { return string(e.Code) }
+
+// TestClassifyDBError tests SQLSTATE recognition over pgconn- and pq-shaped driver errors
+func TestClassifyDBError(t *testing.T) {
+	tests := []struct {
+		name          string
+		err           error
+		wantState     string
+		wantRetryable bool
+	}{
+		{"pgconn deadlock", &fakePgError{Severity: "ERROR", Code: "40P01", Message: "deadlock detected"}, "40P01", true},
+		{"pgconn serialization failure", &fakePgError{Code: "40001", Message: "could not serialize access"}, "40001", true},
+		{"pq lock not available", &fakePqError{Code: "55P03", Message: "could not obtain lock"}, "55P03", true},
+		{"wrapped pq deadlock", fmt.Errorf("reserving stock: %w", &fakePqError{Code: "40P01", Message: "deadlock detected"}), "40P01", true},
+		{"pgconn unique violation", &fakePgError{Code: "23505", Message: "duplicate key value"}, "23505", false},
+		{"pq undefined table", &fakePqError{Code: "42P01", Message: "relation does not exist"}, "42P01", false},
+		{"timeout-sounding message", &fakePgError{Code: "57014", Message: "statement timeout"}, "57014", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ClassifyDBError(tt.err)
+			dbErr, ok := IsDatabaseError(err)
+			if !ok {
+				t.Fatalf("ClassifyDBError() = %T, want a DatabaseError", err)
+			}
+			if dbErr.SQLState != tt.wantState {
+				t.Errorf("SQLState = %q, want %q", dbErr.SQLState, tt.wantState)
+			}
+			if got := IsRetryable(err); got != tt.wantRetryable {
+				t.Errorf("IsRetryable() = %v, want %v", got, tt.wantRetryable)
+			}
+			if got := Is(err, ErrDeadlock); got != tt.wantRetryable {
+				t.Errorf("Is(ErrDeadlock) = %v, want %v", got, tt.wantRetryable)
+			}
+			if !Is(err, tt.err) {
+				t.Error("the driver error should stay in the chain")
+			}
+			if Adopt(tt.err).Error() != err.Error() {
+				t.Errorf("Adopt() = %q, want the ClassifyDBError conversion %q", Adopt(tt.err), err)
+			}
+		})
+	}
+
+	plain := fmt.Errorf("deadlock detected")
+	if ClassifyDBError(nil) != nil || ClassifyDBError(plain) != plain {
+		t.Error("ClassifyDBError() should pass through nil and errors without a SQLSTATE")
+	}
+	typed := NewDatabaseError("UpdateOrder", "orders", WithSQLState("23505"))
+	if ClassifyDBError(typed) != typed {
+		t.Error("ClassifyDBError() should leave an existing DatabaseError unchanged")
+	}
+}
+
+// TestDatabaseError tests the message, SQLSTATE derivation and error info
+func TestDatabaseError(t *testing.T) {
+	driverErr := &fakePgError{Severity: "ERROR", Code: "40P01", Message: "deadlock detected"}
+	err := NewDatabaseError("UpdateOrder", "orders", WithCause(driverErr), WithComponent("orders-repo"))
+
+	if want := "orders-repo: database error in UpdateOrder on orders (SQLSTATE 40P01): ERROR: deadlock detected (SQLSTATE 40P01)"; err.Error() != want {
+		t.Errorf("Error() = %q, want %q", err.Error(), want)
+	}
+	if HTTPStatus(err) != 503 || Classify(err) != ClassTransient || FailureKindOf(err) != FailureDeadlock {
+		t.Errorf("HTTPStatus() = %d, Classify() = %q, want 503 and transient", HTTPStatus(err), Classify(err))
+	}
+
+	info := ExtractErrorInfo(err)
+	if info["type"] != "DatabaseError" || info["table"] != "orders" || info["sqlstate"] != "40P01" || info["retryable"] != true {
+		t.Errorf("ExtractErrorInfo() = %v, want table, sqlstate and retryable", info)
+	}
+
+	override := NewDatabaseError("UpdateOrder", "orders", WithCause(driverErr), WithSQLState("23505"))
+	if IsRetryable(override) || HTTPStatus(override) != 500 {
+		t.Error("WithSQLState should override the state read from the cause")
+	}
+
+	decoded := Decode(Encode(err))
+	if !Is(decoded, ErrDeadlock) || !IsRetryable(decoded) {
+		t.Errorf("decoded %v should keep its SQLSTATE and retryability", decoded)
+	}
+}
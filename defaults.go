@@ -0,0 +1,79 @@
+package errors
+
+import (
+	"slices"
+	"sync/atomic"
+)
+
+// defaultOptions holds the options set by SetDefaultOptions, or nil.
+var defaultOptions atomic.Pointer[[]Option]
+
+// SetDefaultOptions sets options applied to every error this package
+// constructs, before the call site's own options so call sites win. Wrap,
+// Wrapf and WrapCtx apply them too when the wrapped chain holds no typed
+// error, so foreign errors get the same stamp; only options that set
+// metadata have an effect there. Call it once at startup; constructors
+// running concurrently see either the old or the new set, never a mix.
+// NewHTTPErrorFast and the pooled builders skip defaults to stay
+// allocation free.
+//
+// Defaults show up wherever the options' fields do, for instance as
+// metadata in ExtractErrorInfo.
+//
+// Example:
+//
+//	errors.SetDefaultOptions(
+//	    errors.WithMetadata("service", "orders"),
+//	    errors.WithMetadata("version", buildVersion),
+//	    errors.WithMetadata("env", os.Getenv("ENV")),
+//	)
+func SetDefaultOptions(opts ...Option) {
+	if len(opts) == 0 {
+		defaultOptions.Store(nil)
+		return
+	}
+	stored := slices.Clone(opts)
+	defaultOptions.Store(&stored)
+}
+
+// ResetDefaultOptions removes the options set by SetDefaultOptions.
+// Intended for tests.
+func ResetDefaultOptions() {
+	defaultOptions.Store(nil)
+}
+
+// withDefaultOptions returns opts preceded by the default options.
+func withDefaultOptions(opts []Option) []Option {
+	defaults := defaultOptions.Load()
+	if defaults == nil {
+		return opts
+	}
+	return append(slices.Clip(*defaults), opts...)
+}
+
+// withDefaults applies the default options to err, a result of Wrap, when
+// no error in its chain could have received them from a constructor.
+func withDefaults(err error) error {
+	defaults := defaultOptions.Load()
+	if err == nil || defaults == nil {
+		return err
+	}
+
+	stamped := false
+	walkChain(err, func(node error, _ int) bool {
+		stamped = metadataField(node) != nil
+		return !stamped
+	})
+	if stamped {
+		return err
+	}
+
+	result := &metadataError{cause: err}
+	for _, opt := range *defaults {
+		opt(result)
+	}
+	if len(result.metadata) == 0 {
+		return err
+	}
+	return result
+}
@@ -0,0 +1,91 @@
+package errors
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+)
+
+// TestDefaultOptions tests that defaults stamp every constructed error and call sites override them
+func TestDefaultOptions(t *testing.T) {
+	defer ResetDefaultOptions()
+	SetDefaultOptions(WithMetadata("service", "orders"), WithComponent("orders-api"))
+
+	tests := []struct {
+		name          string
+		err           error
+		wantService   any
+		wantComponent string
+	}{
+		{
+			name:        "typed error",
+			err:         NewValidationError("Invalid email", "email"),
+			wantService: "orders", wantComponent: "orders-api",
+		},
+		{
+			name:        "call site wins",
+			err:         NewTimeoutError("timed out", "Fetch", 0, WithMetadata("service", "billing"), WithComponent("billing-api")),
+			wantService: "billing", wantComponent: "billing-api",
+		},
+		{
+			name:        "wrapped foreign error",
+			err:         Wrap(fmt.Errorf("connection reset"), "query orders"),
+			wantService: "orders",
+		},
+		{
+			name:        "wrapf",
+			err:         Wrapf(fmt.Errorf("connection reset"), "query %s", "orders"),
+			wantService: "orders",
+		},
+		{
+			name:        "wrapped typed error keeps its own stamp",
+			err:         Wrap(NewNetworkError("dial failed", "Connect", WithMetadata("service", "billing")), "outer"),
+			wantService: "billing", wantComponent: "orders-api",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			info := ExtractErrorInfo(tt.err)
+			metadata, _ := info["metadata"].(map[string]any)
+			if got := metadata["service"]; got != tt.wantService {
+				t.Errorf("metadata service = %v, want %v", got, tt.wantService)
+			}
+			if got := GetComponent(tt.err); got != tt.wantComponent {
+				t.Errorf("GetComponent() = %q, want %q", got, tt.wantComponent)
+			}
+		})
+	}
+}
+
+// TestResetDefaultOptions tests that defaults don't outlive a reset
+func TestResetDefaultOptions(t *testing.T) {
+	SetDefaultOptions(WithMetadata("service", "orders"))
+	ResetDefaultOptions()
+
+	if _, ok := GetMetadata(NewHTTPError(500, "Internal Server Error", nil), "service"); ok {
+		t.Error("typed error stamped after ResetDefaultOptions")
+	}
+	if _, ok := Wrap(fmt.Errorf("connection reset"), "query").(*metadataError); ok {
+		t.Error("Wrap added metadata after ResetDefaultOptions")
+	}
+}
+
+// TestDefaultOptionsConcurrent tests that constructors race safely with SetDefaultOptions
+func TestDefaultOptionsConcurrent(t *testing.T) {
+	defer ResetDefaultOptions()
+
+	var wg sync.WaitGroup
+	for i := range 8 {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			SetDefaultOptions(WithMetadata("service", fmt.Sprint("svc-", i)))
+		}()
+		go func() {
+			defer wg.Done()
+			_ = NewProcessingError("failed", "Process", WithMetadata("item", i))
+		}()
+	}
+	wg.Wait()
+}
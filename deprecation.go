@@ -0,0 +1,200 @@
+package errors
+
+import (
+	"fmt"
+	"runtime"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+// maxUntypedSites caps how many call sites DeprecationReport tracks.
+const maxUntypedSites = 1024
+
+// maxUntypedCallers caps the frames searched for an untyped error's call
+// site.
+const maxUntypedCallers = 16
+
+// UntypedSite is a call site where an untyped error, such as one from a
+// bare fmt.Errorf, reached a retry decision with nothing but its message to
+// go on (see EnableDeprecationWarnings).
+type UntypedSite struct {
+	File     string
+	Line     int
+	Function string
+
+	// Type is the Go type of the first untyped error seen at the site,
+	// e.g. "*fmt.wrapError".
+	Type string
+
+	// Matched reports whether that error's message matched a retryable
+	// pattern, making it transient; otherwise it was ClassUnknown.
+	Matched bool
+
+	// Count is the number of sampled classifications at the site.
+	Count int
+}
+
+// String returns the site as "file:line".
+func (s UntypedSite) String() string {
+	return fmt.Sprintf("%s:%d", s.File, s.Line)
+}
+
+// DeprecationHook is called once for each new UntypedSite. It runs
+// synchronously on the classifying goroutine, so it must be fast.
+type DeprecationHook func(site UntypedSite)
+
+var (
+	deprecationWarnings atomic.Bool
+	deprecationSample   atomic.Int64
+	deprecationCount    atomic.Int64
+	deprecationHook     atomic.Pointer[DeprecationHook]
+
+	untypedMu    sync.Mutex
+	untypedSites = map[string]*UntypedSite{}
+)
+
+// EnableDeprecationWarnings makes IsRetryable and Classify record where
+// untyped errors enter retry decisions: an error that none of the typed
+// rules recognize, so it is classified by the message fallback or ends up
+// ClassUnknown. The call site is the first frame outside this package.
+// Only one in every sampleEvery such classifications looks up its caller,
+// keeping the cost negligible on hot paths; values below 2 sample every
+// one. New sites are passed to the hook (see RegisterDeprecationHook) once
+// and all sites are listed by DeprecationReport. ExtractErrorInfo and
+// Encode classify too, so logging or sending an untyped error also records
+// its call site.
+//
+// Example:
+//
+//	errors.RegisterDeprecationHook(func(site errors.UntypedSite) {
+//	    slog.Warn("untyped error in retry decision", "site", site.String(), "type", site.Type)
+//	})
+//	errors.EnableDeprecationWarnings(100)
+func EnableDeprecationWarnings(sampleEvery int) {
+	deprecationSample.Store(int64(max(sampleEvery, 1)))
+	deprecationWarnings.Store(true)
+}
+
+// DisableDeprecationWarnings stops recording untyped call sites. Sites
+// already recorded are kept.
+func DisableDeprecationWarnings() {
+	deprecationWarnings.Store(false)
+}
+
+// RegisterDeprecationHook installs the hook called for each new untyped
+// call site, replacing any earlier one. A nil hook removes it.
+func RegisterDeprecationHook(hook DeprecationHook) {
+	if hook == nil {
+		deprecationHook.Store(nil)
+		return
+	}
+	deprecationHook.Store(&hook)
+}
+
+// ResetDeprecationWarnings disables deprecation warnings, removes the hook
+// and forgets recorded sites. Intended for tests.
+func ResetDeprecationWarnings() {
+	DisableDeprecationWarnings()
+	RegisterDeprecationHook(nil)
+	deprecationCount.Store(0)
+
+	untypedMu.Lock()
+	defer untypedMu.Unlock()
+	untypedSites = map[string]*UntypedSite{}
+}
+
+// DeprecationReport returns the untyped call sites observed since
+// deprecation warnings were enabled, most frequent first, for burning down
+// the remaining fmt.Errorf call sites. At most 1024 sites are tracked.
+//
+// Example:
+//
+//	for _, site := range errors.DeprecationReport() {
+//	    fmt.Printf("%6d  %s  %s\n", site.Count, site, site.Type)
+//	}
+func DeprecationReport() []UntypedSite {
+	untypedMu.Lock()
+	sites := make([]UntypedSite, 0, len(untypedSites))
+	for _, site := range untypedSites {
+		sites = append(sites, *site)
+	}
+	untypedMu.Unlock()
+
+	sort.Slice(sites, func(i, j int) bool {
+		if sites[i].Count != sites[j].Count {
+			return sites[i].Count > sites[j].Count
+		}
+		return sites[i].String() < sites[j].String()
+	})
+	return sites
+}
+
+// noteUntyped records the call site of an untyped error that reached the
+// message fallback in a retry decision, when deprecation warnings are on
+// and this classification is sampled.
+func noteUntyped(err error, matched bool) {
+	if !deprecationWarnings.Load() || hasTypedNode(err) || deprecationCount.Add(1)%deprecationSample.Load() != 0 {
+		return
+	}
+	frame, ok := untypedCaller()
+	if !ok {
+		return
+	}
+
+	key := fmt.Sprintf("%s:%d", frame.File, frame.Line)
+	untypedMu.Lock()
+	if site, seen := untypedSites[key]; seen {
+		site.Count++
+		untypedMu.Unlock()
+		return
+	}
+	if len(untypedSites) >= maxUntypedSites {
+		untypedMu.Unlock()
+		return
+	}
+	site := &UntypedSite{
+		File:     frame.File,
+		Line:     frame.Line,
+		Function: frame.Function,
+		Type:     fmt.Sprintf("%T", err),
+		Matched:  matched,
+		Count:    1,
+	}
+	untypedSites[key] = site
+	first := *site
+	untypedMu.Unlock()
+
+	if hook := deprecationHook.Load(); hook != nil {
+		(*hook)(first)
+	}
+}
+
+// hasTypedNode reports whether err's chain holds one of this package's
+// typed errors, such as a PanicError, which has no IsRetryable method.
+func hasTypedNode(err error) bool {
+	typed := false
+	walkChain(err, func(node error, _ int) bool {
+		typed = stateField(node) != nil
+		return !typed
+	})
+	return typed
+}
+
+// untypedCaller returns the first frame outside this package's non-test
+// code.
+func untypedCaller() (runtime.Frame, bool) {
+	pcs := make([]uintptr, maxUntypedCallers)
+	frames := runtime.CallersFrames(pcs[:runtime.Callers(3, pcs)])
+	for {
+		frame, more := frames.Next()
+		if pkg, _ := splitFunction(frame.Function); frame.Function != "" &&
+			(pkg != thisPackage || strings.HasSuffix(frame.File, "_test.go")) {
+			return frame, true
+		}
+		if !more {
+			return runtime.Frame{}, false
+		}
+	}
+}
@@ -0,0 +1,108 @@
+package errors
+
+import (
+	"fmt"
+	"runtime"
+	"testing"
+)
+
+// classifyHere classifies err and returns the line it did so on.
+func classifyHere(err error) int {
+	_, _, line, _ := runtime.Caller(0)
+	Classify(err)
+	return line + 1
+}
+
+// TestDeprecationWarnings tests recording of call sites where untyped errors are classified
+func TestDeprecationWarnings(t *testing.T) {
+	t.Cleanup(ResetDeprecationWarnings)
+
+	tests := []struct {
+		name        string
+		err         error
+		wantSite    bool
+		wantMatched bool
+	}{
+		{name: "bare fmt.Errorf", err: fmt.Errorf("disk full"), wantSite: true},
+		{name: "message fallback", err: fmt.Errorf("GitHub rate limit exceeded"), wantSite: true, wantMatched: true},
+		{name: "wrapped untyped", err: Wrap(fmt.Errorf("disk full"), "saving"), wantSite: true},
+		{name: "typed", err: NewProcessingError("failed", "Process")},
+		{name: "typed without IsRetryable", err: NewPanicError("boom")},
+		{name: "sentinel", err: fmt.Errorf("saving: %w", ErrDeadlock)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ResetDeprecationWarnings()
+			var hooked []UntypedSite
+			RegisterDeprecationHook(func(site UntypedSite) { hooked = append(hooked, site) })
+			EnableDeprecationWarnings(1)
+
+			var line int
+			for range 3 {
+				line = classifyHere(tt.err)
+			}
+
+			report := DeprecationReport()
+			if !tt.wantSite {
+				if len(report) != 0 || len(hooked) != 0 {
+					t.Errorf("recorded %v, want nothing", report)
+				}
+				return
+			}
+			if len(hooked) != 1 {
+				t.Fatalf("hook called %d times, want once", len(hooked))
+			}
+			if len(report) != 1 {
+				t.Fatalf("DeprecationReport() = %v, want one site", report)
+			}
+			site := report[0]
+			if site.Line != line || site.Function != thisPackage+".classifyHere" {
+				t.Errorf("site = %s in %s, want line %d in classifyHere", site, site.Function, line)
+			}
+			if site.Count != 3 || site.Matched != tt.wantMatched || site.Type != fmt.Sprintf("%T", tt.err) {
+				t.Errorf("site = %+v, want count 3, matched %v, type %T", site, tt.wantMatched, tt.err)
+			}
+		})
+	}
+}
+
+// TestDeprecationSampling tests sampling, disabling and report order
+func TestDeprecationSampling(t *testing.T) {
+	t.Cleanup(ResetDeprecationWarnings)
+
+	t.Run("one in three", func(t *testing.T) {
+		ResetDeprecationWarnings()
+		EnableDeprecationWarnings(3)
+		for range 9 {
+			IsRetryable(fmt.Errorf("disk full"))
+		}
+		if report := DeprecationReport(); len(report) != 1 || report[0].Count != 3 {
+			t.Errorf("DeprecationReport() = %v, want one site counted 3 times", report)
+		}
+	})
+
+	t.Run("disabled", func(t *testing.T) {
+		ResetDeprecationWarnings()
+		IsRetryable(fmt.Errorf("disk full"))
+		EnableDeprecationWarnings(1)
+		DisableDeprecationWarnings()
+		IsRetryable(fmt.Errorf("disk full"))
+		if report := DeprecationReport(); len(report) != 0 {
+			t.Errorf("DeprecationReport() = %v, want nothing while disabled", report)
+		}
+	})
+
+	t.Run("most frequent first", func(t *testing.T) {
+		ResetDeprecationWarnings()
+		EnableDeprecationWarnings(1)
+		IsRetryable(fmt.Errorf("rare"))
+		for range 2 {
+			IsRetryable(fmt.Errorf("common"))
+		}
+		report := DeprecationReport()
+		if len(report) != 2 || report[0].Count != 2 || report[1].Count != 1 {
+			t.Errorf("DeprecationReport() = %v, want the site seen twice first", report)
+		}
+	})
+}
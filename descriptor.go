@@ -0,0 +1,239 @@
+package errors
+
+import (
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+)
+
+// ProblemExtension documents a member that problem details (see
+// ToProblemDetails) can carry beyond the RFC 7807 ones.
+type ProblemExtension struct {
+	Name        string `json:"name"`
+	Type        string `json:"type"` // JSON Schema type, e.g. "string" or "integer"
+	Description string `json:"description"`
+}
+
+// TypeDescriptor documents an error type for generated references such as
+// SchemaExport. Its status and class aren't written down: they are derived
+// from Example by HTTPStatus and Classify, so documentation can't drift
+// from behavior.
+type TypeDescriptor struct {
+	Name        string
+	Description string
+
+	// Example is a representative instance of the type.
+	Example error
+
+	// Extensions lists the problem details members specific to the type.
+	Extensions []ProblemExtension
+}
+
+// Status returns the HTTP status the type maps to.
+func (d TypeDescriptor) Status() int {
+	return HTTPStatus(d.Example)
+}
+
+// Class returns the class the type maps to.
+func (d TypeDescriptor) Class() ErrorClass {
+	return Classify(d.Example)
+}
+
+// Problem details members that can appear on any error.
+var commonExtensions = []ProblemExtension{
+	{Name: ProblemReference, Type: "string", Description: "Opaque reference code for support requests (see ReferenceCode)"},
+	{Name: ProblemCode, Type: "string", Description: "Stable machine-readable error code, when one is set"},
+	{Name: ProblemWarnings, Type: "array", Description: "Non-fatal issues collected during the request (WriteProblemCtx only)"},
+}
+
+var retryAfterExtension = ProblemExtension{
+	Name: ProblemRetryAfter, Type: "integer", Description: "Seconds to wait before retrying",
+}
+
+// builtinDescriptors describe this package's typed errors.
+func builtinDescriptors() []TypeDescriptor {
+	return []TypeDescriptor{
+		{
+			Name:        "HTTPError",
+			Description: "Failure reported by or for an HTTP API; the status is the one it was created with",
+			Example:     NewHTTPError(http.StatusBadGateway, "Bad Gateway", nil),
+		},
+		{
+			Name:        "ValidationError",
+			Description: "Invalid input",
+			Example:     NewValidationError("Invalid email", "email"),
+			Extensions: []ProblemExtension{
+				{Name: ProblemField, Type: "string", Description: "Name of the invalid field"},
+				{Name: ProblemValue, Type: "any", Description: "The rejected value, unless the field is sensitive"},
+				{Name: ProblemValueStringEncoded, Type: "boolean", Description: "Set when value is an integer beyond ±2^53 sent as a decimal string"},
+			},
+		},
+		{
+			Name:        "TimeoutError",
+			Description: "Operation exceeded its own timeout",
+			Example:     NewTimeoutError("timed out", "Fetch", time.Second),
+		},
+		{
+			Name:        "RateLimitError",
+			Description: "Request rejected by a rate limit",
+			Example:     NewRateLimitError("Too many requests", "Search", time.Second),
+			Extensions:  []ProblemExtension{retryAfterExtension},
+		},
+		{
+			Name:        "RetryableError",
+			Description: "Temporary failure with a retry-after hint",
+			Example:     NewRetryableError("busy", "Lock", time.Second),
+			Extensions:  []ProblemExtension{retryAfterExtension},
+		},
+		{
+			Name:        "ProcessingError",
+			Description: "Failure processing an item",
+			Example:     NewProcessingError("Failed to enrich", "Enrich"),
+		},
+		{
+			Name:        "NetworkError",
+			Description: "Network failure reaching a dependency",
+			Example:     NewNetworkError("connection refused", "Connect"),
+		},
+		{
+			Name:        "SerializationError",
+			Description: "Data that couldn't be encoded or decoded",
+			Example:     NewSerializationError("unexpected EOF", "Decode", "json"),
+		},
+		{
+			Name:        "CircuitBreakerError",
+			Description: "Call rejected by an open circuit breaker",
+			Example:     NewCircuitBreakerError("circuit open", "Call", "open"),
+			Extensions: []ProblemExtension{
+				{Name: ProblemState, Type: "string", Description: "Breaker state: \"open\", \"half-open\" or \"closed\""},
+				{Name: ProblemCounts, Type: "object", Description: "Breaker request and failure counts, when recorded"},
+			},
+		},
+		{
+			Name:        "NotImplementedError",
+			Description: "Feature not available yet; retryable once its AvailableFrom is near",
+			Example:     NewNotImplementedError("bulk export"),
+			Extensions: []ProblemExtension{
+				{Name: ProblemFeature, Type: "string", Description: "Name of the feature that isn't available yet"},
+				{Name: ProblemAvailableFrom, Type: "string", Description: "RFC 3339 time the feature is expected, when known"},
+				retryAfterExtension,
+			},
+		},
+		{
+			Name:        "UnsupportedError",
+			Description: "Request that will never be supported; the client must change it",
+			Example:     NewUnsupportedError("HEIC uploads", "JPEG or PNG"),
+			Extensions: []ProblemExtension{
+				{Name: ProblemUnsupported, Type: "string", Description: "What the request asked for that isn't supported"},
+				{Name: ProblemAlternative, Type: "string", Description: "What to use instead, when there is an alternative"},
+			},
+		},
+		{
+			Name:        "ConsistencyError",
+			Description: "Read served by a replica behind the caller's own write; 409 when it can be retried against the primary",
+			Example:     NewConsistencyError("order/42", "17", "15", 2*time.Second),
+			Extensions:  []ProblemExtension{retryAfterExtension},
+		},
+		{
+			Name:        "NotFoundError",
+			Description: "Looked-up resource doesn't exist",
+			Example:     NewNotFoundError("order", "42"),
+		},
+		{
+			Name:        "ConflictError",
+			Description: "Write clashed with the resource's current state",
+			Example:     NewConflictError("order", "42", WithVersions("7", "8")),
+		},
+		{
+			Name:        "DatabaseError",
+			Description: "Database statement failed; 503 when the SQLSTATE is a retryable transaction conflict",
+			Example:     NewDatabaseError("UpdateOrder", "orders", WithSQLState(SQLStateDeadlockDetected)),
+		},
+		{
+			Name:        "ProviderError",
+			Description: "Third-party API failed; classified by its provider code, else its HTTP status",
+			Example:     NewProviderError("stripe", WithCode("card_declined"), WithStatusCode(402)),
+		},
+		{
+			Name:        "ConfigError",
+			Description: "Configuration, such as a flag or retry spec, that can't be used as given",
+			Example:     NewConfigError("must be at least 1", "max", WithValue(0)),
+		},
+		{
+			Name:        "RetryError",
+			Description: "Retries exhausted",
+			Example:     NewRetryError(3, 3, nil, nil),
+		},
+		{
+			Name:        "PanicError",
+			Description: "Recovered panic",
+			Example:     NewPanicError("assignment to entry in nil map"),
+		},
+		{
+			Name:        "RemoteError",
+			Description: "Decoded error of a type this build doesn't know; the class is the one its sender computed",
+			Example:     &RemoteError{Type: "QuotaError", Message: "monthly quota exhausted", Class: ClassPermanent},
+		},
+	}
+}
+
+var (
+	descriptorsMu sync.RWMutex
+	descriptors   []TypeDescriptor
+)
+
+// RegisterTypeDescriptor documents an error type defined outside this
+// package, so that generated references include it and LookupReferenceCode
+// resolves its codes for origins registered afterwards. Registering a name
+// again replaces the earlier descriptor.
+//
+// Example:
+//
+//	errors.RegisterTypeDescriptor(errors.TypeDescriptor{
+//	    Name:        "QuotaError",
+//	    Description: "Monthly quota exhausted",
+//	    Example:     &QuotaError{Bucket: "sms"},
+//	})
+func RegisterTypeDescriptor(d TypeDescriptor) {
+	descriptorsMu.Lock()
+	defer descriptorsMu.Unlock()
+	for i, existing := range descriptors {
+		if existing.Name == d.Name {
+			descriptors[i] = d
+			return
+		}
+	}
+	descriptors = append(descriptors, d)
+}
+
+// TypeDescriptors returns the descriptors of this package's typed errors
+// and of registered types, sorted by name.
+func TypeDescriptors() []TypeDescriptor {
+	descriptorsMu.RLock()
+	registered := append([]TypeDescriptor(nil), descriptors...)
+	descriptorsMu.RUnlock()
+
+	all := builtinDescriptors()
+	for _, d := range registered {
+		replaced := false
+		for i := range all {
+			if all[i].Name == d.Name {
+				all[i], replaced = d, true
+			}
+		}
+		if !replaced {
+			all = append(all, d)
+		}
+	}
+	sort.Slice(all, func(i, j int) bool { return all[i].Name < all[j].Name })
+	return all
+}
+
+// ResetTypeDescriptors removes all registered descriptors. Intended for
+// tests.
+func ResetTypeDescriptors() {
+	descriptorsMu.Lock()
+	defer descriptorsMu.Unlock()
+	descriptors = nil
+}
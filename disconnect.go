@@ -0,0 +1,76 @@
+package errors
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"syscall"
+)
+
+// ErrCallerDisconnected indicates the caller went away before the operation
+// completed, for example a client closing its HTTP connection.
+var ErrCallerDisconnected = New("caller disconnected")
+
+// callerDisconnectCause is the cancellation cause used by TagCallerContext.
+// It matches both ErrCallerDisconnected and context.Canceled.
+var callerDisconnectCause = fmt.Errorf("%w: %w", ErrCallerDisconnected, context.Canceled)
+
+// TagCallerContext returns a copy of ctx whose cancellation cause identifies
+// a caller disconnect. When ctx is canceled (as net/http does when a client
+// goes away), the returned context is canceled with a cause that matches
+// ErrCallerDisconnected, so errors built from context.Cause are recognised by
+// IsCallerDisconnect. Deadlines and values of ctx are preserved.
+//
+// The httperrors middleware applies this to every request context.
+//
+// Example:
+//
+//	ctx, cancel := TagCallerContext(r.Context())
+//	defer cancel()
+//	if err := process(ctx); err != nil {
+//	    return context.Cause(ctx) // IsCallerDisconnect(...) is true if the client left
+//	}
+func TagCallerContext(ctx context.Context) (context.Context, context.CancelFunc) {
+	tagged, cancelCause := context.WithCancelCause(context.WithoutCancel(ctx))
+
+	cancelDeadline := context.CancelFunc(func() {})
+	if deadline, ok := ctx.Deadline(); ok {
+		tagged, cancelDeadline = context.WithDeadline(tagged, deadline)
+	}
+
+	stop := context.AfterFunc(ctx, func() {
+		cause := context.Cause(ctx)
+		if Is(cause, context.Canceled) {
+			cause = callerDisconnectCause
+		}
+		cancelCause(cause)
+	})
+
+	return tagged, func() {
+		stop()
+		cancelDeadline()
+		cancelCause(context.Canceled)
+	}
+}
+
+// IsCallerDisconnect checks if err was caused by the caller going away.
+// Returns true for:
+// - ErrCallerDisconnected, including cancellation causes from TagCallerContext
+// - EPIPE write failures (the caller closed the connection under us)
+// - "client disconnected" and "broken pipe" failures from net/http
+//
+// A bare context.Canceled is NOT reported as a disconnect: without the
+// tagged cause there is no way to tell who canceled it.
+func IsCallerDisconnect(err error) bool {
+	if err == nil {
+		return false
+	}
+
+	if Is(err, ErrCallerDisconnected) || Is(err, syscall.EPIPE) {
+		return true
+	}
+
+	errMsg := strings.ToLower(err.Error())
+	return strings.Contains(errMsg, "client disconnected") ||
+		strings.Contains(errMsg, "broken pipe")
+}
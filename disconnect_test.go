@@ -0,0 +1,167 @@
+package errors
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"os"
+	"syscall"
+	"testing"
+	"time"
+)
+
+// TestIsCallerDisconnect tests caller disconnect detection
+func TestIsCallerDisconnect(t *testing.T) {
+	tests := []struct {
+		name       string
+		err        error
+		disconnect bool
+	}{
+		{"nil error", nil, false},
+		{"bare context.Canceled", context.Canceled, false},
+		{"context.DeadlineExceeded", context.DeadlineExceeded, false},
+		{"sentinel", ErrCallerDisconnected, true},
+		{"wrapped sentinel", Wrap(ErrCallerDisconnected, "handler"), true},
+		{"EPIPE write", &net.OpError{Op: "write", Net: "tcp", Err: os.NewSyscallError("write", syscall.EPIPE)}, true},
+		{"broken pipe message", fmt.Errorf("write tcp 10.0.0.1:443: broken pipe"), true},
+		{"http2 client disconnected", fmt.Errorf("http2: stream closed: client disconnected"), true},
+		{"generic error", fmt.Errorf("something failed"), false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := IsCallerDisconnect(tt.err); got != tt.disconnect {
+				t.Errorf("IsCallerDisconnect() = %v, want %v", got, tt.disconnect)
+			}
+		})
+	}
+}
+
+// TestTagCallerContext tests that tagged contexts report disconnect causes
+func TestTagCallerContext(t *testing.T) {
+	t.Run("parent cancel becomes disconnect", func(t *testing.T) {
+		parent, cancelParent := context.WithCancel(context.Background())
+		ctx, cancel := TagCallerContext(parent)
+		defer cancel()
+
+		cancelParent()
+		<-ctx.Done()
+
+		cause := context.Cause(ctx)
+		if !IsCallerDisconnect(cause) {
+			t.Errorf("expected disconnect cause, got %v", cause)
+		}
+		if !Is(cause, context.Canceled) {
+			t.Error("disconnect cause should still match context.Canceled")
+		}
+		if ctx.Err() != context.Canceled {
+			t.Errorf("ctx.Err() = %v, want context.Canceled", ctx.Err())
+		}
+	})
+
+	t.Run("deadline is not a disconnect", func(t *testing.T) {
+		parent, cancelParent := context.WithTimeout(context.Background(), 10*time.Millisecond)
+		defer cancelParent()
+		ctx, cancel := TagCallerContext(parent)
+		defer cancel()
+
+		<-ctx.Done()
+
+		if IsCallerDisconnect(context.Cause(ctx)) {
+			t.Error("deadline expiry should not be a caller disconnect")
+		}
+		if !Is(context.Cause(ctx), context.DeadlineExceeded) {
+			t.Errorf("expected DeadlineExceeded, got %v", context.Cause(ctx))
+		}
+	})
+
+	t.Run("own cancel is not a disconnect", func(t *testing.T) {
+		ctx, cancel := TagCallerContext(context.Background())
+		cancel()
+
+		if IsCallerDisconnect(context.Cause(ctx)) {
+			t.Error("canceling the tagged context itself should not be a disconnect")
+		}
+	})
+
+	t.Run("values are preserved", func(t *testing.T) {
+		type key struct{}
+		parent := context.WithValue(context.Background(), key{}, "v")
+		ctx, cancel := TagCallerContext(parent)
+		defer cancel()
+
+		if ctx.Value(key{}) != "v" {
+			t.Error("expected parent values to be visible")
+		}
+	})
+}
+
+// TestCallerDisconnectSeverityAndLabels tests severity and metric class for disconnects
+func TestCallerDisconnectSeverityAndLabels(t *testing.T) {
+	disconnect := Wrap(callerDisconnectCause, "streaming response")
+
+	if got := GetSeverity(disconnect); got != SeverityInfo {
+		t.Errorf("GetSeverity() = %v, want info", got)
+	}
+	if got := MetricLabels(disconnect)[LabelErrorClass]; got != ClassCallerDisconnect {
+		t.Errorf("error_class = %q, want %q", got, ClassCallerDisconnect)
+	}
+
+	if got := MetricLabels(context.Canceled)[LabelErrorClass]; got != string(ClassContext) {
+		t.Errorf("error_class for bare cancel = %q, want %q", got, ClassContext)
+	}
+}
+
+// TestGetSeverity tests default severities
+func TestGetSeverity(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want Severity
+	}{
+		{"nil error", nil, 0},
+		{"validation", NewValidationError("invalid", "field"), SeverityWarning},
+		{"deadline", context.DeadlineExceeded, SeverityWarning},
+		{"server error", NewHTTPError(500, "boom", nil), SeverityError},
+		{"generic", fmt.Errorf("oops"), SeverityError},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := GetSeverity(tt.err); got != tt.want {
+				t.Errorf("GetSeverity() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+// TestMetricLabels tests metric label extraction
+func TestMetricLabels(t *testing.T) {
+	tests := []struct {
+		name      string
+		err       error
+		class     string
+		retryable string
+	}{
+		{"transient", NewHTTPError(503, "down", nil), "transient", "true"},
+		{"permanent", NewValidationError("invalid", "field"), "permanent", "false"},
+		{"context", context.DeadlineExceeded, "context", "false"},
+		{"unknown", fmt.Errorf("oops"), "unknown", "false"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			labels := MetricLabels(tt.err)
+			if labels[LabelErrorClass] != tt.class {
+				t.Errorf("error_class = %q, want %q", labels[LabelErrorClass], tt.class)
+			}
+			if labels[LabelRetryable] != tt.retryable {
+				t.Errorf("retryable = %q, want %q", labels[LabelRetryable], tt.retryable)
+			}
+		})
+	}
+
+	if MetricLabels(nil) != nil {
+		t.Error("expected nil labels for nil error")
+	}
+}
@@ -0,0 +1,943 @@
+package errors
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+	"time"
+)
+
+// EnvelopeVersion is the envelope schema version written by Encode.
+//
+// Version history:
+//  1. Original format, without a version member.
+//  2. Adds version, and class and status_code on every typed node so
+//     receivers can downgrade types they don't know (see RemoteError).
+//  3. Adds Sentinel nodes so this package's sentinels and context errors
+//     keep their identity, and class on foreign nodes.
+const EnvelopeVersion = 3
+
+// Envelope is the transport form of an error chain: a JSON-friendly tree of
+// nodes that can be sent between services and decoded back into typed
+// errors. Typed errors keep their fields and metadata; foreign errors are
+// preserved as their message text.
+//
+// Decoding is forward compatible: members this version doesn't know are
+// kept in RawExtensions and an unknown node type decodes as a RemoteError,
+// so services can upgrade one at a time.
+type Envelope struct {
+	// Version is the schema version, set on the root node only. Envelopes
+	// without one predate versioning and are version 1. It is bumped when
+	// the meaning of existing members changes; new optional members, which
+	// older receivers keep in RawExtensions, don't need a bump.
+	Version int `json:"version,omitempty"`
+
+	// OriginKey is the OriginKey of the error where it was encoded, set on
+	// the root node only, so reference codes survive transport and storage
+	// after the stack that derived them is gone.
+	OriginKey string `json:"origin_key,omitempty"`
+
+	Type          string         `json:"type"`
+	Message       string         `json:"message"`
+	Operation     string         `json:"operation,omitempty"`
+	Component     string         `json:"component,omitempty"`
+	Code          string         `json:"code,omitempty"`
+	Owner         string         `json:"owner,omitempty"`
+	StatusCode    int            `json:"status_code,omitempty"`
+	Origin        string         `json:"origin_component,omitempty"`
+	RequestID     string         `json:"upstream_request_id,omitempty"`
+	Field         string         `json:"field,omitempty"`
+	Value         any            `json:"value,omitempty"`
+	StringEncoded bool           `json:"is_string_encoded,omitempty"`
+	ItemID        string         `json:"item_id,omitempty"`
+	Retryable     bool           `json:"retryable"`
+	Transient     bool           `json:"transient,omitempty"`
+	Overloaded    bool           `json:"overloaded,omitempty"`
+	RetryAfter    float64        `json:"retry_after_ms,omitempty"`
+	Limit         int            `json:"limit,omitempty"`
+	Remaining     int            `json:"remaining,omitempty"`
+	ResetAt       *time.Time     `json:"reset_at,omitempty"`
+	ReopenAt      *time.Time     `json:"reopen_at,omitempty"`
+	Duration      float64        `json:"duration_ms,omitempty"`
+	Source        string         `json:"source,omitempty"`
+	Format        string         `json:"format,omitempty"`
+	Direction     string         `json:"direction,omitempty"`
+	Dependency    string         `json:"dependency,omitempty"`
+	NetworkKind   string         `json:"network_kind,omitempty"`
+	Class         string         `json:"class,omitempty"`
+	State         string         `json:"state,omitempty"`
+	Counts        *CircuitCounts `json:"counts,omitempty"`
+	Feature       string         `json:"feature,omitempty"`
+	Available     *time.Time     `json:"available_from,omitempty"`
+	Unsupported   string         `json:"unsupported,omitempty"`
+	Alternative   string         `json:"alternative,omitempty"`
+	Resource      string         `json:"resource,omitempty"`
+	ResourceID    string         `json:"resource_id,omitempty"`
+	MinVersion    string         `json:"min_version,omitempty"`
+	Observed      string         `json:"observed_version,omitempty"`
+	Expected      string         `json:"expected_version,omitempty"`
+	Actual        string         `json:"actual_version,omitempty"`
+	Table         string         `json:"table,omitempty"`
+	SQLState      string         `json:"sqlstate,omitempty"`
+	Provider      string         `json:"provider,omitempty"`
+	Lag           float64        `json:"lag_ms,omitempty"`
+	ToPrimary     bool           `json:"retry_against_primary,omitempty"`
+	Attempts      int            `json:"attempts,omitempty"`
+	MaxAttempts   int            `json:"max_attempts,omitempty"`
+	Reason        string         `json:"reason,omitempty"`
+	Plans         []RetryPlan    `json:"plans,omitempty"`
+	Metadata      map[string]any `json:"metadata,omitempty"`
+	Cause         *Envelope      `json:"cause,omitempty"`
+	Causes        []*Envelope    `json:"causes,omitempty"`
+	AllErrors     []*Envelope    `json:"all_errors,omitempty"`
+
+	// RawExtensions holds members this version doesn't know, such as
+	// fields added by a newer sender. They are re-emitted when the envelope
+	// is marshaled, including after a Decode and re-Encode.
+	RawExtensions map[string]json.RawMessage `json:"-"`
+}
+
+// envelopeFields has Envelope's fields without its JSON methods.
+type envelopeFields Envelope
+
+// envelopeMembers is the set of JSON member names Envelope knows.
+var envelopeMembers = func() map[string]bool {
+	members := make(map[string]bool)
+	t := reflect.TypeOf(Envelope{})
+	for i := range t.NumField() {
+		name, _, _ := strings.Cut(t.Field(i).Tag.Get("json"), ",")
+		if name != "" && name != "-" {
+			members[name] = true
+		}
+	}
+	return members
+}()
+
+// MarshalJSON encodes the envelope with its RawExtensions as additional
+// members. Known members take precedence over extensions with the same name.
+func (e Envelope) MarshalJSON() ([]byte, error) {
+	data, err := json.Marshal(envelopeFields(e))
+	if err != nil || len(e.RawExtensions) == 0 {
+		return data, err
+	}
+
+	var members map[string]json.RawMessage
+	if err := json.Unmarshal(data, &members); err != nil {
+		return nil, err
+	}
+	for name, value := range e.RawExtensions {
+		if !envelopeMembers[name] && json.Valid(value) {
+			members[name] = value
+		}
+	}
+	return json.Marshal(members)
+}
+
+// UnmarshalJSON decodes the envelope, keeping unknown members in
+// RawExtensions.
+func (e *Envelope) UnmarshalJSON(data []byte) error {
+	var fields envelopeFields
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return err
+	}
+
+	var members map[string]json.RawMessage
+	if err := json.Unmarshal(data, &members); err != nil {
+		return err
+	}
+	for name := range members {
+		if envelopeMembers[name] {
+			delete(members, name)
+		}
+	}
+
+	*e = Envelope(fields)
+	if len(members) > 0 {
+		e.RawExtensions = members
+	}
+	return nil
+}
+
+// Envelope node types for errors that aren't one of the typed errors.
+const (
+	envelopeForeign  = "Error"
+	envelopeMetadata = "Metadata"
+	envelopeForced   = "Forced"
+	envelopeSentinel = "Sentinel"
+)
+
+// forcedSourceMark is the Source of a Forced node set by MarkPermanent or
+// MarkRetryable, which decide over the markers inside them.
+const forcedSourceMark = "mark"
+
+// wireSentinels are the sentinel errors encoded as Sentinel nodes, so that
+// errors.Is still matches them after decoding. They are identified on the
+// wire by message.
+var wireSentinels = []error{
+	ErrRateLimited, ErrNetworkTimeout, ErrServerError, ErrConnectionError, ErrDeadlock,
+	ErrCircuitOpen, ErrCircuitHalfOpen, ErrInvalidResponse, ErrRetryExhausted,
+	ErrMaxAttemptsInvalid, ErrActivityNotFound, ErrLocationNotFound,
+	context.Canceled, context.DeadlineExceeded,
+}
+
+func isWireSentinel(err error) bool {
+	for _, sentinel := range wireSentinels {
+		if err == sentinel {
+			return true
+		}
+	}
+	return false
+}
+
+func wireSentinelNamed(msg string) (error, bool) {
+	for _, sentinel := range wireSentinels {
+		if sentinel.Error() == msg {
+			return sentinel, true
+		}
+	}
+	return nil, false
+}
+
+// Encode converts err into an Envelope. Returns nil for a nil error.
+// Chains deeper than the Error() depth cap are truncated.
+//
+// Example:
+//
+//	env := Encode(err)
+//	payload, _ := json.Marshal(env)
+func Encode(err error) *Envelope {
+	env := encodeDepth(err, 0)
+	if env != nil {
+		env.Version = EnvelopeVersion
+		env.OriginKey = recordedOriginKey(err)
+	}
+	return env
+}
+
+func encodeDepth(err error, depth int) *Envelope {
+	if err == nil {
+		return nil
+	}
+	if depth > maxCauseDepth {
+		return &Envelope{Type: envelopeForeign, Message: truncatedCause}
+	}
+	// A typed nil has no fields to read; encode it by its message
+	if isTypedNil(err) {
+		return &Envelope{Type: envelopeForeign, Message: err.Error(), Class: string(Classify(err))}
+	}
+	if e, ok := err.(*originKeyError); ok {
+		return encodeDepth(e.err, depth)
+	}
+	if e, ok := err.(*extendedError); ok {
+		env := encodeDepth(e.err, depth)
+		if env != nil {
+			env.RawExtensions = e.extensions
+		}
+		return env
+	}
+	if isWireSentinel(err) {
+		return &Envelope{
+			Type: envelopeSentinel, Message: err.Error(),
+			Retryable: IsRetryable(err), Class: string(Classify(err)),
+		}
+	}
+
+	env := &Envelope{
+		Retryable:  IsRetryable(err),
+		Class:      string(Classify(err)),
+		StatusCode: nodeHTTPStatus(err),
+	}
+	if code := codeField(err); code != nil {
+		env.Code = *code
+	}
+	if owner := ownerField(err); owner != nil {
+		env.Owner = *owner
+	}
+	var cause error
+
+	switch e := err.(type) {
+	case *HTTPError:
+		env.Type = "HTTPError"
+		env.Message, env.Component, env.Metadata = e.Message, e.Component, e.Metadata
+		env.StatusCode, env.Origin, env.Overloaded = e.StatusCode, e.OriginComponent, e.Overloaded
+		env.RequestID, env.Dependency = e.UpstreamRequestID, e.Dependency
+		cause = e.Err
+	case *ValidationError:
+		env.Type = "ValidationError"
+		env.Message, env.Component, env.Metadata = e.Message, e.Component, e.Metadata
+		env.Field = e.Field
+		env.Value, env.StringEncoded = encodeValue(e.Value)
+		cause = e.Err
+	case *TimeoutError:
+		env.Type = "TimeoutError"
+		env.Message, env.Operation, env.Component, env.Metadata = e.Message, e.Operation, e.Component, e.Metadata
+		env.Duration, env.Source = durationMillis(e.Duration), e.Source
+		cause = e.Err
+	case *RateLimitError:
+		env.Type = "RateLimitError"
+		encodeRetryHint(env, &e.RetryHint)
+		env.Limit, env.Remaining = e.Limit, e.Remaining
+		if !e.ResetAt.IsZero() {
+			resetAt := e.ResetAt
+			env.ResetAt = &resetAt
+		}
+		cause = e.Err
+	case *RetryableError:
+		env.Type = "RetryableError"
+		encodeRetryHint(env, &e.RetryHint)
+		cause = e.Err
+	case *ProcessingError:
+		env.Type = "ProcessingError"
+		env.Message, env.Operation, env.Component, env.Metadata = e.Message, e.Operation, e.Component, e.Metadata
+		env.ItemID = e.ItemID
+		env.Retryable = e.Retryable
+		cause = e.Err
+	case *NetworkError:
+		env.Type = "NetworkError"
+		env.Message, env.Operation, env.Component, env.Metadata = e.Message, e.Operation, e.Component, e.Metadata
+		env.Transient, env.Dependency, env.NetworkKind = e.IsTransient, e.Dependency, string(e.Kind)
+		cause = e.Err
+	case *SerializationError:
+		env.Type = "SerializationError"
+		env.Message, env.Operation, env.Component, env.Metadata = e.Message, e.Operation, e.Component, e.Metadata
+		env.Format, env.Direction, env.Dependency = e.Format, string(e.Direction), e.Dependency
+		cause = e.Err
+	case *CircuitBreakerError:
+		env.Type = "CircuitBreakerError"
+		env.Message, env.Operation, env.Component, env.Metadata = e.Message, e.Operation, e.Component, e.Metadata
+		env.State = e.State
+		counts := e.Counts
+		env.Counts = &counts
+		if !e.ReopenAt.IsZero() {
+			reopenAt := e.ReopenAt
+			env.ReopenAt = &reopenAt
+		}
+		cause = e.Err
+	case *NotImplementedError:
+		env.Type = "NotImplementedError"
+		env.Message, env.Operation, env.Component, env.Metadata = e.Message, e.Operation, e.Component, e.Metadata
+		env.Feature = e.Feature
+		if !e.AvailableFrom.IsZero() {
+			availableFrom := e.AvailableFrom
+			env.Available = &availableFrom
+		}
+		cause = e.Err
+	case *UnsupportedError:
+		env.Type = "UnsupportedError"
+		env.Message, env.Operation, env.Component, env.Metadata = e.Message, e.Operation, e.Component, e.Metadata
+		env.Unsupported, env.Alternative = e.What, e.Alternative
+		cause = e.Err
+	case *ConsistencyError:
+		env.Type = "ConsistencyError"
+		env.Message, env.Operation, env.Component, env.Metadata = e.Message, e.Operation, e.Component, e.Metadata
+		env.Resource, env.MinVersion, env.Observed = e.Resource, e.MinVersion, e.ObservedVersion
+		env.Lag, env.ToPrimary = durationMillis(e.LagEstimate), e.RetryAgainstPrimary
+		cause = e.Err
+	case *NotFoundError:
+		env.Type = "NotFoundError"
+		env.Message, env.Component, env.Metadata = e.Message, e.Component, e.Metadata
+		env.Resource, env.ResourceID = e.Resource, e.ID
+		cause = e.Err
+	case *ConflictError:
+		env.Type = "ConflictError"
+		env.Message, env.Component, env.Metadata = e.Message, e.Component, e.Metadata
+		env.Resource, env.ResourceID = e.Resource, e.ID
+		env.Expected, env.Actual = e.ExpectedVersion, e.ActualVersion
+		env.Retryable = e.Retryable
+		cause = e.Err
+	case *DatabaseError:
+		env.Type = "DatabaseError"
+		env.Message, env.Operation, env.Component, env.Metadata = e.Message, e.Operation, e.Component, e.Metadata
+		env.Table, env.SQLState = e.Table, e.SQLState
+		cause = e.Err
+	case *ProviderError:
+		env.Type = "ProviderError"
+		env.Message, env.Operation, env.Component, env.Metadata = e.Message, e.Operation, e.Component, e.Metadata
+		env.Provider, env.RequestID = e.Provider, e.RequestID
+		cause = e.Err
+	case *ConfigError:
+		env.Type = "ConfigError"
+		env.Message, env.Operation, env.Component, env.Metadata = e.Message, e.Operation, e.Component, e.Metadata
+		env.Field = e.Key
+		env.Value, env.StringEncoded = encodeValue(e.Value)
+		cause = e.Err
+	case *RetryError:
+		env.Type = "RetryError"
+		env.Operation, env.Component, env.Metadata = e.Operation, e.Component, e.Metadata
+		env.Attempts, env.MaxAttempts, env.Reason = e.Attempts, e.MaxAttempts, e.Reason
+		for _, attemptErr := range e.AllErrors {
+			env.AllErrors = append(env.AllErrors, encodeDepth(attemptErr, depth+1))
+		}
+		for _, attempt := range e.History {
+			env.Plans = append(env.Plans, attempt.Plan)
+		}
+		cause = e.LastError
+	case *PanicError:
+		env.Type = "PanicError"
+		env.Operation, env.Component, env.Metadata = e.Operation, e.Component, e.Metadata
+		env.Message = fmt.Sprint(e.Value)
+		cause = e.causeError()
+	case *RemoteError:
+		env.Type = e.Type
+		env.Message, env.Operation, env.Component, env.Metadata = e.Message, e.Operation, e.Component, e.Metadata
+		env.StatusCode, env.Class = e.StatusCode, string(e.Class)
+		env.RetryAfter = durationMillis(e.RetryAfter)
+		cause = e.Err
+	case *forcedError:
+		env.Type = envelopeForced
+		env.Class = string(e.class)
+		if e.marked {
+			env.Source = forcedSourceMark
+		}
+		cause = e.err
+	case *metadataError:
+		if len(e.metadata) == 0 {
+			return encodeDepth(e.cause, depth)
+		}
+		env.Type = envelopeMetadata
+		env.Metadata = e.metadata
+		cause = e.cause
+	default:
+		return encodeForeign(err, depth)
+	}
+
+	env.Metadata = sanitizeMap(env.Metadata)
+	env.Cause = encodeDepth(cause, depth+1)
+	if additional := additionalCausesField(err); additional != nil {
+		for _, c := range *additional {
+			if c != nil {
+				env.Causes = append(env.Causes, encodeDepth(c, depth+1))
+			}
+		}
+	}
+	return env
+}
+
+func encodeRetryHint(env *Envelope, h *RetryHint) {
+	env.Message, env.Operation, env.Component, env.Metadata = h.Message, h.Operation, h.Component, h.Metadata
+	env.RetryAfter = durationMillis(h.RetryAfter)
+}
+
+// encodeForeign encodes an error from another package by its message text.
+// Pure decorators whose message matches their cause (such as stack trace
+// wrappers) are skipped.
+func encodeForeign(err error, depth int) *Envelope {
+	env := &Envelope{
+		Type:      envelopeForeign,
+		Message:   err.Error(),
+		Retryable: IsRetryable(err),
+		Class:     string(Classify(err)),
+	}
+
+	switch u := err.(type) {
+	case interface{ Unwrap() []error }:
+		for _, c := range u.Unwrap() {
+			if c != nil {
+				env.Causes = append(env.Causes, encodeDepth(c, depth+1))
+			}
+		}
+	default:
+		if c := Unwrap(err); c != nil {
+			if c.Error() == env.Message {
+				return encodeDepth(c, depth)
+			}
+			env.Cause = encodeDepth(c, depth+1)
+		}
+	}
+	return env
+}
+
+// Decode reconstructs an error from an Envelope. Typed errors are rebuilt
+// with their fields, code, metadata and additional causes; foreign errors become
+// opaque errors with the original message. Returns nil for a nil envelope.
+func Decode(env *Envelope) error {
+	if env == nil {
+		return nil
+	}
+
+	err := decodeNode(env, Decode(env.Cause))
+	if code := codeField(err); code != nil {
+		*code = env.Code
+	}
+	if owner := ownerField(err); owner != nil {
+		*owner = env.Owner
+	}
+	if class := preclassField(err); class != nil {
+		*class = ErrorClass(env.Class)
+	}
+	if additional := additionalCausesField(err); additional != nil {
+		for _, c := range env.Causes {
+			*additional = append(*additional, Decode(c))
+		}
+	}
+	if err != nil && len(env.RawExtensions) > 0 {
+		err = &extendedError{err: err, extensions: env.RawExtensions}
+	}
+	if err != nil && env.OriginKey != "" {
+		err = &originKeyError{err: err, key: env.OriginKey}
+	}
+	return err
+}
+
+func decodeNode(env *Envelope, cause error) error {
+	switch env.Type {
+	case "HTTPError":
+		return &HTTPError{
+			StatusCode: env.StatusCode, Message: env.Message, Component: env.Component,
+			OriginComponent: env.Origin, Overloaded: env.Overloaded, UpstreamRequestID: env.RequestID,
+			Dependency: env.Dependency, Err: cause, Metadata: env.Metadata,
+		}
+	case "ValidationError":
+		return &ValidationError{
+			Message: env.Message, Field: env.Field, Component: env.Component,
+			Value: decodeValue(env.Value, env.StringEncoded), Err: cause, Metadata: env.Metadata,
+		}
+	case "TimeoutError":
+		return &TimeoutError{
+			Message: env.Message, Operation: env.Operation, Component: env.Component,
+			Duration: millisDuration(env.Duration), Source: env.Source, Err: cause, Metadata: env.Metadata,
+		}
+	case "RateLimitError":
+		rateErr := &RateLimitError{RetryHint: decodeRetryHint(env, cause), Limit: env.Limit, Remaining: env.Remaining}
+		if env.ResetAt != nil {
+			rateErr.ResetAt = *env.ResetAt
+		}
+		return rateErr
+	case "RetryableError":
+		return &RetryableError{decodeRetryHint(env, cause)}
+	case "ProcessingError":
+		return &ProcessingError{
+			Message: env.Message, Operation: env.Operation, ItemID: env.ItemID, Component: env.Component,
+			Retryable: env.Retryable, Err: cause, Metadata: env.Metadata,
+		}
+	case "NetworkError":
+		return &NetworkError{
+			Message: env.Message, Operation: env.Operation, Component: env.Component,
+			IsTransient: env.Transient, Dependency: env.Dependency, Kind: NetworkKind(env.NetworkKind),
+			Err: cause, Metadata: env.Metadata,
+		}
+	case "SerializationError":
+		return &SerializationError{
+			Message: env.Message, Operation: env.Operation, Component: env.Component,
+			Format: env.Format, Direction: Direction(env.Direction), Dependency: env.Dependency,
+			Err: cause, Metadata: env.Metadata,
+		}
+	case "CircuitBreakerError":
+		cbErr := &CircuitBreakerError{
+			Message: env.Message, Operation: env.Operation, Component: env.Component,
+			State: env.State, Err: cause, Metadata: env.Metadata,
+		}
+		if env.Counts != nil {
+			cbErr.Counts = *env.Counts
+		}
+		if env.ReopenAt != nil {
+			cbErr.ReopenAt = *env.ReopenAt
+		}
+		return cbErr
+	case "NotImplementedError":
+		notImplErr := &NotImplementedError{
+			Feature: env.Feature, Message: env.Message, Operation: env.Operation, Component: env.Component,
+			Err: cause, Metadata: env.Metadata,
+		}
+		if env.Available != nil {
+			notImplErr.AvailableFrom = *env.Available
+		}
+		return notImplErr
+	case "UnsupportedError":
+		return &UnsupportedError{
+			What: env.Unsupported, Alternative: env.Alternative, Message: env.Message, Operation: env.Operation,
+			Component: env.Component, Err: cause, Metadata: env.Metadata,
+		}
+	case "ConsistencyError":
+		return &ConsistencyError{
+			Resource: env.Resource, MinVersion: env.MinVersion, ObservedVersion: env.Observed,
+			LagEstimate: millisDuration(env.Lag), RetryAgainstPrimary: env.ToPrimary,
+			Message: env.Message, Operation: env.Operation, Component: env.Component,
+			Err: cause, Metadata: env.Metadata,
+		}
+	case "NotFoundError":
+		return &NotFoundError{
+			Resource: env.Resource, ID: env.ResourceID, Message: env.Message,
+			Component: env.Component, Err: cause, Metadata: env.Metadata,
+		}
+	case "ConflictError":
+		return &ConflictError{
+			Resource: env.Resource, ID: env.ResourceID, ExpectedVersion: env.Expected,
+			ActualVersion: env.Actual, Message: env.Message, Component: env.Component,
+			Retryable: env.Retryable, Err: cause, Metadata: env.Metadata,
+		}
+	case "DatabaseError":
+		return &DatabaseError{
+			Message: env.Message, Operation: env.Operation, Table: env.Table, SQLState: env.SQLState,
+			Component: env.Component, Err: cause, Metadata: env.Metadata,
+		}
+	case "ProviderError":
+		return &ProviderError{
+			Provider: env.Provider, RequestID: env.RequestID, HTTPStatus: env.StatusCode,
+			Message: env.Message, Operation: env.Operation, Component: env.Component,
+			Err: cause, Metadata: env.Metadata,
+		}
+	case "ConfigError":
+		return &ConfigError{
+			Key: env.Field, Value: decodeValue(env.Value, env.StringEncoded), Message: env.Message, Operation: env.Operation,
+			Component: env.Component, Err: cause, Metadata: env.Metadata,
+		}
+	case "PanicError":
+		var value any = env.Message
+		if cause != nil {
+			value = cause
+		}
+		return &PanicError{Value: value, Operation: env.Operation, Component: env.Component, Metadata: env.Metadata}
+	case "RetryError":
+		retryErr := &RetryError{
+			Attempts: env.Attempts, MaxAttempts: env.MaxAttempts, LastError: cause, Reason: env.Reason,
+			Operation: env.Operation, Component: env.Component, Metadata: env.Metadata,
+		}
+		for _, attempt := range env.AllErrors {
+			retryErr.AllErrors = append(retryErr.AllErrors, Decode(attempt))
+		}
+		for i, plan := range env.Plans {
+			attempt := Attempt{Number: plan.Attempt, Plan: plan}
+			if len(env.Plans) == len(retryErr.AllErrors) {
+				attempt.Err = retryErr.AllErrors[i]
+			}
+			retryErr.History = append(retryErr.History, attempt)
+		}
+		return retryErr
+	case envelopeForced:
+		if cause == nil {
+			cause = causelessNode(env)
+		}
+		return &forcedError{class: ErrorClass(env.Class), err: cause, marked: env.Source == forcedSourceMark}
+	case envelopeMetadata:
+		if cause == nil {
+			cause = causelessNode(env)
+		}
+		return &metadataError{cause: cause, metadata: env.Metadata}
+	case envelopeSentinel:
+		if sentinel, ok := wireSentinelNamed(env.Message); ok {
+			return sentinel
+		}
+	}
+
+	if env.Type != envelopeForeign && env.Type != envelopeSentinel && env.Type != "" {
+		return &RemoteError{
+			Type: env.Type, Message: env.Message, Operation: env.Operation, Component: env.Component,
+			StatusCode: env.StatusCode, Class: remoteClass(env), RetryAfter: millisDuration(env.RetryAfter),
+			Err: cause, Metadata: env.Metadata,
+		}
+	}
+
+	decoded := &decodedError{message: env.Message, cause: cause}
+	for _, c := range env.Causes {
+		decoded.causes = append(decoded.causes, Decode(c))
+	}
+	return decoded
+}
+
+// remoteClass returns the class recorded for an unknown node, falling back
+// to its retryable flag for senders that didn't record one.
+func remoteClass(env *Envelope) ErrorClass {
+	if env.Class != "" {
+		return ErrorClass(env.Class)
+	}
+	if env.Retryable {
+		return ClassTransient
+	}
+	return ClassUnknown
+}
+
+func decodeRetryHint(env *Envelope, cause error) RetryHint {
+	return RetryHint{
+		Message: env.Message, Operation: env.Operation, Component: env.Component,
+		RetryAfter: millisDuration(env.RetryAfter), Err: cause, Metadata: env.Metadata,
+	}
+}
+
+// causelessNode stands in for the missing cause of a wrapper node, such as
+// one from a truncated or hand-written envelope, so that a non-nil
+// envelope never decodes to nil.
+func causelessNode(env *Envelope) error {
+	message := env.Message
+	if message == "" {
+		message = env.Type + " envelope without a cause"
+	}
+	return &decodedError{message: message}
+}
+
+// decodedError stands in for a foreign error received over a transport.
+type decodedError struct {
+	message string
+	cause   error
+	causes  []error
+
+	preclassified ErrorClass
+}
+
+func (e *decodedError) Error() string {
+	return e.message
+}
+
+func (e *decodedError) Unwrap() []error {
+	if e.cause != nil {
+		return []error{e.cause}
+	}
+	return e.causes
+}
+
+// extendedError carries envelope members this version doesn't know, so
+// that re-encoding a decoded error re-emits them. It is otherwise
+// transparent.
+type extendedError struct {
+	err        error
+	extensions map[string]json.RawMessage
+}
+
+func (e *extendedError) Error() string {
+	return e.err.Error()
+}
+
+func (e *extendedError) Unwrap() error {
+	return e.err
+}
+
+// originKeyError carries the OriginKey recorded in a decoded envelope, so
+// OriginKey and ReferenceCode give the sender's values. It is otherwise
+// transparent.
+type originKeyError struct {
+	err error
+	key string
+}
+
+func (e *originKeyError) Error() string {
+	return e.err.Error()
+}
+
+func (e *originKeyError) Unwrap() error {
+	return e.err
+}
+
+// MarshalError encodes err as envelope JSON. Errors from other packages
+// are encoded by their message and classification.
+//
+// Example:
+//
+//	payload, err := MarshalError(procErr)
+//	// {"type":"ProcessingError","message":"Failed to charge","operation":"Charge",...}
+func MarshalError(err error) ([]byte, error) {
+	return json.Marshal(Encode(err))
+}
+
+// MarshalJSON encodes the error as envelope JSON (see MarshalError): its
+// type, message, retryability and type-specific members, with the cause
+// chain nested under "cause" up to the Error() depth cap. Logging the error
+// as JSON or embedding it in an API response keeps everything Decode needs.
+// For an HTTPError the members include the status code, upstream request ID
+// and dependency. A nil receiver encodes as null.
+func (e *HTTPError) MarshalJSON() ([]byte, error) {
+	if e == nil {
+		return []byte("null"), nil
+	}
+	return MarshalError(e)
+}
+
+// MarshalJSON encodes the validation failure as envelope JSON, with the
+// offending field and, sanitized, its value. A nil receiver encodes as null.
+func (e *ValidationError) MarshalJSON() ([]byte, error) {
+	if e == nil {
+		return []byte("null"), nil
+	}
+	return MarshalError(e)
+}
+
+// MarshalJSON encodes the timeout as envelope JSON, with the duration in
+// milliseconds and its source, such as insufficient headroom. A nil
+// receiver encodes as null.
+func (e *TimeoutError) MarshalJSON() ([]byte, error) {
+	if e == nil {
+		return []byte("null"), nil
+	}
+	return MarshalError(e)
+}
+
+// MarshalJSON encodes the rate limit as envelope JSON, with the server's
+// retry-after wait, the limit, the remaining quota and when it resets. A nil
+// receiver encodes as null.
+func (e *RateLimitError) MarshalJSON() ([]byte, error) {
+	if e == nil {
+		return []byte("null"), nil
+	}
+	return MarshalError(e)
+}
+
+// MarshalJSON encodes the error as envelope JSON with its retry-after hint.
+// A nil receiver encodes as null.
+func (e *RetryableError) MarshalJSON() ([]byte, error) {
+	if e == nil {
+		return []byte("null"), nil
+	}
+	return MarshalError(e)
+}
+
+// MarshalJSON encodes the processing failure as envelope JSON, with the
+// item ID and its own Retryable flag. A nil receiver encodes as null.
+func (e *ProcessingError) MarshalJSON() ([]byte, error) {
+	if e == nil {
+		return []byte("null"), nil
+	}
+	return MarshalError(e)
+}
+
+// MarshalJSON encodes the network failure as envelope JSON, with whether it
+// is transient, the dependency and the network kind. A nil receiver encodes
+// as null.
+func (e *NetworkError) MarshalJSON() ([]byte, error) {
+	if e == nil {
+		return []byte("null"), nil
+	}
+	return MarshalError(e)
+}
+
+// MarshalJSON encodes the serialization failure as envelope JSON, with the
+// format, the direction and the dependency. A nil receiver encodes as null.
+func (e *SerializationError) MarshalJSON() ([]byte, error) {
+	if e == nil {
+		return []byte("null"), nil
+	}
+	return MarshalError(e)
+}
+
+// MarshalJSON encodes the open circuit as envelope JSON, with its state,
+// counts and reopen time. A nil receiver encodes as null.
+func (e *CircuitBreakerError) MarshalJSON() ([]byte, error) {
+	if e == nil {
+		return []byte("null"), nil
+	}
+	return MarshalError(e)
+}
+
+// MarshalJSON encodes the stale read as envelope JSON, with the resource,
+// the versions required and observed, the lag estimate and whether to retry
+// against the primary. A nil receiver encodes as null.
+func (e *ConsistencyError) MarshalJSON() ([]byte, error) {
+	if e == nil {
+		return []byte("null"), nil
+	}
+	return MarshalError(e)
+}
+
+// MarshalJSON encodes the missing resource as envelope JSON, with its kind
+// and ID. A nil receiver encodes as null.
+func (e *NotFoundError) MarshalJSON() ([]byte, error) {
+	if e == nil {
+		return []byte("null"), nil
+	}
+	return MarshalError(e)
+}
+
+// MarshalJSON encodes the conflict as envelope JSON, with the resource, the
+// expected and actual versions and its Retryable flag. A nil receiver
+// encodes as null.
+func (e *ConflictError) MarshalJSON() ([]byte, error) {
+	if e == nil {
+		return []byte("null"), nil
+	}
+	return MarshalError(e)
+}
+
+// MarshalJSON encodes the database failure as envelope JSON, with the table
+// and SQLSTATE. A nil receiver encodes as null.
+func (e *DatabaseError) MarshalJSON() ([]byte, error) {
+	if e == nil {
+		return []byte("null"), nil
+	}
+	return MarshalError(e)
+}
+
+// MarshalJSON encodes the provider failure as envelope JSON, with the
+// provider and its request ID. A nil receiver encodes as null.
+func (e *ProviderError) MarshalJSON() ([]byte, error) {
+	if e == nil {
+		return []byte("null"), nil
+	}
+	return MarshalError(e)
+}
+
+// MarshalJSON encodes the configuration problem as envelope JSON, with the
+// key and, sanitized, its value. A nil receiver encodes as null.
+func (e *ConfigError) MarshalJSON() ([]byte, error) {
+	if e == nil {
+		return []byte("null"), nil
+	}
+	return MarshalError(e)
+}
+
+// MarshalJSON encodes the missing feature as envelope JSON, with its name
+// and when it becomes available. A nil receiver encodes as null.
+func (e *NotImplementedError) MarshalJSON() ([]byte, error) {
+	if e == nil {
+		return []byte("null"), nil
+	}
+	return MarshalError(e)
+}
+
+// MarshalJSON encodes the unsupported request as envelope JSON, with what
+// isn't supported and the alternative. A nil receiver encodes as null.
+func (e *UnsupportedError) MarshalJSON() ([]byte, error) {
+	if e == nil {
+		return []byte("null"), nil
+	}
+	return MarshalError(e)
+}
+
+// MarshalJSON encodes the exhausted retry as envelope JSON, with the
+// attempt counts, the stop reason, every attempt's error and retry plan, and
+// the last error as the cause. A nil receiver encodes as null.
+func (e *RetryError) MarshalJSON() ([]byte, error) {
+	if e == nil {
+		return []byte("null"), nil
+	}
+	return MarshalError(e)
+}
+
+// MarshalJSON encodes the recovered panic as envelope JSON, with the
+// panic value as the message. A nil receiver encodes as null.
+func (e *PanicError) MarshalJSON() ([]byte, error) {
+	if e == nil {
+		return []byte("null"), nil
+	}
+	return MarshalError(e)
+}
+
+// MarshalJSON re-encodes the decoded error as envelope JSON under its
+// sender's type and class, so it can be forwarded unchanged. A nil receiver
+// encodes as null.
+func (e *RemoteError) MarshalJSON() ([]byte, error) {
+	if e == nil {
+		return []byte("null"), nil
+	}
+	return MarshalError(e)
+}
+
+// UnmarshalError decodes envelope JSON produced by MarshalError back into
+// an error. The second return value reports malformed input. Envelopes from
+// older and newer versions of this package decode too; when one carries
+// members this version doesn't know the result wraps the typed error, so
+// use errors.As rather than a type assertion.
+func UnmarshalError(data []byte) (error, error) {
+	var env *Envelope
+	if err := json.Unmarshal(data, &env); err != nil {
+		return nil, NewSerializationError("invalid error envelope", "UnmarshalError", "json", WithCause(err))
+	}
+	return Decode(env), nil
+}
+
+func durationMillis(d time.Duration) float64 {
+	return float64(d) / float64(time.Millisecond)
+}
+
+func millisDuration(ms float64) time.Duration {
+	return time.Duration(ms * float64(time.Millisecond))
+}
@@ -0,0 +1,548 @@
+package errors
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestEnvelopeRoundTrip tests that errors survive Encode/Decode and JSON serialization
+func TestEnvelopeRoundTrip(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+	}{
+		{
+			name: "http error with typed cause",
+			err:  NewHTTPError(503, "Service Unavailable", NewNetworkError("dial failed", "Connect", WithCause(fmt.Errorf("connection refused")))),
+		},
+		{
+			name: "rate limit error",
+			err:  NewRateLimitError("Too many requests", "FetchData", 30*time.Second, WithComponent("gateway")),
+		},
+		{
+			name: "rate limit error with policy",
+			err:  NewRateLimitError("Too many requests", "Search", time.Second, WithRateLimitPolicy(100, 3, time.Now().Add(time.Minute))),
+		},
+		{
+			name: "processing error with metadata",
+			err:  NewProcessingError("Failed to charge", "Charge", WithItemID("order-1"), WithMetadata("tenant", "acme")),
+		},
+		{
+			name: "processing error with owner",
+			err:  NewProcessingError("Ledger out of balance", "Reconcile", WithComponent("billing"), WithOwner("payments")),
+		},
+		{
+			name: "processing error with additional cause",
+			err: NewProcessingError("Failed to write order", "SaveOrder",
+				WithCause(NewNetworkError("primary down", "Write")),
+				WithAdditionalCause(fmt.Errorf("compensating delete failed"))),
+		},
+		{
+			name: "wrapped sentinel",
+			err:  fmt.Errorf("saving order: %w", ErrDeadlock),
+		},
+		{
+			name: "nested markers",
+			err:  MarkRetryable(MarkPermanent(fmt.Errorf("sdk: broker unavailable"))),
+		},
+		{
+			name: "foreign wrapper",
+			err:  Wrap(NewValidationError("Invalid email", "email"), "handling signup"),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data, err := MarshalError(tt.err)
+			if err != nil {
+				t.Fatalf("MarshalError() error = %v", err)
+			}
+
+			decoded, err := UnmarshalError(data)
+			if err != nil {
+				t.Fatalf("UnmarshalError() error = %v", err)
+			}
+
+			if decoded.Error() != tt.err.Error() {
+				t.Errorf("got %q, want %q", decoded.Error(), tt.err.Error())
+			}
+			if IsRetryable(decoded) != IsRetryable(tt.err) {
+				t.Errorf("IsRetryable() = %v, want %v", IsRetryable(decoded), IsRetryable(tt.err))
+			}
+			if Classify(decoded) != Classify(tt.err) {
+				t.Errorf("Classify() = %q, want %q", Classify(decoded), Classify(tt.err))
+			}
+			if GetComponent(decoded) != GetComponent(tt.err) {
+				t.Errorf("GetComponent() = %q, want %q", GetComponent(decoded), GetComponent(tt.err))
+			}
+		})
+	}
+
+	t.Run("nil error", func(t *testing.T) {
+		if env := Encode(nil); env != nil {
+			t.Errorf("Encode(nil) = %+v, want nil", env)
+		}
+		if err := Decode(nil); err != nil {
+			t.Errorf("Decode(nil) = %v, want nil", err)
+		}
+	})
+
+	t.Run("malformed input", func(t *testing.T) {
+		if _, err := UnmarshalError([]byte("{")); !IsSerialization(err) {
+			t.Errorf("expected SerializationError, got %v", err)
+		}
+	})
+}
+
+// TestTypedMarshalJSON tests that json.Marshal encodes typed errors as envelopes
+func TestTypedMarshalJSON(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want map[string]any
+	}{
+		{"http", NewHTTPError(503, "Service Unavailable", nil), map[string]any{"type": "HTTPError", "status_code": 503.0, "retryable": true}},
+		{"validation", NewValidationError("Invalid email", "email", WithValue("x")), map[string]any{"type": "ValidationError", "field": "email", "value": "x", "retryable": false}},
+		{"timeout", NewTimeoutError("slow", "Fetch", 2*time.Second), map[string]any{"type": "TimeoutError", "duration_ms": 2000.0}},
+		{"rate limit", NewRateLimitError("slow down", "Search", time.Second), map[string]any{"type": "RateLimitError", "retry_after_ms": 1000.0}},
+		{"retryable", NewRetryableError("busy", "Save", time.Second), map[string]any{"type": "RetryableError", "retryable": true}},
+		{"processing", NewProcessingError("failed", "Import", WithItemID("row-1")), map[string]any{"type": "ProcessingError", "item_id": "row-1"}},
+		{"network", NewNetworkError("reset", "Dial"), map[string]any{"type": "NetworkError", "transient": true}},
+		{"circuit", NewCircuitBreakerError("open", "Charge", "open"), map[string]any{"type": "CircuitBreakerError", "state": "open"}},
+		{"retry", NewRetryError(3, 3, fmt.Errorf("reset"), nil), map[string]any{"type": "RetryError", "attempts": 3.0}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data, err := json.Marshal(map[string]error{"error": tt.err})
+			if err != nil {
+				t.Fatalf("json.Marshal() error = %v", err)
+			}
+			var got map[string]map[string]any
+			if err := json.Unmarshal(data, &got); err != nil {
+				t.Fatalf("json.Unmarshal() error = %v", err)
+			}
+			for key, want := range tt.want {
+				if got["error"][key] != want {
+					t.Errorf("%s = %v, want %v in %s", key, got["error"][key], want, data)
+				}
+			}
+			if got["error"]["message"] == nil {
+				t.Errorf("message missing from %s", data)
+			}
+
+			decoded, err := UnmarshalError(data[len(`{"error":`) : len(data)-1])
+			if err != nil || decoded.Error() != tt.err.Error() {
+				t.Errorf("UnmarshalError() = %v, %v, want %v", decoded, err, tt.err)
+			}
+		})
+	}
+
+	t.Run("nested cause", func(t *testing.T) {
+		err := NewHTTPError(502, "Bad Gateway", NewNetworkError("reset", "Dial", WithCause(fmt.Errorf("read: connection reset"))))
+		data, _ := json.Marshal(err)
+		var got struct {
+			Cause struct {
+				Type  string `json:"type"`
+				Cause struct {
+					Message string `json:"message"`
+				} `json:"cause"`
+			} `json:"cause"`
+		}
+		if jsonErr := json.Unmarshal(data, &got); jsonErr != nil || got.Cause.Type != "NetworkError" || got.Cause.Cause.Message != "read: connection reset" {
+			t.Errorf("json.Marshal() = %s, want the cause chain nested", data)
+		}
+	})
+
+	t.Run("depth cap", func(t *testing.T) {
+		var err error = fmt.Errorf("root")
+		for range maxCauseDepth * 2 {
+			err = NewProcessingError("step", "Run", WithCause(err))
+		}
+		data, jsonErr := json.Marshal(err)
+		if jsonErr != nil || !json.Valid(data) {
+			t.Fatalf("json.Marshal() error = %v", jsonErr)
+		}
+		if depth := strings.Count(string(data), `"cause"`); depth > maxCauseDepth+2 {
+			t.Errorf("encoded %d nested causes, want at most %d", depth, maxCauseDepth+2)
+		}
+	})
+
+	t.Run("nil pointer", func(t *testing.T) {
+		var httpErr *HTTPError
+		if data, err := json.Marshal(httpErr); err != nil || string(data) != "null" {
+			t.Errorf("json.Marshal(nil) = %s, %v, want null", data, err)
+		}
+	})
+}
+
+// TestOriginTrace tests that ctx-aware constructors record the active trace
+func TestOriginTrace(t *testing.T) {
+	ctx := ContextWithTrace(context.Background(), "trace-abc", "span-123")
+
+	tests := []struct {
+		name string
+		err  error
+	}{
+		{name: "NewHTTPErrorCtx", err: NewHTTPErrorCtx(ctx, 502, "Bad Gateway", nil)},
+		{name: "WrapCtx", err: WrapCtx(ctx, fmt.Errorf("connection reset"), "calling billing")},
+		{name: "WithContext", err: NewProcessingError("Failed to charge", "Charge", WithContext(ctx))},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			traceID, spanID, ok := GetOriginTrace(tt.err)
+			if !ok || traceID != "trace-abc" || spanID != "span-123" {
+				t.Fatalf("GetOriginTrace() = %q, %q, %v", traceID, spanID, ok)
+			}
+
+			data, err := MarshalError(tt.err)
+			if err != nil {
+				t.Fatalf("MarshalError() error = %v", err)
+			}
+			decoded, err := UnmarshalError(data)
+			if err != nil {
+				t.Fatalf("UnmarshalError() error = %v", err)
+			}
+
+			traceID, spanID, ok = GetOriginTrace(decoded)
+			if !ok || traceID != "trace-abc" || spanID != "span-123" {
+				t.Errorf("after round trip GetOriginTrace() = %q, %q, %v", traceID, spanID, ok)
+			}
+			if decoded.Error() != tt.err.Error() {
+				t.Errorf("got %q, want %q", decoded.Error(), tt.err.Error())
+			}
+		})
+	}
+
+	t.Run("innermost origin wins", func(t *testing.T) {
+		origin := NewHTTPErrorCtx(ContextWithTrace(context.Background(), "trace-origin", "span-origin"), 500, "Internal", nil)
+		err := NewHTTPErrorCtx(ctx, 502, "Bad Gateway", origin)
+
+		traceID, spanID, ok := GetOriginTrace(err)
+		if !ok || traceID != "trace-origin" || spanID != "span-origin" {
+			t.Errorf("GetOriginTrace() = %q, %q, %v", traceID, spanID, ok)
+		}
+	})
+
+	t.Run("no tracing", func(t *testing.T) {
+		err := NewHTTPErrorCtx(context.Background(), 502, "Bad Gateway", nil)
+		if _, _, ok := GetOriginTrace(err); ok {
+			t.Error("expected no origin trace")
+		}
+		if httpErr := err.(*HTTPError); httpErr.Metadata != nil {
+			t.Errorf("expected no metadata, got %v", httpErr.Metadata)
+		}
+
+		plain := fmt.Errorf("boom")
+		if wrapped := WrapCtx(context.Background(), plain, "outer"); wrapped.Error() != "outer: boom" {
+			t.Errorf("got %q", wrapped.Error())
+		}
+		if WrapCtx(ctx, nil, "outer") != nil {
+			t.Error("WrapCtx(nil) should return nil")
+		}
+
+		env := Encode(err)
+		data, _ := json.Marshal(env)
+		var decoded Envelope
+		if jsonErr := json.Unmarshal(data, &decoded); jsonErr != nil {
+			t.Fatal(jsonErr)
+		}
+		if decoded.Metadata != nil {
+			t.Errorf("expected no metadata in envelope, got %v", decoded.Metadata)
+		}
+	})
+
+	t.Run("custom enricher", func(t *testing.T) {
+		defer ResetContextEnrichers()
+		type tenantKey struct{}
+		RegisterContextEnricher(func(ctx context.Context) map[string]any {
+			if tenant, ok := ctx.Value(tenantKey{}).(string); ok {
+				return map[string]any{"tenant": tenant}
+			}
+			return nil
+		})
+
+		err := NewProcessingError("failed", "Process", WithContext(context.WithValue(ctx, tenantKey{}, "acme")))
+		if tenant, ok := GetMetadata(err, "tenant"); !ok || tenant != "acme" {
+			t.Errorf("GetMetadata(tenant) = %v, %v", tenant, ok)
+		}
+		if _, _, ok := GetOriginTrace(err); !ok {
+			t.Error("built-in trace enricher should still run")
+		}
+	})
+}
+
+// TestDecodeCauselessWrappers tests that wrapper nodes missing their cause still decode to an error, keeping what they carry
+func TestDecodeCauselessWrappers(t *testing.T) {
+	tests := []struct {
+		name        string
+		data        string
+		wantMessage string
+		wantClass   ErrorClass
+	}{
+		{name: "metadata", data: `{"version":2,"type":"Metadata","metadata":{"a":1}}`, wantMessage: "Metadata envelope without a cause"},
+		{name: "metadata with message", data: `{"version":2,"type":"Metadata","message":"lookup failed","metadata":{"a":1}}`, wantMessage: "lookup failed"},
+		{name: "forced permanent", data: `{"version":2,"type":"Forced","class":"permanent"}`, wantMessage: "Forced envelope without a cause", wantClass: ClassPermanent},
+		{name: "forced transient", data: `{"version":2,"type":"Forced","class":"transient"}`, wantMessage: "Forced envelope without a cause", wantClass: ClassTransient},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err, decodeErr := UnmarshalError([]byte(tt.data))
+			if decodeErr != nil || err == nil {
+				t.Fatalf("UnmarshalError() = %v, %v; want a non-nil error", err, decodeErr)
+			}
+			if got := err.Error(); got != tt.wantMessage {
+				t.Errorf("Error() = %q, want %q", got, tt.wantMessage)
+			}
+			if tt.wantClass != "" {
+				if got := Classify(err); got != tt.wantClass {
+					t.Errorf("Classify() = %q, want %q", got, tt.wantClass)
+				}
+				return
+			}
+			if value, ok := GetMetadata(err, "a"); !ok || value != float64(1) {
+				t.Errorf("GetMetadata() = %v, %v; want the node's metadata", value, ok)
+			}
+		})
+	}
+}
+
+// TestEnvelopeCompatibility tests decoding fixtures written by older and newer envelope versions
+func TestEnvelopeCompatibility(t *testing.T) {
+	tests := []struct {
+		fixture       string
+		wantMessage   string
+		wantRetryable bool
+		wantStatus    int
+		wantClass     ErrorClass
+	}{
+		{
+			fixture:       "http_network",
+			wantMessage:   "HTTP 503: Service Unavailable: network error in Connect (transient): dial failed: connection refused",
+			wantRetryable: true,
+			wantStatus:    503,
+			wantClass:     ClassTransient,
+		},
+		{
+			fixture:       "rate_limit_policy",
+			wantMessage:   "rate limited in gateway/Search (retry after 30s): Too many requests",
+			wantRetryable: true,
+			wantStatus:    429,
+			wantClass:     ClassTransient,
+		},
+		{
+			fixture:       "processing_meta",
+			wantMessage:   "Failed to charge: Charge failed for item order-1 (retryable)",
+			wantRetryable: true,
+			wantStatus:    500,
+			wantClass:     ClassTransient,
+		},
+		{
+			fixture:     "validation_wrapped",
+			wantMessage: "handling signup: validation failed for field 'email' (value: x@): Invalid email",
+			wantStatus:  400,
+			wantClass:   ClassPermanent,
+		},
+		{
+			fixture:     "retry_exhausted",
+			wantMessage: "retry exhausted after 3/3 attempts for Fetch: timeout in Fetch after 2s: slow",
+			wantStatus:  504,
+			wantClass:   ClassUnknown,
+		},
+		{
+			fixture:     "forced_permanent",
+			wantMessage: "retryable error in Lock (retry after 1s): busy",
+			wantStatus:  500,
+			wantClass:   ClassPermanent,
+		},
+	}
+
+	for _, version := range []string{"v1", "v2", "v3"} {
+		for _, tt := range tests {
+			t.Run(version+"/"+tt.fixture, func(t *testing.T) {
+				decoded := decodeFixture(t, version+"_"+tt.fixture)
+
+				if decoded.Error() != tt.wantMessage {
+					t.Errorf("Error() = %q, want %q", decoded.Error(), tt.wantMessage)
+				}
+				if got := IsRetryable(decoded); got != tt.wantRetryable {
+					t.Errorf("IsRetryable() = %v, want %v", got, tt.wantRetryable)
+				}
+				if got := HTTPStatus(decoded); got != tt.wantStatus {
+					t.Errorf("HTTPStatus() = %d, want %d", got, tt.wantStatus)
+				}
+				if got := Classify(decoded); got != tt.wantClass {
+					t.Errorf("Classify() = %q, want %q", got, tt.wantClass)
+				}
+			})
+		}
+	}
+
+	t.Run("v3/wrapped_sentinel", func(t *testing.T) {
+		decoded := decodeFixture(t, "v3_wrapped_sentinel")
+
+		if !Is(decoded, ErrDeadlock) {
+			t.Errorf("decoded %v does not match ErrDeadlock", decoded)
+		}
+		if got := Classify(decoded); got != ClassTransient {
+			t.Errorf("Classify() = %q, want %q", got, ClassTransient)
+		}
+	})
+
+	t.Run("unknown type from newer version", func(t *testing.T) {
+		decoded := decodeFixture(t, "v4_unknown_type")
+
+		remoteErr, ok := IsRemoteError(decoded)
+		if !ok {
+			t.Fatalf("expected RemoteError, got %T", decoded)
+		}
+		if remoteErr.Type != "QuotaError" || remoteErr.Message != "Monthly quota exhausted" || remoteErr.Component != "notifier" {
+			t.Errorf("RemoteError fields not preserved: %+v", remoteErr)
+		}
+		if !IsRetryable(decoded) || Classify(decoded) != ClassTransient {
+			t.Errorf("class not preserved: IsRetryable() = %v, Classify() = %q", IsRetryable(decoded), Classify(decoded))
+		}
+		if got := HTTPStatus(decoded); got != 429 {
+			t.Errorf("HTTPStatus() = %d, want 429", got)
+		}
+		if wait, ok := GetRetryAfter(decoded); !ok || wait != time.Minute {
+			t.Errorf("GetRetryAfter() = %v, %v, want 1m, true", wait, ok)
+		}
+		if _, ok := IsHTTPError(decoded); !ok {
+			t.Error("known cause should decode as HTTPError")
+		}
+	})
+
+	t.Run("unknown permanent type", func(t *testing.T) {
+		decoded := decodeFixture(t, "v4_unknown_permanent")
+
+		if IsRetryable(decoded) || !IsPermanentError(decoded) || Classify(decoded) != ClassPermanent {
+			t.Errorf("permanent class not preserved for %v", decoded)
+		}
+		if got := HTTPStatus(decoded); got != 403 {
+			t.Errorf("HTTPStatus() = %d, want 403", got)
+		}
+	})
+
+	t.Run("unknown members are re-emitted", func(t *testing.T) {
+		data, err := MarshalError(decodeFixture(t, "v4_unknown_type"))
+		if err != nil {
+			t.Fatalf("MarshalError() error = %v", err)
+		}
+
+		var root map[string]any
+		if err := json.Unmarshal(data, &root); err != nil {
+			t.Fatalf("json.Unmarshal() error = %v", err)
+		}
+		if root["type"] != "QuotaError" || root["quota_bucket"] != "sms-monthly" || root["code"] != "NOTIFY_QUOTA" {
+			t.Errorf("root members not preserved: %s", data)
+		}
+		if root["version"] != float64(EnvelopeVersion) {
+			t.Errorf("version = %v, want %d", root["version"], EnvelopeVersion)
+		}
+		cause, _ := root["cause"].(map[string]any)
+		if cause["provider_request_id"] != "req-81f2" {
+			t.Errorf("cause members not preserved: %s", data)
+		}
+	})
+}
+
+// decodeFixture decodes an envelope fixture from testdata/envelope
+func decodeFixture(t *testing.T, name string) error {
+	t.Helper()
+
+	data, err := os.ReadFile(filepath.Join("testdata", "envelope", name+".json"))
+	if err != nil {
+		t.Fatalf("reading fixture: %v", err)
+	}
+	decoded, err := UnmarshalError(data)
+	if err != nil {
+		t.Fatalf("UnmarshalError() error = %v", err)
+	}
+	return decoded
+}
+
+// TestPreclassification tests that decoded errors keep the class their sender computed
+func TestPreclassification(t *testing.T) {
+	tests := []struct {
+		fixture   string
+		wantLocal ErrorClass
+	}{
+		{fixture: "v2_preclassified_foreign", wantLocal: ClassUnknown},
+		{fixture: "v2_preclassified_http", wantLocal: ClassPermanent},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.fixture, func(t *testing.T) {
+			decoded := decodeFixture(t, tt.fixture)
+
+			if !WasPreclassified(decoded) {
+				t.Fatal("WasPreclassified() = false, want true")
+			}
+			if !IsRetryable(decoded) {
+				t.Error("IsRetryable() = false, want sender's transient class")
+			}
+			if got := Classify(decoded); got != ClassTransient {
+				t.Errorf("Classify() = %q, want %q", got, ClassTransient)
+			}
+			if got := Classify(decoded, IgnorePreclassification()); got != tt.wantLocal {
+				t.Errorf("Classify(IgnorePreclassification()) = %q, want %q", got, tt.wantLocal)
+			}
+
+			_, reason := ExplainClassification(decoded)
+			if want := "preclassified transient by sender (local rules: " + string(tt.wantLocal) + ")"; reason != want {
+				t.Errorf("ExplainClassification() reason = %q, want %q", reason, want)
+			}
+		})
+	}
+
+	t.Run("v1 envelopes use local rules", func(t *testing.T) {
+		if WasPreclassified(decodeFixture(t, "v1_http_network")) {
+			t.Error("v1 envelope carries no class and should not be preclassified")
+		}
+	})
+
+	t.Run("local errors are not preclassified", func(t *testing.T) {
+		if WasPreclassified(NewHTTPError(503, "unavailable", nil)) {
+			t.Error("locally created error should not be preclassified")
+		}
+	})
+
+	t.Run("local typed wrapper applies local rules", func(t *testing.T) {
+		wrapped := NewValidationError("rejected by inventory", "sku", WithCause(decodeFixture(t, "v2_preclassified_foreign")))
+		if WasPreclassified(wrapped) {
+			t.Error("WasPreclassified() = true for a local typed wrapper")
+		}
+		if got := Classify(wrapped); got != ClassPermanent {
+			t.Errorf("Classify() = %q, want %q", got, ClassPermanent)
+		}
+	})
+
+	t.Run("Permanent still wins", func(t *testing.T) {
+		if IsRetryable(Permanent(decodeFixture(t, "v2_preclassified_http"))) {
+			t.Error("Permanent() should veto the sender's transient class")
+		}
+	})
+
+	t.Run("survives re-encoding", func(t *testing.T) {
+		data, err := MarshalError(decodeFixture(t, "v2_preclassified_http"))
+		if err != nil {
+			t.Fatalf("MarshalError() error = %v", err)
+		}
+		decoded, err := UnmarshalError(data)
+		if err != nil {
+			t.Fatalf("UnmarshalError() error = %v", err)
+		}
+		if got := Classify(decoded); got != ClassTransient {
+			t.Errorf("Classify() after round trip = %q, want %q", got, ClassTransient)
+		}
+	})
+}
@@ -17,6 +17,7 @@ import (
 	"time"
 
 	"github.com/cockroachdb/errors"
+	"github.com/cockroachdb/errors/errbase"
 )
 
 // Re-export commonly used cockroachdb/errors functions.
@@ -28,12 +29,6 @@ var (
 	// Errorf creates a new error with formatted message and stack trace.
 	Errorf = errors.Errorf
 
-	// Wrap annotates an error with a message and stack trace.
-	Wrap = errors.Wrap
-
-	// Wrapf annotates an error with a formatted message and stack trace.
-	Wrapf = errors.Wrapf
-
 	// WithStack adds a stack trace to an error if it doesn't have one.
 	WithStack = errors.WithStack
 
@@ -42,14 +37,49 @@ var (
 
 	// As finds the first error in the chain that matches target type.
 	As = errors.As
+)
 
-	// Unwrap returns the result of calling Unwrap on err, if err's type contains
-	// an Unwrap method returning error. Otherwise, Unwrap returns nil.
-	Unwrap = errors.Unwrap
+// Unwrap returns the error err wraps: the primary cause (Err) of this
+// package's typed errors, whose Unwrap() []error also lists their
+// additional causes, or the result of calling Unwrap on err if err's type
+// contains an Unwrap method returning error. Otherwise, Unwrap returns nil.
+func Unwrap(err error) error {
+	if typed, ok := err.(chainFormatter); ok {
+		return typed.causeError()
+	}
+	return errors.UnwrapOnce(err)
+}
 
-	// Cause returns the underlying cause of the error, if possible.
-	Cause = errors.Cause
-)
+// Cause returns the underlying cause of the error, if possible: the last
+// error reached by repeated Unwrap, following the primary cause of typed
+// errors.
+func Cause(err error) error {
+	for range maxChainNodes {
+		next := Unwrap(err)
+		if next == nil {
+			return err
+		}
+		err = next
+	}
+	return err
+}
+
+// Wrap annotates an error with a message and stack trace. When the chain
+// holds no typed error, it also gets the default options' metadata (see
+// SetDefaultOptions). Returns nil if err is nil.
+func Wrap(err error, message string) error {
+	wrapped := withDefaults(errors.WrapWithDepth(1, err, message))
+	profileError(wrapped)
+	return wrapped
+}
+
+// Wrapf annotates an error with a formatted message and stack trace, like
+// Wrap.
+func Wrapf(err error, format string, args ...any) error {
+	wrapped := withDefaults(errors.WrapWithDepthf(1, err, format, args...))
+	profileError(wrapped)
+	return wrapped
+}
 
 // Sentinel errors for common retryable conditions.
 // Use these when wrapping errors to enable type-safe error detection.
@@ -82,99 +112,260 @@ type HTTPError struct {
 	StatusCode int
 	Message    string
 	Component  string
-	Err        error
+	Code       string
+	Owner      string
+
+	// OriginComponent is the component of the cause at construction time.
+	// It lets an HTTPError minted by shared middleware be attributed to the
+	// component that actually failed.
+	OriginComponent string
+
+	// Overloaded marks a response from a server shedding load rather than
+	// a broken one (see IsOverload).
+	Overloaded bool
+
+	// UpstreamRequestID is the request ID the provider returned, for
+	// support tickets (see GetUpstreamRequestID).
+	UpstreamRequestID string
+
+	// Dependency is the service that returned the response, such as the
+	// request's host (see GetDependency).
+	Dependency string
+
+	// Attempt and MaxAttempts record which retry attempt produced the
+	// error (see WithAttempt). Zero when unknown.
+	Attempt     int
+	MaxAttempts int
+
+	// RetryableOverride, when set, decides IsRetryable instead of the
+	// status (see WithRetryableOverride).
+	RetryableOverride *bool
+
+	Err              error
+	AdditionalCauses []error
+	Metadata         map[string]any
+
+	state errorState
 }
 
 func (e *HTTPError) Error() string {
-	msgStr := e.Message
-	if e.Component != "" {
-		msgStr = fmt.Sprintf("%s: %s", e.Component, e.Message)
+	if e == nil {
+		return "<nil HTTPError>"
 	}
+	return e.formatWithCause(formatCauses(e.Err, e.AdditionalCauses))
+}
 
-	if e.Err != nil {
-		return fmt.Sprintf("HTTP %d: %s: %v", e.StatusCode, msgStr, e.Err)
+func (e *HTTPError) formatWithCause(cause string) string {
+	if e == nil {
+		return "<nil HTTPError>"
+	}
+	msgStr := joinDetails(fmt.Sprintf("HTTP %d", e.StatusCode), e.Component, e.Message)
+	msgStr += attemptSuffix(e.Attempt, e.MaxAttempts)
+	if e.UpstreamRequestID != "" {
+		msgStr += fmt.Sprintf(" [request %s]", e.UpstreamRequestID)
 	}
-	return fmt.Sprintf("HTTP %d: %s", e.StatusCode, msgStr)
+	return joinDetails(msgStr, cause)
 }
 
-func (e *HTTPError) Unwrap() error {
+func (e *HTTPError) causeError() error {
+	if e == nil {
+		return nil
+	}
 	return e.Err
 }
 
-// IsRetryable returns true for 5xx errors and 429 (rate limit).
+func (e *HTTPError) Unwrap() []error {
+	if e == nil {
+		return nil
+	}
+	return causeList(e.Err, e.AdditionalCauses)
+}
+
+// StackTrace returns the stack from where the error was created. It
+// implements the cockroachdb/errors stack trace provider interface.
+func (e *HTTPError) StackTrace() errbase.StackTrace {
+	if e == nil {
+		return nil
+	}
+	return e.state.stackTrace()
+}
+
+// Is implements value matching for errors.Is: target matches when it is an
+// *HTTPError whose non-zero fields all equal e's. Zero fields are
+// wildcards, so errors.Is(err, &HTTPError{StatusCode: 404}) matches any 404
+// and &HTTPError{} matches any HTTPError. Causes and metadata are not
+// compared. Sentinel matching is unaffected.
+func (e *HTTPError) Is(target error) bool {
+	t, ok := target.(*HTTPError)
+	if e == nil || !ok || t == nil {
+		return false
+	}
+	return (t.StatusCode == 0 || t.StatusCode == e.StatusCode) &&
+		(t.Message == "" || t.Message == e.Message) &&
+		(t.Component == "" || t.Component == e.Component) &&
+		(t.Code == "" || t.Code == e.Code) &&
+		(t.OriginComponent == "" || t.OriginComponent == e.OriginComponent) &&
+		(!t.Overloaded || e.Overloaded) &&
+		(t.UpstreamRequestID == "" || t.UpstreamRequestID == e.UpstreamRequestID) &&
+		(t.Attempt == 0 || t.Attempt == e.Attempt) &&
+		(t.MaxAttempts == 0 || t.MaxAttempts == e.MaxAttempts)
+}
+
+// IsRetryable returns RetryableOverride when set, otherwise whether the
+// status is in the retryable set (see IsRetryableHTTPStatus): by default
+// 408, 429, 500, 502, 503 and 504.
 func (e *HTTPError) IsRetryable() bool {
-	return e.StatusCode >= 500 || e.StatusCode == 429
+	if e == nil {
+		return false
+	}
+	if e.RetryableOverride != nil {
+		return *e.RetryableOverride
+	}
+	return IsRetryableHTTPStatus(e.StatusCode)
 }
 
 // NewHTTPError creates an HTTPError with automatic stack trace.
-func NewHTTPError(statusCode int, message string, cause error) error {
+// OriginComponent is derived from the final cause's component, if it has
+// one, after options are applied.
+func NewHTTPError(statusCode int, message string, cause error, opts ...Option) error {
 	httpErr := &HTTPError{
 		StatusCode: statusCode,
 		Message:    message,
 		Err:        cause,
 	}
+	applyOptions(httpErr, opts)
+	httpErr.OriginComponent = GetComponent(httpErr.Err)
 	return httpErr
 }
 
 // IsHTTPError checks if err is an HTTPError and returns it.
 func IsHTTPError(err error) (*HTTPError, bool) {
 	var httpErr *HTTPError
-	if errors.As(err, &httpErr) {
+	if errors.As(err, &httpErr) && httpErr != nil {
 		return httpErr, true
 	}
 	return nil, false
 }
 
 // GetHTTPStatusCode extracts HTTP status code from error, or 0 if not found.
+// A NotFoundError is 404. Use HTTPStatus for the status of any error.
 func GetHTTPStatusCode(err error) int {
 	if httpErr, ok := IsHTTPError(err); ok {
 		return httpErr.StatusCode
 	}
+	if _, ok := IsNotFoundError(err); ok {
+		return 404
+	}
 	return 0
 }
 
+// RetryHint holds the fields shared by RateLimitError and RetryableError.
+// Both types embed it so options, formatting and helpers treat them the same
+// way and can't drift apart.
+type RetryHint struct {
+	Message          string
+	Operation        string
+	Component        string
+	Code             string
+	Owner            string
+	RetryAfter       time.Duration
+	Err              error
+	AdditionalCauses []error
+	Metadata         map[string]any
+
+	state errorState
+}
+
+func (h *RetryHint) retryHint() *RetryHint {
+	return h
+}
+
+func (h *RetryHint) formatWithPrefix(prefix, cause string) string {
+	head := joinWords(prefix, clause("in", opLabel(h.Component, h.Operation)),
+		fmt.Sprintf("(retry after %v)", h.RetryAfter))
+	return joinDetails(head, h.Message, cause)
+}
+
+func (h *RetryHint) causeError() error {
+	return h.Err
+}
+
+// IsRetryable returns true - both embedding types are always retryable.
+func (h *RetryHint) IsRetryable() bool {
+	return true
+}
+
+// retryHintHolder is implemented by every type embedding RetryHint.
+type retryHintHolder interface {
+	retryHint() *RetryHint
+}
+
 // RateLimitError represents rate limiting with retry-after duration.
+// Wraps ErrRateLimited sentinel so errors.Is() works.
 // Automatically includes stack trace from creation point.
+//
+// Limit, Remaining and ResetAt optionally describe the rate limit window
+// (see WithRateLimitPolicy); Limit is zero when the policy is unknown.
 type RateLimitError struct {
-	Message    string
-	Operation  string
-	Component  string
-	RetryAfter time.Duration
-	Err        error
+	RetryHint
+	Limit     int
+	Remaining int
+	ResetAt   time.Time
 }
 
 func (e *RateLimitError) Error() string {
-	opStr := e.Operation
-	if e.Component != "" {
-		opStr = fmt.Sprintf("%s/%s", e.Component, e.Operation)
+	if e == nil {
+		return "<nil RateLimitError>"
 	}
+	return e.formatWithCause(formatCauses(e.Err, e.AdditionalCauses))
+}
 
-	if e.Err != nil {
-		return fmt.Sprintf("rate limited in %s (retry after %v): %s: %v",
-			opStr, e.RetryAfter, e.Message, e.Err)
+func (e *RateLimitError) formatWithCause(cause string) string {
+	if e == nil {
+		return "<nil RateLimitError>"
 	}
-	return fmt.Sprintf("rate limited in %s (retry after %v): %s",
-		opStr, e.RetryAfter, e.Message)
+	return e.formatWithPrefix("rate limited", cause)
 }
 
-func (e *RateLimitError) Unwrap() error {
-	return e.Err
+// Unwrap returns the ErrRateLimited sentinel plus any wrapped causes
+// for errors.Is() and errors.As() compatibility.
+func (e *RateLimitError) Unwrap() []error {
+	if e == nil {
+		return nil
+	}
+	return append([]error{ErrRateLimited}, causeList(e.Err, e.AdditionalCauses)...)
 }
 
+// StackTrace returns the stack from where the error was created. It
+// implements the cockroachdb/errors stack trace provider interface.
+func (e *RateLimitError) StackTrace() errbase.StackTrace {
+	if e == nil {
+		return nil
+	}
+	return e.RetryHint.state.stackTrace()
+}
+
+// IsRetryable returns true, or false for a nil *RateLimitError. Overrides the
+// promoted RetryHint method, which can't be called through a nil pointer.
 func (e *RateLimitError) IsRetryable() bool {
-	return true
+	return e != nil
+}
+
+func (e *RateLimitError) causeError() error {
+	if e == nil {
+		return nil
+	}
+	return e.Err
 }
 
 // NewRateLimitError creates a RateLimitError with automatic stack trace.
 func NewRateLimitError(message, operation string, retryAfter time.Duration, opts ...Option) error {
-	err := &RateLimitError{
+	err := &RateLimitError{RetryHint: RetryHint{
 		Message:    message,
 		Operation:  operation,
 		RetryAfter: retryAfter,
-	}
-	for _, opt := range opts {
-		opt(err)
-	}
+	}}
+	applyOptions(err, opts)
 	return err
 }
 
@@ -182,78 +373,163 @@ func NewRateLimitError(message, operation string, retryAfter time.Duration, opts
 // More general than RateLimitError - can be used for any temporary failure.
 // Automatically includes stack trace from creation point.
 type RetryableError struct {
-	Message    string
-	Operation  string
-	Component  string
-	RetryAfter time.Duration
-	Err        error
+	RetryHint
 }
 
 func (e *RetryableError) Error() string {
-	opStr := e.Operation
-	if e.Component != "" {
-		opStr = fmt.Sprintf("%s/%s", e.Component, e.Operation)
+	if e == nil {
+		return "<nil RetryableError>"
 	}
+	return e.formatWithCause(formatCauses(e.Err, e.AdditionalCauses))
+}
 
-	if e.Err != nil {
-		return fmt.Sprintf("retryable error in %s (retry after %v): %s: %v",
-			opStr, e.RetryAfter, e.Message, e.Err)
+func (e *RetryableError) formatWithCause(cause string) string {
+	if e == nil {
+		return "<nil RetryableError>"
 	}
-	return fmt.Sprintf("retryable error in %s (retry after %v): %s",
-		opStr, e.RetryAfter, e.Message)
+	return e.formatWithPrefix("retryable error", cause)
 }
 
-func (e *RetryableError) Unwrap() error {
-	return e.Err
+func (e *RetryableError) Unwrap() []error {
+	if e == nil {
+		return nil
+	}
+	return causeList(e.Err, e.AdditionalCauses)
 }
 
+// StackTrace returns the stack from where the error was created. It
+// implements the cockroachdb/errors stack trace provider interface.
+func (e *RetryableError) StackTrace() errbase.StackTrace {
+	if e == nil {
+		return nil
+	}
+	return e.RetryHint.state.stackTrace()
+}
+
+// IsRetryable returns true, or false for a nil *RetryableError. Overrides the
+// promoted RetryHint method, which can't be called through a nil pointer.
 func (e *RetryableError) IsRetryable() bool {
-	return true
+	return e != nil
+}
+
+func (e *RetryableError) causeError() error {
+	if e == nil {
+		return nil
+	}
+	return e.Err
 }
 
 // NewRetryableError creates a RetryableError with automatic stack trace.
 func NewRetryableError(message, operation string, retryAfter time.Duration, opts ...Option) error {
-	err := &RetryableError{
+	err := &RetryableError{RetryHint{
 		Message:    message,
 		Operation:  operation,
 		RetryAfter: retryAfter,
+	}}
+	applyOptions(err, opts)
+	return err
+}
+
+// AsRetryable finds a RetryableError or RateLimitError in err's chain and
+// returns it as a *RetryableError, so callers can read the retry hint without
+// caring which of the two types produced it. A RateLimitError is returned as
+// a copy sharing its RetryHint fields.
+//
+// Example:
+//
+//	if re, ok := AsRetryable(err); ok {
+//	    time.Sleep(re.RetryAfter)
+//	}
+func AsRetryable(err error) (*RetryableError, bool) {
+	var holder retryHintHolder
+	if !errors.As(err, &holder) || isTypedNil(holder) {
+		return nil, false
 	}
-	for _, opt := range opts {
-		opt(err)
+
+	if re, ok := holder.(*RetryableError); ok {
+		return re, true
 	}
-	return err
+	return &RetryableError{RetryHint: *holder.retryHint()}, true
 }
 
 // TimeoutError represents an operation that exceeded its deadline.
 // Automatically includes stack trace from creation point.
 type TimeoutError struct {
-	Message   string
-	Operation string
-	Component string
-	Duration  time.Duration
-	Err       error
+	Message          string
+	Operation        string
+	Component        string
+	Code             string
+	Owner            string
+	Duration         time.Duration
+	Source           string // what produced the timeout, e.g. TimeoutSourceHeadroom (optional)
+	Attempt          int
+	MaxAttempts      int
+	Err              error
+	AdditionalCauses []error
+	Metadata         map[string]any
+
+	state errorState
 }
 
 func (e *TimeoutError) Error() string {
-	opStr := e.Operation
-	if e.Component != "" {
-		opStr = fmt.Sprintf("%s/%s", e.Component, e.Operation)
+	if e == nil {
+		return "<nil TimeoutError>"
 	}
+	return e.formatWithCause(formatCauses(e.Err, e.AdditionalCauses))
+}
 
-	if e.Err != nil {
-		return fmt.Sprintf("timeout in %s after %v: %s: %v",
-			opStr, e.Duration, e.Message, e.Err)
+func (e *TimeoutError) formatWithCause(cause string) string {
+	if e == nil {
+		return "<nil TimeoutError>"
 	}
-	return fmt.Sprintf("timeout in %s after %v: %s",
-		opStr, e.Duration, e.Message)
+	head := joinWords("timeout", clause("in", opLabel(e.Component, e.Operation)),
+		fmt.Sprintf("after %v", e.Duration))
+	head += attemptSuffix(e.Attempt, e.MaxAttempts)
+	return joinDetails(head, e.Message, cause)
 }
 
-func (e *TimeoutError) Unwrap() error {
+func (e *TimeoutError) causeError() error {
+	if e == nil {
+		return nil
+	}
 	return e.Err
 }
 
+func (e *TimeoutError) Unwrap() []error {
+	if e == nil {
+		return nil
+	}
+	return causeList(e.Err, e.AdditionalCauses)
+}
+
+// StackTrace returns the stack from where the error was created. It
+// implements the cockroachdb/errors stack trace provider interface.
+func (e *TimeoutError) StackTrace() errbase.StackTrace {
+	if e == nil {
+		return nil
+	}
+	return e.state.stackTrace()
+}
+
+// IsRetryable returns true, except for timeouts from RequireHeadroom:
+// retrying under the same deadline would fail again.
 func (e *TimeoutError) IsRetryable() bool {
-	return true
+	if e == nil {
+		return false
+	}
+	return e.Source != TimeoutSourceHeadroom
+}
+
+// Timeout reports true, so code that checks for a net.Error timeout treats
+// a TimeoutError as one.
+func (e *TimeoutError) Timeout() bool {
+	return e != nil
+}
+
+// Temporary reports IsRetryable, for net.Error. Deprecated in net.Error
+// itself, but still checked by some retry libraries.
+func (e *TimeoutError) Temporary() bool {
+	return e.IsRetryable()
 }
 
 // NewTimeoutError creates a TimeoutError with automatic stack trace.
@@ -263,9 +539,7 @@ func NewTimeoutError(message, operation string, duration time.Duration, opts ...
 		Operation: operation,
 		Duration:  duration,
 	}
-	for _, opt := range opts {
-		opt(err)
-	}
+	applyOptions(err, opts)
 	return err
 }
 
@@ -287,41 +561,83 @@ func IsTimeout(err error) bool {
 // ValidationError represents a data validation failure.
 // Automatically includes stack trace from creation point.
 type ValidationError struct {
-	Message   string
-	Field     string
-	Component string
-	Value     any
-	Err       error
+	Message          string
+	Field            string
+	Component        string
+	Code             string
+	Owner            string
+	Value            any
+	Err              error
+	AdditionalCauses []error
+	Metadata         map[string]any
+
+	state errorState
 }
 
 func (e *ValidationError) Error() string {
-	baseMsg := ""
-	if e.Component != "" {
-		baseMsg = fmt.Sprintf("validation failed in %s for field '%s' (value: %v)",
-			e.Component, e.Field, e.Value)
-	} else {
-		baseMsg = fmt.Sprintf("validation failed for field '%s' (value: %v)",
-			e.Field, e.Value)
-	}
-
-	if e.Message != "" {
-		if e.Err != nil {
-			return fmt.Sprintf("%s: %s: %v", baseMsg, e.Message, e.Err)
-		}
-		return fmt.Sprintf("%s: %s", baseMsg, e.Message)
+	if e == nil {
+		return "<nil ValidationError>"
 	}
+	return e.formatWithCause(formatCauses(e.Err, e.AdditionalCauses))
+}
 
-	if e.Err != nil {
-		return fmt.Sprintf("%s: %v", baseMsg, e.Err)
+func (e *ValidationError) formatWithCause(cause string) string {
+	if e == nil {
+		return "<nil ValidationError>"
+	}
+	field := ""
+	if e.Field != "" {
+		field = fmt.Sprintf("for field '%s'", e.Field)
 	}
-	return baseMsg
+	baseMsg := joinWords("validation failed", clause("in", e.Component), field,
+		fmt.Sprintf("(value: %s)", FormatValue(e.Value)))
+
+	return joinDetails(baseMsg, e.Message, cause)
 }
 
-func (e *ValidationError) Unwrap() error {
+func (e *ValidationError) causeError() error {
+	if e == nil {
+		return nil
+	}
 	return e.Err
 }
 
+func (e *ValidationError) Unwrap() []error {
+	if e == nil {
+		return nil
+	}
+	return causeList(e.Err, e.AdditionalCauses)
+}
+
+// StackTrace returns the stack from where the error was created. It
+// implements the cockroachdb/errors stack trace provider interface.
+func (e *ValidationError) StackTrace() errbase.StackTrace {
+	if e == nil {
+		return nil
+	}
+	return e.state.stackTrace()
+}
+
+// Is implements value matching for errors.Is: target matches when it is a
+// *ValidationError whose non-zero Field, Message, Component and Code equal
+// e's. Zero fields are wildcards, so
+// errors.Is(err, &ValidationError{Field: "email"}) matches any error on the
+// email field.
+func (e *ValidationError) Is(target error) bool {
+	t, ok := target.(*ValidationError)
+	if e == nil || !ok || t == nil {
+		return false
+	}
+	return (t.Field == "" || t.Field == e.Field) &&
+		(t.Message == "" || t.Message == e.Message) &&
+		(t.Component == "" || t.Component == e.Component) &&
+		(t.Code == "" || t.Code == e.Code)
+}
+
 func (e *ValidationError) IsRetryable() bool {
+	if e == nil {
+		return false
+	}
 	return false
 }
 
@@ -331,9 +647,7 @@ func NewValidationError(message, field string, opts ...Option) error {
 		Message: message,
 		Field:   field,
 	}
-	for _, opt := range opts {
-		opt(err)
-	}
+	applyOptions(err, opts)
 	return err
 }
 
@@ -346,43 +660,70 @@ func IsValidation(err error) bool {
 // ProcessingError represents an error during data processing.
 // Automatically includes stack trace from creation point.
 type ProcessingError struct {
-	Message   string
-	Operation string
-	ItemID    string
-	Component string
-	Retryable bool
-	Err       error
+	Message          string
+	Operation        string
+	ItemID           string
+	Component        string
+	Code             string
+	Owner            string
+	Retryable        bool
+	Attempt          int
+	MaxAttempts      int
+	Err              error
+	AdditionalCauses []error
+	Metadata         map[string]any
+
+	state errorState
 }
 
 func (e *ProcessingError) Error() string {
+	if e == nil {
+		return "<nil ProcessingError>"
+	}
+	return e.formatWithCause(formatCauses(e.Err, e.AdditionalCauses))
+}
+
+func (e *ProcessingError) formatWithCause(cause string) string {
+	if e == nil {
+		return "<nil ProcessingError>"
+	}
 	retryStr := "not retryable"
 	if e.Retryable {
 		retryStr = "retryable"
 	}
+	action := joinWords(opLabel(e.Component, e.Operation), "failed",
+		clause("for item", e.ItemID), "("+retryStr+")")
+	action += attemptSuffix(e.Attempt, e.MaxAttempts)
+	return joinDetails(e.Message, action, cause)
+}
 
-	opStr := e.Operation
-	if e.Component != "" {
-		opStr = fmt.Sprintf("%s/%s", e.Component, e.Operation)
-	}
-
-	if e.ItemID != "" {
-		if e.Err != nil {
-			return fmt.Sprintf("%s: %s failed for item %s (%s): %v", e.Message, opStr, e.ItemID, retryStr, e.Err)
-		}
-		return fmt.Sprintf("%s: %s failed for item %s (%s)", e.Message, opStr, e.ItemID, retryStr)
+func (e *ProcessingError) causeError() error {
+	if e == nil {
+		return nil
 	}
+	return e.Err
+}
 
-	if e.Err != nil {
-		return fmt.Sprintf("%s: %s failed (%s): %v", e.Message, opStr, retryStr, e.Err)
+func (e *ProcessingError) Unwrap() []error {
+	if e == nil {
+		return nil
 	}
-	return fmt.Sprintf("%s: %s failed (%s)", e.Message, opStr, retryStr)
+	return causeList(e.Err, e.AdditionalCauses)
 }
 
-func (e *ProcessingError) Unwrap() error {
-	return e.Err
+// StackTrace returns the stack from where the error was created. It
+// implements the cockroachdb/errors stack trace provider interface.
+func (e *ProcessingError) StackTrace() errbase.StackTrace {
+	if e == nil {
+		return nil
+	}
+	return e.state.stackTrace()
 }
 
 func (e *ProcessingError) IsRetryable() bool {
+	if e == nil {
+		return false
+	}
 	// Check explicit flag first
 	if e.Retryable {
 		return true
@@ -403,9 +744,7 @@ func NewProcessingError(message, operation string, opts ...Option) error {
 		Operation: operation,
 		Retryable: false,
 	}
-	for _, opt := range opts {
-		opt(err)
-	}
+	applyOptions(err, opts)
 	return err
 }
 
@@ -415,43 +754,120 @@ func NewRetryableProcessingError(message, operation string, opts ...Option) erro
 	return NewProcessingError(message, operation, allOpts...)
 }
 
+// NetworkKind names the stage of a network call that failed.
+type NetworkKind string
+
+const (
+	// NetworkKindDial is a failure to establish a connection.
+	NetworkKindDial NetworkKind = "dial"
+
+	// NetworkKindDNS is a failure to resolve a host name.
+	NetworkKindDNS NetworkKind = "dns"
+
+	// NetworkKindRead is a failure reading from an established connection.
+	NetworkKindRead NetworkKind = "read"
+
+	// NetworkKindWrite is a failure writing to an established connection.
+	NetworkKindWrite NetworkKind = "write"
+
+	// NetworkKindTLS is a failed TLS handshake, including a certificate
+	// that didn't verify.
+	NetworkKindTLS NetworkKind = "tls"
+)
+
 // NetworkError represents a network connectivity failure.
 // Automatically includes stack trace from creation point.
 type NetworkError struct {
-	Message     string
-	Operation   string
-	Component   string
-	IsTransient bool
-	Err         error
+	Message          string
+	Operation        string
+	Component        string
+	Code             string
+	Owner            string
+	IsTransient      bool
+	Kind             NetworkKind // stage that failed, if known (see ClassifyNetError)
+	Dependency       string      // service that couldn't be reached
+	Attempt          int
+	MaxAttempts      int
+	Err              error
+	AdditionalCauses []error
+	Metadata         map[string]any
+
+	state errorState
 }
 
 func (e *NetworkError) Error() string {
+	if e == nil {
+		return "<nil NetworkError>"
+	}
+	return e.formatWithCause(formatCauses(e.Err, e.AdditionalCauses))
+}
+
+func (e *NetworkError) formatWithCause(cause string) string {
+	if e == nil {
+		return "<nil NetworkError>"
+	}
 	transientStr := "persistent"
 	if e.IsTransient {
 		transientStr = "transient"
 	}
+	if e.Kind != "" {
+		transientStr = string(e.Kind) + ", " + transientStr
+	}
+	head := joinWords("network error", clause("in", opLabel(e.Component, e.Operation)),
+		"("+transientStr+")")
+	head += attemptSuffix(e.Attempt, e.MaxAttempts)
+	return joinDetails(head, e.Message, cause)
+}
 
-	opStr := e.Operation
-	if e.Component != "" {
-		opStr = fmt.Sprintf("%s/%s", e.Component, e.Operation)
+func (e *NetworkError) causeError() error {
+	if e == nil {
+		return nil
 	}
+	return e.Err
+}
 
-	if e.Err != nil {
-		return fmt.Sprintf("network error in %s (%s): %s: %v",
-			opStr, transientStr, e.Message, e.Err)
+func (e *NetworkError) Unwrap() []error {
+	if e == nil {
+		return nil
 	}
-	return fmt.Sprintf("network error in %s (%s): %s",
-		opStr, transientStr, e.Message)
+	return causeList(e.Err, e.AdditionalCauses)
 }
 
-func (e *NetworkError) Unwrap() error {
-	return e.Err
+// StackTrace returns the stack from where the error was created. It
+// implements the cockroachdb/errors stack trace provider interface.
+func (e *NetworkError) StackTrace() errbase.StackTrace {
+	if e == nil {
+		return nil
+	}
+	return e.state.stackTrace()
 }
 
 func (e *NetworkError) IsRetryable() bool {
+	if e == nil {
+		return false
+	}
 	return e.IsTransient
 }
 
+// Timeout reports false, for net.Error: timeouts are TimeoutErrors (see
+// ClassifyNetError).
+func (e *NetworkError) Timeout() bool {
+	return false
+}
+
+// Temporary reports IsTransient, for net.Error. Deprecated in net.Error
+// itself, but still checked by some retry libraries.
+func (e *NetworkError) Temporary() bool {
+	return e.IsRetryable()
+}
+
+// TimeoutError and NetworkError satisfy net.Error, so checks written
+// against the standard library recognize them.
+var (
+	_ net.Error = (*TimeoutError)(nil)
+	_ net.Error = (*NetworkError)(nil)
+)
+
 // NewNetworkError creates a NetworkError with automatic stack trace.
 func NewNetworkError(message, operation string, opts ...Option) error {
 	err := &NetworkError{
@@ -459,42 +875,243 @@ func NewNetworkError(message, operation string, opts ...Option) error {
 		Operation:   operation,
 		IsTransient: true, // Default to transient for network errors
 	}
-	for _, opt := range opts {
-		opt(err)
+	applyOptions(err, opts)
+	return err
+}
+
+// Direction says whether a SerializationError happened on data this service
+// produced or on data it received.
+type Direction string
+
+const (
+	// DirectionOutbound is a failure to encode our own request or message:
+	// a bug on our side.
+	DirectionOutbound Direction = "outbound"
+
+	// DirectionInbound is a failure to decode data received from a
+	// dependency: a bug on theirs.
+	DirectionInbound Direction = "inbound"
+)
+
+// SerializationError represents a failure to encode or decode data,
+// such as malformed JSON. Not retryable: the same bytes fail the same way.
+// Automatically includes stack trace from creation point.
+//
+// Direction, set by NewEncodeError and NewDecodeError, separates our bugs
+// from our dependencies': outbound errors map to 500 and SeverityCritical,
+// inbound ones to 502 and are attributed to Dependency. Both are permanent.
+type SerializationError struct {
+	Message          string
+	Operation        string
+	Component        string
+	Code             string
+	Owner            string
+	Format           string // "json", "protobuf", etc.
+	Direction        Direction
+	Dependency       string // service that sent the data, for inbound errors
+	Err              error
+	AdditionalCauses []error
+	Metadata         map[string]any
+
+	state errorState
+}
+
+func (e *SerializationError) Error() string {
+	if e == nil {
+		return "<nil SerializationError>"
+	}
+	return e.formatWithCause(formatCauses(e.Err, e.AdditionalCauses))
+}
+
+func (e *SerializationError) formatWithCause(cause string) string {
+	if e == nil {
+		return "<nil SerializationError>"
+	}
+	head := joinWords(e.Format, "serialization error",
+		clause("in", opLabel(e.Component, e.Operation)))
+	return joinDetails(head, e.Message, cause)
+}
+
+func (e *SerializationError) causeError() error {
+	if e == nil {
+		return nil
+	}
+	return e.Err
+}
+
+func (e *SerializationError) Unwrap() []error {
+	if e == nil {
+		return nil
+	}
+	return causeList(e.Err, e.AdditionalCauses)
+}
+
+// StackTrace returns the stack from where the error was created. It
+// implements the cockroachdb/errors stack trace provider interface.
+func (e *SerializationError) StackTrace() errbase.StackTrace {
+	if e == nil {
+		return nil
+	}
+	return e.state.stackTrace()
+}
+
+func (e *SerializationError) IsRetryable() bool {
+	if e == nil {
+		return false
+	}
+	return false
+}
+
+// NewSerializationError creates a SerializationError with automatic stack trace.
+func NewSerializationError(message, operation, format string, opts ...Option) error {
+	err := &SerializationError{
+		Message:   message,
+		Operation: operation,
+		Format:    format,
 	}
+	applyOptions(err, opts)
 	return err
 }
 
+// NewEncodeError creates a SerializationError for data this service failed
+// to encode, such as a request body that doesn't marshal. It is a bug on
+// our side: permanent, SeverityCritical and reported as 500.
+//
+// Example:
+//
+//	body, err := json.Marshal(req)
+//	if err != nil {
+//	    return errors.NewEncodeError("encoding charge request", "Charge", "json", errors.WithCause(err))
+//	}
+func NewEncodeError(message, operation, format string, opts ...Option) error {
+	err := &SerializationError{
+		Message:   message,
+		Operation: operation,
+		Format:    format,
+		Direction: DirectionOutbound,
+	}
+	applyOptions(err, opts)
+	return err
+}
+
+// NewDecodeError creates a SerializationError for data received from a
+// dependency that couldn't be decoded. It is the dependency's bug:
+// permanent, reported as 502 and attributed to the dependency named with
+// WithDependency.
+//
+// Example:
+//
+//	if err := json.NewDecoder(resp.Body).Decode(&out); err != nil {
+//	    return errors.NewDecodeError("decoding quote", "GetQuote", "json",
+//	        errors.WithCause(err), errors.WithDependency("pricing-api"))
+//	}
+func NewDecodeError(message, operation, format string, opts ...Option) error {
+	err := &SerializationError{
+		Message:   message,
+		Operation: operation,
+		Format:    format,
+		Direction: DirectionInbound,
+	}
+	applyOptions(err, opts)
+	return err
+}
+
+// GetDependency returns the dependency an error is attributed to (see
+// WithDependency), searching err's chain outermost first. Returns "" if
+// none is recorded.
+func GetDependency(err error) string {
+	var dependency string
+	walkChain(err, func(node error, _ int) bool {
+		if field := dependencyField(node); field != nil {
+			dependency = *field
+		}
+		return dependency == ""
+	})
+	return dependency
+}
+
+// dependencyField returns a pointer to the Dependency field of a typed
+// error, or nil if err's type has none.
+func dependencyField(err any) *string {
+	if isTypedNil(err) {
+		return nil
+	}
+	switch e := err.(type) {
+	case *HTTPError:
+		return &e.Dependency
+	case *NetworkError:
+		return &e.Dependency
+	case *SerializationError:
+		return &e.Dependency
+	}
+	return nil
+}
+
+// serializationDirection returns the Direction of the first
+// SerializationError in err's chain, or "" if there is none.
+func serializationDirection(err error) Direction {
+	var serErr *SerializationError
+	if errors.As(err, &serErr) && serErr != nil {
+		return serErr.Direction
+	}
+	return ""
+}
+
+// IsSerialization checks if err is a SerializationError.
+func IsSerialization(err error) bool {
+	var serErr *SerializationError
+	return errors.As(err, &serErr)
+}
+
 // CircuitBreakerError represents circuit breaker protection.
 // Wraps sentinel errors (ErrCircuitOpen, ErrCircuitHalfOpen) for errors.Is() compatibility.
 // Automatically includes stack trace from creation point.
 type CircuitBreakerError struct {
-	Message   string
-	Operation string
-	Component string
-	State     string        // "open", "half-open", "closed"
-	Counts    CircuitCounts // Circuit breaker statistics for observability
-	Err       error         // Additional wrapped error (optional)
+	Message          string
+	Operation        string
+	Component        string
+	Code             string
+	Owner            string
+	State            string        // "open", "half-open", "closed"
+	Counts           CircuitCounts // Circuit breaker statistics for observability
+	ReopenAt         time.Time     // When an open circuit lets calls through again (optional)
+	Err              error         // Additional wrapped error (optional)
+	AdditionalCauses []error
+	Metadata         map[string]any
+
+	state errorState
 }
 
 func (e *CircuitBreakerError) Error() string {
-	opStr := e.Operation
-	if e.Component != "" {
-		opStr = fmt.Sprintf("%s/%s", e.Component, e.Operation)
+	if e == nil {
+		return "<nil CircuitBreakerError>"
+	}
+	return e.formatWithCause(formatCauses(e.Err, e.AdditionalCauses))
+}
+
+func (e *CircuitBreakerError) formatWithCause(cause string) string {
+	if e == nil {
+		return "<nil CircuitBreakerError>"
 	}
+	head := joinWords("circuit breaker", e.State,
+		clause("for", opLabel(e.Component, e.Operation)))
+	return joinDetails(head, e.Message, cause)
+}
 
-	if e.Err != nil {
-		return fmt.Sprintf("circuit breaker %s for %s: %s: %v",
-			e.State, opStr, e.Message, e.Err)
+func (e *CircuitBreakerError) causeError() error {
+	if e == nil {
+		return nil
 	}
-	return fmt.Sprintf("circuit breaker %s for %s: %s",
-		e.State, opStr, e.Message)
+	return e.Err
 }
 
 // Unwrap returns both the sentinel and cause errors for errors.Is() and errors.As() compatibility.
 // Returns ErrCircuitOpen for "open" state, ErrCircuitHalfOpen for "half-open" state,
-// plus any wrapped cause error.
+// plus any wrapped cause errors.
 func (e *CircuitBreakerError) Unwrap() []error {
+	if e == nil {
+		return nil
+	}
 	var errs []error
 
 	// Add the appropriate sentinel based on state
@@ -505,15 +1122,40 @@ func (e *CircuitBreakerError) Unwrap() []error {
 		errs = append(errs, ErrCircuitHalfOpen)
 	}
 
-	// Add the wrapped cause if present
-	if e.Err != nil {
-		errs = append(errs, e.Err)
+	// Add the wrapped causes if present
+	return append(errs, causeList(e.Err, e.AdditionalCauses)...)
+}
+
+// StackTrace returns the stack from where the error was created. It
+// implements the cockroachdb/errors stack trace provider interface.
+func (e *CircuitBreakerError) StackTrace() errbase.StackTrace {
+	if e == nil {
+		return nil
 	}
+	return e.state.stackTrace()
+}
 
-	return errs
+// Is implements value matching for errors.Is: target matches when it is a
+// *CircuitBreakerError whose non-zero State, Message, Operation, Component
+// and Code equal e's. Zero fields are wildcards, so
+// errors.Is(err, &CircuitBreakerError{State: "half-open"}) matches any
+// half-open circuit.
+func (e *CircuitBreakerError) Is(target error) bool {
+	t, ok := target.(*CircuitBreakerError)
+	if e == nil || !ok || t == nil {
+		return false
+	}
+	return (t.State == "" || t.State == e.State) &&
+		(t.Message == "" || t.Message == e.Message) &&
+		(t.Operation == "" || t.Operation == e.Operation) &&
+		(t.Component == "" || t.Component == e.Component) &&
+		(t.Code == "" || t.Code == e.Code)
 }
 
 func (e *CircuitBreakerError) IsRetryable() bool {
+	if e == nil {
+		return false
+	}
 	// Circuit breaker manages its own retry timing
 	return false
 }
@@ -526,21 +1168,29 @@ func NewCircuitBreakerError(message, operation, state string, opts ...Option) *C
 		Operation: operation,
 		State:     state,
 	}
-	for _, opt := range opts {
-		opt(err)
-	}
+	applyOptions(err, opts)
 	return err
 }
 
 // IsNetworkError checks if err is a network error (NetworkError or net.Error).
+// A TimeoutError satisfies net.Error but isn't counted.
+// It says nothing about retryability: IsRetryable treats a *net.OpError or
+// a net.Error timeout as retryable, but not a *url.Error wrapping a TLS
+// certificate failure.
 func IsNetworkError(err error) bool {
 	var netErr *NetworkError
 	if errors.As(err, &netErr) {
 		return true
 	}
 
-	var stdNetErr net.Error
-	return errors.As(err, &stdNetErr)
+	found := false
+	walkChain(err, func(node error, _ int) bool {
+		if _, isTimeout := node.(*TimeoutError); !isTimeout {
+			_, found = node.(net.Error)
+		}
+		return !found
+	})
+	return found
 }
 
 // IsContextError checks if err is a context error (DeadlineExceeded or Canceled).
@@ -554,35 +1204,357 @@ func NewInternalError(message string, cause error) error {
 	return NewHTTPError(500, message, cause)
 }
 
-// NewNotFoundError creates an HTTPError with status 404 (Not Found).
-// This is a convenience wrapper for API/backend services.
-func NewNotFoundError(message string, cause error) error {
-	return NewHTTPError(404, message, cause)
-}
-
-// Sentinel errors for common API/backend error conditions.
+// Sentinel errors for common API/backend error conditions. They are
+// NotFoundErrors, so errors.Is also matches a NotFoundError for the same
+// resource (see NotFoundError.Is).
 var (
 	// ErrActivityNotFound indicates a requested activity was not found.
-	ErrActivityNotFound = errors.New("activity not found")
+	ErrActivityNotFound error = &NotFoundError{Resource: "activity"}
 
 	// ErrLocationNotFound indicates a requested location was not found.
-	ErrLocationNotFound = errors.New("location not found")
+	ErrLocationNotFound error = &NotFoundError{Resource: "location"}
 )
 
-// IsNotFound checks if an error represents a "not found" condition.
-// Returns true for:
-// - ErrActivityNotFound or ErrLocationNotFound sentinels
-// - Any error wrapping these sentinels
-// - HTTPError with status code 404
-func IsNotFound(err error) bool {
-	if errors.Is(err, ErrActivityNotFound) || errors.Is(err, ErrLocationNotFound) {
-		return true
+// GetComponent returns the first non-empty Component found in err's chain,
+// or "" if no typed error in the chain carries one.
+//
+// Example:
+//
+//	err := Wrap(NewTimeoutError("slow", "Fetch", time.Second, WithComponent("api")), "outer")
+//	GetComponent(err) // "api"
+func GetComponent(err error) string {
+	var component string
+	walkChain(err, func(node error, _ int) bool {
+		component = componentOf(node)
+		return component == ""
+	})
+	return component
+}
+
+// GetCode returns the first non-empty error code found in err's chain (see
+// WithCode), or "" if no typed error in the chain carries one.
+//
+// Example:
+//
+//	err := Wrap(NewHTTPError(404, "Order not found", nil, WithCode("ORDERS_NOT_FOUND")), "outer")
+//	GetCode(err) // "ORDERS_NOT_FOUND"
+func GetCode(err error) string {
+	var code string
+	walkChain(err, func(node error, _ int) bool {
+		if field := codeField(node); field != nil {
+			code = *field
+		}
+		return code == ""
+	})
+	return code
+}
+
+// codeField returns a pointer to the Code field of a typed error, or nil if
+// err is not one of this package's typed errors.
+func codeField(err any) *string {
+	if isTypedNil(err) {
+		return nil
 	}
+	switch e := err.(type) {
+	case *HTTPError:
+		return &e.Code
+	case *ValidationError:
+		return &e.Code
+	case *TimeoutError:
+		return &e.Code
+	case retryHintHolder:
+		return &e.retryHint().Code
+	case *ProcessingError:
+		return &e.Code
+	case *NetworkError:
+		return &e.Code
+	case *SerializationError:
+		return &e.Code
+	case *CircuitBreakerError:
+		return &e.Code
+	case *ConsistencyError:
+		return &e.Code
+	case *NotFoundError:
+		return &e.Code
+	case *ConflictError:
+		return &e.Code
+	case *DatabaseError:
+		return &e.Code
+	case *ProviderError:
+		return &e.Code
+	case *ConfigError:
+		return &e.Code
+	case *NotImplementedError:
+		return &e.Code
+	case *UnsupportedError:
+		return &e.Code
+	case *RetryError:
+		return &e.Code
+	case *PanicError:
+		return &e.Code
+	case *RemoteError:
+		return &e.Code
+	}
+	return nil
+}
 
-	// Check for HTTPError with 404 status
-	if httpErr, ok := IsHTTPError(err); ok {
-		return httpErr.StatusCode == 404
+// ownerField returns a pointer to the Owner field of a typed error, or nil if
+// err is not one of this package's typed errors.
+func ownerField(err any) *string {
+	if isTypedNil(err) {
+		return nil
+	}
+	switch e := err.(type) {
+	case *HTTPError:
+		return &e.Owner
+	case *ValidationError:
+		return &e.Owner
+	case *TimeoutError:
+		return &e.Owner
+	case retryHintHolder:
+		return &e.retryHint().Owner
+	case *ProcessingError:
+		return &e.Owner
+	case *NetworkError:
+		return &e.Owner
+	case *SerializationError:
+		return &e.Owner
+	case *CircuitBreakerError:
+		return &e.Owner
+	case *ConsistencyError:
+		return &e.Owner
+	case *NotFoundError:
+		return &e.Owner
+	case *ConflictError:
+		return &e.Owner
+	case *DatabaseError:
+		return &e.Owner
+	case *ProviderError:
+		return &e.Owner
+	case *ConfigError:
+		return &e.Owner
+	case *NotImplementedError:
+		return &e.Owner
+	case *UnsupportedError:
+		return &e.Owner
+	case *RetryError:
+		return &e.Owner
+	case *PanicError:
+		return &e.Owner
+	case *RemoteError:
+		return &e.Owner
 	}
+	return nil
+}
 
-	return false
+// errorState is bookkeeping kept on typed errors that isn't part of their
+// public shape or their transport form.
+type errorState struct {
+	// preclassified is the class the sender computed, set by Decode (see
+	// PreclassifiedClass).
+	preclassified ErrorClass
+
+	// spanRecorded is set once the error has been recorded on a span (see
+	// EnableSpanRecording).
+	spanRecorded bool
+
+	// goroutineInfo is set by WithGoroutineInfo.
+	goroutineInfo bool
+
+	// severity overrides GetSeverity's defaults when set (see WithSeverity).
+	severity Severity
+
+	// stack is where the error was created (see captureStack).
+	stack []uintptr
+}
+
+// stateField returns a pointer to the bookkeeping of a locally defined
+// typed error, or nil if err is not one.
+func stateField(err any) *errorState {
+	if isTypedNil(err) {
+		return nil
+	}
+	switch e := err.(type) {
+	case *HTTPError:
+		return &e.state
+	case *ValidationError:
+		return &e.state
+	case *TimeoutError:
+		return &e.state
+	case retryHintHolder:
+		return &e.retryHint().state
+	case *ProcessingError:
+		return &e.state
+	case *NetworkError:
+		return &e.state
+	case *SerializationError:
+		return &e.state
+	case *CircuitBreakerError:
+		return &e.state
+	case *ConsistencyError:
+		return &e.state
+	case *NotFoundError:
+		return &e.state
+	case *ConflictError:
+		return &e.state
+	case *DatabaseError:
+		return &e.state
+	case *ProviderError:
+		return &e.state
+	case *ConfigError:
+		return &e.state
+	case *NotImplementedError:
+		return &e.state
+	case *UnsupportedError:
+		return &e.state
+	case *RetryError:
+		return &e.state
+	case *PanicError:
+		return &e.state
+	}
+	return nil
+}
+
+// metadataField returns a pointer to the Metadata field of a typed error,
+// or nil if err is not one of this package's typed errors.
+func metadataField(err any) *map[string]any {
+	if isTypedNil(err) {
+		return nil
+	}
+	switch e := err.(type) {
+	case *HTTPError:
+		return &e.Metadata
+	case *ValidationError:
+		return &e.Metadata
+	case *TimeoutError:
+		return &e.Metadata
+	case retryHintHolder:
+		return &e.retryHint().Metadata
+	case *ProcessingError:
+		return &e.Metadata
+	case *NetworkError:
+		return &e.Metadata
+	case *SerializationError:
+		return &e.Metadata
+	case *CircuitBreakerError:
+		return &e.Metadata
+	case *ConsistencyError:
+		return &e.Metadata
+	case *NotFoundError:
+		return &e.Metadata
+	case *ConflictError:
+		return &e.Metadata
+	case *DatabaseError:
+		return &e.Metadata
+	case *ProviderError:
+		return &e.Metadata
+	case *ConfigError:
+		return &e.Metadata
+	case *NotImplementedError:
+		return &e.Metadata
+	case *UnsupportedError:
+		return &e.Metadata
+	case *RetryError:
+		return &e.Metadata
+	case *PanicError:
+		return &e.Metadata
+	case *RemoteError:
+		return &e.Metadata
+	case *metadataError:
+		return &e.metadata
+	}
+	return nil
+}
+
+// additionalCausesField returns a pointer to the AdditionalCauses field of
+// a typed error, or nil if err's type does not carry additional causes.
+func additionalCausesField(err any) *[]error {
+	if isTypedNil(err) {
+		return nil
+	}
+	switch e := err.(type) {
+	case *HTTPError:
+		return &e.AdditionalCauses
+	case *ValidationError:
+		return &e.AdditionalCauses
+	case *TimeoutError:
+		return &e.AdditionalCauses
+	case retryHintHolder:
+		return &e.retryHint().AdditionalCauses
+	case *ProcessingError:
+		return &e.AdditionalCauses
+	case *NetworkError:
+		return &e.AdditionalCauses
+	case *SerializationError:
+		return &e.AdditionalCauses
+	case *CircuitBreakerError:
+		return &e.AdditionalCauses
+	case *ConsistencyError:
+		return &e.AdditionalCauses
+	case *NotFoundError:
+		return &e.AdditionalCauses
+	case *ConflictError:
+		return &e.AdditionalCauses
+	case *DatabaseError:
+		return &e.AdditionalCauses
+	case *ProviderError:
+		return &e.AdditionalCauses
+	case *ConfigError:
+		return &e.AdditionalCauses
+	case *NotImplementedError:
+		return &e.AdditionalCauses
+	case *UnsupportedError:
+		return &e.AdditionalCauses
+	case *RemoteError:
+		return &e.AdditionalCauses
+	}
+	return nil
+}
+
+// componentOf returns the Component field of a single typed error node.
+func componentOf(err error) string {
+	if isTypedNil(err) {
+		return ""
+	}
+	switch e := err.(type) {
+	case *HTTPError:
+		return e.Component
+	case *ValidationError:
+		return e.Component
+	case *TimeoutError:
+		return e.Component
+	case retryHintHolder:
+		return e.retryHint().Component
+	case *ProcessingError:
+		return e.Component
+	case *NetworkError:
+		return e.Component
+	case *SerializationError:
+		return e.Component
+	case *CircuitBreakerError:
+		return e.Component
+	case *ConsistencyError:
+		return e.Component
+	case *NotFoundError:
+		return e.Component
+	case *ConflictError:
+		return e.Component
+	case *DatabaseError:
+		return e.Component
+	case *ProviderError:
+		return e.Component
+	case *ConfigError:
+		return e.Component
+	case *NotImplementedError:
+		return e.Component
+	case *UnsupportedError:
+		return e.Component
+	case *RetryError:
+		return e.Component
+	case *PanicError:
+		return e.Component
+	case *RemoteError:
+		return e.Component
+	}
+	return ""
 }
@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 	"net"
+	"strings"
 	"testing"
 	"time"
 )
@@ -303,6 +304,44 @@ func TestIsRetryable(t *testing.T) {
 	}
 }
 
+// TestIsRetryableWithFreshContext tests which context errors become retryable when each attempt gets a new context
+func TestIsRetryableWithFreshContext(t *testing.T) {
+	perCall := NewTimeoutError("operation timed out", "Process", 30*time.Second, WithCause(context.DeadlineExceeded))
+	headroom := NewTimeoutError("no headroom", "Process", 0, WithCause(context.DeadlineExceeded)).(*TimeoutError)
+	headroom.Source = TimeoutSourceHeadroom
+
+	tests := []struct {
+		name           string
+		err            error
+		wantRetryable  bool
+		wantFreshRetry bool
+	}{
+		{name: "nil", err: nil},
+		{name: "bare DeadlineExceeded", err: context.DeadlineExceeded},
+		{name: "fmt-wrapped DeadlineExceeded", err: fmt.Errorf("query: %w", context.DeadlineExceeded)},
+		{name: "Wrap of DeadlineExceeded", err: Wrap(context.DeadlineExceeded, "query failed")},
+		{name: "TimeoutError wrapping DeadlineExceeded", err: perCall, wantFreshRetry: true},
+		{name: "wrapped TimeoutError wrapping DeadlineExceeded", err: fmt.Errorf("job: %w", perCall), wantFreshRetry: true},
+		{name: "NetworkError wrapping DeadlineExceeded", err: NewNetworkError("dial timed out", "Connect", WithTransient(true), WithCause(context.DeadlineExceeded)), wantFreshRetry: true},
+		{name: "headroom TimeoutError", err: headroom},
+		{name: "ValidationError wrapping DeadlineExceeded", err: NewValidationError("invalid", "field", WithCause(context.DeadlineExceeded))},
+		{name: "bare Canceled", err: context.Canceled},
+		{name: "TimeoutError wrapping Canceled", err: NewTimeoutError("operation timed out", "Process", time.Second, WithCause(context.Canceled))},
+		{name: "no context error", err: NewHTTPError(503, "Service Unavailable", nil), wantRetryable: true, wantFreshRetry: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := IsRetryable(tt.err); got != tt.wantRetryable {
+				t.Errorf("IsRetryable() = %v, want %v", got, tt.wantRetryable)
+			}
+			if got := IsRetryableWithFreshContext(tt.err); got != tt.wantFreshRetry {
+				t.Errorf("IsRetryableWithFreshContext() = %v, want %v", got, tt.wantFreshRetry)
+			}
+		})
+	}
+}
+
 // TestIsRetryableTimeout tests the IsRetryableTimeout function
 func TestIsRetryableTimeout(t *testing.T) {
 	tests := []struct {
@@ -414,10 +453,15 @@ func TestIsPermanentError(t *testing.T) {
 			permanent: true,
 		},
 		{
-			name:      "circuit breaker",
-			err:       ErrCircuitOpen,
+			name:      "circuit breaker error",
+			err:       NewCircuitBreakerError("tripped", "Charge", "open"),
 			permanent: true,
 		},
+		{
+			name:      "circuit open sentinel is retryable, not permanent",
+			err:       ErrCircuitOpen,
+			permanent: false,
+		},
 		{
 			name:      "404 HTTP error",
 			err:       NewHTTPError(404, "Not Found", nil),
@@ -495,6 +539,15 @@ func TestErrorWrapping(t *testing.T) {
 		if IsRetryable(timeoutErr) {
 			t.Error("TimeoutError wrapping context.DeadlineExceeded should NOT be retryable")
 		}
+
+		// Under a fresh context per attempt, the expired deadline no longer
+		// vetoes the TimeoutError
+		if !IsRetryableWithFreshContext(timeoutErr) {
+			t.Error("TimeoutError wrapping context.DeadlineExceeded should be retryable with a fresh context")
+		}
+		if !Is(timeoutErr, context.DeadlineExceeded) {
+			t.Error("IsRetryableWithFreshContext should leave the error's chain alone")
+		}
 	})
 }
 
@@ -578,6 +631,85 @@ func TestStackTrace(t *testing.T) {
 	})
 }
 
+// TestTypedStackTrace tests that typed errors record the stack of their creation site
+func TestTypedStackTrace(t *testing.T) {
+	tests := []struct {
+		name string
+		err  func() error
+	}{
+		{"HTTPError", func() error { return NewHTTPError(502, "Bad Gateway", nil) }},
+		{"NotFoundError", func() error { return NewNotFoundError("order", "42") }},
+		{"ConflictError", func() error { return NewConflictError("order", "42") }},
+		{"DatabaseError", func() error { return NewDatabaseError("UpdateOrder", "orders") }},
+		{"ProviderError", func() error { return NewProviderError("stripe") }},
+		{"ConfigError", func() error { return NewConfigError("must be at least 1", "max") }},
+		{"ValidationError", func() error { return NewValidationError("invalid email", "email") }},
+		{"TimeoutError", func() error { return NewTimeoutError("too slow", "GetQuote", time.Second) }},
+		{"RateLimitError", func() error { return NewRateLimitError("slow down", "List", time.Second) }},
+		{"RetryableError", func() error { return NewRetryableError("busy", "Lock", time.Second) }},
+		{"ProcessingError", func() error { return NewProcessingError("failed", "Process") }},
+		{"RetryableProcessingError", func() error { return NewRetryableProcessingError("failed", "Process") }},
+		{"NetworkError", func() error { return NewNetworkError("refused", "Dial") }},
+		{"SerializationError", func() error { return NewDecodeError("bad body", "Parse", "json") }},
+		{"CircuitBreakerError", func() error { return NewCircuitBreakerError("open", "Charge", "open") }},
+		{"ConsistencyError", func() error { return NewConsistencyError("order/42", "17", "15", time.Second) }},
+		{"NotImplementedError", func() error { return NewNotImplementedError("exports") }},
+		{"UnsupportedError", func() error { return NewUnsupportedError("HEIC", "JPEG") }},
+		{"RetryError", func() error { return NewRetryError(3, 3, nil, nil) }},
+		{"PanicError", func() error { return NewPanicError("boom") }},
+		{"headroom", func() error {
+			ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond)
+			defer cancel()
+			return RequireHeadroom(ctx, time.Hour, "Sync")
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.err()
+			if !HasStackTrace(err) {
+				t.Fatal("HasStackTrace() = false, want true")
+			}
+			if trace := GetStackTrace(err); !strings.Contains(trace, "errors_test.go") {
+				t.Errorf("GetStackTrace() doesn't reach the creation site:\n%s", trace)
+			}
+			first := strings.SplitN(GetOriginStackTrace(err), "\n", 2)[0]
+			if !strings.Contains(first, "TestTypedStackTrace") {
+				t.Errorf("stack starts at %q, want the creating function", first)
+			}
+		})
+	}
+
+	t.Run("cause with a stack", func(t *testing.T) {
+		cause := New("connection reset")
+		err := NewHTTPError(502, "Bad Gateway", cause)
+		if trace := err.(*HTTPError).StackTrace(); trace != nil {
+			t.Errorf("StackTrace() = %d frames, want none when the cause has a stack", len(trace))
+		}
+		if GetOriginStackTrace(err) != GetOriginStackTrace(cause) {
+			t.Error("origin stack should be the cause's")
+		}
+	})
+
+	t.Run("typed cause", func(t *testing.T) {
+		err := NewProcessingError("failed", "Process", WithCause(NewNetworkError("refused", "Dial")))
+		if trace := err.(*ProcessingError).StackTrace(); trace != nil {
+			t.Errorf("StackTrace() = %d frames, want none when the cause has a stack", len(trace))
+		}
+		if !HasStackTrace(err) {
+			t.Error("HasStackTrace() = false, want the cause's stack")
+		}
+	})
+
+	t.Run("no stack", func(t *testing.T) {
+		for _, err := range []error{NewHTTPErrorFast(503, "Unavailable"), Decode(Encode(NewHTTPError(503, "Unavailable", nil)))} {
+			if HasStackTrace(err) {
+				t.Errorf("HasStackTrace(%T) = true, want false", err)
+			}
+		}
+	})
+}
+
 // TestFormatError tests error formatting
 func TestFormatError(t *testing.T) {
 	tests := []struct {
@@ -646,6 +778,68 @@ func TestExtractErrorInfo(t *testing.T) {
 	}
 }
 
+// TestExtractErrorInfoWrapped tests that wrapping doesn't hide the typed error
+func TestExtractErrorInfoWrapped(t *testing.T) {
+	httpErr := NewHTTPError(503, "down", nil)
+
+	tests := []struct {
+		name        string
+		err         error
+		wantType    string
+		wantContext []string
+		wantFormat  string
+	}{
+		{
+			name:       "unwrapped",
+			err:        httpErr,
+			wantType:   "HTTPError",
+			wantFormat: "HTTPError(503): HTTP 503: down",
+		},
+		{
+			name:        "Wrap",
+			err:         Wrap(httpErr, "calling billing"),
+			wantType:    "HTTPError",
+			wantContext: []string{"calling billing"},
+			wantFormat:  "HTTPError(503): calling billing: HTTP 503: down",
+		},
+		{
+			name:        "nested wraps",
+			err:         fmt.Errorf("charging order 42: %w", Wrap(httpErr, "calling billing")),
+			wantType:    "HTTPError",
+			wantContext: []string{"charging order 42", "calling billing"},
+			wantFormat:  "HTTPError(503): charging order 42: calling billing: HTTP 503: down",
+		},
+		{
+			name:       "untyped",
+			err:        Wrap(fmt.Errorf("disk full"), "saving"),
+			wantType:   "Error",
+			wantFormat: "Error: saving: disk full",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			info := ExtractErrorInfo(tt.err)
+			if info["type"] != tt.wantType {
+				t.Errorf("type = %v, want %s", info["type"], tt.wantType)
+			}
+			if info["message"] != tt.err.Error() {
+				t.Errorf("message = %v, want the outermost message %q", info["message"], tt.err.Error())
+			}
+			if tt.wantType == "HTTPError" && info["status_code"] != 503 {
+				t.Errorf("status_code = %v, want 503", info["status_code"])
+			}
+			context, _ := info["context"].([]string)
+			if fmt.Sprint(context) != fmt.Sprint(tt.wantContext) {
+				t.Errorf("context = %q, want %q", context, tt.wantContext)
+			}
+			if got := FormatError(tt.err); got != tt.wantFormat {
+				t.Errorf("FormatError() = %q, want %q", got, tt.wantFormat)
+			}
+		})
+	}
+}
+
 // TestIsTimeout tests timeout detection
 func TestIsTimeout(t *testing.T) {
 	tests := []struct {
@@ -837,6 +1031,36 @@ func TestReExports(t *testing.T) {
 	})
 }
 
+// TestUnwrapCauseTypedErrors tests that Unwrap and Cause follow every typed error's primary cause
+func TestUnwrapCauseTypedErrors(t *testing.T) {
+	root := fmt.Errorf("connection refused")
+	for _, d := range TypeDescriptors() {
+		t.Run(d.Name, func(t *testing.T) {
+			primary := fmt.Errorf("dial: %w", root)
+			typed := cloneNode(d.Example)
+			setCause(typed, primary)
+			WithAdditionalCause(fmt.Errorf("cleanup failed"))(typed)
+
+			if got := Unwrap(typed); got != primary {
+				t.Errorf("Unwrap() = %v, want the primary cause", got)
+			}
+			if got := Cause(typed); got != root {
+				t.Errorf("Cause() = %v, want the root cause", got)
+			}
+			if got := Cause(Wrap(typed, "handler")); got != root {
+				t.Errorf("Cause() of a wrapped %s = %v, want the root cause", d.Name, got)
+			}
+			if got := Cause(cloneNode(d.Example)); got == nil {
+				t.Error("Cause() without a cause = nil, want the error itself")
+			}
+		})
+	}
+
+	if Unwrap((*HTTPError)(nil)) != nil || Cause((*HTTPError)(nil)) == nil {
+		t.Error("typed nil should unwrap to nil and be its own cause")
+	}
+}
+
 // TestAllOptions tests all option functions for coverage
 func TestAllOptions(t *testing.T) {
 	t.Run("WithMessage", func(t *testing.T) {
@@ -1012,17 +1236,46 @@ func TestNewInternalError(t *testing.T) {
 }
 
 func TestNewNotFoundError(t *testing.T) {
-	err := NewNotFoundError("user missing", nil)
+	cause := fmt.Errorf("sql: no rows in result set")
+	err := NewNotFoundError("order", "42", WithCause(cause), WithComponent("orders"))
 
-	httpErr, ok := IsHTTPError(err)
+	notFoundErr, ok := IsNotFoundError(err)
 	if !ok {
-		t.Fatal("NewNotFoundError should return an HTTPError")
+		t.Fatal("NewNotFoundError should return a NotFoundError")
+	}
+	if notFoundErr.Resource != "order" || notFoundErr.ID != "42" || !Is(err, cause) {
+		t.Errorf("unexpected fields: %+v", notFoundErr)
+	}
+	if want := "orders: order 42 not found: sql: no rows in result set"; err.Error() != want {
+		t.Errorf("Error() = %q, want %q", err.Error(), want)
+	}
+	if HTTPStatus(err) != 404 || GetHTTPStatusCode(err) != 404 || ToGRPCStatus(err) != GRPCNotFound {
+		t.Errorf("HTTPStatus() = %d, ToGRPCStatus() = %v, want 404 and NotFound", HTTPStatus(err), ToGRPCStatus(err))
+	}
+	if !IsNotFound(err) || !IsPermanentError(err) || IsRetryable(err) || Classify(err) != ClassPermanent {
+		t.Error("NotFoundError should be a permanent, non-retryable not found")
 	}
-	if httpErr.StatusCode != 404 {
-		t.Errorf("expected status 404, got %d", httpErr.StatusCode)
+}
+
+// TestNotFoundSentinels tests that the sentinels match NotFoundErrors for the same resource
+func TestNotFoundSentinels(t *testing.T) {
+	activity := NewNotFoundError("activity", "a-1")
+	if !Is(activity, ErrActivityNotFound) || Is(activity, ErrLocationNotFound) {
+		t.Error("a missing activity should match ErrActivityNotFound only")
+	}
+	if !Is(Wrap(ErrLocationNotFound, "loading"), ErrLocationNotFound) {
+		t.Error("a wrapped sentinel should still match itself")
+	}
+	if ErrActivityNotFound.Error() != "activity not found" || ErrLocationNotFound.Error() != "location not found" {
+		t.Errorf("sentinel messages changed: %q, %q", ErrActivityNotFound, ErrLocationNotFound)
 	}
-	if !IsNotFound(err) {
-		t.Error("NewNotFoundError result should match IsNotFound")
+	if !Is(NewNotFoundError("invoice", "7"), &NotFoundError{Resource: "invoice"}) {
+		t.Error("a target with only Resource set should match any ID")
+	}
+
+	decoded := Decode(Encode(Wrap(ErrActivityNotFound, "loading")))
+	if !Is(decoded, ErrActivityNotFound) {
+		t.Errorf("decoded %v should still match the sentinel", decoded)
 	}
 }
 
@@ -1037,6 +1290,7 @@ func TestIsNotFound(t *testing.T) {
 		{"activity sentinel", ErrActivityNotFound, true},
 		{"location sentinel", ErrLocationNotFound, true},
 		{"wrapped activity", fmt.Errorf("lookup: %w", ErrActivityNotFound), true},
+		{"not found error", fmt.Errorf("lookup: %w", NewNotFoundError("invoice", "7")), true},
 		{"http 404", NewHTTPError(404, "missing", nil), true},
 		{"http 500", NewHTTPError(500, "boom", nil), false},
 	}
@@ -1073,3 +1327,353 @@ func TestRetryableErrorType(t *testing.T) {
 		t.Error("bare RetryableError.Error should return non-empty string")
 	}
 }
+
+// TestRetryHintErrorGolden pins the Error() formats of the RetryHint-based types
+func TestRetryHintErrorGolden(t *testing.T) {
+	cause := fmt.Errorf("upstream said no")
+
+	tests := []struct {
+		name string
+		err  error
+		want string
+	}{
+		{
+			name: "RateLimitError bare",
+			err:  NewRateLimitError("quota exceeded", "CallAPI", 60*time.Second),
+			want: "rate limited in CallAPI (retry after 1m0s): quota exceeded",
+		},
+		{
+			name: "RateLimitError with component and cause",
+			err:  NewRateLimitError("quota exceeded", "CallAPI", 2*time.Second, WithComponent("billing"), WithCause(cause)),
+			want: "rate limited in billing/CallAPI (retry after 2s): quota exceeded: upstream said no",
+		},
+		{
+			name: "RetryableError bare",
+			err:  NewRetryableError("slow upstream", "Fetch", 5*time.Second),
+			want: "retryable error in Fetch (retry after 5s): slow upstream",
+		},
+		{
+			name: "RetryableError with component and cause",
+			err:  NewRetryableError("slow upstream", "Fetch", 500*time.Millisecond, WithComponent("api"), WithCause(cause)),
+			want: "retryable error in api/Fetch (retry after 500ms): slow upstream: upstream said no",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.err.Error(); got != tt.want {
+				t.Errorf("Error() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+// TestRateLimitErrorSentinelUnwrap tests that RateLimitError unwraps to ErrRateLimited
+func TestRateLimitErrorSentinelUnwrap(t *testing.T) {
+	cause := fmt.Errorf("429 from upstream")
+	err := NewRateLimitError("quota exceeded", "CallAPI", time.Second, WithCause(cause))
+
+	if !Is(err, ErrRateLimited) {
+		t.Error("RateLimitError should unwrap to ErrRateLimited")
+	}
+	if !Is(err, cause) {
+		t.Error("cause should remain in error chain")
+	}
+	if !Is(Wrap(err, "calling billing"), ErrRateLimited) {
+		t.Error("wrapped RateLimitError should unwrap to ErrRateLimited")
+	}
+	if Is(NewRetryableError("slow", "Fetch", time.Second), ErrRateLimited) {
+		t.Error("RetryableError should not unwrap to ErrRateLimited")
+	}
+}
+
+// TestAsRetryable tests the uniform view over RetryableError and RateLimitError
+func TestAsRetryable(t *testing.T) {
+	t.Run("RetryableError is returned as-is", func(t *testing.T) {
+		err := NewRetryableError("slow", "Fetch", 3*time.Second)
+
+		re, ok := AsRetryable(Wrap(err, "outer"))
+		if !ok {
+			t.Fatal("expected AsRetryable to find RetryableError")
+		}
+		if re != err.(*RetryableError) {
+			t.Error("expected the original RetryableError")
+		}
+	})
+
+	t.Run("RateLimitError is viewed as RetryableError", func(t *testing.T) {
+		err := NewRateLimitError("quota", "CallAPI", 7*time.Second, WithComponent("billing"))
+
+		re, ok := AsRetryable(Wrap(err, "outer"))
+		if !ok {
+			t.Fatal("expected AsRetryable to find RateLimitError")
+		}
+		if re.RetryAfter != 7*time.Second || re.Operation != "CallAPI" || re.Component != "billing" {
+			t.Errorf("unexpected view: %+v", re.RetryHint)
+		}
+	})
+
+	t.Run("other errors", func(t *testing.T) {
+		if _, ok := AsRetryable(NewHTTPError(503, "down", nil)); ok {
+			t.Error("HTTPError should not be viewed as RetryableError")
+		}
+		if _, ok := AsRetryable(nil); ok {
+			t.Error("nil should not be viewed as RetryableError")
+		}
+	})
+}
+
+// TestHTTPErrorOriginComponent tests OriginComponent derivation from the cause
+func TestHTTPErrorOriginComponent(t *testing.T) {
+	t.Run("derived from cause", func(t *testing.T) {
+		cause := Wrap(NewTimeoutError("slow", "Query", time.Second, WithComponent("orders-db")), "loading order")
+		err := NewHTTPError(502, "Bad Gateway", cause)
+
+		httpErr, _ := IsHTTPError(err)
+		if httpErr.OriginComponent != "orders-db" {
+			t.Errorf("got OriginComponent=%q, want orders-db", httpErr.OriginComponent)
+		}
+
+		info := ExtractErrorInfo(err)
+		if info["origin_component"] != "orders-db" {
+			t.Errorf("got origin_component=%v, want orders-db", info["origin_component"])
+		}
+	})
+
+	t.Run("empty without component in cause", func(t *testing.T) {
+		err := NewHTTPError(502, "Bad Gateway", fmt.Errorf("plain"))
+
+		httpErr, _ := IsHTTPError(err)
+		if httpErr.OriginComponent != "" {
+			t.Errorf("got OriginComponent=%q, want empty", httpErr.OriginComponent)
+		}
+		if _, ok := ExtractErrorInfo(err)["origin_component"]; ok {
+			t.Error("origin_component should be omitted when empty")
+		}
+	})
+}
+
+// TestGetComponent tests component lookup through the error chain
+func TestGetComponent(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want string
+	}{
+		{"nil error", nil, ""},
+		{"generic error", fmt.Errorf("plain"), ""},
+		{"direct", NewNetworkError("down", "Dial", WithComponent("gateway")), "gateway"},
+		{"wrapped", Wrap(NewRateLimitError("slow", "Call", time.Second, WithComponent("billing")), "outer"), "billing"},
+		{"outermost wins", NewProcessingError("failed", "Run", WithComponent("worker"),
+			WithCause(NewHTTPError(500, "boom", nil))), "worker"},
+		{"skips empty", NewProcessingError("failed", "Run",
+			WithCause(NewRetryError(3, 3, nil, nil, WithComponent("retrier")))), "retrier"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := GetComponent(tt.err); got != tt.want {
+				t.Errorf("GetComponent() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+// TestWithComponentEveryType tests that every type takes a component and reports it through GetComponent, ExtractErrorInfo and FormatError
+func TestWithComponentEveryType(t *testing.T) {
+	for _, typ := range formatTypes {
+		t.Run(typ.name, func(t *testing.T) {
+			err := Wrap(typ.new(nil, []Option{WithComponent("billing")}), "outer")
+			if got := GetComponent(err); got != "billing" {
+				t.Errorf("GetComponent() = %q, want billing", got)
+			}
+			if got := ExtractErrorInfo(err)["component"]; got != "billing" {
+				t.Errorf("ExtractErrorInfo()[component] = %v, want billing", got)
+			}
+			if got := FormatError(err); !strings.Contains(got, "billing") {
+				t.Errorf("FormatError() = %q, want it to name the component", got)
+			}
+		})
+	}
+
+	if _, ok := ExtractErrorInfo(NewNetworkError("down", "Dial"))["component"]; ok {
+		t.Error("ExtractErrorInfo() should omit an empty component")
+	}
+}
+
+// newOriginError creates a stack-carrying error from a distinct function
+func newOriginError() error {
+	return New("connection reset")
+}
+
+// TestGetOriginStackTrace tests that the innermost stack trace is preferred
+func TestGetOriginStackTrace(t *testing.T) {
+	t.Run("prefers cause stack", func(t *testing.T) {
+		err := Wrap(NewHTTPError(502, "Bad Gateway", newOriginError()), "middleware")
+
+		trace := GetOriginStackTrace(err)
+		if !strings.HasPrefix(trace, "github.com/JohnPlummer/jp-go-errors.newOriginError") {
+			t.Errorf("origin stack should start at newOriginError, got:\n%s", trace)
+		}
+	})
+
+	t.Run("no stack", func(t *testing.T) {
+		if trace := GetOriginStackTrace(fmt.Errorf("plain")); trace != "" {
+			t.Errorf("expected empty trace, got %q", trace)
+		}
+		if trace := GetOriginStackTrace(nil); trace != "" {
+			t.Errorf("expected empty trace for nil, got %q", trace)
+		}
+	})
+}
+
+// TestAdditionalCauses tests typed errors carrying secondary causes
+func TestAdditionalCauses(t *testing.T) {
+	primary := fmt.Errorf("write to primary failed")
+	secondary := fmt.Errorf("compensating delete failed")
+
+	tests := []struct {
+		name          string
+		err           error
+		wantMessage   string
+		wantRetryable bool
+		wantIs        []error
+	}{
+		{
+			name: "one additional cause",
+			err: NewProcessingError("Failed to save", "SaveOrder",
+				WithCause(primary), WithAdditionalCause(secondary)),
+			wantMessage: "Failed to save: SaveOrder failed (not retryable): write to primary failed (+1 more cause)",
+			wantIs:      []error{primary, secondary},
+		},
+		{
+			name: "several additional causes",
+			err: NewHTTPError(502, "Bad Gateway", primary,
+				WithAdditionalCause(secondary), WithAdditionalCause(ErrDeadlock), WithAdditionalCause(nil)),
+			wantMessage:   "HTTP 502: Bad Gateway: write to primary failed (+2 more causes)",
+			wantRetryable: true,
+			wantIs:        []error{primary, secondary, ErrDeadlock},
+		},
+		{
+			name:        "no primary cause",
+			err:         NewValidationError("bad", "email", WithAdditionalCause(secondary)),
+			wantMessage: "validation failed for field 'email' (value: <nil>): bad: compensating delete failed",
+			wantIs:      []error{secondary},
+		},
+		{
+			name: "retryable secondary does not promote",
+			err: NewProcessingError("Failed to save", "SaveOrder",
+				WithCause(primary), WithAdditionalCause(ErrNetworkTimeout), WithAdditionalCause(Transient(secondary))),
+			wantMessage: "Failed to save: SaveOrder failed (not retryable): write to primary failed (+2 more causes)",
+			wantIs:      []error{ErrNetworkTimeout, secondary},
+		},
+		{
+			name: "retryable primary",
+			err: NewProcessingError("Failed to save", "SaveOrder",
+				WithCause(ErrNetworkTimeout), WithAdditionalCause(secondary)),
+			wantMessage:   "Failed to save: SaveOrder failed (not retryable): network timeout (+1 more cause)",
+			wantRetryable: true,
+		},
+		{
+			name: "context error in secondary blocks retry",
+			err: NewRateLimitError("slow down", "Search", time.Second,
+				WithAdditionalCause(context.Canceled)),
+			wantMessage: "rate limited in Search (retry after 1s): slow down: context canceled",
+			wantIs:      []error{ErrRateLimited, context.Canceled},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if msg := tt.err.Error(); msg != tt.wantMessage {
+				t.Errorf("Error() = %q, want %q", msg, tt.wantMessage)
+			}
+			if got := IsRetryable(tt.err); got != tt.wantRetryable {
+				t.Errorf("IsRetryable() = %v, want %v", got, tt.wantRetryable)
+			}
+			for _, target := range tt.wantIs {
+				if !Is(tt.err, target) {
+					t.Errorf("Is(%v) = false, want true", target)
+				}
+			}
+		})
+	}
+
+	t.Run("unwrap order", func(t *testing.T) {
+		err := NewTimeoutError("slow", "Fetch", time.Second,
+			WithCause(primary), WithAdditionalCause(secondary))
+
+		causes := err.(*TimeoutError).Unwrap()
+		if len(causes) != 2 || causes[0] != primary || causes[1] != secondary {
+			t.Errorf("Unwrap() = %v, want [primary secondary]", causes)
+		}
+	})
+
+	t.Run("structured info lists causes", func(t *testing.T) {
+		err := NewNetworkError("write failed", "Write", WithCause(primary), WithAdditionalCause(secondary))
+
+		causes, ok := ExtractErrorInfo(err)["causes"].([]string)
+		if !ok || len(causes) != 2 || causes[0] != primary.Error() || causes[1] != secondary.Error() {
+			t.Errorf("causes = %v, want both cause messages", ExtractErrorInfo(err)["causes"])
+		}
+		if _, ok := ExtractErrorInfo(NewNetworkError("x", "y", WithCause(primary)))["causes"]; ok {
+			t.Error("causes should be omitted without additional causes")
+		}
+	})
+
+	t.Run("nested typed error keeps count", func(t *testing.T) {
+		inner := NewSerializationError("bad json", "Decode", "json",
+			WithCause(primary), WithAdditionalCause(secondary))
+		outer := NewHTTPError(500, "Internal", inner)
+
+		if !strings.HasSuffix(outer.Error(), "write to primary failed (+1 more cause)") {
+			t.Errorf("nested Error() lost the cause count: %q", outer.Error())
+		}
+	})
+}
+
+// TestIsValueMatching tests Is against typed targets, where zero fields are wildcards
+func TestIsValueMatching(t *testing.T) {
+	notFound := Wrap(NewHTTPError(404, "Not Found", nil, WithComponent("orders")), "loading order")
+	invalid := NewValidationError("invalid email", "email", WithCode("EMAIL_INVALID"))
+	halfOpen := NewCircuitBreakerError("probing", "Charge", "half-open")
+
+	tests := []struct {
+		name   string
+		err    error
+		target error
+		want   bool
+	}{
+		{name: "status matches", err: notFound, target: &HTTPError{StatusCode: 404}, want: true},
+		{name: "status and component match", err: notFound, target: &HTTPError{StatusCode: 404, Component: "orders"}, want: true},
+		{name: "empty HTTPError matches any", err: notFound, target: &HTTPError{}, want: true},
+		{name: "status differs", err: notFound, target: &HTTPError{StatusCode: 500}},
+		{name: "component differs", err: notFound, target: &HTTPError{StatusCode: 404, Component: "billing"}},
+		{name: "overloaded required", err: notFound, target: &HTTPError{Overloaded: true}},
+		{name: "field matches", err: invalid, target: &ValidationError{Field: "email"}, want: true},
+		{name: "field and code match", err: invalid, target: &ValidationError{Field: "email", Code: "EMAIL_INVALID"}, want: true},
+		{name: "field differs", err: invalid, target: &ValidationError{Field: "name"}},
+		{name: "state matches", err: halfOpen, target: &CircuitBreakerError{State: "half-open"}, want: true},
+		{name: "state differs", err: halfOpen, target: &CircuitBreakerError{State: "open"}},
+		{name: "other type", err: notFound, target: &ValidationError{}},
+		{name: "typed nil target", err: notFound, target: (*HTTPError)(nil)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Is(tt.err, tt.target); got != tt.want {
+				t.Errorf("Is() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	t.Run("sentinel matching unaffected", func(t *testing.T) {
+		if !Is(halfOpen, ErrCircuitHalfOpen) || Is(halfOpen, ErrCircuitOpen) {
+			t.Error("circuit state sentinels should still match by state")
+		}
+		cause := NewHTTPError(503, "Service Unavailable", ErrInvalidResponse)
+		if !Is(cause, ErrInvalidResponse) || !Is(cause, cause) {
+			t.Error("causes and identity should still match")
+		}
+	})
+}
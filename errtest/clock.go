@@ -0,0 +1,61 @@
+// Package errtest provides helpers for testing code built on jp-go-errors:
+// builders for realistic error values, and a fake clock for the package's
+// time seam so duration-dependent behavior can be tested without sleeping.
+package errtest
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// FakeClock is a manually advanced clock. It satisfies errors.Clock and
+// errors.Sleeper: Sleep advances the clock by the requested duration and
+// returns immediately. Safe for concurrent use.
+//
+// Example:
+//
+//	clock := errtest.NewFakeClock(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+//	errors.SetClock(clock)
+//	defer errors.SetClock(nil)
+//	clock.Advance(time.Minute)
+type FakeClock struct {
+	mu  sync.Mutex
+	now time.Time
+}
+
+// NewFakeClock returns a FakeClock reading start.
+func NewFakeClock(start time.Time) *FakeClock {
+	return &FakeClock{now: start}
+}
+
+// Now returns the fake time.
+func (c *FakeClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+// Since returns the fake time elapsed since t.
+func (c *FakeClock) Since(t time.Time) time.Duration {
+	return c.Now().Sub(t)
+}
+
+// Advance moves the clock forward by d.
+func (c *FakeClock) Advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = c.now.Add(d)
+}
+
+// Sleep advances the clock by d without blocking. It returns ctx's error
+// instead if ctx is already done.
+func (c *FakeClock) Sleep(ctx context.Context, d time.Duration) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	if d > 0 {
+		c.Advance(d)
+	}
+	return nil
+}
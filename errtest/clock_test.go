@@ -0,0 +1,34 @@
+package errtest
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+// TestFakeClock tests advancing and sleeping on the fake clock
+func TestFakeClock(t *testing.T) {
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	clock := NewFakeClock(start)
+
+	clock.Advance(time.Minute)
+	if got := clock.Since(start); got != time.Minute {
+		t.Errorf("Since() after Advance = %v, want 1m", got)
+	}
+
+	if err := clock.Sleep(context.Background(), time.Hour); err != nil {
+		t.Fatalf("Sleep() error = %v", err)
+	}
+	if got := clock.Now(); !got.Equal(start.Add(61 * time.Minute)) {
+		t.Errorf("Now() after Sleep = %v, want %v", got, start.Add(61*time.Minute))
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := clock.Sleep(ctx, time.Hour); err != context.Canceled {
+		t.Errorf("Sleep() on a canceled context = %v, want context.Canceled", err)
+	}
+	if got := clock.Since(start); got != 61*time.Minute {
+		t.Errorf("canceled Sleep advanced the clock to %v", got)
+	}
+}
@@ -0,0 +1,187 @@
+package errtest
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	errors "github.com/JohnPlummer/jp-go-errors"
+)
+
+// FixtureBuilder builds an error value for table-driven tests. Pick a base
+// error with one of its type methods (HTTP, Timeout, RateLimited, ...),
+// add options and wrapping, then call Build. Options apply to the base
+// error whatever order they are added in; wraps apply outward in order.
+// The zero base is a 500 HTTPError.
+//
+// Example:
+//
+//	err := errtest.Fixture().HTTP(503).WithComponent("api").Wrapped("fetch failed").Build()
+//	// "fetch failed: HTTP 503: api: Service Unavailable"
+type FixtureBuilder struct {
+	base  func(opts ...errors.Option) error
+	opts  []errors.Option
+	wraps []string
+}
+
+// Fixture starts building an error.
+func Fixture() *FixtureBuilder {
+	return &FixtureBuilder{}
+}
+
+// HTTP makes the base an HTTPError with status and its standard text.
+func (b *FixtureBuilder) HTTP(status int) *FixtureBuilder {
+	b.base = func(opts ...errors.Option) error {
+		return errors.NewHTTPError(status, http.StatusText(status), nil, opts...)
+	}
+	return b
+}
+
+// Validation makes the base a ValidationError for field.
+func (b *FixtureBuilder) Validation(field string) *FixtureBuilder {
+	b.base = func(opts ...errors.Option) error {
+		return errors.NewValidationError("Invalid "+field, field, opts...)
+	}
+	return b
+}
+
+// Timeout makes the base a TimeoutError after d.
+func (b *FixtureBuilder) Timeout(d time.Duration) *FixtureBuilder {
+	b.base = func(opts ...errors.Option) error {
+		return errors.NewTimeoutError("timed out", "Fetch", d, opts...)
+	}
+	return b
+}
+
+// Network makes the base a transient NetworkError.
+func (b *FixtureBuilder) Network() *FixtureBuilder {
+	b.base = func(opts ...errors.Option) error {
+		return errors.NewNetworkError("connection reset by peer", "Connect", opts...)
+	}
+	return b
+}
+
+// Processing makes the base a non-retryable ProcessingError.
+func (b *FixtureBuilder) Processing() *FixtureBuilder {
+	b.base = func(opts ...errors.Option) error {
+		return errors.NewProcessingError("Failed to process", "Process", opts...)
+	}
+	return b
+}
+
+// RateLimited makes the base a RateLimitError asking callers to wait
+// retryAfter.
+func (b *FixtureBuilder) RateLimited(retryAfter time.Duration) *FixtureBuilder {
+	b.base = func(opts ...errors.Option) error {
+		return errors.NewRateLimitError("Too many requests", "Search", retryAfter, opts...)
+	}
+	return b
+}
+
+// CircuitOpen makes the base a CircuitBreakerError for an open circuit.
+func (b *FixtureBuilder) CircuitOpen() *FixtureBuilder {
+	b.base = func(opts ...errors.Option) error {
+		return errors.NewCircuitBreakerError("circuit open", "Call", "open", opts...)
+	}
+	return b
+}
+
+// RetryExhausted makes the base a RetryError after three failed attempts
+// at a 503, each recorded with its attempt number, as a retry executor
+// would return it.
+func (b *FixtureBuilder) RetryExhausted() *FixtureBuilder {
+	const maxAttempts = 3
+	b.base = func(opts ...errors.Option) error {
+		attempts := make([]error, maxAttempts)
+		for i := range attempts {
+			attempts[i] = errors.NewHTTPError(http.StatusServiceUnavailable,
+				http.StatusText(http.StatusServiceUnavailable), nil, errors.WithAttempt(i+1, maxAttempts))
+		}
+		opts = append([]errors.Option{errors.WithOperation("GET api.example.com")}, opts...)
+		return errors.NewRetryError(maxAttempts, maxAttempts, attempts[maxAttempts-1], attempts, opts...)
+	}
+	return b
+}
+
+// DeepChain wraps the error in n more layers when built, as an error
+// passed up through many call sites would be.
+func (b *FixtureBuilder) DeepChain(n int) *FixtureBuilder {
+	for i := range n {
+		b.wraps = append(b.wraps, fmt.Sprintf("layer %d", i+1))
+	}
+	return b
+}
+
+// Wrapped wraps the error with message when built.
+func (b *FixtureBuilder) Wrapped(message string) *FixtureBuilder {
+	b.wraps = append(b.wraps, message)
+	return b
+}
+
+// With adds options for the base error.
+func (b *FixtureBuilder) With(opts ...errors.Option) *FixtureBuilder {
+	b.opts = append(b.opts, opts...)
+	return b
+}
+
+// WithComponent sets the base error's component (see errors.WithComponent).
+func (b *FixtureBuilder) WithComponent(component string) *FixtureBuilder {
+	return b.With(errors.WithComponent(component))
+}
+
+// WithOperation sets the base error's operation (see errors.WithOperation).
+func (b *FixtureBuilder) WithOperation(operation string) *FixtureBuilder {
+	return b.With(errors.WithOperation(operation))
+}
+
+// WithCode sets the base error's code (see errors.WithCode).
+func (b *FixtureBuilder) WithCode(code string) *FixtureBuilder {
+	return b.With(errors.WithCode(code))
+}
+
+// WithCause sets the base error's cause (see errors.WithCause).
+func (b *FixtureBuilder) WithCause(cause error) *FixtureBuilder {
+	return b.With(errors.WithCause(cause))
+}
+
+// WithMetadata adds metadata to the base error (see errors.WithMetadata).
+func (b *FixtureBuilder) WithMetadata(key string, value any) *FixtureBuilder {
+	return b.With(errors.WithMetadata(key, value))
+}
+
+// Build returns the error. Each call constructs a new value with its own
+// stack trace.
+func (b *FixtureBuilder) Build() error {
+	base := b.base
+	if base == nil {
+		base = Fixture().HTTP(http.StatusInternalServerError).base
+	}
+	err := base(b.opts...)
+	for _, message := range b.wraps {
+		err = errors.Wrap(err, message)
+	}
+	return err
+}
+
+// Examples returns an example of every error type described by
+// errors.TypeDescriptors, including types registered with
+// errors.RegisterTypeDescriptor, keyed by type name. Use it for
+// conformance tests that every error type survives a consumer's handling.
+//
+// Example:
+//
+//	for name, err := range errtest.Examples() {
+//	    t.Run(name, func(t *testing.T) {
+//	        rec := httptest.NewRecorder()
+//	        myapi.WriteError(rec, err)
+//	        if rec.Code < 400 { t.Errorf("status %d", rec.Code) }
+//	    })
+//	}
+func Examples() map[string]error {
+	descriptors := errors.TypeDescriptors()
+	examples := make(map[string]error, len(descriptors))
+	for _, d := range descriptors {
+		examples[d.Name] = d.Example
+	}
+	return examples
+}
@@ -0,0 +1,105 @@
+package errtest
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	errors "github.com/JohnPlummer/jp-go-errors"
+)
+
+// TestFixture tests building errors with the fixture builder
+func TestFixture(t *testing.T) {
+	tests := []struct {
+		name       string
+		err        error
+		wantString string
+		wantStatus int
+		wantClass  errors.ErrorClass
+	}{
+		{
+			name:       "chained http error",
+			err:        Fixture().HTTP(503).Wrapped("fetch failed").WithComponent("api").Build(),
+			wantString: "fetch failed: HTTP 503: api: Service Unavailable",
+			wantStatus: http.StatusServiceUnavailable,
+			wantClass:  errors.ClassTransient,
+		},
+		{
+			name:       "default base",
+			err:        Fixture().Build(),
+			wantString: "HTTP 500: Internal Server Error",
+			wantStatus: http.StatusInternalServerError,
+			wantClass:  errors.ClassTransient,
+		},
+		{
+			name:       "rate limited",
+			err:        Fixture().RateLimited(5 * time.Second).Build(),
+			wantString: "rate limited in Search (retry after 5s): Too many requests",
+			wantStatus: http.StatusTooManyRequests,
+			wantClass:  errors.ClassTransient,
+		},
+		{
+			name:       "validation",
+			err:        Fixture().Validation("email").WithCode("SIGNUP_INVALID").Build(),
+			wantString: "validation failed for field 'email' (value: <nil>): Invalid email",
+			wantStatus: http.StatusBadRequest,
+			wantClass:  errors.ClassPermanent,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.err.Error(); got != tt.wantString {
+				t.Errorf("Error() = %q, want %q", got, tt.wantString)
+			}
+			if got := errors.HTTPStatus(tt.err); got != tt.wantStatus {
+				t.Errorf("HTTPStatus() = %d, want %d", got, tt.wantStatus)
+			}
+			if got := errors.Classify(tt.err); got != tt.wantClass {
+				t.Errorf("Classify() = %q, want %q", got, tt.wantClass)
+			}
+		})
+	}
+}
+
+// TestFixtureRetryExhausted tests the canned exhausted-retries fixture
+func TestFixtureRetryExhausted(t *testing.T) {
+	var retryErr *errors.RetryError
+	if !errors.As(Fixture().RetryExhausted().Build(), &retryErr) {
+		t.Fatal("RetryExhausted() did not build a RetryError")
+	}
+	if retryErr.Attempts != 3 || retryErr.MaxAttempts != 3 || len(retryErr.AllErrors) != 3 {
+		t.Errorf("got %d/%d attempts with %d errors, want 3/3 with 3", retryErr.Attempts, retryErr.MaxAttempts, len(retryErr.AllErrors))
+	}
+	if n, maxAttempts, _ := errors.GetAttempt(retryErr.LastError); n != 3 || maxAttempts != 3 {
+		t.Errorf("last error is from attempt %d/%d, want 3/3", n, maxAttempts)
+	}
+}
+
+// TestFixtureDeepChain tests wrapping a fixture in many layers
+func TestFixtureDeepChain(t *testing.T) {
+	err := Fixture().Network().DeepChain(5).Build()
+	if !strings.HasPrefix(err.Error(), "layer 5: layer 4: ") || !errors.IsNetworkError(err) {
+		t.Errorf("Error() = %q, want five layers over a NetworkError", err.Error())
+	}
+	if errors.GetOriginStackTrace(err) == "" {
+		t.Error("wrapped fixture has no stack trace")
+	}
+}
+
+// TestExamples tests that every registered type has an example
+func TestExamples(t *testing.T) {
+	defer errors.ResetTypeDescriptors()
+	errors.RegisterTypeDescriptor(errors.TypeDescriptor{Name: "QuotaError", Example: errors.New("quota exhausted")})
+
+	examples := Examples()
+	for _, name := range []string{"HTTPError", "PanicError", "RetryableError", "QuotaError"} {
+		if examples[name] == nil {
+			t.Errorf("Examples() has no %s", name)
+		}
+	}
+	if len(examples) != len(errors.TypeDescriptors()) {
+		t.Errorf("got %d examples, want one per descriptor", len(examples))
+	}
+}
@@ -0,0 +1,35 @@
+package inject_test
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	errors "github.com/JohnPlummer/jp-go-errors"
+	"github.com/JohnPlummer/jp-go-errors/errtest"
+	"github.com/JohnPlummer/jp-go-errors/errtest/inject"
+	"github.com/JohnPlummer/jp-go-errors/retry"
+)
+
+func ExampleFaulty() {
+	// A fake clock makes the backoff between attempts instant.
+	errors.SetClock(errtest.NewFakeClock(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)))
+	defer errors.SetClock(nil)
+
+	calls := 0
+	getUser := func(context.Context) (string, error) {
+		calls++
+		return "ada", nil
+	}
+	fetch := inject.Faulty(getUser, inject.Sequence(
+		inject.Times(2, errors.NewHTTPError(503, "Service Unavailable", nil)),
+	))
+
+	var user string
+	err := retry.Retry(context.Background(), func(ctx context.Context) (err error) {
+		user, err = fetch(ctx)
+		return err
+	})
+	fmt.Println(user, err, calls)
+	// Output: ada <nil> 1
+}
@@ -0,0 +1,154 @@
+// Package inject provides failure-injection helpers for chaos testing retry,
+// backoff and circuit-breaker code against the real jp-go-errors
+// classification logic rather than hand-rolled fakes.
+package inject
+
+import (
+	"context"
+	"math/rand/v2"
+	"sync"
+)
+
+// Schedule decides, call by call, whether an injected error is returned
+// instead of calling the real function. Next returns nil to let the call
+// through. Implementations must be safe for concurrent use.
+type Schedule interface {
+	Next() error
+}
+
+// Faulty wraps fn so that each call first consults schedule. When the
+// schedule yields an error it is returned (with T's zero value) and fn is
+// not called; otherwise fn runs normally.
+//
+// Example:
+//
+//	fetch := inject.Faulty(client.GetUser, inject.Sequence(
+//	    inject.Times(2, errors.NewHTTPError(503, "Service Unavailable", nil)),
+//	))
+//	var user User
+//	err := retry.Retry(ctx, func(ctx context.Context) (err error) {
+//	    user, err = fetch(ctx)
+//	    return err
+//	}) // fails twice, then succeeds on the third attempt
+func Faulty[T any](fn func(context.Context) (T, error), schedule Schedule) func(context.Context) (T, error) {
+	return func(ctx context.Context) (T, error) {
+		if err := schedule.Next(); err != nil {
+			var zero T
+			return zero, err
+		}
+		return fn(ctx)
+	}
+}
+
+// FaultyFunc is Faulty for functions that only return an error.
+func FaultyFunc(fn func(context.Context) error, schedule Schedule) func(context.Context) error {
+	return func(ctx context.Context) error {
+		if err := schedule.Next(); err != nil {
+			return err
+		}
+		return fn(ctx)
+	}
+}
+
+// Step is one stage of a Sequence: Err is injected for Count calls.
+// A nil Err lets calls through.
+type Step struct {
+	Count int
+	Err   error
+}
+
+// Times returns a Step injecting err for the next n calls.
+func Times(n int, err error) Step {
+	return Step{Count: n, Err: err}
+}
+
+// Pass returns a Step letting the next n calls through.
+func Pass(n int) Step {
+	return Step{Count: n}
+}
+
+// sequence plays steps in order, then lets every later call through.
+type sequence struct {
+	mu    sync.Mutex
+	steps []Step
+	step  int
+	used  int
+}
+
+// Sequence returns a Schedule that plays steps in order and lets every call
+// through once they are exhausted, e.g. "first 3 calls 503, then success":
+//
+//	inject.Sequence(inject.Times(3, errors.NewHTTPError(503, "down", nil)))
+func Sequence(steps ...Step) Schedule {
+	return &sequence{steps: steps}
+}
+
+func (s *sequence) Next() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for s.step < len(s.steps) && s.used >= s.steps[s.step].Count {
+		s.step++
+		s.used = 0
+	}
+	if s.step >= len(s.steps) {
+		return nil
+	}
+
+	s.used++
+	return s.steps[s.step].Err
+}
+
+// onCall injects an error on exactly one call.
+type onCall struct {
+	mu    sync.Mutex
+	n     int
+	calls int
+	err   error
+}
+
+// OnCall returns a Schedule that injects err on the nth call (1-based) only.
+func OnCall(n int, err error) Schedule {
+	return &onCall{n: n, err: err}
+}
+
+func (s *onCall) Next() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.calls++
+	if s.calls == s.n {
+		return s.err
+	}
+	return nil
+}
+
+// probability injects errors at random with a fixed probability.
+type probability struct {
+	mu  sync.Mutex
+	p   float64
+	r   *rand.Rand
+	gen func(r *rand.Rand) error
+}
+
+// Probability returns a Schedule that injects err on each call with
+// probability p, drawing from r so runs are reproducible with a fixed seed.
+func Probability(p float64, r *rand.Rand, err error) Schedule {
+	return ProbabilityFunc(p, r, func(*rand.Rand) error { return err })
+}
+
+// ProbabilityFunc is like Probability but builds each injected error with
+// gen, for example a RandomError generator.
+func ProbabilityFunc(p float64, r *rand.Rand, gen func(r *rand.Rand) error) Schedule {
+	return &probability{p: p, r: r, gen: gen}
+}
+
+func (s *probability) Next() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.r.Float64() < s.p {
+		return s.gen(s.r)
+	}
+	return nil
+}
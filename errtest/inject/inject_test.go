@@ -0,0 +1,170 @@
+package inject
+
+import (
+	"context"
+	"math/rand/v2"
+	"sync"
+	"testing"
+
+	errors "github.com/JohnPlummer/jp-go-errors"
+)
+
+// TestSequence tests "first N calls fail, then success" schedules
+func TestSequence(t *testing.T) {
+	unavailable := errors.NewHTTPError(503, "Service Unavailable", nil)
+	calls := 0
+	fetch := Faulty(func(context.Context) (string, error) {
+		calls++
+		return "ok", nil
+	}, Sequence(Times(3, unavailable)))
+
+	for i := 1; i <= 3; i++ {
+		got, err := fetch(context.Background())
+		if err != unavailable {
+			t.Fatalf("call %d: got err %v, want injected 503", i, err)
+		}
+		if got != "" {
+			t.Errorf("call %d: got %q, want zero value", i, got)
+		}
+		if !errors.IsRetryable(err) {
+			t.Errorf("call %d: injected 503 should be retryable", i)
+		}
+	}
+
+	got, err := fetch(context.Background())
+	if err != nil || got != "ok" {
+		t.Errorf("call 4: got (%q, %v), want (ok, nil)", got, err)
+	}
+	if calls != 1 {
+		t.Errorf("real function called %d times, want 1", calls)
+	}
+}
+
+// TestSequenceMixedSteps tests interleaved pass and fail steps
+func TestSequenceMixedSteps(t *testing.T) {
+	limited := errors.NewRateLimitError("slow down", "Call", 0)
+	s := Sequence(Pass(1), Times(2, limited), Pass(1), Times(1, limited))
+
+	want := []error{nil, limited, limited, nil, limited, nil, nil}
+	for i, w := range want {
+		if got := s.Next(); got != w {
+			t.Errorf("call %d: got %v, want %v", i+1, got, w)
+		}
+	}
+}
+
+// TestOnCall tests injection on exactly the Nth call
+func TestOnCall(t *testing.T) {
+	injected := errors.NewTimeoutError("slow", "Fetch", 0)
+	run := FaultyFunc(func(context.Context) error { return nil }, OnCall(2, injected))
+
+	for i := 1; i <= 4; i++ {
+		err := run(context.Background())
+		if (i == 2) != (err == injected) {
+			t.Errorf("call %d: got err %v", i, err)
+		}
+	}
+}
+
+// TestProbability tests seeded random injection
+func TestProbability(t *testing.T) {
+	injected := errors.NewNetworkError("reset", "Dial")
+
+	count := func(seed uint64) int {
+		s := Probability(0.3, rand.New(rand.NewPCG(seed, seed)), injected)
+		n := 0
+		for i := 0; i < 1000; i++ {
+			if s.Next() != nil {
+				n++
+			}
+		}
+		return n
+	}
+
+	n := count(42)
+	if n < 200 || n > 400 {
+		t.Errorf("injected %d/1000 errors, want roughly 300", n)
+	}
+	if count(42) != n {
+		t.Error("same seed should inject the same number of errors")
+	}
+
+	never := Probability(0, rand.New(rand.NewPCG(1, 1)), injected)
+	always := Probability(1, rand.New(rand.NewPCG(1, 1)), injected)
+	for i := 0; i < 100; i++ {
+		if never.Next() != nil {
+			t.Fatal("p=0 should never inject")
+		}
+		if always.Next() == nil {
+			t.Fatal("p=1 should always inject")
+		}
+	}
+}
+
+// TestScheduleConcurrency tests that schedules are safe for concurrent use
+func TestScheduleConcurrency(t *testing.T) {
+	s := Sequence(Times(50, errors.ErrServerError))
+
+	var mu sync.Mutex
+	injected := 0
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 10; j++ {
+				if s.Next() != nil {
+					mu.Lock()
+					injected++
+					mu.Unlock()
+				}
+			}
+		}()
+	}
+	wg.Wait()
+
+	if injected != 50 {
+		t.Errorf("injected %d errors, want 50", injected)
+	}
+}
+
+// TestRandomError tests that generated errors classify as requested
+func TestRandomError(t *testing.T) {
+	r := rand.New(rand.NewPCG(7, 11))
+	classes := []errors.ErrorClass{
+		errors.ClassTransient,
+		errors.ClassPermanent,
+		errors.ClassContext,
+		errors.ClassUnknown,
+	}
+
+	for _, class := range classes {
+		t.Run(string(class), func(t *testing.T) {
+			seen := make(map[string]bool)
+			for i := 0; i < 200; i++ {
+				err := RandomError(class, r)
+				if got := errors.Classify(err); got != class {
+					t.Fatalf("Classify(%v) = %s, want %s", err, got, class)
+				}
+				seen[errors.FormatError(err)[:8]] = true
+			}
+			if len(seen) < 2 {
+				t.Errorf("expected varied errors, got %d distinct kinds", len(seen))
+			}
+		})
+	}
+}
+
+// TestProbabilityFuncWithRandomError tests random injection of random typed errors
+func TestProbabilityFuncWithRandomError(t *testing.T) {
+	r := rand.New(rand.NewPCG(3, 5))
+	s := ProbabilityFunc(0.5, r, func(r *rand.Rand) error {
+		return RandomError(errors.ClassTransient, r)
+	})
+
+	for i := 0; i < 100; i++ {
+		if err := s.Next(); err != nil && !errors.IsRetryable(err) {
+			t.Fatalf("injected error should be retryable: %v", err)
+		}
+	}
+}
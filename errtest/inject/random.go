@@ -0,0 +1,105 @@
+package inject
+
+import (
+	"context"
+	"fmt"
+	"math/rand/v2"
+	"net/http"
+	"time"
+
+	errors "github.com/JohnPlummer/jp-go-errors"
+)
+
+// RandomError returns a varied but valid typed error whose errors.Classify
+// result is class, drawing choices from r. ClassUnknown and unrecognised
+// classes produce errors carrying no classification information.
+//
+// Example:
+//
+//	r := rand.New(rand.NewPCG(1, 2))
+//	err := inject.RandomError(errors.ClassTransient, r) // e.g. a 503 HTTPError
+func RandomError(class errors.ErrorClass, r *rand.Rand) error {
+	switch class {
+	case errors.ClassTransient:
+		return randomTransient(r)
+	case errors.ClassPermanent:
+		return randomPermanent(r)
+	case errors.ClassContext:
+		return randomContext(r)
+	}
+	return randomUnknown(r)
+}
+
+var (
+	transientStatuses = []int{
+		http.StatusTooManyRequests,
+		http.StatusInternalServerError,
+		http.StatusBadGateway,
+		http.StatusServiceUnavailable,
+		http.StatusGatewayTimeout,
+	}
+	permanentStatuses = []int{
+		http.StatusBadRequest,
+		http.StatusUnauthorized,
+		http.StatusForbidden,
+		http.StatusNotFound,
+		http.StatusConflict,
+		http.StatusUnprocessableEntity,
+	}
+)
+
+func randomTransient(r *rand.Rand) error {
+	retryAfter := time.Duration(r.IntN(10)+1) * time.Second
+
+	switch r.IntN(6) {
+	case 0:
+		status := transientStatuses[r.IntN(len(transientStatuses))]
+		return errors.NewHTTPError(status, http.StatusText(status), nil)
+	case 1:
+		return errors.NewRateLimitError("injected rate limit", "Inject", retryAfter)
+	case 2:
+		return errors.NewRetryableError("injected retryable failure", "Inject", retryAfter)
+	case 3:
+		return errors.NewTimeoutError("injected timeout", "Inject", time.Duration(r.IntN(30)+1)*time.Second)
+	case 4:
+		return errors.NewNetworkError("injected connection reset", "Inject")
+	default:
+		return errors.NewRetryableProcessingError("injected processing failure", "Inject")
+	}
+}
+
+func randomPermanent(r *rand.Rand) error {
+	switch r.IntN(3) {
+	case 0:
+		status := permanentStatuses[r.IntN(len(permanentStatuses))]
+		return errors.NewHTTPError(status, http.StatusText(status), nil)
+	case 1:
+		return errors.NewValidationError("injected invalid input", "field",
+			errors.WithValue(r.IntN(100)))
+	default:
+		return errors.NewProcessingError("injected failure", "Inject",
+			errors.WithCause(errors.NewValidationError("injected invalid input", "field")))
+	}
+}
+
+func randomContext(r *rand.Rand) error {
+	cause := context.Canceled
+	if r.IntN(2) == 0 {
+		cause = context.DeadlineExceeded
+	}
+	if r.IntN(2) == 0 {
+		return errors.NewTimeoutError("injected timeout", "Inject", time.Second, errors.WithCause(cause))
+	}
+	return errors.Wrap(cause, "injected")
+}
+
+func randomUnknown(r *rand.Rand) error {
+	switch r.IntN(3) {
+	case 0:
+		return fmt.Errorf("injected failure %d", r.IntN(1000))
+	case 1:
+		return errors.Errorf("injected failure %d", r.IntN(1000))
+	default:
+		return errors.NewSerializationError("injected malformed payload", "Inject", "json")
+	}
+}
@@ -0,0 +1,179 @@
+package errors
+
+import (
+	"context"
+	"reflect"
+	"sync"
+	"time"
+)
+
+// defaultMaxEscalationKeys bounds how many fingerprints an Escalator tracks.
+const defaultMaxEscalationKeys = 10000
+
+// EscalationThreshold escalates errors to Severity once more than Count of
+// them share a fingerprint within the policy window.
+type EscalationThreshold struct {
+	Count    int
+	Severity Severity
+}
+
+// EscalationPolicy describes when repeats of the same failure escalate.
+// Thresholds are in increasing order of Count. An escalated fingerprint
+// drops back a level only once its count falls to Hysteresis times the
+// threshold's Count or below, so a rate hovering near a threshold doesn't
+// flap. Zero Hysteresis means none: a fingerprint drops back as soon as
+// its count is no longer above the threshold.
+type EscalationPolicy struct {
+	Window     time.Duration
+	Thresholds []EscalationThreshold
+	Hysteresis float64
+}
+
+// DefaultEscalationPolicy escalates a fingerprint to SeverityError above
+// 100 occurrences a minute and to SeverityCritical above 1000, and
+// de-escalates once the rate halves.
+var DefaultEscalationPolicy = EscalationPolicy{
+	Window: time.Minute,
+	Thresholds: []EscalationThreshold{
+		{Count: 100, Severity: SeverityError},
+		{Count: 1000, Severity: SeverityCritical},
+	},
+	Hysteresis: 0.5,
+}
+
+// Escalator raises the severity of errors as the same failure (by
+// Fingerprint) repeats: a single timeout is a warning, the thousandth in a
+// minute is an incident. Occurrences are counted over a sliding window per
+// fingerprint; fingerprints idle for a whole window are forgotten, and the
+// least recently seen are evicted once the key limit is reached. Safe for
+// concurrent use.
+type Escalator struct {
+	policy  EscalationPolicy
+	maxKeys int
+	clock   Clock
+
+	mu     sync.Mutex
+	levels *windowedKeys[int] // index into Thresholds, -1 when not escalated
+}
+
+// NewEscalator creates an Escalator applying policy.
+// Supports WithClock and WithMaxKeys options.
+//
+// Example:
+//
+//	escalator := errors.NewEscalator(errors.DefaultEscalationPolicy)
+//	errors.SetEscalator(escalator) // Report and LogError use it
+func NewEscalator(policy EscalationPolicy, opts ...Option) *Escalator {
+	e := &Escalator{
+		policy:  policy,
+		maxKeys: defaultMaxEscalationKeys,
+		clock:   packageClock{},
+	}
+	for _, opt := range opts {
+		opt(e)
+	}
+	e.levels = newWindowedKeys[int](policy.Window, e.maxKeys)
+	return e
+}
+
+// Observe records an occurrence of err and returns its severity: the
+// higher of GetSeverity(err) and the severity of the threshold its
+// fingerprint has reached. Returns zero for a nil error.
+func (e *Escalator) Observe(err error) Severity {
+	if err == nil {
+		return 0
+	}
+	severity := GetSeverity(err)
+	if e.policy.Window <= 0 || len(e.policy.Thresholds) == 0 {
+		return severity
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	entry := e.levels.add(Fingerprint(err), 0, e.clock.Now(), -1)
+	entry.state = e.level(entry.state, entry.counts.total(0))
+	if entry.state >= 0 {
+		severity = max(severity, e.policy.Thresholds[entry.state].Severity)
+	}
+	return severity
+}
+
+// Len returns the number of fingerprints currently tracked.
+func (e *Escalator) Len() int {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return e.levels.len()
+}
+
+// level returns the threshold reached by count, starting from the current
+// level and applying hysteresis on the way down.
+func (e *Escalator) level(current, count int) int {
+	thresholds := e.policy.Thresholds
+	hysteresis := e.policy.Hysteresis
+	if hysteresis <= 0 {
+		hysteresis = 1
+	}
+	for current+1 < len(thresholds) && count > thresholds[current+1].Count {
+		current++
+	}
+	for current >= 0 && float64(count) <= float64(thresholds[current].Count)*hysteresis {
+		current--
+	}
+	return current
+}
+
+var (
+	escalatorMu sync.RWMutex
+	escalator   *Escalator
+)
+
+// SetEscalator installs e for Report and LogError: each reported or logged
+// error is observed once, and hook filters (HookFilter.MinSeverity) and log
+// levels use the escalated severity. A nil e turns escalation off.
+//
+// Example:
+//
+//	errors.SetEscalator(errors.NewEscalator(errors.DefaultEscalationPolicy))
+func SetEscalator(e *Escalator) {
+	escalatorMu.Lock()
+	defer escalatorMu.Unlock()
+	escalator = e
+}
+
+// observedSeverityKey carries the severity Report computed for an error, so
+// hooks that log it (see LogHook) don't observe it a second time.
+type observedSeverityKey struct{}
+
+type observedSeverity struct {
+	err      error
+	severity Severity
+}
+
+// observeSeverity returns err's severity, escalated by the installed
+// Escalator if there is one. An error Report already observed on ctx
+// isn't counted again.
+func observeSeverity(ctx context.Context, err error) Severity {
+	if ctx != nil {
+		if observed, ok := ctx.Value(observedSeverityKey{}).(observedSeverity); ok && sameError(observed.err, err) {
+			return observed.severity
+		}
+	}
+
+	escalatorMu.RLock()
+	e := escalator
+	escalatorMu.RUnlock()
+	if e == nil {
+		return GetSeverity(err)
+	}
+	return e.Observe(err)
+}
+
+// sameError reports whether a and b are the same error value, without
+// panicking on error types that aren't comparable.
+func sameError(a, b error) bool {
+	if ta := reflect.TypeOf(a); ta == nil || ta != reflect.TypeOf(b) || !ta.Comparable() {
+		return false
+	}
+	return a == b
+}
@@ -0,0 +1,157 @@
+package errors
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"strings"
+	"testing"
+	"time"
+)
+
+var testEscalationPolicy = EscalationPolicy{
+	Window: time.Minute,
+	Thresholds: []EscalationThreshold{
+		{Count: 3, Severity: SeverityError},
+		{Count: 6, Severity: SeverityCritical},
+	},
+	Hysteresis: 0.5,
+}
+
+// TestEscalatorObserve tests escalation and de-escalation as a fingerprint repeats
+func TestEscalatorObserve(t *testing.T) {
+	clock := newFakeClock()
+	escalator := NewEscalator(testEscalationPolicy, WithClock(clock))
+	err := NewValidationError("Invalid email", "email")
+
+	want := []Severity{
+		SeverityWarning, SeverityWarning, SeverityWarning,
+		SeverityError, SeverityError, SeverityError,
+		SeverityCritical,
+	}
+	for i, wantSeverity := range want {
+		if got := escalator.Observe(err); got != wantSeverity {
+			t.Errorf("occurrence %d: Observe() = %v, want %v", i+1, got, wantSeverity)
+		}
+	}
+
+	if got := escalator.Observe(NewHTTPError(404, "Not Found", nil)); got != SeverityError {
+		t.Errorf("other fingerprint: Observe() = %v, want its unescalated severity", got)
+	}
+
+	clock.Advance(2 * time.Minute)
+	if got := escalator.Observe(err); got != SeverityWarning {
+		t.Errorf("after the window: Observe() = %v, want warning", got)
+	}
+}
+
+// TestEscalatorHysteresis tests that severity falls back only once the rate drops well below the threshold
+func TestEscalatorHysteresis(t *testing.T) {
+	policy := EscalationPolicy{
+		Window:     time.Minute,
+		Thresholds: []EscalationThreshold{{Count: 4, Severity: SeverityError}},
+		Hysteresis: 0.5,
+	}
+	clock := newFakeClock()
+	escalator := NewEscalator(policy, WithClock(clock))
+	err := NewValidationError("Invalid email", "email")
+
+	for range 5 {
+		escalator.Observe(err)
+	}
+	clock.Advance(30 * time.Second)
+	for range 3 {
+		escalator.Observe(err)
+	}
+
+	// The first burst has left the window: 4 in the last minute doesn't
+	// cross the threshold from scratch, but stays escalated.
+	clock.Advance(35 * time.Second)
+	if got := escalator.Observe(err); got != SeverityError {
+		t.Errorf("at the threshold while escalated: Observe() = %v, want error", got)
+	}
+	fresh := NewEscalator(policy, WithClock(clock))
+	for range 3 {
+		fresh.Observe(err)
+	}
+	if got := fresh.Observe(err); got != SeverityWarning {
+		t.Errorf("at the threshold from scratch: Observe() = %v, want warning", got)
+	}
+
+	// The burst at 30s leaves too, dropping to 2 in the window.
+	clock.Advance(30 * time.Second)
+	escalator.Observe(err)
+	if got := escalator.Observe(err); got != SeverityWarning {
+		t.Errorf("below the hysteresis floor: Observe() = %v, want warning", got)
+	}
+}
+
+// TestEscalatorZeroHysteresis tests that a policy without Hysteresis de-escalates once the count is back at the threshold
+func TestEscalatorZeroHysteresis(t *testing.T) {
+	policy := EscalationPolicy{
+		Window:     time.Minute,
+		Thresholds: []EscalationThreshold{{Count: 4, Severity: SeverityError}},
+	}
+	clock := newFakeClock()
+	escalator := NewEscalator(policy, WithClock(clock))
+	err := NewValidationError("Invalid email", "email")
+
+	for range 4 {
+		escalator.Observe(err)
+	}
+	if got := escalator.Observe(err); got != SeverityError {
+		t.Fatalf("above the threshold: Observe() = %v, want error", got)
+	}
+
+	// The burst leaves the window, leaving 2 in it.
+	clock.Advance(30 * time.Second)
+	escalator.Observe(err)
+	clock.Advance(35 * time.Second)
+	if got := escalator.Observe(err); got != SeverityWarning {
+		t.Errorf("after the burst: Observe() = %v, want warning", got)
+	}
+}
+
+// TestEscalatorBounds tests that tracked fingerprints are capped and forgotten when idle
+func TestEscalatorBounds(t *testing.T) {
+	clock := newFakeClock()
+	escalator := NewEscalator(testEscalationPolicy, WithClock(clock), WithMaxKeys(2))
+
+	escalator.Observe(NewValidationError("Invalid email", "email"))
+	escalator.Observe(NewHTTPError(404, "Not Found", nil))
+	escalator.Observe(NewHTTPError(409, "Conflict", nil))
+	if got := escalator.Len(); got != 2 {
+		t.Errorf("Len() = %d, want 2", got)
+	}
+
+	clock.Advance(2 * time.Minute)
+	escalator.Observe(NewHTTPError(410, "Gone", nil))
+	if got := escalator.Len(); got != 1 {
+		t.Errorf("Len() after idle window = %d, want 1", got)
+	}
+}
+
+// TestReportEscalation tests that Report and logging hooks share one escalated observation per error
+func TestReportEscalation(t *testing.T) {
+	defer ResetHooks()
+	defer SetEscalator(nil)
+	SetEscalator(NewEscalator(testEscalationPolicy, WithClock(newFakeClock())))
+
+	var buf bytes.Buffer
+	RegisterHook(LogHook(slog.New(slog.NewTextHandler(&buf, nil)), "request failed"), HookFilter{})
+	paged := 0
+	RegisterHook(func(context.Context, error) { paged++ }, HookFilter{MinSeverity: SeverityError})
+
+	err := NewValidationError("Invalid email", "email")
+	for range 4 {
+		Report(context.Background(), err)
+	}
+
+	if paged != 1 {
+		t.Errorf("paging hook ran %d times, want once, for the 4th occurrence", paged)
+	}
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 4 || !strings.Contains(lines[2], "level=WARN") || !strings.Contains(lines[3], "level=ERROR") {
+		t.Errorf("log levels = %q, want three warnings then an error", lines)
+	}
+}
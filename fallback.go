@@ -0,0 +1,135 @@
+package errors
+
+import (
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+// stringFallbacks is the configured message fallback: the lowercase
+// substrings that make an otherwise unclassified error retryable, and
+// whether StrictClassification has turned matching off.
+type stringFallbacks struct {
+	patterns []string
+	strict   bool
+}
+
+// defaultStringFallbacks matches "rate limit", for third-party errors that
+// don't use typed errors.
+var defaultStringFallbacks = &stringFallbacks{patterns: []string{"rate limit"}}
+
+var (
+	// fallbacks is read on every IsRetryable call that reaches the
+	// fallback, so readers load it without locking; writers replace it
+	// under fallbackMu.
+	fallbacks  atomic.Pointer[stringFallbacks]
+	fallbackMu sync.Mutex
+)
+
+// currentStringFallbacks returns the configured fallback.
+func currentStringFallbacks() *stringFallbacks {
+	if f := fallbacks.Load(); f != nil {
+		return f
+	}
+	return defaultStringFallbacks
+}
+
+// updateStringFallbacks replaces the fallback with a copy changed by fn.
+func updateStringFallbacks(fn func(f *stringFallbacks)) {
+	fallbackMu.Lock()
+	defer fallbackMu.Unlock()
+	current := currentStringFallbacks()
+	next := &stringFallbacks{patterns: append([]string(nil), current.patterns...), strict: current.strict}
+	fn(next)
+	fallbacks.Store(next)
+}
+
+// normalizePatterns lowercases patterns and drops empty ones, which would
+// match every message.
+func normalizePatterns(patterns []string) []string {
+	normalized := make([]string, 0, len(patterns))
+	for _, pattern := range patterns {
+		if pattern = strings.ToLower(pattern); pattern != "" {
+			normalized = append(normalized, pattern)
+		}
+	}
+	return normalized
+}
+
+// SetStringFallbacks replaces the message substrings that make an
+// otherwise unclassified error retryable. Matching ignores case. The
+// default is "rate limit"; an empty list disables the fallback, as
+// StrictClassification(true) does. Call it at startup; it is safe to call
+// concurrently with IsRetryable.
+//
+// Example:
+//
+//	errors.SetStringFallbacks([]string{"rate limit exceeded", "too many requests"})
+func SetStringFallbacks(patterns []string) {
+	normalized := normalizePatterns(patterns)
+	updateStringFallbacks(func(f *stringFallbacks) {
+		f.patterns = normalized
+	})
+}
+
+// AddRetryablePattern adds a message substring to the string fallbacks
+// (see SetStringFallbacks).
+//
+// Example:
+//
+//	errors.AddRetryablePattern("throttled")
+func AddRetryablePattern(pattern string) {
+	normalized := normalizePatterns([]string{pattern})
+	updateStringFallbacks(func(f *stringFallbacks) {
+		f.patterns = append(f.patterns, normalized...)
+	})
+}
+
+// StrictClassification turns message matching off, so an untyped error is
+// retryable only through the typed rules: interfaces, sentinels and
+// statuses. The configured patterns are kept for when it is turned back
+// off.
+//
+// Example:
+//
+//	errors.StrictClassification(true)
+//	errors.IsRetryable(fmt.Errorf("rate limit disabled for this tenant")) // false
+func StrictClassification(strict bool) {
+	updateStringFallbacks(func(f *stringFallbacks) {
+		f.strict = strict
+	})
+}
+
+// StringFallbacks returns the message substrings IsRetryable matches for
+// untyped errors, or nil under StrictClassification.
+func StringFallbacks() []string {
+	f := currentStringFallbacks()
+	if f.strict || len(f.patterns) == 0 {
+		return nil
+	}
+	return append([]string(nil), f.patterns...)
+}
+
+// ResetStringFallbacks restores the default patterns and turns strict
+// classification off. Intended for tests.
+func ResetStringFallbacks() {
+	fallbackMu.Lock()
+	defer fallbackMu.Unlock()
+	fallbacks.Store(nil)
+}
+
+// matchStringFallback returns the first configured pattern err's message
+// contains.
+func matchStringFallback(err error) (string, bool) {
+	f := currentStringFallbacks()
+	if f.strict || len(f.patterns) == 0 {
+		return "", false
+	}
+	msg := strings.ToLower(err.Error())
+	for _, pattern := range f.patterns {
+		if strings.Contains(msg, pattern) {
+			return pattern, true
+		}
+	}
+	return "", false
+}
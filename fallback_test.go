@@ -0,0 +1,77 @@
+package errors
+
+import (
+	"fmt"
+	"slices"
+	"sync"
+	"testing"
+)
+
+// TestStringFallbacks tests replacing, extending and disabling the message fallback
+func TestStringFallbacks(t *testing.T) {
+	t.Cleanup(ResetStringFallbacks)
+
+	if got, want := StringFallbacks(), []string{"rate limit"}; !slices.Equal(got, want) {
+		t.Errorf("StringFallbacks() = %v, want %v", got, want)
+	}
+
+	tests := []struct {
+		name          string
+		configure     func()
+		err           error
+		wantRetryable bool
+	}{
+		{name: "default matches", configure: ResetStringFallbacks, err: fmt.Errorf("API rate limit exceeded"), wantRetryable: true},
+		{name: "default false positive", configure: ResetStringFallbacks, err: fmt.Errorf("rate limit disabled for this tenant"), wantRetryable: true},
+		{name: "strict ignores patterns", configure: func() { StrictClassification(true) }, err: fmt.Errorf("rate limit disabled for this tenant")},
+		{name: "strict keeps typed rules", configure: func() { StrictClassification(true) }, err: fmt.Errorf("calling: %w", ErrRateLimited), wantRetryable: true},
+		{name: "strict turned off", configure: func() { StrictClassification(true); StrictClassification(false) }, err: fmt.Errorf("API rate limit exceeded"), wantRetryable: true},
+		{name: "replaced drops default", configure: func() { SetStringFallbacks([]string{"Rate Limit Exceeded"}) }, err: fmt.Errorf("rate limit disabled for this tenant")},
+		{name: "replaced ignores case", configure: func() { SetStringFallbacks([]string{"Rate Limit Exceeded"}) }, err: fmt.Errorf("API rate limit exceeded"), wantRetryable: true},
+		{name: "empty list disables", configure: func() { SetStringFallbacks(nil) }, err: fmt.Errorf("API rate limit exceeded")},
+		{name: "empty pattern ignored", configure: func() { SetStringFallbacks([]string{""}) }, err: fmt.Errorf("boom")},
+		{name: "added", configure: func() { ResetStringFallbacks(); AddRetryablePattern("throttled") }, err: fmt.Errorf("request throttled"), wantRetryable: true},
+		{name: "added keeps default", configure: func() { ResetStringFallbacks(); AddRetryablePattern("throttled") }, err: fmt.Errorf("rate limit hit"), wantRetryable: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.configure()
+			if got := IsRetryable(tt.err); got != tt.wantRetryable {
+				t.Errorf("IsRetryable(%q) = %v, want %v", tt.err, got, tt.wantRetryable)
+			}
+		})
+	}
+
+	StrictClassification(true)
+	if got := StringFallbacks(); got != nil {
+		t.Errorf("StringFallbacks() under strict classification = %v, want nil", got)
+	}
+}
+
+// TestStringFallbacksConcurrent tests classifying while the patterns change
+func TestStringFallbacksConcurrent(t *testing.T) {
+	t.Cleanup(ResetStringFallbacks)
+	err := fmt.Errorf("API rate limit exceeded")
+
+	var wg sync.WaitGroup
+	for range 4 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for range 1000 {
+				if !IsRetryable(err) {
+					t.Error("the default pattern should keep matching while others are added")
+					return
+				}
+			}
+		}()
+	}
+	for i := range 100 {
+		AddRetryablePattern(fmt.Sprintf("pattern %d", i))
+	}
+	wg.Wait()
+	if got := len(StringFallbacks()); got != 101 {
+		t.Errorf("len(StringFallbacks()) = %d, want 101", got)
+	}
+}
@@ -0,0 +1,104 @@
+package errors
+
+import "sync"
+
+// NewHTTPErrorFast creates an HTTPError without a cause, options or stack
+// trace, in a single allocation. Use it at wrap sites hot enough for the option
+// closures of NewHTTPError to show up in profiles, such as a proxy
+// rejecting requests during an incident; set further fields directly on the
+// result.
+//
+// Example:
+//
+//	if overloaded {
+//	    return errors.NewHTTPErrorFast(http.StatusServiceUnavailable, "Overloaded")
+//	}
+func NewHTTPErrorFast(statusCode int, message string) *HTTPError {
+	return &HTTPError{StatusCode: statusCode, Message: message}
+}
+
+var processingBuilders = sync.Pool{
+	New: func() any { return new(ProcessingErrorBuilder) },
+}
+
+// ProcessingErrorBuilder builds a ProcessingError from a pool, for call
+// sites that convert the error to another form, such as an Envelope,
+// immediately and can then hand it back with Release. Steady-state use
+// doesn't allocate.
+//
+// The error returned by Err belongs to the builder: it must not be
+// returned, stored or used after Release. Call sites that keep the error
+// should use NewProcessingError instead.
+//
+// Example:
+//
+//	b := errors.AcquireProcessingError("Failed to forward", "Forward").
+//	    Cause(err).
+//	    ItemID(req.ID)
+//	env := errors.Encode(b.Err())
+//	b.Release()
+type ProcessingErrorBuilder struct {
+	err ProcessingError
+}
+
+// AcquireProcessingError returns a pooled builder for a ProcessingError.
+func AcquireProcessingError(message, operation string) *ProcessingErrorBuilder {
+	b := processingBuilders.Get().(*ProcessingErrorBuilder)
+	b.err.Message = message
+	b.err.Operation = operation
+	return b
+}
+
+// Cause sets the wrapped error.
+func (b *ProcessingErrorBuilder) Cause(err error) *ProcessingErrorBuilder {
+	b.err.Err = err
+	return b
+}
+
+// ItemID sets the ID of the item being processed.
+func (b *ProcessingErrorBuilder) ItemID(itemID string) *ProcessingErrorBuilder {
+	b.err.ItemID = itemID
+	return b
+}
+
+// Component sets the component name.
+func (b *ProcessingErrorBuilder) Component(component string) *ProcessingErrorBuilder {
+	b.err.Component = component
+	return b
+}
+
+// Code sets the error code (see WithCode).
+func (b *ProcessingErrorBuilder) Code(code string) *ProcessingErrorBuilder {
+	b.err.Code = code
+	return b
+}
+
+// Retryable marks the error as retryable.
+func (b *ProcessingErrorBuilder) Retryable(retryable bool) *ProcessingErrorBuilder {
+	b.err.Retryable = retryable
+	return b
+}
+
+// Metadata stores key/value. The map is kept across Release, so reusing a
+// builder with the same keys doesn't allocate.
+func (b *ProcessingErrorBuilder) Metadata(key string, value any) *ProcessingErrorBuilder {
+	if b.err.Metadata == nil {
+		b.err.Metadata = make(map[string]any)
+	}
+	b.err.Metadata[key] = value
+	return b
+}
+
+// Err returns the built error, valid until Release.
+func (b *ProcessingErrorBuilder) Err() *ProcessingError {
+	return &b.err
+}
+
+// Release resets the builder and returns it to the pool. Neither the
+// builder nor its error may be used afterwards.
+func (b *ProcessingErrorBuilder) Release() {
+	metadata := b.err.Metadata
+	clear(metadata)
+	b.err = ProcessingError{Metadata: metadata}
+	processingBuilders.Put(b)
+}
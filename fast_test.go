@@ -0,0 +1,80 @@
+package errors
+
+import (
+	"fmt"
+	"testing"
+)
+
+// maxFastAllocs is the allocation budget for the fast construction paths.
+const maxFastAllocs = 2
+
+// fastSink keeps constructed errors escaping to the heap, as they would
+// when returned.
+var fastSink error
+
+// TestFastConstructionAllocs tests that the fast construction paths stay within their allocation budget
+func TestFastConstructionAllocs(t *testing.T) {
+	cause := fmt.Errorf("upstream reset")
+
+	tests := []struct {
+		name string
+		fn   func()
+	}{
+		{
+			name: "NewHTTPErrorFast",
+			fn: func() {
+				err := NewHTTPErrorFast(503, "Overloaded")
+				err.Err = cause
+				fastSink = err
+			},
+		},
+		{
+			name: "ProcessingErrorBuilder",
+			fn: func() {
+				b := AcquireProcessingError("Failed to forward", "Forward").
+					Cause(cause).
+					ItemID("req-1").
+					Component("proxy").
+					Metadata("upstream", "billing")
+				fastSink = b.Err()
+				b.Release()
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if allocs := testing.AllocsPerRun(100, tt.fn); allocs > maxFastAllocs {
+				t.Errorf("%s allocated %.1f times per op, want at most %d", tt.name, allocs, maxFastAllocs)
+			}
+		})
+	}
+}
+
+// TestProcessingErrorBuilder tests that built errors match NewProcessingError and that Release resets the builder
+func TestProcessingErrorBuilder(t *testing.T) {
+	cause := NewNetworkError("connection refused", "Connect")
+
+	b := AcquireProcessingError("Failed to forward", "Forward").
+		Cause(cause).
+		ItemID("req-1").
+		Retryable(true).
+		Metadata("upstream", "billing")
+	built := b.Err()
+
+	want := NewProcessingError("Failed to forward", "Forward",
+		WithCause(cause), WithItemID("req-1"), WithRetryable(true), WithMetadata("upstream", "billing"))
+	if built.Error() != want.Error() || IsRetryable(built) != IsRetryable(want) {
+		t.Errorf("built %q, want %q", built.Error(), want.Error())
+	}
+	if got := Encode(built); got.Metadata["upstream"] != "billing" || got.ItemID != "req-1" {
+		t.Errorf("Encode() lost fields: %+v", got)
+	}
+
+	b.Release()
+	reused := AcquireProcessingError("second", "Op")
+	defer reused.Release()
+	if e := reused.Err(); e.Err != nil || e.ItemID != "" || e.Retryable || len(e.Metadata) != 0 {
+		t.Errorf("builder not reset after Release: %+v", e)
+	}
+}
@@ -0,0 +1,212 @@
+package errors
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/cockroachdb/errors"
+	"github.com/cockroachdb/errors/errbase"
+)
+
+// Sentinels for features a service can't serve, so errors.Is() works on
+// either type and on foreign errors wrapping them.
+var (
+	// ErrNotImplemented indicates a feature that isn't available yet.
+	ErrNotImplemented = errors.New("not implemented")
+
+	// ErrUnsupported indicates a request that will never be served as is.
+	ErrUnsupported = errors.New("unsupported")
+)
+
+// notImplementedHorizon is how close AvailableFrom must be for a
+// NotImplementedError to be worth retrying.
+const notImplementedHorizon = time.Hour
+
+// NotImplementedError represents a feature that exists but isn't available
+// yet, such as one behind a staged rollout. Clients should retry later or
+// tell the user the feature is coming. Maps to 501.
+// Wraps ErrNotImplemented so errors.Is() works.
+// Automatically includes stack trace from creation point.
+//
+// AvailableFrom optionally records when the feature is expected (see
+// WithAvailableFrom). The error is retryable once that is within an hour,
+// and the remaining wait is its retry-after hint (see GetRetryAfter).
+type NotImplementedError struct {
+	Feature          string
+	Message          string
+	Operation        string
+	Component        string
+	Code             string
+	Owner            string
+	AvailableFrom    time.Time
+	Err              error
+	AdditionalCauses []error
+	Metadata         map[string]any
+
+	state errorState
+}
+
+func (e *NotImplementedError) Error() string {
+	if e == nil {
+		return "<nil NotImplementedError>"
+	}
+	return e.formatWithCause(formatCauses(e.Err, e.AdditionalCauses))
+}
+
+func (e *NotImplementedError) formatWithCause(cause string) string {
+	if e == nil {
+		return "<nil NotImplementedError>"
+	}
+	msgStr := joinDetails("not implemented yet", e.Feature)
+	if !e.AvailableFrom.IsZero() {
+		msgStr += fmt.Sprintf(" (available from %s)", e.AvailableFrom.Format(time.RFC3339))
+	}
+	return joinDetails(e.Component, msgStr, e.Message, cause)
+}
+
+func (e *NotImplementedError) causeError() error {
+	if e == nil {
+		return nil
+	}
+	return e.Err
+}
+
+// Unwrap returns the ErrNotImplemented sentinel plus any wrapped causes
+// for errors.Is() and errors.As() compatibility.
+func (e *NotImplementedError) Unwrap() []error {
+	if e == nil {
+		return nil
+	}
+	return append([]error{ErrNotImplemented}, causeList(e.Err, e.AdditionalCauses)...)
+}
+
+// StackTrace returns the stack from where the error was created. It
+// implements the cockroachdb/errors stack trace provider interface.
+func (e *NotImplementedError) StackTrace() errbase.StackTrace {
+	if e == nil {
+		return nil
+	}
+	return e.state.stackTrace()
+}
+
+// IsRetryable returns true when AvailableFrom is set and at most an hour
+// away. A feature with no date, or one further off, is treated as
+// permanent for now.
+func (e *NotImplementedError) IsRetryable() bool {
+	if e == nil || e.AvailableFrom.IsZero() {
+		return false
+	}
+	return until(e.AvailableFrom) <= notImplementedHorizon
+}
+
+// NewNotImplementedError creates a NotImplementedError for feature with
+// automatic stack trace.
+//
+// Example:
+//
+//	err := errors.NewNotImplementedError("bulk export",
+//	    errors.WithAvailableFrom(rolloutAt))
+func NewNotImplementedError(feature string, opts ...Option) error {
+	err := &NotImplementedError{Feature: feature}
+	applyOptions(err, opts)
+	return err
+}
+
+// UnsupportedError represents a request that will never be served as is:
+// the client must change it, for instance by using Alternative. Maps to
+// 422 and is never retryable.
+// Wraps ErrUnsupported so errors.Is() works.
+// Automatically includes stack trace from creation point.
+type UnsupportedError struct {
+	What             string
+	Alternative      string // what the client should do instead (optional)
+	Message          string
+	Operation        string
+	Component        string
+	Code             string
+	Owner            string
+	Err              error
+	AdditionalCauses []error
+	Metadata         map[string]any
+
+	state errorState
+}
+
+func (e *UnsupportedError) Error() string {
+	if e == nil {
+		return "<nil UnsupportedError>"
+	}
+	return e.formatWithCause(formatCauses(e.Err, e.AdditionalCauses))
+}
+
+func (e *UnsupportedError) formatWithCause(cause string) string {
+	if e == nil {
+		return "<nil UnsupportedError>"
+	}
+	msgStr := joinDetails("unsupported", e.What)
+	if e.Alternative != "" {
+		msgStr += fmt.Sprintf(" (use %s instead)", e.Alternative)
+	}
+	return joinDetails(e.Component, msgStr, e.Message, cause)
+}
+
+func (e *UnsupportedError) causeError() error {
+	if e == nil {
+		return nil
+	}
+	return e.Err
+}
+
+// Unwrap returns the ErrUnsupported sentinel plus any wrapped causes
+// for errors.Is() and errors.As() compatibility.
+func (e *UnsupportedError) Unwrap() []error {
+	if e == nil {
+		return nil
+	}
+	return append([]error{ErrUnsupported}, causeList(e.Err, e.AdditionalCauses)...)
+}
+
+// StackTrace returns the stack from where the error was created. It
+// implements the cockroachdb/errors stack trace provider interface.
+func (e *UnsupportedError) StackTrace() errbase.StackTrace {
+	if e == nil {
+		return nil
+	}
+	return e.state.stackTrace()
+}
+
+// IsRetryable returns false - the same request fails the same way every
+// time.
+func (e *UnsupportedError) IsRetryable() bool {
+	return false
+}
+
+// NewUnsupportedError creates an UnsupportedError with automatic stack
+// trace. alternative may be empty when there is nothing to suggest.
+//
+// Example:
+//
+//	err := errors.NewUnsupportedError("HEIC uploads", "JPEG or PNG")
+func NewUnsupportedError(what, alternative string, opts ...Option) error {
+	err := &UnsupportedError{What: what, Alternative: alternative}
+	applyOptions(err, opts)
+	return err
+}
+
+// IsNotImplemented checks if err is a NotImplementedError or wraps
+// ErrNotImplemented: the feature may arrive later.
+//
+// Example:
+//
+//	if errors.IsNotImplemented(err) {
+//	    showComingSoon()
+//	}
+func IsNotImplemented(err error) bool {
+	return errors.Is(err, ErrNotImplemented)
+}
+
+// IsUnsupported checks if err is an UnsupportedError or wraps
+// ErrUnsupported: the client must change its request.
+func IsUnsupported(err error) bool {
+	return errors.Is(err, ErrUnsupported)
+}
@@ -0,0 +1,178 @@
+package errors
+
+import (
+	"fmt"
+	"testing"
+	"time"
+)
+
+// TestNotImplementedError tests retryability and retry hints as AvailableFrom approaches
+func TestNotImplementedError(t *testing.T) {
+	clock := newFakeClock()
+	SetClock(clock)
+	defer SetClock(nil)
+
+	tests := []struct {
+		name          string
+		err           error
+		wantRetryable bool
+		wantClass     ErrorClass
+		wantWait      time.Duration
+	}{
+		{name: "no date", err: NewNotImplementedError("bulk export"), wantClass: ClassPermanent},
+		{
+			name:      "far off",
+			err:       NewNotImplementedError("bulk export", WithAvailableFrom(clock.Now().Add(48*time.Hour))),
+			wantClass: ClassPermanent, wantWait: 48 * time.Hour,
+		},
+		{
+			name:          "near",
+			err:           NewNotImplementedError("bulk export", WithAvailableFrom(clock.Now().Add(10*time.Minute))),
+			wantRetryable: true, wantClass: ClassTransient, wantWait: 10 * time.Minute,
+		},
+		{
+			name:          "already due",
+			err:           NewNotImplementedError("bulk export", WithAvailableFrom(clock.Now().Add(-time.Minute))),
+			wantRetryable: true, wantClass: ClassTransient,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := IsRetryable(tt.err); got != tt.wantRetryable {
+				t.Errorf("IsRetryable() = %v, want %v", got, tt.wantRetryable)
+			}
+			if got := Classify(tt.err); got != tt.wantClass {
+				t.Errorf("Classify() = %v, want %v", got, tt.wantClass)
+			}
+			wait, _ := GetRetryAfter(tt.err)
+			if wait != tt.wantWait {
+				t.Errorf("GetRetryAfter() = %v, want %v", wait, tt.wantWait)
+			}
+			if got := HTTPStatus(tt.err); got != 501 {
+				t.Errorf("HTTPStatus() = %d, want 501", got)
+			}
+		})
+	}
+}
+
+// TestFeatureErrorMappings tests that the two types and their sentinels map apart on every transport
+func TestFeatureErrorMappings(t *testing.T) {
+	tests := []struct {
+		name               string
+		err                error
+		wantMessage        string
+		wantStatus         int
+		wantGRPC           GRPCCode
+		wantNotImplemented bool
+		wantUnsupported    bool
+	}{
+		{
+			name:        "not implemented",
+			err:         NewNotImplementedError("bulk export", WithComponent("exports")),
+			wantMessage: "exports: not implemented yet: bulk export",
+			wantStatus:  501, wantGRPC: GRPCUnimplemented, wantNotImplemented: true,
+		},
+		{
+			name: "not implemented with date",
+			err: NewNotImplementedError("bulk export", WithMessage("rolling out by region"),
+				WithAvailableFrom(time.Date(2099, 3, 1, 0, 0, 0, 0, time.UTC))),
+			wantMessage: "not implemented yet: bulk export (available from 2099-03-01T00:00:00Z): rolling out by region",
+			wantStatus:  501, wantGRPC: GRPCUnimplemented, wantNotImplemented: true,
+		},
+		{
+			name:        "unsupported",
+			err:         NewUnsupportedError("HEIC uploads", "JPEG or PNG"),
+			wantMessage: "unsupported: HEIC uploads (use JPEG or PNG instead)",
+			wantStatus:  422, wantGRPC: GRPCInvalidArgument, wantUnsupported: true,
+		},
+		{
+			name:        "unsupported without alternative",
+			err:         NewUnsupportedError("SOAP", "", WithCause(New("no handler"))),
+			wantMessage: "unsupported: SOAP: no handler",
+			wantStatus:  422, wantGRPC: GRPCInvalidArgument, wantUnsupported: true,
+		},
+		{
+			name:        "wrapped sentinel",
+			err:         fmt.Errorf("export: %w", ErrNotImplemented),
+			wantMessage: "export: not implemented",
+			wantStatus:  501, wantGRPC: GRPCUnimplemented, wantNotImplemented: true,
+		},
+		{
+			name:        "unsupported sentinel",
+			err:         Wrap(ErrUnsupported, "upload"),
+			wantMessage: "upload: unsupported",
+			wantStatus:  422, wantGRPC: GRPCInvalidArgument, wantUnsupported: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.err.Error(); got != tt.wantMessage {
+				t.Errorf("Error() = %q, want %q", got, tt.wantMessage)
+			}
+			if got := HTTPStatus(tt.err); got != tt.wantStatus {
+				t.Errorf("HTTPStatus() = %d, want %d", got, tt.wantStatus)
+			}
+			if got := ToGRPCStatus(tt.err); got != tt.wantGRPC {
+				t.Errorf("ToGRPCStatus() = %d, want %d", got, tt.wantGRPC)
+			}
+			if got := IsNotImplemented(tt.err); got != tt.wantNotImplemented {
+				t.Errorf("IsNotImplemented() = %v, want %v", got, tt.wantNotImplemented)
+			}
+			if got := IsUnsupported(tt.err); got != tt.wantUnsupported {
+				t.Errorf("IsUnsupported() = %v, want %v", got, tt.wantUnsupported)
+			}
+			if IsRetryable(tt.err) || !IsPermanentError(tt.err) {
+				t.Error("expected a permanent, non-retryable error")
+			}
+		})
+	}
+}
+
+// TestFeatureErrorProblemDetails tests the problem details members clients key off
+func TestFeatureErrorProblemDetails(t *testing.T) {
+	availableFrom := time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)
+	notImpl := ToProblemDetails(Wrap(NewNotImplementedError("bulk export", WithAvailableFrom(availableFrom)), "handler"))
+	if notImpl.Extensions[ProblemFeature] != "bulk export" || notImpl.Extensions[ProblemAvailableFrom] != "2026-03-01T00:00:00Z" {
+		t.Errorf("not implemented extensions = %v", notImpl.Extensions)
+	}
+	if _, ok := notImpl.Extensions[ProblemUnsupported]; ok {
+		t.Error("not implemented problem has an unsupported member")
+	}
+
+	unsupported := ToProblemDetails(NewUnsupportedError("HEIC uploads", "JPEG or PNG"))
+	if unsupported.Extensions[ProblemUnsupported] != "HEIC uploads" || unsupported.Extensions[ProblemAlternative] != "JPEG or PNG" {
+		t.Errorf("unsupported extensions = %v", unsupported.Extensions)
+	}
+	if _, ok := unsupported.Extensions[ProblemFeature]; ok {
+		t.Error("unsupported problem has a feature member")
+	}
+}
+
+// TestFeatureErrorRoundTrip tests that both types survive Encode and Decode
+func TestFeatureErrorRoundTrip(t *testing.T) {
+	availableFrom := time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)
+	decoded := Decode(Encode(NewNotImplementedError("bulk export",
+		WithAvailableFrom(availableFrom), WithOperation("Export"), WithCode("EXPORT_ROLLOUT"))))
+	var notImplErr *NotImplementedError
+	if !As(decoded, &notImplErr) {
+		t.Fatalf("decoded %T, want *NotImplementedError", decoded)
+	}
+	if notImplErr.Feature != "bulk export" || !notImplErr.AvailableFrom.Equal(availableFrom) ||
+		notImplErr.Operation != "Export" || notImplErr.Code != "EXPORT_ROLLOUT" {
+		t.Errorf("decoded %+v", notImplErr)
+	}
+
+	decoded = Decode(Encode(NewUnsupportedError("HEIC uploads", "JPEG or PNG")))
+	var unsupportedErr *UnsupportedError
+	if !As(decoded, &unsupportedErr) {
+		t.Fatalf("decoded %T, want *UnsupportedError", decoded)
+	}
+	if unsupportedErr.What != "HEIC uploads" || unsupportedErr.Alternative != "JPEG or PNG" {
+		t.Errorf("decoded %+v", unsupportedErr)
+	}
+	if !IsUnsupported(decoded) {
+		t.Error("decoded error doesn't match ErrUnsupported")
+	}
+}
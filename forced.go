@@ -0,0 +1,291 @@
+package errors
+
+import "reflect"
+
+// forcedError overrides the classification of the error it wraps.
+// It is transparent otherwise: Error() is unchanged and Unwrap exposes the
+// original for errors.Is and errors.As.
+type forcedError struct {
+	class  ErrorClass
+	err    error
+	marked bool // set by MarkRetryable and MarkPermanent
+}
+
+func (e *forcedError) Error() string {
+	return e.err.Error()
+}
+
+func (e *forcedError) Unwrap() error {
+	return e.err
+}
+
+// IsRetryable reports the forced decision.
+func (e *forcedError) IsRetryable() bool {
+	return e.class == ClassTransient
+}
+
+// Permanent marks err as permanent so it is never retried, whatever it
+// wraps. Use it when retrying would be unsafe, for example because the
+// operation already had a side effect. Returns nil if err is nil.
+//
+// Permanent is a veto: it wins over Transient and over retryable errors
+// anywhere in the chain, including ProcessingErrors with Retryable set and
+// ProcessingErrors delegating to a retryable cause.
+//
+// Example:
+//
+//	if err := charge(ctx, order); err != nil && chargeSubmitted {
+//	    return errors.Permanent(err)
+//	}
+func Permanent(err error) error {
+	if err == nil {
+		return nil
+	}
+	return &forcedError{class: ClassPermanent, err: err}
+}
+
+// Transient marks err as retryable, whatever it wraps. Returns nil if err
+// is nil.
+//
+// Transient is a promotion and yields to everything that makes retrying
+// pointless: it has no effect when the chain contains a context error
+// (the context is already done) or a Permanent wrapper.
+//
+// Example:
+//
+//	if errors.Is(err, errLockHeld) {
+//	    return errors.Transient(err)
+//	}
+func Transient(err error) error {
+	if err == nil {
+		return nil
+	}
+	return &forcedError{class: ClassTransient, err: err}
+}
+
+// MarkRetryable marks an opaque error, such as one from a third-party
+// library, as worth retrying: IsRetryable reports true whatever err wraps,
+// except when the chain contains a context error. Unlike Transient, it
+// decides over any Permanent, Transient or MarkPermanent marker inside it,
+// so the outermost Mark call wins; a Permanent wrapped around it still
+// vetoes. Is and As see through it. Returns nil if err is nil.
+//
+// Example:
+//
+//	if err := sdk.Publish(msg); err != nil {
+//	    return errors.MarkRetryable(err) // the SDK only fails on broker restarts
+//	}
+func MarkRetryable(err error) error {
+	if err == nil {
+		return nil
+	}
+	return &forcedError{class: ClassTransient, err: err, marked: true}
+}
+
+// MarkPermanent marks an opaque error as not worth retrying: IsRetryable
+// reports false whatever err wraps; for a context error it would anyway.
+// Like MarkRetryable, it decides over the markers inside it, so
+// MarkPermanent(MarkRetryable(err)) is permanent and
+// MarkRetryable(MarkPermanent(err)) retryable. Is and As see through it.
+// Returns nil if err is nil.
+//
+// Example:
+//
+//	if err := sdk.Publish(msg); errors.Is(err, sdk.ErrTopicDeleted) {
+//	    return errors.MarkPermanent(err)
+//	}
+func MarkPermanent(err error) error {
+	if err == nil {
+		return nil
+	}
+	return &forcedError{class: ClassPermanent, err: err, marked: true}
+}
+
+// Unmark strips every Permanent, Transient, MarkPermanent and
+// MarkRetryable marker from err's chain, so it is classified by what it
+// wraps again. Wrappers above a marker are copied as ReplaceCause copies
+// them; err itself is never modified. Returns err unchanged when it carries
+// no marker.
+//
+// Example:
+//
+//	// The caller marked it permanent; decide afresh after a failover
+//	err = errors.Unmark(err)
+func Unmark(err error) error {
+	for range maxChainNodes {
+		var marker *forcedError
+		walkChain(err, func(node error, _ int) bool {
+			marker, _ = node.(*forcedError)
+			return marker == nil
+		})
+		if marker == nil {
+			return err
+		}
+		unmarked, ok := replaceCause(err, func(node error) bool { return node == error(marker) }, marker.err, 0)
+		if !ok {
+			return err // the marker is out of ReplaceCause's reach
+		}
+		err = unmarked
+	}
+	return err
+}
+
+// IsForced reports whether err's classification has been overridden by
+// Permanent, Transient, MarkPermanent or MarkRetryable, and to which class.
+// The outermost MarkPermanent or MarkRetryable decides, unless a Permanent
+// or a cenkalti/backoff *PermanentError wraps it. Otherwise a Permanent,
+// MarkPermanent or *PermanentError anywhere in the chain forces
+// ClassPermanent, and a Transient forces ClassTransient. Whichever marker
+// decides, ClassTransient is never forced when the chain contains a
+// context error. A Transient or MarkRetryable inside an additional cause
+// (see WithAdditionalCause) or a RetryError's LastError is ignored: it
+// can't make the error it is attached to, or an exhausted retry,
+// retryable.
+func IsForced(err error) (ErrorClass, bool) {
+	class, _, ok := forcedBy(err)
+	return class, ok
+}
+
+// forcedBy returns the class err is forced to and the marker forcing it,
+// such as "Permanent()", for IsRetryable and Classify to explain.
+func forcedBy(err error) (class ErrorClass, by string, ok bool) {
+	marker := primaryMarker(err)
+	switch {
+	case marker != nil && marker.marked && marker.class == ClassPermanent:
+		return ClassPermanent, "MarkPermanent()", true
+	case marker != nil && marker.marked:
+		if IsContextError(err) {
+			return "", "", false
+		}
+		return ClassTransient, "MarkRetryable()", true
+	}
+	if by, ok := forcedPermanentBy(err); ok {
+		return ClassPermanent, by, true
+	}
+	if marker != nil && !IsContextError(err) {
+		return ClassTransient, "Transient()", true
+	}
+	return "", "", false
+}
+
+// primaryMarker returns the outermost marker reachable from err without
+// passing through a typed error's additional causes or a RetryError. It
+// returns nil when there is none, or when a cenkalti/backoff
+// *PermanentError comes first.
+func primaryMarker(err error) *forcedError {
+	visited := 0
+	var search func(err error, depth int) (*forcedError, bool)
+	search = func(err error, depth int) (*forcedError, bool) {
+		if err == nil || depth > maxCauseDepth || visited >= maxChainNodes {
+			return nil, false
+		}
+		visited++
+
+		if f, ok := err.(*forcedError); ok {
+			return f, true
+		}
+		if isBackoffPermanent(err) {
+			return nil, true
+		}
+		if _, ok := err.(*RetryError); ok {
+			return nil, false
+		}
+		if field := additionalCausesField(err); field != nil && len(*field) > 0 {
+			return search(err.(chainFormatter).causeError(), depth+1)
+		}
+
+		switch u := err.(type) {
+		case interface{ Unwrap() []error }:
+			for _, c := range u.Unwrap() {
+				if marker, found := search(c, depth+1); found {
+					return marker, true
+				}
+			}
+		case interface{ Unwrap() error }:
+			return search(u.Unwrap(), depth+1)
+		}
+		return nil, false
+	}
+	marker, _ := search(err, 0)
+	return marker
+}
+
+// forcedPermanentBy returns the first marker in err's chain that forces it
+// permanent: "Permanent()", "MarkPermanent()" or "backoff.Permanent()".
+func forcedPermanentBy(err error) (string, bool) {
+	by := ""
+	walkChain(err, func(node error, _ int) bool {
+		if f, ok := node.(*forcedError); ok && f.class == ClassPermanent {
+			by = "Permanent()"
+			if f.marked {
+				by = "MarkPermanent()"
+			}
+		} else if isBackoffPermanent(node) {
+			by = "backoff.Permanent()"
+		}
+		return by == ""
+	})
+	return by, by != ""
+}
+
+// AsPermanent wraps err so that a cenkalti/backoff retry loop stops on it,
+// when IsPermanentError reports it as permanent. The wrapper satisfies
+// errors.As for a *backoff.PermanentError, which is all backoff.Retry
+// checks, without this package importing the library. Other errors, which
+// backoff should go on retrying, are returned unchanged, as is nil.
+// Error() is unchanged and Unwrap exposes err.
+//
+// Example:
+//
+//	err := backoff.Retry(func() error {
+//	    return errors.AsPermanent(client.Charge(ctx, order))
+//	}, backoff.NewExponentialBackOff())
+func AsPermanent(err error) error {
+	if err == nil || !IsPermanentError(err) {
+		return err
+	}
+	return &backoffPermanentError{err: err}
+}
+
+// backoffPermanentError is AsPermanent's wrapper.
+type backoffPermanentError struct {
+	err error
+}
+
+func (e *backoffPermanentError) Error() string {
+	return e.err.Error()
+}
+
+func (e *backoffPermanentError) Unwrap() error {
+	return e.err
+}
+
+// As fills a **backoff.PermanentError target with one wrapping e's error.
+func (e *backoffPermanentError) As(target any) bool {
+	ptr := reflect.ValueOf(target)
+	if ptr.Kind() != reflect.Pointer || ptr.IsNil() || !isBackoffPermanentType(ptr.Elem().Type()) {
+		return false
+	}
+	permanent := reflect.New(ptr.Elem().Type().Elem())
+	permanent.Elem().FieldByName("Err").Set(reflect.ValueOf(&e.err).Elem())
+	ptr.Elem().Set(permanent)
+	return true
+}
+
+// isBackoffPermanent reports whether err is a cenkalti/backoff
+// *PermanentError, recognized by its shape so this package doesn't import
+// the library.
+func isBackoffPermanent(err error) bool {
+	return isBackoffPermanentType(reflect.TypeOf(err))
+}
+
+// isBackoffPermanentType reports whether t is a pointer to a struct named
+// PermanentError whose exported Err field holds the error, as in
+// cenkalti/backoff v4 and v5.
+func isBackoffPermanentType(t reflect.Type) bool {
+	if t == nil || t.Kind() != reflect.Pointer || t.Elem().Kind() != reflect.Struct || t.Elem().Name() != "PermanentError" {
+		return false
+	}
+	field, ok := t.Elem().FieldByName("Err")
+	return ok && field.IsExported() && field.Type == reflect.TypeFor[error]()
+}
@@ -0,0 +1,230 @@
+package errors
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+)
+
+// TestForcedClassification tests the Permanent veto and Transient promotion precedence
+func TestForcedClassification(t *testing.T) {
+	rateLimited := NewRateLimitError("slow down", "Call", time.Second)
+	validation := NewValidationError("Invalid email", "email")
+
+	tests := []struct {
+		name       string
+		err        error
+		wantClass  ErrorClass
+		wantForced bool
+	}{
+		{name: "permanent over retryable", err: Permanent(rateLimited), wantClass: ClassPermanent, wantForced: true},
+		{name: "permanent over context", err: Permanent(context.DeadlineExceeded), wantClass: ClassPermanent, wantForced: true},
+		{name: "transient over permanent type", err: Transient(validation), wantClass: ClassTransient, wantForced: true},
+		{name: "transient over unknown", err: Transient(fmt.Errorf("lock held")), wantClass: ClassTransient, wantForced: true},
+		{name: "transient yields to context", err: Transient(Wrap(context.Canceled, "aborted")), wantClass: ClassContext},
+		{name: "permanent inside transient", err: Transient(Permanent(rateLimited)), wantClass: ClassPermanent, wantForced: true},
+		{name: "transient inside permanent", err: Permanent(Transient(validation)), wantClass: ClassPermanent, wantForced: true},
+		{
+			name:       "permanent beats retryable ProcessingError flag",
+			err:        NewProcessingError("failed", "Charge", WithRetryable(true), WithCause(Permanent(fmt.Errorf("declined")))),
+			wantClass:  ClassPermanent,
+			wantForced: true,
+		},
+		{
+			name:       "permanent beats ProcessingError cause delegation",
+			err:        Permanent(NewProcessingError("failed", "Charge", WithCause(rateLimited))),
+			wantClass:  ClassPermanent,
+			wantForced: true,
+		},
+		{
+			name:       "transient through ProcessingError",
+			err:        NewProcessingError("failed", "Charge", WithCause(Transient(fmt.Errorf("lock held")))),
+			wantClass:  ClassTransient,
+			wantForced: true,
+		},
+		{name: "unforced", err: rateLimited, wantClass: ClassTransient},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Classify(tt.err); got != tt.wantClass {
+				t.Errorf("Classify() = %v, want %v", got, tt.wantClass)
+			}
+			if _, forced := IsForced(tt.err); forced != tt.wantForced {
+				t.Errorf("IsForced() forced = %v, want %v", forced, tt.wantForced)
+			}
+
+			wantRetryable := tt.wantClass == ClassTransient
+			if got := IsRetryable(tt.err); got != wantRetryable {
+				t.Errorf("IsRetryable() = %v, want %v", got, wantRetryable)
+			}
+			if got := IsTransientError(tt.err); got != wantRetryable {
+				t.Errorf("IsTransientError() = %v, want %v", got, wantRetryable)
+			}
+			if tt.wantForced && IsPermanentError(tt.err) != (tt.wantClass == ClassPermanent) {
+				t.Errorf("IsPermanentError() = %v", IsPermanentError(tt.err))
+			}
+		})
+	}
+}
+
+// TestForcedWrappers tests that the wrappers are otherwise transparent
+func TestForcedWrappers(t *testing.T) {
+	cause := NewRateLimitError("slow down", "Call", time.Second)
+	err := Permanent(cause)
+
+	if err.Error() != cause.Error() {
+		t.Errorf("Error() = %q, want %q", err.Error(), cause.Error())
+	}
+	if !Is(err, ErrRateLimited) {
+		t.Error("Permanent should expose the original to errors.Is")
+	}
+	if _, ok := AsRetryable(Transient(NewRetryableError("busy", "Call", time.Second))); !ok {
+		t.Error("Transient should expose the original to errors.As")
+	}
+	if Permanent(nil) != nil || Transient(nil) != nil {
+		t.Error("wrapping nil should return nil")
+	}
+
+	class, reason := ExplainClassification(err)
+	if class != ClassPermanent || reason != "forced permanent by Permanent()" {
+		t.Errorf("ExplainClassification() = %v, %q", class, reason)
+	}
+
+	decoded := Decode(Encode(err))
+	if Classify(decoded) != ClassPermanent {
+		t.Errorf("override lost in envelope round trip: %v", Classify(decoded))
+	}
+}
+
+// PermanentError mimics cenkalti/backoff's *PermanentError.
+type PermanentError struct {
+	Err error
+}
+
+func (e *PermanentError) Error() string { return e.Err.Error() }
+func (e *PermanentError) Unwrap() error { return e.Err }
+
+// fakeBackoffRetry runs op the way backoff.Retry does: until it succeeds,
+// returns a *PermanentError or has been tried maxTries times.
+func fakeBackoffRetry(op func() error, maxTries int) (error, int) {
+	for tries := 1; ; tries++ {
+		err := op()
+		if err == nil {
+			return nil, tries
+		}
+		var permanent *PermanentError
+		if As(err, &permanent) {
+			return permanent.Err, tries
+		}
+		if tries == maxTries {
+			return err, tries
+		}
+	}
+}
+
+// TestBackoffPermanent tests that backoff's Permanent marker is honored and that AsPermanent stops its loop
+func TestBackoffPermanent(t *testing.T) {
+	rateLimited := NewRateLimitError("slow down", "Call", time.Second)
+
+	t.Run("detected", func(t *testing.T) {
+		err := fmt.Errorf("charge: %w", &PermanentError{Err: rateLimited})
+		if !IsPermanentError(err) || IsRetryable(err) {
+			t.Errorf("IsPermanentError() = %v, IsRetryable() = %v; want a backoff.Permanent to force permanent", IsPermanentError(err), IsRetryable(err))
+		}
+		if _, reason := ExplainClassification(err); reason != "forced permanent by backoff.Permanent()" {
+			t.Errorf("reason = %q", reason)
+		}
+	})
+
+	tests := []struct {
+		name      string
+		err       error
+		wantTries int
+	}{
+		{name: "validation stops", err: NewValidationError("must be positive", "amount"), wantTries: 1},
+		{name: "context stops", err: Wrap(context.Canceled, "aborted"), wantTries: 1},
+		{name: "retryable retried", err: rateLimited, wantTries: 3},
+		{name: "unclassified retried", err: fmt.Errorf("boom"), wantTries: 3},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err, tries := fakeBackoffRetry(func() error { return AsPermanent(tt.err) }, 3)
+			if tries != tt.wantTries {
+				t.Errorf("tried %d times, want %d", tries, tt.wantTries)
+			}
+			if err != tt.err && !Is(err, tt.err) {
+				t.Errorf("Retry() = %v, want %v", err, tt.err)
+			}
+			if err.Error() != tt.err.Error() {
+				t.Errorf("Error() = %q, want %q", err.Error(), tt.err.Error())
+			}
+		})
+	}
+
+	if AsPermanent(nil) != nil {
+		t.Error("AsPermanent(nil) should be nil")
+	}
+}
+
+// TestMarkers tests MarkRetryable, MarkPermanent and Unmark, including around a wrapped context.Canceled
+func TestMarkers(t *testing.T) {
+	rateLimited := NewRateLimitError("slow down", "Call", time.Second)
+	validation := NewValidationError("Invalid email", "email")
+	opaque := fmt.Errorf("sdk: broker unavailable")
+	canceled := fmt.Errorf("publish: %w", context.Canceled)
+
+	tests := []struct {
+		name          string
+		err           error
+		wantRetryable bool
+		wantIs        error
+	}{
+		{name: "retryable opaque", err: MarkRetryable(opaque), wantRetryable: true, wantIs: opaque},
+		{name: "retryable over permanent type", err: MarkRetryable(validation), wantRetryable: true, wantIs: validation},
+		{name: "permanent over retryable", err: MarkPermanent(rateLimited), wantIs: ErrRateLimited},
+		{name: "retryable yields to canceled", err: MarkRetryable(canceled), wantIs: context.Canceled},
+		{name: "retryable yields to canceled through typed error", err: MarkRetryable(Wrap(canceled, "publish failed")), wantIs: context.Canceled},
+		{name: "permanent around canceled", err: MarkPermanent(canceled), wantIs: context.Canceled},
+		{name: "outermost retryable wins", err: MarkRetryable(MarkPermanent(opaque)), wantRetryable: true, wantIs: opaque},
+		{name: "outermost permanent wins", err: MarkPermanent(MarkRetryable(opaque)), wantIs: opaque},
+		{name: "outermost wins under a wrapper", err: fmt.Errorf("publish: %w", MarkRetryable(MarkPermanent(opaque))), wantRetryable: true, wantIs: opaque},
+		{name: "retryable over Permanent", err: MarkRetryable(Permanent(opaque)), wantRetryable: true, wantIs: opaque},
+		{name: "Permanent vetoes retryable", err: Permanent(MarkRetryable(opaque)), wantIs: opaque},
+		{name: "Transient yields to permanent", err: Transient(MarkPermanent(opaque)), wantIs: opaque},
+		{name: "outermost retryable yields to canceled", err: MarkRetryable(MarkPermanent(canceled)), wantIs: context.Canceled},
+		{name: "unmark permanent", err: Unmark(MarkPermanent(rateLimited)), wantRetryable: true, wantIs: ErrRateLimited},
+		{name: "unmark retryable", err: Unmark(MarkRetryable(validation)), wantIs: validation},
+		{name: "unmark under a wrapper", err: Unmark(fmt.Errorf("publish: %w", MarkPermanent(rateLimited))), wantRetryable: true, wantIs: ErrRateLimited},
+		{name: "unmark both", err: Unmark(MarkRetryable(MarkPermanent(rateLimited))), wantRetryable: true, wantIs: ErrRateLimited},
+		{name: "unmark leaves canceled", err: Unmark(MarkRetryable(canceled)), wantIs: context.Canceled},
+		{name: "unmark unmarked", err: Unmark(opaque), wantIs: opaque},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := IsRetryable(tt.err); got != tt.wantRetryable {
+				t.Errorf("IsRetryable() = %v, want %v", got, tt.wantRetryable)
+			}
+			if !Is(tt.err, tt.wantIs) {
+				t.Errorf("Is(%v) = false, want the marker to be transparent", tt.wantIs)
+			}
+		})
+	}
+
+	if err := Unmark(fmt.Errorf("publish: %w", MarkPermanent(rateLimited))); err.Error() != "publish: "+rateLimited.Error() {
+		t.Errorf("Unmark() message = %q", err.Error())
+	}
+	var rateErr *RateLimitError
+	if !As(MarkPermanent(rateLimited), &rateErr) || rateErr != rateLimited {
+		t.Error("As() should find the RateLimitError behind MarkPermanent")
+	}
+	if _, reason := ExplainClassification(MarkRetryable(MarkPermanent(opaque))); reason != "forced transient by MarkRetryable()" {
+		t.Errorf("ExplainClassification() reason = %q", reason)
+	}
+	if MarkRetryable(nil) != nil || MarkPermanent(nil) != nil || Unmark(nil) != nil {
+		t.Error("the markers should return nil for nil")
+	}
+}
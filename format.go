@@ -0,0 +1,54 @@
+package errors
+
+import "strings"
+
+// The helpers below build every typed error's Error() text. Each leaves out
+// empty parts together with their separators, so an error without an
+// operation, component, message or cause never renders a double space, a
+// dangling "in" or a trailing ": ".
+
+// opLabel renders where an error happened: "component/operation", or
+// whichever of the two is set.
+func opLabel(component, operation string) string {
+	switch {
+	case component == "":
+		return operation
+	case operation == "":
+		return component
+	}
+	return component + "/" + operation
+}
+
+// clause renders "word value", such as "in Charge", or "" when value is
+// empty.
+func clause(word, value string) string {
+	if value == "" {
+		return ""
+	}
+	return word + " " + value
+}
+
+// joinWords joins the non-empty parts with spaces.
+func joinWords(parts ...string) string {
+	return joinNonEmpty(" ", parts)
+}
+
+// joinDetails joins the non-empty parts with ": ", as in
+// "component: message: cause".
+func joinDetails(parts ...string) string {
+	return joinNonEmpty(": ", parts)
+}
+
+func joinNonEmpty(sep string, parts []string) string {
+	var b strings.Builder
+	for _, part := range parts {
+		if part == "" {
+			continue
+		}
+		if b.Len() > 0 {
+			b.WriteString(sep)
+		}
+		b.WriteString(part)
+	}
+	return b.String()
+}
@@ -0,0 +1,152 @@
+package errors
+
+import (
+	"bytes"
+	stderrors "errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// formatTypes builds one error of every type from a cause (possibly nil)
+// and options, for the formatting matrix.
+var formatTypes = []struct {
+	name string
+	new  func(cause error, opts []Option) error
+}{
+	{"HTTPError", func(cause error, opts []Option) error {
+		return NewHTTPError(503, "Service Unavailable", cause, opts...)
+	}},
+	{"ValidationError", func(cause error, opts []Option) error {
+		return NewValidationError("must be positive", "amount", append(opts, WithValue(-1), WithCause(cause))...)
+	}},
+	{"TimeoutError", func(cause error, opts []Option) error {
+		return NewTimeoutError("slow", "", 5*time.Second, append(opts, WithCause(cause))...)
+	}},
+	{"RateLimitError", func(cause error, opts []Option) error {
+		return NewRateLimitError("slow down", "", time.Second, append(opts, WithCause(cause))...)
+	}},
+	{"RetryableError", func(cause error, opts []Option) error {
+		return NewRetryableError("try again", "", time.Second, append(opts, WithCause(cause))...)
+	}},
+	{"ProcessingError", func(cause error, opts []Option) error {
+		return NewProcessingError("bad row", "", append(opts, WithItemID("row-7"), WithCause(cause))...)
+	}},
+	{"NetworkError", func(cause error, opts []Option) error {
+		return NewNetworkError("connection refused", "", append(opts, WithCause(cause))...)
+	}},
+	{"SerializationError", func(cause error, opts []Option) error {
+		return NewDecodeError("unexpected end of input", "", "json", append(opts, WithCause(cause))...)
+	}},
+	{"CircuitBreakerError", func(cause error, opts []Option) error {
+		return NewCircuitBreakerError("too many failures", "", "open", append(opts, WithCause(cause))...)
+	}},
+	{"RetryError", func(cause error, opts []Option) error { return NewRetryError(3, 3, cause, nil, opts...) }},
+	{"NotImplementedError", func(cause error, opts []Option) error {
+		return NewNotImplementedError("bulk export", append(opts, WithCause(cause))...)
+	}},
+	{"UnsupportedError", func(cause error, opts []Option) error {
+		return NewUnsupportedError("xml", "json", append(opts, WithCause(cause))...)
+	}},
+	{"ConsistencyError", func(cause error, opts []Option) error {
+		return NewConsistencyError("order/42", "17", "15", time.Second, append(opts, WithCause(cause))...)
+	}},
+	{"NotFoundError", func(cause error, opts []Option) error {
+		return NewNotFoundError("order", "42", append(opts, WithCause(cause))...)
+	}},
+	{"ConflictError", func(cause error, opts []Option) error {
+		return NewConflictError("order", "42", append(opts, WithCause(cause))...)
+	}},
+	{"DatabaseError", func(cause error, opts []Option) error {
+		return NewDatabaseError("", "orders", append(opts, WithCause(cause))...)
+	}},
+	{"ProviderError", func(cause error, opts []Option) error {
+		return NewProviderError("stripe", append(opts, WithCode("card_declined"), WithCause(cause))...)
+	}},
+	{"ConfigError", func(cause error, opts []Option) error {
+		return NewConfigError("must be at least 1", "max", append(opts, WithValue(0), WithCause(cause))...)
+	}},
+	{"PanicError", func(cause error, opts []Option) error {
+		var value any = "index out of range"
+		if cause != nil {
+			value = cause
+		}
+		return NewPanicError(value, opts...)
+	}},
+}
+
+// TestErrorFormattingMatrix tests every type's message with and without a component, operation and cause against the committed golden file
+func TestErrorFormattingMatrix(t *testing.T) {
+	var got bytes.Buffer
+	for _, typ := range formatTypes {
+		for _, component := range []string{"billing", ""} {
+			for _, operation := range []string{"Charge", ""} {
+				for _, cause := range []error{stderrors.New("connection reset"), nil} {
+					var opts []Option
+					if component != "" {
+						opts = append(opts, WithComponent(component))
+					}
+					if operation != "" {
+						opts = append(opts, WithOperation(operation))
+					}
+					msg := typ.new(cause, opts).Error()
+					fmt.Fprintf(&got, "%s component=%t operation=%t cause=%t: %s\n",
+						typ.name, component != "", operation != "", cause != nil, msg)
+
+					for _, artifact := range []string{"  ", ": :", "/:", "/ ", " in :", " for :", "(/", "''"} {
+						if strings.Contains(msg, artifact) {
+							t.Errorf("%s: %q contains %q", typ.name, msg, artifact)
+						}
+					}
+					if strings.HasSuffix(msg, ":") || strings.HasSuffix(msg, " ") || strings.HasPrefix(msg, " ") || strings.HasPrefix(msg, ":") {
+						t.Errorf("%s: %q has a dangling separator", typ.name, msg)
+					}
+				}
+			}
+		}
+	}
+
+	golden := filepath.Join("testdata", "format.golden")
+	if *updateGolden {
+		if err := os.WriteFile(golden, got.Bytes(), 0o644); err != nil {
+			t.Fatalf("writing golden file: %v", err)
+		}
+	}
+	want, err := os.ReadFile(golden)
+	if err != nil {
+		t.Fatalf("reading golden file: %v", err)
+	}
+	if !bytes.Equal(got.Bytes(), want) {
+		t.Errorf("messages differ from %s; review the change and run go test -run TestErrorFormattingMatrix -update\n%s", golden, got.String())
+	}
+}
+
+// TestErrorFormattingEmptyParts tests messages of errors whose identifying fields are empty
+func TestErrorFormattingEmptyParts(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want string
+	}{
+		{name: "timeout without operation", err: NewTimeoutError("slow", "", 5*time.Second), want: "timeout after 5s: slow"},
+		{name: "timeout without message", err: NewTimeoutError("", "Quote", 5*time.Second), want: "timeout in Quote after 5s"},
+		{name: "http without message", err: NewHTTPError(503, "", nil), want: "HTTP 503"},
+		{name: "not found without resource", err: NewNotFoundError("", "42"), want: "42 not found"},
+		{name: "conflict without resource", err: NewConflictError("", ""), want: "conflict"},
+		{name: "validation without field", err: NewValidationError("bad", ""), want: "validation failed (value: <nil>): bad"},
+		{name: "serialization without format", err: NewSerializationError("bad", "Load", ""), want: "serialization error in Load: bad"},
+		{name: "remote without type", err: &RemoteError{Operation: "Charge", Message: "declined"}, want: "remote error in Charge: declined"},
+		{name: "not implemented without feature", err: NewNotImplementedError(""), want: "not implemented yet"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.err.Error(); got != tt.want {
+				t.Errorf("Error() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -2,12 +2,14 @@ module github.com/JohnPlummer/jp-go-errors
 
 go 1.25.0
 
-require github.com/cockroachdb/errors v1.14.0
+require (
+	github.com/cockroachdb/errors v1.14.0
+	github.com/getsentry/sentry-go v0.46.0
+)
 
 require (
 	github.com/cockroachdb/logtags v0.0.0-20230118201751-21c54148d20b // indirect
 	github.com/cockroachdb/redact v1.1.5 // indirect
-	github.com/getsentry/sentry-go v0.46.0 // indirect
 	github.com/gogo/protobuf v1.3.2 // indirect
 	github.com/kr/pretty v0.3.1 // indirect
 	github.com/kr/text v0.2.0 // indirect
@@ -0,0 +1,99 @@
+package errors
+
+import (
+	"bytes"
+	"context"
+	"runtime"
+	"runtime/pprof"
+	"strconv"
+	"sync/atomic"
+)
+
+// Metadata keys populated by goroutine capture (see WithGoroutineInfo).
+const (
+	MetadataGoroutineID = "goroutine_id"
+	MetadataPprofLabels = "pprof_labels"
+)
+
+var goroutineCapture atomic.Bool
+
+// WithGoroutineInfo records the ID of the goroutine creating the error
+// under "goroutine_id", and, when the constructor also gets a context (see
+// WithContext), the pprof labels set on it with pprof.Do under
+// "pprof_labels". Both appear in ExtractErrorInfo and DebugString, never in
+// Error(). Applies to all typed errors with a Metadata field.
+//
+// Capturing costs a runtime.Stack call and a few allocations per error,
+// so it is off by default; use it while debugging concurrent pipelines.
+//
+// Example:
+//
+//	pprof.Do(ctx, pprof.Labels("worker", strconv.Itoa(id)), func(ctx context.Context) {
+//	    err := NewProcessingError("Failed to resize", "Resize",
+//	        WithContext(ctx), WithGoroutineInfo())
+//	    // GetMetadata(err, "pprof_labels") = map[string]string{"worker": "3"}
+//	})
+func WithGoroutineInfo() Option {
+	return func(err any) {
+		if state := stateField(err); state != nil {
+			state.goroutineInfo = true
+		}
+	}
+}
+
+// EnableGoroutineCapture makes every typed constructor behave as if given
+// WithGoroutineInfo. See WithGoroutineInfo for the cost.
+func EnableGoroutineCapture() {
+	goroutineCapture.Store(true)
+}
+
+// DisableGoroutineCapture turns goroutine capture off again.
+func DisableGoroutineCapture() {
+	goroutineCapture.Store(false)
+}
+
+// goroutineCaptureRequested reports whether err should record goroutine
+// information, globally or through WithGoroutineInfo.
+func goroutineCaptureRequested(err error) bool {
+	if goroutineCapture.Load() {
+		return true
+	}
+	state := stateField(err)
+	return state != nil && state.goroutineInfo
+}
+
+// captureGoroutine records the current goroutine's ID and ctx's pprof
+// labels, if ctx is non-nil, in err's metadata.
+func captureGoroutine(ctx context.Context, err error) {
+	if id, ok := currentGoroutineID(); ok {
+		setMetadata(err, MetadataGoroutineID, id)
+	}
+	if ctx == nil {
+		return
+	}
+
+	var labels map[string]string
+	pprof.ForLabels(ctx, func(key, value string) bool {
+		if labels == nil {
+			labels = make(map[string]string)
+		}
+		labels[key] = value
+		return true
+	})
+	if labels != nil {
+		setMetadata(err, MetadataPprofLabels, labels)
+	}
+}
+
+// currentGoroutineID parses the goroutine ID from the "goroutine N [...]"
+// header of runtime.Stack. The runtime offers no other way to read it.
+func currentGoroutineID() (uint64, bool) {
+	var buf [64]byte
+	header := buf[:runtime.Stack(buf[:], false)]
+	header = bytes.TrimPrefix(header, []byte("goroutine "))
+	if end := bytes.IndexByte(header, ' '); end > 0 {
+		header = header[:end]
+	}
+	id, err := strconv.ParseUint(string(header), 10, 64)
+	return id, err == nil
+}
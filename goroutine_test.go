@@ -0,0 +1,82 @@
+package errors
+
+import (
+	"context"
+	"runtime/pprof"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// TestGoroutineInfo tests capturing the goroutine ID and pprof labels at construction
+func TestGoroutineInfo(t *testing.T) {
+	t.Run("captures labels set with pprof.Do", func(t *testing.T) {
+		var err error
+		pprof.Do(context.Background(), pprof.Labels("worker", "3", "stage", "resize"), func(ctx context.Context) {
+			err = NewProcessingError("Failed to resize", "Resize", WithContext(ctx), WithGoroutineInfo())
+		})
+
+		if id, ok := GetMetadata(err, MetadataGoroutineID); !ok || id.(uint64) == 0 {
+			t.Errorf("goroutine_id = %v, %v, want a goroutine ID", id, ok)
+		}
+		labels, _ := GetMetadata(err, MetadataPprofLabels)
+		want := map[string]string{"worker": "3", "stage": "resize"}
+		got, _ := labels.(map[string]string)
+		if len(got) != len(want) || got["worker"] != "3" || got["stage"] != "resize" {
+			t.Errorf("pprof_labels = %v, want %v", labels, want)
+		}
+
+		if strings.Contains(err.Error(), "goroutine") || strings.Contains(err.Error(), "worker") {
+			t.Errorf("Error() = %q, should not include goroutine information", err.Error())
+		}
+		if debug := DebugString(err); !strings.Contains(debug, "pprof_labels=map[stage:resize worker:3]") {
+			t.Errorf("DebugString() = %q, want the labels", debug)
+		}
+		metadata, _ := ExtractErrorInfo(err)["metadata"].(map[string]any)
+		if _, ok := metadata[MetadataGoroutineID]; !ok {
+			t.Errorf("ExtractErrorInfo() metadata = %v, want goroutine_id", metadata)
+		}
+	})
+
+	t.Run("distinguishes goroutines", func(t *testing.T) {
+		ids := make([]any, 2)
+		var wg sync.WaitGroup
+		for i := range ids {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				ids[i], _ = GetMetadata(NewNetworkError("reset", "Fetch", WithGoroutineInfo()), MetadataGoroutineID)
+			}()
+		}
+		wg.Wait()
+		if ids[0] == nil || ids[0] == ids[1] {
+			t.Errorf("goroutine IDs = %v, want two distinct IDs", ids)
+		}
+	})
+
+	t.Run("off by default", func(t *testing.T) {
+		err := NewProcessingError("Failed", "Process")
+		if _, ok := GetMetadata(err, MetadataGoroutineID); ok {
+			t.Error("goroutine_id captured without being requested")
+		}
+		if DebugString(err) != FormatError(err) {
+			t.Errorf("DebugString() = %q, want FormatError() without metadata", DebugString(err))
+		}
+	})
+
+	t.Run("global capture", func(t *testing.T) {
+		EnableGoroutineCapture()
+		defer DisableGoroutineCapture()
+
+		var err error
+		pprof.Do(context.Background(), pprof.Labels("tenant", "acme"), func(ctx context.Context) {
+			err = NewHTTPErrorCtx(ctx, 503, "Unavailable", nil)
+		})
+		if _, ok := GetMetadata(err, MetadataGoroutineID); !ok {
+			t.Error("goroutine_id not captured with global capture enabled")
+		}
+		if labels, _ := GetMetadata(err, MetadataPprofLabels); labels.(map[string]string)["tenant"] != "acme" {
+			t.Errorf("pprof_labels = %v", labels)
+		}
+	})
+}
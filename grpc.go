@@ -0,0 +1,172 @@
+package errors
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"reflect"
+	"time"
+)
+
+// GRPCStatusError is a gRPC status carried as an error, mirroring
+// google.golang.org/grpc/status without the dependency. ToGRPCError builds
+// one to send and FromGRPCError reads one back; a gRPC server converts it
+// with status.New(codes.Code(e.Code), e.Message).
+type GRPCStatusError struct {
+	Code    GRPCCode
+	Message string
+
+	// RetryDelay is the server's retry hint, as sent in a
+	// google.rpc.RetryInfo detail (optional).
+	RetryDelay time.Duration
+}
+
+// Error renders the status as grpc/status does.
+func (e *GRPCStatusError) Error() string {
+	if e == nil {
+		return "<nil GRPCStatusError>"
+	}
+	return fmt.Sprintf("rpc error: code = %s desc = %s", e.Code, e.Message)
+}
+
+// RetryAfter implements RetryAfterer, so GetRetryAfter sees the server's
+// hint through errors built by FromGRPCError.
+func (e *GRPCStatusError) RetryAfter() time.Duration {
+	if e == nil {
+		return 0
+	}
+	return e.RetryDelay
+}
+
+// ToGRPCCode returns the gRPC status code for err; it is ToGRPCStatus
+// under the name grpc/codes users look for.
+func ToGRPCCode(err error) GRPCCode {
+	return ToGRPCStatus(err)
+}
+
+// ToGRPCError converts err into the status a gRPC handler returns: its
+// code is ToGRPCStatus(err), and its message the typed error's message for
+// client errors, or the code's HTTP status text for server errors so
+// internal causes don't leak, like ToProblemDetails. Retry-after hints are
+// kept. Returns nil for a nil error.
+//
+// Example:
+//
+//	if err != nil {
+//	    s := errors.ToGRPCError(err).(*errors.GRPCStatusError)
+//	    return nil, status.Error(codes.Code(s.Code), s.Message)
+//	}
+func ToGRPCError(err error) error {
+	if err == nil {
+		return nil
+	}
+	status := HTTPStatus(err)
+	message := http.StatusText(status)
+	if status < http.StatusInternalServerError {
+		if typed := typedMessage(err); typed != "" {
+			message = typed
+		}
+	}
+	retryAfter, _ := GetRetryAfter(err)
+	return &GRPCStatusError{Code: ToGRPCStatus(err), Message: message, RetryDelay: retryAfter}
+}
+
+// FromGRPCError converts a gRPC status error back into the closest typed
+// error, so retryability and HTTP mappings survive a gRPC hop. err may be a
+// GRPCStatusError or any error whose chain has a grpc/status error (one
+// with a GRPCStatus method); errors without a status are returned as is,
+// and an OK status as nil. The original error becomes the cause and opts
+// are applied to the result.
+//
+// Unavailable (NetworkError), ResourceExhausted (RateLimitError), Aborted
+// (retryable ConflictError) and DeadlineExceeded (TimeoutError) come back
+// retryable; InvalidArgument and OutOfRange become ValidationErrors,
+// NotFound a NotFoundError, AlreadyExists a ConflictError, Unimplemented a
+// NotImplementedError and Canceled wraps context.Canceled. Other codes
+// become HTTPErrors for the matching status, with Internal, Unknown and
+// DataLoss marked Permanent so they aren't retried.
+//
+// Example:
+//
+//	resp, err := client.GetOrder(ctx, req)
+//	if err != nil {
+//	    return nil, errors.FromGRPCError(err, errors.WithOperation("GetOrder"))
+//	}
+func FromGRPCError(err error, opts ...Option) error {
+	code, message, ok := grpcStatusOf(err)
+	if !ok {
+		return err
+	}
+	opts = append([]Option{WithCause(err)}, opts...)
+
+	switch code {
+	case GRPCOK:
+		return nil
+	case GRPCCanceled:
+		return Wrap(context.Canceled, message)
+	case GRPCInvalidArgument, GRPCOutOfRange:
+		return NewValidationError(message, "", opts...)
+	case GRPCDeadlineExceeded:
+		return NewTimeoutError(message, "", 0, opts...)
+	case GRPCNotFound:
+		return NewNotFoundError("", "", append(opts, WithMessage(message))...)
+	case GRPCAlreadyExists:
+		return NewConflictError("", "", append(opts, WithMessage(message))...)
+	case GRPCAborted:
+		return NewConflictError("", "", append(opts, WithMessage(message), WithRetryable(true))...)
+	case GRPCResourceExhausted:
+		retryAfter, _ := GetRetryAfter(err)
+		return NewRateLimitError(message, "", retryAfter, opts...)
+	case GRPCUnavailable:
+		return NewNetworkError(message, "", opts...)
+	case GRPCUnimplemented:
+		return NewNotImplementedError(message, opts...)
+	case GRPCUnauthenticated:
+		return NewHTTPError(http.StatusUnauthorized, message, nil, opts...)
+	case GRPCPermissionDenied:
+		return NewHTTPError(http.StatusForbidden, message, nil, opts...)
+	case GRPCFailedPrecondition:
+		return NewHTTPError(http.StatusPreconditionFailed, message, nil, opts...)
+	default:
+		return Permanent(NewHTTPError(http.StatusInternalServerError, message, nil, opts...))
+	}
+}
+
+// grpcStatusOf returns the code and message of the first gRPC status in
+// err's chain: a GRPCStatusError, or an error with a GRPCStatus method
+// returning a status with Code and Message methods, as grpc/status errors
+// have.
+func grpcStatusOf(err error) (GRPCCode, string, bool) {
+	var (
+		code    GRPCCode
+		message string
+		found   bool
+	)
+	walkChain(err, func(node error, _ int) bool {
+		if s, ok := node.(*GRPCStatusError); ok && s != nil {
+			code, message, found = s.Code, s.Message, true
+			return false
+		}
+		method := reflect.ValueOf(node).MethodByName("GRPCStatus")
+		if !method.IsValid() || method.Type().NumIn() != 0 || method.Type().NumOut() != 1 {
+			return true
+		}
+		status := method.Call(nil)[0]
+		if status.Kind() == reflect.Pointer && status.IsNil() {
+			return true
+		}
+		codeMethod, messageMethod := status.MethodByName("Code"), status.MethodByName("Message")
+		if !codeMethod.IsValid() || !messageMethod.IsValid() ||
+			codeMethod.Type().NumIn() != 0 || codeMethod.Type().NumOut() != 1 ||
+			messageMethod.Type().NumIn() != 0 || messageMethod.Type().NumOut() != 1 {
+			return true
+		}
+		c, m := codeMethod.Call(nil)[0], messageMethod.Call(nil)[0]
+		if !c.CanUint() || m.Kind() != reflect.String {
+			return true
+		}
+		code, message, found = GRPCCode(c.Uint()), m.String(), true
+		return false
+	})
+	return code, message, found
+}
@@ -0,0 +1,89 @@
+package errors
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+// fakeStatus and fakeStatusError stand in for grpc/status's Status and the
+// error it returns.
+type fakeStatus struct {
+	code    uint32
+	message string
+}
+
+func (s *fakeStatus) Code() uint32    { return s.code }
+func (s *fakeStatus) Message() string { return s.message }
+
+type fakeStatusError struct{ s *fakeStatus }
+
+func (e *fakeStatusError) Error() string           { return "rpc error: " + e.s.message }
+func (e *fakeStatusError) GRPCStatus() *fakeStatus { return e.s }
+
+// TestGRPCRoundTrip tests that typed errors keep their code and retryability through a gRPC status
+func TestGRPCRoundTrip(t *testing.T) {
+	tests := []struct {
+		name          string
+		err           error
+		wantCode      GRPCCode
+		wantRetryable bool
+	}{
+		{name: "validation", err: NewValidationError("bad id", "id"), wantCode: GRPCInvalidArgument},
+		{name: "rate limit", err: NewRateLimitError("slow down", "Search", 3*time.Second), wantCode: GRPCResourceExhausted, wantRetryable: true},
+		{name: "timeout", err: NewTimeoutError("too slow", "Quote", time.Second), wantCode: GRPCDeadlineExceeded, wantRetryable: true},
+		{name: "not found", err: NewNotFoundError("order", "42"), wantCode: GRPCNotFound},
+		{name: "circuit open", err: NewCircuitBreakerError("open", "Charge", "open"), wantCode: GRPCUnavailable, wantRetryable: true},
+		{name: "http status", err: NewHTTPError(403, "forbidden", nil), wantCode: GRPCPermissionDenied},
+		{name: "conflict", err: NewConflictError("order", "42", WithRetryable(true)), wantCode: GRPCAborted, wantRetryable: true},
+		{name: "not implemented", err: NewNotImplementedError("bulk export"), wantCode: GRPCUnimplemented},
+		{name: "internal", err: NewProcessingError("bad row", "Import"), wantCode: GRPCInternal},
+		{name: "canceled", err: context.Canceled, wantCode: GRPCCanceled},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ToGRPCCode(tt.err); got != tt.wantCode {
+				t.Fatalf("ToGRPCCode() = %v, want %v", got, tt.wantCode)
+			}
+			back := FromGRPCError(ToGRPCError(tt.err))
+			if got := ToGRPCCode(back); got != tt.wantCode {
+				t.Errorf("ToGRPCCode(FromGRPCError()) = %v, want %v (%v)", got, tt.wantCode, back)
+			}
+			if got := IsRetryable(back); got != tt.wantRetryable {
+				t.Errorf("IsRetryable(FromGRPCError()) = %v, want %v (%v)", got, tt.wantRetryable, back)
+			}
+		})
+	}
+}
+
+// TestFromGRPCError tests reconstruction from foreign status errors and retry hints
+func TestFromGRPCError(t *testing.T) {
+	if FromGRPCError(nil) != nil || FromGRPCError(&GRPCStatusError{Code: GRPCOK}) != nil {
+		t.Error("nil and OK statuses should convert to nil")
+	}
+	plain := NewNetworkError("connection reset", "Quote")
+	if FromGRPCError(plain) != plain {
+		t.Error("an error without a status should be returned as is")
+	}
+
+	foreign := Wrap(&fakeStatusError{&fakeStatus{code: uint32(GRPCUnavailable), message: "no healthy upstream"}}, "calling quotes")
+	got := FromGRPCError(foreign, WithOperation("GetQuote"))
+	var netErr *NetworkError
+	if !As(got, &netErr) || netErr.Message != "no healthy upstream" || netErr.Operation != "GetQuote" || !IsRetryable(got) {
+		t.Errorf("FromGRPCError(foreign Unavailable) = %v, want a retryable NetworkError in GetQuote", got)
+	}
+
+	hinted := FromGRPCError(&GRPCStatusError{Code: GRPCResourceExhausted, Message: "quota", RetryDelay: 5 * time.Second})
+	if wait, ok := GetRetryAfter(hinted); !ok || wait != 5*time.Second {
+		t.Errorf("GetRetryAfter() = %v, %v, want 5s", wait, ok)
+	}
+
+	internal := ToGRPCError(NewHTTPError(500, "db password rejected", nil)).(*GRPCStatusError)
+	if internal.Message != "Internal Server Error" {
+		t.Errorf("server error message = %q, want the status text", internal.Message)
+	}
+	if IsRetryable(FromGRPCError(internal)) {
+		t.Error("Internal should not come back retryable")
+	}
+}
@@ -0,0 +1,80 @@
+package errors
+
+import (
+	"fmt"
+	"runtime"
+	"sync/atomic"
+)
+
+// handledError marks an error that has already been logged or reported.
+// It is transparent: its message is its cause's, and errors.Is, errors.As,
+// classification and transport mappings see straight through it.
+type handledError struct {
+	err  error
+	site string
+}
+
+func (e *handledError) Error() string {
+	return e.err.Error()
+}
+
+func (e *handledError) Unwrap() error {
+	return e.err
+}
+
+// logHandled is set by EnableHandledLogging.
+var logHandled atomic.Bool
+
+// MarkHandled marks err as already logged or reported, recording the
+// caller's file and line as where it was handled (see HandledAt). LogError
+// and hooks registered with RegisterHook skip marked errors, so an error
+// logged where it happened and returned up through the service and handler
+// layers reaches Sentry once. Nothing else changes: the message,
+// classification, HTTP status and envelope are those of err. Returns err
+// unchanged if it is nil or already marked.
+//
+// Example:
+//
+//	if err := repo.Save(ctx, order); err != nil {
+//	    errors.LogError(ctx, logger, "saving order", err)
+//	    return errors.MarkHandled(err)
+//	}
+func MarkHandled(err error) error {
+	if err == nil || IsHandled(err) {
+		return err
+	}
+	site := "unknown"
+	if _, file, line, ok := runtime.Caller(1); ok {
+		site = fmt.Sprintf("%s:%d", file, line)
+	}
+	return &handledError{err: err, site: site}
+}
+
+// IsHandled reports whether err's chain was marked with MarkHandled.
+func IsHandled(err error) bool {
+	_, ok := HandledAt(err)
+	return ok
+}
+
+// HandledAt returns the file and line where err's chain was marked with
+// MarkHandled, such as "/src/orders/repo.go:42".
+func HandledAt(err error) (string, bool) {
+	var handled *handledError
+	if As(err, &handled) {
+		return handled.site, true
+	}
+	return "", false
+}
+
+// EnableHandledLogging makes LogError log errors marked with MarkHandled
+// again, such as while debugging which layer handled an error. Hooks
+// choose for themselves with HookFilter.IncludeHandled.
+func EnableHandledLogging() {
+	logHandled.Store(true)
+}
+
+// DisableHandledLogging restores the default of LogError skipping errors
+// marked with MarkHandled.
+func DisableHandledLogging() {
+	logHandled.Store(false)
+}
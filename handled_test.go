@@ -0,0 +1,95 @@
+package errors
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"log/slog"
+	"strings"
+	"testing"
+)
+
+// TestMarkHandled tests that the handled mark changes nothing but IsHandled
+func TestMarkHandled(t *testing.T) {
+	if MarkHandled(nil) != nil {
+		t.Error("MarkHandled(nil) should return nil")
+	}
+
+	err := NewHTTPError(503, "Service Unavailable", nil)
+	handled := MarkHandled(err)
+	if !IsHandled(handled) || !IsHandled(Wrap(handled, "outer")) || IsHandled(err) {
+		t.Error("IsHandled() should see the mark through wrappers and not on the original")
+	}
+	if site, ok := HandledAt(handled); !ok || !strings.Contains(site, "handled_test.go:") {
+		t.Errorf("HandledAt() = %q, %v, want this file", site, ok)
+	}
+	if again := MarkHandled(handled); again != handled {
+		t.Error("MarkHandled() should return an already handled error unchanged")
+	}
+
+	if handled.Error() != err.Error() {
+		t.Errorf("Error() = %q, want %q", handled.Error(), err.Error())
+	}
+	if !Is(handled, err) || Classify(handled) != Classify(err) || IsRetryable(handled) != IsRetryable(err) {
+		t.Error("classification should be unaffected by the mark")
+	}
+	if HTTPStatus(handled) != HTTPStatus(err) {
+		t.Errorf("HTTPStatus() = %d, want %d", HTTPStatus(handled), HTTPStatus(err))
+	}
+	if Encode(handled).Type != "HTTPError" {
+		t.Errorf("Encode().Type = %q, want the mark skipped", Encode(handled).Type)
+	}
+}
+
+// TestHandledReportedOnce tests that an error reported at the bottom of the stack reaches hooks once
+func TestHandledReportedOnce(t *testing.T) {
+	t.Cleanup(ResetHooks)
+	var calls, allCalls int
+	RegisterHook(func(context.Context, error) { calls++ }, HookFilter{})
+	RegisterHook(func(context.Context, error) { allCalls++ }, HookFilter{IncludeHandled: true})
+
+	ctx := context.Background()
+	repository := func() error {
+		err := NewNetworkError("connection reset", "SaveOrder")
+		Report(ctx, err)
+		return MarkHandled(err)
+	}
+	service := func() error {
+		if err := repository(); err != nil {
+			Report(ctx, err)
+			return Wrap(err, "placing order")
+		}
+		return nil
+	}
+	if err := service(); err != nil {
+		Report(ctx, fmt.Errorf("handling checkout: %w", err))
+	}
+
+	if calls != 1 {
+		t.Errorf("hook called %d times, want 1", calls)
+	}
+	if allCalls != 3 {
+		t.Errorf("IncludeHandled hook called %d times, want 3", allCalls)
+	}
+}
+
+// TestLogErrorHandled tests that LogError skips handled errors unless enabled
+func TestLogErrorHandled(t *testing.T) {
+	t.Cleanup(DisableHandledLogging)
+	var buf bytes.Buffer
+	logger := slog.New(slog.NewTextHandler(&buf, nil))
+	ctx := context.Background()
+
+	err := NewProcessingError("failed", "Import")
+	LogError(ctx, logger, "importing", err)
+	LogError(ctx, logger, "importing", Wrap(MarkHandled(err), "outer"))
+	if got := strings.Count(buf.String(), "msg=importing"); got != 1 {
+		t.Errorf("logged %d times, want 1:\n%s", got, buf.String())
+	}
+
+	EnableHandledLogging()
+	LogError(ctx, logger, "importing", MarkHandled(err))
+	if got := strings.Count(buf.String(), "msg=importing"); got != 2 {
+		t.Errorf("logged %d times with handled logging enabled, want 2", got)
+	}
+}
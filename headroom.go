@@ -0,0 +1,98 @@
+package errors
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/cockroachdb/errors"
+)
+
+// TimeoutSourceHeadroom is the Source of a TimeoutError returned by
+// RequireHeadroom: the call was never made because too little of the
+// deadline was left.
+const TimeoutSourceHeadroom = "insufficient_headroom"
+
+// RequireHeadroom returns a TimeoutError when ctx's deadline leaves less
+// than need, so a call that can't finish in time fails fast instead of
+// wasting a connection on a guaranteed context.DeadlineExceeded. Returns nil
+// when ctx has no deadline or need isn't positive.
+//
+// The error's Source is TimeoutSourceHeadroom and it is not retryable:
+// retrying under the same deadline can only fail again. A caller that gets
+// a fresh deadline per try, such as a queue redelivering a message, can
+// still retry it; IsInsufficientHeadroom tells it apart from timeouts that
+// actually happened. The remaining time is read from the package clock
+// (see SetClock).
+//
+// Example:
+//
+//	if err := errors.RequireHeadroom(ctx, 50*time.Millisecond, "GetQuote"); err != nil {
+//	    return err
+//	}
+//	return pricing.GetQuote(ctx, req)
+func RequireHeadroom(ctx context.Context, need time.Duration, op string) error {
+	if ctx == nil || need <= 0 {
+		return nil
+	}
+	deadline, ok := ctx.Deadline()
+	if !ok {
+		return nil
+	}
+	left := until(deadline)
+	if left >= need {
+		return nil
+	}
+
+	left = max(left, 0)
+	err := &TimeoutError{
+		Message:   fmt.Sprintf("%v left before the deadline, %v needed", left, need),
+		Operation: op,
+		Duration:  left,
+		Source:    TimeoutSourceHeadroom,
+	}
+	applyOptions(err, nil)
+	return err
+}
+
+type headroomKey struct{}
+
+// WithHeadroom checks that ctx's deadline leaves at least need, as
+// RequireHeadroom does, and returns a context recording need so later calls
+// made with it keep the same margin (see HeadroomFromContext). The
+// returned context is ctx itself when the check fails.
+//
+// Example:
+//
+//	ctx, err := errors.WithHeadroom(ctx, 100*time.Millisecond)
+//	if err != nil {
+//	    return err
+//	}
+func WithHeadroom(ctx context.Context, need time.Duration) (context.Context, error) {
+	if err := RequireHeadroom(ctx, need, ""); err != nil {
+		return ctx, err
+	}
+	return context.WithValue(ctx, headroomKey{}, need), nil
+}
+
+// HeadroomFromContext returns the headroom recorded on ctx by WithHeadroom.
+func HeadroomFromContext(ctx context.Context) (time.Duration, bool) {
+	if ctx == nil {
+		return 0, false
+	}
+	need, ok := ctx.Value(headroomKey{}).(time.Duration)
+	return need, ok
+}
+
+// IsInsufficientHeadroom reports whether err's chain holds a TimeoutError
+// from RequireHeadroom, meaning the call was skipped rather than timed out.
+//
+// Example:
+//
+//	if errors.IsInsufficientHeadroom(err) {
+//	    msg.Nack() // redelivered with a fresh deadline
+//	}
+func IsInsufficientHeadroom(err error) bool {
+	var timeoutErr *TimeoutError
+	return errors.As(err, &timeoutErr) && timeoutErr != nil && timeoutErr.Source == TimeoutSourceHeadroom
+}
@@ -0,0 +1,107 @@
+package errors
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+// deadlineCtx reports a deadline on the package clock without expiring in real time
+type deadlineCtx struct {
+	context.Context
+	deadline time.Time
+}
+
+func (c deadlineCtx) Deadline() (time.Time, bool) {
+	return c.deadline, true
+}
+
+// TestRequireHeadroom tests headroom checks against deadlines on the fake clock
+func TestRequireHeadroom(t *testing.T) {
+	clock := newFakeClock()
+	SetClock(clock)
+	t.Cleanup(func() { SetClock(nil) })
+
+	withDeadline := func(left time.Duration) context.Context {
+		return deadlineCtx{Context: context.Background(), deadline: clock.Now().Add(left)}
+	}
+
+	tests := []struct {
+		name    string
+		ctx     context.Context
+		need    time.Duration
+		wantErr bool
+	}{
+		{name: "enough headroom", ctx: withDeadline(time.Second), need: 100 * time.Millisecond},
+		{name: "exactly enough", ctx: withDeadline(100 * time.Millisecond), need: 100 * time.Millisecond},
+		{name: "too little left", ctx: withDeadline(20 * time.Millisecond), need: 100 * time.Millisecond, wantErr: true},
+		{name: "deadline passed", ctx: withDeadline(-time.Second), need: time.Millisecond, wantErr: true},
+		{name: "no deadline", ctx: context.Background(), need: time.Hour},
+		{name: "no requirement", ctx: withDeadline(time.Millisecond)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := RequireHeadroom(tt.ctx, tt.need, "GetQuote")
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("RequireHeadroom() = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err == nil {
+				return
+			}
+			if !IsInsufficientHeadroom(err) || !IsTimeout(err) {
+				t.Errorf("%v should be a headroom TimeoutError", err)
+			}
+			if IsRetryable(err) {
+				t.Error("headroom errors should not be retryable under the same deadline")
+			}
+			if timeoutErr := err.(*TimeoutError); timeoutErr.Duration < 0 || timeoutErr.Operation != "GetQuote" {
+				t.Errorf("unexpected fields: %+v", timeoutErr)
+			}
+		})
+	}
+
+	t.Run("other timeouts", func(t *testing.T) {
+		err := NewTimeoutError("slow", "GetQuote", time.Second)
+		if IsInsufficientHeadroom(err) || !IsRetryable(err) {
+			t.Error("ordinary timeouts should be retryable and not headroom errors")
+		}
+	})
+
+	t.Run("survives transport", func(t *testing.T) {
+		err := RequireHeadroom(withDeadline(time.Millisecond), time.Second, "GetQuote")
+		data, marshalErr := MarshalError(Wrap(err, "pricing"))
+		if marshalErr != nil {
+			t.Fatal(marshalErr)
+		}
+		decoded, decodeErr := UnmarshalError(data)
+		if decodeErr != nil || !IsInsufficientHeadroom(decoded) {
+			t.Errorf("decoded %v (%v) should still be a headroom error", decoded, decodeErr)
+		}
+	})
+}
+
+// TestWithHeadroom tests the guard clause and the headroom it records
+func TestWithHeadroom(t *testing.T) {
+	clock := newFakeClock()
+	SetClock(clock)
+	t.Cleanup(func() { SetClock(nil) })
+
+	ctx := deadlineCtx{Context: context.Background(), deadline: clock.Now().Add(time.Second)}
+
+	guarded, err := WithHeadroom(ctx, 200*time.Millisecond)
+	if err != nil {
+		t.Fatalf("WithHeadroom() error = %v", err)
+	}
+	if need, ok := HeadroomFromContext(guarded); !ok || need != 200*time.Millisecond {
+		t.Errorf("HeadroomFromContext() = %v, %v, want 200ms", need, ok)
+	}
+
+	clock.Advance(900 * time.Millisecond)
+	if _, err := WithHeadroom(ctx, 200*time.Millisecond); !IsInsufficientHeadroom(err) {
+		t.Errorf("WithHeadroom() with 100ms left = %v, want a headroom error", err)
+	}
+	if _, ok := HeadroomFromContext(context.Background()); ok {
+		t.Error("a plain context should record no headroom")
+	}
+}
@@ -0,0 +1,229 @@
+package errors
+
+import (
+	"encoding/json"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// defaultMaxHealthKeys bounds how many dependencies a HealthTracker tracks.
+const defaultMaxHealthKeys = 1000
+
+// HealthState is a dependency's health as judged by a HealthTracker.
+type HealthState string
+
+// Health states reported by HealthTracker.Status.
+const (
+	HealthHealthy  HealthState = "healthy"
+	HealthDegraded HealthState = "degraded"
+	HealthDown     HealthState = "down"
+)
+
+// HealthPolicy decides a dependency's health from the share of calls to it
+// that failed within Window. Dependencies with fewer than MinSamples calls
+// in the window are healthy, so one early failure doesn't mark a cold
+// dependency down.
+type HealthPolicy struct {
+	Window        time.Duration
+	MinSamples    int
+	DegradedRatio float64 // failure share at or above which it is degraded
+	DownRatio     float64 // failure share at or above which it is down
+}
+
+// DefaultHealthPolicy marks a dependency degraded when 10% of at least 5
+// calls in the last minute failed, and down at 50%.
+var DefaultHealthPolicy = HealthPolicy{
+	Window:        time.Minute,
+	MinSamples:    5,
+	DegradedRatio: 0.1,
+	DownRatio:     0.5,
+}
+
+// DependencyHealth is one dependency's state and the calls it was judged
+// on.
+type DependencyHealth struct {
+	State     HealthState `json:"state"`
+	Successes int         `json:"successes"`
+	Failures  int         `json:"failures"`
+}
+
+// HealthTracker judges the health of dependencies from the errors calls to
+// them return, so a readiness probe can report "payments-api down" without
+// probing it. Calls are counted over a sliding window per dependency;
+// dependencies idle for a whole window are forgotten, and the least
+// recently seen are evicted once the key limit is reached. Safe for
+// concurrent use.
+type HealthTracker struct {
+	policy  HealthPolicy
+	maxKeys int
+	clock   Clock
+
+	mu    sync.Mutex
+	calls *windowedKeys[struct{}] // successes and failures per dependency
+}
+
+// Kinds of calls a HealthTracker counts.
+const (
+	healthSuccess = iota
+	healthFailure
+)
+
+// NewHealthTracker creates a HealthTracker applying policy.
+// Supports WithClock and WithMaxKeys options.
+//
+// Example:
+//
+//	health := errors.NewHealthTracker(errors.DefaultHealthPolicy)
+//	http.Handle("/readyz/details", health.Handler())
+func NewHealthTracker(policy HealthPolicy, opts ...Option) *HealthTracker {
+	t := &HealthTracker{
+		policy:  policy,
+		maxKeys: defaultMaxHealthKeys,
+		clock:   packageClock{},
+	}
+	for _, opt := range opts {
+		opt(t)
+	}
+	t.calls = newWindowedKeys[struct{}](policy.Window, t.maxKeys)
+	return t
+}
+
+// Observe records the outcome of a call that returned err, attributed to
+// the dependency named by GetDependency (see WithDependency;
+// FromHTTPResponse and httperrors.Transport record the request's host).
+// Nil errors, errors without a dependency and caller disconnects are
+// ignored. Client errors such as a 404 show the dependency answering, so
+// they count as successes.
+//
+// Example:
+//
+//	resp, err := client.Do(req)
+//	health.Observe(err)
+func (t *HealthTracker) Observe(err error) {
+	if err == nil || IsCallerDisconnect(err) {
+		return
+	}
+	dependency := GetDependency(err)
+	if dependency == "" {
+		return
+	}
+	t.observe(dependency, !isClientStatus(HTTPStatus(err)))
+}
+
+// ObserveSuccess records a successful call to dependency.
+func (t *HealthTracker) ObserveSuccess(dependency string) {
+	if dependency == "" {
+		return
+	}
+	t.observe(dependency, false)
+}
+
+func (t *HealthTracker) observe(dependency string, failed bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	kind := healthSuccess
+	if failed {
+		kind = healthFailure
+	}
+	t.calls.add(dependency, kind, t.clock.Now(), struct{}{})
+}
+
+// Status returns the health of every dependency seen within the window.
+//
+// Example:
+//
+//	for dependency, state := range health.Status() {
+//	    if state != errors.HealthHealthy {
+//	        log.Printf("degraded: %s %s", dependency, state)
+//	    }
+//	}
+func (t *HealthTracker) Status() map[string]HealthState {
+	status := make(map[string]HealthState)
+	for dependency, health := range t.Snapshot() {
+		status[dependency] = health.State
+	}
+	return status
+}
+
+// Snapshot returns the health of every dependency seen within the window,
+// with the calls in the window it was judged on.
+func (t *HealthTracker) Snapshot() map[string]DependencyHealth {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	snapshot := make(map[string]DependencyHealth, t.calls.len())
+	t.calls.each(t.clock.Now(), func(entry *windowEntry[struct{}]) {
+		health := DependencyHealth{
+			Successes: entry.counts.total(healthSuccess),
+			Failures:  entry.counts.total(healthFailure),
+		}
+		health.State = t.state(health.Successes, health.Failures)
+		snapshot[entry.key] = health
+	})
+	return snapshot
+}
+
+// Handler returns an http.Handler rendering Snapshot as JSON, with an
+// overall "status" that is the worst dependency's state, for readiness
+// probe details. It responds 503 when any dependency is down and 200
+// otherwise.
+//
+// Example:
+//
+//	mux.Handle("/readyz/details", health.Handler())
+//	// {"status":"down","dependencies":{"payments-api":{"state":"down","successes":2,"failures":9}}}
+func (t *HealthTracker) Handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		dependencies := t.Snapshot()
+		overall := HealthHealthy
+		for _, health := range dependencies {
+			if healthRank(health.State) > healthRank(overall) {
+				overall = health.State
+			}
+		}
+
+		body, err := json.Marshal(struct {
+			Status       HealthState                 `json:"status"`
+			Dependencies map[string]DependencyHealth `json:"dependencies"`
+		}{overall, dependencies})
+		if err != nil {
+			WriteProblem(w, NewEncodeError("encoding health", "HealthTracker", "json", WithCause(err)))
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		if overall == HealthDown {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+		_, _ = w.Write(body)
+	})
+}
+
+// state judges a window's counts against the policy.
+func (t *HealthTracker) state(successes, failures int) HealthState {
+	total := successes + failures
+	if total == 0 || total < t.policy.MinSamples {
+		return HealthHealthy
+	}
+	ratio := float64(failures) / float64(total)
+	switch {
+	case t.policy.DownRatio > 0 && ratio >= t.policy.DownRatio:
+		return HealthDown
+	case t.policy.DegradedRatio > 0 && ratio >= t.policy.DegradedRatio:
+		return HealthDegraded
+	}
+	return HealthHealthy
+}
+
+// healthRank orders states from best to worst.
+func healthRank(state HealthState) int {
+	switch state {
+	case HealthDegraded:
+		return 1
+	case HealthDown:
+		return 2
+	}
+	return 0
+}
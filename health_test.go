@@ -0,0 +1,160 @@
+package errors
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+)
+
+var testHealthPolicy = HealthPolicy{
+	Window:        time.Minute,
+	MinSamples:    4,
+	DegradedRatio: 0.2,
+	DownRatio:     0.5,
+}
+
+// paymentsFailure returns a failed call to payments-api.
+func paymentsFailure() error {
+	return NewNetworkError("connection refused", "Charge", WithDependency("payments-api"))
+}
+
+// TestHealthTrackerFlapping tests health following a dependency that fails, recovers and flaps
+func TestHealthTrackerFlapping(t *testing.T) {
+	clock := newFakeClock()
+	health := NewHealthTracker(testHealthPolicy, WithClock(clock))
+
+	for range 3 {
+		health.Observe(paymentsFailure())
+	}
+	if got := health.Status()["payments-api"]; got != HealthHealthy {
+		t.Errorf("below MinSamples: state = %q, want healthy", got)
+	}
+	health.Observe(paymentsFailure())
+	if got := health.Status()["payments-api"]; got != HealthDown {
+		t.Errorf("all failing: state = %q, want down", got)
+	}
+
+	// The failures age out of the window while calls succeed again.
+	for range 12 {
+		clock.Advance(10 * time.Second)
+		health.ObserveSuccess("payments-api")
+	}
+	if got := health.Status()["payments-api"]; got != HealthHealthy {
+		t.Errorf("recovered: state = %q, want healthy", got)
+	}
+
+	// Flapping: one call in three fails.
+	var states []HealthState
+	for i := range 12 {
+		clock.Advance(5 * time.Second)
+		if i%3 == 0 {
+			health.Observe(paymentsFailure())
+		} else {
+			health.ObserveSuccess("payments-api")
+		}
+		states = append(states, health.Status()["payments-api"])
+	}
+	if got := states[len(states)-1]; got != HealthDegraded {
+		t.Errorf("flapping: state = %q, want degraded (states %v)", got, states)
+	}
+
+	clock.Advance(2 * time.Minute)
+	if status := health.Status(); len(status) != 0 {
+		t.Errorf("idle for a window: Status() = %v, want the dependency forgotten", status)
+	}
+}
+
+// TestHealthTrackerObserve tests which errors count against which dependency
+func TestHealthTrackerObserve(t *testing.T) {
+	recorded := &http.Response{
+		StatusCode: http.StatusServiceUnavailable,
+		Request:    &http.Request{URL: &url.URL{Scheme: "https", Host: "pricing.internal"}},
+	}
+
+	tests := []struct {
+		name       string
+		err        error
+		dependency string
+		failures   int
+		successes  int
+	}{
+		{"network error", paymentsFailure(), "payments-api", 1, 0},
+		{"response host", FromHTTPResponse(recorded), "pricing.internal", 1, 0},
+		{"wrapped", fmt.Errorf("charging: %w", paymentsFailure()), "payments-api", 1, 0},
+		{"rate limited", NewHTTPError(429, "Too Many Requests", nil, WithDependency("search")), "search", 1, 0},
+		{"client error", NewHTTPError(404, "Not Found", nil, WithDependency("search")), "search", 0, 1},
+		{"bad payload", NewDecodeError("decoding quote", "GetQuote", "json", WithDependency("pricing")), "pricing", 1, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			health := NewHealthTracker(testHealthPolicy)
+			health.Observe(tt.err)
+			got := health.Snapshot()[tt.dependency]
+			if got.Failures != tt.failures || got.Successes != tt.successes {
+				t.Errorf("Snapshot()[%q] = %+v, want %d failures and %d successes", tt.dependency, got, tt.failures, tt.successes)
+			}
+		})
+	}
+
+	t.Run("ignored", func(t *testing.T) {
+		health := NewHealthTracker(testHealthPolicy)
+		health.Observe(nil)
+		health.Observe(NewNetworkError("connection refused", "Charge"))
+		health.Observe(NewNetworkError("write failed", "Charge", WithDependency("payments-api"), WithCause(ErrCallerDisconnected)))
+		health.ObserveSuccess("")
+		if status := health.Status(); len(status) != 0 {
+			t.Errorf("Status() = %v, want nothing tracked", status)
+		}
+	})
+}
+
+// TestHealthTrackerHandler tests the JSON readiness details
+func TestHealthTrackerHandler(t *testing.T) {
+	health := NewHealthTracker(testHealthPolicy)
+	for range 4 {
+		health.ObserveSuccess("search")
+		health.Observe(paymentsFailure())
+	}
+
+	rec := httptest.NewRecorder()
+	health.Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/readyz/details", nil))
+	if rec.Code != http.StatusServiceUnavailable {
+		t.Errorf("status = %d, want 503 with a dependency down", rec.Code)
+	}
+
+	var body struct {
+		Status       HealthState                 `json:"status"`
+		Dependencies map[string]DependencyHealth `json:"dependencies"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+		t.Fatalf("invalid JSON %s: %v", rec.Body, err)
+	}
+	want := DependencyHealth{State: HealthDown, Failures: 4}
+	if body.Status != HealthDown || body.Dependencies["payments-api"] != want || body.Dependencies["search"].State != HealthHealthy {
+		t.Errorf("body = %s", rec.Body)
+	}
+
+	healthy := NewHealthTracker(testHealthPolicy)
+	rec = httptest.NewRecorder()
+	healthy.Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/readyz/details", nil))
+	if rec.Code != http.StatusOK {
+		t.Errorf("status = %d, want 200 with nothing down", rec.Code)
+	}
+}
+
+// TestHealthTrackerBounded tests that the least recently seen dependencies are evicted
+func TestHealthTrackerBounded(t *testing.T) {
+	health := NewHealthTracker(testHealthPolicy, WithMaxKeys(2))
+	for _, dependency := range []string{"a", "b", "c"} {
+		health.ObserveSuccess(dependency)
+	}
+	status := health.Status()
+	if _, ok := status["a"]; ok || len(status) != 2 {
+		t.Errorf("Status() = %v, want only the 2 most recent", status)
+	}
+}
@@ -0,0 +1,137 @@
+package errors
+
+import (
+	"context"
+	"slices"
+	"sync"
+)
+
+// Hook receives errors passed to Report, for forwarding to error trackers
+// such as Sentry. Hooks run synchronously on the reporting goroutine.
+type Hook func(ctx context.Context, err error)
+
+// HookFilter selects which errors reach a hook. The zero value matches every
+// error not yet handled. All set conditions must hold:
+//   - MinSeverity: GetSeverity(err) is at least this severity; under Report
+//     with an Escalator installed (see SetEscalator), the escalated severity
+//   - Classes: Classify(err) is one of these classes
+//   - ExcludeTypes: no typed error in the chain has one of these type names
+//     (as reported by ExtractErrorInfo, e.g. "ValidationError")
+//
+// Errors marked with MarkHandled don't match unless IncludeHandled is set,
+// so an error reported where it happened isn't reported again on its way
+// up.
+type HookFilter struct {
+	MinSeverity    Severity
+	Classes        []ErrorClass
+	ExcludeTypes   []string
+	IncludeHandled bool
+}
+
+// Match reports whether err passes the filter. Returns false for a nil
+// error.
+func (f HookFilter) Match(err error) bool {
+	if err == nil {
+		return false
+	}
+	return f.match(err, GetSeverity(err))
+}
+
+// match is Match with err's severity already computed.
+func (f HookFilter) match(err error, severity Severity) bool {
+	if !f.IncludeHandled && IsHandled(err) {
+		return false
+	}
+	if len(f.ExcludeTypes) > 0 && hasTypeNamed(err, f.ExcludeTypes) {
+		return false
+	}
+	if f.MinSeverity > 0 && severity < f.MinSeverity {
+		return false
+	}
+	if len(f.Classes) > 0 && !slices.Contains(f.Classes, Classify(err)) {
+		return false
+	}
+	return true
+}
+
+type registeredHook struct {
+	fn     Hook
+	filter HookFilter
+}
+
+var (
+	hooksMu sync.RWMutex
+	hooks   []registeredHook
+)
+
+// RegisterHook adds a hook called by Report for errors matching filter.
+// Each hook has its own filter, so several hooks can receive different
+// subsets of errors.
+//
+// Example:
+//
+//	errors.RegisterHook(sentryerrors.Hook(hub), errors.HookFilter{
+//	    MinSeverity:  errors.SeverityError,
+//	    Classes:      []errors.ErrorClass{errors.ClassPermanent, errors.ClassUnknown},
+//	    ExcludeTypes: []string{"ValidationError"},
+//	})
+func RegisterHook(fn Hook, filter HookFilter) {
+	hooksMu.Lock()
+	defer hooksMu.Unlock()
+	hooks = append(hooks, registeredHook{fn: fn, filter: filter})
+}
+
+// ResetHooks removes all registered hooks. Intended for tests.
+func ResetHooks() {
+	hooksMu.Lock()
+	defer hooksMu.Unlock()
+	hooks = nil
+}
+
+// Report passes err to every registered hook whose filter matches it.
+// Filters are evaluated before any hook runs. With an Escalator installed
+// (see SetEscalator), err is observed once and filters see its escalated
+// severity. Does nothing for a nil error.
+//
+// Example:
+//
+//	if err := processOrder(ctx, order); err != nil {
+//	    errors.Report(ctx, err)
+//	    return err
+//	}
+func Report(ctx context.Context, err error) {
+	if err == nil {
+		return
+	}
+
+	hooksMu.RLock()
+	registered := hooks
+	hooksMu.RUnlock()
+	if len(registered) == 0 {
+		return
+	}
+
+	severity := observeSeverity(ctx, err)
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	ctx = context.WithValue(ctx, observedSeverityKey{}, observedSeverity{err: err, severity: severity})
+	for _, h := range registered {
+		if h.filter.match(err, severity) {
+			h.fn(ctx, err)
+		}
+	}
+}
+
+// hasTypeNamed reports whether any typed error in err's chain has one of
+// the given type names.
+func hasTypeNamed(err error, names []string) bool {
+	found := false
+	walkChain(err, func(node error, _ int) bool {
+		if typed, ok := node.(chainFormatter); ok {
+			found = slices.Contains(names, typeName(typed))
+		}
+		return !found
+	})
+	return found
+}
@@ -0,0 +1,97 @@
+package errors
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"log/slog"
+	"testing"
+)
+
+// TestHookFilterMatch tests the filter conditions
+func TestHookFilterMatch(t *testing.T) {
+	validation := NewValidationError("Invalid email", "email")
+	internal := NewProcessingError("failed", "Charge", WithCause(fmt.Errorf("disk full")))
+	transient := NewNetworkError("dial failed", "Connect")
+
+	tests := []struct {
+		name   string
+		filter HookFilter
+		err    error
+		want   bool
+	}{
+		{name: "zero filter matches", filter: HookFilter{}, err: validation, want: true},
+		{name: "nil never matches", filter: HookFilter{}, err: nil, want: false},
+		{name: "below min severity", filter: HookFilter{MinSeverity: SeverityError}, err: validation, want: false},
+		{name: "at min severity", filter: HookFilter{MinSeverity: SeverityError}, err: internal, want: true},
+		{name: "class allowed", filter: HookFilter{Classes: []ErrorClass{ClassUnknown}}, err: internal, want: true},
+		{name: "class not allowed", filter: HookFilter{Classes: []ErrorClass{ClassPermanent}}, err: transient, want: false},
+		{name: "excluded type", filter: HookFilter{ExcludeTypes: []string{"ValidationError"}}, err: validation, want: false},
+		{
+			name:   "excluded type deeper in chain",
+			filter: HookFilter{ExcludeTypes: []string{"ValidationError"}},
+			err:    NewHTTPError(400, "Bad Request", Wrap(validation, "parsing body")),
+			want:   false,
+		},
+		{name: "other type not excluded", filter: HookFilter{ExcludeTypes: []string{"ValidationError"}}, err: internal, want: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.filter.Match(tt.err); got != tt.want {
+				t.Errorf("Match() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+// TestReportHooks tests that filtered errors never reach hooks and filters compose
+func TestReportHooks(t *testing.T) {
+	defer ResetHooks()
+
+	var tracker, audit []error
+	RegisterHook(func(_ context.Context, err error) { tracker = append(tracker, err) }, HookFilter{
+		MinSeverity:  SeverityError,
+		Classes:      []ErrorClass{ClassPermanent, ClassUnknown},
+		ExcludeTypes: []string{"ValidationError"},
+	})
+	RegisterHook(func(_ context.Context, err error) { audit = append(audit, err) }, HookFilter{
+		Classes: []ErrorClass{ClassPermanent},
+	})
+
+	ctx := context.Background()
+	validation := NewValidationError("Invalid email", "email")
+	internal := NewProcessingError("failed", "Charge")
+	transient := NewNetworkError("dial failed", "Connect")
+	forbidden := NewHTTPError(403, "Forbidden", nil)
+
+	for _, err := range []error{validation, internal, transient, forbidden, nil} {
+		Report(ctx, err)
+	}
+
+	if len(tracker) != 2 || tracker[0] != internal || tracker[1] != forbidden {
+		t.Errorf("tracker hook received %v", tracker)
+	}
+	if len(audit) != 2 || audit[0] != validation || audit[1] != forbidden {
+		t.Errorf("audit hook received %v", audit)
+	}
+}
+
+// TestLogHook tests the slog adapter as a filtered hook
+func TestLogHook(t *testing.T) {
+	defer ResetHooks()
+
+	var buf bytes.Buffer
+	RegisterHook(LogHook(slog.New(slog.NewJSONHandler(&buf, nil)), "request failed"),
+		HookFilter{ExcludeTypes: []string{"ValidationError"}})
+
+	Report(context.Background(), NewValidationError("Invalid email", "email"))
+	if buf.Len() != 0 {
+		t.Fatalf("excluded error was logged: %s", buf.String())
+	}
+
+	Report(context.Background(), NewProcessingError("failed", "Charge"))
+	if !bytes.Contains(buf.Bytes(), []byte(`"msg":"request failed"`)) {
+		t.Errorf("expected log record, got %s", buf.String())
+	}
+}
@@ -0,0 +1,367 @@
+package errors
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"mime"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// HTTPStatus returns the HTTP status code a server should respond with for
+// err. Returns 200 for a nil error.
+//
+// A mapping for err's code in the installed MappingTable wins, then the
+// first status registered with RegisterHTTPStatus for an error err
+// matches. Otherwise the chain is walked outermost first and the first
+// typed error with a mapping wins:
+//   - HTTPError - its StatusCode
+//   - ValidationError - 400
+//   - RateLimitError - 429
+//   - CircuitBreakerError - 503
+//   - NetworkError - 502
+//   - TimeoutError - 504
+//   - SerializationError - 502 when inbound (see NewDecodeError), else 500
+//   - NotImplementedError - 501
+//   - UnsupportedError - 422
+//   - ConsistencyError - 409 when the read can be retried against the
+//     primary, else 503
+//   - NotFoundError - 404
+//   - ConflictError - 409
+//   - DatabaseError - 503 for a retryable SQLSTATE, else 500
+//   - ProviderError - its HTTPStatus, if known
+//   - ConfigError - 500
+//   - RemoteError - its StatusCode, if the sender recorded one
+//
+// Failing that, sentinels are checked (not found - 404, ErrRateLimited - 429,
+// circuit sentinels - 503, timeouts - 504, ErrNotImplemented - 501,
+// ErrUnsupported - 422), and anything else is 500.
+func HTTPStatus(err error) int {
+	if err == nil {
+		return http.StatusOK
+	}
+	if m, ok := mappingFor(err); ok && m.httpStatus != 0 {
+		return m.httpStatus
+	}
+	if status, ok := registeredHTTPStatus(err); ok {
+		return status
+	}
+
+	status := 0
+	walkChain(err, func(node error, _ int) bool {
+		status = nodeHTTPStatus(node)
+		return status == 0
+	})
+	if status != 0 {
+		return status
+	}
+
+	switch {
+	case IsNotFound(err):
+		return http.StatusNotFound
+	case Is(err, ErrRateLimited):
+		return http.StatusTooManyRequests
+	case Is(err, ErrCircuitOpen), Is(err, ErrCircuitHalfOpen):
+		return http.StatusServiceUnavailable
+	case Is(err, context.DeadlineExceeded), IsTimeout(err), Is(err, ErrNetworkTimeout):
+		return http.StatusGatewayTimeout
+	case Is(err, ErrNotImplemented):
+		return http.StatusNotImplemented
+	case Is(err, ErrUnsupported):
+		return http.StatusUnprocessableEntity
+	}
+	return http.StatusInternalServerError
+}
+
+// statusMapping is a status registered with RegisterHTTPStatus.
+type statusMapping struct {
+	target error
+	status int
+}
+
+var (
+	statusMu       sync.RWMutex
+	statusMappings []statusMapping
+)
+
+// RegisterHTTPStatus makes HTTPStatus, and so WriteError, WriteProblem and
+// ToGRPCStatus, return status for errors matching target with errors.Is,
+// such as a service's own sentinels. Registrations are checked in order,
+// after the installed MappingTable and before the built-in rules.
+//
+// Example:
+//
+//	var ErrQuotaExceeded = errors.New("quota exceeded")
+//
+//	errors.RegisterHTTPStatus(ErrQuotaExceeded, http.StatusPaymentRequired)
+func RegisterHTTPStatus(target error, status int) {
+	statusMu.Lock()
+	defer statusMu.Unlock()
+	statusMappings = append(statusMappings, statusMapping{target: target, status: status})
+}
+
+// ResetHTTPStatuses removes all statuses registered with
+// RegisterHTTPStatus. Intended for tests.
+func ResetHTTPStatuses() {
+	statusMu.Lock()
+	defer statusMu.Unlock()
+	statusMappings = nil
+}
+
+// registeredHTTPStatus returns the first registered status whose target
+// err matches.
+func registeredHTTPStatus(err error) (int, bool) {
+	statusMu.RLock()
+	defer statusMu.RUnlock()
+	for _, m := range statusMappings {
+		if Is(err, m.target) {
+			return m.status, true
+		}
+	}
+	return 0, false
+}
+
+// nodeHTTPStatus returns the status for a single typed error node,
+// or 0 if the node has no mapping of its own.
+func nodeHTTPStatus(err error) int {
+	if isTypedNil(err) {
+		return 0
+	}
+	switch e := err.(type) {
+	case *HTTPError:
+		return e.StatusCode
+	case *ValidationError:
+		return http.StatusBadRequest
+	case *RateLimitError:
+		return http.StatusTooManyRequests
+	case *CircuitBreakerError:
+		return http.StatusServiceUnavailable
+	case *NetworkError:
+		return http.StatusBadGateway
+	case *TimeoutError:
+		return http.StatusGatewayTimeout
+	case *SerializationError:
+		if e.Direction == DirectionInbound {
+			return http.StatusBadGateway
+		}
+		return http.StatusInternalServerError
+	case *NotImplementedError:
+		return http.StatusNotImplemented
+	case *UnsupportedError:
+		return http.StatusUnprocessableEntity
+	case *ConsistencyError:
+		if e.RetryAgainstPrimary {
+			return http.StatusConflict
+		}
+		return http.StatusServiceUnavailable
+	case *NotFoundError:
+		return http.StatusNotFound
+	case *ConflictError:
+		return http.StatusConflict
+	case *DatabaseError:
+		if e.IsRetryable() {
+			return http.StatusServiceUnavailable
+		}
+		return http.StatusInternalServerError
+	case *ProviderError:
+		return e.HTTPStatus
+	case *ConfigError:
+		return http.StatusInternalServerError
+	case *RemoteError:
+		return e.StatusCode
+	}
+	return 0
+}
+
+// Response headers describing retry timing and rate limit windows.
+// The RateLimit-* headers follow the IETF httpapi rate limit headers draft,
+// with RateLimit-Reset given in seconds until the window resets.
+const (
+	HeaderRetryAfter         = "Retry-After"
+	HeaderRateLimitLimit     = "RateLimit-Limit"
+	HeaderRateLimitRemaining = "RateLimit-Remaining"
+	HeaderRateLimitReset     = "RateLimit-Reset"
+)
+
+// RetryAfterHeader sets retry headers on h for err: Retry-After from the
+// longest retry-after hint in the chain (see GetRetryAfter), rounded up to
+// whole seconds, and the RateLimit-* headers when the chain holds a
+// RateLimitError with a known policy. Returns true if any header was set.
+//
+// Example:
+//
+//	errors.RetryAfterHeader(w.Header(), err)
+//	w.WriteHeader(errors.HTTPStatus(err))
+func RetryAfterHeader(h http.Header, err error) bool {
+	set := false
+	if wait, ok := GetRetryAfter(err); ok {
+		h.Set(HeaderRetryAfter, strconv.FormatInt(ceilSeconds(wait), 10))
+		set = true
+	}
+
+	var rateErr *RateLimitError
+	if As(err, &rateErr) && rateErr != nil && rateErr.Limit > 0 {
+		h.Set(HeaderRateLimitLimit, strconv.Itoa(rateErr.Limit))
+		h.Set(HeaderRateLimitRemaining, strconv.Itoa(max(rateErr.Remaining, 0)))
+		if !rateErr.ResetAt.IsZero() {
+			h.Set(HeaderRateLimitReset, strconv.FormatInt(ceilSeconds(max(until(rateErr.ResetAt), 0)), 10))
+		}
+		set = true
+	}
+	return set
+}
+
+// ParseRateLimitPolicy reads Retry-After and RateLimit-* headers from a
+// response into a RateLimitError, for clients that honor server-directed
+// backoff. When Retry-After is absent but RateLimit-Remaining says the
+// window is exhausted, the wait is the time until RateLimit-Reset; without
+// RateLimit-Remaining, exhaustion is unknown and no wait is derived.
+// Returns false if neither Retry-After nor RateLimit-Limit is present.
+//
+// Example:
+//
+//	if rateErr, ok := errors.ParseRateLimitPolicy(resp.Header); ok {
+//	    time.Sleep(backoff.NextDelay(endpoint, rateErr))
+//	}
+func ParseRateLimitPolicy(h http.Header) (*RateLimitError, bool) {
+	retryAfter, hasRetryAfter := parseRetryAfter(h.Get(HeaderRetryAfter))
+	limit, hasLimit := parseHeaderInt(h, HeaderRateLimitLimit)
+	if !hasRetryAfter && !hasLimit {
+		return nil, false
+	}
+
+	rateErr := &RateLimitError{RetryHint: RetryHint{
+		Message:    "rate limited by server",
+		RetryAfter: retryAfter,
+	}}
+
+	if hasLimit {
+		rateErr.Limit = limit
+		remaining, hasRemaining := parseHeaderInt(h, HeaderRateLimitRemaining)
+		rateErr.Remaining = remaining
+		if reset, ok := parseHeaderInt(h, HeaderRateLimitReset); ok {
+			resetIn := time.Duration(reset) * time.Second
+			rateErr.ResetAt = now().Add(resetIn).Truncate(time.Second)
+			if !hasRetryAfter && hasRemaining && remaining == 0 {
+				rateErr.RetryAfter = resetIn
+			}
+		}
+	}
+	captureStack(rateErr)
+	return rateErr, true
+}
+
+// maxProblemBody caps how much of an error response FromHTTPResponse reads.
+const maxProblemBody = 64 << 10
+
+// FromHTTPResponse converts an error response from a dependency into an
+// HTTPError carrying its status. An application/problem+json body supplies
+// the message (detail, else title) and code; a problem body that can't be
+// decoded becomes an inbound SerializationError cause (see NewDecodeError)
+// attributed to the request's host. A 429 carries the server's rate-limit
+// policy (see ParseRateLimitPolicy) as its cause, and a 5xx signalling load
+// shedding (see IsOverloadResponse) is marked Overloaded, with any
+// Retry-After as a RetryableError cause. The provider's request ID, from
+// the first registered request ID header present (see
+// RegisterRequestIDHeader), is kept as UpstreamRequestID, and the request's
+// host as Dependency (see GetDependency). Returns nil for
+// statuses below 400. Up to 64KiB of the body is read; it is not closed.
+//
+// Example:
+//
+//	resp, err := client.Do(req)
+//	if err != nil {
+//	    return errors.NewNetworkError("calling pricing", "GetQuote", errors.WithCause(err))
+//	}
+//	defer resp.Body.Close()
+//	if err := errors.FromHTTPResponse(resp); err != nil {
+//	    return err
+//	}
+func FromHTTPResponse(resp *http.Response) error {
+	if resp == nil || resp.StatusCode < 400 {
+		return nil
+	}
+
+	var (
+		body    []byte
+		readErr error
+	)
+	if resp.Body != nil {
+		body, readErr = io.ReadAll(io.LimitReader(resp.Body, maxProblemBody))
+	}
+
+	httpErr := &HTTPError{StatusCode: resp.StatusCode, Message: http.StatusText(resp.StatusCode)}
+	httpErr.UpstreamRequestID, _ = RequestIDFromHeader(resp.Header)
+	if resp.Request != nil && resp.Request.URL != nil {
+		httpErr.Dependency = resp.Request.URL.Host
+	}
+	if rateErr, ok := ParseRateLimitPolicy(resp.Header); ok && resp.StatusCode == http.StatusTooManyRequests {
+		httpErr.Err = rateErr
+	}
+	if IsOverloadResponse(resp, body) {
+		httpErr.Overloaded = true
+		if retryAfter, ok := parseRetryAfter(resp.Header.Get(HeaderRetryAfter)); ok {
+			httpErr.Err = &RetryableError{RetryHint: RetryHint{Message: "server overloaded", RetryAfter: retryAfter}}
+		}
+	}
+	captureStack(httpErr)
+
+	mediaType, _, _ := mime.ParseMediaType(resp.Header.Get("Content-Type"))
+	if mediaType != ProblemContentType || resp.Body == nil {
+		return httpErr
+	}
+
+	var problem struct {
+		Title  string `json:"title"`
+		Detail string `json:"detail"`
+		Code   string `json:"code"`
+	}
+	err := readErr
+	if err == nil {
+		err = json.Unmarshal(body, &problem)
+	}
+	if err != nil {
+		httpErr.Err = NewDecodeError("decoding problem details", "FromHTTPResponse", "json",
+			WithCause(err), WithDependency(httpErr.Dependency))
+		return httpErr
+	}
+
+	switch {
+	case problem.Detail != "":
+		httpErr.Message = problem.Detail
+	case problem.Title != "":
+		httpErr.Message = problem.Title
+	}
+	httpErr.Code = problem.Code
+	return httpErr
+}
+
+// parseRetryAfter parses a Retry-After value given as delay seconds or an
+// HTTP date.
+func parseRetryAfter(value string) (time.Duration, bool) {
+	if value == "" {
+		return 0, false
+	}
+	if seconds, err := strconv.Atoi(value); err == nil && seconds >= 0 {
+		return time.Duration(seconds) * time.Second, true
+	}
+	if date, err := http.ParseTime(value); err == nil {
+		return max(until(date), 0), true
+	}
+	return 0, false
+}
+
+func parseHeaderInt(h http.Header, key string) (int, bool) {
+	n, err := strconv.Atoi(h.Get(key))
+	if err != nil || n < 0 {
+		return 0, false
+	}
+	return n, true
+}
+
+func ceilSeconds(d time.Duration) int64 {
+	return int64((d + time.Second - 1) / time.Second)
+}
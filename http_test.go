@@ -0,0 +1,347 @@
+package errors
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestRateLimitHeadersRoundTrip tests that rate limit headers written by a server parse back into an equivalent error
+func TestRateLimitHeadersRoundTrip(t *testing.T) {
+	clock := newFakeClock()
+	SetClock(clock)
+	defer SetClock(nil)
+	resetAt := clock.Now().Add(90 * time.Second)
+
+	tests := []struct {
+		name string
+		err  error
+		want RateLimitError
+	}{
+		{
+			name: "full policy",
+			err: NewRateLimitError("Too many requests", "Search", 30*time.Second,
+				WithRateLimitPolicy(100, 0, resetAt)),
+			want: RateLimitError{RetryHint: RetryHint{RetryAfter: 30 * time.Second}, Limit: 100, Remaining: 0, ResetAt: resetAt},
+		},
+		{
+			name: "remaining budget",
+			err: Wrap(NewRateLimitError("Slow down", "Search", 1500*time.Millisecond,
+				WithRateLimitPolicy(100, 12, resetAt)), "calling search"),
+			want: RateLimitError{RetryHint: RetryHint{RetryAfter: 2 * time.Second}, Limit: 100, Remaining: 12, ResetAt: resetAt},
+		},
+		{
+			name: "retry after only",
+			err:  NewRetryableError("Busy", "Search", 5*time.Second),
+			want: RateLimitError{RetryHint: RetryHint{RetryAfter: 5 * time.Second}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			WriteProblem(rec, tt.err)
+
+			got, ok := ParseRateLimitPolicy(rec.Header())
+			if !ok {
+				t.Fatalf("ParseRateLimitPolicy() found no policy in %v", rec.Header())
+			}
+			if got.RetryAfter != tt.want.RetryAfter || got.Limit != tt.want.Limit || got.Remaining != tt.want.Remaining {
+				t.Errorf("got retry_after=%v limit=%d remaining=%d, want %v %d %d",
+					got.RetryAfter, got.Limit, got.Remaining, tt.want.RetryAfter, tt.want.Limit, tt.want.Remaining)
+			}
+			if !got.ResetAt.Equal(tt.want.ResetAt) {
+				t.Errorf("ResetAt = %v, want %v", got.ResetAt, tt.want.ResetAt)
+			}
+			if !IsRetryable(got) || !Is(got, ErrRateLimited) {
+				t.Error("parsed policy should be a retryable rate limit error")
+			}
+		})
+	}
+}
+
+// TestParseRateLimitPolicy tests client-side header parsing
+func TestParseRateLimitPolicy(t *testing.T) {
+	t.Run("exhausted window without retry-after waits for reset", func(t *testing.T) {
+		h := http.Header{}
+		h.Set(HeaderRateLimitLimit, "10")
+		h.Set(HeaderRateLimitRemaining, "0")
+		h.Set(HeaderRateLimitReset, "7")
+
+		got, ok := ParseRateLimitPolicy(h)
+		if !ok || got.RetryAfter != 7*time.Second {
+			t.Errorf("got %v, %v, want 7s wait", got, ok)
+		}
+	})
+
+	t.Run("missing remaining doesn't imply an exhausted window", func(t *testing.T) {
+		h := http.Header{}
+		h.Set(HeaderRateLimitLimit, "10")
+		h.Set(HeaderRateLimitReset, "7")
+
+		got, ok := ParseRateLimitPolicy(h)
+		if !ok || got.RetryAfter != 0 || got.ResetAt.IsZero() {
+			t.Errorf("got %v, %v, want the reset time without a wait", got, ok)
+		}
+	})
+
+	t.Run("http date retry-after", func(t *testing.T) {
+		clock := newFakeClock()
+		SetClock(clock)
+		defer SetClock(nil)
+		h := http.Header{}
+		h.Set(HeaderRetryAfter, clock.Now().Add(time.Minute).UTC().Format(http.TimeFormat))
+
+		got, ok := ParseRateLimitPolicy(h)
+		if !ok || got.RetryAfter != time.Minute {
+			t.Errorf("got %v, %v, want 1m", got, ok)
+		}
+	})
+
+	t.Run("no headers", func(t *testing.T) {
+		if _, ok := ParseRateLimitPolicy(http.Header{}); ok {
+			t.Error("expected no policy")
+		}
+	})
+
+	t.Run("feeds the backoff registry", func(t *testing.T) {
+		h := http.Header{}
+		h.Set(HeaderRetryAfter, "4")
+		rateErr, _ := ParseRateLimitPolicy(h)
+
+		registry := NewBackoffRegistry(testBackoffPolicy, time.Minute)
+		if got := registry.NextDelay("search", rateErr); got != 4*time.Second {
+			t.Errorf("NextDelay() = %v, want 4s", got)
+		}
+	})
+
+	t.Run("no headers for errors without hints", func(t *testing.T) {
+		h := http.Header{}
+		if RetryAfterHeader(h, NewValidationError("Invalid", "q")) || len(h) != 0 {
+			t.Errorf("unexpected headers: %v", h)
+		}
+	})
+}
+
+// TestFromHTTPResponse tests converting dependency error responses, including undecodable ones
+func TestFromHTTPResponse(t *testing.T) {
+	tests := []struct {
+		name        string
+		status      int
+		contentType string
+		body        string
+		wantMessage string
+		wantCode    string
+		wantDecode  bool
+	}{
+		{
+			name:        "problem details",
+			status:      http.StatusConflict,
+			contentType: ProblemContentType + "; charset=utf-8",
+			body:        `{"type":"about:blank","title":"Conflict","status":409,"detail":"Order already paid","code":"ORDER_PAID"}`,
+			wantMessage: "Order already paid",
+			wantCode:    "ORDER_PAID",
+		},
+		{
+			name:        "plain body",
+			status:      http.StatusBadGateway,
+			contentType: "text/html",
+			body:        "<html>bad gateway</html>",
+			wantMessage: "Bad Gateway",
+		},
+		{
+			name:        "malformed problem details",
+			status:      http.StatusInternalServerError,
+			contentType: ProblemContentType,
+			body:        `{"title": "Internal`,
+			wantMessage: "Internal Server Error",
+			wantDecode:  true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+				w.Header().Set("Content-Type", tt.contentType)
+				w.WriteHeader(tt.status)
+				_, _ = w.Write([]byte(tt.body))
+			}))
+			defer server.Close()
+
+			resp, err := http.Get(server.URL)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer resp.Body.Close()
+
+			got := FromHTTPResponse(resp)
+			httpErr, ok := IsHTTPError(got)
+			if !ok {
+				t.Fatalf("FromHTTPResponse() = %v, want an HTTPError", got)
+			}
+			if httpErr.StatusCode != tt.status || httpErr.Message != tt.wantMessage || httpErr.Code != tt.wantCode {
+				t.Errorf("got status=%d message=%q code=%q, want %d %q %q",
+					httpErr.StatusCode, httpErr.Message, httpErr.Code, tt.status, tt.wantMessage, tt.wantCode)
+			}
+
+			var serErr *SerializationError
+			if gotDecode := As(got, &serErr); gotDecode != tt.wantDecode {
+				t.Fatalf("SerializationError in chain = %v, want %v", gotDecode, tt.wantDecode)
+			}
+			if !tt.wantDecode {
+				return
+			}
+			if serErr.Direction != DirectionInbound {
+				t.Errorf("Direction = %q, want %q", serErr.Direction, DirectionInbound)
+			}
+			if dep := GetDependency(got); dep != resp.Request.URL.Host {
+				t.Errorf("GetDependency() = %q, want %q", dep, resp.Request.URL.Host)
+			}
+			if class := MetricLabels(serErr)["error_class"]; class != ClassDependencyFault {
+				t.Errorf("error_class = %q, want %q", class, ClassDependencyFault)
+			}
+			if status := HTTPStatus(serErr); status != http.StatusBadGateway {
+				t.Errorf("HTTPStatus() = %d, want 502", status)
+			}
+		})
+	}
+
+	t.Run("success and rate limits", func(t *testing.T) {
+		if err := FromHTTPResponse(&http.Response{StatusCode: http.StatusOK}); err != nil {
+			t.Errorf("FromHTTPResponse(200) = %v, want nil", err)
+		}
+
+		h := http.Header{}
+		h.Set(HeaderRetryAfter, "3")
+		err := FromHTTPResponse(&http.Response{StatusCode: http.StatusTooManyRequests, Header: h})
+		if wait, ok := GetRetryAfter(err); !ok || wait != 3*time.Second {
+			t.Errorf("GetRetryAfter() = %v, %v, want 3s", wait, ok)
+		}
+	})
+}
+
+// TestEncodeError tests that failing to marshal our own request is reported as our bug
+func TestEncodeError(t *testing.T) {
+	_, cause := json.Marshal(map[string]any{"callback": make(chan int)})
+	if cause == nil {
+		t.Fatal("expected json.Marshal to fail")
+	}
+	err := Wrap(NewEncodeError("encoding charge request", "Charge", "json", WithCause(cause)), "charging card")
+
+	if status := HTTPStatus(err); status != http.StatusInternalServerError {
+		t.Errorf("HTTPStatus() = %d, want 500", status)
+	}
+	if !IsPermanentError(err) || IsRetryable(err) {
+		t.Error("encode errors should be permanent")
+	}
+	if sev := GetSeverity(err); sev != SeverityCritical {
+		t.Errorf("GetSeverity() = %v, want %v", sev, SeverityCritical)
+	}
+	if class := MetricLabels(err)["error_class"]; class != ClassEncodeFailure {
+		t.Errorf("error_class = %q, want %q", class, ClassEncodeFailure)
+	}
+	if dep := GetDependency(err); dep != "" {
+		t.Errorf("GetDependency() = %q, want empty", dep)
+	}
+
+	decoded := Decode(Encode(err))
+	var serErr *SerializationError
+	if !As(decoded, &serErr) || serErr.Direction != DirectionOutbound {
+		t.Errorf("direction lost in envelope round trip: %#v", serErr)
+	}
+}
+
+// TestWriteError tests statuses, safe messages and rate limit headers in plain JSON error responses
+func TestWriteError(t *testing.T) {
+	tests := []struct {
+		name           string
+		err            error
+		wantStatus     int
+		wantType       string
+		wantMessage    string
+		wantRetryAfter string
+	}{
+		{
+			name: "http error", err: NewHTTPError(409, "Order already shipped", nil),
+			wantStatus: 409, wantType: "HTTPError", wantMessage: "Order already shipped",
+		},
+		{
+			name: "validation", err: Wrap(NewValidationError("Invalid email", "email"), "signing up"),
+			wantStatus: 400, wantType: "ValidationError", wantMessage: "Invalid email",
+		},
+		{
+			name: "not found sentinel", err: Wrap(ErrActivityNotFound, "loading activity"),
+			wantStatus: 404, wantType: "NotFoundError", wantMessage: "Not Found",
+		},
+		{
+			name: "rate limit", err: NewRateLimitError("Too many orders", "PlaceOrder", 30*time.Second),
+			wantStatus: 429, wantType: "RateLimitError", wantMessage: "Too many orders", wantRetryAfter: "30",
+		},
+		{
+			name: "timeout", err: NewTimeoutError("pricing too slow", "GetQuote", 2*time.Second),
+			wantStatus: 504, wantType: "TimeoutError", wantMessage: serverErrorMessage,
+		},
+		{
+			name: "server error hides causes", err: NewHTTPError(500, "db password rejected", New("pq: password=hunter2")),
+			wantStatus: 500, wantType: "HTTPError", wantMessage: serverErrorMessage,
+		},
+		{
+			name: "untyped", err: New("boom"),
+			wantStatus: 500, wantType: "Error", wantMessage: serverErrorMessage,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			WriteError(rec, tt.err)
+
+			if rec.Code != tt.wantStatus {
+				t.Errorf("status = %d, want %d", rec.Code, tt.wantStatus)
+			}
+			if got := rec.Header().Get("Content-Type"); got != "application/json" {
+				t.Errorf("Content-Type = %q, want application/json", got)
+			}
+			if got := rec.Header().Get(HeaderRetryAfter); got != tt.wantRetryAfter {
+				t.Errorf("Retry-After = %q, want %q", got, tt.wantRetryAfter)
+			}
+
+			var body ErrorResponse
+			if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+				t.Fatalf("decoding body: %v", err)
+			}
+			if body.Type != tt.wantType || body.Message != tt.wantMessage || body.Reference != ReferenceCode(tt.err) {
+				t.Errorf("body = %+v, want type %q, message %q and the reference", body, tt.wantType, tt.wantMessage)
+			}
+			if tt.wantStatus >= 500 && strings.Contains(rec.Body.String(), "password") {
+				t.Errorf("body %s leaks cause text", rec.Body.String())
+			}
+		})
+	}
+}
+
+// TestRegisterHTTPStatus tests that registered statuses apply after code mappings and before built-in rules
+func TestRegisterHTTPStatus(t *testing.T) {
+	t.Cleanup(ResetHTTPStatuses)
+	t.Cleanup(ResetMappings)
+	errQuota := New("quota exceeded")
+	RegisterHTTPStatus(errQuota, http.StatusPaymentRequired)
+	RegisterHTTPStatus(ErrRateLimited, http.StatusServiceUnavailable)
+
+	if got := HTTPStatus(Wrap(errQuota, "creating project")); got != http.StatusPaymentRequired {
+		t.Errorf("HTTPStatus() = %d, want the registered 402", got)
+	}
+	rec := httptest.NewRecorder()
+	WriteError(rec, NewHTTPError(500, "upstream", ErrRateLimited))
+	if rec.Code != http.StatusServiceUnavailable {
+		t.Errorf("status = %d, want the registered 503 over the typed error's own", rec.Code)
+	}
+
+	SetMappingTable(NewMappingTable(Code("QUOTA").HTTP(http.StatusTooManyRequests)))
+	if got := HTTPStatus(NewProcessingError("over quota", "Create", WithCode("QUOTA"), WithCause(errQuota))); got != http.StatusTooManyRequests {
+		t.Errorf("HTTPStatus() = %d, want the code mapping to win", got)
+	}
+}
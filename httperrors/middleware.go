@@ -0,0 +1,114 @@
+// Package httperrors provides net/http integration for jp-go-errors.
+package httperrors
+
+import (
+	"bufio"
+	"context"
+	"io"
+	"net"
+	"net/http"
+
+	errors "github.com/JohnPlummer/jp-go-errors"
+)
+
+// Middleware tags every request context with errors.TagCallerContext so that
+// cancellations caused by the client going away are reported by
+// errors.IsCallerDisconnect rather than counted as server failures, and
+// installs a warning collector (errors.CollectWarnings) so handlers can
+// report non-fatal issues with errors.WarnCtx. A warning marking a stale
+// value served after a failure (see errors.ServedStale) adds Warning and Age
+// headers to the response, which keeps its 2xx status.
+//
+// When the caller sends an errors.HeaderRetryBudget header, the budget is
+// installed on the request context for Transport and errors.IsSafeToRetry
+// to spend from, and the response reports the retries left in the same
+// header so the caller's budget reflects retries made on its behalf.
+//
+// Handlers still get a writer that implements http.Flusher, http.Hijacker
+// and io.ReaderFrom, so streaming responses and websockets work behind it.
+//
+// Example:
+//
+//	mux := http.NewServeMux()
+//	mux.HandleFunc("/orders", handleOrders)
+//	http.ListenAndServe(":8080", httperrors.Middleware(mux))
+func Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx, cancel := errors.TagCallerContext(r.Context())
+		defer cancel()
+		ctx = errors.CollectWarnings(ctx)
+
+		rw := &responseWriter{ResponseWriter: w, ctx: ctx}
+		if budget, ok := errors.BudgetFromHeader(r.Header); ok {
+			ctx = errors.ContextWithRetryBudget(ctx, budget)
+			rw.budget = budget
+		}
+
+		next.ServeHTTP(rw, r.WithContext(ctx))
+	})
+}
+
+// responseWriter adds the headers Middleware owes the response: the
+// remaining retry budget, when the caller sent one, and stale-data
+// warnings collected on ctx.
+type responseWriter struct {
+	http.ResponseWriter
+	ctx         context.Context
+	budget      *errors.RetryBudget
+	wroteHeader bool
+}
+
+func (w *responseWriter) WriteHeader(statusCode int) {
+	if !w.wroteHeader {
+		w.wroteHeader = true
+		if w.budget != nil {
+			errors.WriteBudgetHeader(w.Header(), w.budget)
+		}
+		for _, warning := range errors.WarningsFromContext(w.ctx) {
+			errors.StaleHeaders(w.Header(), warning)
+		}
+	}
+	w.ResponseWriter.WriteHeader(statusCode)
+}
+
+func (w *responseWriter) Write(p []byte) (int, error) {
+	if !w.wroteHeader {
+		w.WriteHeader(http.StatusOK)
+	}
+	return w.ResponseWriter.Write(p)
+}
+
+// Flush sends the headers Middleware owes, then flushes the underlying
+// writer, so streaming handlers keep working behind Middleware.
+func (w *responseWriter) Flush() {
+	if !w.wroteHeader {
+		w.WriteHeader(http.StatusOK)
+	}
+	if flusher, ok := w.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+// Hijack hands the connection over to the handler, for websockets. The
+// headers Middleware owes aren't sent: the handler writes the response.
+func (w *responseWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	hijacker, ok := w.ResponseWriter.(http.Hijacker)
+	if !ok {
+		return nil, nil, http.ErrNotSupported
+	}
+	return hijacker.Hijack()
+}
+
+// ReadFrom keeps the underlying writer's io.ReaderFrom fast path, such as
+// sendfile, for handlers that copy a file into the response.
+func (w *responseWriter) ReadFrom(r io.Reader) (int64, error) {
+	if !w.wroteHeader {
+		w.WriteHeader(http.StatusOK)
+	}
+	return io.Copy(w.ResponseWriter, r)
+}
+
+// Unwrap exposes the underlying writer to http.ResponseController.
+func (w *responseWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
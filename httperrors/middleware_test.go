@@ -0,0 +1,143 @@
+package httperrors
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	errors "github.com/JohnPlummer/jp-go-errors"
+)
+
+// TestMiddlewareTagsRequestContext tests that client cancellation is reported as a disconnect
+func TestMiddlewareTagsRequestContext(t *testing.T) {
+	clientCtx, disconnect := context.WithCancel(context.Background())
+
+	var cause error
+	handler := Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		disconnect()
+		<-r.Context().Done()
+		cause = context.Cause(r.Context())
+	}))
+
+	req := httptest.NewRequest(http.MethodGet, "/orders", nil).WithContext(clientCtx)
+	handler.ServeHTTP(httptest.NewRecorder(), req)
+
+	if !errors.IsCallerDisconnect(cause) {
+		t.Errorf("expected caller disconnect, got %v", cause)
+	}
+}
+
+// TestMiddlewarePassesThrough tests that normal requests are unaffected
+func TestMiddlewarePassesThrough(t *testing.T) {
+	handler := Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Context().Err() != nil {
+			t.Errorf("context should be live, got %v", r.Context().Err())
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}))
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+
+	if rec.Code != http.StatusNoContent {
+		t.Errorf("got status %d, want 204", rec.Code)
+	}
+}
+
+// TestMiddlewareCollectsWarnings tests that handlers can report warnings on the request context
+func TestMiddlewareCollectsWarnings(t *testing.T) {
+	handler := Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !errors.WarnCtx(r.Context(), errors.NewValidationError("invalid date", "date")) {
+			t.Error("expected a warning collector on the request context")
+		}
+		if n := len(errors.WarningsFromContext(r.Context())); n != 1 {
+			t.Errorf("got %d warnings, want 1", n)
+		}
+	}))
+
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+}
+
+// TestMiddlewareStaleWarning tests that stale values keep their status and gain Warning and Age headers
+func TestMiddlewareStaleWarning(t *testing.T) {
+	handler := Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		errors.WarnCtx(r.Context(), errors.ServedStale(errors.NewHTTPError(503, "Service Unavailable", nil), 42*time.Second))
+		_, _ = w.Write([]byte(`{"price": 10}`))
+	}))
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+
+	if rec.Code != http.StatusOK {
+		t.Errorf("status = %d, want 200", rec.Code)
+	}
+	if rec.Header().Get(errors.HeaderWarning) == "" || rec.Header().Get(errors.HeaderAge) != "42" {
+		t.Errorf("headers = %v, want Warning and Age: 42", rec.Header())
+	}
+}
+
+// TestMiddlewareStreaming tests that Flusher, Hijacker and io.ReaderFrom still work through the middleware
+func TestMiddlewareStreaming(t *testing.T) {
+	t.Run("flush", func(t *testing.T) {
+		handler := Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			errors.WarnCtx(r.Context(), errors.ServedStale(errors.NewTimeoutError("timed out", "Quote", time.Second), time.Minute))
+			flusher, ok := w.(http.Flusher)
+			if !ok {
+				t.Fatal("the writer should implement http.Flusher")
+			}
+			_, _ = io.WriteString(w, "data: 1\n\n")
+			flusher.Flush()
+		}))
+
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/events", nil))
+		if !rec.Flushed {
+			t.Error("Flush() should reach the underlying writer")
+		}
+		if rec.Header().Get(errors.HeaderWarning) == "" {
+			t.Error("the stale warning should be sent before the first flush")
+		}
+	})
+
+	t.Run("hijack", func(t *testing.T) {
+		server := httptest.NewServer(Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			conn, buf, err := w.(http.Hijacker).Hijack()
+			if err != nil {
+				t.Errorf("Hijack() error = %v", err)
+				return
+			}
+			defer conn.Close()
+			_, _ = buf.WriteString("HTTP/1.1 204 No Content\r\nConnection: close\r\n\r\n")
+			_ = buf.Flush()
+		})))
+		defer server.Close()
+
+		resp, err := http.Get(server.URL)
+		if err != nil {
+			t.Fatalf("Get() error = %v", err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusNoContent {
+			t.Errorf("status = %d, want the hijacked connection's 204", resp.StatusCode)
+		}
+	})
+
+	t.Run("read from", func(t *testing.T) {
+		handler := Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if _, ok := w.(io.ReaderFrom); !ok {
+				t.Fatal("the writer should implement io.ReaderFrom")
+			}
+			_, _ = io.Copy(w, strings.NewReader("body"))
+		}))
+
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+		if rec.Body.String() != "body" {
+			t.Errorf("body = %q, want %q", rec.Body.String(), "body")
+		}
+	})
+}
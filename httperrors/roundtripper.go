@@ -0,0 +1,163 @@
+package httperrors
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"time"
+
+	errors "github.com/JohnPlummer/jp-go-errors"
+)
+
+// ErrorRoundTripperOption configures NewErrorRoundTripper.
+type ErrorRoundTripperOption func(*errorRoundTripper)
+
+// WithTimeout bounds each request, including reading the response body, to
+// timeout. A request that runs out of it fails with an *errors.TimeoutError
+// whose Duration is timeout, which is retryable; a deadline set by the
+// caller still fails with its own error (see NewErrorRoundTripper).
+//
+// Example:
+//
+//	rt := httperrors.NewErrorRoundTripper(nil, httperrors.WithTimeout(2*time.Second))
+func WithTimeout(timeout time.Duration) ErrorRoundTripperOption {
+	return func(t *errorRoundTripper) {
+		t.timeout = timeout
+	}
+}
+
+// TreatStatusAsError selects the response statuses, other than 429, that
+// become an *errors.HTTPError (see errors.FromHTTPResponse) instead of a
+// response. Without it every other status is returned as a response.
+//
+// Example:
+//
+//	rt := httperrors.NewErrorRoundTripper(nil,
+//	    httperrors.TreatStatusAsError(func(status int) bool { return status >= 500 }))
+func TreatStatusAsError(treat func(status int) bool) ErrorRoundTripperOption {
+	return func(t *errorRoundTripper) {
+		t.treatStatusAsError = treat
+	}
+}
+
+// NewErrorRoundTripper returns an http.RoundTripper that reports failures
+// with this package's types, keeping the original error as the cause:
+//   - dial, DNS, read and write failures - *errors.NetworkError (see
+//     errors.ClassifyNetError)
+//   - transport timeouts, and requests outlasting WithTimeout -
+//     *errors.TimeoutError
+//   - 429 - *errors.RateLimitError with the server's Retry-After and
+//     RateLimit-* headers (see errors.ParseRateLimitPolicy), caused by an
+//     *errors.HTTPError for the response
+//   - statuses selected by TreatStatusAsError - *errors.HTTPError
+//
+// The request's host is recorded as the Dependency of the errors that
+// carry one (see errors.GetDependency). A response returned as an error
+// has its body read, for problem details, and closed; the body of any
+// other response is left unread. Context errors from the caller's own
+// cancellation or deadline are returned unchanged, so
+// errors.Is(err, context.DeadlineExceeded) still holds for them. next
+// defaults to http.DefaultTransport.
+//
+// Example:
+//
+//	client := &http.Client{Transport: httperrors.NewErrorRoundTripper(nil,
+//	    httperrors.WithTimeout(2*time.Second))}
+//	resp, err := client.Get(url)
+//	var rateErr *errors.RateLimitError
+//	if errors.As(err, &rateErr) {
+//	    time.Sleep(rateErr.RetryAfter)
+//	}
+func NewErrorRoundTripper(next http.RoundTripper, opts ...ErrorRoundTripperOption) http.RoundTripper {
+	if next == nil {
+		next = http.DefaultTransport
+	}
+	t := &errorRoundTripper{next: next}
+	for _, opt := range opts {
+		opt(t)
+	}
+	return t
+}
+
+// errorRoundTripper is the http.RoundTripper returned by
+// NewErrorRoundTripper.
+type errorRoundTripper struct {
+	next               http.RoundTripper
+	timeout            time.Duration
+	treatStatusAsError func(status int) bool
+}
+
+// RoundTrip implements http.RoundTripper.
+func (t *errorRoundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
+	if t.timeout <= 0 {
+		return t.roundTrip(req, req)
+	}
+	ctx, cancel := context.WithTimeout(req.Context(), t.timeout)
+	resp, err := t.roundTrip(req, req.WithContext(ctx))
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+	resp.Body = &cancelBody{ReadCloser: resp.Body, cancel: cancel}
+	return resp, nil
+}
+
+// roundTrip sends out, which is req bounded by the timeout if one is set,
+// and converts its failures.
+func (t *errorRoundTripper) roundTrip(req, out *http.Request) (*http.Response, error) {
+	operation := req.Method + " " + req.URL.Host
+	dependency := errors.WithDependency(req.URL.Host)
+
+	resp, err := t.next.RoundTrip(out)
+	if err != nil {
+		if out.Context().Err() != nil && req.Context().Err() == nil {
+			// The deadline is ours, so the context error it produced
+			// would wrongly read as the caller giving up
+			return nil, errors.NewTimeoutError("request timed out", operation, t.timeout)
+		}
+		return nil, errors.ClassifyNetError(err, operation, dependency)
+	}
+
+	if statusErr := t.statusError(resp, operation, dependency); statusErr != nil {
+		discard(resp)
+		return nil, statusErr
+	}
+	return resp, nil
+}
+
+// statusError returns the error resp's status stands for, or nil if resp
+// is returned as a response.
+func (t *errorRoundTripper) statusError(resp *http.Response, operation string, opts ...errors.Option) error {
+	if id, ok := errors.RequestIDFromHeader(resp.Header); ok {
+		opts = append(opts, errors.WithUpstreamRequestID(id))
+	}
+
+	switch {
+	case resp.StatusCode == http.StatusTooManyRequests:
+		cause := errors.NewHTTPError(resp.StatusCode, http.StatusText(resp.StatusCode), nil, opts...)
+		rateErr, ok := errors.ParseRateLimitPolicy(resp.Header)
+		if !ok {
+			return errors.NewRateLimitError("rate limited by server", operation, 0, errors.WithCause(cause))
+		}
+		rateErr.Operation, rateErr.Err = operation, cause
+		return rateErr
+	case t.treatStatusAsError != nil && t.treatStatusAsError(resp.StatusCode):
+		if err := errors.FromHTTPResponse(resp); err != nil {
+			return err
+		}
+		return errors.NewHTTPError(resp.StatusCode, http.StatusText(resp.StatusCode), nil, opts...)
+	}
+	return nil
+}
+
+// cancelBody releases a response's timeout when its body is closed.
+type cancelBody struct {
+	io.ReadCloser
+	cancel context.CancelFunc
+}
+
+func (b *cancelBody) Close() error {
+	err := b.ReadCloser.Close()
+	b.cancel()
+	return err
+}
@@ -0,0 +1,209 @@
+package httperrors
+
+import (
+	"context"
+	stderrors "errors"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	errors "github.com/JohnPlummer/jp-go-errors"
+)
+
+// TestErrorRoundTripper tests the error each kind of failure becomes
+func TestErrorRoundTripper(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/limited":
+			w.Header().Set(errors.HeaderRetryAfter, "3")
+			w.WriteHeader(http.StatusTooManyRequests)
+		case "/limited-bare":
+			w.WriteHeader(http.StatusTooManyRequests)
+		case "/down":
+			w.Header().Set("Content-Type", errors.ProblemContentType)
+			w.WriteHeader(http.StatusServiceUnavailable)
+			_, _ = io.WriteString(w, `{"title":"Service Unavailable","detail":"pricing is restarting"}`)
+		case "/slow":
+			<-r.Context().Done()
+		default:
+			_, _ = io.WriteString(w, "ok")
+		}
+	}))
+	defer server.Close()
+	host := strings.TrimPrefix(server.URL, "http://")
+
+	closed := httptest.NewServer(http.NotFoundHandler())
+	closedURL := closed.URL
+	closed.Close()
+
+	noSuchHost := &http.Transport{DialContext: func(context.Context, string, string) (net.Conn, error) {
+		return nil, &net.OpError{Op: "dial", Net: "tcp", Err: &net.DNSError{Err: "no such host", Name: "pricing", IsNotFound: true}}
+	}}
+	serverErrors := TreatStatusAsError(func(status int) bool { return status >= 500 })
+
+	tests := []struct {
+		name  string
+		next  http.RoundTripper
+		opts  []ErrorRoundTripperOption
+		url   string
+		check func(t *testing.T, err error)
+	}{
+		{
+			name: "dial failure",
+			url:  closedURL,
+			check: func(t *testing.T, err error) {
+				var netErr *errors.NetworkError
+				if !errors.As(err, &netErr) || netErr.Kind != errors.NetworkKindDial || !netErr.IsTransient {
+					t.Fatalf("got %v, want a transient dial NetworkError", err)
+				}
+				if netErr.Dependency != strings.TrimPrefix(closedURL, "http://") {
+					t.Errorf("Dependency = %q, want the request's host", netErr.Dependency)
+				}
+			},
+		},
+		{
+			name: "DNS failure",
+			next: noSuchHost,
+			url:  server.URL,
+			check: func(t *testing.T, err error) {
+				var netErr *errors.NetworkError
+				if !errors.As(err, &netErr) || netErr.Kind != errors.NetworkKindDNS || netErr.IsTransient {
+					t.Fatalf("got %v, want a persistent dns NetworkError", err)
+				}
+			},
+		},
+		{
+			name: "own timeout",
+			opts: []ErrorRoundTripperOption{WithTimeout(20 * time.Millisecond)},
+			url:  server.URL + "/slow",
+			check: func(t *testing.T, err error) {
+				var timeoutErr *errors.TimeoutError
+				if !errors.As(err, &timeoutErr) || timeoutErr.Duration != 20*time.Millisecond {
+					t.Fatalf("got %v, want a TimeoutError after 20ms", err)
+				}
+				if stderrors.Is(err, context.DeadlineExceeded) || !errors.IsRetryable(err) {
+					t.Error("a timeout of the round tripper's own should be retryable, not a context error")
+				}
+			},
+		},
+		{
+			name: "rate limited",
+			url:  server.URL + "/limited",
+			check: func(t *testing.T, err error) {
+				var rateErr *errors.RateLimitError
+				if !errors.As(err, &rateErr) || rateErr.RetryAfter != 3*time.Second {
+					t.Fatalf("got %v, want a RateLimitError retrying after 3s", err)
+				}
+				if status := errors.GetHTTPStatusCode(err); status != http.StatusTooManyRequests {
+					t.Errorf("GetHTTPStatusCode() = %d, want 429", status)
+				}
+				if dependency := errors.GetDependency(err); dependency != host {
+					t.Errorf("GetDependency() = %q, want %q", dependency, host)
+				}
+			},
+		},
+		{
+			name: "rate limited without headers",
+			url:  server.URL + "/limited-bare",
+			check: func(t *testing.T, err error) {
+				var rateErr *errors.RateLimitError
+				if !errors.As(err, &rateErr) || rateErr.RetryAfter != 0 || !errors.IsRetryable(err) {
+					t.Fatalf("got %v, want a retryable RateLimitError without a wait", err)
+				}
+			},
+		},
+		{
+			name: "status treated as error",
+			opts: []ErrorRoundTripperOption{serverErrors},
+			url:  server.URL + "/down",
+			check: func(t *testing.T, err error) {
+				httpErr, ok := errors.IsHTTPError(err)
+				if !ok || httpErr.StatusCode != http.StatusServiceUnavailable || httpErr.Message != "pricing is restarting" {
+					t.Fatalf("got %v, want an HTTPError 503 with the problem detail", err)
+				}
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := &http.Client{Transport: NewErrorRoundTripper(tt.next, tt.opts...)}
+			resp, err := client.Get(tt.url)
+			if err == nil {
+				resp.Body.Close()
+				t.Fatalf("Get() returned status %d, want an error", resp.StatusCode)
+			}
+			tt.check(t, err)
+		})
+	}
+}
+
+// TestErrorRoundTripperResponses tests that responses which aren't errors are returned unread
+func TestErrorRoundTripperResponses(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/down" {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+		_, _ = io.WriteString(w, "body")
+	}))
+	defer server.Close()
+
+	tests := []struct {
+		name       string
+		opts       []ErrorRoundTripperOption
+		path       string
+		wantStatus int
+	}{
+		{name: "success", path: "/", wantStatus: http.StatusOK},
+		{name: "success with timeout", opts: []ErrorRoundTripperOption{WithTimeout(time.Second)}, path: "/", wantStatus: http.StatusOK},
+		{name: "5xx without TreatStatusAsError", path: "/down", wantStatus: http.StatusServiceUnavailable},
+		{
+			name:       "status not selected",
+			opts:       []ErrorRoundTripperOption{TreatStatusAsError(func(status int) bool { return status == http.StatusBadGateway })},
+			path:       "/down",
+			wantStatus: http.StatusServiceUnavailable,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := &http.Client{Transport: NewErrorRoundTripper(nil, tt.opts...)}
+			resp, err := client.Get(server.URL + tt.path)
+			if err != nil {
+				t.Fatalf("Get() error = %v", err)
+			}
+			defer resp.Body.Close()
+			if resp.StatusCode != tt.wantStatus {
+				t.Errorf("status = %d, want %d", resp.StatusCode, tt.wantStatus)
+			}
+			if body, err := io.ReadAll(resp.Body); err != nil || string(body) != "body" {
+				t.Errorf("body = %q, %v; want it unread by the round tripper", body, err)
+			}
+		})
+	}
+}
+
+// TestErrorRoundTripperCallerDeadline tests that the caller's own deadline still reads as a context error
+func TestErrorRoundTripperCallerDeadline(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-r.Context().Done()
+	}))
+	defer server.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, server.URL, nil)
+	client := &http.Client{Transport: NewErrorRoundTripper(nil, WithTimeout(time.Minute))}
+	_, err := client.Do(req)
+
+	if !stderrors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("Do() error = %v, want context.DeadlineExceeded in the chain", err)
+	}
+	if errors.IsRetryable(err) {
+		t.Error("the caller's deadline shouldn't be retryable")
+	}
+}
@@ -0,0 +1,187 @@
+package httperrors
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"time"
+
+	errors "github.com/JohnPlummer/jp-go-errors"
+	"github.com/JohnPlummer/jp-go-errors/retry"
+)
+
+// Transport is an http.RoundTripper that retries failed requests while
+// errors.IsSafeToRetry allows it. Retries are spent from the retry budget
+// on the request context (see errors.ContextWithRetryBudget), which
+// Middleware installs from the caller's errors.HeaderRetryBudget header;
+// without one, each call starts a budget of MaxAttempts-1 retries. The
+// remaining budget is sent downstream on every attempt, and a downstream
+// service running Middleware reports what it left, so a chain of services
+// retrying each other shares one budget instead of multiplying attempts.
+//
+// Responses with a retryable status (see errors.IsRetryableHTTPStatus) and
+// transport failures are retried; other responses are returned as is.
+// Requests whose body can't be replayed (no GetBody) are never retried.
+// The wait before each retry comes from errors.ExplainRetryPlan, so a
+// server's Retry-After wins over Backoff.
+// When retries stop on a retryable failure, RoundTrip returns a
+// *errors.RetryError, with Reason set when the budget, rather than
+// MaxAttempts, ran out, and History holding every attempt's plan. Each
+// retried attempt's error records its attempt number (see
+// errors.StampAttempt).
+// After a failure carrying an errors.ConsistencyError that can be retried
+// against the primary, later attempts' request contexts are marked with
+// errors.ContextWithPrimaryRead, so Base can route them there.
+//
+// Attempts that can't finish before the request deadline aren't made. The
+// required margin is MinHeadroom, or the headroom recorded on the request
+// context by errors.WithHeadroom if larger. A first attempt without enough
+// headroom fails with errors.RequireHeadroom's TimeoutError. Retrying stops,
+// with errors.RetryStopHeadroom as the StopReason, once the wait before the
+// next attempt wouldn't leave enough.
+//
+// A retry spec (see errors.ParseRetrySpec) on the request context, or Spec
+// without one, overrides MaxAttempts and Backoff where set, narrows which
+// failures are retried to its On patterns, and allows retries only for
+// idempotent methods unless the spec is marked idempotent. A server's
+// Retry-After still wins over the spec's backoff.
+//
+// Example:
+//
+//	client := &http.Client{Transport: &httperrors.Transport{MaxAttempts: 4}}
+type Transport struct {
+	// Base performs each attempt. Defaults to http.DefaultTransport.
+	Base http.RoundTripper
+
+	// MaxAttempts caps the attempts for one call, including the first.
+	// Defaults to 3.
+	MaxAttempts int
+
+	// Backoff sets the delay between attempts. The zero value picks a
+	// preset for each failure with errors.PolicyForClass.
+	Backoff errors.BackoffPolicy
+
+	// MinHeadroom is the least time an attempt needs before the request
+	// deadline. Zero only applies headroom from errors.WithHeadroom.
+	MinHeadroom time.Duration
+
+	// Spec is the retry spec for requests without one on their context
+	// (see errors.ContextWithRetrySpec). Nil applies no spec.
+	Spec *errors.RetrySpec
+}
+
+// RoundTrip implements http.RoundTripper. The retry loop is retry.Retry's,
+// so the two honor the same budget, headroom, spec and primary-read rules.
+func (t *Transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	base := t.Base
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	spec, hasSpec := errors.RetrySpecFromContext(req.Context())
+	if !hasSpec && t.Spec != nil {
+		spec, hasSpec = *t.Spec, true
+	}
+	replayable := req.Body == nil || req.Body == http.NoBody || req.GetBody != nil
+	if hasSpec && !spec.AllowsMethod(req.Method) {
+		replayable = false
+	}
+
+	var (
+		attempt  int
+		lastResp *http.Response
+		lastErr  error
+		failure  error
+	)
+	op := func(ctx context.Context) error {
+		attempt++
+		out, err := attemptRequest(ctx, req, attempt)
+		if err != nil {
+			return err
+		}
+		budget, _ := errors.RetryBudgetFromContext(ctx)
+		errors.WriteBudgetHeader(out.Header, budget)
+
+		lastResp, lastErr = base.RoundTrip(out)
+		if lastResp != nil {
+			if reported, ok := errors.BudgetFromHeader(lastResp.Header); ok {
+				budget.Limit(reported.Remaining())
+			}
+		}
+		if !replayable {
+			return nil
+		}
+		failure = responseError(req, lastResp, lastErr)
+		return failure
+	}
+
+	opts := []retry.RetryOption{
+		retry.WithBackoffPolicy(t.Backoff),
+		retry.WithMinHeadroom(t.MinHeadroom),
+		retry.WithOperation(req.Method + " " + req.URL.Host),
+		retry.WithOnRetry(func(errors.Attempt) {
+			discard(lastResp)
+			lastResp = nil
+		}),
+	}
+	if t.MaxAttempts > 0 {
+		opts = append(opts, retry.WithMaxAttempts(t.MaxAttempts))
+	}
+	if t.Spec != nil {
+		opts = append(opts, retry.WithSpec(*t.Spec))
+	}
+	err := retry.Retry(req.Context(), op, opts...)
+	if err == nil || err == failure {
+		return lastResp, lastErr
+	}
+	discard(lastResp)
+	return nil, err
+}
+
+// attemptRequest returns the request to send for attempt with context ctx,
+// with a fresh body for retries.
+func attemptRequest(ctx context.Context, req *http.Request, attempt int) (*http.Request, error) {
+	out := req.Clone(ctx)
+	if attempt > 1 && req.GetBody != nil {
+		body, err := req.GetBody()
+		if err != nil {
+			return nil, err
+		}
+		out.Body = body
+	}
+	return out, nil
+}
+
+// responseError returns the error describing a failed attempt, or nil if
+// the attempt succeeded or failed in a way that isn't worth retrying.
+func responseError(req *http.Request, resp *http.Response, err error) error {
+	opts := []errors.Option{errors.WithDependency(req.URL.Host)}
+	switch {
+	case err != nil:
+		if errors.IsContextError(err) || req.Context().Err() != nil {
+			return nil
+		}
+		return errors.NewNetworkError(err.Error(), req.Method+" "+req.URL.Host, append(opts, errors.WithCause(err))...)
+	case errors.IsRetryableHTTPStatus(resp.StatusCode):
+		var cause error
+		if rateErr, ok := errors.ParseRateLimitPolicy(resp.Header); ok {
+			cause = rateErr
+		}
+		if errors.IsOverloadResponse(resp, nil) {
+			opts = append(opts, errors.WithOverloaded())
+		}
+		if id, ok := errors.RequestIDFromHeader(resp.Header); ok {
+			opts = append(opts, errors.WithUpstreamRequestID(id))
+		}
+		return errors.NewHTTPError(resp.StatusCode, http.StatusText(resp.StatusCode), cause, opts...)
+	}
+	return nil
+}
+
+// discard drains and closes resp's body so the connection can be reused.
+func discard(resp *http.Response) {
+	if resp == nil {
+		return
+	}
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 4096))
+	_ = resp.Body.Close()
+}
@@ -0,0 +1,350 @@
+package httperrors
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strconv"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	errors "github.com/JohnPlummer/jp-go-errors"
+	"github.com/JohnPlummer/jp-go-errors/errtest"
+)
+
+// clock stands in for the system clock so retry waits don't sleep.
+var clock = errtest.NewFakeClock(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+
+func TestMain(m *testing.M) {
+	errors.SetClock(clock)
+	os.Exit(m.Run())
+}
+
+// TestTransportRetries tests retrying of failed attempts within MaxAttempts
+func TestTransportRetries(t *testing.T) {
+	tests := []struct {
+		name         string
+		statuses     []int
+		wantStatus   int
+		wantAttempts int32
+		wantErr      bool
+	}{
+		{name: "succeeds after retry", statuses: []int{503, 200}, wantStatus: 200, wantAttempts: 2},
+		{name: "non-retryable returned as is", statuses: []int{404}, wantStatus: 404, wantAttempts: 1},
+		{name: "exhausts attempts", statuses: []int{503, 503, 503, 503}, wantAttempts: 3, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var hits atomic.Int32
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				n := hits.Add(1)
+				w.Header().Set(errors.HeaderRequestID, "req-"+strconv.Itoa(int(n)))
+				w.WriteHeader(tt.statuses[n-1])
+			}))
+			defer server.Close()
+
+			client := &http.Client{Transport: &Transport{}}
+			resp, err := client.Get(server.URL)
+
+			if got := hits.Load(); got != tt.wantAttempts {
+				t.Errorf("server saw %d attempts, want %d", got, tt.wantAttempts)
+			}
+			if tt.wantErr {
+				retryErr, ok := asRetryError(err)
+				if !ok {
+					t.Fatalf("expected RetryError, got %v", err)
+				}
+				if retryErr.Reason != "" || retryErr.Attempts != 3 {
+					t.Errorf("got reason %q after %d attempts, want no reason after 3", retryErr.Reason, retryErr.Attempts)
+				}
+				if len(retryErr.History) != 3 {
+					t.Fatalf("History has %d attempts, want 3", len(retryErr.History))
+				}
+				if id := errors.GetUpstreamRequestID(retryErr.LastError); id != "req-3" {
+					t.Errorf("last attempt's upstream request ID = %q, want req-3", id)
+				}
+				for i, attempt := range retryErr.History {
+					wantStop := ""
+					if i == 2 {
+						wantStop = errors.RetryStopMaxAttempts
+					}
+					if attempt.Plan.Source != errors.DelaySourcePolicy || attempt.Plan.StopReason != wantStop {
+						t.Errorf("History[%d].Plan = %v", i, attempt.Plan)
+					}
+					if n, maxAttempts, _ := errors.GetAttempt(attempt.Err); n != i+1 || maxAttempts != 3 {
+						t.Errorf("History[%d].Err is from attempt %d/%d, want %d/3", i, n, maxAttempts, i+1)
+					}
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Get() error = %v", err)
+			}
+			defer resp.Body.Close()
+			if resp.StatusCode != tt.wantStatus {
+				t.Errorf("got status %d, want %d", resp.StatusCode, tt.wantStatus)
+			}
+		})
+	}
+}
+
+// TestRetryBudgetPropagation tests that chained services share one retry budget
+func TestRetryBudgetPropagation(t *testing.T) {
+	var hitsA, hitsB, hitsC atomic.Int32
+	client := &http.Client{Transport: &Transport{MaxAttempts: 4}}
+
+	serverC := httptest.NewServer(Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hitsC.Add(1)
+		w.WriteHeader(http.StatusServiceUnavailable)
+	})))
+	defer serverC.Close()
+
+	// forward calls next with the incoming request's context, as a service
+	// calling its dependencies would.
+	forward := func(hits *atomic.Int32, next string, failure *error) http.Handler {
+		return Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			hits.Add(1)
+			req, _ := http.NewRequestWithContext(r.Context(), http.MethodGet, next, nil)
+			resp, err := client.Do(req)
+			if err != nil {
+				*failure = err
+				w.WriteHeader(http.StatusServiceUnavailable)
+				return
+			}
+			resp.Body.Close()
+			w.WriteHeader(resp.StatusCode)
+		}))
+	}
+
+	var failureA, failureB error
+	serverB := httptest.NewServer(forward(&hitsB, serverC.URL, &failureB))
+	defer serverB.Close()
+	serverA := httptest.NewServer(forward(&hitsA, serverB.URL, &failureA))
+	defer serverA.Close()
+
+	ctx := errors.ContextWithRetryBudget(context.Background(), errors.NewRetryBudget(2))
+	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, serverA.URL, nil)
+	_, err := client.Do(req)
+
+	// Each hop is allowed 4 attempts, so without a shared budget C would
+	// see 4 * 4 * 4 = 64 attempts.
+	if a, b, c := hitsA.Load(), hitsB.Load(), hitsC.Load(); a != 1 || b != 1 || c != 3 {
+		t.Errorf("attempts A=%d B=%d C=%d, want A=1 B=1 C=3", a, b, c)
+	}
+
+	for name, failure := range map[string]error{"A": failureA, "B": failureB} {
+		retryErr, ok := asRetryError(failure)
+		if !ok {
+			t.Errorf("%s: expected RetryError, got %v", name, failure)
+			continue
+		}
+		if retryErr.Reason != errors.RetryReasonBudgetExhaustedUpstream {
+			t.Errorf("%s: reason = %q, want %q", name, retryErr.Reason, errors.RetryReasonBudgetExhaustedUpstream)
+		}
+	}
+
+	retryErr, ok := asRetryError(err)
+	if !ok || retryErr.Reason != errors.RetryReasonBudgetExhausted {
+		t.Errorf("client: expected RetryError with reason %q, got %v", errors.RetryReasonBudgetExhausted, err)
+	}
+}
+
+// TestTransportHonorsRetryAfter tests that the default policy waits exactly as long as a 429 asks
+func TestTransportHonorsRetryAfter(t *testing.T) {
+	var hits atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if hits.Add(1) == 1 {
+			w.Header().Set(errors.HeaderRetryAfter, "2")
+			w.WriteHeader(http.StatusTooManyRequests)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	client := &http.Client{Transport: &Transport{}}
+	start := clock.Now()
+	resp, err := client.Get(server.URL)
+	if err != nil {
+		t.Fatalf("Get() error = %v", err)
+	}
+	defer resp.Body.Close()
+
+	if waited := clock.Since(start); waited != 2*time.Second {
+		t.Errorf("waited %v, want 2s", waited)
+	}
+}
+
+// TestTransportRetrySpec tests that a retry spec overrides the transport's settings but not a server's Retry-After
+func TestTransportRetrySpec(t *testing.T) {
+	constant := errors.MustParseRetrySpec("max=2,backoff=const:5s")
+	tests := []struct {
+		name         string
+		method       string
+		spec         *errors.RetrySpec
+		ctxSpec      string
+		retryAfter   string
+		status       int
+		wantAttempts int32
+		wantWait     time.Duration
+	}{
+		{name: "spec backoff", method: http.MethodGet, spec: &constant, status: 503, wantAttempts: 2, wantWait: 5 * time.Second},
+		{name: "retry-after wins", method: http.MethodGet, spec: &constant, retryAfter: "2", status: 429, wantAttempts: 2, wantWait: 2 * time.Second},
+		{name: "context spec wins", method: http.MethodGet, spec: &constant, ctxSpec: "max=3,backoff=none", status: 503, wantAttempts: 3},
+		{name: "never", method: http.MethodGet, ctxSpec: "never", status: 503, wantAttempts: 1},
+		{name: "status not in on", method: http.MethodGet, ctxSpec: "on=429", status: 503, wantAttempts: 1},
+		{name: "post not retried", method: http.MethodPost, spec: &constant, status: 503, wantAttempts: 1},
+		{name: "post marked idempotent", method: http.MethodPost, ctxSpec: "max=2,idempotent,backoff=none", status: 503, wantAttempts: 2},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var hits atomic.Int32
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				hits.Add(1)
+				if tt.retryAfter != "" {
+					w.Header().Set(errors.HeaderRetryAfter, tt.retryAfter)
+				}
+				w.WriteHeader(tt.status)
+			}))
+			defer server.Close()
+
+			ctx := context.Background()
+			if tt.ctxSpec != "" {
+				ctx = errors.ContextWithRetrySpec(ctx, errors.MustParseRetrySpec(tt.ctxSpec))
+			}
+			req, _ := http.NewRequestWithContext(ctx, tt.method, server.URL, http.NoBody)
+			client := &http.Client{Transport: &Transport{Backoff: errors.BackoffPolicy{Initial: time.Minute}, Spec: tt.spec}}
+			start := clock.Now()
+			resp, err := client.Do(req)
+			if err == nil {
+				resp.Body.Close()
+			}
+
+			if got := hits.Load(); got != tt.wantAttempts {
+				t.Errorf("server saw %d attempts, want %d", got, tt.wantAttempts)
+			}
+			if waited := clock.Since(start); waited != tt.wantWait {
+				t.Errorf("waited %v, want %v", waited, tt.wantWait)
+			}
+		})
+	}
+}
+
+// TestTransportRetriesAgainstPrimary tests that a consistency hint reaches later attempts' contexts
+func TestTransportRetriesAgainstPrimary(t *testing.T) {
+	var primaryReads []bool
+	base := roundTripFunc(func(req *http.Request) (*http.Response, error) {
+		primaryReads = append(primaryReads, errors.PrimaryReadRequested(req.Context()))
+		if len(primaryReads) == 1 {
+			return nil, errors.NewConsistencyError("order/42", "17", "15", time.Second, errors.WithRetryAgainstPrimary())
+		}
+		return &http.Response{StatusCode: http.StatusOK, Body: http.NoBody, Request: req}, nil
+	})
+
+	client := &http.Client{Transport: &Transport{Base: base}}
+	resp, err := client.Get("http://orders.internal/orders/42")
+	if err != nil {
+		t.Fatalf("Get() error = %v", err)
+	}
+	defer resp.Body.Close()
+
+	if len(primaryReads) != 2 || primaryReads[0] || !primaryReads[1] {
+		t.Errorf("primary reads per attempt = %v, want [false true]", primaryReads)
+	}
+}
+
+// TestTransportHeadroom tests that attempts which can't finish before the deadline are skipped
+func TestTransportHeadroom(t *testing.T) {
+	retryAfter := func(req *http.Request) (*http.Response, error) {
+		header := http.Header{errors.HeaderRetryAfter: []string{"2"}}
+		return &http.Response{StatusCode: http.StatusServiceUnavailable, Header: header, Body: http.NoBody, Request: req}, nil
+	}
+
+	tests := []struct {
+		name         string
+		left         time.Duration
+		minHeadroom  time.Duration
+		wantAttempts int
+		wantStop     string
+	}{
+		{name: "first attempt skipped", left: 50 * time.Millisecond, minHeadroom: 100 * time.Millisecond},
+		{name: "retry wait leaves too little", left: 2500 * time.Millisecond, minHeadroom: time.Second, wantAttempts: 1, wantStop: errors.RetryStopHeadroom},
+		{name: "enough for every attempt", left: time.Minute, minHeadroom: time.Second, wantAttempts: 3, wantStop: errors.RetryStopMaxAttempts},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			attempts := 0
+			base := roundTripFunc(func(req *http.Request) (*http.Response, error) {
+				attempts++
+				return retryAfter(req)
+			})
+
+			ctx := deadlineCtx{Context: context.Background(), deadline: clock.Now().Add(tt.left)}
+			req, _ := http.NewRequestWithContext(ctx, http.MethodGet, "http://pricing.internal/quote", nil)
+			_, err := (&Transport{Base: base, MinHeadroom: tt.minHeadroom}).RoundTrip(req)
+
+			if attempts != tt.wantAttempts {
+				t.Errorf("attempts = %d, want %d", attempts, tt.wantAttempts)
+			}
+			if tt.wantAttempts == 0 {
+				if !errors.IsInsufficientHeadroom(err) {
+					t.Errorf("RoundTrip() error = %v, want a headroom error", err)
+				}
+				return
+			}
+			retryErr, ok := asRetryError(err)
+			if !ok {
+				t.Fatalf("RoundTrip() error = %v, want a RetryError", err)
+			}
+			if stop := retryErr.History[len(retryErr.History)-1].Plan.StopReason; stop != tt.wantStop {
+				t.Errorf("StopReason = %q, want %q", stop, tt.wantStop)
+			}
+		})
+	}
+
+	t.Run("WithHeadroom raises MinHeadroom", func(t *testing.T) {
+		attempts := 0
+		base := roundTripFunc(func(req *http.Request) (*http.Response, error) {
+			attempts++
+			return retryAfter(req)
+		})
+		deadline := deadlineCtx{Context: context.Background(), deadline: clock.Now().Add(2500 * time.Millisecond)}
+		ctx, err := errors.WithHeadroom(deadline, time.Second)
+		if err != nil {
+			t.Fatalf("WithHeadroom() error = %v", err)
+		}
+		req, _ := http.NewRequestWithContext(ctx, http.MethodGet, "http://pricing.internal/quote", nil)
+		if _, err := (&Transport{Base: base}).RoundTrip(req); attempts != 1 || err == nil {
+			t.Errorf("RoundTrip() made %d attempts (%v), want 1 before the headroom ran out", attempts, err)
+		}
+	})
+}
+
+// deadlineCtx reports a deadline on the fake clock without expiring in real time
+type deadlineCtx struct {
+	context.Context
+	deadline time.Time
+}
+
+func (c deadlineCtx) Deadline() (time.Time, bool) {
+	return c.deadline, true
+}
+
+// roundTripFunc adapts a function to http.RoundTripper
+type roundTripFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
+
+// asRetryError finds a RetryError in err's chain
+func asRetryError(err error) (*errors.RetryError, bool) {
+	var retryErr *errors.RetryError
+	ok := errors.As(err, &retryErr)
+	return retryErr, ok
+}
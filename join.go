@@ -0,0 +1,112 @@
+package errors
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/cockroachdb/errors"
+)
+
+// AnyRetryable reports whether any error joined in err, at any depth of
+// nesting, is retryable, even when others are permanent, such as to retry
+// the items of a batch that can still succeed. A context error anywhere in
+// err still makes it false. An error without joins is treated as a join of
+// one.
+//
+// Example:
+//
+//	err := fmt.Errorf("%w; %w", chargeErr, emailErr) // charge timed out, email invalid
+//	errors.IsRetryable(err)  // false: the email error is permanent
+//	errors.AnyRetryable(err) // true: the charge can be retried
+func AnyRetryable(err error) bool {
+	if err == nil || errors.Is(err, context.DeadlineExceeded) || errors.Is(err, context.Canceled) {
+		return false
+	}
+	for _, leaf := range joinedLeaves(err) {
+		if IsRetryable(leaf) {
+			return true
+		}
+	}
+	return false
+}
+
+// AllRetryable reports whether every error joined in err, at any depth of
+// nesting, is retryable. An error without joins is treated as a join of
+// one, so AllRetryable agrees with IsRetryable for it.
+func AllRetryable(err error) bool {
+	if err == nil {
+		return false
+	}
+	for _, leaf := range joinedLeaves(err) {
+		if !IsRetryable(leaf) {
+			return false
+		}
+	}
+	return true
+}
+
+// joinedLeaves flattens nested joins in err into the errors they join (see
+// joinedBranches), or returns err alone if it isn't a join.
+func joinedLeaves(err error) []error {
+	branches := joinedBranches(err)
+	if branches == nil {
+		return []error{err}
+	}
+	var leaves []error
+	for _, branch := range branches {
+		leaves = append(leaves, joinedLeaves(branch)...)
+	}
+	return leaves
+}
+
+// joinedPrecedence orders the classes of a join's branches: the highest
+// decides the join's class.
+var joinedPrecedence = map[ErrorClass]int{
+	ClassTransient: 1,
+	ClassPermanent: 2,
+	ClassContext:   3,
+}
+
+// explainJoined classifies each branch of a join and returns the strongest
+// class, so one validation failure among retryable ones makes the join
+// permanent and one canceled context makes it a context error.
+func explainJoined(branches []error, opts []Option) (ErrorClass, string) {
+	class, reason := ClassUnknown, "no joined error has classification information"
+	for i, branch := range branches {
+		c, r := ExplainClassification(branch, opts...)
+		if joinedPrecedence[c] > joinedPrecedence[class] {
+			class, reason = c, fmt.Sprintf("joined error %d of %d: %s", i+1, len(branches), r)
+		}
+	}
+	return class, reason
+}
+
+// joinedBranches returns the errors joined by the first errors.Join, or
+// fmt.Errorf with several %w, in err's chain, or nil if there is none.
+// Only plain wrappers may sit above the join: a typed error, a
+// Permanent/Transient override, a decoded error or anything with an
+// IsRetryable method classifies the whole chain itself, even when it holds
+// several causes.
+func joinedBranches(err error) []error {
+	for err != nil {
+		switch err.(type) {
+		case chainFormatter, *forcedError, Retryable:
+			return nil
+		}
+		if preclassField(err) != nil {
+			return nil
+		}
+		switch e := err.(type) {
+		case interface{ Unwrap() []error }:
+			if branches := e.Unwrap(); len(branches) > 0 {
+				return branches
+			}
+			return nil
+		case interface{ Unwrap() error }:
+			err = e.Unwrap()
+		default:
+			return nil
+		}
+	}
+	return nil
+}
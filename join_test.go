@@ -0,0 +1,133 @@
+package errors
+
+import (
+	"context"
+	stderrors "errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/cockroachdb/errors"
+)
+
+// TestJoinedClassification tests the branch semantics of joined errors, including nested joins
+func TestJoinedClassification(t *testing.T) {
+	timeout := NewTimeoutError("too slow", "Charge", time.Second)
+	rateLimited := NewRateLimitError("slow down", "Quote", time.Second)
+	validation := NewValidationError("Invalid email", "email")
+	unknown := fmt.Errorf("boom")
+
+	tests := []struct {
+		name          string
+		err           error
+		wantClass     ErrorClass
+		wantRetryable bool
+		wantPermanent bool
+		wantAny       bool
+		wantAll       bool
+	}{
+		{
+			name:      "all retryable",
+			err:       stderrors.Join(timeout, rateLimited),
+			wantClass: ClassTransient, wantRetryable: true, wantAny: true, wantAll: true,
+		},
+		{
+			name:      "retryable and validation",
+			err:       stderrors.Join(timeout, validation),
+			wantClass: ClassPermanent, wantPermanent: true, wantAny: true,
+		},
+		{
+			name:      "validation first",
+			err:       stderrors.Join(validation, timeout),
+			wantClass: ClassPermanent, wantPermanent: true, wantAny: true,
+		},
+		{
+			name:      "retryable and unknown",
+			err:       stderrors.Join(unknown, timeout),
+			wantClass: ClassTransient, wantRetryable: true, wantAny: true,
+		},
+		{
+			name:      "retryable and context",
+			err:       stderrors.Join(timeout, Wrap(context.Canceled, "aborted")),
+			wantClass: ClassContext, wantPermanent: true,
+		},
+		{
+			name:      "only unknown",
+			err:       stderrors.Join(unknown, fmt.Errorf("bang")),
+			wantClass: ClassUnknown,
+		},
+		{
+			name:      "nested join with a permanent leaf",
+			err:       stderrors.Join(timeout, stderrors.Join(rateLimited, validation)),
+			wantClass: ClassPermanent, wantPermanent: true, wantAny: true,
+		},
+		{
+			name:      "nested join of retryable leaves",
+			err:       fmt.Errorf("batch: %w", stderrors.Join(timeout, fmt.Errorf("item 2: %w", stderrors.Join(rateLimited, ErrDeadlock)))),
+			wantClass: ClassTransient, wantRetryable: true, wantAny: true, wantAll: true,
+		},
+		{
+			name:      "multiple %w",
+			err:       fmt.Errorf("charge: %w; email: %w", timeout, validation),
+			wantClass: ClassPermanent, wantPermanent: true, wantAny: true,
+		},
+		{
+			name:      "cockroach join",
+			err:       errors.Join(rateLimited, validation),
+			wantClass: ClassPermanent, wantPermanent: true, wantAny: true,
+		},
+		{
+			name:      "forced transient branch still loses to a permanent one",
+			err:       stderrors.Join(Transient(unknown), validation),
+			wantClass: ClassPermanent, wantPermanent: true, wantAny: true,
+		},
+		{
+			name:      "override above the join decides",
+			err:       Transient(stderrors.Join(timeout, validation)),
+			wantClass: ClassTransient, wantRetryable: true, wantAny: true, wantAll: true,
+		},
+		{
+			name:      "typed error above the join decides",
+			err:       NewHTTPError(400, "bad batch", stderrors.Join(timeout, rateLimited)),
+			wantClass: ClassPermanent, wantPermanent: true,
+		},
+		{
+			name:      "not a join",
+			err:       timeout,
+			wantClass: ClassTransient, wantRetryable: true, wantAny: true, wantAll: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Classify(tt.err); got != tt.wantClass {
+				t.Errorf("Classify() = %v, want %v", got, tt.wantClass)
+			}
+			if got := IsRetryable(tt.err); got != tt.wantRetryable {
+				t.Errorf("IsRetryable() = %v, want %v", got, tt.wantRetryable)
+			}
+			if got := IsPermanentError(tt.err); got != tt.wantPermanent {
+				t.Errorf("IsPermanentError() = %v, want %v", got, tt.wantPermanent)
+			}
+			if got := AnyRetryable(tt.err); got != tt.wantAny {
+				t.Errorf("AnyRetryable() = %v, want %v", got, tt.wantAny)
+			}
+			if got := AllRetryable(tt.err); got != tt.wantAll {
+				t.Errorf("AllRetryable() = %v, want %v", got, tt.wantAll)
+			}
+		})
+	}
+
+	if AnyRetryable(nil) || AllRetryable(nil) {
+		t.Error("AnyRetryable() and AllRetryable() should be false for nil")
+	}
+}
+
+// TestExplainJoined tests that the reason names the joined error that decided
+func TestExplainJoined(t *testing.T) {
+	err := stderrors.Join(NewTimeoutError("too slow", "Charge", time.Second), NewValidationError("Invalid email", "email"))
+	class, reason := ExplainClassification(err)
+	if want := "joined error 2 of 2: IsPermanentError reported true"; class != ClassPermanent || reason != want {
+		t.Errorf("ExplainClassification() = %v, %q, want permanent, %q", class, reason, want)
+	}
+}
@@ -0,0 +1,187 @@
+package errors
+
+import "strconv"
+
+// Metric label keys returned by MetricLabels.
+const (
+	LabelErrorClass = "error_class"
+	LabelRetryable  = "retryable"
+	LabelAttempt    = "attempt"
+	LabelOwner      = "owner"
+
+	LabelProvider     = "provider"
+	LabelProviderCode = "provider_code"
+)
+
+// ClassCallerDisconnect is the error_class label value for errors caused by
+// the caller going away. These are split out of ClassContext so they don't
+// pollute error rates.
+const ClassCallerDisconnect = "caller_disconnect"
+
+// ClassStaleServed is the error_class label value for stale values served
+// after a failure (see ServedStale). These are degradations rather than
+// failures, so they are split out of every other class.
+const ClassStaleServed = "stale_served"
+
+// ClassReplicaLag is the error_class label value for reads that hit a
+// lagging replica (see ConsistencyError), split out of ClassTransient so
+// replication lag has its own alert.
+const ClassReplicaLag = "replica_lag"
+
+// ClassOverload is the error_class label value for transient errors from a
+// server shedding load (see IsOverload), split out of ClassTransient so
+// dashboards separate overload from breakage.
+const ClassOverload = "overload"
+
+// error_class label values split out of ClassPermanent for serialization
+// errors with a Direction, so our bugs and our dependencies' bugs alert
+// different people.
+const (
+	// ClassEncodeFailure is for data we failed to encode (NewEncodeError).
+	ClassEncodeFailure = "encode_failure"
+
+	// ClassDependencyFault is for data from a dependency we failed to
+	// decode (NewDecodeError).
+	ClassDependencyFault = "dependency_fault"
+)
+
+// MetricLabels returns low-cardinality labels describing err, suitable for
+// metrics. Values are drawn from small fixed sets and never contain
+// messages, IDs or other free-form text. Errors with a recorded attempt
+// (see WithAttempt) also get an "attempt" label of "first", "middle" or
+// "last", and errors with an owner registered with RegisterComponentOwner
+// (see GetOwner) get an "owner" label; owners set with WithOwner but never
+// registered are left out, keeping the label's values a known set.
+// ProviderErrors add "provider" and "provider_code" labels, whose values
+// are bounded by the set of codes each provider documents.
+// Returns nil for a nil error.
+//
+// Example:
+//
+//	labels := MetricLabels(err)
+//	// labels = map[string]string{
+//	//     "error_class": "transient",
+//	//     "retryable":   "true",
+//	// }
+func MetricLabels(err error) map[string]string {
+	if err == nil {
+		return nil
+	}
+
+	class := string(Classify(err))
+	_, stale := IsServedStale(err)
+	switch {
+	case stale:
+		class = ClassStaleServed
+	case IsCallerDisconnect(err):
+		class = ClassCallerDisconnect
+	case isConsistencyError(err):
+		class = ClassReplicaLag
+	case class == string(ClassTransient) && IsOverload(err):
+		class = ClassOverload
+	case class != string(ClassPermanent):
+	case serializationDirection(err) == DirectionOutbound:
+		class = ClassEncodeFailure
+	case serializationDirection(err) == DirectionInbound:
+		class = ClassDependencyFault
+	}
+
+	labels := map[string]string{
+		LabelErrorClass: class,
+		LabelRetryable:  strconv.FormatBool(IsRetryable(err)),
+	}
+	if n, maxAttempts, ok := GetAttempt(err); ok {
+		labels[LabelAttempt] = attemptBucket(n, maxAttempts)
+	}
+	if owner := GetOwner(err); owner != "" && isRegisteredOwner(owner) {
+		labels[LabelOwner] = owner
+	}
+	if providerErr, ok := IsProviderError(err); ok {
+		labels[LabelProvider] = providerErr.Provider
+		if providerErr.Code != "" {
+			labels[LabelProviderCode] = providerErr.Code
+		}
+	}
+	return labels
+}
+
+// ErrorLabelSet.Type values. The set is closed: every error maps to one of
+// them, so a metric labelled with Type has at most this many series.
+const (
+	LabelTypeHTTP           = "http"
+	LabelTypeValidation     = "validation"
+	LabelTypeTimeout        = "timeout"
+	LabelTypeRateLimit      = "rate_limit"
+	LabelTypeNetwork        = "network"
+	LabelTypeCircuitBreaker = "circuit_breaker"
+	LabelTypeProcessing     = "processing"
+	LabelTypeRetryExhausted = "retry_exhausted"
+	LabelTypeContext        = "context"
+	LabelTypeOther          = "other"
+)
+
+// statusClassLabels are the ErrorLabelSet.StatusClass values, indexed by the
+// status's hundreds digit.
+var statusClassLabels = [...]string{"", "1xx", "2xx", "3xx", "4xx", "5xx"}
+
+// ErrorLabelSet is a fixed set of low-cardinality labels for an error, for
+// metrics middleware that wants typed fields instead of MetricLabels' map.
+type ErrorLabelSet struct {
+	Type        string // one of the LabelType values
+	Retryable   bool
+	StatusClass string // "4xx", "5xx" and so on for errors with an HTTP status (see GetHTTPStatusCode); otherwise ""
+	Component   string // see GetComponent
+}
+
+// ErrorLabels returns err's ErrorLabelSet. Type comes from the outermost
+// typed error in the chain, so wrapping doesn't change it, and is
+// LabelTypeContext for any error caused by a cancelled or expired context,
+// as in Classify. It never contains messages or IDs. Returns the zero
+// ErrorLabelSet for a nil error.
+//
+// Example:
+//
+//	labels := errors.ErrorLabels(errors.Wrap(errors.NewHTTPError(503, "down", nil), "calling billing"))
+//	// labels = errors.ErrorLabelSet{Type: "http", Retryable: true, StatusClass: "5xx"}
+//	requests.WithLabelValues(labels.Type, labels.StatusClass).Inc()
+func ErrorLabels(err error) ErrorLabelSet {
+	if err == nil {
+		return ErrorLabelSet{}
+	}
+
+	labels := ErrorLabelSet{
+		Type:      labelType(err),
+		Retryable: IsRetryable(err),
+		Component: GetComponent(err),
+	}
+	if status := GetHTTPStatusCode(err); status >= 100 && status < 600 {
+		labels.StatusClass = statusClassLabels[status/100]
+	}
+	return labels
+}
+
+// labelType maps err to its ErrorLabelSet.Type.
+func labelType(err error) string {
+	if IsContextError(err) {
+		return LabelTypeContext
+	}
+	switch outermostTyped(err).(type) {
+	case *HTTPError:
+		return LabelTypeHTTP
+	case *ValidationError:
+		return LabelTypeValidation
+	case *TimeoutError:
+		return LabelTypeTimeout
+	case *RateLimitError:
+		return LabelTypeRateLimit
+	case *NetworkError:
+		return LabelTypeNetwork
+	case *CircuitBreakerError:
+		return LabelTypeCircuitBreaker
+	case *ProcessingError:
+		return LabelTypeProcessing
+	case *RetryError:
+		return LabelTypeRetryExhausted
+	}
+	return LabelTypeOther
+}
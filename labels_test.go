@@ -0,0 +1,63 @@
+package errors
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+)
+
+// TestErrorLabels tests the labels of wrapped, context and untyped errors
+func TestErrorLabels(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want ErrorLabelSet
+	}{
+		{name: "nil", err: nil, want: ErrorLabelSet{}},
+		{
+			name: "wrapped http", err: Wrap(NewHTTPError(503, "down", nil, WithComponent("billing")), "calling billing"),
+			want: ErrorLabelSet{Type: LabelTypeHTTP, Retryable: true, StatusClass: "5xx", Component: "billing"},
+		},
+		{name: "http 404", err: NewHTTPError(404, "missing", nil), want: ErrorLabelSet{Type: LabelTypeHTTP, StatusClass: "4xx"}},
+		{name: "not found", err: NewNotFoundError("order", "42"), want: ErrorLabelSet{Type: LabelTypeOther, StatusClass: "4xx"}},
+		{name: "validation", err: NewValidationError("bad", "email"), want: ErrorLabelSet{Type: LabelTypeValidation}},
+		{name: "rate limit", err: NewRateLimitError("slow", "Quote", time.Second), want: ErrorLabelSet{Type: LabelTypeRateLimit, Retryable: true}},
+		{name: "retry exhausted", err: NewRetryError(3, 3, fmt.Errorf("down"), nil), want: ErrorLabelSet{Type: LabelTypeRetryExhausted}},
+		{name: "deadline", err: NewTimeoutError("slow", "Quote", time.Second, WithCause(context.DeadlineExceeded)), want: ErrorLabelSet{Type: LabelTypeContext}},
+		{name: "canceled", err: fmt.Errorf("op: %w", context.Canceled), want: ErrorLabelSet{Type: LabelTypeContext}},
+		{name: "untyped", err: fmt.Errorf("order 42 for user@example.com failed"), want: ErrorLabelSet{Type: LabelTypeOther}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ErrorLabels(tt.err); got != tt.want {
+				t.Errorf("ErrorLabels() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+// TestErrorLabelsBounded tests that Type and StatusClass stay within their fixed sets for every type
+func TestErrorLabelsBounded(t *testing.T) {
+	types := map[string]bool{
+		LabelTypeHTTP: true, LabelTypeValidation: true, LabelTypeTimeout: true, LabelTypeRateLimit: true,
+		LabelTypeNetwork: true, LabelTypeCircuitBreaker: true, LabelTypeProcessing: true,
+		LabelTypeRetryExhausted: true, LabelTypeContext: true, LabelTypeOther: true,
+	}
+	classes := map[string]bool{"": true, "1xx": true, "2xx": true, "3xx": true, "4xx": true, "5xx": true}
+
+	errs := []error{fmt.Errorf("free-form text"), NewHTTPError(999, "odd", nil), NewHTTPError(-1, "odd", nil)}
+	for _, typ := range formatTypes {
+		errs = append(errs, typ.new(fmt.Errorf("cause 42"), []Option{WithComponent("billing"), WithOperation("Charge")}))
+	}
+	for _, err := range errs {
+		labels := ErrorLabels(err)
+		if !types[labels.Type] {
+			t.Errorf("ErrorLabels(%q).Type = %q, outside the fixed set", err, labels.Type)
+		}
+		if !classes[labels.StatusClass] {
+			t.Errorf("ErrorLabels(%q).StatusClass = %q, outside the fixed set", err, labels.StatusClass)
+		}
+	}
+}
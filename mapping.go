@@ -0,0 +1,411 @@
+package errors
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sort"
+	"sync"
+
+	"github.com/cockroachdb/errors"
+)
+
+// GRPCCode mirrors google.golang.org/grpc/codes.Code without the
+// dependency. Values are identical, so convert with codes.Code(c).
+type GRPCCode uint32
+
+// gRPC status codes, matching google.golang.org/grpc/codes.
+const (
+	GRPCOK                 GRPCCode = 0
+	GRPCCanceled           GRPCCode = 1
+	GRPCUnknown            GRPCCode = 2
+	GRPCInvalidArgument    GRPCCode = 3
+	GRPCDeadlineExceeded   GRPCCode = 4
+	GRPCNotFound           GRPCCode = 5
+	GRPCAlreadyExists      GRPCCode = 6
+	GRPCPermissionDenied   GRPCCode = 7
+	GRPCResourceExhausted  GRPCCode = 8
+	GRPCFailedPrecondition GRPCCode = 9
+	GRPCAborted            GRPCCode = 10
+	GRPCOutOfRange         GRPCCode = 11
+	GRPCUnimplemented      GRPCCode = 12
+	GRPCInternal           GRPCCode = 13
+	GRPCUnavailable        GRPCCode = 14
+	GRPCDataLoss           GRPCCode = 15
+	GRPCUnauthenticated    GRPCCode = 16
+)
+
+var grpcCodeNames = [...]string{
+	"OK", "Canceled", "Unknown", "InvalidArgument", "DeadlineExceeded", "NotFound",
+	"AlreadyExists", "PermissionDenied", "ResourceExhausted", "FailedPrecondition",
+	"Aborted", "OutOfRange", "Unimplemented", "Internal", "Unavailable", "DataLoss",
+	"Unauthenticated",
+}
+
+// String returns the code's name as grpc/codes spells it, such as
+// "NotFound", or "Code(17)" for codes it doesn't define.
+func (c GRPCCode) String() string {
+	if int(c) < len(grpcCodeNames) {
+		return grpcCodeNames[c]
+	}
+	return fmt.Sprintf("Code(%d)", uint32(c))
+}
+
+// Process exit codes returned by ExitCode when no mapping applies. The
+// non-generic ones follow BSD sysexits.h.
+const (
+	ExitOK       = 0
+	ExitFailure  = 1
+	ExitDataErr  = 65 // EX_DATAERR: invalid input
+	ExitTempFail = 75 // EX_TEMPFAIL: temporary failure, retry later
+)
+
+// CodeMapping declares how one error code maps onto transports. Build it
+// with Code and the chained setters; unset transports fall back to the
+// built-in heuristics.
+type CodeMapping struct {
+	code       string
+	httpStatus int
+	grpcCode   *GRPCCode
+	exitCode   *int
+	retryable  *bool
+}
+
+// Code starts a mapping for the given error code (see WithCode).
+//
+// Example:
+//
+//	errors.Code("ORDERS_NOT_FOUND").HTTP(404).GRPC(errors.GRPCNotFound).Exit(3).Retryable(false)
+func Code(code string) *CodeMapping {
+	return &CodeMapping{code: code}
+}
+
+// HTTP sets the HTTP status returned by HTTPStatus for the code.
+func (m *CodeMapping) HTTP(status int) *CodeMapping {
+	m.httpStatus = status
+	return m
+}
+
+// GRPC sets the gRPC code returned by ToGRPCStatus for the code.
+func (m *CodeMapping) GRPC(code GRPCCode) *CodeMapping {
+	m.grpcCode = &code
+	return m
+}
+
+// Exit sets the process exit code returned by ExitCode for the code.
+func (m *CodeMapping) Exit(code int) *CodeMapping {
+	m.exitCode = &code
+	return m
+}
+
+// Retryable declares whether errors with the code are retryable. IsRetryable
+// honors it after context errors and Permanent/Transient overrides.
+func (m *CodeMapping) Retryable(retryable bool) *CodeMapping {
+	m.retryable = &retryable
+	return m
+}
+
+// String renders the mapping in the same fluent form used to build it.
+func (m *CodeMapping) String() string {
+	s := fmt.Sprintf("Code(%q)", m.code)
+	if m.httpStatus != 0 {
+		s += fmt.Sprintf(".HTTP(%d)", m.httpStatus)
+	}
+	if m.grpcCode != nil {
+		s += fmt.Sprintf(".GRPC(%d)", *m.grpcCode)
+	}
+	if m.exitCode != nil {
+		s += fmt.Sprintf(".Exit(%d)", *m.exitCode)
+	}
+	if m.retryable != nil {
+		s += fmt.Sprintf(".Retryable(%t)", *m.retryable)
+	}
+	return s
+}
+
+// MappingTable is the data-driven definition of transport mappings for
+// error codes, meant to live in one reviewable place and be installed at
+// startup with SetMappingTable. HTTPStatus, ToGRPCStatus, ExitCode and
+// IsRetryable consult the installed table before their built-in rules.
+//
+// Example:
+//
+//	table := errors.NewMappingTable(
+//	    errors.Code("ORDERS_NOT_FOUND").HTTP(404).GRPC(errors.GRPCNotFound).Exit(3).Retryable(false),
+//	    errors.Code("ORDERS_LOCKED").HTTP(409).GRPC(errors.GRPCAborted).Retryable(true),
+//	)
+//	if err := table.Verify(); err != nil {
+//	    log.Fatal(err)
+//	}
+//	errors.SetMappingTable(table)
+type MappingTable struct {
+	mappings  map[string]*CodeMapping
+	conflicts []*CodeMapping
+}
+
+// NewMappingTable creates a table holding mappings.
+func NewMappingTable(mappings ...*CodeMapping) *MappingTable {
+	t := &MappingTable{mappings: make(map[string]*CodeMapping)}
+	return t.Add(mappings...)
+}
+
+// Add registers more mappings. Registering a code twice with different
+// mappings is a conflict reported by Verify; the first registration is
+// kept.
+func (t *MappingTable) Add(mappings ...*CodeMapping) *MappingTable {
+	for _, m := range mappings {
+		existing, ok := t.mappings[m.code]
+		if !ok {
+			t.mappings[m.code] = m
+			continue
+		}
+		if existing.String() != m.String() {
+			t.conflicts = append(t.conflicts, m)
+		}
+	}
+	return t
+}
+
+// Lookup returns the mapping for code.
+func (t *MappingTable) Lookup(code string) (*CodeMapping, bool) {
+	m, ok := t.mappings[code]
+	return m, ok
+}
+
+// Verify reports problems with the table, suitable for a startup check or
+// a test: conflicting registrations, codes registered with RegisterCodes
+// but missing from the table or mapped to no transport, out-of-range
+// values, and suspicious combinations such as a 4xx status marked
+// retryable. Returns nil when the table is sound; otherwise each problem is
+// a ValidationError whose Field is the code.
+func (t *MappingTable) Verify() error {
+	var problems []error
+	report := func(code, format string, args ...any) {
+		problems = append(problems, NewValidationError(fmt.Sprintf(format, args...), code))
+	}
+
+	for _, conflict := range t.conflicts {
+		report(conflict.code, "%s conflicts with %s", conflict, t.mappings[conflict.code])
+	}
+
+	for _, code := range RegisteredCodes() {
+		if _, ok := t.mappings[code]; !ok {
+			report(code, "registered code has no mapping")
+		}
+	}
+
+	codes := make([]string, 0, len(t.mappings))
+	for code := range t.mappings {
+		codes = append(codes, code)
+	}
+	sort.Strings(codes)
+
+	for _, code := range codes {
+		m := t.mappings[code]
+		switch {
+		case code == "":
+			report(code, "mapping has an empty code")
+		case m.httpStatus == 0 && m.grpcCode == nil && m.exitCode == nil:
+			report(code, "code is not mapped to any transport")
+		}
+		if m.httpStatus != 0 && (m.httpStatus < 400 || m.httpStatus > 599) {
+			report(code, "HTTP status %d is not an error status", m.httpStatus)
+		}
+		if m.grpcCode != nil && (*m.grpcCode == GRPCOK || *m.grpcCode > GRPCUnauthenticated) {
+			report(code, "gRPC code %d is not an error code", *m.grpcCode)
+		}
+		if m.exitCode != nil && (*m.exitCode <= 0 || *m.exitCode > 255) {
+			report(code, "exit code %d is not a failure exit code", *m.exitCode)
+		}
+		if m.retryable != nil && *m.retryable && isClientStatus(m.httpStatus) {
+			report(code, "HTTP %d is a client error but the code is marked retryable", m.httpStatus)
+		}
+	}
+
+	return errors.Join(problems...)
+}
+
+// isClientStatus reports whether status is a 4xx that retrying can't fix.
+// 408, 425 and 429 are excluded: they invite the client to try again.
+func isClientStatus(status int) bool {
+	switch status {
+	case http.StatusRequestTimeout, http.StatusTooEarly, http.StatusTooManyRequests:
+		return false
+	}
+	return status >= 400 && status < 500
+}
+
+var (
+	mappingMu       sync.RWMutex
+	activeMappings  *MappingTable
+	registeredCodes = make(map[string]struct{})
+)
+
+// SetMappingTable installs t as the table consulted by HTTPStatus,
+// ToGRPCStatus, ExitCode and IsRetryable. Pass nil to remove it.
+func SetMappingTable(t *MappingTable) {
+	mappingMu.Lock()
+	defer mappingMu.Unlock()
+	activeMappings = t
+}
+
+// RegisterCodes declares error codes the service uses, so the installed
+// table's Verify can report ones left unmapped.
+func RegisterCodes(codes ...string) {
+	mappingMu.Lock()
+	defer mappingMu.Unlock()
+	for _, code := range codes {
+		registeredCodes[code] = struct{}{}
+	}
+}
+
+// RegisteredCodes returns the codes declared with RegisterCodes, sorted.
+func RegisteredCodes() []string {
+	mappingMu.RLock()
+	defer mappingMu.RUnlock()
+
+	codes := make([]string, 0, len(registeredCodes))
+	for code := range registeredCodes {
+		codes = append(codes, code)
+	}
+	sort.Strings(codes)
+	return codes
+}
+
+// catalogEntry is one code in the catalog built by codeCatalog.
+type catalogEntry struct {
+	code    string
+	mapping *CodeMapping // nil if the installed table doesn't map the code
+}
+
+// codeCatalog returns every code known to the package, registered with
+// RegisterCodes or mapped by the installed table, sorted by code.
+func codeCatalog() []catalogEntry {
+	mappingMu.RLock()
+	defer mappingMu.RUnlock()
+
+	known := make(map[string]*CodeMapping, len(registeredCodes))
+	for code := range registeredCodes {
+		known[code] = nil
+	}
+	if activeMappings != nil {
+		for code, m := range activeMappings.mappings {
+			known[code] = m
+		}
+	}
+
+	catalog := make([]catalogEntry, 0, len(known))
+	for code, m := range known {
+		catalog = append(catalog, catalogEntry{code: code, mapping: m})
+	}
+	sort.Slice(catalog, func(i, j int) bool { return catalog[i].code < catalog[j].code })
+	return catalog
+}
+
+// ResetMappings removes the installed table and all registered codes.
+// Intended for tests.
+func ResetMappings() {
+	mappingMu.Lock()
+	defer mappingMu.Unlock()
+	activeMappings = nil
+	registeredCodes = make(map[string]struct{})
+}
+
+// mappingFor returns the installed table's mapping for err's code.
+func mappingFor(err error) (*CodeMapping, bool) {
+	mappingMu.RLock()
+	table := activeMappings
+	mappingMu.RUnlock()
+
+	if table == nil {
+		return nil, false
+	}
+	code := GetCode(err)
+	if code == "" {
+		return nil, false
+	}
+	return table.Lookup(code)
+}
+
+// ToGRPCStatus returns the gRPC status code for err. Returns GRPCOK for a
+// nil error.
+//
+// A mapping for err's code in the installed MappingTable wins. Otherwise
+// context errors map to Canceled and DeadlineExceeded, and everything else
+// is derived from HTTPStatus (400 - InvalidArgument, 404 - NotFound,
+// 429 - ResourceExhausted, 503 - Unavailable, 504 - DeadlineExceeded, ...),
+// with unmapped statuses becoming Internal.
+func ToGRPCStatus(err error) GRPCCode {
+	if err == nil {
+		return GRPCOK
+	}
+	if m, ok := mappingFor(err); ok && m.grpcCode != nil {
+		return *m.grpcCode
+	}
+
+	switch {
+	case errors.Is(err, context.Canceled):
+		return GRPCCanceled
+	case errors.Is(err, context.DeadlineExceeded):
+		return GRPCDeadlineExceeded
+	}
+	if _, ok := IsConsistencyError(err); ok {
+		return GRPCFailedPrecondition
+	}
+
+	switch status := HTTPStatus(err); status {
+	case http.StatusBadRequest, http.StatusUnprocessableEntity:
+		return GRPCInvalidArgument
+	case http.StatusUnauthorized:
+		return GRPCUnauthenticated
+	case http.StatusForbidden:
+		return GRPCPermissionDenied
+	case http.StatusNotFound:
+		return GRPCNotFound
+	case http.StatusConflict:
+		return GRPCAborted
+	case http.StatusPreconditionFailed:
+		return GRPCFailedPrecondition
+	case http.StatusTooManyRequests:
+		return GRPCResourceExhausted
+	case http.StatusRequestTimeout, http.StatusGatewayTimeout:
+		return GRPCDeadlineExceeded
+	case http.StatusNotImplemented:
+		return GRPCUnimplemented
+	case http.StatusBadGateway, http.StatusServiceUnavailable:
+		return GRPCUnavailable
+	default:
+		return GRPCInternal
+	}
+}
+
+// ExitCode returns the process exit code for err, for CLIs and batch jobs.
+// Returns ExitOK for a nil error.
+//
+// A mapping for err's code in the installed MappingTable wins. Otherwise
+// retryable errors return ExitTempFail, validation errors ExitDataErr, and
+// everything else ExitFailure.
+//
+// Example:
+//
+//	if err := run(ctx); err != nil {
+//	    log.Print(err)
+//	    os.Exit(errors.ExitCode(err))
+//	}
+func ExitCode(err error) int {
+	if err == nil {
+		return ExitOK
+	}
+	if m, ok := mappingFor(err); ok && m.exitCode != nil {
+		return *m.exitCode
+	}
+
+	switch {
+	case IsRetryable(err):
+		return ExitTempFail
+	case IsValidation(err):
+		return ExitDataErr
+	default:
+		return ExitFailure
+	}
+}
@@ -0,0 +1,165 @@
+package errors
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+// TestMappingTableVerify tests detection of conflicting, unmapped and suspicious mappings
+func TestMappingTableVerify(t *testing.T) {
+	tests := []struct {
+		name       string
+		registered []string
+		mappings   []*CodeMapping
+		wantErrs   []string
+	}{
+		{
+			name:       "sound table",
+			registered: []string{"ORDERS_NOT_FOUND"},
+			mappings: []*CodeMapping{
+				Code("ORDERS_NOT_FOUND").HTTP(404).GRPC(GRPCNotFound).Exit(3).Retryable(false),
+				Code("ORDERS_THROTTLED").HTTP(429).Retryable(true),
+			},
+		},
+		{
+			name:     "client error marked retryable",
+			mappings: []*CodeMapping{Code("ORDERS_INVALID").HTTP(422).Retryable(true)},
+			wantErrs: []string{"HTTP 422 is a client error but the code is marked retryable"},
+		},
+		{
+			name: "conflicting registrations",
+			mappings: []*CodeMapping{
+				Code("ORDERS_LOCKED").HTTP(409),
+				Code("ORDERS_LOCKED").HTTP(423),
+				Code("ORDERS_LOCKED").HTTP(409),
+			},
+			wantErrs: []string{`Code("ORDERS_LOCKED").HTTP(423) conflicts with Code("ORDERS_LOCKED").HTTP(409)`},
+		},
+		{
+			name:       "registered code without mapping",
+			registered: []string{"ORDERS_MISSING"},
+			wantErrs:   []string{"'ORDERS_MISSING'", "registered code has no mapping"},
+		},
+		{
+			name:     "no transport",
+			mappings: []*CodeMapping{Code("ORDERS_BARE").Retryable(false)},
+			wantErrs: []string{"not mapped to any transport"},
+		},
+		{
+			name: "out of range values",
+			mappings: []*CodeMapping{
+				Code("BAD_HTTP").HTTP(200),
+				Code("BAD_GRPC").GRPC(GRPCOK),
+				Code("BAD_EXIT").Exit(0),
+			},
+			wantErrs: []string{"HTTP status 200", "gRPC code 0", "exit code 0"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Cleanup(ResetMappings)
+			RegisterCodes(tt.registered...)
+
+			err := NewMappingTable(tt.mappings...).Verify()
+			if len(tt.wantErrs) == 0 {
+				if err != nil {
+					t.Errorf("Verify() error = %v, want nil", err)
+				}
+				return
+			}
+			if err == nil {
+				t.Fatal("Verify() = nil, want problems")
+			}
+			for _, want := range tt.wantErrs {
+				if !strings.Contains(err.Error(), want) {
+					t.Errorf("Verify() = %q, want it to mention %q", err, want)
+				}
+			}
+			if !IsValidation(err) {
+				t.Errorf("Verify() problems should be ValidationErrors, got %T", err)
+			}
+		})
+	}
+}
+
+// TestMappingTableLookup tests that installed mappings take precedence over built-in rules
+func TestMappingTableLookup(t *testing.T) {
+	t.Cleanup(ResetMappings)
+	SetMappingTable(NewMappingTable(
+		Code("ORDERS_NOT_FOUND").HTTP(410).GRPC(GRPCFailedPrecondition).Exit(3).Retryable(false),
+		Code("ORDERS_LOCKED").Retryable(true),
+	))
+
+	notFound := Wrap(NewHTTPError(404, "Order not found", nil, WithCode("ORDERS_NOT_FOUND")), "loading")
+	if got := HTTPStatus(notFound); got != 410 {
+		t.Errorf("HTTPStatus() = %d, want 410", got)
+	}
+	if got := ToGRPCStatus(notFound); got != GRPCFailedPrecondition {
+		t.Errorf("ToGRPCStatus() = %d, want %d", got, GRPCFailedPrecondition)
+	}
+	if got := ExitCode(notFound); got != 3 {
+		t.Errorf("ExitCode() = %d, want 3", got)
+	}
+
+	locked := NewValidationError("locked", "order_id", WithCode("ORDERS_LOCKED"))
+	if !IsRetryable(locked) {
+		t.Error("IsRetryable() = false, want true from the mapping")
+	}
+	if got := HTTPStatus(locked); got != 400 {
+		t.Errorf("HTTPStatus() = %d, want built-in 400 for an unset transport", got)
+	}
+	if IsRetryable(Permanent(locked)) || IsRetryable(NewProcessingError("x", "y", WithCode("ORDERS_LOCKED"), WithCause(context.Canceled))) {
+		t.Error("Permanent and context errors should win over the mapping")
+	}
+}
+
+// TestBuiltinTransportMappings tests the heuristics used without a mapping
+func TestBuiltinTransportMappings(t *testing.T) {
+	tests := []struct {
+		name     string
+		err      error
+		wantGRPC GRPCCode
+		wantExit int
+	}{
+		{"nil", nil, GRPCOK, ExitOK},
+		{"validation", NewValidationError("bad", "email"), GRPCInvalidArgument, ExitDataErr},
+		{"rate limit", NewRateLimitError("slow down", "Search", 0), GRPCResourceExhausted, ExitTempFail},
+		{"timeout", NewTimeoutError("slow", "Fetch", 0), GRPCDeadlineExceeded, ExitTempFail},
+		{"not found", Wrap(ErrActivityNotFound, "loading"), GRPCNotFound, ExitFailure},
+		{"circuit open", NewCircuitBreakerError("open", "Call", "open"), GRPCUnavailable, ExitFailure},
+		{"canceled", fmt.Errorf("stopping: %w", context.Canceled), GRPCCanceled, ExitFailure},
+		{"http forbidden", NewHTTPError(403, "Forbidden", nil), GRPCPermissionDenied, ExitFailure},
+		{"unknown", fmt.Errorf("boom"), GRPCInternal, ExitFailure},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ToGRPCStatus(tt.err); got != tt.wantGRPC {
+				t.Errorf("ToGRPCStatus() = %d, want %d", got, tt.wantGRPC)
+			}
+			if got := ExitCode(tt.err); got != tt.wantExit {
+				t.Errorf("ExitCode() = %d, want %d", got, tt.wantExit)
+			}
+		})
+	}
+}
+
+// TestGetCode tests code lookup through the chain and transports
+func TestGetCode(t *testing.T) {
+	inner := NewProcessingError("failed", "Run", WithCause(NewHTTPError(404, "missing", nil, WithCode("INNER"))))
+	if got := GetCode(inner); got != "INNER" {
+		t.Errorf("GetCode() = %q, want INNER", got)
+	}
+
+	outer := NewHTTPError(500, "outer", inner, WithCode("OUTER"))
+	decoded, _ := UnmarshalError(CompactJSON(outer))
+	if got := GetCode(decoded); got != "OUTER" {
+		t.Errorf("GetCode() after transport = %q, want OUTER", got)
+	}
+	if got := GetCode(fmt.Errorf("plain")); got != "" {
+		t.Errorf("GetCode() = %q, want empty", got)
+	}
+}
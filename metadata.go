@@ -0,0 +1,77 @@
+package errors
+
+// metadataError attaches metadata to an error that isn't one of this
+// package's typed errors, such as the result of Wrap.
+type metadataError struct {
+	cause    error
+	metadata map[string]any
+
+	// spanRecorded is set when WrapCtx recorded the error on a span.
+	spanRecorded bool
+}
+
+func (e *metadataError) Error() string {
+	return e.cause.Error()
+}
+
+func (e *metadataError) Unwrap() error {
+	return e.cause
+}
+
+// setMetadata stores key/value on a typed error's Metadata field.
+// Returns false if err has no Metadata field.
+func setMetadata(err any, key string, value any) bool {
+	field := metadataField(err)
+	if field == nil {
+		return false
+	}
+	if *field == nil {
+		*field = make(map[string]any)
+	}
+	(*field)[key] = value
+	return true
+}
+
+// GetMetadata returns the value stored under key, searching err's chain
+// outermost first so the most recent annotation wins.
+//
+// Example:
+//
+//	err := NewProcessingError("failed", "Process", WithMetadata("tenant", "acme"))
+//	tenant, ok := GetMetadata(Wrap(err, "outer"), "tenant") // "acme", true
+func GetMetadata(err error, key string) (any, bool) {
+	var (
+		value any
+		found bool
+	)
+	walkChain(err, func(node error, _ int) bool {
+		if field := metadataField(node); field != nil {
+			value, found = (*field)[key]
+		}
+		return !found
+	})
+	return value, found
+}
+
+// allMetadata merges the metadata of every error in err's chain, with
+// outer errors winning over inner ones for the same key. Returns nil if the
+// chain carries no metadata.
+func allMetadata(err error) map[string]any {
+	var merged map[string]any
+	walkChain(err, func(node error, _ int) bool {
+		field := metadataField(node)
+		if field == nil {
+			return true
+		}
+		for k, v := range *field {
+			if merged == nil {
+				merged = make(map[string]any)
+			}
+			if _, exists := merged[k]; !exists {
+				merged[k] = v
+			}
+		}
+		return true
+	})
+	return merged
+}
@@ -0,0 +1,169 @@
+package errors
+
+import (
+	"container/list"
+	"sync"
+	"time"
+)
+
+// negativeCachedError marks an error served from a NegativeCache rather
+// than returned by a fresh call. It is transparent: message, errors.Is,
+// errors.As and classification are those of the cached error.
+type negativeCachedError struct {
+	err      error
+	cachedAt time.Time
+}
+
+func (e *negativeCachedError) Error() string {
+	return e.err.Error()
+}
+
+func (e *negativeCachedError) Unwrap() error {
+	return e.err
+}
+
+// NegativeCache remembers permanent failures by key, such as a 404 for an
+// order ID or a validation failure for a request, so repeated lookups are
+// answered without calling the dependency again. Only errors Classify
+// judges ClassPermanent are stored: a retryable or transient failure must
+// reach the dependency next time. Errors are stored compacted (see
+// Compact), without stacks, and each Get returns a fresh copy marked as
+// cache-served (see IsNegativeCached). Entries expire after the TTL and
+// the least recently used are evicted beyond the entry limit. Safe for
+// concurrent use.
+type NegativeCache struct {
+	ttl        time.Duration
+	maxEntries int
+	clock      Clock
+
+	mu      sync.Mutex
+	entries map[string]*list.Element
+	order   *list.List // front = most recently used
+}
+
+type negativeEntry struct {
+	key      string
+	stored   []byte // CompactJSON of the error
+	cachedAt time.Time
+}
+
+// NewNegativeCache creates a NegativeCache keeping failures for ttl, with
+// at most maxEntries keys (unbounded when maxEntries <= 0).
+// Supports the WithClock option.
+//
+// Example:
+//
+//	notFound := errors.NewNegativeCache(time.Minute, 10000)
+//
+//	if err, ok := notFound.Get(orderID); ok {
+//	    return nil, err
+//	}
+//	order, err := orders.Get(ctx, orderID)
+//	if err != nil {
+//	    notFound.Put(orderID, err) // kept only if permanent
+//	}
+func NewNegativeCache(ttl time.Duration, maxEntries int, opts ...Option) *NegativeCache {
+	c := &NegativeCache{
+		ttl:        ttl,
+		maxEntries: maxEntries,
+		clock:      packageClock{},
+		entries:    make(map[string]*list.Element),
+		order:      list.New(),
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
+}
+
+// Put caches err for key if it is a permanent failure, replacing any
+// earlier entry. Nil, retryable, transient, context and unclassified
+// errors are not stored. An already cache-served error is stored again
+// with its original age.
+func (c *NegativeCache) Put(key string, err error) {
+	if err == nil || Classify(err) != ClassPermanent || IsRetryable(err) {
+		return
+	}
+
+	now := c.clock.Now()
+	cachedAt := now
+	var served *negativeCachedError
+	if As(err, &served) {
+		cachedAt = served.cachedAt
+		err = served.err
+	}
+	entry := &negativeEntry{key: key, stored: CompactJSON(err), cachedAt: cachedAt}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if elem, ok := c.entries[key]; ok {
+		elem.Value = entry
+		c.order.MoveToFront(elem)
+		return
+	}
+	c.entries[key] = c.order.PushFront(entry)
+	for c.maxEntries > 0 && len(c.entries) > c.maxEntries {
+		c.remove(c.order.Back())
+	}
+}
+
+// Get returns a copy of the failure cached for key, marked as cache-served,
+// and true, or nil and false when nothing unexpired is cached.
+func (c *NegativeCache) Get(key string) (error, bool) {
+	c.mu.Lock()
+	elem, ok := c.entries[key]
+	if !ok {
+		c.mu.Unlock()
+		return nil, false
+	}
+	entry := elem.Value.(*negativeEntry)
+	if c.ttl > 0 && c.clock.Now().Sub(entry.cachedAt) >= c.ttl {
+		c.remove(elem)
+		c.mu.Unlock()
+		return nil, false
+	}
+	c.order.MoveToFront(elem)
+	c.mu.Unlock()
+
+	// Entries hold envelope JSON produced by CompactJSON, so it decodes.
+	err, _ := UnmarshalError(entry.stored)
+	return &negativeCachedError{err: err, cachedAt: entry.cachedAt}, true
+}
+
+// Delete forgets the failure cached for key, such as after the resource
+// it was missing is created.
+func (c *NegativeCache) Delete(key string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if elem, ok := c.entries[key]; ok {
+		c.remove(elem)
+	}
+}
+
+// Len returns how many keys are cached, including any that expired but
+// haven't been looked up since.
+func (c *NegativeCache) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return len(c.entries)
+}
+
+// remove drops elem. Must be called with c.mu held.
+func (c *NegativeCache) remove(elem *list.Element) {
+	c.order.Remove(elem)
+	delete(c.entries, elem.Value.(*negativeEntry).key)
+}
+
+// IsNegativeCached reports whether err was served from a NegativeCache
+// rather than returned by a fresh call, such as to skip logging a failure
+// already reported when it was cached.
+//
+// Example:
+//
+//	if err != nil && !errors.IsNegativeCached(err) {
+//	    errors.LogError(ctx, logger, "loading order", err)
+//	}
+func IsNegativeCached(err error) bool {
+	var served *negativeCachedError
+	return As(err, &served)
+}
@@ -0,0 +1,114 @@
+package errors
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+)
+
+// TestNegativeCacheClassification tests that only permanent failures are cached
+func TestNegativeCacheClassification(t *testing.T) {
+	tests := []struct {
+		name   string
+		err    error
+		cached bool
+	}{
+		{"not found", NewNotFoundError("order", "42"), true},
+		{"validation", NewValidationError("invalid", "email"), true},
+		{"http 404", NewHTTPError(404, "Not Found", nil), true},
+		{"forced permanent", Permanent(NewNetworkError("bad host", "Dial")), true},
+		{"wrapped not found", fmt.Errorf("loading: %w", NewNotFoundError("order", "42")), true},
+		{"nil", nil, false},
+		{"http 503", NewHTTPError(503, "Service Unavailable", nil), false},
+		{"rate limited", NewRateLimitError("slow down", "Get", time.Second), false},
+		{"network", NewNetworkError("connection reset", "Get"), false},
+		{"context", NewNotFoundError("order", "42", WithCause(context.Canceled)), false},
+		{"forced transient", Transient(NewValidationError("locked", "id")), false},
+		{"unclassified", New("something odd"), false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cache := NewNegativeCache(time.Minute, 10)
+			cache.Put("key", tt.err)
+			got, ok := cache.Get("key")
+			if ok != tt.cached {
+				t.Fatalf("Get() ok = %v, want %v", ok, tt.cached)
+			}
+			if !ok {
+				return
+			}
+			if !IsNegativeCached(got) || IsNegativeCached(tt.err) {
+				t.Error("only the cache-served copy should be marked")
+			}
+			if got.Error() != tt.err.Error() || Classify(got) != ClassPermanent || HTTPStatus(got) != HTTPStatus(tt.err) {
+				t.Errorf("Get() = %v, want the same permanent failure as %v", got, tt.err)
+			}
+			if HasStackTrace(got) {
+				t.Error("cached errors should be compacted without stacks")
+			}
+		})
+	}
+}
+
+// TestNegativeCacheCopies tests that each Get returns an independent copy
+func TestNegativeCacheCopies(t *testing.T) {
+	cache := NewNegativeCache(time.Minute, 10)
+	cache.Put("order-42", NewNotFoundError("order", "42", WithMetadata("tenant", "acme")))
+
+	first, _ := cache.Get("order-42")
+	var notFoundErr *NotFoundError
+	if !As(first, &notFoundErr) {
+		t.Fatalf("Get() = %T, want a NotFoundError in the chain", first)
+	}
+	notFoundErr.Metadata["tenant"] = "globex"
+
+	second, _ := cache.Get("order-42")
+	if got, _ := GetMetadata(second, "tenant"); got != "acme" {
+		t.Errorf("metadata = %v, want the cached value unaffected by changes to another copy", got)
+	}
+	if !Is(second, &NotFoundError{Resource: "order", ID: "42"}) {
+		t.Error("errors.Is should see the cached NotFoundError")
+	}
+}
+
+// TestNegativeCacheExpiry tests TTL expiry, deletion and LRU bounding
+func TestNegativeCacheExpiry(t *testing.T) {
+	clock := newFakeClock()
+	cache := NewNegativeCache(time.Minute, 2, WithClock(clock))
+	notFound := func(id string) error { return NewNotFoundError("order", id) }
+
+	cache.Put("a", notFound("a"))
+	clock.Advance(59 * time.Second)
+	if _, ok := cache.Get("a"); !ok {
+		t.Error("entry should be served before the TTL")
+	}
+	clock.Advance(time.Second)
+	if _, ok := cache.Get("a"); ok || cache.Len() != 0 {
+		t.Error("entry should expire at the TTL")
+	}
+
+	// Re-putting a served error keeps its original age.
+	cache.Put("a", notFound("a"))
+	clock.Advance(30 * time.Second)
+	served, _ := cache.Get("a")
+	cache.Put("a", served)
+	clock.Advance(30 * time.Second)
+	if _, ok := cache.Get("a"); ok {
+		t.Error("re-cached error should expire with its original age")
+	}
+
+	cache.Put("a", notFound("a"))
+	cache.Put("b", notFound("b"))
+	cache.Get("a")
+	cache.Put("c", notFound("c"))
+	if _, ok := cache.Get("b"); ok || cache.Len() != 2 {
+		t.Error("least recently used entry should be evicted beyond maxEntries")
+	}
+
+	cache.Delete("a")
+	if _, ok := cache.Get("a"); ok {
+		t.Error("deleted entry should not be served")
+	}
+}
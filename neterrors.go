@@ -0,0 +1,193 @@
+package errors
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"io"
+	"net"
+	"syscall"
+
+	"github.com/cockroachdb/errors"
+)
+
+// ClassifyNetError converts a failure from the net, syscall or crypto/tls
+// packages into a typed error, keeping the original as its cause: a
+// TimeoutError for a timeout, otherwise a NetworkError whose Kind names
+// the stage that failed and whose IsTransient follows IsRetryable's rules
+// for the original (see explainNetRetryable). TLS handshake failures are
+// always persistent. Errors that aren't network failures, context errors
+// and errors already carrying a type from this package are returned
+// unchanged, as is nil. opts are applied to the error built.
+//
+// A *url.Error from net/http is looked through, so the error returned by
+// http.Client.Do can be passed as is.
+//
+// Example:
+//
+//	resp, err := client.Do(req)
+//	if err != nil {
+//	    return errors.ClassifyNetError(err, "FetchQuote")
+//	    // "network error in FetchQuote (dial, transient): connection failed: Get ...: dial tcp ...: connection refused"
+//	}
+func ClassifyNetError(err error, operation string, opts ...Option) error {
+	if err == nil || hasTypedError(err) || IsContextError(err) {
+		return err
+	}
+
+	var stdNetErr net.Error
+	if errors.As(err, &stdNetErr) && stdNetErr.Timeout() {
+		return NewTimeoutError("operation timed out", operation, 0, append(opts, WithCause(err))...)
+	}
+
+	kind, message, ok := netKind(err)
+	if !ok {
+		return err
+	}
+	transient, _, _ := explainNetRetryable(err)
+	if kind == NetworkKindTLS {
+		transient = false
+	}
+	return NewNetworkError(message, operation, append(opts,
+		WithNetworkKind(kind), WithTransient(transient), WithCause(err))...)
+}
+
+// netKind returns the stage at which a network failure happened and a
+// message describing it, reporting ok=false when err isn't a network
+// failure. An empty kind means the stage isn't known.
+func netKind(err error) (kind NetworkKind, message string, ok bool) {
+	if isTLSFailure(err) {
+		return NetworkKindTLS, "TLS handshake failed", true
+	}
+
+	var dnsErr *net.DNSError
+	if errors.As(err, &dnsErr) {
+		return NetworkKindDNS, "DNS lookup failed", true
+	}
+
+	var opErr *net.OpError
+	if errors.As(err, &opErr) {
+		switch opErr.Op {
+		case "dial":
+			return NetworkKindDial, "connection failed", true
+		case "read":
+			return NetworkKindRead, "read failed", true
+		case "write":
+			return NetworkKindWrite, "write failed", true
+		case "remote error":
+			// crypto/tls reports an alert from the peer this way
+			return NetworkKindTLS, "TLS handshake failed", true
+		}
+		return "", "network operation failed", true
+	}
+
+	switch {
+	case errors.Is(err, syscall.ECONNREFUSED):
+		return NetworkKindDial, "connection failed", true
+	case errors.Is(err, syscall.ECONNRESET), errors.Is(err, io.ErrUnexpectedEOF):
+		return NetworkKindRead, "read failed", true
+	case errors.Is(err, syscall.EPIPE):
+		return NetworkKindWrite, "write failed", true
+	}
+	return "", "", false
+}
+
+// retryableNetErrors are the low-level failures IsRetryable treats as
+// transient: the connection was refused, reset or cut off mid-response.
+// Each carries the reason ExplainRetryable reports.
+var retryableNetErrors = []struct {
+	err    error
+	reason string
+}{
+	{syscall.ECONNRESET, "syscall.ECONNRESET in chain"},
+	{syscall.ECONNREFUSED, "syscall.ECONNREFUSED in chain"},
+	{syscall.EPIPE, "syscall.EPIPE in chain"},
+	{io.ErrUnexpectedEOF, "io.ErrUnexpectedEOF in chain"},
+}
+
+// explainNetRetryable decides IsRetryable for errors from the net, syscall
+// and crypto/tls packages that carry no typed error of this package. It
+// reports ok=false when the chain holds none it recognizes.
+//
+// Certificate verification failures and names that don't exist win over
+// everything else, since a refused handshake is often wrapped in a
+// *net.OpError.
+func explainNetRetryable(err error) (retryable bool, reason string, ok bool) {
+	if reason, ok := netPermanentReason(err); ok {
+		return false, reason, true
+	}
+
+	var dnsErr *net.DNSError
+	if errors.As(err, &dnsErr) {
+		switch {
+		case dnsErr.IsTimeout:
+			return true, "DNSError timeout", true
+		case dnsErr.IsTemporary:
+			return true, "DNSError temporary failure", true
+		}
+	}
+
+	for _, netErr := range retryableNetErrors {
+		if errors.Is(err, netErr.err) {
+			return true, netErr.reason, true
+		}
+	}
+
+	// A failed dial, read or write; the errno checks above name the common
+	// causes
+	var opErr *net.OpError
+	if errors.As(err, &opErr) {
+		return true, "net.OpError in chain", true
+	}
+
+	// Other net.Error implementations, such as *url.Error, only say
+	// whether they timed out
+	var stdNetErr net.Error
+	if errors.As(err, &stdNetErr) && stdNetErr.Timeout() {
+		return true, "net.Error timeout", true
+	}
+
+	return false, "", false
+}
+
+// netPermanentReason reports whether err is a network failure that a retry
+// can't fix: a TLS certificate that failed verification, or a DNS name that
+// doesn't exist (NXDOMAIN).
+func netPermanentReason(err error) (string, bool) {
+	if isCertVerificationError(err) {
+		return "TLS certificate verification failed", true
+	}
+
+	var dnsErr *net.DNSError
+	if errors.As(err, &dnsErr) && dnsErr.IsNotFound {
+		return "DNSError name not found", true
+	}
+	return "", false
+}
+
+// isCertVerificationError reports whether err's chain holds a TLS
+// certificate that failed verification.
+func isCertVerificationError(err error) bool {
+	var (
+		verifyErr    *tls.CertificateVerificationError
+		authorityErr x509.UnknownAuthorityError
+		invalidErr   x509.CertificateInvalidError
+		hostnameErr  x509.HostnameError
+	)
+	return errors.As(err, &verifyErr) ||
+		errors.As(err, &authorityErr) ||
+		errors.As(err, &invalidErr) ||
+		errors.As(err, &hostnameErr)
+}
+
+// isTLSFailure reports whether err's chain holds a failed TLS handshake: a
+// certificate that didn't verify, an alert from the peer or a peer that
+// doesn't speak TLS.
+func isTLSFailure(err error) bool {
+	var (
+		alertErr  tls.AlertError
+		recordErr tls.RecordHeaderError
+	)
+	return isCertVerificationError(err) ||
+		errors.As(err, &alertErr) ||
+		errors.As(err, &recordErr)
+}
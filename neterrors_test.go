@@ -0,0 +1,198 @@
+package errors
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	stderrors "errors"
+	"fmt"
+	"io"
+	"net"
+	"net/url"
+	"os"
+	"syscall"
+	"testing"
+	"time"
+)
+
+// TestNetErrorClassification tests IsRetryable, IsTransientError and IsPermanentError on standard library network failures
+func TestNetErrorClassification(t *testing.T) {
+	refused := &net.OpError{Op: "dial", Net: "tcp", Err: os.NewSyscallError("connect", syscall.ECONNREFUSED)}
+	certErr := &tls.CertificateVerificationError{Err: x509.UnknownAuthorityError{}}
+
+	tests := []struct {
+		name       string
+		err        error
+		wantClass  ErrorClass
+		wantReason string
+	}{
+		{name: "ECONNRESET", err: fmt.Errorf("reading body: %w", syscall.ECONNRESET), wantClass: ClassTransient, wantReason: "syscall.ECONNRESET in chain"},
+		{name: "ECONNREFUSED", err: syscall.ECONNREFUSED, wantClass: ClassTransient, wantReason: "syscall.ECONNREFUSED in chain"},
+		{name: "EPIPE", err: os.NewSyscallError("write", syscall.EPIPE), wantClass: ClassTransient, wantReason: "syscall.EPIPE in chain"},
+		{name: "unexpected EOF", err: fmt.Errorf("decoding: %w", io.ErrUnexpectedEOF), wantClass: ClassTransient, wantReason: "io.ErrUnexpectedEOF in chain"},
+		{name: "wrapped OpError refused", err: Wrap(refused, "calling billing"), wantClass: ClassTransient, wantReason: "syscall.ECONNREFUSED in chain"},
+		{name: "url error refused", err: &url.Error{Op: "Get", URL: "http://billing", Err: refused}, wantClass: ClassTransient, wantReason: "syscall.ECONNREFUSED in chain"},
+		{name: "OpError other cause", err: &net.OpError{Op: "dial", Net: "tcp", Err: syscall.EHOSTUNREACH}, wantClass: ClassTransient, wantReason: "net.OpError in chain"},
+		{name: "DNS temporary", err: &net.DNSError{Err: "server misbehaving", Name: "billing", IsTemporary: true}, wantClass: ClassTransient, wantReason: "DNSError temporary failure"},
+		{name: "DNS timeout", err: &net.DNSError{Err: "i/o timeout", Name: "billing", IsTimeout: true}, wantClass: ClassTransient, wantReason: "DNSError timeout"},
+		{name: "NXDOMAIN", err: &net.DNSError{Err: "no such host", Name: "billing", IsNotFound: true}, wantClass: ClassPermanent, wantReason: "DNSError name not found"},
+		{name: "NXDOMAIN in OpError", err: &net.OpError{Op: "dial", Net: "tcp", Err: &net.DNSError{Err: "no such host", Name: "billing", IsNotFound: true}}, wantClass: ClassPermanent, wantReason: "DNSError name not found"},
+		{name: "TLS verification", err: &url.Error{Op: "Get", URL: "https://billing", Err: certErr}, wantClass: ClassPermanent, wantReason: "TLS certificate verification failed"},
+		{name: "TLS in OpError", err: &net.OpError{Op: "remote error", Net: "tcp", Err: certErr}, wantClass: ClassPermanent, wantReason: "TLS certificate verification failed"},
+		{name: "hostname mismatch", err: fmt.Errorf("handshake: %w", x509.HostnameError{Host: "billing"}), wantClass: ClassPermanent, wantReason: "TLS certificate verification failed"},
+		{name: "url error timeout", err: &url.Error{Op: "Get", URL: "http://billing", Err: &timeoutNetError{}}, wantClass: ClassTransient, wantReason: "net.Error timeout"},
+		{name: "url error other", err: &url.Error{Op: "Get", URL: "http://billing", Err: stderrors.New("unsupported protocol scheme")}, wantClass: ClassUnknown, wantReason: "no retryable rule matched"},
+		{name: "typed error decides", err: NewValidationError("bad", "host", WithCause(syscall.ECONNRESET)), wantClass: ClassPermanent, wantReason: "ValidationError.IsRetryable() reported false"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Classify(tt.err); got != tt.wantClass {
+				t.Errorf("Classify() = %v, want %v", got, tt.wantClass)
+			}
+			if got, want := IsRetryable(tt.err), tt.wantClass == ClassTransient; got != want {
+				t.Errorf("IsRetryable() = %v, want %v", got, want)
+			}
+			if got, want := IsTransientError(tt.err), tt.wantClass == ClassTransient; got != want {
+				t.Errorf("IsTransientError() = %v, want %v", got, want)
+			}
+			if got, want := IsPermanentError(tt.err), tt.wantClass == ClassPermanent; got != want {
+				t.Errorf("IsPermanentError() = %v, want %v", got, want)
+			}
+			if _, reason := ExplainRetryable(tt.err); reason != tt.wantReason {
+				t.Errorf("ExplainRetryable() reason = %q, want %q", reason, tt.wantReason)
+			}
+		})
+	}
+}
+
+// timeoutNetError is a net.Error that timed out without being a context error
+type timeoutNetError struct{}
+
+func (*timeoutNetError) Error() string   { return "i/o timeout" }
+func (*timeoutNetError) Timeout() bool   { return true }
+func (*timeoutNetError) Temporary() bool { return true }
+
+// TestClassifyNetError tests the type, kind and transience ClassifyNetError gives standard library failures
+func TestClassifyNetError(t *testing.T) {
+	refused := &net.OpError{Op: "dial", Net: "tcp", Err: os.NewSyscallError("connect", syscall.ECONNREFUSED)}
+	certErr := &tls.CertificateVerificationError{Err: x509.UnknownAuthorityError{}}
+	plain := stderrors.New("boom")
+	typed := NewValidationError("bad", "host")
+
+	tests := []struct {
+		name          string
+		err           error
+		wantType      string
+		wantKind      NetworkKind
+		wantTransient bool
+		wantSame      bool
+	}{
+		{name: "nil", err: nil, wantSame: true},
+		{name: "not a network error", err: plain, wantSame: true},
+		{name: "typed error", err: typed, wantSame: true},
+		{name: "context error", err: fmt.Errorf("dial: %w", context.DeadlineExceeded), wantSame: true},
+		{name: "url error not network", err: &url.Error{Op: "Get", URL: "ftp://x", Err: plain}, wantSame: true},
+		{name: "dial refused", err: refused, wantType: "*errors.NetworkError", wantKind: NetworkKindDial, wantTransient: true},
+		{name: "dial refused via url error", err: &url.Error{Op: "Get", URL: "http://billing", Err: refused}, wantType: "*errors.NetworkError", wantKind: NetworkKindDial, wantTransient: true},
+		{name: "read reset", err: &net.OpError{Op: "read", Net: "tcp", Err: syscall.ECONNRESET}, wantType: "*errors.NetworkError", wantKind: NetworkKindRead, wantTransient: true},
+		{name: "write broken pipe", err: &net.OpError{Op: "write", Net: "tcp", Err: syscall.EPIPE}, wantType: "*errors.NetworkError", wantKind: NetworkKindWrite, wantTransient: true},
+		{name: "bare reset", err: syscall.ECONNRESET, wantType: "*errors.NetworkError", wantKind: NetworkKindRead, wantTransient: true},
+		{name: "unexpected EOF", err: io.ErrUnexpectedEOF, wantType: "*errors.NetworkError", wantKind: NetworkKindRead, wantTransient: true},
+		{name: "other op", err: &net.OpError{Op: "listen", Net: "tcp", Err: syscall.EADDRINUSE}, wantType: "*errors.NetworkError", wantTransient: true},
+		{name: "DNS temporary", err: &net.DNSError{Err: "server misbehaving", Name: "billing", IsTemporary: true}, wantType: "*errors.NetworkError", wantKind: NetworkKindDNS, wantTransient: true},
+		{name: "NXDOMAIN", err: &net.OpError{Op: "dial", Net: "tcp", Err: &net.DNSError{Err: "no such host", Name: "billing", IsNotFound: true}}, wantType: "*errors.NetworkError", wantKind: NetworkKindDNS},
+		{name: "certificate", err: &url.Error{Op: "Get", URL: "https://billing", Err: certErr}, wantType: "*errors.NetworkError", wantKind: NetworkKindTLS},
+		{name: "remote alert", err: &net.OpError{Op: "remote error", Net: "tcp", Err: stderrors.New("tls: handshake failure")}, wantType: "*errors.NetworkError", wantKind: NetworkKindTLS},
+		{name: "not TLS", err: tls.RecordHeaderError{Msg: "first record does not look like a TLS handshake"}, wantType: "*errors.NetworkError", wantKind: NetworkKindTLS},
+		{name: "timeout", err: &url.Error{Op: "Get", URL: "http://billing", Err: &timeoutNetError{}}, wantType: "*errors.TimeoutError", wantTransient: true},
+		{name: "DNS timeout", err: &net.DNSError{Err: "i/o timeout", Name: "billing", IsTimeout: true}, wantType: "*errors.TimeoutError", wantTransient: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := ClassifyNetError(tt.err, "FetchQuote")
+			if tt.wantSame {
+				if got != tt.err {
+					t.Errorf("ClassifyNetError() = %v, want the original error", got)
+				}
+				return
+			}
+			if typ := fmt.Sprintf("%T", got); typ != tt.wantType {
+				t.Fatalf("ClassifyNetError() type = %s, want %s", typ, tt.wantType)
+			}
+			if !stderrors.Is(got, tt.err) {
+				t.Error("ClassifyNetError() should keep the original as its cause")
+			}
+			if IsRetryable(got) != tt.wantTransient {
+				t.Errorf("IsRetryable() = %v, want %v", IsRetryable(got), tt.wantTransient)
+			}
+			if netErr, ok := got.(*NetworkError); ok {
+				if netErr.Kind != tt.wantKind {
+					t.Errorf("Kind = %q, want %q", netErr.Kind, tt.wantKind)
+				}
+				if netErr.Operation != "FetchQuote" {
+					t.Errorf("Operation = %q, want FetchQuote", netErr.Operation)
+				}
+			}
+		})
+	}
+}
+
+// TestNetworkErrorKind tests that the kind shows in the message and in ExtractErrorInfo
+func TestNetworkErrorKind(t *testing.T) {
+	err := NewNetworkError("connection failed", "FetchQuote", WithNetworkKind(NetworkKindDial))
+	if got, want := err.Error(), "network error in FetchQuote (dial, transient): connection failed"; got != want {
+		t.Errorf("Error() = %q, want %q", got, want)
+	}
+	if got := ExtractErrorInfo(err)["kind"]; got != "dial" {
+		t.Errorf("ExtractErrorInfo()[kind] = %v, want dial", got)
+	}
+	if _, ok := ExtractErrorInfo(NewNetworkError("down", "Dial"))["kind"]; ok {
+		t.Error("ExtractErrorInfo() should omit an unknown kind")
+	}
+}
+
+// TestNetErrorInterface tests that wrapped TimeoutErrors and NetworkErrors are found as net.Error without changing their retryability
+func TestNetErrorInterface(t *testing.T) {
+	tests := []struct {
+		name          string
+		err           error
+		wantTimeout   bool
+		wantTemporary bool
+		wantNetwork   bool
+	}{
+		{name: "timeout", err: Wrap(NewTimeoutError("slow", "Quote", time.Second), "pricing"), wantTimeout: true, wantTemporary: true},
+		{name: "headroom timeout", err: fmt.Errorf("pricing: %w", &TimeoutError{Operation: "Quote", Source: TimeoutSourceHeadroom}), wantTimeout: true},
+		{name: "timeout over net error", err: NewTimeoutError("slow", "Quote", time.Second, WithCause(&timeoutNetError{})), wantTimeout: true, wantTemporary: true, wantNetwork: true},
+		{name: "transient network", err: fmt.Errorf("pricing: %w", NewNetworkError("reset", "Quote")), wantTemporary: true, wantNetwork: true},
+		{name: "persistent network", err: Wrap(NewNetworkError("refused", "Quote", WithTransient(false)), "pricing"), wantNetwork: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var netErr net.Error
+			if !stderrors.As(tt.err, &netErr) {
+				t.Fatal("errors.As(err, *net.Error) = false, want true")
+			}
+			if got := netErr.Timeout(); got != tt.wantTimeout {
+				t.Errorf("Timeout() = %v, want %v", got, tt.wantTimeout)
+			}
+			if got := netErr.Temporary(); got != tt.wantTemporary {
+				t.Errorf("Temporary() = %v, want %v", got, tt.wantTemporary)
+			}
+			if got := IsRetryable(tt.err); got != tt.wantTemporary {
+				t.Errorf("IsRetryable() = %v, want %v", got, tt.wantTemporary)
+			}
+			if got := IsNetworkError(tt.err); got != tt.wantNetwork {
+				t.Errorf("IsNetworkError() = %v, want %v", got, tt.wantNetwork)
+			}
+		})
+	}
+
+	var timeoutErr *TimeoutError
+	var networkErr *NetworkError
+	if timeoutErr.Timeout() || timeoutErr.Temporary() || networkErr.Timeout() || networkErr.Temporary() {
+		t.Error("nil receivers should report false")
+	}
+}
@@ -0,0 +1,129 @@
+package errors
+
+import (
+	"github.com/cockroachdb/errors"
+	"github.com/cockroachdb/errors/errbase"
+)
+
+// NotFoundError represents a lookup of a resource that doesn't exist, such
+// as an order or invoice by ID. It is permanent: retrying finds the same
+// nothing. Automatically includes stack trace from creation point.
+//
+// Maps to 404 and gRPC NotFound.
+type NotFoundError struct {
+	Resource         string // kind of thing looked up, such as "order"
+	ID               string // identifier looked up; empty when not by ID
+	Message          string
+	Component        string
+	Code             string
+	Owner            string
+	Err              error
+	AdditionalCauses []error
+	Metadata         map[string]any
+
+	state errorState
+}
+
+func (e *NotFoundError) Error() string {
+	if e == nil {
+		return "<nil NotFoundError>"
+	}
+	return e.formatWithCause(formatCauses(e.Err, e.AdditionalCauses))
+}
+
+func (e *NotFoundError) formatWithCause(cause string) string {
+	if e == nil {
+		return "<nil NotFoundError>"
+	}
+	head := joinWords(e.Resource, e.ID, "not found")
+	return joinDetails(e.Component, head, e.Message, cause)
+}
+
+func (e *NotFoundError) causeError() error {
+	if e == nil {
+		return nil
+	}
+	return e.Err
+}
+
+func (e *NotFoundError) Unwrap() []error {
+	if e == nil {
+		return nil
+	}
+	return causeList(e.Err, e.AdditionalCauses)
+}
+
+// StackTrace returns the stack from where the error was created. It
+// implements the cockroachdb/errors stack trace provider interface.
+func (e *NotFoundError) StackTrace() errbase.StackTrace {
+	if e == nil {
+		return nil
+	}
+	return e.state.stackTrace()
+}
+
+// Is implements value matching for errors.Is: target matches when it is a
+// *NotFoundError whose non-zero fields all equal e's, so
+// errors.Is(err, &NotFoundError{Resource: "order"}) matches any missing
+// order, and errors.Is(err, ErrActivityNotFound) matches any missing
+// activity. Causes and metadata are not compared.
+func (e *NotFoundError) Is(target error) bool {
+	t, ok := target.(*NotFoundError)
+	if e == nil || !ok || t == nil {
+		return false
+	}
+	return (t.Resource == "" || t.Resource == e.Resource) &&
+		(t.ID == "" || t.ID == e.ID) &&
+		(t.Message == "" || t.Message == e.Message) &&
+		(t.Component == "" || t.Component == e.Component) &&
+		(t.Code == "" || t.Code == e.Code)
+}
+
+// IsRetryable returns false - the resource won't appear by asking again.
+func (e *NotFoundError) IsRetryable() bool {
+	return false
+}
+
+// NewNotFoundError creates a NotFoundError with automatic stack trace.
+// id may be empty when the lookup wasn't by ID.
+//
+// Example:
+//
+//	if errors.Is(err, sql.ErrNoRows) {
+//	    return errors.NewNotFoundError("order", orderID, errors.WithCause(err))
+//	}
+func NewNotFoundError(resource, id string, opts ...Option) error {
+	err := &NotFoundError{
+		Resource: resource,
+		ID:       id,
+	}
+	applyOptions(err, opts)
+	return err
+}
+
+// IsNotFoundError checks if err is a NotFoundError and returns it.
+func IsNotFoundError(err error) (*NotFoundError, bool) {
+	var notFoundErr *NotFoundError
+	if errors.As(err, &notFoundErr) && notFoundErr != nil {
+		return notFoundErr, true
+	}
+	return nil, false
+}
+
+// IsNotFound checks if an error represents a "not found" condition.
+// Returns true for:
+// - A NotFoundError anywhere in the chain, including the
+// ErrActivityNotFound and ErrLocationNotFound sentinels
+// - HTTPError with status code 404
+func IsNotFound(err error) bool {
+	if _, ok := IsNotFoundError(err); ok {
+		return true
+	}
+
+	// Check for HTTPError with 404 status
+	if httpErr, ok := IsHTTPError(err); ok {
+		return httpErr.StatusCode == 404
+	}
+
+	return false
+}
@@ -0,0 +1,43 @@
+package errors
+
+import "reflect"
+
+// NotNil reports whether err is a usable error: non-nil and not a nil
+// pointer stored in an error interface. The classic bug it catches is a
+// function returning a typed nil:
+//
+//	func find() error {
+//	    var err *HTTPError
+//	    return err // err != nil is true for the caller
+//	}
+//
+// Every typed error in this package is nil-receiver safe, so such an error
+// formats as "<nil HTTPError>" instead of panicking, but it still passes an
+// err != nil check. Use NotNil where a typed nil may leak through.
+//
+// Example:
+//
+//	if err := find(); errors.NotNil(err) {
+//	    return err
+//	}
+func NotNil(err error) bool {
+	return err != nil && !isTypedNil(err)
+}
+
+// isTypedNil reports whether v is a nil pointer in a non-nil interface.
+// The typed-error field helpers check it first, so a typed nil anywhere in
+// a chain reads as an error without fields.
+func isTypedNil(v any) bool {
+	rv := reflect.ValueOf(v)
+	return rv.Kind() == reflect.Pointer && rv.IsNil()
+}
+
+// typedNilInfo is ExtractErrorInfo for a typed-nil error, which has no
+// fields to read.
+func typedNilInfo(err error) map[string]any {
+	return map[string]any{
+		"message":   err.Error(),
+		"type":      reflect.TypeOf(err).Elem().Name(),
+		"retryable": false,
+	}
+}
@@ -0,0 +1,144 @@
+package errors
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http/httptest"
+	"testing"
+)
+
+// typedNils returns a nil pointer of every typed error, each stored in an
+// error interface.
+func typedNils() map[string]error {
+	return map[string]error{
+		"HTTPError":           (*HTTPError)(nil),
+		"ValidationError":     (*ValidationError)(nil),
+		"TimeoutError":        (*TimeoutError)(nil),
+		"RateLimitError":      (*RateLimitError)(nil),
+		"RetryableError":      (*RetryableError)(nil),
+		"ProcessingError":     (*ProcessingError)(nil),
+		"NetworkError":        (*NetworkError)(nil),
+		"SerializationError":  (*SerializationError)(nil),
+		"CircuitBreakerError": (*CircuitBreakerError)(nil),
+		"PanicError":          (*PanicError)(nil),
+		"RemoteError":         (*RemoteError)(nil),
+		"RetryError":          (*RetryError)(nil),
+		"BatchError":          (*BatchError)(nil),
+		"NotImplementedError": (*NotImplementedError)(nil),
+		"UnsupportedError":    (*UnsupportedError)(nil),
+		"ConsistencyError":    (*ConsistencyError)(nil),
+		"NotFoundError":       (*NotFoundError)(nil),
+		"ConflictError":       (*ConflictError)(nil),
+		"DatabaseError":       (*DatabaseError)(nil),
+		"ProviderError":       (*ProviderError)(nil),
+		"ConfigError":         (*ConfigError)(nil),
+	}
+}
+
+// TestTypedNilErrors tests that typed-nil errors pass through the helpers without panicking
+func TestTypedNilErrors(t *testing.T) {
+	for name, err := range typedNils() {
+		t.Run(name, func(t *testing.T) {
+			want := "<nil " + name + ">"
+			if got := err.Error(); got != want {
+				t.Errorf("Error() = %q, want %q", got, want)
+			}
+			if IsRetryable(err) {
+				t.Error("IsRetryable() = true, want false")
+			}
+			if got := FormatError(err); got != want {
+				t.Errorf("FormatError() = %q, want %q", got, want)
+			}
+			info := ExtractErrorInfo(err)
+			if info["type"] != name || info["message"] != want || info["retryable"] != false {
+				t.Errorf("ExtractErrorInfo() = %v", info)
+			}
+			if r, ok := err.(Retryable); ok && r.IsRetryable() {
+				t.Error("method IsRetryable() = true, want false")
+			}
+			switch u := err.(type) {
+			case interface{ Unwrap() error }:
+				if u.Unwrap() != nil {
+					t.Error("Unwrap() != nil")
+				}
+			case interface{ Unwrap() []error }:
+				if u.Unwrap() != nil {
+					t.Error("Unwrap() != nil")
+				}
+			}
+
+			if env := Encode(err); env == nil || env.Message != want {
+				t.Errorf("Encode() = %+v", env)
+			}
+			if _, marshalErr := MarshalError(err); marshalErr != nil {
+				t.Errorf("MarshalError() error = %v", marshalErr)
+			}
+			if got := Compact(err); got == nil || got.Error() != want {
+				t.Errorf("Compact() = %v", got)
+			}
+			WriteProblem(httptest.NewRecorder(), err)
+			WriteError(httptest.NewRecorder(), err)
+
+			wrapped := Wrap(fmt.Errorf("lookup: %w", err), "handler")
+			if got := wrapped.Error(); got != "handler: lookup: "+want {
+				t.Errorf("wrapped Error() = %q", got)
+			}
+			if IsRetryable(wrapped) {
+				t.Error("wrapped IsRetryable() = true, want false")
+			}
+			FormatError(wrapped)
+			Encode(wrapped)
+			Compact(wrapped)
+			WriteProblem(httptest.NewRecorder(), wrapped)
+			if info := ExtractErrorInfo(wrapped); info["message"] != wrapped.Error() {
+				t.Errorf("wrapped ExtractErrorInfo() = %v", info)
+			}
+		})
+	}
+}
+
+// TestTypedNilMarshalJSON tests that every typed error's MarshalJSON encodes a nil receiver as null
+func TestTypedNilMarshalJSON(t *testing.T) {
+	for name, err := range typedNils() {
+		t.Run(name, func(t *testing.T) {
+			marshaler, ok := err.(json.Marshaler)
+			if !ok {
+				if _, typed := err.(chainFormatter); typed {
+					t.Fatal("typed error doesn't implement json.Marshaler")
+				}
+				t.Skip("not a json.Marshaler")
+			}
+			data, marshalErr := marshaler.MarshalJSON()
+			if marshalErr != nil || string(data) != "null" {
+				t.Errorf("MarshalJSON() = %s, %v; want null", data, marshalErr)
+			}
+			if data, _ := json.Marshal(map[string]error{"error": err}); string(data) != `{"error":null}` {
+				t.Errorf("json.Marshal() = %s", data)
+			}
+		})
+	}
+}
+
+// TestNotNil tests detection of nil pointers stored in error interfaces
+func TestNotNil(t *testing.T) {
+	var typed *HTTPError
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{name: "nil", err: nil, want: false},
+		{name: "typed nil", err: typed, want: false},
+		{name: "typed error", err: NewHTTPError(500, "Internal Server Error", nil), want: true},
+		{name: "sentinel", err: ErrRateLimited, want: true},
+		{name: "wrapped typed nil", err: fmt.Errorf("lookup: %w", typed), want: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := NotNil(tt.err); got != tt.want {
+				t.Errorf("NotNil() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -1,8 +1,21 @@
 package errors
 
+import (
+	"context"
+	"time"
+)
+
 // Option is a functional option for configuring error creation.
 // Use with error constructor functions to specify optional fields.
 //
+// Options are applied strictly left to right, after the constructor's
+// positional arguments. Each option sets only its own field, so options for
+// different fields commute, and an option always overrides the positional
+// argument for the same field and any earlier option setting it: the last
+// one wins. Behavior derived from several fields, such as IsRetryable
+// consulting both ProcessingError.Retryable and the cause, is computed from
+// the final state and so never depends on option order.
+//
 // Example:
 //
 //	err := NewProcessingError("Failed to process item", "ProcessItem",
@@ -11,6 +24,42 @@ package errors
 //	    WithRetryable(true))
 type Option func(any)
 
+// constructConfig receives the options that affect construction as a
+// whole rather than a field, such as WithContext.
+type constructConfig struct {
+	ctx context.Context
+}
+
+// applyOptions applies the default options (see SetDefaultOptions) and
+// then opts to a newly created typed error, captures its stack (see
+// captureStack), counts it in the error profile (see EnableErrorProfile),
+// then captures goroutine information when requested (see
+// WithGoroutineInfo) and records the error on the span of a WithContext
+// context when span recording is enabled.
+func applyOptions(err error, opts []Option) {
+	opts = withDefaultOptions(opts)
+	for _, opt := range opts {
+		opt(err)
+	}
+	captureStack(err)
+	profileError(err)
+	capture, spans := goroutineCaptureRequested(err), spanRecordingActive()
+	if !capture && !spans {
+		return
+	}
+
+	cfg := &constructConfig{}
+	for _, opt := range opts {
+		opt(cfg)
+	}
+	if capture {
+		captureGoroutine(cfg.ctx, err)
+	}
+	if spans && cfg.ctx != nil {
+		recordTypedOnSpan(cfg.ctx, err)
+	}
+}
+
 // WithCause sets the underlying cause for an error.
 // Use this to wrap lower-level errors while maintaining the error chain.
 //
@@ -28,24 +77,65 @@ func WithCause(cause error) Option {
 			e.Err = cause
 		case *TimeoutError:
 			e.Err = cause
-		case *RateLimitError:
-			e.Err = cause
-		case *RetryableError:
-			e.Err = cause
+		case retryHintHolder:
+			e.retryHint().Err = cause
 		case *ProcessingError:
 			e.Err = cause
 		case *NetworkError:
 			e.Err = cause
+		case *SerializationError:
+			e.Err = cause
 		case *CircuitBreakerError:
 			e.Err = cause
+		case *ConsistencyError:
+			e.Err = cause
+		case *NotFoundError:
+			e.Err = cause
+		case *ConflictError:
+			e.Err = cause
+		case *DatabaseError:
+			e.Err = cause
+		case *ProviderError:
+			e.Err = cause
+		case *ConfigError:
+			e.Err = cause
+		case *NotImplementedError:
+			e.Err = cause
+		case *UnsupportedError:
+			e.Err = cause
 		case *RetryError:
 			e.LastError = cause
 		}
 	}
 }
 
-// WithRetryable sets whether a processing error is retryable.
-// Only applies to ProcessingError types, ignored for others.
+// WithAdditionalCause records a secondary cause alongside the primary one
+// set by WithCause, for a step that failed for independent reasons. It can
+// be repeated; causes keep the order they were added in. Applies to all
+// error types that have an Err field, ignored for RetryError.
+//
+// Unwrap() exposes the primary cause first and then the additional ones, so
+// errors.Is and errors.As see all of them, and Error() notes how many were
+// added ("+1 more cause"). Classification treats the set like a join with a
+// primary: the error is retryable only if it would be with the primary cause
+// alone and no cause is a context error.
+//
+// Example:
+//
+//	err := NewProcessingError("Failed to write order", "SaveOrder",
+//	    WithCause(primaryErr),
+//	    WithAdditionalCause(compensateErr))
+func WithAdditionalCause(cause error) Option {
+	return func(err any) {
+		if field := additionalCausesField(err); field != nil && cause != nil {
+			*field = append(*field, cause)
+		}
+	}
+}
+
+// WithRetryable sets whether a processing error or conflict is retryable.
+// Only applies to ProcessingError and ConflictError types, ignored for
+// others.
 //
 // Example:
 //
@@ -53,7 +143,10 @@ func WithCause(cause error) Option {
 //	    WithRetryable(true))
 func WithRetryable(retryable bool) Option {
 	return func(err any) {
-		if e, ok := err.(*ProcessingError); ok {
+		switch e := err.(type) {
+		case *ProcessingError:
+			e.Retryable = retryable
+		case *ConflictError:
 			e.Retryable = retryable
 		}
 	}
@@ -74,8 +167,8 @@ func WithItemID(itemID string) Option {
 	}
 }
 
-// WithValue sets the value field for validation errors.
-// Only applies to ValidationError types, ignored for others.
+// WithValue sets the value that was rejected.
+// Only applies to ValidationError and ConfigError types, ignored for others.
 //
 // Example:
 //
@@ -83,14 +176,19 @@ func WithItemID(itemID string) Option {
 //	    WithValue(-10))
 func WithValue(value any) Option {
 	return func(err any) {
-		if e, ok := err.(*ValidationError); ok {
+		switch e := err.(type) {
+		case *ValidationError:
+			e.Value = value
+		case *ConfigError:
 			e.Value = value
 		}
 	}
 }
 
 // WithOperation sets the operation name for errors that support it.
-// Applies to TimeoutError, RateLimitError, ProcessingError, NetworkError, CircuitBreakerError, and RetryError.
+// Applies to TimeoutError, RateLimitError, RetryableError, ProcessingError, NetworkError,
+// SerializationError, CircuitBreakerError, NotImplementedError, UnsupportedError,
+// ConsistencyError, DatabaseError, ProviderError, ConfigError, and RetryError.
 //
 // Example:
 //
@@ -100,18 +198,32 @@ func WithOperation(operation string) Option {
 		switch e := err.(type) {
 		case *TimeoutError:
 			e.Operation = operation
-		case *RateLimitError:
-			e.Operation = operation
-		case *RetryableError:
-			e.Operation = operation
+		case retryHintHolder:
+			e.retryHint().Operation = operation
 		case *ProcessingError:
 			e.Operation = operation
 		case *NetworkError:
 			e.Operation = operation
+		case *SerializationError:
+			e.Operation = operation
 		case *CircuitBreakerError:
 			e.Operation = operation
+		case *ConsistencyError:
+			e.Operation = operation
+		case *DatabaseError:
+			e.Operation = operation
+		case *ProviderError:
+			e.Operation = operation
+		case *ConfigError:
+			e.Operation = operation
+		case *NotImplementedError:
+			e.Operation = operation
+		case *UnsupportedError:
+			e.Operation = operation
 		case *RetryError:
 			e.Operation = operation
+		case *PanicError:
+			e.Operation = operation
 		}
 	}
 }
@@ -132,22 +244,38 @@ func WithMessage(message string) Option {
 			e.Message = message
 		case *TimeoutError:
 			e.Message = message
-		case *RateLimitError:
-			e.Message = message
-		case *RetryableError:
-			e.Message = message
+		case retryHintHolder:
+			e.retryHint().Message = message
 		case *ProcessingError:
 			e.Message = message
 		case *NetworkError:
 			e.Message = message
+		case *SerializationError:
+			e.Message = message
 		case *CircuitBreakerError:
 			e.Message = message
+		case *ConsistencyError:
+			e.Message = message
+		case *NotFoundError:
+			e.Message = message
+		case *ConflictError:
+			e.Message = message
+		case *DatabaseError:
+			e.Message = message
+		case *ProviderError:
+			e.Message = message
+		case *ConfigError:
+			e.Message = message
+		case *NotImplementedError:
+			e.Message = message
+		case *UnsupportedError:
+			e.Message = message
 		}
 	}
 }
 
 // WithStatusCode sets the HTTP status code.
-// Only applies to HTTPError types, ignored for others.
+// Only applies to HTTPError and ProviderError types, ignored for others.
 //
 // Example:
 //
@@ -155,8 +283,11 @@ func WithMessage(message string) Option {
 //	    WithStatusCode(503))
 func WithStatusCode(statusCode int) Option {
 	return func(err any) {
-		if e, ok := err.(*HTTPError); ok {
+		switch e := err.(type) {
+		case *HTTPError:
 			e.StatusCode = statusCode
+		case *ProviderError:
+			e.HTTPStatus = statusCode
 		}
 	}
 }
@@ -191,6 +322,21 @@ func WithTransient(transient bool) Option {
 	}
 }
 
+// WithNetworkKind sets the stage of the call that failed.
+// Only applies to NetworkError types, ignored for others.
+//
+// Example:
+//
+//	err := NewNetworkError("Handshake failed", "Connect",
+//	    WithNetworkKind(NetworkKindTLS), WithTransient(false))
+func WithNetworkKind(kind NetworkKind) Option {
+	return func(err any) {
+		if e, ok := err.(*NetworkError); ok {
+			e.Kind = kind
+		}
+	}
+}
+
 // WithState sets the circuit breaker state.
 // Only applies to CircuitBreakerError types, ignored for others.
 //
@@ -224,18 +370,265 @@ func WithComponent(component string) Option {
 			e.Component = component
 		case *TimeoutError:
 			e.Component = component
-		case *RateLimitError:
-			e.Component = component
-		case *RetryableError:
-			e.Component = component
+		case retryHintHolder:
+			e.retryHint().Component = component
 		case *ProcessingError:
 			e.Component = component
 		case *NetworkError:
 			e.Component = component
+		case *SerializationError:
+			e.Component = component
 		case *CircuitBreakerError:
 			e.Component = component
+		case *ConsistencyError:
+			e.Component = component
+		case *NotFoundError:
+			e.Component = component
+		case *ConflictError:
+			e.Component = component
+		case *DatabaseError:
+			e.Component = component
+		case *ProviderError:
+			e.Component = component
+		case *ConfigError:
+			e.Component = component
+		case *NotImplementedError:
+			e.Component = component
+		case *UnsupportedError:
+			e.Component = component
 		case *RetryError:
 			e.Component = component
+		case *PanicError:
+			e.Component = component
+		}
+	}
+}
+
+// WithCode sets a stable, machine-readable error code such as
+// "ORDERS_NOT_FOUND" that clients and transport mappings (see
+// MappingTable) can key off instead of messages.
+// Applies to all error types.
+//
+// Example:
+//
+//	err := NewHTTPError(404, "Order not found", nil,
+//	    WithCode("ORDERS_NOT_FOUND"))
+func WithCode(code string) Option {
+	return func(err any) {
+		if field := codeField(err); field != nil {
+			*field = code
+		}
+	}
+}
+
+// WithOwner sets the team that owns the error, as a slug such as
+// "payments", so alerts route to whoever can fix it. Errors without one
+// inherit their component's owner (see RegisterComponentOwner).
+// Applies to all error types.
+//
+// Example:
+//
+//	err := NewProcessingError("Ledger out of balance", "Reconcile",
+//	    WithOwner("payments"))
+func WithOwner(owner string) Option {
+	return func(err any) {
+		if field := ownerField(err); field != nil {
+			*field = owner
+		}
+	}
+}
+
+// WithReason records why retrying stopped before MaxAttempts.
+// Only applies to RetryError types, ignored for others.
+//
+// Example:
+//
+//	err := NewRetryError(1, 3, lastErr, nil,
+//	    WithReason(RetryReasonBudgetExhaustedUpstream))
+func WithReason(reason string) Option {
+	return func(err any) {
+		if e, ok := err.(*RetryError); ok {
+			e.Reason = reason
+		}
+	}
+}
+
+// WithAttemptHistory records each attempt's retry decision (see
+// ExplainRetryPlan), so post-mortems can reconstruct why and how long a
+// retry executor waited. Only applies to RetryError types, ignored for
+// others.
+//
+// Example:
+//
+//	history = append(history, Attempt{Number: attempt, Err: err, Plan: plan})
+//	...
+//	return NewRetryError(attempt, maxAttempts, err, errs, WithAttemptHistory(history))
+func WithAttemptHistory(history []Attempt) Option {
+	return func(err any) {
+		if e, ok := err.(*RetryError); ok {
+			e.History = history
+		}
+	}
+}
+
+// WithAttempt records that the error came from retry attempt n of
+// maxAttempts (1-based; 0 when the cap is unknown), so an error logged in
+// isolation still shows it was mid-retry. Retry executors set it on each
+// attempt's error. Applies to HTTPError, TimeoutError, ProcessingError and
+// NetworkError types, ignored for others.
+//
+// Example:
+//
+//	err := NewNetworkError("Connection reset", "FetchQuote",
+//	    WithAttempt(4, 5))
+//	// "network error in FetchQuote (transient) (attempt 4/5): Connection reset"
+func WithAttempt(n, maxAttempts int) Option {
+	return func(err any) {
+		if attempt, maxPtr := attemptFields(err); attempt != nil {
+			*attempt, *maxPtr = n, maxAttempts
+		}
+	}
+}
+
+// WithReopenAt records when an open circuit lets calls through again,
+// which ExplainRetryPlan uses as the wait. Only applies to
+// CircuitBreakerError types, ignored for others.
+//
+// Example:
+//
+//	err := NewCircuitBreakerError("Too many failures", "Charge", "open",
+//	    WithReopenAt(openedAt.Add(cooldown)))
+func WithReopenAt(t time.Time) Option {
+	return func(err any) {
+		if e, ok := err.(*CircuitBreakerError); ok {
+			e.ReopenAt = t
+		}
+	}
+}
+
+// WithAvailableFrom records when a not-yet-implemented feature is expected,
+// which makes the error retryable once it is near and sets its retry-after
+// hint. Only applies to NotImplementedError types, ignored for others.
+//
+// Example:
+//
+//	err := NewNotImplementedError("bulk export", WithAvailableFrom(rolloutAt))
+func WithAvailableFrom(t time.Time) Option {
+	return func(err any) {
+		if e, ok := err.(*NotImplementedError); ok {
+			e.AvailableFrom = t
+		}
+	}
+}
+
+// WithRetryAgainstPrimary marks a ConsistencyError's read as one the
+// caller can re-route to the primary (see ShouldRetryAgainstPrimary).
+// Only applies to ConsistencyError types, ignored for others.
+//
+// Example:
+//
+//	err := NewConsistencyError("order/42", "17", "15", 0, WithRetryAgainstPrimary())
+func WithRetryAgainstPrimary() Option {
+	return func(err any) {
+		if e, ok := err.(*ConsistencyError); ok {
+			e.RetryAgainstPrimary = true
+		}
+	}
+}
+
+// WithSQLState sets the SQLSTATE code of a database error, overriding the
+// one read from its cause. Only applies to DatabaseError types, ignored for
+// others.
+//
+// Example:
+//
+//	err := NewDatabaseError("Reserve", "stock", WithSQLState(SQLStateLockNotAvailable))
+func WithSQLState(state string) Option {
+	return func(err any) {
+		if e, ok := err.(*DatabaseError); ok {
+			e.SQLState = state
+		}
+	}
+}
+
+// WithVersions sets the version a write expected and the version it found
+// on a conflict. Only applies to ConflictError types, ignored for others.
+//
+// Example:
+//
+//	err := NewConflictError("order", "42", WithVersions("7", "8"))
+func WithVersions(expected, actual string) Option {
+	return func(err any) {
+		if e, ok := err.(*ConflictError); ok {
+			e.ExpectedVersion, e.ActualVersion = expected, actual
+		}
+	}
+}
+
+// WithOverloaded marks an HTTPError as load shedding (see IsOverload),
+// such as a 503 returned by an admission controller. Only applies to
+// HTTPError types, ignored for others.
+//
+// Example:
+//
+//	err := NewHTTPError(503, "Shedding load", nil, WithOverloaded())
+func WithOverloaded() Option {
+	return func(err any) {
+		if e, ok := err.(*HTTPError); ok {
+			e.Overloaded = true
+		}
+	}
+}
+
+// WithRetryableOverride decides an HTTPError's retryability regardless of
+// its status and the retryable status set (see SetRetryableHTTPStatuses),
+// for an endpoint known to behave differently. Only applies to HTTPError
+// types, ignored for others.
+//
+// Example:
+//
+//	// this endpoint returns 500 for a malformed document
+//	err := NewHTTPError(500, "Rejected document", nil, WithRetryableOverride(false))
+func WithRetryableOverride(retryable bool) Option {
+	return func(err any) {
+		if e, ok := err.(*HTTPError); ok {
+			e.RetryableOverride = &retryable
+		}
+	}
+}
+
+// WithUpstreamRequestID records the request ID a provider returned.
+// Only applies to HTTPError and ProviderError types, ignored for others.
+// FromHTTPResponse sets it from the registered request ID headers.
+//
+// Example:
+//
+//	err := NewHTTPError(resp.StatusCode, "payment failed", nil,
+//	    WithUpstreamRequestID(resp.Header.Get("X-Stripe-Request-Id")))
+func WithUpstreamRequestID(id string) Option {
+	return func(err any) {
+		switch e := err.(type) {
+		case *HTTPError:
+			e.UpstreamRequestID = id
+		case *ProviderError:
+			e.RequestID = id
+		}
+	}
+}
+
+// WithDependency names the dependency an error is attributed to: the
+// service that returned an HTTP error, couldn't be reached, or sent data
+// which couldn't be decoded. Applies to HTTPError, NetworkError and
+// SerializationError types, ignored for others.
+//
+// Example:
+//
+//	err := NewDecodeError("decoding quote", "GetQuote", "json",
+//	    WithDependency("pricing-api"))
+func WithDependency(dependency string) Option {
+	return func(err any) {
+		if field := dependencyField(err); field != nil {
+			*field = dependency
 		}
 	}
 }
@@ -254,3 +647,152 @@ func WithCounts(counts CircuitCounts) Option {
 		}
 	}
 }
+
+// WithMetadata attaches an arbitrary key/value pair to an error.
+// Applies to all error types that have a Metadata field. Later calls with
+// the same key overwrite earlier ones.
+//
+// Example:
+//
+//	err := NewProcessingError("Failed to sync", "SyncTenant",
+//	    WithMetadata("tenant", tenantID))
+func WithMetadata(key string, value any) Option {
+	return func(err any) {
+		setMetadata(err, key, value)
+	}
+}
+
+// WithRateLimitPolicy records the rate limit window on a RateLimitError:
+// the request limit, the requests remaining, and when the window resets.
+// Only applies to RateLimitError types, ignored for others.
+//
+// Example:
+//
+//	err := NewRateLimitError("Too many requests", "Search", 30*time.Second,
+//	    WithRateLimitPolicy(100, 0, windowEnd))
+func WithRateLimitPolicy(limit, remaining int, resetAt time.Time) Option {
+	return func(err any) {
+		if e, ok := err.(*RateLimitError); ok {
+			e.Limit = limit
+			e.Remaining = remaining
+			e.ResetAt = resetAt
+		}
+	}
+}
+
+// IgnorePreclassification makes Classify and ExplainClassification apply
+// this package's rules even to errors carrying their sender's
+// classification (see PreclassifiedClass). Use it to audit disagreements.
+//
+// Example:
+//
+//	if errors.Classify(err) != errors.Classify(err, errors.IgnorePreclassification()) {
+//	    log.Printf("sender and receiver disagree about %v", err)
+//	}
+func IgnorePreclassification() Option {
+	return func(target any) {
+		if c, ok := target.(*classifyConfig); ok {
+			c.ignorePreclassified = true
+		}
+	}
+}
+
+// WithClock sets the clock used by one time-dependent helper, overriding
+// the package clock installed with SetClock.
+// Applies to BackoffRegistry, Escalator, Reporter, HealthTracker,
+// NegativeCache, SharedFailure and ExplainRetryPlan, ignored for others.
+//
+// Example:
+//
+//	registry := NewBackoffRegistry(DefaultBackoffPolicy, time.Minute,
+//	    WithClock(fakeClock))
+func WithClock(clock Clock) Option {
+	return func(target any) {
+		switch r := target.(type) {
+		case *BackoffRegistry:
+			r.clock = clock
+		case *retryPlanConfig:
+			r.clock = clock
+		case *Escalator:
+			r.clock = clock
+		case *Reporter:
+			r.clock = clock
+		case *HealthTracker:
+			r.clock = clock
+		case *NegativeCache:
+			r.clock = clock
+		case *SharedFailure:
+			r.clock = clock
+		}
+	}
+}
+
+// WithMaxKeys bounds how many keys a registry tracks before evicting the
+// least recently used. Applies to BackoffRegistry, Escalator, Reporter and
+// HealthTracker, ignored for others.
+//
+// Example:
+//
+//	registry := NewBackoffRegistry(DefaultBackoffPolicy, time.Minute,
+//	    WithMaxKeys(1000))
+func WithMaxKeys(n int) Option {
+	return func(target any) {
+		switch r := target.(type) {
+		case *BackoffRegistry:
+			r.maxKeys = n
+		case *Escalator:
+			r.maxKeys = n
+		case *Reporter:
+			r.maxKeys = n
+		case *HealthTracker:
+			r.maxKeys = n
+		}
+	}
+}
+
+// WithReportInterval sets how often a Reporter passes errors sharing a
+// fingerprint to its sink; repeats in between are only counted. Defaults
+// to a minute. Only applies to Reporter types, ignored for others.
+//
+// Example:
+//
+//	rep := NewReporter("Migrate", sink, WithReportInterval(10*time.Second))
+func WithReportInterval(interval time.Duration) Option {
+	return func(target any) {
+		if r, ok := target.(*Reporter); ok {
+			r.interval = interval
+		}
+	}
+}
+
+// WithSampleEvery makes the error profile record one error in every n
+// (see EnableErrorProfile). Defaults to 100; 1 records every error. Only
+// applies to EnableErrorProfile, ignored for others.
+//
+// Example:
+//
+//	errors.EnableErrorProfile(errors.WithSampleEvery(1000))
+func WithSampleEvery(n int) Option {
+	return func(target any) {
+		if cfg, ok := target.(*profileConfig); ok {
+			cfg.sampleEvery = n
+		}
+	}
+}
+
+// WithHoldFailure makes a SharedFailure hold each failure for d after the
+// call that produced it returns, so calls arriving meanwhile get it
+// without running fn again. Defaults to 0, sharing failures only between
+// concurrent calls. Only applies to SharedFailure types, ignored for
+// others.
+//
+// Example:
+//
+//	shared := NewSharedFailure(WithHoldFailure(100 * time.Millisecond))
+func WithHoldFailure(d time.Duration) Option {
+	return func(target any) {
+		if s, ok := target.(*SharedFailure); ok {
+			s.holdFor = d
+		}
+	}
+}
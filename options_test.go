@@ -0,0 +1,277 @@
+package errors
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// constructorCase lists, for one constructor, options that set distinct
+// fields (and so must commute) and pairs of options that set the same field
+// (where the last must win).
+type constructorCase struct {
+	name      string
+	construct func(opts ...Option) error
+	commuting []Option
+	conflicts [][2]Option
+}
+
+func constructorCases() []constructorCase {
+	cause := fmt.Errorf("root cause")
+	otherCause := NewValidationError("bad", "field")
+	ctx := ContextWithTrace(context.Background(), "trace-1", "span-1")
+
+	shared := []Option{
+		WithCause(cause),
+		WithComponent("billing"),
+		WithMetadata("tenant", "acme"),
+		WithContext(ctx),
+	}
+	sharedConflicts := [][2]Option{
+		{WithCause(cause), WithCause(otherCause)},
+		{WithComponent("billing"), WithComponent("ledger")},
+		{WithMetadata("tenant", "acme"), WithMetadata("tenant", "globex")},
+	}
+	with := func(opts ...Option) []Option {
+		return append(append([]Option{}, shared...), opts...)
+	}
+
+	return []constructorCase{
+		{
+			name: "HTTPError",
+			construct: func(opts ...Option) error {
+				return NewHTTPError(500, "Internal Server Error", nil, opts...)
+			},
+			commuting: with(WithStatusCode(503), WithMessage("Unavailable")),
+			conflicts: append(sharedConflicts, [2]Option{WithStatusCode(503), WithStatusCode(502)}),
+		},
+		{
+			name: "ValidationError",
+			construct: func(opts ...Option) error {
+				return NewValidationError("invalid", "email", opts...)
+			},
+			commuting: with(WithField("phone"), WithValue(42), WithMessage("bad phone")),
+			conflicts: append(sharedConflicts, [2]Option{WithValue(1), WithValue(2)}),
+		},
+		{
+			name: "TimeoutError",
+			construct: func(opts ...Option) error {
+				return NewTimeoutError("timed out", "Fetch", time.Second, opts...)
+			},
+			commuting: with(WithOperation("Load"), WithMessage("slow")),
+			conflicts: append(sharedConflicts, [2]Option{WithOperation("Load"), WithOperation("Store")}),
+		},
+		{
+			name: "RateLimitError",
+			construct: func(opts ...Option) error {
+				return NewRateLimitError("slow down", "Fetch", time.Second, opts...)
+			},
+			commuting: with(WithOperation("Load"), WithMessage("too many")),
+			conflicts: append(sharedConflicts, [2]Option{WithMessage("a"), WithMessage("b")}),
+		},
+		{
+			name: "RetryableError",
+			construct: func(opts ...Option) error {
+				return NewRetryableError("busy", "Fetch", time.Second, opts...)
+			},
+			commuting: with(WithOperation("Load"), WithMessage("still busy")),
+			conflicts: append(sharedConflicts, [2]Option{WithOperation("Load"), WithOperation("Store")}),
+		},
+		{
+			name: "ProcessingError",
+			construct: func(opts ...Option) error {
+				return NewProcessingError("failed", "Charge", opts...)
+			},
+			commuting: with(WithRetryable(true), WithItemID("order-1"), WithOperation("Refund")),
+			conflicts: append(sharedConflicts,
+				[2]Option{WithRetryable(true), WithRetryable(false)},
+				[2]Option{WithRetryable(false), WithRetryable(true)},
+				[2]Option{WithItemID("a"), WithItemID("b")}),
+		},
+		{
+			name: "RetryableProcessingError",
+			construct: func(opts ...Option) error {
+				return NewRetryableProcessingError("failed", "Charge", opts...)
+			},
+			commuting: with(WithItemID("order-1"), WithMessage("failed hard")),
+			conflicts: append(sharedConflicts, [2]Option{WithRetryable(true), WithRetryable(false)}),
+		},
+		{
+			name: "NetworkError",
+			construct: func(opts ...Option) error {
+				return NewNetworkError("dial failed", "Connect", opts...)
+			},
+			commuting: with(WithTransient(false), WithOperation("Dial")),
+			conflicts: append(sharedConflicts, [2]Option{WithTransient(false), WithTransient(true)}),
+		},
+		{
+			name: "SerializationError",
+			construct: func(opts ...Option) error {
+				return NewSerializationError("bad payload", "Decode", "json", opts...)
+			},
+			commuting: with(WithOperation("Parse"), WithMessage("truncated")),
+			conflicts: append(sharedConflicts, [2]Option{WithMessage("a"), WithMessage("b")}),
+		},
+		{
+			name: "CircuitBreakerError",
+			construct: func(opts ...Option) error {
+				return NewCircuitBreakerError("tripped", "Charge", "open", opts...)
+			},
+			commuting: with(WithState("half-open"), WithCounts(CircuitCounts{ConsecutiveFailures: 3}), WithOperation("Refund")),
+			conflicts: append(sharedConflicts, [2]Option{WithState("open"), WithState("closed")}),
+		},
+		{
+			name: "RetryError",
+			construct: func(opts ...Option) error {
+				return NewRetryError(3, 3, nil, nil, opts...)
+			},
+			commuting: with(WithOperation("Sync")),
+			conflicts: append(sharedConflicts, [2]Option{WithOperation("a"), WithOperation("b")}),
+		},
+	}
+}
+
+// withoutStack clears err's creation stack, which differs between call
+// sites, so constructed errors can be compared by value.
+func withoutStack(err error) error {
+	if state := stateField(err); state != nil {
+		state.stack = nil
+	}
+	return err
+}
+
+// TestOptionOrder tests that commuting options are order-independent and conflicting options are last-wins
+func TestOptionOrder(t *testing.T) {
+	for _, tc := range constructorCases() {
+		t.Run(tc.name, func(t *testing.T) {
+			want := withoutStack(tc.construct(tc.commuting...))
+			wantRetryable := IsRetryable(want)
+
+			permute(tc.commuting, func(opts []Option) {
+				got := withoutStack(tc.construct(opts...))
+				if !reflect.DeepEqual(got, want) {
+					t.Errorf("permuted options produced %#v, want %#v", got, want)
+				}
+				if IsRetryable(got) != wantRetryable {
+					t.Errorf("IsRetryable() depends on option order")
+				}
+			})
+
+			for i, pair := range tc.conflicts {
+				got := withoutStack(tc.construct(pair[0], pair[1]))
+				if last := withoutStack(tc.construct(pair[1])); !reflect.DeepEqual(got, last) {
+					t.Errorf("conflict %d: got %#v, want last option to win: %#v", i, got, last)
+				}
+			}
+		})
+	}
+}
+
+// TestOptionsOverridePositionalArgs tests that options win over constructor arguments
+func TestOptionsOverridePositionalArgs(t *testing.T) {
+	cause := NewValidationError("bad", "email", WithComponent("signup"))
+
+	httpErr := NewHTTPError(500, "Internal", fmt.Errorf("ignored"), WithStatusCode(400), WithCause(cause)).(*HTTPError)
+	if httpErr.StatusCode != 400 || httpErr.Err != cause {
+		t.Errorf("options should override positional args: %+v", httpErr)
+	}
+	if httpErr.OriginComponent != "signup" {
+		t.Errorf("OriginComponent = %q, want it derived from the final cause", httpErr.OriginComponent)
+	}
+
+	retryErr := NewRetryError(3, 3, fmt.Errorf("ignored"), nil, WithCause(cause))
+	if retryErr.LastError != cause {
+		t.Errorf("WithCause should override the positional last error")
+	}
+
+	if procErr := NewRetryableProcessingError("failed", "Charge", WithRetryable(false)).(*ProcessingError); procErr.Retryable {
+		t.Error("caller's WithRetryable(false) should override the constructor default")
+	}
+}
+
+// TestSharedOptionsApplyToEveryType tests that WithCause, WithOperation, WithMessage and WithComponent set their field on every typed error that has it
+func TestSharedOptionsApplyToEveryType(t *testing.T) {
+	cause := fmt.Errorf("db unavailable")
+	opts := []Option{
+		WithCause(cause),
+		WithOperation("op-set"),
+		WithMessage("message-set"),
+		WithComponent("component-set"),
+	}
+
+	constructors := map[string]func(opts ...Option) error{
+		"ConsistencyError": func(opts ...Option) error {
+			return NewConsistencyError("orders", "v2", "v1", time.Second, opts...)
+		},
+		"NotImplementedError": func(opts ...Option) error {
+			return NewNotImplementedError("exports", opts...)
+		},
+		"UnsupportedError": func(opts ...Option) error {
+			return NewUnsupportedError("xml", "json", opts...)
+		},
+		"PanicError": func(opts ...Option) error {
+			return NewPanicError("boom", opts...)
+		},
+		"NotFoundError": func(opts ...Option) error {
+			return NewNotFoundError("order", "42", opts...)
+		},
+		"ConflictError": func(opts ...Option) error {
+			return NewConflictError("order", "42", opts...)
+		},
+		"DatabaseError": func(opts ...Option) error {
+			return NewDatabaseError("UpdateOrder", "orders", opts...)
+		},
+		"ProviderError": func(opts ...Option) error {
+			return NewProviderError("stripe", opts...)
+		},
+		"ConfigError": func(opts ...Option) error {
+			return NewConfigError("must be at least 1", "max", opts...)
+		},
+	}
+	for _, tc := range constructorCases() {
+		constructors[tc.name] = tc.construct
+	}
+
+	for name, construct := range constructors {
+		t.Run(name, func(t *testing.T) {
+			err := construct(opts...)
+			if got := GetComponent(err); got != "component-set" {
+				t.Errorf("GetComponent() = %q, want WithComponent applied", got)
+			}
+
+			value := reflect.ValueOf(err).Elem()
+			for _, field := range []string{"Err", "LastError"} {
+				if f := value.FieldByName(field); f.IsValid() && f.Interface() != cause {
+					t.Errorf("%s = %v, want WithCause applied", field, f.Interface())
+				}
+			}
+			if value.FieldByName("Err").IsValid() && !Is(err, cause) {
+				t.Errorf("errors.Is() should find the cause set by WithCause: %v", err)
+			}
+			for field, want := range map[string]string{"Operation": "op-set", "Message": "message-set"} {
+				if f := value.FieldByName(field); f.IsValid() && f.String() != want {
+					t.Errorf("%s = %q, want %q", field, f.String(), want)
+				}
+			}
+		})
+	}
+}
+
+// permute calls fn with every ordering of opts.
+func permute(opts []Option, fn func([]Option)) {
+	var rec func(k int)
+	rec = func(k int) {
+		if k == len(opts) {
+			fn(append([]Option(nil), opts...))
+			return
+		}
+		for i := k; i < len(opts); i++ {
+			opts[k], opts[i] = opts[i], opts[k]
+			rec(k + 1)
+			opts[k], opts[i] = opts[i], opts[k]
+		}
+	}
+	rec(0)
+}
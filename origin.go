@@ -0,0 +1,287 @@
+package errors
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"reflect"
+	"runtime"
+	"strconv"
+	"strings"
+
+	"github.com/cockroachdb/errors/errbase"
+)
+
+// thisPackage is the import path of this package, used to skip its own
+// frames when locating where an error originated.
+var thisPackage = reflect.TypeOf(HTTPError{}).PkgPath()
+
+// maxFingerprintFrames caps how many origin frames contribute to Fingerprint.
+const maxFingerprintFrames = 8
+
+// Fingerprint returns a short hash grouping occurrences of the same failure
+// at the same code path within one build. It combines the outermost typed
+// error's type and operation (and a ProviderError's provider and code), the
+// HTTP status, and the function names of the origin stack (see
+// GetOriginStackTrace). Line numbers are ignored, but
+// function names can change with inlining or refactoring; use OriginKey to
+// group across builds. Returns "" for a nil error.
+func Fingerprint(err error) string {
+	if err == nil {
+		return ""
+	}
+
+	parts := []string{errorSignature(err)}
+	frames := originFrames(err)
+	for i := 0; i < len(frames) && i < maxFingerprintFrames; i++ {
+		parts = append(parts, frames[i].Function)
+	}
+	return shortHash(parts...)
+}
+
+// OriginKey returns a short hash identifying the function where err
+// originated, stable across rebuilds and unrelated code changes. It uses the
+// innermost stack in the chain and takes the first frame outside this
+// package and cockroachdb/errors, normalized to import path and function
+// name with receiver, closure suffixes and line number stripped, so
+// "(*Client).Query.func1" at any line in package db keys as "db.Query".
+//
+// Errors without a stack fall back to a key derived from the outermost
+// typed error's type, operation and HTTP status, which groups by logical
+// failure rather than location. Returns "" for a nil error.
+func OriginKey(err error) string {
+	if err == nil {
+		return ""
+	}
+
+	if key := recordedOriginKey(err); key != "" {
+		return key
+	}
+	return shortHash("signature", errorSignature(err))
+}
+
+// recordedOriginKey returns err's OriginKey when it was derived from a
+// stack, here or by the sender of a decoded error, and "" for the fallback
+// derived from the error's signature.
+func recordedOriginKey(err error) string {
+	var decoded *originKeyError
+	if As(err, &decoded) {
+		return decoded.key
+	}
+	if origin := originFunction(originFrames(err)); origin != "" {
+		return shortHash("origin", origin)
+	}
+	return ""
+}
+
+// originStack returns the innermost stack trace provider in err's chain
+// that has a stack. Typed errors built without one, such as decoded errors,
+// are skipped, as are sentinels: their stacks record package
+// initialization rather than a failure.
+func originStack(err error) errbase.StackTraceProvider {
+	var origin errbase.StackTraceProvider
+	walkChain(err, func(node error, _ int) bool {
+		if st, ok := node.(errbase.StackTraceProvider); ok && !initStack(st.StackTrace()) {
+			origin = st
+		}
+		return true
+	})
+	return origin
+}
+
+// initStack reports whether trace is empty or was recorded while packages
+// were being initialized.
+func initStack(trace errbase.StackTrace) bool {
+	if len(trace) == 0 {
+		return true
+	}
+	pcs := make([]uintptr, len(trace))
+	for i, f := range trace {
+		pcs[i] = uintptr(f)
+	}
+	frames := runtime.CallersFrames(pcs)
+	for {
+		frame, more := frames.Next()
+		if strings.HasPrefix(frame.Function, "runtime.doInit") {
+			return true
+		}
+		if !more {
+			return false
+		}
+	}
+}
+
+// originFrames resolves the innermost stack in err's chain into frames.
+func originFrames(err error) []runtime.Frame {
+	st := originStack(err)
+	if st == nil {
+		return nil
+	}
+
+	trace := st.StackTrace()
+	pcs := make([]uintptr, len(trace))
+	for i, f := range trace {
+		pcs[i] = uintptr(f)
+	}
+
+	var result []runtime.Frame
+	frames := runtime.CallersFrames(pcs)
+	for {
+		frame, more := frames.Next()
+		if frame.Function != "" {
+			result = append(result, frame)
+		}
+		if !more {
+			return result
+		}
+	}
+}
+
+// originFunction returns the normalized name of the first frame that
+// belongs to the caller's code.
+func originFunction(frames []runtime.Frame) string {
+	for _, frame := range frames {
+		pkg, fn := splitFunction(frame.Function)
+		switch {
+		case pkg == "runtime", strings.HasPrefix(pkg, "github.com/cockroachdb/errors"):
+			continue
+		case pkg == thisPackage && !strings.HasSuffix(frame.File, "_test.go"):
+			continue
+		}
+		return pkg + "." + normalizeFunction(fn)
+	}
+	return ""
+}
+
+// splitFunction splits a runtime function name such as
+// "github.com/org/app/db.(*Client).Query.func1" into its import path and
+// the remainder.
+func splitFunction(name string) (pkg, fn string) {
+	slash := strings.LastIndex(name, "/")
+	dot := strings.Index(name[slash+1:], ".")
+	if dot < 0 {
+		return name, ""
+	}
+	dot += slash + 1
+	return name[:dot], name[dot+1:]
+}
+
+// normalizeFunction strips receivers, generic type arguments and closure
+// suffixes, leaving the enclosing function or method name.
+func normalizeFunction(fn string) string {
+	if i := strings.Index(fn, "["); i >= 0 {
+		if j := strings.LastIndex(fn, "]"); j > i {
+			fn = fn[:i] + fn[j+1:]
+		}
+	}
+
+	var kept []string
+	for _, segment := range strings.Split(fn, ".") {
+		if isGeneratedSegment(segment) {
+			continue
+		}
+		kept = append(kept, segment)
+	}
+	if len(kept) == 0 {
+		return fn
+	}
+	return kept[len(kept)-1]
+}
+
+// isGeneratedSegment reports whether a function name segment was generated
+// by the compiler for a closure or go/defer wrapper.
+func isGeneratedSegment(segment string) bool {
+	for _, prefix := range []string{"func", "gowrap", "deferwrap"} {
+		if rest, ok := strings.CutPrefix(segment, prefix); ok {
+			if _, err := strconv.Atoi(rest); err == nil {
+				return true
+			}
+		}
+	}
+	_, err := strconv.Atoi(segment)
+	return err == nil
+}
+
+// errorSignature describes err by its outermost typed error's type and
+// operation plus its HTTP status, and a ProviderError's provider and code,
+// for grouping errors without a stack.
+func errorSignature(err error) string {
+	operation := ""
+	typed := outermostTyped(err)
+	if typed != nil {
+		operation = operationOf(typed)
+	}
+	signature := fmt.Sprintf("%s|%s|%d", signatureType(err), operation, HTTPStatus(err))
+	if providerErr, ok := typed.(*ProviderError); ok {
+		signature += "|" + providerErr.Provider + "|" + providerErr.Code
+	}
+	return signature
+}
+
+// signatureType returns the type name of the outermost typed error in err's
+// chain, or "Error" if there is none.
+func signatureType(err error) string {
+	if typed := outermostTyped(err); typed != nil {
+		return typeName(typed.(chainFormatter))
+	}
+	return "Error"
+}
+
+// outermostTyped returns the outermost of this package's typed errors in
+// err's chain, or nil if there is none.
+func outermostTyped(err error) error {
+	var typed error
+	walkChain(err, func(node error, _ int) bool {
+		if _, ok := node.(chainFormatter); ok {
+			typed = node
+			return false
+		}
+		return true
+	})
+	return typed
+}
+
+// operationOf returns the Operation field of a typed error, if it has one.
+func operationOf(err error) string {
+	if isTypedNil(err) {
+		return ""
+	}
+	switch e := err.(type) {
+	case *TimeoutError:
+		return e.Operation
+	case retryHintHolder:
+		return e.retryHint().Operation
+	case *ProcessingError:
+		return e.Operation
+	case *NetworkError:
+		return e.Operation
+	case *SerializationError:
+		return e.Operation
+	case *CircuitBreakerError:
+		return e.Operation
+	case *ConsistencyError:
+		return e.Operation
+	case *DatabaseError:
+		return e.Operation
+	case *ProviderError:
+		return e.Operation
+	case *ConfigError:
+		return e.Operation
+	case *NotImplementedError:
+		return e.Operation
+	case *UnsupportedError:
+		return e.Operation
+	case *RetryError:
+		return e.Operation
+	case *PanicError:
+		return e.Operation
+	case *RemoteError:
+		return e.Operation
+	}
+	return ""
+}
+
+func shortHash(parts ...string) string {
+	sum := sha256.Sum256([]byte(strings.Join(parts, "\x00")))
+	return hex.EncodeToString(sum[:8])
+}
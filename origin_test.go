@@ -0,0 +1,122 @@
+package errors
+
+import (
+	"fmt"
+	"testing"
+)
+
+func originFirstLine() error {
+	return New("first failure")
+}
+
+func originSecondLine() (error, error) {
+	a := New("failure a")
+	b := New("failure b")
+	return a, b
+}
+
+type originClient struct{}
+
+func (originClient) query() error {
+	fail := func() error { return New("query failed") }
+	return fail()
+}
+
+// TestOriginKey tests that origin keys group by originating function
+func TestOriginKey(t *testing.T) {
+	t.Run("same function at different lines", func(t *testing.T) {
+		a, b := originSecondLine()
+		if OriginKey(a) != OriginKey(b) {
+			t.Error("errors from the same function should share an origin key")
+		}
+	})
+
+	t.Run("different functions", func(t *testing.T) {
+		a, _ := originSecondLine()
+		if OriginKey(a) == OriginKey(originFirstLine()) {
+			t.Error("errors from different functions should have different origin keys")
+		}
+	})
+
+	t.Run("wrapping keeps the origin", func(t *testing.T) {
+		err := originFirstLine()
+		wrapped := NewHTTPError(502, "Bad Gateway", Wrap(err, "calling upstream"))
+		if OriginKey(wrapped) != OriginKey(err) {
+			t.Error("wrapping should not change the origin key")
+		}
+	})
+
+	t.Run("closure and receiver are stripped", func(t *testing.T) {
+		want := shortHash("origin", thisPackage+".query")
+		if got := OriginKey(originClient{}.query()); got != want {
+			t.Errorf("OriginKey() = %s, want key for %s.query", got, thisPackage)
+		}
+	})
+
+	t.Run("fallback without stack", func(t *testing.T) {
+		a := &ProcessingError{Message: "failed to charge", Operation: "Charge"}
+		b := &ProcessingError{Message: "failed to charge card 42", Operation: "Charge"}
+		c := &ProcessingError{Message: "failed to refund", Operation: "Refund"}
+
+		if OriginKey(a) != OriginKey(b) {
+			t.Error("same type and operation should share a fallback key")
+		}
+		if OriginKey(a) == OriginKey(c) {
+			t.Error("different operations should have different fallback keys")
+		}
+		if OriginKey(fmt.Errorf("plain")) == "" {
+			t.Error("foreign errors should still have a key")
+		}
+	})
+
+	t.Run("nil", func(t *testing.T) {
+		if OriginKey(nil) != "" || Fingerprint(nil) != "" {
+			t.Error("nil error should have empty keys")
+		}
+	})
+}
+
+// TestNormalizeFunction tests reduction of runtime function names
+func TestNormalizeFunction(t *testing.T) {
+	tests := []struct {
+		name    string
+		wantPkg string
+		wantFn  string
+	}{
+		{name: "github.com/org/app/db.(*Client).Query", wantPkg: "github.com/org/app/db", wantFn: "Query"},
+		{name: "github.com/org/app/db.(*Client).Query.func1", wantPkg: "github.com/org/app/db", wantFn: "Query"},
+		{name: "github.com/org/app/db.Client.Query", wantPkg: "github.com/org/app/db", wantFn: "Query"},
+		{name: "github.com/org/app/db.load.func2.1", wantPkg: "github.com/org/app/db", wantFn: "load"},
+		{name: "github.com/org/app/db.Map[...].Get", wantPkg: "github.com/org/app/db", wantFn: "Get"},
+		{name: "github.com/org/app.(*Server).Serve.gowrap1", wantPkg: "github.com/org/app", wantFn: "Serve"},
+		{name: "main.main", wantPkg: "main", wantFn: "main"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pkg, fn := splitFunction(tt.name)
+			if pkg != tt.wantPkg {
+				t.Errorf("package = %q, want %q", pkg, tt.wantPkg)
+			}
+			if got := normalizeFunction(fn); got != tt.wantFn {
+				t.Errorf("function = %q, want %q", got, tt.wantFn)
+			}
+		})
+	}
+}
+
+// TestFingerprint tests grouping within a build
+func TestFingerprint(t *testing.T) {
+	a, b := originSecondLine()
+	if Fingerprint(a) != Fingerprint(b) {
+		t.Error("same failure at the same code path should share a fingerprint")
+	}
+	if Fingerprint(a) == Fingerprint(NewHTTPError(502, "Bad Gateway", a)) {
+		t.Error("different outer error types should have different fingerprints")
+	}
+
+	info := ExtractErrorInfo(a)
+	if info["fingerprint"] != Fingerprint(a) || info["origin_key"] != OriginKey(a) {
+		t.Errorf("ExtractErrorInfo() missing grouping keys: %v", info)
+	}
+}
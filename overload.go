@@ -0,0 +1,124 @@
+package errors
+
+import (
+	"net/http"
+	"sync"
+)
+
+// HeaderEnvoyOverloaded is set by Envoy on responses it sheds under load.
+const HeaderEnvoyOverloaded = "X-Envoy-Overloaded"
+
+// OverloadBackoffMultiplier scales a BackoffPolicy's Multiplier for
+// overloaded errors (see SuggestedBackoff), so clients back off harder from
+// a server shedding load than from one that is merely failing.
+const OverloadBackoffMultiplier = 2
+
+// OverloadMatcher reports whether an error response signals overload, such
+// as a load balancer's "upstream overloaded" page. body holds what was read
+// of the response body, and is nil when the caller didn't read it.
+type OverloadMatcher func(resp *http.Response, body []byte) bool
+
+var (
+	overloadMu       sync.RWMutex
+	overloadHeaders  = defaultOverloadHeaders()
+	overloadMatchers []OverloadMatcher
+)
+
+func defaultOverloadHeaders() []string {
+	return []string{HeaderRetryAfter, HeaderEnvoyOverloaded}
+}
+
+// RegisterOverloadHeader adds a response header whose presence on a 5xx
+// response marks it as load shedding (see IsOverloadResponse). Retry-After
+// and X-Envoy-Overloaded are registered by default.
+//
+// Example:
+//
+//	errors.RegisterOverloadHeader("X-Load-Shed")
+func RegisterOverloadHeader(name string) {
+	overloadMu.Lock()
+	defer overloadMu.Unlock()
+	overloadHeaders = append(overloadHeaders, name)
+}
+
+// RegisterOverloadMatcher adds a matcher consulted for 5xx responses
+// carrying none of the registered overload headers.
+//
+// Example:
+//
+//	errors.RegisterOverloadMatcher(func(resp *http.Response, body []byte) bool {
+//	    return bytes.Contains(body, []byte("upstream overloaded"))
+//	})
+func RegisterOverloadMatcher(matcher OverloadMatcher) {
+	overloadMu.Lock()
+	defer overloadMu.Unlock()
+	overloadMatchers = append(overloadMatchers, matcher)
+}
+
+// ResetOverloadSignals restores the default overload headers and removes
+// all matchers. Intended for tests.
+func ResetOverloadSignals() {
+	overloadMu.Lock()
+	defer overloadMu.Unlock()
+	overloadHeaders = defaultOverloadHeaders()
+	overloadMatchers = nil
+}
+
+// IsOverloadResponse reports whether resp is a 5xx sent by a server
+// shedding load rather than one that is broken: it carries a registered
+// overload header (see RegisterOverloadHeader) or a registered matcher
+// accepts it. body is passed to matchers and may be nil.
+func IsOverloadResponse(resp *http.Response, body []byte) bool {
+	if resp == nil || resp.StatusCode < 500 {
+		return false
+	}
+
+	overloadMu.RLock()
+	defer overloadMu.RUnlock()
+
+	for _, name := range overloadHeaders {
+		if resp.Header.Get(name) != "" {
+			return true
+		}
+	}
+	for _, matcher := range overloadMatchers {
+		if matcher(resp, body) {
+			return true
+		}
+	}
+	return false
+}
+
+// IsOverload reports whether err is, or wraps, an HTTPError marked
+// Overloaded: a server shedding load, which callers should back off from
+// and autoscalers should scale out for, rather than fail over from.
+//
+// Example:
+//
+//	if errors.IsOverload(err) {
+//	    metrics.ShedResponses.Inc()
+//	}
+func IsOverload(err error) bool {
+	overloaded := false
+	walkChain(err, func(node error, _ int) bool {
+		if e, ok := node.(*HTTPError); ok && e.Overloaded {
+			overloaded = true
+		}
+		return !overloaded
+	})
+	return overloaded
+}
+
+// SuggestedBackoff returns policy adjusted for err: for overloaded errors
+// (see IsOverload) the Multiplier is scaled by OverloadBackoffMultiplier;
+// other errors get policy unchanged. ExplainRetryPlan applies it.
+//
+// Example:
+//
+//	delay := errors.SuggestedBackoff(err, errors.DefaultBackoffPolicy).Delay(attempt)
+func SuggestedBackoff(err error, policy BackoffPolicy) BackoffPolicy {
+	if IsOverload(err) {
+		policy.Multiplier = max(policy.Multiplier, 1) * OverloadBackoffMultiplier
+	}
+	return policy
+}
@@ -0,0 +1,95 @@
+package errors
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// TestFromHTTPResponseOverload tests telling load shedding apart from broken servers
+func TestFromHTTPResponseOverload(t *testing.T) {
+	defer ResetOverloadSignals()
+	RegisterOverloadHeader("X-Load-Shed")
+	RegisterOverloadMatcher(func(_ *http.Response, body []byte) bool {
+		return bytes.Contains(body, []byte("upstream overloaded"))
+	})
+
+	tests := []struct {
+		name           string
+		status         int
+		header         string
+		body           string
+		wantOverloaded bool
+		wantRetryAfter time.Duration
+	}{
+		{name: "crash", status: 503, body: "panic: nil map", wantOverloaded: false},
+		{name: "retry-after", status: 503, header: HeaderRetryAfter, wantOverloaded: true, wantRetryAfter: 5 * time.Second},
+		{name: "envoy shedding", status: 503, header: HeaderEnvoyOverloaded, wantOverloaded: true},
+		{name: "registered header", status: 500, header: "X-Load-Shed", wantOverloaded: true},
+		{name: "registered matcher", status: 502, body: "upstream overloaded, try later", wantOverloaded: true},
+		{name: "client errors are never overload", status: 429, header: HeaderRetryAfter, wantRetryAfter: 5 * time.Second},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			if tt.header != "" {
+				rec.Header().Set(tt.header, "5")
+			}
+			rec.WriteHeader(tt.status)
+			rec.WriteString(tt.body)
+
+			err := FromHTTPResponse(rec.Result())
+			if got := IsOverload(err); got != tt.wantOverloaded {
+				t.Errorf("IsOverload() = %v, want %v", got, tt.wantOverloaded)
+			}
+			if wait, _ := GetRetryAfter(err); wait != tt.wantRetryAfter {
+				t.Errorf("GetRetryAfter() = %v, want %v", wait, tt.wantRetryAfter)
+			}
+
+			wantClass := string(ClassTransient)
+			if tt.wantOverloaded {
+				wantClass = ClassOverload
+			}
+			if class := MetricLabels(err)[LabelErrorClass]; class != wantClass {
+				t.Errorf("error_class = %q, want %q", class, wantClass)
+			}
+		})
+	}
+}
+
+// TestOverloadBackoff tests that overloaded errors back off harder and survive transport
+func TestOverloadBackoff(t *testing.T) {
+	policy := BackoffPolicy{Initial: 100 * time.Millisecond, Max: time.Minute, Multiplier: 2}
+	broken := NewHTTPError(503, "Unavailable", nil)
+	overloaded := Wrap(NewHTTPError(503, "Shedding load", nil, WithOverloaded()), "calling search")
+
+	if got := ExplainRetryPlan(broken, 3, policy).Delay; got != 400*time.Millisecond {
+		t.Errorf("broken delay = %v, want 400ms", got)
+	}
+	if got := ExplainRetryPlan(overloaded, 3, policy).Delay; got != 1600*time.Millisecond {
+		t.Errorf("overloaded delay = %v, want 1.6s", got)
+	}
+	if SuggestedBackoff(broken, policy) != policy {
+		t.Error("SuggestedBackoff() should leave the policy alone for other errors")
+	}
+
+	decoded, err := UnmarshalError(mustMarshal(t, overloaded))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !IsOverload(decoded) {
+		t.Error("Overloaded lost in envelope round trip")
+	}
+}
+
+func mustMarshal(t *testing.T, err error) []byte {
+	t.Helper()
+	payload, marshalErr := MarshalError(err)
+	if marshalErr != nil {
+		t.Fatal(marshalErr)
+	}
+	return payload
+}
@@ -0,0 +1,122 @@
+package errors
+
+import (
+	"sort"
+	"sync"
+
+	"github.com/cockroachdb/errors"
+)
+
+var (
+	ownersMu        sync.RWMutex
+	componentOwners = make(map[string]string)
+	knownComponents = make(map[string]struct{})
+)
+
+// RegisterComponentOwner records that owner, a team slug, owns component.
+// Errors from the component that don't set an owner with WithOwner are
+// attributed to it (see GetOwner), and owner becomes a permitted value of
+// the "owner" metric label (see MetricLabels). A later registration for
+// the same component replaces the earlier one.
+//
+// Example:
+//
+//	errors.RegisterComponentOwner("enricher", "data-platform")
+//	errors.RegisterComponentOwner("billing", "payments")
+func RegisterComponentOwner(component, owner string) {
+	ownersMu.Lock()
+	defer ownersMu.Unlock()
+	componentOwners[component] = owner
+	knownComponents[component] = struct{}{}
+}
+
+// RegisterComponents declares components the service uses, so
+// VerifyOwners can report ones nobody owns.
+func RegisterComponents(components ...string) {
+	ownersMu.Lock()
+	defer ownersMu.Unlock()
+	for _, component := range components {
+		knownComponents[component] = struct{}{}
+	}
+}
+
+// ResetComponentOwners removes all registered components and owners.
+// Intended for tests.
+func ResetComponentOwners() {
+	ownersMu.Lock()
+	defer ownersMu.Unlock()
+	componentOwners = make(map[string]string)
+	knownComponents = make(map[string]struct{})
+}
+
+// VerifyOwners reports components registered with RegisterComponents or
+// RegisterComponentOwner that have no owner, suitable for a startup check
+// or a test. Returns nil when every component is owned; otherwise each
+// problem is a ValidationError whose Field is the component.
+//
+// Example:
+//
+//	func TestEveryComponentHasAnOwner(t *testing.T) {
+//	    if err := errors.VerifyOwners(); err != nil {
+//	        t.Fatal(err)
+//	    }
+//	}
+func VerifyOwners() error {
+	ownersMu.RLock()
+	components := make([]string, 0, len(knownComponents))
+	for component := range knownComponents {
+		if componentOwners[component] == "" {
+			components = append(components, component)
+		}
+	}
+	ownersMu.RUnlock()
+
+	sort.Strings(components)
+	var problems []error
+	for _, component := range components {
+		problems = append(problems, NewValidationError("registered component has no owner", component))
+	}
+	return errors.Join(problems...)
+}
+
+// GetOwner returns the team that owns err: the outermost typed error in
+// its chain with an owner set by WithOwner or a component registered with
+// RegisterComponentOwner. Returns "" if nobody owns it.
+//
+// Example:
+//
+//	if owner := errors.GetOwner(err); owner != "" {
+//	    alert.Route(owner)
+//	}
+func GetOwner(err error) string {
+	var owner string
+	walkChain(err, func(node error, _ int) bool {
+		if field := ownerField(node); field != nil && *field != "" {
+			owner = *field
+		} else if component := componentOf(node); component != "" {
+			owner = componentOwner(component)
+		}
+		return owner == ""
+	})
+	return owner
+}
+
+// componentOwner returns the owner registered for component.
+func componentOwner(component string) string {
+	ownersMu.RLock()
+	defer ownersMu.RUnlock()
+	return componentOwners[component]
+}
+
+// isRegisteredOwner reports whether owner was registered for some
+// component, making it a permitted metric label value.
+func isRegisteredOwner(owner string) bool {
+	ownersMu.RLock()
+	defer ownersMu.RUnlock()
+	for _, registered := range componentOwners {
+		if registered == owner {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,92 @@
+package errors
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+// TestGetOwner tests resolving owners from errors and the component table
+func TestGetOwner(t *testing.T) {
+	t.Cleanup(ResetComponentOwners)
+	RegisterComponentOwner("enricher", "data-platform")
+	RegisterComponentOwner("billing", "payments")
+
+	tests := []struct {
+		name string
+		err  error
+		want string
+	}{
+		{"explicit owner", NewProcessingError("failed", "Enrich", WithOwner("search")), "search"},
+		{"explicit owner beats component", NewProcessingError("failed", "Enrich", WithComponent("enricher"), WithOwner("search")), "search"},
+		{"inherited from component", NewTimeoutError("slow", "Enrich", 0, WithComponent("enricher")), "data-platform"},
+		{"wrapped", fmt.Errorf("charging: %w", NewHTTPError(502, "Bad Gateway", nil, WithComponent("billing"))), "payments"},
+		{"outermost wins", NewHTTPError(500, "Internal", NewNetworkError("reset", "Charge", WithComponent("billing")), WithOwner("edge")), "edge"},
+		{"owner from cause", NewHTTPError(500, "Internal", NewNetworkError("reset", "Charge", WithComponent("billing"))), "payments"},
+		{"unregistered component", NewProcessingError("failed", "Curate", WithComponent("curator")), ""},
+		{"untyped", fmt.Errorf("plain"), ""},
+		{"nil", nil, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := GetOwner(tt.err); got != tt.want {
+				t.Errorf("GetOwner() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+// TestOwnerLabel tests that only registered owners become metric labels
+func TestOwnerLabel(t *testing.T) {
+	t.Cleanup(ResetComponentOwners)
+	RegisterComponentOwner("billing", "payments")
+
+	tests := []struct {
+		name string
+		err  error
+		want string
+	}{
+		{"inherited", NewProcessingError("failed", "Charge", WithComponent("billing")), "payments"},
+		{"explicit registered owner", NewProcessingError("failed", "Refund", WithOwner("payments")), "payments"},
+		{"explicit unregistered owner", NewProcessingError("failed", "Refund", WithOwner("order-#4512")), ""},
+		{"no owner", NewProcessingError("failed", "Refund"), ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := MetricLabels(tt.err)[LabelOwner]; got != tt.want {
+				t.Errorf("owner label = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+// TestVerifyOwners tests reporting registered components without an owner
+func TestVerifyOwners(t *testing.T) {
+	t.Cleanup(ResetComponentOwners)
+	RegisterComponents("enricher", "curator", "billing")
+	RegisterComponentOwner("billing", "payments")
+
+	err := VerifyOwners()
+	if err == nil {
+		t.Fatal("VerifyOwners() = nil, want problems")
+	}
+	for _, component := range []string{"enricher", "curator"} {
+		if !strings.Contains(err.Error(), component) {
+			t.Errorf("VerifyOwners() = %q, want it to mention %q", err, component)
+		}
+	}
+	if strings.Contains(err.Error(), "billing") {
+		t.Errorf("VerifyOwners() = %q, want owned components left out", err)
+	}
+	if !IsValidation(err) {
+		t.Errorf("VerifyOwners() problems should be ValidationErrors, got %T", err)
+	}
+
+	RegisterComponentOwner("enricher", "data-platform")
+	RegisterComponentOwner("curator", "data-platform")
+	if err := VerifyOwners(); err != nil {
+		t.Errorf("VerifyOwners() = %v, want nil once every component is owned", err)
+	}
+}
@@ -0,0 +1,168 @@
+package errors
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"runtime"
+	"strings"
+
+	"github.com/cockroachdb/errors"
+	"github.com/cockroachdb/errors/errbase"
+)
+
+// maxPanicFrames caps the stack recorded for a panic.
+const maxPanicFrames = 64
+
+// PanicError is a recovered panic. Its stack trace is the panicking
+// goroutine's stack from the panic site, not from where it was recovered,
+// so GetOriginStackTrace and grouping point at the bug. When the panic
+// value is an error, errors.Is and errors.As see it. Reported as
+// SeverityCritical.
+type PanicError struct {
+	Value     any // the value passed to panic
+	Operation string
+	Component string
+	Code      string
+	Owner     string
+	Metadata  map[string]any
+
+	state errorState
+}
+
+func (e *PanicError) Error() string {
+	if e == nil {
+		return "<nil PanicError>"
+	}
+	return e.formatWithCause(formatCause(e.causeError()))
+}
+
+func (e *PanicError) formatWithCause(cause string) string {
+	if e == nil {
+		return "<nil PanicError>"
+	}
+	value := cause
+	if value == "" {
+		value = fmt.Sprint(e.Value)
+	}
+	return joinDetails(joinWords("panic", clause("in", opLabel(e.Component, e.Operation))), value)
+}
+
+func (e *PanicError) causeError() error {
+	if e == nil {
+		return nil
+	}
+	err, _ := e.Value.(error)
+	return err
+}
+
+// Unwrap returns the panic value when it is an error.
+func (e *PanicError) Unwrap() error {
+	if e == nil {
+		return nil
+	}
+	return e.causeError()
+}
+
+// StackTrace returns the stack from the panic site. It implements
+// the cockroachdb/errors stack trace provider interface.
+func (e *PanicError) StackTrace() errbase.StackTrace {
+	if e == nil {
+		return nil
+	}
+	return e.state.stackTrace()
+}
+
+// NewPanicError creates a PanicError for a value returned by recover().
+// Called from a deferred function while the goroutine is panicking, the
+// stack trace starts at the panic site; otherwise it starts at the caller.
+// Most code should defer Recover instead.
+//
+// Example:
+//
+//	defer func() {
+//	    if r := recover(); r != nil {
+//	        jobs.Fail(job, errors.NewPanicError(r, errors.WithOperation("ProcessJob")))
+//	    }
+//	}()
+func NewPanicError(value any, opts ...Option) *PanicError {
+	err := &PanicError{Value: value}
+	err.state.stack = panicStack()
+	applyOptions(err, opts)
+	return err
+}
+
+// IsPanic checks if err is a PanicError and returns it.
+func IsPanic(err error) (*PanicError, bool) {
+	var panicErr *PanicError
+	if errors.As(err, &panicErr) && panicErr != nil {
+		return panicErr, true
+	}
+	return nil, false
+}
+
+func isPanic(err error) bool {
+	_, ok := IsPanic(err)
+	return ok
+}
+
+// Recover converts a panic into a PanicError stored in *errp and reported
+// to the registered hooks (see Report), with the context from WithContext
+// if given. It must be deferred directly. Without a panic, *errp is left
+// as is; a panic replaces any error already there. http.ErrAbortHandler is
+// re-panicked, so net/http still aborts the response quietly.
+//
+// Example:
+//
+//	func (w *Worker) ProcessJob(ctx context.Context, job Job) (err error) {
+//	    defer errors.Recover(&err, errors.WithOperation("ProcessJob"), errors.WithContext(ctx))
+//	    ...
+//	}
+func Recover(errp *error, opts ...Option) {
+	r := recover()
+	if r == nil {
+		return
+	}
+	if r == http.ErrAbortHandler {
+		// net/http recovers this sentinel by identity to abort quietly
+		panic(r)
+	}
+
+	err := NewPanicError(r, opts...)
+	if errp != nil {
+		*errp = err
+	}
+
+	cfg := &constructConfig{}
+	for _, opt := range opts {
+		opt(cfg)
+	}
+	ctx := cfg.ctx
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	Report(ctx, err)
+}
+
+// panicStack returns the current goroutine's stack starting at the frame
+// that panicked, skipping the runtime's panic machinery. When the goroutine
+// isn't panicking it starts at the caller of panicStack's caller.
+func panicStack() []uintptr {
+	pcs := make([]uintptr, maxPanicFrames)
+	pcs = pcs[:runtime.Callers(3, pcs)]
+
+	inPanic := false
+	for i, pc := range pcs {
+		var name string
+		if fn := runtime.FuncForPC(pc - 1); fn != nil {
+			name = fn.Name()
+		}
+		switch {
+		case name == "runtime.gopanic":
+			inPanic = true
+		case inPanic && !strings.HasPrefix(name, "runtime."):
+			return pcs[i:]
+		}
+	}
+	return pcs
+}
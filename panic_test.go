@@ -0,0 +1,101 @@
+package errors
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+var errPanicValue = New("invariant violated")
+
+// panicking runs fn behind Recover and returns the error it produced
+func panicking(fn func(), prior error, opts ...Option) (err error) {
+	err = prior
+	defer Recover(&err, opts...)
+	fn()
+	return err
+}
+
+// writeNilMap panics with a runtime error from inside this function
+func writeNilMap() {
+	var m map[string]int
+	m["boom"] = 1
+}
+
+// TestRecover tests converting panics into PanicErrors at a boundary
+func TestRecover(t *testing.T) {
+	tests := []struct {
+		name      string
+		fn        func()
+		wantValue string
+		wantIs    error
+	}{
+		{name: "error value", fn: func() { panic(errPanicValue) }, wantValue: "invariant violated", wantIs: errPanicValue},
+		{name: "string value", fn: func() { panic("unreachable state") }, wantValue: "unreachable state"},
+		{name: "nil map write", fn: writeNilMap, wantValue: "assignment to entry in nil map"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			defer ResetHooks()
+			var reported error
+			RegisterHook(func(_ context.Context, err error) { reported = err }, HookFilter{MinSeverity: SeverityCritical})
+
+			err := panicking(tt.fn, nil, WithOperation("ProcessJob"))
+
+			panicErr, ok := IsPanic(err)
+			if !ok {
+				t.Fatalf("err = %v, want a PanicError", err)
+			}
+			if !strings.HasPrefix(err.Error(), "panic in ProcessJob: ") || !strings.Contains(err.Error(), tt.wantValue) {
+				t.Errorf("Error() = %q, want the operation and %q", err.Error(), tt.wantValue)
+			}
+			if tt.wantIs != nil && !Is(err, tt.wantIs) {
+				t.Errorf("errors.Is(err, %v) = false", tt.wantIs)
+			}
+			if reported != panicErr {
+				t.Errorf("reported = %v, want the PanicError at critical severity", reported)
+			}
+			if GetSeverity(err) != SeverityCritical {
+				t.Errorf("GetSeverity() = %v, want critical", GetSeverity(err))
+			}
+		})
+	}
+}
+
+// TestRecoverStack tests that the recorded stack starts at the panic site
+func TestRecoverStack(t *testing.T) {
+	err := panicking(writeNilMap, nil)
+
+	trace := GetOriginStackTrace(err)
+	first, _, _ := strings.Cut(trace, "\n")
+	if !strings.HasSuffix(first, ".writeNilMap") {
+		t.Errorf("stack starts at %q, want writeNilMap\n%s", first, trace)
+	}
+	if strings.Contains(trace, "runtime.gopanic") || strings.Contains(trace, ".Recover") {
+		t.Errorf("stack includes panic machinery or the defer:\n%s", trace)
+	}
+}
+
+// TestRecoverWithoutPanic tests that Recover leaves the result alone when nothing panics
+func TestRecoverWithoutPanic(t *testing.T) {
+	prior := NewValidationError("Invalid email", "email")
+	if err := panicking(func() {}, prior); err != prior {
+		t.Errorf("err = %v, want the pre-existing error", err)
+	}
+	if err := panicking(func() {}, nil); err != nil {
+		t.Errorf("err = %v, want nil", err)
+	}
+}
+
+// TestRecoverAbortHandler tests that http.ErrAbortHandler keeps propagating
+func TestRecoverAbortHandler(t *testing.T) {
+	defer func() {
+		if r := recover(); r != http.ErrAbortHandler {
+			t.Errorf("recovered %v, want http.ErrAbortHandler", r)
+		}
+	}()
+	_ = panicking(func() { panic(http.ErrAbortHandler) }, nil)
+	t.Error("Recover swallowed http.ErrAbortHandler")
+}
@@ -0,0 +1,191 @@
+package errors
+
+import (
+	"encoding/json"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// MetadataParams is the metadata key WithParams stores its snapshot under.
+const MetadataParams = "params"
+
+// Limits applied by WithParams.
+const (
+	maxParams     = 20
+	maxParamValue = 256
+)
+
+// defaultSensitiveFields are matched against parameter keys, ignoring case,
+// "-" and "_", as substrings, so "X-Api-Key" and "new_password" match too.
+var defaultSensitiveFields = []string{
+	"password", "passwd", "secret", "token", "apikey", "authorization",
+	"cookie", "session", "credential", "privatekey", "ssn", "cardnumber", "cvv",
+}
+
+var (
+	paramsMu        sync.RWMutex
+	sensitiveFields = append([]string(nil), defaultSensitiveFields...)
+	paramAllowList  map[string]bool
+)
+
+// RegisterSensitiveField adds names to the sensitive-field list. Parameters
+// whose key contains one of them, ignoring case, "-" and "_", are dropped
+// by WithParams whatever the allow list says.
+//
+// Example:
+//
+//	errors.RegisterSensitiveField("otp", "iban")
+func RegisterSensitiveField(names ...string) {
+	paramsMu.Lock()
+	defer paramsMu.Unlock()
+	for _, name := range names {
+		sensitiveFields = append(sensitiveFields, normalizeParamKey(name))
+	}
+}
+
+// RegisterParamAllowList restricts WithParams to the given keys, matched
+// exactly. Without an allow list, every key not on the sensitive-field list
+// is kept.
+//
+// Example:
+//
+//	errors.RegisterParamAllowList("limit", "offset", "sort", "tenant")
+func RegisterParamAllowList(keys ...string) {
+	paramsMu.Lock()
+	defer paramsMu.Unlock()
+	if paramAllowList == nil {
+		paramAllowList = make(map[string]bool, len(keys))
+	}
+	for _, key := range keys {
+		paramAllowList[key] = true
+	}
+}
+
+// ResetParamFilters restores the default sensitive-field list and removes
+// the allow list. Intended for tests.
+func ResetParamFilters() {
+	paramsMu.Lock()
+	defer paramsMu.Unlock()
+	sensitiveFields = append([]string(nil), defaultSensitiveFields...)
+	paramAllowList = nil
+}
+
+// WithParams attaches a snapshot of the request parameters that led to the
+// error, under the "params" metadata key. Keys on the sensitive-field list
+// (see RegisterSensitiveField) and keys missing from the allow list (see
+// RegisterParamAllowList) are dropped entirely, never masked. At most 20
+// keys are kept, in sorted order, and each value is sanitized (see
+// SanitizeValue) and capped at 256 bytes. The snapshot shows up in
+// ExtractErrorInfo, DebugString and Sentry events, but never in Error(),
+// problem details or GetSafeDetails. Applies to all error types that have a
+// Metadata field.
+//
+// Example:
+//
+//	err := NewValidationError("limit must be at most 100", "limit",
+//	    WithParams(map[string]any{"limit": r.URL.Query().Get("limit"), "sort": sort}))
+func WithParams(params map[string]any) Option {
+	snapshot := paramSnapshot(params)
+	return func(err any) {
+		if snapshot != nil {
+			setMetadata(err, MetadataParams, snapshot)
+		}
+	}
+}
+
+// paramSnapshot filters, bounds and sanitizes params for WithParams.
+// Returns nil if nothing is left.
+func paramSnapshot(params map[string]any) map[string]any {
+	paramsMu.RLock()
+	defer paramsMu.RUnlock()
+
+	keys := make([]string, 0, len(params))
+	for key := range params {
+		if allowedParam(key) {
+			keys = append(keys, key)
+		}
+	}
+	if len(keys) == 0 {
+		return nil
+	}
+	sort.Strings(keys)
+
+	snapshot := make(map[string]any, min(len(keys), maxParams))
+	for _, key := range keys[:min(len(keys), maxParams)] {
+		snapshot[key] = capParamValue(params[key])
+	}
+	return snapshot
+}
+
+// allowedParam reports whether key passes the allow list and the
+// sensitive-field list. Callers hold paramsMu.
+func allowedParam(key string) bool {
+	if paramAllowList != nil && !paramAllowList[key] {
+		return false
+	}
+	return !sensitiveParam(key)
+}
+
+// sensitiveField reports whether name is on the sensitive-field list.
+func sensitiveField(name string) bool {
+	paramsMu.RLock()
+	defer paramsMu.RUnlock()
+	return sensitiveParam(name)
+}
+
+// sensitiveParam reports whether key is on the sensitive-field list.
+// Callers hold paramsMu.
+func sensitiveParam(key string) bool {
+	normalized := normalizeParamKey(key)
+	for _, field := range sensitiveFields {
+		if strings.Contains(normalized, field) {
+			return true
+		}
+	}
+	return false
+}
+
+func normalizeParamKey(key string) string {
+	return strings.ToLower(strings.NewReplacer("-", "", "_", "").Replace(key))
+}
+
+// capParamValue sanitizes v, keeping scalars as they are and rendering
+// anything else as JSON, with sensitive keys of nested objects dropped,
+// capped at maxParamValue bytes. Callers hold paramsMu.
+func capParamValue(v any) any {
+	switch sanitized := SanitizeValue(v).(type) {
+	case nil, bool, int, int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64, float32, float64:
+		return sanitized
+	case string:
+		return truncateString(sanitized, maxParamValue)
+	default:
+		data, err := json.Marshal(dropSensitive(sanitized))
+		if err != nil {
+			return placeholder(nil)
+		}
+		return truncateString(string(data), maxParamValue)
+	}
+}
+
+// dropSensitive removes keys on the sensitive-field list from the objects
+// in a sanitized value. Callers hold paramsMu.
+func dropSensitive(v any) any {
+	switch t := v.(type) {
+	case map[string]any:
+		kept := make(map[string]any, len(t))
+		for key, value := range t {
+			if !sensitiveParam(key) {
+				kept[key] = dropSensitive(value)
+			}
+		}
+		return kept
+	case []any:
+		kept := make([]any, len(t))
+		for i, value := range t {
+			kept[i] = dropSensitive(value)
+		}
+		return kept
+	}
+	return v
+}
@@ -0,0 +1,134 @@
+package errors
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// TestWithParams tests the filtering and bounds applied to parameter snapshots
+func TestWithParams(t *testing.T) {
+	tests := []struct {
+		name      string
+		allow     []string
+		params    map[string]any
+		wantKeys  []string
+		wantValue map[string]any
+	}{
+		{
+			name:      "keeps plain parameters",
+			params:    map[string]any{"limit": 500, "sort": "desc"},
+			wantKeys:  []string{"limit", "sort"},
+			wantValue: map[string]any{"limit": 500, "sort": "desc"},
+		},
+		{
+			name:     "drops sensitive keys in any spelling",
+			params:   map[string]any{"limit": 1, "Password": "x", "X-Api-Key": "x", "refresh_token": "x"},
+			wantKeys: []string{"limit"},
+		},
+		{
+			name:     "allow list",
+			allow:    []string{"limit"},
+			params:   map[string]any{"limit": 1, "tenant": "acme"},
+			wantKeys: []string{"limit"},
+		},
+		{
+			name:      "caps values",
+			params:    map[string]any{"q": strings.Repeat("a", 1000)},
+			wantKeys:  []string{"q"},
+			wantValue: map[string]any{"q": strings.Repeat("a", maxParamValue) + truncatedCause},
+		},
+		{
+			name:     "caps count",
+			params:   manyParams(maxParams + 5),
+			wantKeys: sortedParamKeys(maxParams),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			defer ResetParamFilters()
+			if tt.allow != nil {
+				RegisterParamAllowList(tt.allow...)
+			}
+
+			err := NewValidationError("limit must be at most 100", "limit", WithParams(tt.params))
+			got, _ := GetMetadata(err, MetadataParams)
+			snapshot, _ := got.(map[string]any)
+			if len(snapshot) != len(tt.wantKeys) {
+				t.Fatalf("snapshot = %v, want keys %v", snapshot, tt.wantKeys)
+			}
+			for _, key := range tt.wantKeys {
+				if _, ok := snapshot[key]; !ok {
+					t.Errorf("snapshot = %v, missing %q", snapshot, key)
+				}
+			}
+			for key, want := range tt.wantValue {
+				if fmt.Sprint(snapshot[key]) != fmt.Sprint(want) {
+					t.Errorf("snapshot[%q] = %v, want %v", key, snapshot[key], want)
+				}
+			}
+		})
+	}
+}
+
+// TestWithParamsNeverLeaksSensitiveKeys tests that a sensitive parameter is absent from every rendering, even when allow-listed
+func TestWithParamsNeverLeaksSensitiveKeys(t *testing.T) {
+	defer ResetParamFilters()
+	RegisterParamAllowList("password", "limit", "user")
+
+	err := Wrap(NewValidationError("limit must be at most 100", "limit", WithParams(map[string]any{
+		"password": "hunter2",
+		"limit":    500,
+		"user":     map[string]any{"name": "ada", "password": "hunter2"},
+	})), "listing orders")
+
+	info, _ := json.Marshal(ExtractErrorInfo(err))
+	envelope, _ := MarshalError(err)
+	problem := httptest.NewRecorder()
+	WriteProblem(problem, err)
+
+	renderings := map[string]string{
+		"Error":            err.Error(),
+		"%+v":              fmt.Sprintf("%+v", err),
+		"FormatError":      FormatError(err),
+		"DebugString":      DebugString(err),
+		"GetSafeDetails":   GetSafeDetails(err),
+		"ExtractErrorInfo": string(info),
+		"LogValue":         LogValue(err).String(),
+		"envelope":         string(envelope),
+		"problem":          problem.Body.String(),
+	}
+	for name, rendering := range renderings {
+		if strings.Contains(rendering, "password") || strings.Contains(rendering, "hunter2") {
+			t.Errorf("%s leaks the password: %s", name, rendering)
+		}
+	}
+
+	if !strings.Contains(renderings["DebugString"], "limit:500") || !strings.Contains(renderings["ExtractErrorInfo"], "ada") {
+		t.Errorf("params missing from debug output: %s / %s", renderings["DebugString"], renderings["ExtractErrorInfo"])
+	}
+	for _, name := range []string{"Error", "problem", "GetSafeDetails"} {
+		if strings.Contains(renderings[name], "ada") || strings.Contains(renderings[name], "500") {
+			t.Errorf("%s exposes params: %s", name, renderings[name])
+		}
+	}
+}
+
+func manyParams(n int) map[string]any {
+	params := make(map[string]any, n)
+	for i := range n {
+		params[fmt.Sprintf("p%02d", i)] = i
+	}
+	return params
+}
+
+func sortedParamKeys(n int) []string {
+	keys := make([]string, n)
+	for i := range keys {
+		keys[i] = fmt.Sprintf("p%02d", i)
+	}
+	return keys
+}
@@ -0,0 +1,290 @@
+package errors
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"time"
+)
+
+// ProblemContentType is the media type for RFC 7807 problem details.
+const ProblemContentType = "application/problem+json"
+
+// ProblemDetails is an RFC 7807 problem details object.
+// Extensions are serialized as top-level members alongside the standard
+// ones.
+type ProblemDetails struct {
+	Type       string
+	Title      string
+	Status     int
+	Detail     string
+	Instance   string
+	Extensions map[string]any
+}
+
+// MarshalJSON renders the problem with its extensions as top-level members.
+// Standard members take precedence over extensions with the same name.
+// Extension values are passed through SanitizeValue.
+func (p *ProblemDetails) MarshalJSON() ([]byte, error) {
+	members := make(map[string]any, len(p.Extensions)+5)
+	for k, v := range p.Extensions {
+		members[k] = SanitizeValue(v)
+	}
+
+	members[ProblemType] = p.Type
+	members[ProblemTitle] = p.Title
+	members[ProblemStatus] = p.Status
+	if p.Detail != "" {
+		members[ProblemDetail] = p.Detail
+	}
+	if p.Instance != "" {
+		members[ProblemInstance] = p.Instance
+	}
+	return json.Marshal(members)
+}
+
+// ToProblemDetails converts err to problem details. The status comes from
+// HTTPStatus. Detail carries the message of the outermost typed error for
+// client errors only; server errors get just the status title so internal
+// causes don't leak. Extensions (see SchemaExport) carry the opaque
+// ReferenceCode as "reference", the error code as "code", the invalid
+// field and value of a client error as "field" and "value" (the value is
+// sanitized, and left out for fields on the sensitive-field list, see
+// RegisterSensitiveField), the retry-after hint in seconds as
+// "retry_after", a CircuitBreakerError's "state" and "counts", and
+// "feature" and "available_from" for a
+// NotImplementedError or "unsupported" and "alternative" for an
+// UnsupportedError, so clients can tell "coming later" from "never"
+// without matching messages; fingerprints, origin keys and stack traces
+// never appear.
+func ToProblemDetails(err error) *ProblemDetails {
+	status := HTTPStatus(err)
+	problem := &ProblemDetails{
+		Type:   "about:blank",
+		Title:  http.StatusText(status),
+		Status: status,
+	}
+
+	extensions := make(map[string]any)
+	if status < http.StatusInternalServerError {
+		problem.Detail = typedMessage(err)
+
+		var validationErr *ValidationError
+		if As(err, &validationErr) && validationErr.Field != "" {
+			extensions[ProblemField] = validationErr.Field
+			if validationErr.Value != nil && !sensitiveField(validationErr.Field) {
+				value, stringEncoded := encodeValue(validationErr.Value)
+				extensions[ProblemValue] = value
+				if stringEncoded {
+					extensions[ProblemValueStringEncoded] = true
+				}
+			}
+		}
+	}
+	var cbErr *CircuitBreakerError
+	if As(err, &cbErr) && cbErr != nil && cbErr.State != "" {
+		extensions[ProblemState] = cbErr.State
+		if cbErr.Counts != (CircuitCounts{}) {
+			extensions[ProblemCounts] = cbErr.Counts
+		}
+	}
+	featureExtensions(err, extensions)
+	if code := ReferenceCode(err); code != "" {
+		extensions[ProblemReference] = code
+	}
+	if code := GetCode(err); code != "" {
+		extensions[ProblemCode] = code
+	}
+	if wait, ok := GetRetryAfter(err); ok {
+		extensions[ProblemRetryAfter] = ceilSeconds(wait)
+	}
+	if len(extensions) > 0 {
+		problem.Extensions = extensions
+	}
+	return problem
+}
+
+// featureExtensions adds the members describing a NotImplementedError or
+// UnsupportedError in err's chain to extensions.
+func featureExtensions(err error, extensions map[string]any) {
+	var notImplErr *NotImplementedError
+	if As(err, &notImplErr) && notImplErr != nil {
+		extensions[ProblemFeature] = notImplErr.Feature
+		if !notImplErr.AvailableFrom.IsZero() {
+			extensions[ProblemAvailableFrom] = notImplErr.AvailableFrom.Format(time.RFC3339)
+		}
+	}
+	var unsupportedErr *UnsupportedError
+	if As(err, &unsupportedErr) && unsupportedErr != nil {
+		extensions[ProblemUnsupported] = unsupportedErr.What
+		if unsupportedErr.Alternative != "" {
+			extensions[ProblemAlternative] = unsupportedErr.Alternative
+		}
+	}
+}
+
+// UserMessage returns a message that is safe to show end users, ending with
+// the error's ReferenceCode. Client errors use the outermost typed error's
+// message, falling back to the status text; server errors always get a
+// generic message so internal causes don't leak. Returns "" for a nil
+// error.
+//
+// Example:
+//
+//	errors.UserMessage(NewValidationError("Email is invalid", "email"))
+//	// "Email is invalid (ref: 4F7K-2QXA)"
+//	errors.UserMessage(dbErr)
+//	// "Something went wrong. Please try again later. (ref: E7K2-9QXM)"
+func UserMessage(err error) string {
+	if err == nil {
+		return ""
+	}
+
+	return clientMessage(err, HTTPStatus(err)) + " (ref: " + ReferenceCode(err) + ")"
+}
+
+// serverErrorMessage is shown to clients in place of any 5xx error's own
+// message.
+const serverErrorMessage = "Something went wrong. Please try again later."
+
+// clientMessage returns the message UserMessage and WriteError show for err
+// with the given status: the outermost typed error's message for client
+// errors, falling back to the status text, and a generic message for server
+// errors.
+func clientMessage(err error, status int) string {
+	if status >= http.StatusInternalServerError {
+		return serverErrorMessage
+	}
+	if message := typedMessage(err); message != "" {
+		return message
+	}
+	return http.StatusText(status)
+}
+
+// WriteProblem writes err to w as an application/problem+json response,
+// with Retry-After and RateLimit-* headers when err carries them (see
+// RetryAfterHeader).
+//
+// Example:
+//
+//	if err := svc.CreateUser(r.Context(), req); err != nil {
+//	    errors.WriteProblem(w, err)
+//	    return
+//	}
+func WriteProblem(w http.ResponseWriter, err error) {
+	writeProblem(w, err, ToProblemDetails(err))
+}
+
+// WriteProblemCtx is like WriteProblem but also includes any warnings
+// collected on ctx (see CollectWarnings) under a "warnings" member.
+func WriteProblemCtx(ctx context.Context, w http.ResponseWriter, err error) {
+	problem := ToProblemDetails(err)
+	if warnings := WarningsFromContext(ctx).Info(); warnings != nil {
+		if problem.Extensions == nil {
+			problem.Extensions = make(map[string]any)
+		}
+		problem.Extensions[ProblemWarnings] = warnings
+	}
+	writeProblem(w, err, problem)
+}
+
+// ErrorResponse is the JSON body written by WriteError.
+type ErrorResponse struct {
+	Type      string `json:"type"`                // outermost typed error's type, or "Error"
+	Message   string `json:"message"`             // safe to show; generic for 5xx responses
+	Code      string `json:"code,omitempty"`      // error code (see WithCode)
+	Reference string `json:"reference,omitempty"` // see ReferenceCode
+}
+
+// WriteError writes err to w as a plain application/json response for
+// services that don't speak problem details. The status comes from
+// HTTPStatus: an HTTPError's StatusCode, 400 for validation errors, 404
+// for not found, 429 for rate limits, 504 for timeouts, 500 for anything
+// else, and any mapping registered with RegisterHTTPStatus or a
+// MappingTable. Retry-After and RateLimit-* headers are set when err
+// carries them (see RetryAfterHeader). The body is an ErrorResponse whose
+// message is the typed error's own for client errors only: 5xx responses
+// never include cause or message text.
+//
+// Example:
+//
+//	if err := svc.PlaceOrder(r.Context(), req); err != nil {
+//	    errors.WriteError(w, err)
+//	    return
+//	}
+//	// HTTP/1.1 429 Too Many Requests
+//	// Retry-After: 30
+//	// {"type":"RateLimitError","message":"Too many orders","reference":"4F7K-2QXA"}
+func WriteError(w http.ResponseWriter, err error) {
+	status := HTTPStatus(err)
+	body, _ := json.Marshal(ErrorResponse{
+		Type:      signatureType(err),
+		Message:   clientMessage(err, status),
+		Code:      GetCode(err),
+		Reference: ReferenceCode(err),
+	})
+
+	w.Header().Set("Content-Type", "application/json")
+	RetryAfterHeader(w.Header(), err)
+	w.WriteHeader(status)
+	_, _ = w.Write(body)
+}
+
+func writeProblem(w http.ResponseWriter, err error, problem *ProblemDetails) {
+	body, marshalErr := json.Marshal(problem)
+	if marshalErr != nil {
+		// Extensions hold arbitrary values; fall back to the standard members.
+		body, _ = json.Marshal(&ProblemDetails{Type: problem.Type, Title: problem.Title, Status: problem.Status})
+	}
+
+	w.Header().Set("Content-Type", ProblemContentType)
+	RetryAfterHeader(w.Header(), err)
+	w.WriteHeader(problem.Status)
+	_, _ = w.Write(body)
+}
+
+// typedMessage returns the Message of the outermost typed error in err's
+// chain, or "" if there is none.
+func typedMessage(err error) string {
+	message := ""
+	walkChain(err, func(node error, _ int) bool {
+		switch e := node.(type) {
+		case *HTTPError:
+			message = e.Message
+		case *ValidationError:
+			message = e.Message
+		case *TimeoutError:
+			message = e.Message
+		case retryHintHolder:
+			message = e.retryHint().Message
+		case *ProcessingError:
+			message = e.Message
+		case *NetworkError:
+			message = e.Message
+		case *SerializationError:
+			message = e.Message
+		case *CircuitBreakerError:
+			message = e.Message
+		case *ConsistencyError:
+			message = e.Message
+		case *NotFoundError:
+			message = e.Message
+		case *ConflictError:
+			message = e.Message
+		case *DatabaseError:
+			message = e.Message
+		case *ProviderError:
+			message = e.Message
+		case *ConfigError:
+			message = e.Message
+		case *NotImplementedError:
+			message = e.Message
+		case *UnsupportedError:
+			message = e.Message
+		case *RemoteError:
+			message = e.Message
+		}
+		return message == ""
+	})
+	return message
+}
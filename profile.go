@@ -0,0 +1,324 @@
+package errors
+
+import (
+	"encoding/json"
+	"net/http"
+	"runtime"
+	"sort"
+	"strconv"
+	"sync/atomic"
+)
+
+const (
+	// defaultProfileSampleEvery is how many errors an error profile sees
+	// for each one it records, unless WithSampleEvery says otherwise.
+	defaultProfileSampleEvery = 100
+
+	// profileSlots is how many call sites an error profile can hold. It is
+	// a power of two so a PC's hash can be masked into a slot index.
+	profileSlots = 1024
+
+	// profileProbes is how many slots a call site may probe for a free one
+	// before its samples are dropped.
+	profileProbes = 16
+
+	// profileTypes is how many error types are counted per call site;
+	// further types are counted as "other".
+	profileTypes = 4
+
+	// defaultProfileTop is how many call sites the profile handler lists
+	// without a top query parameter.
+	defaultProfileTop = 50
+)
+
+// profileClasses are the classes counted per call site, in slot order.
+var profileClasses = [...]ErrorClass{ClassTransient, ClassPermanent, ClassContext, ClassUnknown}
+
+var (
+	// profileEvery is the sample rate of the error profile; 0 while it is
+	// disabled, so the disabled path is a single atomic load.
+	profileEvery atomic.Int64
+	profileTick  atomic.Int64
+	profile      atomic.Pointer[profileTable]
+)
+
+// profileTable is a fixed-size open-addressing hash of call sites keyed by
+// PC. Slots are claimed with a compare-and-swap and never released, so
+// recording is lock-free and memory is bounded.
+type profileTable struct {
+	slots   [profileSlots]profileSlot
+	every   atomic.Int64 // sample rate last enabled, for scaling counts
+	dropped atomic.Int64
+}
+
+type profileSlot struct {
+	pc      atomic.Uintptr
+	samples atomic.Int64
+	classes [len(profileClasses)]atomic.Int64
+	types   [profileTypes]profileTypeCount
+	other   atomic.Int64
+}
+
+type profileTypeCount struct {
+	name    atomic.Pointer[string]
+	samples atomic.Int64
+}
+
+// ProfileSite is one call site in an error profile. Counts are estimates:
+// samples scaled by the sample rate.
+type ProfileSite struct {
+	Function string               `json:"function"`
+	File     string               `json:"file"`
+	Line     int                  `json:"line"`
+	Count    int64                `json:"count"`
+	Samples  int64                `json:"samples"`
+	Types    map[string]int64     `json:"types"`
+	Classes  map[ErrorClass]int64 `json:"classes"`
+}
+
+// ErrorProfileReport is a snapshot of the error profile.
+type ErrorProfileReport struct {
+	Enabled     bool          `json:"enabled"`
+	SampleEvery int64         `json:"sample_every"`
+	Dropped     int64         `json:"dropped"` // samples lost because every slot was taken
+	Sites       []ProfileSite `json:"sites"`   // most frequent first
+}
+
+// profileConfig collects the options of EnableErrorProfile.
+type profileConfig struct {
+	sampleEvery int
+}
+
+// EnableErrorProfile starts counting where errors are created: typed
+// constructors, Wrap and Wrapf record the call site that made the error,
+// with its type and class, for one error in every 100 (see
+// WithSampleEvery). Counts accumulate until ResetErrorProfile and are
+// kept while disabled. Up to 1024 call sites are tracked; samples from
+// further sites are counted as dropped. When disabled, the only cost at
+// each site is one atomic load.
+//
+// Example:
+//
+//	errors.EnableErrorProfile(errors.WithSampleEvery(1000))
+//	mux.Handle("/debug/errors", errors.ErrorProfileHandler())
+func EnableErrorProfile(opts ...Option) {
+	cfg := &profileConfig{sampleEvery: defaultProfileSampleEvery}
+	for _, opt := range opts {
+		opt(cfg)
+	}
+	every := int64(max(cfg.sampleEvery, 1))
+	if profile.Load() == nil {
+		profile.CompareAndSwap(nil, &profileTable{})
+	}
+	profile.Load().every.Store(every)
+	profileEvery.Store(every)
+}
+
+// DisableErrorProfile stops the error profile, keeping its counts.
+func DisableErrorProfile() {
+	profileEvery.Store(0)
+}
+
+// ResetErrorProfile disables the error profile and discards its counts.
+// Intended for tests.
+func ResetErrorProfile() {
+	DisableErrorProfile()
+	profile.Store(nil)
+	profileTick.Store(0)
+}
+
+// ErrorProfile returns the top call sites of the error profile, most
+// frequent first; all of them when top is zero or less.
+//
+// Example:
+//
+//	for _, site := range errors.ErrorProfile(10).Sites {
+//	    fmt.Printf("%8d %s (%s:%d) %v\n", site.Count, site.Function, site.File, site.Line, site.Classes)
+//	}
+func ErrorProfile(top int) ErrorProfileReport {
+	report := ErrorProfileReport{Enabled: profileEvery.Load() > 0, Sites: []ProfileSite{}}
+	table := profile.Load()
+	if table == nil {
+		return report
+	}
+	scale := table.every.Load()
+	report.SampleEvery = scale
+	report.Dropped = table.dropped.Load()
+
+	// A call site inlined into several callers has a PC per caller;
+	// merge them under the location they resolve to.
+	type location struct {
+		function, file string
+		line           int
+	}
+	index := make(map[location]int)
+	for i := range table.slots {
+		slot := &table.slots[i]
+		pc := slot.pc.Load()
+		samples := slot.samples.Load()
+		if pc == 0 || samples == 0 {
+			continue
+		}
+		frame, _ := runtime.CallersFrames([]uintptr{pc}).Next()
+		loc := location{frame.Function, frame.File, frame.Line}
+		at, ok := index[loc]
+		if !ok {
+			at = len(report.Sites)
+			index[loc] = at
+			report.Sites = append(report.Sites, ProfileSite{
+				Function: frame.Function,
+				File:     frame.File,
+				Line:     frame.Line,
+				Types:    make(map[string]int64),
+				Classes:  make(map[ErrorClass]int64),
+			})
+		}
+		site := &report.Sites[at]
+		site.Samples += samples
+		site.Count += samples * scale
+		for j := range slot.types {
+			if name := slot.types[j].name.Load(); name != nil {
+				site.Types[*name] += slot.types[j].samples.Load() * scale
+			}
+		}
+		if other := slot.other.Load(); other > 0 {
+			site.Types["other"] += other * scale
+		}
+		for j, class := range profileClasses {
+			if n := slot.classes[j].Load(); n > 0 {
+				site.Classes[class] += n * scale
+			}
+		}
+	}
+
+	sort.Slice(report.Sites, func(i, j int) bool {
+		a, b := report.Sites[i], report.Sites[j]
+		if a.Samples != b.Samples {
+			return a.Samples > b.Samples
+		}
+		return a.Function < b.Function || (a.Function == b.Function && a.Line < b.Line)
+	})
+	if top > 0 && len(report.Sites) > top {
+		report.Sites = report.Sites[:top]
+	}
+	return report
+}
+
+// ErrorProfileHandler returns an http.Handler rendering ErrorProfile as
+// JSON, in the manner of expvar, listing the top 50 call sites or as many
+// as the top query parameter asks for.
+//
+// Example:
+//
+//	mux.Handle("/debug/errors", errors.ErrorProfileHandler())
+//	// GET /debug/errors?top=5
+//	// {"enabled":true,"sample_every":100,"dropped":0,"sites":[{"function":"example.com/orders.(*Store).Get","count":184300,...}]}
+func ErrorProfileHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		top := defaultProfileTop
+		if raw := r.URL.Query().Get("top"); raw != "" {
+			n, err := strconv.Atoi(raw)
+			if err != nil {
+				WriteProblem(w, NewValidationError("top must be an integer", "top", WithValue(raw)))
+				return
+			}
+			top = n
+		}
+
+		body, err := json.Marshal(ErrorProfile(top))
+		if err != nil {
+			WriteProblem(w, NewEncodeError("encoding error profile", "ErrorProfile", "json", WithCause(err)))
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write(body)
+	})
+}
+
+// profileError records err's creation in the error profile when it is
+// enabled and err is sampled. The call site is the first frame outside
+// this package.
+func profileError(err error) {
+	every := profileEvery.Load()
+	if every == 0 || err == nil || profileTick.Add(1)%every != 0 {
+		return
+	}
+	table := profile.Load()
+	if table == nil {
+		return
+	}
+	pc := profileCallSite()
+	if pc == 0 {
+		return
+	}
+	slot := table.slot(pc)
+	if slot == nil {
+		table.dropped.Add(1)
+		return
+	}
+
+	slot.samples.Add(1)
+	class := Classify(err)
+	for i, c := range profileClasses {
+		if c == class {
+			slot.classes[i].Add(1)
+		}
+	}
+	slot.countType(signatureType(err))
+}
+
+// profileCallSite returns the PC of the first caller outside this package,
+// or 0 if the stack holds none.
+func profileCallSite() uintptr {
+	var pcs [maxStackFrames]uintptr
+	n := runtime.Callers(3, pcs[:])
+	for i := range n {
+		if !ownFrame(pcs[i : i+1]) {
+			return pcs[i]
+		}
+	}
+	return 0
+}
+
+// slot returns the slot for pc, claiming a free one if pc has none, or nil
+// when every slot pc may probe belongs to another call site.
+func (t *profileTable) slot(pc uintptr) *profileSlot {
+	// Fibonacci hashing spreads PCs, which share their high bits, across
+	// the table.
+	h := uint64(pc) * 0x9E3779B97F4A7C15
+	for i := range uint64(profileProbes) {
+		slot := &t.slots[(h>>54+i)%profileSlots]
+		current := slot.pc.Load()
+		if current == pc {
+			return slot
+		}
+		if current == 0 && slot.pc.CompareAndSwap(0, pc) {
+			return slot
+		}
+		if slot.pc.Load() == pc {
+			return slot
+		}
+	}
+	return nil
+}
+
+// countType counts one sample of typeName at the slot, in the first free
+// or matching type counter, or as "other" once they are all taken.
+func (s *profileSlot) countType(typeName string) {
+	for i := range s.types {
+		counter := &s.types[i]
+		name := counter.name.Load()
+		if name == nil {
+			if counter.name.CompareAndSwap(nil, &typeName) {
+				counter.samples.Add(1)
+				return
+			}
+			name = counter.name.Load()
+		}
+		if *name == typeName {
+			counter.samples.Add(1)
+			return
+		}
+	}
+	s.other.Add(1)
+}
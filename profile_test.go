@@ -0,0 +1,149 @@
+package errors
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// profiledTimeout and profiledWrap are the call sites the profile tests count.
+func profiledTimeout() error { return NewTimeoutError("too slow", "Quote", 0) }
+
+func profiledWrap(err error) error { return Wrap(err, "loading order") }
+
+// TestErrorProfile tests call site counts, type and class breakdowns and sampling
+func TestErrorProfile(t *testing.T) {
+	t.Cleanup(ResetErrorProfile)
+	profiledTimeout()
+	if report := ErrorProfile(0); report.Enabled || len(report.Sites) != 0 {
+		t.Fatalf("ErrorProfile() = %+v, want nothing recorded while disabled", report)
+	}
+
+	EnableErrorProfile(WithSampleEvery(1))
+	for range 30 {
+		profiledTimeout()
+	}
+	for range 10 {
+		profiledWrap(ErrActivityNotFound)
+		profiledWrap(NewValidationError("bad id", "id"))
+	}
+	profiledWrap(nil)
+
+	report := ErrorProfile(0)
+	if !report.Enabled || report.SampleEvery != 1 || report.Dropped != 0 {
+		t.Errorf("ErrorProfile() = %+v, want enabled at 1-in-1", report)
+	}
+	sites := make(map[string]ProfileSite)
+	for _, site := range report.Sites {
+		sites[site.Function[strings.LastIndex(site.Function, ".")+1:]] = site
+	}
+
+	timeout := sites["profiledTimeout"]
+	if timeout.Count != 30 || timeout.Types["TimeoutError"] != 30 || timeout.Classes[ClassTransient] != 30 ||
+		!strings.HasSuffix(timeout.File, "profile_test.go") || timeout.Line == 0 {
+		t.Errorf("profiledTimeout = %+v, want 30 transient TimeoutErrors", timeout)
+	}
+	wrap := sites["profiledWrap"]
+	if wrap.Count != 20 || wrap.Types["NotFoundError"] != 10 || wrap.Types["ValidationError"] != 10 ||
+		wrap.Classes[ClassPermanent] != 20 {
+		t.Errorf("profiledWrap = %+v, want 20 permanent errors of two types", wrap)
+	}
+	if report.Sites[0].Function != timeout.Function {
+		t.Errorf("top site = %s, want the most frequent first", report.Sites[0].Function)
+	}
+	if top := ErrorProfile(1); len(top.Sites) != 1 {
+		t.Errorf("ErrorProfile(1) listed %d sites, want 1", len(top.Sites))
+	}
+
+	ResetErrorProfile()
+	EnableErrorProfile(WithSampleEvery(10))
+	for range 100 {
+		profiledTimeout()
+	}
+	DisableErrorProfile()
+	profiledTimeout()
+	sampled := ErrorProfile(0)
+	if len(sampled.Sites) != 1 || sampled.Sites[0].Samples != 10 || sampled.Sites[0].Count != 100 {
+		t.Errorf("ErrorProfile() = %+v, want 10 samples scaled to 100, kept while disabled", sampled)
+	}
+}
+
+// TestErrorProfileConcurrent tests recording from many goroutines and the bound on call sites
+func TestErrorProfileConcurrent(t *testing.T) {
+	t.Cleanup(ResetErrorProfile)
+	EnableErrorProfile(WithSampleEvery(1))
+
+	var wg sync.WaitGroup
+	for range 8 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for range 1000 {
+				profiledTimeout()
+			}
+		}()
+	}
+	wg.Wait()
+	if sites := ErrorProfile(0).Sites; len(sites) != 1 || sites[0].Count != 8000 {
+		t.Errorf("ErrorProfile() = %+v, want all 8000 errors at one site", sites)
+	}
+
+	table := &profileTable{}
+	for pc := uintptr(1); pc <= 100*profileSlots; pc++ {
+		table.slot(pc)
+	}
+	if table.slot(1<<40) != nil {
+		t.Error("a full table should not claim another slot")
+	}
+}
+
+// TestErrorProfileHandler tests the JSON endpoint and its top parameter
+func TestErrorProfileHandler(t *testing.T) {
+	t.Cleanup(ResetErrorProfile)
+	EnableErrorProfile(WithSampleEvery(1))
+	profiledTimeout()
+	profiledWrap(ErrActivityNotFound)
+
+	rec := httptest.NewRecorder()
+	ErrorProfileHandler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/debug/errors?top=1", nil))
+	var report ErrorProfileReport
+	if err := json.Unmarshal(rec.Body.Bytes(), &report); err != nil {
+		t.Fatalf("decoding %s: %v", rec.Body, err)
+	}
+	if rec.Code != http.StatusOK || len(report.Sites) != 1 || report.Sites[0].Count != 1 {
+		t.Errorf("handler = %d %s, want one site", rec.Code, rec.Body)
+	}
+
+	rec = httptest.NewRecorder()
+	ErrorProfileHandler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/debug/errors?top=all", nil))
+	if rec.Code != http.StatusBadRequest {
+		t.Errorf("invalid top: status = %d, want 400", rec.Code)
+	}
+}
+
+// BenchmarkErrorProfile compares constructing errors with the profile disabled, sampling and recording every error
+func BenchmarkErrorProfile(b *testing.B) {
+	b.Cleanup(ResetErrorProfile)
+	for _, bench := range []struct {
+		name  string
+		every int // 0 disables the profile
+	}{
+		{"disabled", 0},
+		{"sampled", defaultProfileSampleEvery},
+		{"every", 1},
+	} {
+		b.Run(bench.name, func(b *testing.B) {
+			ResetErrorProfile()
+			if bench.every > 0 {
+				EnableErrorProfile(WithSampleEvery(bench.every))
+			}
+			b.ReportAllocs()
+			for range b.N {
+				fastSink = NewNetworkError("connection refused", "Reserve")
+			}
+		})
+	}
+}
@@ -0,0 +1,249 @@
+package errors
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/cockroachdb/errors"
+	"github.com/cockroachdb/errors/errbase"
+)
+
+// ProviderError represents a failure returned by a third-party API's SDK,
+// such as Stripe, Twilio or Slack, keeping the provider's own error code,
+// HTTP status and request ID instead of flattening them into a message.
+// Code is both the provider's code and the error's code (see WithCode), so
+// provider codes are classified and mapped through the installed
+// MappingTable; without a mapping, HTTPStatus decides as for HTTPError.
+// Automatically includes stack trace from creation point.
+type ProviderError struct {
+	Provider         string // provider name, such as "stripe"
+	Code             string // provider's error code, such as "card_declined"
+	RequestID        string // provider's request ID, for support tickets
+	HTTPStatus       int    // status of the provider's response; 0 when unknown
+	Message          string
+	Operation        string
+	Component        string
+	Owner            string
+	Err              error
+	AdditionalCauses []error
+	Metadata         map[string]any
+
+	state errorState
+}
+
+func (e *ProviderError) Error() string {
+	if e == nil {
+		return "<nil ProviderError>"
+	}
+	return e.formatWithCause(formatCauses(e.Err, e.AdditionalCauses))
+}
+
+func (e *ProviderError) formatWithCause(cause string) string {
+	if e == nil {
+		return "<nil ProviderError>"
+	}
+	msgStr := e.Message
+	if msgStr == "" {
+		msgStr = e.Provider + " error"
+	}
+	details := ""
+	switch {
+	case e.Code != "" && e.HTTPStatus != 0:
+		details = fmt.Sprintf("(code %s, HTTP %d)", e.Code, e.HTTPStatus)
+	case e.Code != "":
+		details = fmt.Sprintf("(code %s)", e.Code)
+	case e.HTTPStatus != 0:
+		details = fmt.Sprintf("(HTTP %d)", e.HTTPStatus)
+	}
+	requestID := ""
+	if e.RequestID != "" {
+		requestID = fmt.Sprintf("[request %s]", e.RequestID)
+	}
+	head := joinWords(msgStr, clause("in", e.Operation), details, requestID)
+	return joinDetails(e.Component, head, cause)
+}
+
+func (e *ProviderError) causeError() error {
+	if e == nil {
+		return nil
+	}
+	return e.Err
+}
+
+func (e *ProviderError) Unwrap() []error {
+	if e == nil {
+		return nil
+	}
+	return causeList(e.Err, e.AdditionalCauses)
+}
+
+// StackTrace returns the stack from where the error was created. It
+// implements the cockroachdb/errors stack trace provider interface.
+func (e *ProviderError) StackTrace() errbase.StackTrace {
+	if e == nil {
+		return nil
+	}
+	return e.state.stackTrace()
+}
+
+// Is implements value matching for errors.Is: target matches when it is a
+// *ProviderError whose non-zero Provider, Code and HTTPStatus all equal
+// e's, so errors.Is(err, &ProviderError{Provider: "stripe", Code:
+// "card_declined"}) matches any declined card.
+func (e *ProviderError) Is(target error) bool {
+	t, ok := target.(*ProviderError)
+	if e == nil || !ok || t == nil {
+		return false
+	}
+	return (t.Provider == "" || t.Provider == e.Provider) &&
+		(t.Code == "" || t.Code == e.Code) &&
+		(t.HTTPStatus == 0 || t.HTTPStatus == e.HTTPStatus)
+}
+
+// IsRetryable returns true for an HTTPStatus in the retryable set (see
+// IsRetryableHTTPStatus). Without a status,
+// the cause decides. A retryability declared for the code in the installed
+// MappingTable wins over both (see IsRetryable).
+func (e *ProviderError) IsRetryable() bool {
+	if e == nil {
+		return false
+	}
+	if e.HTTPStatus != 0 {
+		return IsRetryableHTTPStatus(e.HTTPStatus)
+	}
+	return e.Err != nil && IsRetryable(e.Err)
+}
+
+// NewProviderError creates a ProviderError with automatic stack trace. Set
+// the provider's code, status and request ID with WithCode, WithStatusCode
+// and WithUpstreamRequestID, or use FromProviderError to read them from an
+// SDK error.
+//
+// Example:
+//
+//	err := errors.NewProviderError("stripe",
+//	    errors.WithCode(stripeErr.Code),
+//	    errors.WithStatusCode(stripeErr.HTTPStatusCode),
+//	    errors.WithUpstreamRequestID(stripeErr.RequestID),
+//	    errors.WithOperation("CreateCharge"),
+//	    errors.WithCause(stripeErr))
+func NewProviderError(provider string, opts ...Option) error {
+	err := &ProviderError{
+		Provider: provider,
+	}
+	applyOptions(err, opts)
+	return err
+}
+
+// IsProviderError checks if err is a ProviderError and returns it.
+func IsProviderError(err error) (*ProviderError, bool) {
+	var providerErr *ProviderError
+	if errors.As(err, &providerErr) && providerErr != nil {
+		return providerErr, true
+	}
+	return nil, false
+}
+
+// ProviderDetails is what a ProviderExtractor reads from an SDK error.
+type ProviderDetails struct {
+	Code       string
+	RequestID  string
+	HTTPStatus int
+}
+
+// ProviderExtractor reads a provider's code, status and request ID from an
+// error returned by its SDK, reporting false when err isn't one of its
+// errors.
+type ProviderExtractor func(err error) (ProviderDetails, bool)
+
+var (
+	providerMu         sync.RWMutex
+	providerExtractors = make(map[string]ProviderExtractor)
+)
+
+// RegisterProviderExtractor installs the extractor FromProviderError uses
+// for provider, for SDKs whose errors expose their details as fields rather
+// than through the common methods. A later registration for the same
+// provider replaces the earlier one.
+//
+// Example:
+//
+//	errors.RegisterProviderExtractor("stripe", func(err error) (errors.ProviderDetails, bool) {
+//	    var stripeErr *stripe.Error
+//	    if !errors.As(err, &stripeErr) {
+//	        return errors.ProviderDetails{}, false
+//	    }
+//	    return errors.ProviderDetails{
+//	        Code:       string(stripeErr.Code),
+//	        RequestID:  stripeErr.RequestID,
+//	        HTTPStatus: stripeErr.HTTPStatusCode,
+//	    }, true
+//	})
+func RegisterProviderExtractor(provider string, extractor ProviderExtractor) {
+	providerMu.Lock()
+	defer providerMu.Unlock()
+	providerExtractors[provider] = extractor
+}
+
+// ResetProviderExtractors removes all registered provider extractors.
+// Intended for tests.
+func ResetProviderExtractors() {
+	providerMu.Lock()
+	defer providerMu.Unlock()
+	providerExtractors = make(map[string]ProviderExtractor)
+}
+
+// FromProviderError wraps an error returned by provider's SDK in a
+// ProviderError. The code, status and request ID come from the extractor
+// registered for provider (see RegisterProviderExtractor) or, failing that,
+// from the first errors in err's chain with ErrorCode() string,
+// HTTPStatusCode() int and RequestID() string methods. Returns nil for
+// nil, and err unchanged when it already holds a ProviderError.
+//
+// Example:
+//
+//	msg, err := twilioClient.Api.CreateMessage(params)
+//	if err != nil {
+//	    return errors.FromProviderError("twilio", err)
+//	}
+func FromProviderError(provider string, err error) error {
+	if err == nil {
+		return nil
+	}
+	if _, ok := IsProviderError(err); ok {
+		return err
+	}
+	details := providerDetails(provider, err)
+	return NewProviderError(provider, WithCause(err), WithCode(details.Code),
+		WithStatusCode(details.HTTPStatus), WithUpstreamRequestID(details.RequestID))
+}
+
+// providerDetails reads the details of an SDK error with provider's
+// extractor, or through the common methods.
+func providerDetails(provider string, err error) ProviderDetails {
+	providerMu.RLock()
+	extractor := providerExtractors[provider]
+	providerMu.RUnlock()
+	if extractor != nil {
+		if details, ok := extractor(err); ok {
+			return details
+		}
+	}
+
+	var (
+		details ProviderDetails
+		coder   interface{ ErrorCode() string }
+		status  interface{ HTTPStatusCode() int }
+		request interface{ RequestID() string }
+	)
+	if errors.As(err, &coder) {
+		details.Code = coder.ErrorCode()
+	}
+	if errors.As(err, &status) {
+		details.HTTPStatus = status.HTTPStatusCode()
+	}
+	if errors.As(err, &request) {
+		details.RequestID = request.RequestID()
+	}
+	return details
+}
@@ -0,0 +1,147 @@
+package errors
+
+import (
+	"fmt"
+	"testing"
+)
+
+// fakeSDKError has the shape of an SDK error exposing its details through methods.
+type fakeSDKError struct {
+	code      string
+	status    int
+	requestID string
+}
+
+func (e *fakeSDKError) Error() string       { return "sdk: " + e.code }
+func (e *fakeSDKError) ErrorCode() string   { return e.code }
+func (e *fakeSDKError) HTTPStatusCode() int { return e.status }
+func (e *fakeSDKError) RequestID() string   { return e.requestID }
+
+// fakeStripeError has the shape of *stripe.Error: details as fields, no methods.
+type fakeStripeError struct {
+	Code           string
+	HTTPStatusCode int
+	RequestID      string
+}
+
+func (e *fakeStripeError) Error() string { return "stripe: " + e.Code }
+
+// TestFromProviderError tests detail extraction through the common methods and registered extractors
+func TestFromProviderError(t *testing.T) {
+	t.Cleanup(ResetProviderExtractors)
+	RegisterProviderExtractor("stripe", func(err error) (ProviderDetails, bool) {
+		var stripeErr *fakeStripeError
+		if !As(err, &stripeErr) {
+			return ProviderDetails{}, false
+		}
+		return ProviderDetails{Code: stripeErr.Code, RequestID: stripeErr.RequestID, HTTPStatus: stripeErr.HTTPStatusCode}, true
+	})
+
+	tests := []struct {
+		name          string
+		provider      string
+		err           error
+		wantCode      string
+		wantStatus    int
+		wantRequestID string
+		wantRetryable bool
+	}{
+		{
+			name: "methods", provider: "twilio",
+			err:      &fakeSDKError{code: "21211", status: 400, requestID: "RQ1"},
+			wantCode: "21211", wantStatus: 400, wantRequestID: "RQ1",
+		},
+		{
+			name: "wrapped methods", provider: "slack",
+			err:      fmt.Errorf("posting message: %w", &fakeSDKError{code: "ratelimited", status: 429}),
+			wantCode: "ratelimited", wantStatus: 429, wantRetryable: true,
+		},
+		{
+			name: "registered extractor", provider: "stripe",
+			err:      &fakeStripeError{Code: "card_declined", HTTPStatusCode: 402, RequestID: "req_123"},
+			wantCode: "card_declined", wantStatus: 402, wantRequestID: "req_123",
+		},
+		{
+			name: "extractor falls back to methods", provider: "stripe",
+			err:      &fakeSDKError{code: "api_error", status: 500},
+			wantCode: "api_error", wantStatus: 500, wantRetryable: true,
+		},
+		{
+			name: "no details, retryable cause", provider: "slack",
+			err:           Wrap(ErrConnectionError, "posting message"),
+			wantRetryable: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := FromProviderError(tt.provider, tt.err)
+			providerErr, ok := IsProviderError(err)
+			if !ok {
+				t.Fatalf("FromProviderError() = %T, want a ProviderError", err)
+			}
+			if providerErr.Provider != tt.provider || providerErr.Code != tt.wantCode ||
+				providerErr.HTTPStatus != tt.wantStatus || providerErr.RequestID != tt.wantRequestID {
+				t.Errorf("FromProviderError() = %+v, want code %q, status %d, request %q",
+					providerErr, tt.wantCode, tt.wantStatus, tt.wantRequestID)
+			}
+			if got := IsRetryable(err); got != tt.wantRetryable {
+				t.Errorf("IsRetryable() = %v, want %v", got, tt.wantRetryable)
+			}
+			if GetCode(err) != tt.wantCode || GetUpstreamRequestID(err) != tt.wantRequestID {
+				t.Errorf("GetCode() = %q, GetUpstreamRequestID() = %q", GetCode(err), GetUpstreamRequestID(err))
+			}
+			if !Is(err, tt.err) {
+				t.Error("the SDK error should stay in the chain")
+			}
+		})
+	}
+
+	if FromProviderError("stripe", nil) != nil {
+		t.Error("FromProviderError(nil) should be nil")
+	}
+	wrapped := Wrap(NewProviderError("stripe", WithCode("card_declined")), "charging")
+	if FromProviderError("stripe", wrapped) != wrapped {
+		t.Error("an error already holding a ProviderError should be returned unchanged")
+	}
+}
+
+// TestProviderError tests the message, classification, labels and fingerprint
+func TestProviderError(t *testing.T) {
+	t.Cleanup(ResetMappings)
+	declined := NewProviderError("stripe", WithCode("card_declined"), WithStatusCode(402),
+		WithUpstreamRequestID("req_123"), WithOperation("CreateCharge"), WithComponent("billing"))
+
+	if want := "billing: stripe error in CreateCharge (code card_declined, HTTP 402) [request req_123]"; declined.Error() != want {
+		t.Errorf("Error() = %q, want %q", declined.Error(), want)
+	}
+	if Classify(declined) != ClassPermanent || HTTPStatus(declined) != 402 {
+		t.Errorf("Classify() = %v, HTTPStatus() = %d, want permanent and 402", Classify(declined), HTTPStatus(declined))
+	}
+	if !Is(declined, &ProviderError{Provider: "stripe", Code: "card_declined"}) || Is(declined, &ProviderError{Provider: "twilio"}) {
+		t.Error("Is() should match on provider and code")
+	}
+
+	labels := MetricLabels(declined)
+	if labels[LabelProvider] != "stripe" || labels[LabelProviderCode] != "card_declined" {
+		t.Errorf("MetricLabels() = %v, want provider and provider_code", labels)
+	}
+	insufficient := NewProviderError("stripe", WithCode("insufficient_funds"), WithStatusCode(402), WithOperation("CreateCharge"))
+	if Fingerprint(declined) == Fingerprint(insufficient) {
+		t.Error("Fingerprint() should differ by provider code")
+	}
+
+	SetMappingTable(NewMappingTable(Code("lock_timeout").Retryable(true)))
+	if !IsRetryable(NewProviderError("stripe", WithCode("lock_timeout"), WithStatusCode(409))) {
+		t.Error("a mapping for the provider code should win over the status")
+	}
+
+	info := ExtractErrorInfo(declined)
+	if info["type"] != "ProviderError" || info["provider"] != "stripe" || info["upstream_request_id"] != "req_123" {
+		t.Errorf("ExtractErrorInfo() = %v, want provider details", info)
+	}
+	decoded, ok := IsProviderError(Decode(Encode(declined)))
+	if !ok || decoded.Provider != "stripe" || decoded.Code != "card_declined" || decoded.HTTPStatus != 402 || decoded.RequestID != "req_123" {
+		t.Errorf("decoded %+v should keep the provider details", decoded)
+	}
+}
@@ -0,0 +1,109 @@
+package errors
+
+import (
+	"crypto/sha256"
+	"encoding/base32"
+	"strings"
+	"sync"
+)
+
+// referenceEncoding is Crockford's base32 alphabet, which leaves out I, L,
+// O and U so codes survive being read out over the phone.
+var referenceEncoding = base32.NewEncoding("0123456789ABCDEFGHJKMNPQRSTVWXYZ").WithPadding(base32.NoPadding)
+
+// referenceTypes returns the error type names a reference code can be
+// derived from, used to build the reverse index: every type with a
+// descriptor (see TypeDescriptors), and "Error" for errors without a typed
+// error in their chain.
+func referenceTypes() []string {
+	types := []string{"Error"}
+	for _, d := range TypeDescriptors() {
+		types = append(types, d.Name)
+	}
+	return types
+}
+
+var (
+	originIndexMu sync.RWMutex
+	originIndex   = make(map[string]string)
+)
+
+// ReferenceCode returns a short opaque code identifying where err
+// originated, for users to quote to support, such as "E7K2-9QXA". It is 8
+// Crockford base32 characters derived from OriginKey and the outermost
+// typed error's type, so it is stable across rebuilds and reveals nothing
+// about file paths or function names. Engineers map it back with
+// LookupReferenceCode. Returns "" for a nil error.
+//
+// Example:
+//
+//	log.Printf("ref %s: %v", errors.ReferenceCode(err), err)
+//	fmt.Fprintf(w, "Something went wrong (ref: %s)", errors.ReferenceCode(err))
+func ReferenceCode(err error) string {
+	if err == nil {
+		return ""
+	}
+	return referenceCode(OriginKey(err), signatureType(err))
+}
+
+func referenceCode(originKey, typ string) string {
+	sum := sha256.Sum256([]byte(originKey + "\x00" + typ))
+	code := referenceEncoding.EncodeToString(sum[:5])
+	return code[:4] + "-" + code[4:]
+}
+
+// RegisterOriginIndex records the functions errors can originate from so
+// LookupReferenceCode can map codes back to them. Call it at startup with
+// the call sites that construct errors, either as runtime names such as
+// "github.com/org/app/db.(*Client).Query.func1" or already normalized as
+// "github.com/org/app/db.Query". Codes of errors without a stack trace are
+// derived from their type and operation instead and can't be looked up.
+//
+// Example:
+//
+//	errors.RegisterOriginIndex(
+//	    "github.com/org/app/orders.(*Service).Charge",
+//	    "github.com/org/app/db.(*Client).Query",
+//	)
+func RegisterOriginIndex(functions ...string) {
+	originIndexMu.Lock()
+	defer originIndexMu.Unlock()
+
+	types := referenceTypes()
+	for _, function := range functions {
+		pkg, fn := splitFunction(function)
+		origin := pkg + "." + normalizeFunction(fn)
+		key := shortHash("origin", origin)
+		for _, typ := range types {
+			originIndex[referenceCode(key, typ)] = origin
+		}
+	}
+}
+
+// LookupReferenceCode returns the normalized package.Function a reference
+// code was derived from, if it was registered with RegisterOriginIndex.
+// Codes are matched case-insensitively, with or without the dash, and with
+// the look-alike letters O, I and L read as 0, 1 and 1.
+func LookupReferenceCode(code string) (string, bool) {
+	originIndexMu.RLock()
+	defer originIndexMu.RUnlock()
+
+	origin, ok := originIndex[normalizeReferenceCode(code)]
+	return origin, ok
+}
+
+// ResetOriginIndex removes all registered origins. Intended for tests.
+func ResetOriginIndex() {
+	originIndexMu.Lock()
+	defer originIndexMu.Unlock()
+	originIndex = make(map[string]string)
+}
+
+func normalizeReferenceCode(code string) string {
+	code = strings.NewReplacer("-", "", " ", "", "O", "0", "I", "1", "L", "1").
+		Replace(strings.ToUpper(strings.TrimSpace(code)))
+	if len(code) != 8 {
+		return code
+	}
+	return code[:4] + "-" + code[4:]
+}
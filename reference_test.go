@@ -0,0 +1,169 @@
+package errors
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+var referencePattern = regexp.MustCompile(`^[0-9A-HJKMNP-TV-Z]{4}-[0-9A-HJKMNP-TV-Z]{4}$`)
+
+// TestReferenceCode tests that reference codes are opaque and deterministic
+func TestReferenceCode(t *testing.T) {
+	first := NewHTTPError(502, "Bad Gateway", newOriginError())
+	second := Wrap(NewHTTPError(502, "Bad Gateway", newOriginError()), "retrying")
+
+	code := ReferenceCode(first)
+	if !referencePattern.MatchString(code) {
+		t.Fatalf("ReferenceCode() = %q, want 8 base32 characters", code)
+	}
+	if got := ReferenceCode(second); got != code {
+		t.Errorf("same origin and type gave %q and %q", code, got)
+	}
+	if got := ReferenceCode(NewProcessingError("failed", "Run", WithCause(newOriginError()))); got == code {
+		t.Error("different error type should change the code")
+	}
+	if got := ReferenceCode(nil); got != "" {
+		t.Errorf("ReferenceCode(nil) = %q, want empty", got)
+	}
+}
+
+// TestLookupReferenceCode tests mapping codes back to registered origins
+func TestLookupReferenceCode(t *testing.T) {
+	t.Cleanup(ResetOriginIndex)
+	RegisterOriginIndex("github.com/JohnPlummer/jp-go-errors.newOriginError")
+
+	code := ReferenceCode(NewHTTPError(502, "Bad Gateway", newOriginError()))
+
+	tests := []struct {
+		name   string
+		code   string
+		want   string
+		wantOK bool
+	}{
+		{"exact", code, "github.com/JohnPlummer/jp-go-errors.newOriginError", true},
+		{"lowercase without dash", strings.ToLower(strings.ReplaceAll(code, "-", "")), "github.com/JohnPlummer/jp-go-errors.newOriginError", true},
+		{"unknown", "ZZZZ-ZZZZ", "", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := LookupReferenceCode(tt.code)
+			if got != tt.want || ok != tt.wantOK {
+				t.Errorf("LookupReferenceCode(%q) = %q, %v, want %q, %v", tt.code, got, ok, tt.want, tt.wantOK)
+			}
+		})
+	}
+
+	t.Run("runtime names are normalized", func(t *testing.T) {
+		ResetOriginIndex()
+		RegisterOriginIndex("github.com/org/app/db.(*Client).Query.func1")
+
+		want := "github.com/org/app/db.Query"
+		for _, typ := range []string{"HTTPError", "Error"} {
+			if got, ok := LookupReferenceCode(referenceCode(shortHash("origin", want), typ)); !ok || got != want {
+				t.Errorf("lookup for %s = %q, %v, want %q", typ, got, ok, want)
+			}
+		}
+	})
+}
+
+// recoveredPanic returns the error Recover makes of a panic in it.
+func recoveredPanic() (err error) {
+	defer Recover(&err)
+	var counts map[string]int
+	counts["orders"]++
+	return nil
+}
+
+// TestLookupReferenceCodeTypes tests that codes resolve for every typed error, including recovered panics
+func TestLookupReferenceCodeTypes(t *testing.T) {
+	t.Cleanup(ResetOriginIndex)
+	const origin = "github.com/JohnPlummer/jp-go-errors.newOriginError"
+	RegisterOriginIndex(origin)
+
+	key := OriginKey(newOriginError())
+	for _, d := range TypeDescriptors() {
+		if got, ok := LookupReferenceCode(referenceCode(key, d.Name)); !ok || got != origin {
+			t.Errorf("lookup for %s = %q, %v, want %q", d.Name, got, ok, origin)
+		}
+	}
+
+	described := make(map[string]bool)
+	for _, d := range TypeDescriptors() {
+		described[d.Name] = true
+	}
+	for name, err := range typedNils() {
+		if _, typed := err.(chainFormatter); typed && !described[name] {
+			t.Errorf("%s has no type descriptor, so its reference codes can't be looked up", name)
+		}
+	}
+
+	t.Run("recovered panic", func(t *testing.T) {
+		RegisterOriginIndex("github.com/JohnPlummer/jp-go-errors.recoveredPanic")
+		err := recoveredPanic()
+		if _, ok := IsPanic(err); !ok {
+			t.Fatalf("recoveredPanic() = %v, want a PanicError", err)
+		}
+		if got, ok := LookupReferenceCode(ReferenceCode(err)); !ok || got != "github.com/JohnPlummer/jp-go-errors.recoveredPanic" {
+			t.Errorf("LookupReferenceCode() = %q, %v, want the panicking function", got, ok)
+		}
+	})
+}
+
+// TestUserMessage tests end-user messages carry the reference code and no internals
+func TestUserMessage(t *testing.T) {
+	tests := []struct {
+		name       string
+		err        error
+		wantPrefix string
+	}{
+		{"validation error", NewValidationError("Email is invalid", "email"), "Email is invalid (ref: "},
+		{"client error without message", NewHTTPError(404, "", nil), "Not Found (ref: "},
+		{"server error", NewHTTPError(500, "db password rejected", fmt.Errorf("pq: auth failed")), "Something went wrong. Please try again later. (ref: "},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			msg := UserMessage(tt.err)
+			if !strings.HasPrefix(msg, tt.wantPrefix) {
+				t.Errorf("UserMessage() = %q, want prefix %q", msg, tt.wantPrefix)
+			}
+			if !strings.HasSuffix(msg, ReferenceCode(tt.err)+")") {
+				t.Errorf("UserMessage() = %q, want reference code %s", msg, ReferenceCode(tt.err))
+			}
+		})
+	}
+
+	if msg := UserMessage(nil); msg != "" {
+		t.Errorf("UserMessage(nil) = %q, want empty", msg)
+	}
+}
+
+// TestProblemDetailsReference tests problem details expose only the opaque code
+func TestProblemDetailsReference(t *testing.T) {
+	err := NewHTTPError(500, "Internal", newOriginError())
+
+	data, marshalErr := json.Marshal(ToProblemDetails(err))
+	if marshalErr != nil {
+		t.Fatalf("json.Marshal() error = %v", marshalErr)
+	}
+
+	var members map[string]any
+	if unmarshalErr := json.Unmarshal(data, &members); unmarshalErr != nil {
+		t.Fatalf("json.Unmarshal() error = %v", unmarshalErr)
+	}
+	if members["reference"] != ReferenceCode(err) {
+		t.Errorf("reference = %v, want %s", members["reference"], ReferenceCode(err))
+	}
+	for _, leak := range []string{"origin_key", "fingerprint", "stack"} {
+		if _, ok := members[leak]; ok {
+			t.Errorf("problem details should not include %q", leak)
+		}
+	}
+	if strings.Contains(string(data), ".go") || strings.Contains(string(data), "newOriginError") {
+		t.Errorf("problem details leak code locations: %s", data)
+	}
+}
@@ -0,0 +1,77 @@
+package errors
+
+import "time"
+
+// RemoteError stands in for a typed error received in an envelope whose
+// type this version of the package doesn't know, such as a type added in a
+// newer release. It keeps the sender's type name, message, classification,
+// status code and retry hint, so retry and response decisions still work
+// while services run different versions.
+//
+// RemoteError values are produced by Decode and UnmarshalError; re-encoding
+// one restores the original type name.
+type RemoteError struct {
+	Type             string
+	Message          string
+	Operation        string
+	Component        string
+	Code             string
+	Owner            string
+	StatusCode       int
+	Class            ErrorClass
+	RetryAfter       time.Duration
+	Err              error
+	AdditionalCauses []error
+	Metadata         map[string]any
+}
+
+func (e *RemoteError) Error() string {
+	if e == nil {
+		return "<nil RemoteError>"
+	}
+	return e.formatWithCause(formatCauses(e.Err, e.AdditionalCauses))
+}
+
+func (e *RemoteError) formatWithCause(cause string) string {
+	if e == nil {
+		return "<nil RemoteError>"
+	}
+	opStr := opLabel(e.Component, e.Operation)
+	typ := e.Type
+	if typ == "" && opStr != "" {
+		typ = "remote error"
+	}
+	return joinDetails(joinWords(typ, clause("in", opStr)), e.Message, cause)
+}
+
+func (e *RemoteError) causeError() error {
+	if e == nil {
+		return nil
+	}
+	return e.Err
+}
+
+func (e *RemoteError) Unwrap() []error {
+	if e == nil {
+		return nil
+	}
+	return causeList(e.Err, e.AdditionalCauses)
+}
+
+// IsRetryable returns true when the sender classified the error as
+// transient.
+func (e *RemoteError) IsRetryable() bool {
+	if e == nil {
+		return false
+	}
+	return e.Class == ClassTransient
+}
+
+// IsRemoteError checks if err is a RemoteError and returns it.
+func IsRemoteError(err error) (*RemoteError, bool) {
+	var remoteErr *RemoteError
+	if As(err, &remoteErr) {
+		return remoteErr, true
+	}
+	return nil, false
+}
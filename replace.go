@@ -0,0 +1,284 @@
+package errors
+
+import (
+	"maps"
+	"slices"
+	"strings"
+)
+
+// ReplaceCause returns a copy of err's chain with the first node for which
+// match returns true, in errors.Is order, swapped for replacement. It lets
+// tests ask "what if the cause had been a 503 instead of a timeout" without
+// rebuilding the chain by hand. err itself is never modified: this
+// package's typed errors and wrappers on the path to the match are copied
+// with their messages, metadata and stacks, and the copy is classified
+// afresh rather than by a sender's classification (see
+// PreclassifiedClass). Foreign wrappers, such as fmt.Errorf or
+// cockroachdb/errors wrappers, can't be copied and are replaced by a
+// wrapper that keeps their message around the new cause, or appends a note
+// naming it when the message doesn't embed the old one. Returns err
+// unchanged when nothing matches.
+//
+// match is called with each node in turn, outermost first, so it should
+// test the node itself: a predicate that searches the chain, such as
+// IsTimeout, matches the outermost node.
+//
+// Example:
+//
+//	isTimeout := func(node error) bool {
+//	    _, ok := node.(*errors.TimeoutError)
+//	    return ok
+//	}
+//	unavailable := errors.NewHTTPError(503, "Service Unavailable", nil)
+//	err = errors.ReplaceCause(err, isTimeout, unavailable)
+//	if !errors.IsRetryable(err) {
+//	    t.Error("a 503 from the dependency should be retried")
+//	}
+func ReplaceCause(err error, match func(error) bool, replacement error) error {
+	if err == nil || match == nil {
+		return err
+	}
+	replaced, _ := replaceCause(err, match, replacement, 0)
+	return replaced
+}
+
+// replaceCause implements ReplaceCause, reporting whether a node matched.
+func replaceCause(err error, match func(error) bool, replacement error, depth int) (error, bool) {
+	if err == nil || depth > maxCauseDepth {
+		return err, false
+	}
+	if match(err) {
+		return replacement, true
+	}
+	if isTypedNil(err) {
+		return err, false
+	}
+
+	if clone := cloneNode(err); clone != nil {
+		if cause, ok := replaceCause(primaryCause(clone), match, replacement, depth+1); ok {
+			setCause(clone, cause)
+			return clone, true
+		}
+		if field := additionalCausesField(clone); field != nil {
+			for i, c := range *field {
+				if cause, ok := replaceCause(c, match, replacement, depth+1); ok {
+					*field = slices.Clone(*field)
+					(*field)[i] = cause
+					return clone, true
+				}
+			}
+		}
+		return err, false
+	}
+
+	var causes []error
+	switch e := err.(type) {
+	case interface{ Unwrap() error }:
+		causes = []error{e.Unwrap()}
+	case interface{ Unwrap() []error }:
+		causes = e.Unwrap()
+	}
+	for i, c := range causes {
+		if cause, ok := replaceCause(c, match, replacement, depth+1); ok {
+			return newSubstitutedError(err, c, cause, slices.Replace(slices.Clone(causes), i, i+1, cause)), true
+		}
+	}
+	return err, false
+}
+
+// cloneNode returns a shallow copy of one of this package's typed errors or
+// wrappers, with its metadata copied and its sender's classification
+// dropped, or nil for a foreign error.
+func cloneNode(err error) error {
+	var clone error
+	switch e := err.(type) {
+	case *HTTPError:
+		c := *e
+		clone = &c
+	case *ValidationError:
+		c := *e
+		clone = &c
+	case *TimeoutError:
+		c := *e
+		clone = &c
+	case *RateLimitError:
+		c := *e
+		clone = &c
+	case *RetryableError:
+		c := *e
+		clone = &c
+	case *ProcessingError:
+		c := *e
+		clone = &c
+	case *NetworkError:
+		c := *e
+		clone = &c
+	case *SerializationError:
+		c := *e
+		clone = &c
+	case *CircuitBreakerError:
+		c := *e
+		clone = &c
+	case *ConsistencyError:
+		c := *e
+		clone = &c
+	case *NotFoundError:
+		c := *e
+		clone = &c
+	case *ConflictError:
+		c := *e
+		clone = &c
+	case *DatabaseError:
+		c := *e
+		clone = &c
+	case *ProviderError:
+		c := *e
+		clone = &c
+	case *ConfigError:
+		c := *e
+		clone = &c
+	case *NotImplementedError:
+		c := *e
+		clone = &c
+	case *UnsupportedError:
+		c := *e
+		clone = &c
+	case *RetryError:
+		c := *e
+		clone = &c
+	case *PanicError:
+		c := *e
+		clone = &c
+	case *RemoteError:
+		c := *e
+		clone = &c
+	case *forcedError:
+		c := *e
+		return &c
+	case *metadataError:
+		c := *e
+		c.metadata = maps.Clone(e.metadata)
+		return &c
+	case *staleError:
+		c := *e
+		return &c
+	case *handledError:
+		c := *e
+		return &c
+	case *cleanupError:
+		c := *e
+		return &c
+	case *extendedError:
+		c := *e
+		return &c
+	case *originKeyError:
+		c := *e
+		return &c
+	case *negativeCachedError:
+		c := *e
+		return &c
+	case *sharedFailureError:
+		c := *e
+		return &c
+	default:
+		return nil
+	}
+
+	if field := metadataField(clone); field != nil {
+		*field = maps.Clone(*field)
+	}
+	if field := preclassField(clone); field != nil {
+		*field = ""
+	}
+	return clone
+}
+
+// primaryCause returns the primary cause of a node returned by cloneNode.
+func primaryCause(err error) error {
+	switch e := err.(type) {
+	case chainFormatter:
+		return e.causeError()
+	case *forcedError:
+		return e.err
+	case *metadataError:
+		return e.cause
+	case *staleError:
+		return e.err
+	case *handledError:
+		return e.err
+	case *cleanupError:
+		return e.err
+	case *extendedError:
+		return e.err
+	case *originKeyError:
+		return e.err
+	case *negativeCachedError:
+		return e.err
+	case *sharedFailureError:
+		return e.err
+	}
+	return nil
+}
+
+// setCause sets the primary cause of a node returned by cloneNode.
+func setCause(err error, cause error) {
+	switch e := err.(type) {
+	case *PanicError:
+		e.Value = cause
+	case *RemoteError:
+		e.Err = cause
+	case *forcedError:
+		e.err = cause
+	case *metadataError:
+		e.cause = cause
+	case *staleError:
+		e.err = cause
+	case *handledError:
+		e.err = cause
+	case *cleanupError:
+		e.err = cause
+	case *extendedError:
+		e.err = cause
+	case *originKeyError:
+		e.err = cause
+	case *negativeCachedError:
+		e.err = cause
+	case *sharedFailureError:
+		e.err = cause
+	default:
+		WithCause(cause)(err)
+	}
+}
+
+// substitutedError stands in for a foreign wrapper whose cause was
+// replaced by ReplaceCause.
+type substitutedError struct {
+	message string
+	causes  []error
+}
+
+// newSubstitutedError re-wraps causes in place of wrapper, whose cause old
+// was replaced by cause. The wrapper's message is kept with old's message
+// swapped for cause's.
+func newSubstitutedError(wrapper, old, cause error, causes []error) *substitutedError {
+	message := wrapper.Error()
+	var replacement string
+	if cause != nil {
+		replacement = cause.Error()
+	}
+
+	if oldMessage := old.Error(); oldMessage != "" && strings.Contains(message, oldMessage) {
+		message = strings.Replace(message, oldMessage, replacement, 1)
+	} else {
+		message += " (cause replaced: " + replacement + ")"
+	}
+	return &substitutedError{message: message, causes: causes}
+}
+
+func (e *substitutedError) Error() string {
+	return e.message
+}
+
+func (e *substitutedError) Unwrap() []error {
+	return e.causes
+}
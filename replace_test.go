@@ -0,0 +1,149 @@
+package errors
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+)
+
+// isNode returns a ReplaceCause matcher for the given node.
+func isNode(target error) func(error) bool {
+	return func(node error) bool { return node == target }
+}
+
+// TestReplaceCause tests substituting a node deep in a chain
+func TestReplaceCause(t *testing.T) {
+	inner := NewValidationError("unknown sku", "sku")
+	middle := fmt.Errorf("calling pricing: %w", inner)
+	outer := NewProcessingError("loading quote", "GetQuote", WithCause(middle), WithMetadata("order", "42"))
+	unavailable := NewHTTPError(503, "Service Unavailable", nil)
+
+	replaced := ReplaceCause(outer, isNode(inner), unavailable)
+
+	if got := Classify(outer); got != ClassPermanent {
+		t.Fatalf("Classify(original) = %q, want permanent", got)
+	}
+	if got := Classify(replaced); got != ClassTransient {
+		t.Errorf("Classify(replaced) = %q, want transient", got)
+	}
+	if want := strings.Replace(outer.Error(), inner.Error(), unavailable.Error(), 1); replaced.Error() != want {
+		t.Errorf("Error() = %q, want %q", replaced.Error(), want)
+	}
+	if !Is(replaced, unavailable) || Is(replaced, inner) {
+		t.Error("replaced chain should hold the replacement and not the original cause")
+	}
+	if Is(outer, unavailable) || !Is(outer, inner) {
+		t.Error("original chain was modified")
+	}
+
+	procErr, ok := replaced.(*ProcessingError)
+	if !ok || procErr == outer {
+		t.Fatalf("ReplaceCause() = %T, want a copy of the ProcessingError", replaced)
+	}
+	if procErr.Operation != "GetQuote" || procErr.Metadata["order"] != "42" {
+		t.Errorf("copy lost fields: %+v", procErr)
+	}
+	procErr.Metadata["order"] = "43"
+	if outer.(*ProcessingError).Metadata["order"] != "42" {
+		t.Error("copy shares metadata with the original")
+	}
+}
+
+// TestReplaceCauseNodes tests how each kind of node on the path is rebuilt
+func TestReplaceCauseNodes(t *testing.T) {
+	timeout := NewTimeoutError("too slow", "GetQuote", time.Second)
+	unavailable := NewHTTPError(503, "Service Unavailable", nil)
+	other := NewValidationError("bad", "email")
+
+	tests := []struct {
+		name      string
+		err       error
+		wantMsg   string
+		wantClass ErrorClass
+	}{
+		{
+			name:      "root",
+			err:       timeout,
+			wantMsg:   unavailable.Error(),
+			wantClass: ClassTransient,
+		},
+		{
+			name:      "forced wrapper kept",
+			err:       Permanent(Wrap(timeout, "quoting")),
+			wantMsg:   "quoting: " + unavailable.Error(),
+			wantClass: ClassPermanent,
+		},
+		{
+			name:      "additional cause",
+			err:       NewProcessingError("saving", "Save", WithCause(other), WithAdditionalCause(timeout)),
+			wantMsg:   NewProcessingError("saving", "Save", WithCause(other), WithAdditionalCause(unavailable)).Error(),
+			wantClass: ClassPermanent,
+		},
+		{
+			name:      "foreign multi-cause wrapper",
+			err:       fmt.Errorf("both failed: %w; %w", other, timeout),
+			wantMsg:   "both failed: " + other.Error() + "; " + unavailable.Error(),
+			wantClass: ClassPermanent,
+		},
+		{
+			name:      "typed wrapper",
+			err:       NewHTTPError(502, "Bad Gateway", timeout),
+			wantMsg:   NewHTTPError(502, "Bad Gateway", unavailable).Error(),
+			wantClass: ClassTransient,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			replaced := ReplaceCause(tt.err, isNode(timeout), unavailable)
+			if replaced.Error() != tt.wantMsg {
+				t.Errorf("Error() = %q, want %q", replaced.Error(), tt.wantMsg)
+			}
+			if got := Classify(replaced); got != tt.wantClass {
+				t.Errorf("Classify() = %q, want %q", got, tt.wantClass)
+			}
+			if !Is(replaced, unavailable) {
+				t.Error("replacement missing from the chain")
+			}
+		})
+	}
+
+	t.Run("foreign wrapper without the cause message", func(t *testing.T) {
+		err := &opaqueWrapper{err: timeout}
+		replaced := ReplaceCause(err, isNode(timeout), unavailable)
+		if want := "opaque failure (cause replaced: " + unavailable.Error() + ")"; replaced.Error() != want {
+			t.Errorf("Error() = %q, want %q", replaced.Error(), want)
+		}
+	})
+
+	t.Run("sender classification dropped", func(t *testing.T) {
+		decoded := Decode(Encode(NewProcessingError("failed", "Sync", WithCause(NewValidationError("bad", "id")))))
+		replaced := ReplaceCause(decoded, func(node error) bool {
+			_, ok := node.(*ValidationError)
+			return ok
+		}, unavailable)
+		if WasPreclassified(replaced) {
+			t.Error("replaced chain should be classified by local rules")
+		}
+		if got := Classify(replaced); got != ClassTransient {
+			t.Errorf("Classify() = %q, want transient", got)
+		}
+	})
+
+	t.Run("no match", func(t *testing.T) {
+		err := Wrap(timeout, "quoting")
+		if replaced := ReplaceCause(err, isNode(other), unavailable); replaced != err {
+			t.Errorf("ReplaceCause() = %v, want err unchanged", replaced)
+		}
+		if ReplaceCause(nil, isNode(timeout), unavailable) != nil {
+			t.Error("ReplaceCause(nil) should be nil")
+		}
+	})
+}
+
+// opaqueWrapper is a foreign wrapper whose message doesn't include its cause's.
+type opaqueWrapper struct{ err error }
+
+func (e *opaqueWrapper) Error() string { return "opaque failure" }
+func (e *opaqueWrapper) Unwrap() error { return e.err }
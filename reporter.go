@@ -0,0 +1,321 @@
+package errors
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Metadata keys stamped on errors passed through a Reporter.
+const (
+	MetadataReportAttempt = "report_attempt"
+	MetadataOccurredAt    = "occurred_at"
+)
+
+// Reporter limits, overridable with WithReportInterval and WithMaxKeys.
+const (
+	defaultReportInterval  = time.Minute
+	defaultMaxReporterKeys = 1000
+)
+
+// maxReporterErrors caps how many errors and how many warnings a Reporter
+// keeps for Close and Warnings; later ones are only counted.
+const maxReporterErrors = 100
+
+// Reporter collects the errors a long-running operation, such as a
+// migration or a large import, hits along the way without stopping it.
+// Each reported error is stamped and passed to a sink as it happens, and
+// Close summarizes the run once the operation ends. Errors at
+// SeverityWarning or below are warnings; anything more severe makes the
+// run degraded. Memory is bounded however long the operation runs. Safe
+// for concurrent use.
+type Reporter struct {
+	op       string
+	sink     func(error)
+	interval time.Duration
+	maxKeys  int
+	clock    Clock
+
+	mu       sync.Mutex
+	seen     map[string]*reportedKey
+	warnings Warnings
+	errs     []error
+	failure  error
+	counts   reporterCounts
+	closed   bool
+	result   error
+}
+
+// reportedKey tracks one fingerprint seen by a Reporter.
+type reportedKey struct {
+	reports int
+	sentAt  time.Time
+}
+
+// reporterCounts counts every error a Reporter saw, kept or not.
+type reporterCounts struct {
+	warnings   int
+	errors     int
+	suppressed int
+}
+
+// NewReporter creates a Reporter for the operation op. sink receives each
+// reported error once stamped, and each terminal failure; it runs on the
+// reporting goroutine, outside the Reporter's lock, and may be nil.
+// Supports WithReportInterval, WithMaxKeys and WithClock options.
+//
+// Example:
+//
+//	rep := errors.NewReporter("ImportRows", func(err error) {
+//	    errors.LogError(ctx, logger, "", err)
+//	})
+//	for _, row := range rows {
+//	    if err := importRow(ctx, row); err != nil {
+//	        rep.Report(err)
+//	    }
+//	}
+//	return rep.Close()
+func NewReporter(op string, sink func(error), opts ...Option) *Reporter {
+	r := &Reporter{
+		op:       op,
+		sink:     sink,
+		interval: defaultReportInterval,
+		maxKeys:  defaultMaxReporterKeys,
+		clock:    packageClock{},
+		seen:     make(map[string]*reportedKey),
+	}
+	for _, opt := range opts {
+		opt(r)
+	}
+	return r
+}
+
+// Report records a non-terminal error. The error is stamped with how many
+// times its fingerprint has been reported (MetadataReportAttempt) and when
+// (MetadataOccurredAt), and passed to the sink, except that an error
+// sharing its fingerprint with one sent within the report interval is
+// only counted, so a failure repeated on every row reaches the sink once a
+// minute rather than a million times. Does nothing for a nil error or
+// after Close.
+func (r *Reporter) Report(err error) {
+	if err == nil {
+		return
+	}
+	warning := GetSeverity(err) <= SeverityWarning
+	key := Fingerprint(err)
+
+	r.mu.Lock()
+	if r.closed {
+		r.mu.Unlock()
+		return
+	}
+	now := r.clock.Now()
+	seen := r.track(key, now)
+	seen.reports++
+	stamped := stampReport(err, seen.reports, now)
+	if warning {
+		r.counts.warnings++
+	} else {
+		r.counts.errors++
+	}
+
+	send := seen.sentAt.IsZero() || now.Sub(seen.sentAt) >= r.interval
+	if !send {
+		r.counts.suppressed++
+		r.mu.Unlock()
+		return
+	}
+	seen.sentAt = now
+	if warning && len(r.warnings) < maxReporterErrors {
+		r.warnings = append(r.warnings, stamped)
+	} else if !warning && len(r.errs) < maxReporterErrors {
+		r.errs = append(r.errs, stamped)
+	}
+	r.mu.Unlock()
+
+	if r.sink != nil {
+		r.sink(stamped)
+	}
+}
+
+// Fail records err as the operation's terminal failure and passes it to
+// the sink. Only the first failure is kept; later ones are passed to the
+// sink but don't replace it. Does nothing for a nil error or after Close.
+func (r *Reporter) Fail(err error) {
+	if err == nil {
+		return
+	}
+
+	r.mu.Lock()
+	if r.closed {
+		r.mu.Unlock()
+		return
+	}
+	now := r.clock.Now()
+	seen := r.track(Fingerprint(err), now)
+	seen.reports++
+	stamped := stampReport(err, seen.reports, now)
+	if r.failure == nil {
+		r.failure = stamped
+	}
+	r.mu.Unlock()
+
+	if r.sink != nil {
+		r.sink(stamped)
+	}
+}
+
+// Warnings returns the warnings reported so far, in the order they were
+// sent to the sink, so a status endpoint for a running task can show them
+// (see WarnCtx). At most 100 are kept.
+//
+// Example:
+//
+//	for _, w := range job.Reporter.Warnings() {
+//	    errors.WarnCtx(r.Context(), w)
+//	}
+//	errors.WriteProblemCtx(r.Context(), w, job.Err())
+func (r *Reporter) Warnings() Warnings {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if len(r.warnings) == 0 {
+		return nil
+	}
+	return append(Warnings(nil), r.warnings...)
+}
+
+// Close ends the run and summarizes it. Returns nil when only warnings
+// were reported, or a DegradedError holding the terminal failure, the
+// errors and the warnings otherwise. Reports after Close are ignored, and
+// later calls return the same result.
+func (r *Reporter) Close() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.closed {
+		return r.result
+	}
+	r.closed = true
+	r.seen = nil
+	if r.failure == nil && r.counts.errors == 0 {
+		return nil
+	}
+
+	r.result = &DegradedError{
+		Operation:    r.op,
+		Failure:      r.failure,
+		Errors:       r.errs,
+		Warnings:     r.warnings,
+		ErrorCount:   r.counts.errors,
+		WarningCount: r.counts.warnings,
+		Suppressed:   r.counts.suppressed,
+	}
+	return r.result
+}
+
+// track returns the state for fingerprint key, evicting fingerprints whose
+// interval has passed once maxKeys are tracked. At the limit with none to
+// evict, the returned state is untracked, so the error is sent.
+func (r *Reporter) track(key string, now time.Time) *reportedKey {
+	if seen, ok := r.seen[key]; ok {
+		return seen
+	}
+	if len(r.seen) >= r.maxKeys {
+		for k, seen := range r.seen {
+			if now.Sub(seen.sentAt) >= r.interval {
+				delete(r.seen, k)
+			}
+		}
+	}
+	seen := &reportedKey{}
+	if len(r.seen) < r.maxKeys {
+		r.seen[key] = seen
+	}
+	return seen
+}
+
+// stampReport wraps err with its report attempt and time.
+func stampReport(err error, attempt int, now time.Time) error {
+	return &metadataError{cause: err, metadata: map[string]any{
+		MetadataReportAttempt: attempt,
+		MetadataOccurredAt:    now,
+	}}
+}
+
+// DegradedError is the error returned by Reporter.Close when a long-running
+// operation failed, or finished but reported errors along the way.
+// errors.Is and errors.As see the failure and the kept errors.
+type DegradedError struct {
+	Operation string
+
+	// Failure is the terminal failure passed to Reporter.Fail, or nil
+	// when the operation ran to completion.
+	Failure error
+
+	// Errors and Warnings are the errors and warnings sent to the sink,
+	// at most 100 of each.
+	Errors   []error
+	Warnings Warnings
+
+	// ErrorCount and WarningCount count every error and warning reported,
+	// including those Suppressed as repeats within the report interval.
+	ErrorCount   int
+	WarningCount int
+	Suppressed   int
+}
+
+func (e *DegradedError) Error() string {
+	if e == nil {
+		return "<nil DegradedError>"
+	}
+
+	noun := "errors"
+	if e.ErrorCount == 1 {
+		noun = "error"
+	}
+	if e.Failure != nil {
+		msg := fmt.Sprintf("%s failed: %v", e.Operation, e.Failure)
+		if e.ErrorCount > 0 {
+			msg += fmt.Sprintf(" (after %d %s)", e.ErrorCount, noun)
+		}
+		return msg
+	}
+
+	listed := make([]string, 0, maxBatchErrorItems+1)
+	for _, err := range e.Errors[:min(len(e.Errors), maxBatchErrorItems)] {
+		listed = append(listed, err.Error())
+	}
+	if more := e.ErrorCount - len(listed); more > 0 {
+		listed = append(listed, fmt.Sprintf("and %d more", more))
+	}
+	return fmt.Sprintf("%s completed with %d %s: %s", e.Operation, e.ErrorCount, noun, strings.Join(listed, "; "))
+}
+
+// Unwrap returns the failure, if any, followed by the kept errors.
+func (e *DegradedError) Unwrap() []error {
+	if e == nil {
+		return nil
+	}
+	errs := make([]error, 0, len(e.Errors)+1)
+	if e.Failure != nil {
+		errs = append(errs, e.Failure)
+	}
+	return append(errs, e.Errors...)
+}
+
+// IsRetryable reports whether the failure is retryable or, for an
+// operation that completed, whether any of its errors is.
+func (e *DegradedError) IsRetryable() bool {
+	if e == nil {
+		return false
+	}
+	if e.Failure != nil {
+		return IsRetryable(e.Failure)
+	}
+	for _, err := range e.Errors {
+		if IsRetryable(err) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,127 @@
+package errors
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// rowError returns an error with the same fingerprint on every call.
+func rowError(row string) error {
+	return NewProcessingError("row rejected", "ImportRows", WithItemID(row), WithRetryable(false))
+}
+
+// TestReporter tests stamping, rate limiting and the summary returned by Close
+func TestReporter(t *testing.T) {
+	clock := newFakeClock()
+	var sent []error
+	rep := NewReporter("ImportRows", func(err error) { sent = append(sent, err) }, WithClock(clock))
+
+	for i := range 5 {
+		rep.Report(rowError(string(rune('a' + i))))
+	}
+	clock.Advance(time.Minute)
+	rep.Report(rowError("f"))
+	rep.Report(NewValidationError("invalid date", "date"))
+	rep.Report(nil)
+
+	if len(sent) != 3 {
+		t.Fatalf("sink got %d errors, want 3 (repeats within a minute suppressed): %v", len(sent), sent)
+	}
+	if attempt, _ := GetMetadata(sent[1], MetadataReportAttempt); attempt != 6 {
+		t.Errorf("report attempt = %v, want 6", attempt)
+	}
+	if at, _ := GetMetadata(sent[1], MetadataOccurredAt); at != clock.Now() {
+		t.Errorf("occurred at = %v, want %v", at, clock.Now())
+	}
+	if got := rep.Warnings(); len(got) != 1 || !IsValidation(got[0]) {
+		t.Errorf("Warnings() = %v, want the validation error", got)
+	}
+
+	err := rep.Close()
+	var degraded *DegradedError
+	if !As(err, &degraded) {
+		t.Fatalf("Close() = %v, want a DegradedError", err)
+	}
+	if degraded.ErrorCount != 6 || degraded.WarningCount != 1 || degraded.Suppressed != 4 || len(degraded.Errors) != 2 {
+		t.Errorf("Close() = %+v, want 6 errors (2 kept), 1 warning and 4 suppressed", degraded)
+	}
+	if want := "ImportRows completed with 6 errors: "; !strings.HasPrefix(err.Error(), want) || !strings.HasSuffix(err.Error(), "; and 4 more") {
+		t.Errorf("Error() = %q", err.Error())
+	}
+	if IsRetryable(err) {
+		t.Error("IsRetryable() = true, want false for permanent row errors")
+	}
+
+	rep.Report(rowError("g"))
+	if again := rep.Close(); again != err || len(sent) != 3 {
+		t.Errorf("Close() again = %v, want the same result with reports ignored", again)
+	}
+}
+
+// TestReporterClose tests the result of Close for warnings only and for a terminal failure
+func TestReporterClose(t *testing.T) {
+	t.Run("warnings only", func(t *testing.T) {
+		rep := NewReporter("Migrate", nil)
+		rep.Report(NewValidationError("invalid date", "date"))
+		if err := rep.Close(); err != nil {
+			t.Errorf("Close() = %v, want nil", err)
+		}
+		if len(rep.Warnings()) != 1 {
+			t.Errorf("Warnings() = %v, want the warning kept after Close", rep.Warnings())
+		}
+	})
+
+	t.Run("failure", func(t *testing.T) {
+		var sent []error
+		rep := NewReporter("Migrate", func(err error) { sent = append(sent, err) })
+		timeout := NewTimeoutError("lock wait", "Migrate", time.Second)
+		rep.Report(rowError("a"))
+		rep.Fail(timeout)
+		rep.Fail(NewHTTPError(500, "Internal Server Error", nil))
+
+		err := rep.Close()
+		if !Is(err, timeout) {
+			t.Errorf("Close() = %v, want the first failure in its chain", err)
+		}
+		if !IsRetryable(err) {
+			t.Error("IsRetryable() = false, want the failure's retryability")
+		}
+		if want := "Migrate failed: " + timeout.Error() + " (after 1 error)"; err.Error() != want {
+			t.Errorf("Error() = %q, want %q", err.Error(), want)
+		}
+		if len(sent) != 3 {
+			t.Errorf("sink got %d errors, want every failure", len(sent))
+		}
+	})
+}
+
+// TestReporterBounded tests that tracked fingerprints and kept errors stay bounded
+func TestReporterBounded(t *testing.T) {
+	clock := newFakeClock()
+	rep := NewReporter("ImportRows", nil, WithClock(clock), WithMaxKeys(2), WithReportInterval(time.Second))
+
+	errs := make([]error, maxReporterErrors+10)
+	for i := range errs {
+		errs[i] = NewProcessingError("row rejected", fmt.Sprintf("Import%d", i))
+	}
+	var wg sync.WaitGroup
+	for _, err := range errs {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			rep.Report(err)
+		}()
+	}
+	wg.Wait()
+
+	if len(rep.seen) > 2 {
+		t.Errorf("tracking %d fingerprints, want at most 2", len(rep.seen))
+	}
+	var degraded *DegradedError
+	if err := rep.Close(); !As(err, &degraded) || len(degraded.Errors) != maxReporterErrors || degraded.ErrorCount != len(errs) {
+		t.Errorf("Close() = %+v, want %d kept of %d", degraded, maxReporterErrors, len(errs))
+	}
+}
@@ -0,0 +1,82 @@
+package errors
+
+import (
+	"net/http"
+	"sync"
+)
+
+// Request ID headers recognized by default.
+const (
+	HeaderRequestID     = "X-Request-Id"
+	HeaderAmznRequestID = "X-Amzn-RequestId"
+	HeaderCFRay         = "CF-Ray"
+)
+
+var (
+	requestIDMu      sync.RWMutex
+	requestIDHeaders = defaultRequestIDHeaders()
+)
+
+func defaultRequestIDHeaders() []string {
+	return []string{HeaderRequestID, HeaderAmznRequestID, HeaderCFRay}
+}
+
+// RegisterRequestIDHeader adds a response header carrying a provider's
+// request ID (see RequestIDFromHeader). X-Request-Id, X-Amzn-RequestId and
+// CF-Ray are registered by default; headers are consulted in registration
+// order.
+//
+// Example:
+//
+//	errors.RegisterRequestIDHeader("X-Stripe-Request-Id")
+func RegisterRequestIDHeader(name string) {
+	requestIDMu.Lock()
+	defer requestIDMu.Unlock()
+	requestIDHeaders = append(requestIDHeaders, name)
+}
+
+// ResetRequestIDHeaders restores the default request ID headers. Intended
+// for tests.
+func ResetRequestIDHeaders() {
+	requestIDMu.Lock()
+	defer requestIDMu.Unlock()
+	requestIDHeaders = defaultRequestIDHeaders()
+}
+
+// RequestIDFromHeader returns the value of the first registered request ID
+// header set in h (see RegisterRequestIDHeader).
+func RequestIDFromHeader(h http.Header) (string, bool) {
+	requestIDMu.RLock()
+	defer requestIDMu.RUnlock()
+
+	for _, name := range requestIDHeaders {
+		if id := h.Get(name); id != "" {
+			return id, true
+		}
+	}
+	return "", false
+}
+
+// GetUpstreamRequestID returns the provider request ID recorded on the
+// outermost HTTPError or ProviderError in err's chain that has one (see
+// FromHTTPResponse, FromProviderError and WithUpstreamRequestID), for
+// quoting in support tickets.
+//
+// Example:
+//
+//	if id := errors.GetUpstreamRequestID(err); id != "" {
+//	    ticket.Add("provider request", id)
+//	}
+func GetUpstreamRequestID(err error) string {
+	var id string
+	walkChain(err, func(node error, _ int) bool {
+		switch e := node.(type) {
+		case *HTTPError:
+			id = e.UpstreamRequestID
+		case *ProviderError:
+			id = e.RequestID
+		}
+		return id == ""
+	})
+	return id
+}
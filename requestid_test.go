@@ -0,0 +1,60 @@
+package errors
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+)
+
+// TestUpstreamRequestID tests capturing provider request IDs from response headers
+func TestUpstreamRequestID(t *testing.T) {
+	t.Cleanup(ResetRequestIDHeaders)
+	RegisterRequestIDHeader("X-Stripe-Request-Id")
+
+	tests := []struct {
+		name   string
+		header http.Header
+		want   string
+	}{
+		{name: "X-Request-Id", header: http.Header{"X-Request-Id": {"req-123"}}, want: "req-123"},
+		{name: "AWS", header: http.Header{"X-Amzn-Requestid": {"aws-456"}}, want: "aws-456"},
+		{name: "Cloudflare", header: http.Header{"Cf-Ray": {"8f1c2d-AMS"}}, want: "8f1c2d-AMS"},
+		{name: "registered header", header: http.Header{"X-Stripe-Request-Id": {"req_789"}}, want: "req_789"},
+		{name: "first registered wins", header: http.Header{"Cf-Ray": {"ray"}, "X-Request-Id": {"req-123"}}, want: "req-123"},
+		{name: "none", header: http.Header{"Content-Type": {"text/plain"}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp := &http.Response{StatusCode: http.StatusBadGateway, Header: tt.header}
+			err := Wrap(FromHTTPResponse(resp), "charging card")
+
+			if got := GetUpstreamRequestID(err); got != tt.want {
+				t.Errorf("GetUpstreamRequestID() = %q, want %q", got, tt.want)
+			}
+			info := ExtractErrorInfo(err)
+			if got, _ := info["upstream_request_id"].(string); got != tt.want {
+				t.Errorf("ExtractErrorInfo()[upstream_request_id] = %v, want %q", info["upstream_request_id"], tt.want)
+			}
+			if tt.want != "" && !strings.Contains(err.Error(), "[request "+tt.want+"]") {
+				t.Errorf("Error() = %q, should mention the request ID", err.Error())
+			}
+		})
+	}
+
+	t.Run("option and transport", func(t *testing.T) {
+		err := NewHTTPError(503, "Service Unavailable", nil, WithUpstreamRequestID("req-1"))
+		if got := err.Error(); got != "HTTP 503: Service Unavailable [request req-1]" {
+			t.Errorf("Error() = %q", got)
+		}
+
+		data, marshalErr := MarshalError(err)
+		if marshalErr != nil {
+			t.Fatal(marshalErr)
+		}
+		decoded, decodeErr := UnmarshalError(data)
+		if decodeErr != nil || GetUpstreamRequestID(decoded) != "req-1" {
+			t.Errorf("decoded %v (%v) should keep the request ID", decoded, decodeErr)
+		}
+	})
+}
@@ -5,7 +5,8 @@ package errors
 
 import (
 	"fmt"
-	"strings"
+
+	"github.com/cockroachdb/errors/errbase"
 )
 
 // Resilience sentinel errors for circuit breaker and retry failures.
@@ -32,44 +33,77 @@ type CircuitCounts struct {
 }
 
 // RetryError provides structured context for retry exhaustion.
-// Wraps ErrRetryExhausted sentinel so errors.Is() works.
+// Unwraps to both the ErrRetryExhausted sentinel and LastError, so
+// errors.Is and errors.As see the sentinel as well as what the final
+// attempt failed with, such as a 429 HTTPError. It is never retryable,
+// whatever LastError is.
 type RetryError struct {
 	Attempts    int
 	MaxAttempts int
 	LastError   error
 	AllErrors   []error
+	Reason      string    // why retrying stopped early, e.g. RetryReasonBudgetExhaustedUpstream
+	History     []Attempt // each attempt's retry decision, when the executor recorded them
 	Operation   string
 	Component   string
+	Code        string
+	Owner       string
+	Metadata    map[string]any
+
+	state errorState
 }
 
 func (e *RetryError) Error() string {
-	var sb strings.Builder
-
-	opStr := e.Operation
-	if e.Component != "" {
-		opStr = fmt.Sprintf("%s/%s", e.Component, e.Operation)
+	if e == nil {
+		return "<nil RetryError>"
 	}
+	return e.formatWithCause(formatCause(e.LastError))
+}
 
-	sb.WriteString(fmt.Sprintf("retry exhausted after %d/%d attempts", e.Attempts, e.MaxAttempts))
-
-	if opStr != "" {
-		sb.WriteString(fmt.Sprintf(" for %s", opStr))
+func (e *RetryError) formatWithCause(cause string) string {
+	if e == nil {
+		return "<nil RetryError>"
+	}
+	head := fmt.Sprintf("retry exhausted after %d/%d attempts", e.Attempts, e.MaxAttempts)
+	if e.Reason != "" {
+		head += fmt.Sprintf(" (%s)", e.Reason)
 	}
+	head = joinWords(head, clause("for", opLabel(e.Component, e.Operation)))
+	return joinDetails(head, cause)
+}
 
-	if e.LastError != nil {
-		sb.WriteString(fmt.Sprintf(": %v", e.LastError))
+func (e *RetryError) causeError() error {
+	if e == nil {
+		return nil
 	}
+	return e.LastError
+}
 
-	return sb.String()
+// Unwrap returns ErrRetryExhausted followed by LastError, when set.
+func (e *RetryError) Unwrap() []error {
+	if e == nil {
+		return nil
+	}
+	if e.LastError == nil {
+		return []error{ErrRetryExhausted}
+	}
+	return []error{ErrRetryExhausted, e.LastError}
 }
 
-// Unwrap returns the sentinel error for errors.Is() compatibility.
-func (e *RetryError) Unwrap() error {
-	return ErrRetryExhausted
+// StackTrace returns the stack from where the error was created. It
+// implements the cockroachdb/errors stack trace provider interface.
+func (e *RetryError) StackTrace() errbase.StackTrace {
+	if e == nil {
+		return nil
+	}
+	return e.state.stackTrace()
 }
 
 // IsRetryable returns false - retry exhaustion means no more retries should occur.
 func (e *RetryError) IsRetryable() bool {
+	if e == nil {
+		return false
+	}
 	return false
 }
 
@@ -81,8 +115,6 @@ func NewRetryError(attempts, maxAttempts int, lastError error, allErrors []error
 		LastError:   lastError,
 		AllErrors:   allErrors,
 	}
-	for _, opt := range opts {
-		opt(err)
-	}
+	applyOptions(err, opts)
 	return err
 }
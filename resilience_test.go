@@ -1,6 +1,7 @@
 package errors
 
 import (
+	"context"
 	"fmt"
 	"testing"
 )
@@ -86,6 +87,43 @@ func TestRetryError(t *testing.T) {
 		}
 	})
 
+	t.Run("unwrap reaches the last error", func(t *testing.T) {
+		rateLimited := NewHTTPError(429, "slow down", nil)
+		err := NewRetryError(3, 3, rateLimited, nil)
+
+		if !Is(err, ErrRetryExhausted) {
+			t.Error("the sentinel should stay reachable alongside the last error")
+		}
+		if httpErr, ok := IsHTTPError(err); !ok || httpErr != rateLimited {
+			t.Errorf("IsHTTPError() = %v, want the final attempt's 429", httpErr)
+		}
+		if GetHTTPStatusCode(err) != 429 || HTTPStatus(err) != 429 {
+			t.Errorf("GetHTTPStatusCode() = %d, want 429", GetHTTPStatusCode(err))
+		}
+
+		deadline := NewRetryError(2, 3, Wrap(context.DeadlineExceeded, "attempt 2"), nil)
+		if !Is(deadline, context.DeadlineExceeded) || Classify(deadline) != ClassContext {
+			t.Errorf("Classify() = %v, want the last attempt's context error visible", Classify(deadline))
+		}
+	})
+
+	t.Run("last error does not make it retryable", func(t *testing.T) {
+		for _, lastErr := range []error{
+			NewHTTPError(503, "unavailable", nil),
+			Transient(fmt.Errorf("lock held")),
+			NewProcessingError("failed", "Charge", WithRetryable(true)),
+			fmt.Errorf("rate limit exceeded"),
+		} {
+			err := NewRetryError(3, 3, lastErr, nil)
+			if IsRetryable(err) || IsRetryable(fmt.Errorf("syncing: %w", err)) {
+				t.Errorf("IsRetryable() = true for exhausted retries ending in %v", lastErr)
+			}
+		}
+		if !IsRetryable(Transient(NewRetryError(3, 3, nil, nil))) {
+			t.Error("Transient around the RetryError itself should still override")
+		}
+	})
+
 	t.Run("is not retryable", func(t *testing.T) {
 		err := NewRetryError(3, 3, nil, nil)
 
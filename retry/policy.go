@@ -0,0 +1,133 @@
+package retry
+
+import (
+	"math/rand/v2"
+	"sync"
+	"time"
+
+	errors "github.com/JohnPlummer/jp-go-errors"
+)
+
+// RetryPolicy makes Retry's decision for one failed attempt, for retry
+// loops that aren't Retry's own, such as go-retryablehttp's CheckRetry or
+// retry-go's RetryIf and DelayType. Safe for concurrent use.
+type RetryPolicy struct {
+	cfg config
+
+	mu   sync.Mutex // guards rand, which isn't safe for concurrent use
+	rand *rand.Rand
+}
+
+// NewPolicy returns a RetryPolicy configured with the options Retry
+// accepts. WithSpec applies as in Retry. WithMaxElapsed is ignored, since
+// ShouldRetry isn't told when the first attempt began, and so are
+// WithOnRetry, WithOperation and WithMinHeadroom. Without a context,
+// ShouldRetry also can't honor a retry spec, retry budget or headroom
+// carried on one, nor pass on a primary-read hint; loops that have one
+// should check errors.IsSafeToRetry and errors.RequireHeadroom themselves.
+//
+// Example:
+//
+//	policy := retry.NewPolicy(retry.WithMaxAttempts(5), retry.WithBackoff(time.Second, time.Minute))
+//	err := retrygo.Do(op,
+//	    retrygo.Attempts(5),
+//	    retrygo.RetryIf(func(err error) bool { ok, _ := policy.ShouldRetry(err, 1); return ok }),
+//	    retrygo.DelayType(func(n uint, err error, _ *retrygo.Config) time.Duration {
+//	        _, wait := policy.ShouldRetry(err, int(n)+1)
+//	        return wait
+//	    }))
+func NewPolicy(opts ...RetryOption) *RetryPolicy {
+	return newPolicy(newConfig(opts))
+}
+
+// newPolicy returns the policy for cfg, with its retry spec's attempts and
+// backoff applied.
+func newPolicy(cfg config) *RetryPolicy {
+	if spec := cfg.spec; spec != nil {
+		if spec.MaxAttempts > 0 {
+			cfg.maxAttempts = spec.MaxAttempts
+		}
+		if spec.Backoff != (errors.BackoffPolicy{}) {
+			cfg.backoff = spec.Backoff
+		}
+	}
+	p := &RetryPolicy{cfg: cfg}
+	if p.cfg.randSource != nil {
+		p.rand = rand.New(p.cfg.randSource)
+	}
+	return p
+}
+
+// DefaultPolicy returns the policy Retry applies without options: three
+// attempts with each failure's backoff preset (see errors.PolicyForClass).
+func DefaultPolicy() *RetryPolicy {
+	return NewPolicy()
+}
+
+// WithRandSource draws backoff jitter from src instead of the package's
+// generator (see errors.SetRandSource), making one policy's waits
+// reproducible without affecting any other.
+//
+// Example:
+//
+//	policy := retry.NewPolicy(retry.WithRandSource(rand.NewPCG(1, 2)))
+func WithRandSource(src rand.Source) RetryOption {
+	return func(c *config) {
+		c.randSource = src
+	}
+}
+
+// ShouldRetry reports whether to retry after attempt (1-based) failed with
+// err, and how long to wait first. It never retries an error that
+// errors.IsRetryable rejects, which includes every error
+// errors.IsPermanentError reports, nor once attempt reaches the maximum.
+// The wait is the server's retry-after hint when err carries one (see
+// errors.GetRetryAfter), otherwise the backoff for attempt; it is zero
+// when not retrying.
+//
+// Example:
+//
+//	client.CheckRetry = func(ctx context.Context, resp *http.Response, err error) (bool, error) {
+//	    if err == nil {
+//	        err = errors.FromHTTPResponse(resp)
+//	    }
+//	    retry, _ := policy.ShouldRetry(err, 1)
+//	    return retry, nil
+//	}
+func (p *RetryPolicy) ShouldRetry(err error, attempt int) (bool, time.Duration) {
+	plan := p.plan(err, attempt)
+	if !plan.Retry || plan.StopReason != "" {
+		return false, 0
+	}
+	return true, plan.Delay
+}
+
+// plan explains ShouldRetry's decision. A plan that stops on a retryable
+// error has StopReason set.
+func (p *RetryPolicy) plan(err error, attempt int) errors.RetryPlan {
+	policy := p.cfg.backoff
+	if policy == (errors.BackoffPolicy{}) {
+		policy = errors.PolicyForClass(err)
+	}
+	jitter := policy.Jitter
+	if p.rand != nil {
+		policy.Jitter = 0
+	}
+
+	plan := errors.ExplainRetryPlan(err, attempt, policy)
+	if errors.IsPermanentError(err) || p.cfg.spec != nil && !p.cfg.spec.Retries(err) {
+		plan.Retry = false
+	}
+	if !plan.Retry {
+		return plan
+	}
+	if p.rand != nil && jitter > 0 && plan.Source == errors.DelaySourcePolicy {
+		p.mu.Lock()
+		plan.Delay -= time.Duration(float64(plan.Delay) * min(jitter, 1) * p.rand.Float64())
+		p.mu.Unlock()
+	}
+	if attempt >= p.cfg.maxAttempts {
+		plan.StopReason = errors.RetryStopMaxAttempts
+	}
+	return plan
+}
@@ -0,0 +1,74 @@
+package retry
+
+import (
+	"fmt"
+	"math/rand/v2"
+	"testing"
+	"time"
+
+	errors "github.com/JohnPlummer/jp-go-errors"
+)
+
+// TestShouldRetry tests the decision and wait for each kind of failure
+func TestShouldRetry(t *testing.T) {
+	unavailable := errors.NewHTTPError(503, "Service Unavailable", nil)
+	backoff := WithBackoff(time.Second, time.Minute)
+
+	tests := []struct {
+		name      string
+		err       error
+		attempt   int
+		opts      []RetryOption
+		wantRetry bool
+		wantWait  time.Duration
+	}{
+		{name: "nil", err: nil, attempt: 1},
+		{name: "permanent", err: errors.NewValidationError("must be positive", "amount"), attempt: 1},
+		{name: "unclassified", err: fmt.Errorf("boom"), attempt: 1},
+		{name: "first retry", err: unavailable, attempt: 1, opts: []RetryOption{backoff}, wantRetry: true, wantWait: time.Second},
+		{name: "backoff grows", err: unavailable, attempt: 2, opts: []RetryOption{backoff}, wantRetry: true, wantWait: 2 * time.Second},
+		{name: "rate limit hint", err: errors.NewRateLimitError("slow down", "Charge", 5*time.Second), attempt: 1, opts: []RetryOption{backoff}, wantRetry: true, wantWait: 5 * time.Second},
+		{name: "default max attempts", err: unavailable, attempt: 3},
+		{name: "raised max attempts", err: unavailable, attempt: 3, opts: []RetryOption{backoff, WithMaxAttempts(5)}, wantRetry: true, wantWait: 4 * time.Second},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			policy := NewPolicy(append(tt.opts, WithRandSource(zeroSource{}))...)
+			retry, wait := policy.ShouldRetry(tt.err, tt.attempt)
+			if retry != tt.wantRetry || wait != tt.wantWait {
+				t.Errorf("ShouldRetry() = %v, %v; want %v, %v", retry, wait, tt.wantRetry, tt.wantWait)
+			}
+		})
+	}
+}
+
+// TestPolicyRandSource tests that a policy's own rand source makes its jitter reproducible
+func TestPolicyRandSource(t *testing.T) {
+	unavailable := errors.NewHTTPError(503, "Service Unavailable", nil)
+	waits := func(policy *RetryPolicy) []time.Duration {
+		var got []time.Duration
+		for attempt := 1; attempt <= 4; attempt++ {
+			_, wait := policy.ShouldRetry(unavailable, attempt)
+			got = append(got, wait)
+		}
+		return got
+	}
+
+	opts := []RetryOption{WithMaxAttempts(5), WithBackoff(time.Second, time.Minute)}
+	first := waits(NewPolicy(append(opts, WithRandSource(rand.NewPCG(1, 2)))...))
+	second := waits(NewPolicy(append(opts, WithRandSource(rand.NewPCG(1, 2)))...))
+	if fmt.Sprint(first) != fmt.Sprint(second) {
+		t.Errorf("waits with the same seed differ: %v and %v", first, second)
+	}
+
+	_, wait := NewPolicy(WithBackoff(time.Second, time.Minute), WithRandSource(maxSource{})).ShouldRetry(unavailable, 1)
+	if wait < 800*time.Millisecond || wait >= time.Second {
+		t.Errorf("wait = %v, want up to 20%% jitter taken off 1s", wait)
+	}
+}
+
+// maxSource makes backoff jitter as large as it can be.
+type maxSource struct{}
+
+func (maxSource) Uint64() uint64 { return ^uint64(0) }
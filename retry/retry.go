@@ -0,0 +1,257 @@
+// Package retry runs operations again until they succeed, using
+// jp-go-errors' classification to decide whether a failure is worth
+// another attempt and how long to wait first.
+package retry
+
+import (
+	"context"
+	"math/rand/v2"
+	"time"
+
+	errors "github.com/JohnPlummer/jp-go-errors"
+)
+
+// defaultMaxAttempts is the number of attempts Retry makes without
+// WithMaxAttempts.
+const defaultMaxAttempts = 3
+
+// RetryOption configures Retry and NewPolicy.
+type RetryOption func(*config)
+
+// config holds the settings of one Retry call or RetryPolicy.
+type config struct {
+	maxAttempts int
+	maxElapsed  time.Duration
+	backoff     errors.BackoffPolicy
+	onRetry     func(errors.Attempt)
+	randSource  rand.Source
+	spec        *errors.RetrySpec
+	operation   string
+	minHeadroom time.Duration
+}
+
+// WithMaxAttempts caps the attempts, including the first. Defaults to 3;
+// values below 1 mean a single attempt.
+func WithMaxAttempts(n int) RetryOption {
+	return func(c *config) {
+		c.maxAttempts = max(n, 1)
+	}
+}
+
+// WithMaxElapsed stops retrying once the next attempt would start more
+// than d after the first began. Zero, the default, sets no limit.
+func WithMaxElapsed(d time.Duration) RetryOption {
+	return func(c *config) {
+		c.maxElapsed = d
+	}
+}
+
+// WithBackoff waits base before the first retry, doubling up to maxDelay,
+// with errors.DefaultBackoffPolicy's jitter. A server's retry-after hint
+// still wins (see errors.ExplainRetryPlan). Without it, each failure gets
+// the preset errors.PolicyForClass picks for it.
+//
+// Example:
+//
+//	err := retry.Retry(ctx, op, retry.WithBackoff(50*time.Millisecond, 5*time.Second))
+func WithBackoff(base, maxDelay time.Duration) RetryOption {
+	return WithBackoffPolicy(errors.BackoffPolicy{
+		Initial:    base,
+		Max:        maxDelay,
+		Multiplier: 2,
+		Jitter:     errors.DefaultBackoffPolicy.Jitter,
+	})
+}
+
+// WithBackoffPolicy sets the backoff between attempts, for callers that
+// already hold an errors.BackoffPolicy. The zero policy picks a preset for
+// each failure with errors.PolicyForClass, as without the option.
+func WithBackoffPolicy(policy errors.BackoffPolicy) RetryOption {
+	return func(c *config) {
+		c.backoff = policy
+	}
+}
+
+// WithOnRetry calls callback after each failed attempt that will be
+// retried, before waiting, with the attempt's error and retry plan.
+//
+// Example:
+//
+//	retry.WithOnRetry(func(a errors.Attempt) {
+//	    logger.Warn("retrying", "plan", a.Plan.String(), "error", a.Err)
+//	})
+func WithOnRetry(callback func(errors.Attempt)) RetryOption {
+	return func(c *config) {
+		c.onRetry = callback
+	}
+}
+
+// WithSpec applies spec (see errors.ParseRetrySpec) when the context
+// carries none (see errors.ContextWithRetrySpec): its MaxAttempts and
+// Backoff override WithMaxAttempts and WithBackoff where set, and its On
+// patterns narrow which failures are retried. Its Idempotent flag only
+// matters to HTTP methods (see errors.RetrySpec.AllowsMethod); Retry can't
+// tell whether op is idempotent, so calling Retry is taken as the caller's
+// word that it is.
+//
+// Example:
+//
+//	err := retry.Retry(ctx, op, retry.WithSpec(errors.MustParseRetrySpec("max=4,on=5xx,429")))
+func WithSpec(spec errors.RetrySpec) RetryOption {
+	return func(c *config) {
+		c.spec = &spec
+	}
+}
+
+// WithOperation names the operation in the errors Retry builds itself: the
+// RetryError when retries run out and the TimeoutError when there isn't
+// enough headroom for the first attempt.
+func WithOperation(name string) RetryOption {
+	return func(c *config) {
+		c.operation = name
+	}
+}
+
+// WithMinHeadroom sets the least time an attempt needs before ctx's
+// deadline (see errors.RequireHeadroom). The headroom recorded on ctx by
+// errors.WithHeadroom applies when larger, and also without the option.
+func WithMinHeadroom(d time.Duration) RetryOption {
+	return func(c *config) {
+		c.minHeadroom = d
+	}
+}
+
+// Retry calls op until it succeeds, returns an error that isn't worth
+// retrying, or runs out of attempts. A failure is retried only while
+// errors.IsRetryable reports true; an error for which it reports false,
+// such as one errors.IsPermanentError reports, is returned as is at once.
+// The wait before each retry comes from errors.ExplainRetryPlan: the
+// server's retry-after hint (see errors.GetRetryAfter) when there is one,
+// otherwise exponential backoff with jitter (see WithBackoff). Waits use
+// errors.Sleep, so a fake clock set with errors.SetClock drives them in
+// tests.
+//
+// Retry honors the same contracts as httperrors.Transport, which is built
+// on it:
+//   - A retry spec on ctx (see errors.ContextWithRetrySpec), else WithSpec,
+//     sets the attempts and backoff and narrows what is retried.
+//   - Retries are spent from the retry budget on ctx (see
+//     errors.ContextWithRetryBudget); without one, the call starts a budget
+//     of one less than the attempts. op gets the budget on its context, so
+//     calls it makes to other services share it.
+//   - An attempt that can't finish before ctx's deadline isn't made (see
+//     WithMinHeadroom): the first fails with errors.RequireHeadroom's
+//     TimeoutError, and later ones stop the retries.
+//   - After a failure that should be retried against the primary (see
+//     errors.ShouldRetryAgainstPrimary), later attempts get a context
+//     marked with errors.ContextWithPrimaryRead.
+//   - Each retried failure records its attempt number (see
+//     errors.StampAttempt) before WithOnRetry sees it.
+//
+// When ctx is done, Retry stops immediately: before an attempt or during a
+// wait it returns ctx's error, and after a failed attempt it returns that
+// attempt's error. When attempts, the budget, the headroom or
+// WithMaxElapsed run out on a retryable failure, it returns an
+// *errors.RetryError holding the last error, every attempt's error and
+// retry plan (see errors.WithAttemptHistory), with Reason
+// errors.RetryStopMaxElapsed or one of the budget reasons (see
+// errors.RetryReasonBudgetExhausted) when those stopped it.
+//
+// Example:
+//
+//	err := retry.Retry(ctx, func(ctx context.Context) error {
+//	    return client.Charge(ctx, order)
+//	}, retry.WithMaxAttempts(5), retry.WithMaxElapsed(time.Minute))
+//	var retryErr *errors.RetryError
+//	if errors.As(err, &retryErr) {
+//	    logger.Error("charge failed", "attempts", retryErr.Attempts, "error", retryErr.LastError)
+//	}
+func Retry(ctx context.Context, op func(context.Context) error, opts ...RetryOption) error {
+	cfg := newConfig(opts)
+	if spec, ok := errors.RetrySpecFromContext(ctx); ok {
+		cfg.spec = &spec
+	}
+	policy := newPolicy(cfg)
+	cfg = policy.cfg
+
+	headroom := cfg.minHeadroom
+	if need, ok := errors.HeadroomFromContext(ctx); ok {
+		headroom = max(headroom, need)
+	}
+	if err := errors.RequireHeadroom(ctx, headroom, cfg.operation); err != nil {
+		return err
+	}
+	budget, ok := errors.RetryBudgetFromContext(ctx)
+	if !ok {
+		budget = errors.NewRetryBudget(cfg.maxAttempts - 1)
+		ctx = errors.ContextWithRetryBudget(ctx, budget)
+	}
+
+	var (
+		start       = errors.Now()
+		attemptCtx  = ctx
+		attemptErrs []error
+		history     []errors.Attempt
+	)
+	for attempt := 1; ; attempt++ {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		err := op(attemptCtx)
+		if err == nil {
+			return nil
+		}
+		if ctx.Err() != nil {
+			return err
+		}
+		plan := policy.plan(err, attempt)
+		if !plan.Retry {
+			return err
+		}
+
+		err = errors.StampAttempt(err, attempt, cfg.maxAttempts)
+		attemptErrs = append(attemptErrs, err)
+		var reason string
+		if plan.StopReason == "" {
+			switch {
+			case cfg.maxElapsed > 0 && errors.Now().Add(plan.Delay).Sub(start) > cfg.maxElapsed:
+				reason = errors.RetryStopMaxElapsed
+				plan.StopReason = reason
+			case headroom > 0 && errors.RequireHeadroom(ctx, plan.Delay+headroom, cfg.operation) != nil:
+				plan.StopReason = errors.RetryStopHeadroom
+			case !errors.IsSafeToRetry(ctx, err) || !budget.Take():
+				reason = errors.RetryReasonBudgetExhausted
+				if budget.Propagated() {
+					reason = errors.RetryReasonBudgetExhaustedUpstream
+				}
+				plan.StopReason = reason
+			}
+		}
+		history = append(history, errors.Attempt{Number: attempt, Err: err, Plan: plan})
+
+		if plan.StopReason != "" {
+			return errors.NewRetryError(attempt, cfg.maxAttempts, err, attemptErrs,
+				errors.WithOperation(cfg.operation),
+				errors.WithReason(reason),
+				errors.WithAttemptHistory(history))
+		}
+		if errors.ShouldRetryAgainstPrimary(err) {
+			attemptCtx = errors.ContextWithPrimaryRead(attemptCtx)
+		}
+		if cfg.onRetry != nil {
+			cfg.onRetry(history[len(history)-1])
+		}
+		if err := errors.Sleep(ctx, plan.Delay); err != nil {
+			return err
+		}
+	}
+}
+
+// newConfig applies opts to the defaults.
+func newConfig(opts []RetryOption) config {
+	cfg := config{maxAttempts: defaultMaxAttempts}
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+	return cfg
+}
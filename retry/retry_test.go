@@ -0,0 +1,387 @@
+package retry
+
+import (
+	"context"
+	stderrors "errors"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	errors "github.com/JohnPlummer/jp-go-errors"
+	"github.com/JohnPlummer/jp-go-errors/errtest"
+)
+
+// useFakeClock makes waits advance a fake clock instead of sleeping.
+func useFakeClock(t *testing.T) *errtest.FakeClock {
+	t.Helper()
+	clock := errtest.NewFakeClock(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+	errors.SetClock(clock)
+	t.Cleanup(func() { errors.SetClock(nil) })
+	return clock
+}
+
+// failing returns an op that fails with errs in turn, then succeeds, and
+// counts its calls.
+func failing(calls *int, errs ...error) func(context.Context) error {
+	return func(context.Context) error {
+		*calls++
+		if *calls <= len(errs) {
+			return errs[*calls-1]
+		}
+		return nil
+	}
+}
+
+// TestRetry tests the attempts made and the error returned for each kind of failure
+func TestRetry(t *testing.T) {
+	unavailable := errors.NewHTTPError(503, "Service Unavailable", nil)
+	invalid := errors.NewValidationError("must be positive", "amount")
+	untyped := fmt.Errorf("boom")
+
+	tests := []struct {
+		name      string
+		errs      []error
+		opts      []RetryOption
+		wantCalls int
+		wantErr   error // returned as is unless wantRetry
+		wantRetry bool
+	}{
+		{name: "succeeds first time", wantCalls: 1},
+		{name: "succeeds after retries", errs: []error{unavailable, unavailable}, wantCalls: 3},
+		{name: "permanent returned at once", errs: []error{invalid}, wantCalls: 1, wantErr: invalid},
+		{name: "unclassified returned at once", errs: []error{untyped}, wantCalls: 1, wantErr: untyped},
+		{name: "permanent after retryable", errs: []error{unavailable, invalid}, wantCalls: 2, wantErr: invalid},
+		{name: "exhausted", errs: []error{unavailable, unavailable, unavailable, unavailable}, wantCalls: 3, wantRetry: true},
+		{name: "max attempts", errs: []error{unavailable, unavailable}, opts: []RetryOption{WithMaxAttempts(2)}, wantCalls: 2, wantRetry: true},
+		{name: "single attempt", errs: []error{unavailable}, opts: []RetryOption{WithMaxAttempts(0)}, wantCalls: 1, wantRetry: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			useFakeClock(t)
+			calls, retries := 0, 0
+			opts := append(tt.opts, WithOnRetry(func(errors.Attempt) { retries++ }))
+			err := Retry(context.Background(), failing(&calls, tt.errs...), opts...)
+
+			if calls != tt.wantCalls {
+				t.Errorf("op called %d times, want %d", calls, tt.wantCalls)
+			}
+			if tt.wantRetry {
+				retryErr, ok := err.(*errors.RetryError)
+				if !ok {
+					t.Fatalf("Retry() = %v, want a RetryError", err)
+				}
+				if retryErr.Attempts != calls || errors.GetHTTPStatusCode(retryErr.LastError) != 503 || len(retryErr.AllErrors) != calls {
+					t.Errorf("RetryError = %d attempts, last %v, %d errors", retryErr.Attempts, retryErr.LastError, len(retryErr.AllErrors))
+				}
+				if last := retryErr.History[len(retryErr.History)-1].Plan; last.StopReason != errors.RetryStopMaxAttempts {
+					t.Errorf("last plan stopped with %q, want %q", last.StopReason, errors.RetryStopMaxAttempts)
+				}
+				if retries != calls-1 {
+					t.Errorf("OnRetry called %d times, want %d", retries, calls-1)
+				}
+				return
+			}
+			if err != tt.wantErr {
+				t.Errorf("Retry() = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+// TestRetryWaits tests that a server's retry-after hint sets the wait and the backoff applies otherwise
+func TestRetryWaits(t *testing.T) {
+	tests := []struct {
+		name       string
+		err        error
+		opts       []RetryOption
+		wantWaited time.Duration
+	}{
+		{name: "rate limit hint", err: errors.NewRateLimitError("slow down", "Charge", 2*time.Second), wantWaited: 2 * time.Second},
+		{name: "hint beats backoff", err: errors.NewRateLimitError("slow down", "Charge", 2*time.Second), opts: []RetryOption{WithBackoff(time.Minute, time.Hour)}, wantWaited: 2 * time.Second},
+		{name: "backoff", err: errors.NewHTTPError(503, "Service Unavailable", nil), opts: []RetryOption{WithBackoff(time.Second, time.Second)}, wantWaited: time.Second},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			clock := useFakeClock(t)
+			errors.SetRandSource(zeroSource{})
+			t.Cleanup(func() { errors.SetRandSource(nil) })
+
+			calls := 0
+			start := clock.Now()
+			if err := Retry(context.Background(), failing(&calls, tt.err), tt.opts...); err != nil {
+				t.Fatalf("Retry() error = %v", err)
+			}
+			if waited := clock.Since(start); waited != tt.wantWaited {
+				t.Errorf("waited %v, want %v", waited, tt.wantWaited)
+			}
+		})
+	}
+}
+
+// TestRetryMaxElapsed tests that retrying stops before a wait would run past the time limit
+func TestRetryMaxElapsed(t *testing.T) {
+	useFakeClock(t)
+	unavailable := errors.NewHTTPError(503, "Service Unavailable", nil)
+
+	calls := 0
+	err := Retry(context.Background(), failing(&calls, unavailable, unavailable, unavailable),
+		WithMaxAttempts(10), WithBackoff(10*time.Second, time.Minute), WithMaxElapsed(15*time.Second))
+
+	retryErr, ok := err.(*errors.RetryError)
+	if !ok {
+		t.Fatalf("Retry() = %v, want a RetryError", err)
+	}
+	if calls != 2 || retryErr.Reason != errors.RetryStopMaxElapsed {
+		t.Errorf("stopped after %d calls with reason %q, want 2 calls and %q", calls, retryErr.Reason, errors.RetryStopMaxElapsed)
+	}
+}
+
+// TestRetryContext tests that cancellation and deadlines stop Retry at once
+func TestRetryContext(t *testing.T) {
+	t.Run("deadline mid-backoff", func(t *testing.T) {
+		ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+		defer cancel()
+
+		calls := 0
+		began := time.Now()
+		err := Retry(ctx, failing(&calls, errors.NewHTTPError(503, "Service Unavailable", nil)),
+			WithBackoff(time.Minute, time.Minute))
+
+		if !stderrors.Is(err, context.DeadlineExceeded) {
+			t.Errorf("Retry() = %v, want context.DeadlineExceeded", err)
+		}
+		if calls != 1 {
+			t.Errorf("op called %d times, want 1", calls)
+		}
+		if elapsed := time.Since(began); elapsed > 10*time.Second {
+			t.Errorf("Retry() returned after %v, want it to stop at the deadline", elapsed)
+		}
+	})
+
+	t.Run("canceled before first attempt", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		calls := 0
+		if err := Retry(ctx, failing(&calls)); !stderrors.Is(err, context.Canceled) || calls != 0 {
+			t.Errorf("Retry() = %v after %d calls, want context.Canceled without calling op", err, calls)
+		}
+	})
+
+	t.Run("canceled by the attempt", func(t *testing.T) {
+		useFakeClock(t)
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		unavailable := errors.NewHTTPError(503, "Service Unavailable", nil)
+		calls := 0
+		err := Retry(ctx, func(context.Context) error {
+			calls++
+			cancel()
+			return unavailable
+		})
+		if err != unavailable || calls != 1 {
+			t.Errorf("Retry() = %v after %d calls, want the attempt's error after 1", err, calls)
+		}
+	})
+}
+
+// TestRetrySpec tests that a retry spec sets the attempts and narrows what is retried, with the context's winning
+func TestRetrySpec(t *testing.T) {
+	unavailable := errors.NewHTTPError(503, "Service Unavailable", nil)
+	badGateway := errors.NewHTTPError(502, "Bad Gateway", nil)
+	onlyUnavailable := errors.MustParseRetrySpec("max=5,backoff=none,on=503")
+
+	tests := []struct {
+		name      string
+		ctx       context.Context
+		opts      []RetryOption
+		errs      []error
+		wantCalls int
+	}{
+		{name: "option spec raises attempts", opts: []RetryOption{WithSpec(onlyUnavailable)}, errs: []error{unavailable, unavailable, unavailable, unavailable}, wantCalls: 5},
+		{name: "option spec narrows retries", opts: []RetryOption{WithSpec(onlyUnavailable)}, errs: []error{badGateway}, wantCalls: 1},
+		{name: "context spec", ctx: errors.ContextWithRetrySpec(context.Background(), onlyUnavailable), errs: []error{unavailable, unavailable, unavailable, unavailable}, wantCalls: 5},
+		{name: "context spec wins", ctx: errors.ContextWithRetrySpec(context.Background(), errors.MustParseRetrySpec("never")), opts: []RetryOption{WithSpec(onlyUnavailable)}, errs: []error{unavailable}, wantCalls: 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			useFakeClock(t)
+			ctx := tt.ctx
+			if ctx == nil {
+				ctx = context.Background()
+			}
+			calls := 0
+			_ = Retry(ctx, failing(&calls, append(tt.errs, unavailable, unavailable, unavailable, unavailable, unavailable)...), tt.opts...)
+			if calls != tt.wantCalls {
+				t.Errorf("op called %d times, want %d", calls, tt.wantCalls)
+			}
+		})
+	}
+}
+
+// TestRetryBudget tests that retries are spent from the context's budget, which op sees
+func TestRetryBudget(t *testing.T) {
+	unavailable := errors.NewHTTPError(503, "Service Unavailable", nil)
+
+	t.Run("own budget", func(t *testing.T) {
+		useFakeClock(t)
+		var remaining []int
+		err := Retry(context.Background(), func(ctx context.Context) error {
+			budget, _ := errors.RetryBudgetFromContext(ctx)
+			remaining = append(remaining, budget.Remaining())
+			return unavailable
+		})
+		var retryErr *errors.RetryError
+		if fmt.Sprint(remaining) != "[2 1 0]" || !errors.As(err, &retryErr) {
+			t.Errorf("budget left per attempt = %v (%v), want [2 1 0]", remaining, err)
+		}
+	})
+
+	t.Run("shared budget", func(t *testing.T) {
+		useFakeClock(t)
+		budget := errors.NewRetryBudget(1)
+		ctx := errors.ContextWithRetryBudget(context.Background(), budget)
+		calls := 0
+		err := Retry(ctx, failing(&calls, unavailable, unavailable, unavailable), WithMaxAttempts(5))
+
+		var retryErr *errors.RetryError
+		if !errors.As(err, &retryErr) {
+			t.Fatalf("Retry() = %v, want a RetryError", err)
+		}
+		if calls != 2 || retryErr.Reason != errors.RetryReasonBudgetExhausted || budget.Remaining() != 0 {
+			t.Errorf("stopped after %d calls with reason %q, %d left", calls, retryErr.Reason, budget.Remaining())
+		}
+	})
+
+	t.Run("upstream budget", func(t *testing.T) {
+		useFakeClock(t)
+		header := http.Header{}
+		errors.WriteBudgetHeader(header, errors.NewRetryBudget(0))
+		budget, _ := errors.BudgetFromHeader(header)
+		ctx := errors.ContextWithRetryBudget(context.Background(), budget)
+		calls := 0
+		err := Retry(ctx, failing(&calls, unavailable))
+
+		var retryErr *errors.RetryError
+		if !errors.As(err, &retryErr) || calls != 1 || retryErr.Reason != errors.RetryReasonBudgetExhaustedUpstream {
+			t.Errorf("Retry() = %v after %d calls, want the upstream budget reason after 1", err, calls)
+		}
+	})
+}
+
+// TestRetryHeadroom tests that attempts which can't finish before the deadline are skipped
+func TestRetryHeadroom(t *testing.T) {
+	unavailable := errors.NewRateLimitError("slow down", "Quote", 2*time.Second)
+
+	tests := []struct {
+		name        string
+		left        time.Duration
+		minHeadroom time.Duration
+		wantCalls   int
+		wantStop    string
+	}{
+		{name: "first attempt skipped", left: 50 * time.Millisecond, minHeadroom: 100 * time.Millisecond},
+		{name: "retry wait leaves too little", left: 2500 * time.Millisecond, minHeadroom: time.Second, wantCalls: 1, wantStop: errors.RetryStopHeadroom},
+		{name: "enough for every attempt", left: time.Minute, minHeadroom: time.Second, wantCalls: 3, wantStop: errors.RetryStopMaxAttempts},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			clock := useFakeClock(t)
+			ctx := deadlineCtx{Context: context.Background(), deadline: clock.Now().Add(tt.left)}
+			calls := 0
+			err := Retry(ctx, func(context.Context) error {
+				calls++
+				return unavailable
+			}, WithMinHeadroom(tt.minHeadroom), WithOperation("GetQuote"))
+
+			if calls != tt.wantCalls {
+				t.Errorf("op called %d times, want %d", calls, tt.wantCalls)
+			}
+			if tt.wantCalls == 0 {
+				var timeoutErr *errors.TimeoutError
+				if !errors.IsInsufficientHeadroom(err) || !errors.As(err, &timeoutErr) || timeoutErr.Operation != "GetQuote" {
+					t.Errorf("Retry() = %v, want a headroom error for GetQuote", err)
+				}
+				return
+			}
+			var retryErr *errors.RetryError
+			if !errors.As(err, &retryErr) {
+				t.Fatalf("Retry() = %v, want a RetryError", err)
+			}
+			if stop := retryErr.History[len(retryErr.History)-1].Plan.StopReason; stop != tt.wantStop {
+				t.Errorf("StopReason = %q, want %q", stop, tt.wantStop)
+			}
+		})
+	}
+
+	t.Run("WithHeadroom on the context", func(t *testing.T) {
+		clock := useFakeClock(t)
+		deadline := deadlineCtx{Context: context.Background(), deadline: clock.Now().Add(2500 * time.Millisecond)}
+		ctx, err := errors.WithHeadroom(deadline, time.Second)
+		if err != nil {
+			t.Fatalf("WithHeadroom() error = %v", err)
+		}
+		calls := 0
+		if err := Retry(ctx, failing(&calls, unavailable, unavailable)); calls != 1 || err == nil {
+			t.Errorf("Retry() made %d calls (%v), want 1 before the headroom ran out", calls, err)
+		}
+	})
+}
+
+// TestRetryAgainstPrimary tests that a consistency hint reaches later attempts' contexts
+func TestRetryAgainstPrimary(t *testing.T) {
+	useFakeClock(t)
+	var primaryReads []bool
+	err := Retry(context.Background(), func(ctx context.Context) error {
+		primaryReads = append(primaryReads, errors.PrimaryReadRequested(ctx))
+		if len(primaryReads) == 1 {
+			return errors.NewConsistencyError("order/42", "17", "15", time.Second, errors.WithRetryAgainstPrimary())
+		}
+		return nil
+	})
+	if err != nil || fmt.Sprint(primaryReads) != "[false true]" {
+		t.Errorf("Retry() = %v with primary reads %v, want [false true]", err, primaryReads)
+	}
+}
+
+// TestRetryStampsAttempts tests that retried failures record their attempt number, leaving op's error untouched
+func TestRetryStampsAttempts(t *testing.T) {
+	useFakeClock(t)
+	unavailable := errors.NewHTTPError(503, "Service Unavailable", nil)
+	var stamped []string
+	calls := 0
+	err := Retry(context.Background(), failing(&calls, unavailable, unavailable, unavailable), WithOnRetry(func(a errors.Attempt) {
+		n, maxAttempts, _ := errors.GetAttempt(a.Err)
+		stamped = append(stamped, fmt.Sprintf("%d/%d", n, maxAttempts))
+	}))
+
+	var retryErr *errors.RetryError
+	if !errors.As(err, &retryErr) {
+		t.Fatalf("Retry() = %v, want a RetryError", err)
+	}
+	if n, _, _ := errors.GetAttempt(retryErr.LastError); n != 3 || fmt.Sprint(stamped) != "[1/3 2/3]" {
+		t.Errorf("attempts stamped %v, last %d; want [1/3 2/3], last 3", stamped, n)
+	}
+	if _, _, ok := errors.GetAttempt(unavailable); ok {
+		t.Error("op's own error was stamped")
+	}
+}
+
+// deadlineCtx reports a deadline on the fake clock without expiring in real time
+type deadlineCtx struct {
+	context.Context
+	deadline time.Time
+}
+
+func (c deadlineCtx) Deadline() (time.Time, bool) {
+	return c.deadline, true
+}
+
+// zeroSource makes backoff jitter zero.
+type zeroSource struct{}
+
+func (zeroSource) Uint64() uint64 { return 0 }
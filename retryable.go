@@ -2,7 +2,8 @@ package errors
 
 import (
 	"context"
-	"strings"
+	"fmt"
+	"time"
 
 	"github.com/cockroachdb/errors"
 )
@@ -16,21 +17,41 @@ type Retryable interface {
 
 // IsRetryable checks if an error should trigger a retry.
 // It checks in priority order:
-// 1. Context errors (DeadlineExceeded, Canceled) - NOT retryable
-// 2. Any error implementing Retryable interface (generic check)
-// 3. Typed sentinel errors (ErrRateLimited, ErrNetworkTimeout, etc.)
-// 4. HTTPError with retryable status codes (429, 5xx)
-// 5. Defensive fallback for untyped rate limit messages
+//  1. Context errors (DeadlineExceeded, Canceled) - NOT retryable
+//  2. Joined errors (errors.Join, fmt.Errorf with several %w) - retryable
+//     only if at least one branch is and none is permanent
+//  3. Permanent/Transient overrides (see IsForced)
+//  4. The sender's classification of a decoded error (see PreclassifiedClass)
+//  5. Retryable declared for the error's code in the installed MappingTable
+//  6. Any error implementing Retryable interface (generic check)
+//  7. Typed sentinel errors (ErrRateLimited, ErrNetworkTimeout, etc.)
+//  8. HTTPError with a retryable status (see IsRetryableHTTPStatus)
+//  9. Network failures from the standard library: a reset, refused or
+//     broken connection (syscall.ECONNRESET, ECONNREFUSED, EPIPE),
+//     io.ErrUnexpectedEOF, a *net.OpError, a temporary DNS failure or a
+//     net.Error timeout - retryable; a TLS certificate verification error
+//     or a DNS name that doesn't exist - NOT retryable
+//  10. Classifiers added with RegisterRetryableClassifier
+//  11. Defensive fallback for untyped rate limit messages (see
+//     SetStringFallbacks; StrictClassification turns it off)
 //
 // CRITICAL: Context errors are checked FIRST because some error types
 // implement IsRetryable() but may wrap context errors. If context.DeadlineExceeded
 // is wrapped, retrying with the same context will fail immediately - these
 // operations should be abandoned, not retried.
 //
-// The generic Retryable interface check (step 2) works with error types from
+// The generic Retryable interface check (step 6) works with error types from
 // any package, not just go-errors. External packages can define their own
 // error types with IsRetryable() methods, and they will be properly detected.
 //
+// A join counts only when plain wrappers sit above it: a typed error or an
+// error with its own IsRetryable method, such as BatchError, decides for
+// all of its causes. Use AnyRetryable or AllRetryable for a different
+// policy over joined errors.
+//
+// A typed-nil error (see NotNil) is never retryable. ExplainRetryable names
+// the rule that decided.
+//
 // Example usage:
 //
 //	if err != nil {
@@ -42,16 +63,106 @@ type Retryable interface {
 //	    return err // Permanent failure
 //	}
 func IsRetryable(err error) bool {
+	return isRetryable(err, true)
+}
+
+// ExplainRetryable returns IsRetryable's answer for err along with a short
+// reason naming the rule that decided it, for debugging unexpected retry
+// decisions. Reasons start with a fixed phrase that is safe to grep for,
+// such as "context.DeadlineExceeded in chain", "HTTPError status 503",
+// "sentinel ErrRateLimited in chain" or "matched \"rate limit\" string
+// fallback".
+//
+// Example:
+//
+//	retryable, reason := errors.ExplainRetryable(err)
+//	logger.Debug("retry decision", "retryable", retryable, "reason", reason)
+func ExplainRetryable(err error) (bool, string) {
+	return explainRetryable(err, true, true)
+}
+
+// isRetryable implements IsRetryable, optionally skipping the sender's
+// classification for IgnorePreclassification.
+func isRetryable(err error, usePreclassified bool) bool {
+	retryable, _ := explainRetryable(err, usePreclassified, false)
+	return retryable
+}
+
+// retryableSentinels are the sentinels IsRetryable treats as retryable,
+// with the names ExplainRetryable reports.
+var retryableSentinels = []struct {
+	err  error
+	name string
+}{
+	{ErrRateLimited, "ErrRateLimited"},
+	{ErrNetworkTimeout, "ErrNetworkTimeout"},
+	{ErrServerError, "ErrServerError"},
+	{ErrConnectionError, "ErrConnectionError"},
+	{ErrDeadlock, "ErrDeadlock"},
+	{ErrCircuitOpen, "ErrCircuitOpen"},
+}
+
+// explainRetryable decides IsRetryable. Reasons that need formatting are
+// only built when explain is set, keeping IsRetryable allocation-free.
+func explainRetryable(err error, usePreclassified, explain bool) (bool, string) {
 	if err == nil {
-		return false
+		return false, "nil error"
+	}
+	if isTypedNil(err) {
+		return false, "typed nil error"
 	}
 
 	// Context errors are NOT retryable - must check BEFORE interface check.
 	// When context.DeadlineExceeded or context.Canceled occurs, the parent
 	// context is already exceeded or canceled. Retrying with the same context
 	// will fail immediately. These indicate the operation should be abandoned.
-	if errors.Is(err, context.DeadlineExceeded) || errors.Is(err, context.Canceled) {
-		return false
+	if errors.Is(err, context.DeadlineExceeded) {
+		return false, "context.DeadlineExceeded in chain"
+	}
+	if errors.Is(err, context.Canceled) {
+		return false, "context.Canceled in chain"
+	}
+
+	// A join is retryable only when some branch is and none is permanent
+	if branches := joinedBranches(err); branches != nil {
+		var opts []Option
+		if !usePreclassified {
+			opts = append(opts, IgnorePreclassification())
+		}
+		class, reason := explainJoined(branches, opts)
+		return class == ClassTransient, reason
+	}
+
+	// Permanent and Transient overrides win over everything inside them,
+	// including ProcessingError's own flag and cause delegation.
+	if class, by, ok := forcedBy(err); ok {
+		if class == ClassTransient {
+			return true, "forced transient by " + by
+		}
+		return false, "forced permanent by " + by
+	}
+
+	// Errors decoded from a transport were classified by their sender;
+	// re-running the rules here could disagree after version skew.
+	if class, ok := PreclassifiedClass(err); ok && usePreclassified {
+		if !explain {
+			return class == ClassTransient, ""
+		}
+		return class == ClassTransient, fmt.Sprintf("preclassified %s by sender", class)
+	}
+
+	// Exhausted retries stay exhausted, whatever the last attempt failed
+	// with; only rules above this point can override that.
+	if _, ok := outermostTyped(err).(*RetryError); ok {
+		return false, "RetryError: retries exhausted"
+	}
+
+	// A declared retryability for the error's code (see MappingTable)
+	if m, ok := mappingFor(err); ok && m.retryable != nil {
+		if !explain {
+			return *m.retryable, ""
+		}
+		return *m.retryable, fmt.Sprintf("MappingTable declares code %s retryable=%t", GetCode(err), *m.retryable)
 	}
 
 	// Generic check for ANY error implementing Retryable interface.
@@ -60,34 +171,76 @@ func IsRetryable(err error) bool {
 	// Use errors.As() to traverse error chains (handles wrapped errors).
 	var r Retryable
 	if errors.As(err, &r) {
-		return r.IsRetryable()
+		retryable := r.IsRetryable()
+		if !explain {
+			return retryable, ""
+		}
+		return retryable, retryableReason(r, retryable)
 	}
 
 	// Check for typed sentinel errors
-	if errors.Is(err, ErrRateLimited) ||
-		errors.Is(err, ErrNetworkTimeout) ||
-		errors.Is(err, ErrServerError) ||
-		errors.Is(err, ErrConnectionError) ||
-		errors.Is(err, ErrDeadlock) ||
-		errors.Is(err, ErrCircuitOpen) {
-		return true
+	for _, sentinel := range retryableSentinels {
+		if errors.Is(err, sentinel.err) {
+			if !explain {
+				return true, ""
+			}
+			return true, "sentinel " + sentinel.name + " in chain"
+		}
 	}
 
 	// Check for HTTPError with retryable status codes
 	if httpErr, ok := IsHTTPError(err); ok {
-		return httpErr.IsRetryable()
+		if !explain {
+			return httpErr.IsRetryable(), ""
+		}
+		return httpErr.IsRetryable(), retryableReason(httpErr, httpErr.IsRetryable())
+	}
+
+	// Standard library network failures, for callers that don't Adopt them
+	if retryable, reason, ok := explainNetRetryable(err); ok {
+		return retryable, reason
+	}
+
+	// Application classifiers for types that can't implement Retryable
+	if retryable, position, ok := classifyRetryable(err); ok {
+		if !explain {
+			return retryable, ""
+		}
+		return retryable, fmt.Sprintf("RetryableClassifier %d reported %t", position, retryable)
 	}
 
 	// Defensive: Check for rate limit patterns from external APIs we don't control.
 	// This is a fallback for third-party libraries that don't use typed errors.
 	// Prefer wrapping external errors with our typed errors at API boundaries.
-	errMsg := strings.ToLower(err.Error())
-	if strings.Contains(errMsg, "rate limit") {
-		return true
+	// See SetStringFallbacks and StrictClassification.
+	if pattern, ok := matchStringFallback(err); ok {
+		if !explain {
+			noteUntyped(err, true)
+			return true, ""
+		}
+		return true, fmt.Sprintf("matched %q string fallback", pattern)
 	}
 
 	// Default to not retryable for safety
-	return false
+	if !explain {
+		noteUntyped(err, false)
+	}
+	return false, "no retryable rule matched"
+}
+
+// retryableReason names the Retryable implementation that decided.
+func retryableReason(r Retryable, retryable bool) string {
+	if httpErr, ok := r.(*HTTPError); ok {
+		if httpErr.RetryableOverride != nil {
+			return fmt.Sprintf("HTTPError WithRetryableOverride(%t)", *httpErr.RetryableOverride)
+		}
+		return fmt.Sprintf("HTTPError status %d", httpErr.StatusCode)
+	}
+	name := fmt.Sprintf("%T", r)
+	if typed, ok := r.(chainFormatter); ok {
+		name = typeName(typed)
+	}
+	return fmt.Sprintf("%s.IsRetryable() reported %t", name, retryable)
 }
 
 // IsRetryableTimeout checks if err is a retryable timeout.
@@ -113,74 +266,235 @@ func IsRetryableTimeout(err error) bool {
 	return errors.Is(err, ErrNetworkTimeout)
 }
 
-// IsTransientError checks if err represents a transient failure.
-// Transient failures are temporary and should be retried.
-// Examples: network errors, rate limits, server errors.
-func IsTransientError(err error) bool {
-	if err == nil {
-		return false
-	}
+// errPreviousDeadline stands in for an expired context.DeadlineExceeded
+// while IsRetryableWithFreshContext classifies what wraps it.
+var errPreviousDeadline = errors.New("deadline of a previous context")
 
-	// Context errors are not transient
-	if IsContextError(err) {
-		return false
+// IsRetryableWithFreshContext reports whether err is worth retrying when
+// the next attempt runs under a new context, as a scheduler that creates
+// one per attempt does. It differs from IsRetryable in one way: a
+// context.DeadlineExceeded wrapped by one of this package's types doesn't
+// veto the retry, and the wrapper decides instead, so a TimeoutError from
+// an expired per-call deadline is retryable. A bare or fmt-wrapped
+// context.DeadlineExceeded still isn't, and neither is anything with
+// context.Canceled in its chain: someone gave up on the work.
+//
+// Example:
+//
+//	for attempt := 1; ; attempt++ {
+//	    ctx, cancel := context.WithTimeout(jobCtx, 30*time.Second)
+//	    err := job.Run(ctx)
+//	    cancel()
+//	    if err == nil || !errors.IsRetryableWithFreshContext(err) {
+//	        return err
+//	    }
+//	}
+func IsRetryableWithFreshContext(err error) bool {
+	if !errors.Is(err, context.DeadlineExceeded) || errors.Is(err, context.Canceled) {
+		return IsRetryable(err)
 	}
-
-	// Network errors are typically transient
-	if IsNetworkError(err) {
-		return true
+	if !hasTypedError(err) {
+		return false
 	}
 
-	// Rate limits are transient
-	if errors.Is(err, ErrRateLimited) {
-		return true
+	for range maxChainNodes {
+		replaced, ok := replaceCause(err, isDeadlineNode, errPreviousDeadline, 0)
+		if !ok {
+			break
+		}
+		err = replaced
 	}
-
-	// Server errors are transient
-	if errors.Is(err, ErrServerError) {
-		return true
+	if errors.Is(err, context.DeadlineExceeded) {
+		return false // a deadline out of ReplaceCause's reach
 	}
+	return isRetryable(err, true)
+}
 
-	// Connection errors are transient
-	if errors.Is(err, ErrConnectionError) {
-		return true
+// isDeadlineNode reports whether node is context.DeadlineExceeded itself,
+// or an error such as net/http's timeout that matches it without wrapping
+// it.
+func isDeadlineNode(node error) bool {
+	if !errors.Is(node, context.DeadlineExceeded) {
+		return false
 	}
-
-	// Deadlocks are transient
-	if errors.Is(err, ErrDeadlock) {
-		return true
+	switch u := node.(type) {
+	case interface{ Unwrap() error }:
+		return !errors.Is(u.Unwrap(), context.DeadlineExceeded)
+	case interface{ Unwrap() []error }:
+		for _, cause := range u.Unwrap() {
+			if errors.Is(cause, context.DeadlineExceeded) {
+				return false
+			}
+		}
 	}
+	return true
+}
 
-	return false
+// IsTransientError reports whether err is a temporary failure that is safe
+// to retry: Classify(err) == ClassTransient. It always agrees with
+// IsRetryable, and never holds together with IsPermanentError.
+func IsTransientError(err error) bool {
+	return Classify(err) == ClassTransient
 }
 
-// IsPermanentError checks if err represents a permanent failure.
-// Permanent failures should not be retried.
-// Examples: validation errors, authentication errors, not found errors.
+// IsPermanentError reports whether err is a failure that will not succeed
+// on retry: Classify(err) is ClassPermanent, or ClassContext since an
+// abandoned operation isn't retried either. Examples: validation errors,
+// authentication errors, not found errors. A joined error is permanent when
+// any of its branches is permanent or a context error. An error with no
+// classification information is neither transient nor permanent.
 func IsPermanentError(err error) bool {
-	if err == nil {
-		return false
+	class := Classify(err)
+	return class == ClassPermanent || class == ClassContext
+}
+
+// ExplainPermanent returns IsPermanentError's answer for err along with a
+// short reason naming the rule that decided it, such as "ValidationError",
+// "HTTPError status 404" or "context.Canceled in chain". See
+// ExplainRetryable.
+//
+// Example:
+//
+//	permanent, reason := errors.ExplainPermanent(err)
+//	// permanent = true, reason = "NotFoundError"
+func ExplainPermanent(err error) (bool, string) {
+	class, reason := ExplainClassification(err)
+	switch class {
+	case ClassPermanent:
+		if reason == reasonPermanentRules {
+			_, reason = explainPermanent(err, true)
+		}
+		return true, reason
+	case ClassContext:
+		return true, reason
 	}
+	return false, reason
+}
+
+// reasonPermanentRules is ExplainClassification's reason when isPermanent
+// decided.
+const reasonPermanentRules = "IsPermanentError reported true"
+
+// isPermanent holds the rules by which Classify finds an error that isn't
+// retryable to be permanent rather than unknown. Joins, overrides, context
+// errors and errors from a transport are decided before it is consulted.
+func isPermanent(err error) bool {
+	permanent, _ := explainPermanent(err, false)
+	return permanent
+}
 
+// explainPermanent implements isPermanent. Reasons that need formatting are
+// only built when explain is set.
+func explainPermanent(err error, explain bool) (bool, string) {
 	// Validation errors are permanent
 	if IsValidation(err) {
-		return true
+		return true, "ValidationError"
 	}
 
-	// Context errors are permanent (operation abandoned)
-	if IsContextError(err) {
-		return true
+	// Unsupported requests never succeed; features that aren't implemented
+	// are permanent until their AvailableFrom is near
+	if IsUnsupported(err) {
+		return true, "UnsupportedError"
+	}
+	if IsNotImplemented(err) && !IsRetryable(err) {
+		return true, "NotImplementedError not due within an hour"
+	}
+
+	// Missing resources stay missing
+	if _, ok := IsNotFoundError(err); ok {
+		return true, "NotFoundError"
+	}
+
+	// Conflicts repeat unless the caller re-reads and marked them retryable
+	if _, ok := IsConflictError(err); ok && !IsRetryable(err) {
+		return true, "ConflictError not marked retryable"
+	}
+
+	// Encoding and decoding fail the same way every time
+	if serializationDirection(err) != "" {
+		return true, "SerializationError with a direction"
 	}
 
 	// Circuit breaker errors are managed externally
 	if errors.Is(err, ErrCircuitOpen) {
-		return true
+		return true, "circuit open"
+	}
+
+	// Configuration stays invalid until someone changes it
+	if _, ok := IsConfigError(err); ok {
+		return true, "ConfigError"
+	}
+
+	// Provider errors with an error status outside the retryable set are
+	// permanent
+	if providerErr, ok := IsProviderError(err); ok && providerErr.HTTPStatus != 0 {
+		permanent := providerErr.HTTPStatus >= 400 && !IsRetryableHTTPStatus(providerErr.HTTPStatus)
+		if !explain {
+			return permanent, ""
+		}
+		return permanent, fmt.Sprintf("ProviderError status %d", providerErr.HTTPStatus)
 	}
 
-	// 4xx HTTP errors (except 429 rate limit) are permanent
+	// HTTP errors are permanent when IsRetryable says no: an error status
+	// outside the retryable set, or a WithRetryableOverride(false)
 	if httpErr, ok := IsHTTPError(err); ok {
-		return httpErr.StatusCode >= 400 && httpErr.StatusCode < 500 && httpErr.StatusCode != 429
+		permanent := httpErr.StatusCode >= 400 && !httpErr.IsRetryable()
+		if !explain {
+			return permanent, ""
+		}
+		return permanent, retryableReason(httpErr, httpErr.IsRetryable())
 	}
 
-	return false
+	// Certificates don't start verifying and missing names don't appear
+	// between attempts
+	if reason, ok := netPermanentReason(err); ok {
+		return true, reason
+	}
+
+	return false, "no permanent rule matched"
+}
+
+// RetryAfterer is implemented by error types from other packages that
+// carry a server-directed wait, so GetRetryAfter and everything built on it
+// honor their hints.
+//
+// Example:
+//
+//	func (e *QuotaError) RetryAfter() time.Duration { return e.ResetIn }
+type RetryAfterer interface {
+	RetryAfter() time.Duration
+}
+
+// GetRetryAfter returns the longest retry-after hint carried by a
+// RateLimitError, RetryableError, RemoteError or RetryAfterer anywhere in
+// err's chain, the LagEstimate of a ConsistencyError, or the wait until a
+// NotImplementedError's AvailableFrom.
+// Returns false when the chain carries no positive hint, so callers can
+// fall back to their own backoff.
+//
+// Example:
+//
+//	if wait, ok := GetRetryAfter(err); ok {
+//	    time.Sleep(wait)
+//	}
+func GetRetryAfter(err error) (time.Duration, bool) {
+	var longest time.Duration
+	walkChain(err, func(node error, _ int) bool {
+		switch e := node.(type) {
+		case retryHintHolder:
+			longest = max(longest, e.retryHint().RetryAfter)
+		case *RemoteError:
+			longest = max(longest, e.RetryAfter)
+		case *ConsistencyError:
+			longest = max(longest, e.LagEstimate)
+		case *NotImplementedError:
+			if !e.AvailableFrom.IsZero() {
+				longest = max(longest, until(e.AvailableFrom))
+			}
+		case RetryAfterer:
+			longest = max(longest, e.RetryAfter())
+		}
+		return true
+	})
+	return longest, longest > 0
 }
@@ -0,0 +1,161 @@
+package errors
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// DelaySource names what decided how long to wait before a retry.
+type DelaySource string
+
+const (
+	// DelaySourceServer is a retry-after hint sent by the server (see
+	// GetRetryAfter).
+	DelaySourceServer DelaySource = "server_retry_after"
+
+	// DelaySourceCircuit is the time a circuit breaker in the chain reopens
+	// (see WithReopenAt).
+	DelaySourceCircuit DelaySource = "circuit_reopen"
+
+	// DelaySourcePolicy is the caller's BackoffPolicy.
+	DelaySourcePolicy DelaySource = "backoff_policy"
+)
+
+// RetryPlan records the retry decision made after one failed attempt: the
+// class and classification rule (see ExplainClassification), whether to
+// retry, and the wait and what it came from. It marshals to JSON with the
+// delay in milliseconds, and String renders it for incident timelines.
+type RetryPlan struct {
+	Attempt int // 1-based number of the failed attempt
+	Retry   bool
+	Class   ErrorClass
+	Rule    string
+	Source  DelaySource // "" when not retrying
+	Delay   time.Duration
+
+	// StopReason says why an executor stopped although the error was
+	// retryable, such as "max_attempts" or RetryReasonBudgetExhausted.
+	StopReason string
+}
+
+// RetryStopMaxAttempts is the StopReason recorded when an executor used
+// all of its attempts.
+const RetryStopMaxAttempts = "max_attempts"
+
+// RetryStopMaxElapsed is the StopReason recorded when an executor stopped
+// because the wait before the next attempt would exceed its time limit.
+const RetryStopMaxElapsed = "max_elapsed"
+
+// RetryStopHeadroom is the StopReason recorded when an executor stopped
+// because the wait before the next attempt would leave less than the
+// required headroom on the deadline (see RequireHeadroom).
+const RetryStopHeadroom = TimeoutSourceHeadroom
+
+// retryPlanConfig holds the options accepted by ExplainRetryPlan.
+type retryPlanConfig struct {
+	clock Clock
+}
+
+// ExplainRetryPlan decides whether to retry err after the given failed
+// attempt (1-based) and how long to wait, and explains both. The wait is,
+// in order of preference, the server's retry-after hint, the time until a
+// circuit breaker in the chain reopens, or policy's delay for the attempt
+// as adjusted by SuggestedBackoff. Executors should sleep for the returned
+// Delay, which already includes the policy's jitter, so the plan records
+// exactly what happened. Supports WithClock.
+//
+// Example:
+//
+//	plan := errors.ExplainRetryPlan(err, attempt, errors.DefaultBackoffPolicy)
+//	logger.Info("retry decision", "plan", plan.String())
+//	// "attempt 2: transient (IsRetryable reported true); waiting 32s (server_retry_after)"
+func ExplainRetryPlan(err error, attempt int, policy BackoffPolicy, opts ...Option) RetryPlan {
+	cfg := &retryPlanConfig{clock: packageClock{}}
+	for _, opt := range opts {
+		opt(cfg)
+	}
+
+	class, rule := ExplainClassification(err)
+	plan := RetryPlan{Attempt: attempt, Retry: IsRetryable(err), Class: class, Rule: rule}
+	if !plan.Retry {
+		return plan
+	}
+
+	if hint, ok := GetRetryAfter(err); ok {
+		plan.Source, plan.Delay = DelaySourceServer, hint
+		return plan
+	}
+	if wait, ok := circuitReopenDelay(err, cfg.clock.Now()); ok {
+		plan.Source, plan.Delay = DelaySourceCircuit, wait
+		return plan
+	}
+	plan.Source, plan.Delay = DelaySourcePolicy, SuggestedBackoff(err, policy).Delay(max(attempt-1, 0))
+	return plan
+}
+
+// circuitReopenDelay returns the time from now until the latest reopen time
+// of a circuit breaker in err's chain.
+func circuitReopenDelay(err error, now time.Time) (time.Duration, bool) {
+	var wait time.Duration
+	walkChain(err, func(node error, _ int) bool {
+		if e, ok := node.(*CircuitBreakerError); ok && e.ReopenAt.After(now) {
+			wait = max(wait, e.ReopenAt.Sub(now))
+		}
+		return true
+	})
+	return wait, wait > 0
+}
+
+// String renders the plan for humans, such as
+// "attempt 1: permanent (IsPermanentError reported true); not retrying".
+func (p RetryPlan) String() string {
+	decision := fmt.Sprintf("attempt %d: %s (%s); ", p.Attempt, p.Class, p.Rule)
+	switch {
+	case p.StopReason != "":
+		return decision + "stopped: " + p.StopReason
+	case !p.Retry:
+		return decision + "not retrying"
+	}
+	return fmt.Sprintf("%swaiting %v (%s)", decision, p.Delay, p.Source)
+}
+
+// retryPlanJSON is the JSON form of a RetryPlan.
+type retryPlanJSON struct {
+	Attempt    int         `json:"attempt"`
+	Retry      bool        `json:"retry"`
+	Class      ErrorClass  `json:"class"`
+	Rule       string      `json:"rule"`
+	Source     DelaySource `json:"delay_source,omitempty"`
+	DelayMS    float64     `json:"delay_ms,omitempty"`
+	StopReason string      `json:"stop_reason,omitempty"`
+}
+
+// MarshalJSON implements json.Marshaler.
+func (p RetryPlan) MarshalJSON() ([]byte, error) {
+	return json.Marshal(retryPlanJSON{
+		Attempt: p.Attempt, Retry: p.Retry, Class: p.Class, Rule: p.Rule,
+		Source: p.Source, DelayMS: durationMillis(p.Delay), StopReason: p.StopReason,
+	})
+}
+
+// UnmarshalJSON implements json.Unmarshaler.
+func (p *RetryPlan) UnmarshalJSON(data []byte) error {
+	var raw retryPlanJSON
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+	*p = RetryPlan{
+		Attempt: raw.Attempt, Retry: raw.Retry, Class: raw.Class, Rule: raw.Rule,
+		Source: raw.Source, Delay: millisDuration(raw.DelayMS), StopReason: raw.StopReason,
+	}
+	return nil
+}
+
+// Attempt is one failed attempt recorded on a RetryError (see
+// WithAttemptHistory): its error and the retry decision made after it.
+type Attempt struct {
+	Number int
+	Err    error
+	Plan   RetryPlan
+}
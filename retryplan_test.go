@@ -0,0 +1,120 @@
+package errors
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestExplainRetryPlan tests which source decides the wait before a retry
+func TestExplainRetryPlan(t *testing.T) {
+	clock := newFakeClock()
+	policy := BackoffPolicy{Initial: time.Second, Max: time.Minute, Multiplier: 2}
+	circuit := NewCircuitBreakerError("Too many failures", "Charge", "open",
+		WithReopenAt(clock.Now().Add(45*time.Second)))
+
+	tests := []struct {
+		name       string
+		err        error
+		attempt    int
+		wantRetry  bool
+		wantSource DelaySource
+		wantDelay  time.Duration
+		wantString string
+	}{
+		{
+			name:       "server hint wins",
+			err:        NewRateLimitError("Slow down", "Search", 32*time.Second),
+			attempt:    1,
+			wantRetry:  true,
+			wantSource: DelaySourceServer,
+			wantDelay:  32 * time.Second,
+			wantString: "attempt 1: transient (IsRetryable reported true); waiting 32s (server_retry_after)",
+		},
+		{
+			name:       "circuit reopen time",
+			err:        Transient(circuit),
+			attempt:    2,
+			wantRetry:  true,
+			wantSource: DelaySourceCircuit,
+			wantDelay:  45 * time.Second,
+		},
+		{
+			name:       "policy backoff",
+			err:        NewNetworkError("Connection reset", "Search"),
+			attempt:    3,
+			wantRetry:  true,
+			wantSource: DelaySourcePolicy,
+			wantDelay:  4 * time.Second,
+		},
+		{
+			name:       "not retryable",
+			err:        NewValidationError("Invalid email", "email"),
+			attempt:    1,
+			wantString: "attempt 1: permanent (IsPermanentError reported true); not retrying",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			plan := ExplainRetryPlan(tt.err, tt.attempt, policy, WithClock(clock))
+			if plan.Retry != tt.wantRetry || plan.Source != tt.wantSource || plan.Delay != tt.wantDelay {
+				t.Errorf("got retry=%v source=%q delay=%v, want %v %q %v",
+					plan.Retry, plan.Source, plan.Delay, tt.wantRetry, tt.wantSource, tt.wantDelay)
+			}
+			if plan.Rule == "" || plan.Attempt != tt.attempt {
+				t.Errorf("plan = %+v, want the rule and attempt recorded", plan)
+			}
+			if tt.wantString != "" && plan.String() != tt.wantString {
+				t.Errorf("String() = %q, want %q", plan.String(), tt.wantString)
+			}
+		})
+	}
+}
+
+// TestRetryHistoryRoundTrip tests that attempt plans survive an envelope round trip
+func TestRetryHistoryRoundTrip(t *testing.T) {
+	policy := BackoffPolicy{Initial: 100 * time.Millisecond, Multiplier: 2}
+	first := NewRateLimitError("Slow down", "Search", 2*time.Second)
+	second := NewNetworkError("Connection reset", "Search")
+
+	secondPlan := ExplainRetryPlan(second, 2, policy)
+	secondPlan.StopReason = RetryStopMaxAttempts
+	history := []Attempt{
+		{Number: 1, Err: first, Plan: ExplainRetryPlan(first, 1, policy)},
+		{Number: 2, Err: second, Plan: secondPlan},
+	}
+	err := NewRetryError(2, 2, second, []error{first, second}, WithAttemptHistory(history))
+
+	payload, marshalErr := MarshalError(err)
+	if marshalErr != nil {
+		t.Fatal(marshalErr)
+	}
+	if !strings.Contains(string(payload), `"delay_source":"server_retry_after","delay_ms":2000`) {
+		t.Errorf("payload = %s, want the first plan's wait", payload)
+	}
+	if err := ValidateWirePayload(payload); err != nil {
+		t.Errorf("ValidateWirePayload() = %v", err)
+	}
+
+	decoded, unmarshalErr := UnmarshalError(payload)
+	if unmarshalErr != nil {
+		t.Fatal(unmarshalErr)
+	}
+	var retryErr *RetryError
+	if !As(decoded, &retryErr) || len(retryErr.History) != 2 {
+		t.Fatalf("decoded = %#v, want two attempts", decoded)
+	}
+	for i, attempt := range retryErr.History {
+		if attempt.Plan != history[i].Plan || attempt.Number != history[i].Number || attempt.Err == nil {
+			t.Errorf("History[%d] = %+v, want %+v", i, attempt, history[i])
+		}
+	}
+
+	info := ExtractErrorInfo(err)
+	plans, _ := json.Marshal(info["retry_plans"])
+	if !strings.Contains(string(plans), `"stop_reason":"max_attempts"`) {
+		t.Errorf("retry_plans = %s", plans)
+	}
+}
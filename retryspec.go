@@ -0,0 +1,299 @@
+package errors
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// RetrySpecTag is the struct tag RetrySpecFromTag reads.
+const RetrySpecTag = "retry"
+
+// RetrySpec is declarative retry behavior for one endpoint or call, such
+// as "GET /users: idempotent, at most 4 attempts" or "POST /orders: never
+// retry", parsed from a compact string by ParseRetrySpec so generated
+// clients can keep it next to the endpoint definition. Zero fields leave
+// the executor's own setting in place.
+//
+// A server's retry-after hint always wins over Backoff (see
+// ExplainRetryPlan): the spec says how to back off when the server doesn't
+// say.
+type RetrySpec struct {
+	// MaxAttempts caps the attempts, including the first. 1 means never
+	// retry; 0 leaves the executor's default.
+	MaxAttempts int
+
+	// Idempotent marks the call as safe to repeat whatever its HTTP
+	// method. Without it, only requests with idempotent methods (GET,
+	// HEAD, OPTIONS, TRACE, PUT and DELETE) are retried.
+	Idempotent bool
+
+	// Backoff is the delay curve between attempts; the zero value leaves
+	// the executor's default.
+	Backoff BackoffPolicy
+
+	// On narrows which retryable failures are retried, as patterns such as
+	// "5xx", "429", "network" or "timeout". Empty retries every failure
+	// IsRetryable accepts.
+	On []string
+}
+
+// ParseRetrySpec parses a comma-separated retry spec:
+//   - max=N - at most N attempts, including the first
+//   - never - never retry; the same as max=1
+//   - idempotent - safe to repeat whatever the method
+//   - backoff=exp:100ms..5s - doubling from 100ms up to 5s, with 20% jitter
+//   - backoff=const:200ms - the same delay before every retry
+//   - backoff=none - retry immediately
+//   - on=5xx,429 - retry only these failures: a status ("503"), a status
+//     class ("5xx"), "network" or "timeout". Patterns can follow on= as
+//     further comma-separated items.
+//
+// Spaces around items are ignored and an empty spec sets nothing. Unknown
+// or repeated keys and malformed values are reported as a ConfigError
+// whose Key is the offending key.
+//
+// Example:
+//
+//	spec, err := errors.ParseRetrySpec("max=4,idempotent,backoff=exp:100ms..5s,on=5xx,429")
+func ParseRetrySpec(s string) (RetrySpec, error) {
+	var spec RetrySpec
+	seen := make(map[string]bool)
+	inOn := false
+	for _, item := range strings.Split(s, ",") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			if strings.TrimSpace(s) == "" {
+				break
+			}
+			return RetrySpec{}, retrySpecError("empty item", "", s)
+		}
+
+		key, value, hasValue := strings.Cut(item, "=")
+		key, value = strings.TrimSpace(key), strings.TrimSpace(value)
+		if !hasValue && inOn && isRetryPattern(key) {
+			spec.On = append(spec.On, strings.ToLower(key))
+			continue
+		}
+		inOn = false
+		if key == "never" {
+			key = "max"
+		}
+		if seen[key] {
+			return RetrySpec{}, retrySpecError("repeated key", key, item)
+		}
+		seen[key] = true
+
+		switch {
+		case item == "never":
+			spec.MaxAttempts = 1
+		case item == "idempotent":
+			spec.Idempotent = true
+		case key == "max" && hasValue:
+			n, err := strconv.Atoi(value)
+			if err != nil || n < 1 {
+				return RetrySpec{}, retrySpecError("must be a whole number of at least 1", key, value)
+			}
+			spec.MaxAttempts = n
+		case key == "backoff" && hasValue:
+			policy, err := parseRetryBackoff(value)
+			if err != nil {
+				return RetrySpec{}, err
+			}
+			spec.Backoff = policy
+		case key == "on" && hasValue:
+			if !isRetryPattern(value) {
+				return RetrySpec{}, retrySpecError(`must be a status such as "503", a class such as "5xx", "network" or "timeout"`, key, value)
+			}
+			spec.On = append(spec.On, strings.ToLower(value))
+			inOn = true
+		default:
+			return RetrySpec{}, retrySpecError("unknown retry spec item", key, item)
+		}
+	}
+	return spec, nil
+}
+
+// MustParseRetrySpec is ParseRetrySpec for specs fixed at compile time,
+// such as in generated code. It panics on an invalid spec.
+//
+// Example:
+//
+//	var createOrderRetry = errors.MustParseRetrySpec("never")
+func MustParseRetrySpec(s string) RetrySpec {
+	spec, err := ParseRetrySpec(s)
+	if err != nil {
+		panic(err)
+	}
+	return spec
+}
+
+// RetrySpecFromTag parses the spec in tag's "retry" key, reporting false
+// when the tag has none.
+//
+// Example:
+//
+//	type GetUserOptions struct {
+//	    _ struct{} `retry:"max=4,idempotent,on=5xx,429"`
+//	}
+//
+//	field, _ := reflect.TypeOf(GetUserOptions{}).FieldByName("_")
+//	spec, ok, err := errors.RetrySpecFromTag(field.Tag)
+func RetrySpecFromTag(tag reflect.StructTag) (RetrySpec, bool, error) {
+	s, ok := tag.Lookup(RetrySpecTag)
+	if !ok {
+		return RetrySpec{}, false, nil
+	}
+	spec, err := ParseRetrySpec(s)
+	return spec, err == nil, err
+}
+
+// String renders the spec in the form ParseRetrySpec reads.
+func (s RetrySpec) String() string {
+	var items []string
+	switch {
+	case s.MaxAttempts == 1:
+		items = append(items, "never")
+	case s.MaxAttempts > 1:
+		items = append(items, "max="+strconv.Itoa(s.MaxAttempts))
+	}
+	if s.Idempotent {
+		items = append(items, "idempotent")
+	}
+	switch {
+	case s.Backoff == (BackoffPolicy{}):
+	case s.Backoff.Initial <= 0:
+		items = append(items, "backoff=none")
+	case s.Backoff.Multiplier <= 1:
+		items = append(items, "backoff=const:"+s.Backoff.Initial.String())
+	default:
+		items = append(items, fmt.Sprintf("backoff=exp:%s..%s", s.Backoff.Initial, s.Backoff.Max))
+	}
+	if len(s.On) > 0 {
+		items = append(items, "on="+strings.Join(s.On, ","))
+	}
+	return strings.Join(items, ",")
+}
+
+// Retries reports whether the spec allows retrying err: err must be
+// retryable (see IsRetryable) and, when On is set, match one of its
+// patterns.
+func (s RetrySpec) Retries(err error) bool {
+	if !IsRetryable(err) {
+		return false
+	}
+	if len(s.On) == 0 {
+		return true
+	}
+	status := GetHTTPStatusCode(err)
+	if status == 0 && Is(err, ErrRateLimited) {
+		status = http.StatusTooManyRequests
+	}
+	for _, pattern := range s.On {
+		switch {
+		case pattern == "network":
+			if status == 0 && IsNetworkError(err) {
+				return true
+			}
+		case pattern == "timeout":
+			if IsTimeout(err) {
+				return true
+			}
+		case strings.HasSuffix(pattern, "xx"):
+			if status != 0 && strconv.Itoa(status/100) == pattern[:1] {
+				return true
+			}
+		case pattern == strconv.Itoa(status):
+			return true
+		}
+	}
+	return false
+}
+
+// AllowsMethod reports whether a request with the HTTP method may be
+// retried: always when the spec is Idempotent, else only for idempotent
+// methods.
+func (s RetrySpec) AllowsMethod(method string) bool {
+	if s.Idempotent {
+		return true
+	}
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodTrace, http.MethodPut, http.MethodDelete:
+		return true
+	}
+	return false
+}
+
+type retrySpecKey struct{}
+
+// ContextWithRetrySpec returns a copy of ctx carrying spec, which retrying
+// transports apply to requests made with it in place of their own
+// settings, so generated clients can attach a spec per call.
+//
+// Example:
+//
+//	var getUserRetry = errors.MustParseRetrySpec("max=4,idempotent")
+//
+//	req, _ := http.NewRequestWithContext(errors.ContextWithRetrySpec(ctx, getUserRetry), http.MethodGet, url, nil)
+func ContextWithRetrySpec(ctx context.Context, spec RetrySpec) context.Context {
+	return context.WithValue(ctx, retrySpecKey{}, spec)
+}
+
+// RetrySpecFromContext returns the spec stored by ContextWithRetrySpec.
+func RetrySpecFromContext(ctx context.Context) (RetrySpec, bool) {
+	if ctx == nil {
+		return RetrySpec{}, false
+	}
+	spec, ok := ctx.Value(retrySpecKey{}).(RetrySpec)
+	return spec, ok
+}
+
+// parseRetryBackoff parses the value of a backoff= item.
+func parseRetryBackoff(value string) (BackoffPolicy, error) {
+	kind, args, _ := strings.Cut(value, ":")
+	switch kind {
+	case "none":
+		if args == "" {
+			// Initial 0 retries immediately; Max sets the policy apart from
+			// the zero value, which would leave the executor's default.
+			return BackoffPolicy{Max: -1}, nil
+		}
+	case "const":
+		if d, err := time.ParseDuration(args); err == nil && d > 0 {
+			return BackoffPolicy{Initial: d, Max: d, Multiplier: 1}, nil
+		}
+	case "exp":
+		from, to, ok := strings.Cut(args, "..")
+		initial, err1 := time.ParseDuration(from)
+		maximum, err2 := time.ParseDuration(to)
+		if ok && err1 == nil && err2 == nil && initial > 0 && maximum >= initial {
+			return BackoffPolicy{Initial: initial, Max: maximum, Multiplier: 2, Jitter: 0.2}, nil
+		}
+	}
+	return BackoffPolicy{}, retrySpecError(`must be "exp:<initial>..<max>", "const:<delay>" or "none"`, "backoff", value)
+}
+
+// isRetryPattern reports whether s is a pattern on= accepts.
+func isRetryPattern(s string) bool {
+	s = strings.ToLower(s)
+	if s == "network" || s == "timeout" {
+		return true
+	}
+	if len(s) != 3 || s[0] < '1' || s[0] > '5' {
+		return false
+	}
+	if s[1:] == "xx" {
+		return true
+	}
+	n, err := strconv.Atoi(s)
+	return err == nil && n >= 100
+}
+
+// retrySpecError reports an invalid item of a retry spec.
+func retrySpecError(message, key, value string) error {
+	return NewConfigError(message, key, WithValue(value), WithOperation("ParseRetrySpec"))
+}
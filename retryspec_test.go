@@ -0,0 +1,164 @@
+package errors
+
+import (
+	"context"
+	"net/http"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// TestParseRetrySpec tests parsing of valid retry specs
+func TestParseRetrySpec(t *testing.T) {
+	tests := []struct {
+		name string
+		spec string
+		want RetrySpec
+	}{
+		{name: "empty", spec: "", want: RetrySpec{}},
+		{name: "blank", spec: "  ", want: RetrySpec{}},
+		{
+			name: "full",
+			spec: "max=4,idempotent,backoff=exp:100ms..5s,on=5xx,429",
+			want: RetrySpec{
+				MaxAttempts: 4,
+				Idempotent:  true,
+				Backoff:     BackoffPolicy{Initial: 100 * time.Millisecond, Max: 5 * time.Second, Multiplier: 2, Jitter: 0.2},
+				On:          []string{"5xx", "429"},
+			},
+		},
+		{name: "never", spec: "never", want: RetrySpec{MaxAttempts: 1}},
+		{name: "spaces", spec: " max = 2 , idempotent ", want: RetrySpec{MaxAttempts: 2, Idempotent: true}},
+		{
+			name: "constant backoff",
+			spec: "backoff=const:200ms",
+			want: RetrySpec{Backoff: BackoffPolicy{Initial: 200 * time.Millisecond, Max: 200 * time.Millisecond, Multiplier: 1}},
+		},
+		{name: "no backoff", spec: "backoff=none", want: RetrySpec{Backoff: BackoffPolicy{Max: -1}}},
+		{name: "patterns before other items", spec: "on=NETWORK,timeout,503,max=3", want: RetrySpec{MaxAttempts: 3, On: []string{"network", "timeout", "503"}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseRetrySpec(tt.spec)
+			if err != nil {
+				t.Fatalf("ParseRetrySpec(%q) error = %v", tt.spec, err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ParseRetrySpec(%q) = %+v, want %+v", tt.spec, got, tt.want)
+			}
+			if again, err := ParseRetrySpec(got.String()); err != nil || !reflect.DeepEqual(again, got) {
+				t.Errorf("String() = %q parses to %+v, %v; want %+v", got.String(), again, err, got)
+			}
+		})
+	}
+}
+
+// TestParseRetrySpecErrors tests that invalid specs report the offending key as a ConfigError
+func TestParseRetrySpecErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		spec    string
+		wantKey string
+	}{
+		{name: "zero attempts", spec: "max=0", wantKey: "max"},
+		{name: "attempts not a number", spec: "max=four", wantKey: "max"},
+		{name: "max without value", spec: "max", wantKey: "max"},
+		{name: "repeated key", spec: "max=2,max=3", wantKey: "max"},
+		{name: "never with max", spec: "never,max=3", wantKey: "max"},
+		{name: "unknown key", spec: "jitter=0.5", wantKey: "jitter"},
+		{name: "idempotent with value", spec: "idempotent=yes", wantKey: "idempotent"},
+		{name: "exp without range", spec: "backoff=exp:100ms", wantKey: "backoff"},
+		{name: "exp range reversed", spec: "backoff=exp:5s..100ms", wantKey: "backoff"},
+		{name: "bad duration", spec: "backoff=const:soon", wantKey: "backoff"},
+		{name: "unknown curve", spec: "backoff=linear:1s", wantKey: "backoff"},
+		{name: "bad status", spec: "on=600", wantKey: "on"},
+		{name: "bad class", spec: "on=4x", wantKey: "on"},
+		{name: "pattern outside on", spec: "max=2,503", wantKey: "503"},
+		{name: "bad pattern after on", spec: "on=5xx,teapot", wantKey: "teapot"},
+		{name: "empty item", spec: "max=2,,idempotent", wantKey: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ParseRetrySpec(tt.spec)
+			configErr, ok := IsConfigError(err)
+			if !ok {
+				t.Fatalf("ParseRetrySpec(%q) error = %v, want a ConfigError", tt.spec, err)
+			}
+			if configErr.Key != tt.wantKey || configErr.Operation != "ParseRetrySpec" || IsRetryable(err) {
+				t.Errorf("ParseRetrySpec(%q) = %+v, want non-retryable key %q", tt.spec, configErr, tt.wantKey)
+			}
+		})
+	}
+}
+
+// TestRetrySpecRetries tests which failures a spec's On patterns allow retrying
+func TestRetrySpecRetries(t *testing.T) {
+	spec := MustParseRetrySpec("on=5xx,429,timeout")
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{name: "server error", err: NewHTTPError(503, "unavailable", nil), want: true},
+		{name: "rate limited", err: NewHTTPError(429, "slow down", nil), want: true},
+		{name: "bare rate limit", err: NewRateLimitError("slow down", "Search", time.Second), want: true},
+		{name: "timeout", err: NewTimeoutError("too slow", "Quote", time.Second), want: true},
+		{name: "network not listed", err: NewNetworkError("connection reset", "Quote"), want: false},
+		{name: "not retryable", err: NewValidationError("bad id", "id"), want: false},
+		{name: "nil", err: nil, want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := spec.Retries(tt.err); got != tt.want {
+				t.Errorf("Retries(%v) = %v, want %v", tt.err, got, tt.want)
+			}
+		})
+	}
+
+	if !(RetrySpec{}).Retries(NewNetworkError("connection reset", "Quote")) {
+		t.Error("a spec without On should retry every retryable failure")
+	}
+	if (RetrySpec{}).AllowsMethod(http.MethodPost) || !(RetrySpec{Idempotent: true}).AllowsMethod(http.MethodPost) ||
+		!(RetrySpec{}).AllowsMethod(http.MethodPut) {
+		t.Error("AllowsMethod should allow idempotent methods, and any method when Idempotent")
+	}
+}
+
+// TestRetrySpecFromTagAndContext tests reading specs from struct tags and contexts
+func TestRetrySpecFromTagAndContext(t *testing.T) {
+	type endpoints struct {
+		GetUser     struct{} `retry:"max=4,idempotent"`
+		CreateOrder struct{} `retry:"never"`
+		Broken      struct{} `retry:"max=none"`
+		Untagged    struct{}
+	}
+	typ := reflect.TypeOf(endpoints{})
+	field := func(name string) reflect.StructTag {
+		f, _ := typ.FieldByName(name)
+		return f.Tag
+	}
+
+	if spec, ok, err := RetrySpecFromTag(field("GetUser")); !ok || err != nil || spec.MaxAttempts != 4 || !spec.Idempotent {
+		t.Errorf("GetUser = %+v, %v, %v", spec, ok, err)
+	}
+	if spec, ok, err := RetrySpecFromTag(field("CreateOrder")); !ok || err != nil || spec.MaxAttempts != 1 {
+		t.Errorf("CreateOrder = %+v, %v, %v", spec, ok, err)
+	}
+	if _, ok, err := RetrySpecFromTag(field("Broken")); ok || err == nil {
+		t.Errorf("Broken = %v, %v, want an error", ok, err)
+	}
+	if _, ok, err := RetrySpecFromTag(field("Untagged")); ok || err != nil {
+		t.Errorf("Untagged = %v, %v, want no spec", ok, err)
+	}
+
+	if _, ok := RetrySpecFromContext(context.Background()); ok {
+		t.Error("a bare context should carry no spec")
+	}
+	ctx := ContextWithRetrySpec(context.Background(), RetrySpec{MaxAttempts: 2})
+	if spec, ok := RetrySpecFromContext(ctx); !ok || spec.MaxAttempts != 2 {
+		t.Errorf("RetrySpecFromContext() = %+v, %v", spec, ok)
+	}
+}
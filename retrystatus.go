@@ -0,0 +1,106 @@
+package errors
+
+import (
+	"sync"
+	"sync/atomic"
+)
+
+// defaultRetryableStatuses are the statuses HTTPError retries unless
+// SetRetryableHTTPStatuses replaces them: request timeout, rate limiting
+// and the 5xx statuses that describe a passing condition. 501 Not
+// Implemented and the other 5xx statuses fail the same way every time.
+var defaultRetryableStatuses = newStatusSet([]int{408, 429, 500, 502, 503, 504})
+
+// statusSet is a set of HTTP statuses 0-639, one bit each.
+type statusSet [10]uint64
+
+func (s *statusSet) has(status int) bool {
+	return status >= 0 && status < len(s)*64 && s[status/64]&(1<<(status%64)) != 0
+}
+
+func (s *statusSet) add(status int) {
+	if status >= 100 && status <= 599 {
+		s[status/64] |= 1 << (status % 64)
+	}
+}
+
+var (
+	// retryableStatuses is read on every IsRetryable call, so readers load
+	// it without locking; writers replace it under retryableStatusMu.
+	retryableStatuses atomic.Pointer[statusSet]
+	retryableStatusMu sync.Mutex
+)
+
+func newStatusSet(statuses []int) *statusSet {
+	set := &statusSet{}
+	for _, status := range statuses {
+		set.add(status)
+	}
+	return set
+}
+
+// IsRetryableHTTPStatus reports whether an HTTPError with status is
+// retryable, according to the set configured with SetRetryableHTTPStatuses
+// and AddRetryableHTTPStatus: 408, 429, 500, 502, 503 and 504 by default.
+// It is safe to call concurrently with changes to the set.
+func IsRetryableHTTPStatus(status int) bool {
+	return currentRetryableStatuses().has(status)
+}
+
+// currentRetryableStatuses returns the configured set.
+func currentRetryableStatuses() *statusSet {
+	if set := retryableStatuses.Load(); set != nil {
+		return set
+	}
+	return defaultRetryableStatuses
+}
+
+// RetryableHTTPStatuses returns the configured retryable statuses in
+// ascending order.
+func RetryableHTTPStatuses() []int {
+	set := currentRetryableStatuses()
+	var statuses []int
+	for status := 100; status <= 599; status++ {
+		if set.has(status) {
+			statuses = append(statuses, status)
+		}
+	}
+	return statuses
+}
+
+// SetRetryableHTTPStatuses replaces the statuses for which HTTPError, and
+// ProviderError with a status, are retryable; all others are permanent
+// (see IsPermanentError). Statuses outside 100-599 are ignored. Call it at
+// startup: errors created before see the new set too, since retryability
+// is decided when it is asked for.
+//
+// Example:
+//
+//	// 501 is permanent already; also retry 425 Too Early
+//	errors.SetRetryableHTTPStatuses(408, 425, 429, 500, 502, 503, 504)
+func SetRetryableHTTPStatuses(statuses ...int) {
+	retryableStatusMu.Lock()
+	defer retryableStatusMu.Unlock()
+	retryableStatuses.Store(newStatusSet(statuses))
+}
+
+// AddRetryableHTTPStatus adds status to the retryable statuses.
+//
+// Example:
+//
+//	errors.AddRetryableHTTPStatus(http.StatusTooEarly)
+func AddRetryableHTTPStatus(status int) {
+	retryableStatusMu.Lock()
+	defer retryableStatusMu.Unlock()
+	set := *currentRetryableStatuses()
+	set.add(status)
+	retryableStatuses.Store(&set)
+}
+
+// ResetRetryableHTTPStatuses restores the default retryable statuses.
+// Intended for tests.
+func ResetRetryableHTTPStatuses() {
+	retryableStatusMu.Lock()
+	defer retryableStatusMu.Unlock()
+	retryableStatuses.Store(nil)
+}
@@ -0,0 +1,99 @@
+package errors
+
+import (
+	"slices"
+	"sync"
+	"testing"
+)
+
+// TestRetryableHTTPStatuses tests the default set, replacing and extending it, and IsPermanentError agreeing
+func TestRetryableHTTPStatuses(t *testing.T) {
+	t.Cleanup(ResetRetryableHTTPStatuses)
+
+	if got, want := RetryableHTTPStatuses(), []int{408, 429, 500, 502, 503, 504}; !slices.Equal(got, want) {
+		t.Errorf("RetryableHTTPStatuses() = %v, want %v", got, want)
+	}
+
+	tests := []struct {
+		name          string
+		configure     func()
+		status        int
+		wantRetryable bool
+	}{
+		{name: "default 503", configure: ResetRetryableHTTPStatuses, status: 503, wantRetryable: true},
+		{name: "default 408", configure: ResetRetryableHTTPStatuses, status: 408, wantRetryable: true},
+		{name: "default 501", configure: ResetRetryableHTTPStatuses, status: 501},
+		{name: "default 404", configure: ResetRetryableHTTPStatuses, status: 404},
+		{name: "replaced set drops 500", configure: func() { SetRetryableHTTPStatuses(429, 503) }, status: 500},
+		{name: "replaced set keeps 503", configure: func() { SetRetryableHTTPStatuses(429, 503) }, status: 503, wantRetryable: true},
+		{name: "added 425", configure: func() { ResetRetryableHTTPStatuses(); AddRetryableHTTPStatus(425) }, status: 425, wantRetryable: true},
+		{name: "add keeps defaults", configure: func() { ResetRetryableHTTPStatuses(); AddRetryableHTTPStatus(425) }, status: 502, wantRetryable: true},
+		{name: "out of range ignored", configure: func() { SetRetryableHTTPStatuses(700) }, status: 700},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.configure()
+			err := NewHTTPError(tt.status, "failed", nil)
+			if got := IsRetryable(err); got != tt.wantRetryable {
+				t.Errorf("IsRetryable(HTTP %d) = %v, want %v", tt.status, got, tt.wantRetryable)
+			}
+			if got := IsPermanentError(err); got == tt.wantRetryable && tt.status < 600 {
+				t.Errorf("IsPermanentError(HTTP %d) = %v, want %v", tt.status, got, !tt.wantRetryable)
+			}
+			provider := NewProviderError("stripe", WithStatusCode(tt.status))
+			if got := IsRetryable(provider); got != tt.wantRetryable {
+				t.Errorf("IsRetryable(provider %d) = %v, want %v", tt.status, got, tt.wantRetryable)
+			}
+		})
+	}
+}
+
+// TestWithRetryableOverride tests that a per-error override wins over the status set
+func TestWithRetryableOverride(t *testing.T) {
+	tests := []struct {
+		name      string
+		status    int
+		retryable bool
+	}{
+		{name: "permanent 500", status: 500, retryable: false},
+		{name: "retryable 409", status: 409, retryable: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := Wrap(NewHTTPError(tt.status, "failed", nil, WithRetryableOverride(tt.retryable)), "calling ledger")
+			if IsRetryable(err) != tt.retryable || IsPermanentError(err) == tt.retryable {
+				t.Errorf("IsRetryable() = %v, IsPermanentError() = %v, want retryable %v",
+					IsRetryable(err), IsPermanentError(err), tt.retryable)
+			}
+		})
+	}
+}
+
+// TestRetryableHTTPStatusesConcurrent tests reading the set while it changes
+func TestRetryableHTTPStatusesConcurrent(t *testing.T) {
+	t.Cleanup(ResetRetryableHTTPStatuses)
+	err := NewHTTPError(503, "failed", nil)
+
+	var wg sync.WaitGroup
+	for range 4 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for range 1000 {
+				if !IsRetryable(err) {
+					t.Error("503 should stay retryable while other statuses change")
+					return
+				}
+			}
+		}()
+	}
+	for status := 400; status < 500; status++ {
+		AddRetryableHTTPStatus(status)
+	}
+	wg.Wait()
+	if !IsRetryableHTTPStatus(451) {
+		t.Error("AddRetryableHTTPStatus(451) was lost")
+	}
+}
@@ -0,0 +1,113 @@
+package errors
+
+import (
+	"encoding/json"
+	"net/http"
+)
+
+// RulesVersion is the version of the classification rules described by
+// RulesManifest. It is bumped whenever a built-in rule changes what Classify
+// returns, so analysis of historical logs can tell which rules a service
+// ran. Registering codes or types doesn't change it.
+const RulesVersion = 11
+
+// classificationRules are Classify's rules in decision order, as listed in
+// its documentation. An empty class means the rule can yield more than one.
+var classificationRules = []manifestRule{
+	{Name: "joined", Description: "errors.Join or several %w below only plain wrappers: each joined error is classified and context wins over permanent, permanent over transient"},
+	{Name: "forced", Description: "Permanent(), Transient(), MarkPermanent() or MarkRetryable() override, or a cenkalti/backoff *PermanentError, in the chain: the forced class, decided by the outermost MarkPermanent() or MarkRetryable() unless Permanent() wraps it"},
+	{Name: "context", Class: ClassContext, Description: "context.DeadlineExceeded or context.Canceled in the chain"},
+	{Name: "preclassified", Description: "error decoded from a transport: the class its sender computed"},
+	{Name: "remote_class", Description: "RemoteError from a newer sender: the class it was sent with"},
+	{Name: "code_mapping", Class: ClassTransient, Description: "code whose catalog entry declares retryable true; false skips to permanent_types"},
+	{Name: "retryable_interface", Class: ClassTransient, Description: "first error in the chain with an IsRetryable method returns true; false skips to permanent_types"},
+	{Name: "sentinels", Class: ClassTransient, Description: "a sentinel classed transient in sentinels"},
+	{Name: "http_status", Class: ClassTransient, Description: "first HTTPError's status is classed transient in status_codes"},
+	{Name: "network_errors", Description: "syscall.ECONNRESET, ECONNREFUSED or EPIPE, io.ErrUnexpectedEOF, *net.OpError, temporary *net.DNSError or net.Error timeout: transient; TLS certificate verification error or DNS name not found: skips to permanent_types"},
+	{Name: "message_patterns", Class: ClassTransient, Description: "lowercased message contains one of message_patterns"},
+	{Name: "permanent_types", Class: ClassPermanent, Description: "ValidationError, ConfigError, NotFoundError, ConflictError not marked retryable, UnsupportedError, NotImplementedError not due within an hour, SerializationError with a direction, circuit open, or HTTPError or ProviderError with a status classed permanent in status_codes (see SetRetryableHTTPStatuses), or an HTTPError with WithRetryableOverride(false), TLS certificate verification error, or *net.DNSError for a name that doesn't exist"},
+	{Name: "default", Class: ClassUnknown, Description: "no classification information"},
+}
+
+type rulesDocument struct {
+	Version         int              `json:"version"`
+	Rules           []manifestRule   `json:"rules"`
+	StatusCodes     []manifestStatus `json:"status_codes"`
+	Sentinels       []manifestKey    `json:"sentinels"`
+	MessagePatterns []string         `json:"message_patterns"`
+	Types           []manifestKey    `json:"types"`
+	Codes           []schemaCode     `json:"codes"`
+}
+
+type manifestRule struct {
+	Name        string     `json:"name"`
+	Class       ErrorClass `json:"class,omitempty"`
+	Description string     `json:"description"`
+}
+
+type manifestStatus struct {
+	From  int        `json:"from"`
+	To    int        `json:"to"`
+	Class ErrorClass `json:"class"`
+}
+
+type manifestKey struct {
+	Key   string     `json:"key"`
+	Class ErrorClass `json:"class"`
+}
+
+// RulesManifest describes the classification rules as data, so batch jobs
+// can re-classify historical errors without linking Go: the rules in
+// Classify's decision order, the class of each HTTP error status, the
+// sentinels that travel on the wire (keyed by message) with their classes,
+// the message patterns matched for untyped errors, every error type (see
+// TypeDescriptors) with its class and the code catalog with its mappings.
+// Classes are derived by running Classify, so the manifest can't disagree
+// with it. Output is indented and deterministic; its version field is
+// RulesVersion.
+//
+// Example:
+//
+//	registerErrorCodes()
+//	manifest, err := errors.RulesManifest()
+//	if err != nil {
+//	    log.Fatal(err)
+//	}
+//	os.WriteFile("rules.json", manifest, 0o644)
+func RulesManifest() ([]byte, error) {
+	doc := rulesDocument{
+		Version:         RulesVersion,
+		Rules:           classificationRules,
+		StatusCodes:     statusClasses(),
+		MessagePatterns: append([]string{}, StringFallbacks()...),
+		Codes:           catalogCodes(),
+	}
+
+	for _, sentinel := range wireSentinels {
+		doc.Sentinels = append(doc.Sentinels, manifestKey{Key: sentinel.Error(), Class: Classify(sentinel)})
+	}
+	for _, d := range TypeDescriptors() {
+		doc.Types = append(doc.Types, manifestKey{Key: d.Name, Class: d.Class()})
+	}
+
+	data, err := json.MarshalIndent(doc, "", "  ")
+	if err != nil {
+		return nil, Wrap(err, "exporting classification rules")
+	}
+	return append(data, '\n'), nil
+}
+
+// statusClasses returns the class of an HTTPError for each error status,
+// merged into ranges.
+func statusClasses() []manifestStatus {
+	var ranges []manifestStatus
+	for status := http.StatusBadRequest; status <= 599; status++ {
+		class := Classify(&HTTPError{StatusCode: status})
+		if n := len(ranges); n > 0 && ranges[n-1].Class == class {
+			ranges[n-1].To = status
+			continue
+		}
+		ranges = append(ranges, manifestStatus{From: status, To: status, Class: class})
+	}
+	return ranges
+}
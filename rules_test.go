@@ -0,0 +1,103 @@
+package errors
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// TestRulesManifest tests the manifest against the committed golden file, refusing to rewrite it without a version bump
+func TestRulesManifest(t *testing.T) {
+	t.Cleanup(ResetMappings)
+
+	RegisterCodes("ORDERS_NOT_FOUND")
+	SetMappingTable(NewMappingTable(
+		Code("INVENTORY_SYNCING").HTTP(503).Retryable(true),
+	))
+
+	got, err := RulesManifest()
+	if err != nil {
+		t.Fatalf("RulesManifest() error = %v", err)
+	}
+
+	golden := filepath.Join("testdata", "rules.golden.json")
+	want, err := os.ReadFile(golden)
+	if err != nil && !*updateGolden {
+		t.Fatalf("reading golden file: %v", err)
+	}
+	if *updateGolden && !bytes.Equal(got, want) {
+		var committed struct {
+			Version int `json:"version"`
+		}
+		if len(want) > 0 && json.Unmarshal(want, &committed) == nil && committed.Version == RulesVersion {
+			t.Fatalf("rules changed but RulesVersion is still %d; bump it before running -update", RulesVersion)
+		}
+		if err := os.WriteFile(golden, got, 0o644); err != nil {
+			t.Fatalf("writing golden file: %v", err)
+		}
+		want = got
+	}
+	if !bytes.Equal(got, want) {
+		t.Errorf("rules differ from %s; bump RulesVersion, review the change and run go test -run TestRulesManifest -update\n%s", golden, got)
+	}
+}
+
+// TestRulesManifestAgreesWithClassify tests that a classifier reading only the manifest agrees with Classify on common cases
+func TestRulesManifestAgreesWithClassify(t *testing.T) {
+	data, err := RulesManifest()
+	if err != nil {
+		t.Fatalf("RulesManifest() error = %v", err)
+	}
+	var manifest rulesDocument
+	if err := json.Unmarshal(data, &manifest); err != nil {
+		t.Fatalf("decoding manifest: %v", err)
+	}
+
+	classifyStatus := func(status int) ErrorClass {
+		for _, r := range manifest.StatusCodes {
+			if status >= r.From && status <= r.To {
+				return r.Class
+			}
+		}
+		return ClassUnknown
+	}
+	classifyMessage := func(msg string) ErrorClass {
+		for _, s := range manifest.Sentinels {
+			if strings.Contains(msg, s.Key) {
+				return s.Class
+			}
+		}
+		for _, pattern := range manifest.MessagePatterns {
+			if strings.Contains(strings.ToLower(msg), pattern) {
+				return ClassTransient
+			}
+		}
+		return ClassUnknown
+	}
+
+	for _, status := range []int{400, 404, 409, 429, 500, 502, 503} {
+		t.Run(fmt.Sprintf("status %d", status), func(t *testing.T) {
+			err := NewHTTPError(status, "failed", nil)
+			if got, want := classifyStatus(status), Classify(err); got != want {
+				t.Errorf("manifest class = %s, Classify = %s", got, want)
+			}
+		})
+	}
+
+	for _, err := range []error{
+		Wrap(ErrRateLimited, "calling api"),
+		Wrap(ErrDeadlock, "saving order"),
+		fmt.Errorf("upstream: Rate Limit exceeded"),
+		fmt.Errorf("boom"),
+	} {
+		t.Run(err.Error(), func(t *testing.T) {
+			if got, want := classifyMessage(err.Error()), Classify(err); got != want {
+				t.Errorf("manifest class = %s, Classify = %s", got, want)
+			}
+		})
+	}
+}
@@ -0,0 +1,319 @@
+package errors
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"math"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// Limits applied by SanitizeValue.
+const (
+	maxSanitizeDepth  = 8
+	maxSanitizeItems  = 100
+	maxSanitizeString = 1024
+	maxSanitizeBytes  = 64
+)
+
+// maxExactJSONInteger is 2^53, beyond which integers don't survive JSON
+// parsers that read numbers as float64, such as JavaScript's and
+// encoding/json's into an any.
+const maxExactJSONInteger = 1 << 53
+
+// SanitizeValue converts v into a form that encoding/json can always
+// marshal, for attaching arbitrary WithValue and WithMetadata values to
+// logs and API responses. It never panics.
+//
+// Conversions:
+//   - time.Time - RFC 3339 string
+//   - errors - their message
+//   - json.Marshaler - kept if it marshals successfully
+//   - fmt.Stringer - its String() result
+//   - []byte - hex string, capped at 64 bytes
+//   - NaN and infinite floats - "NaN", "+Inf" or "-Inf"
+//   - structs - map of exported fields, honoring json tag names
+//   - maps and slices - converted element-wise, capped at 100 entries
+//   - funcs, channels and unsafe pointers - a "<type>" placeholder
+//
+// Strings are capped at 1024 bytes and nesting deeper than 8 levels is
+// replaced with "…".
+//
+// Example:
+//
+//	info := map[string]any{"value": errors.SanitizeValue(v)}
+//	json.Marshal(info) // never fails
+func SanitizeValue(v any) any {
+	return sanitize(v, 0)
+}
+
+func sanitize(v any, depth int) (result any) {
+	if v == nil {
+		return nil
+	}
+	if depth > maxSanitizeDepth {
+		return truncatedCause
+	}
+
+	defer func() {
+		if r := recover(); r != nil {
+			result = placeholder(reflect.TypeOf(v))
+		}
+	}()
+
+	switch t := v.(type) {
+	case time.Time:
+		return t.Format(time.RFC3339Nano)
+	case error:
+		return capString(t.Error())
+	case json.RawMessage:
+		if json.Valid(t) {
+			return t
+		}
+		return capString(string(t))
+	case json.Marshaler:
+		if data, err := t.MarshalJSON(); err == nil && json.Valid(data) {
+			return json.RawMessage(data)
+		}
+	case fmt.Stringer:
+		return capString(t.String())
+	case []byte:
+		return capBytes(t)
+	}
+
+	rv := reflect.ValueOf(v)
+	switch rv.Kind() {
+	case reflect.Bool:
+		return rv.Bool()
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return rv.Int()
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return rv.Uint()
+	case reflect.Float32, reflect.Float64:
+		return sanitizeFloat(rv.Float())
+	case reflect.Complex64, reflect.Complex128:
+		return fmt.Sprint(rv.Complex())
+	case reflect.String:
+		return capString(rv.String())
+	case reflect.Pointer, reflect.Interface:
+		if rv.IsNil() {
+			return nil
+		}
+		return sanitize(rv.Elem().Interface(), depth+1)
+	case reflect.Slice:
+		if rv.IsNil() {
+			return nil
+		}
+		if rv.Type().Elem().Kind() == reflect.Uint8 {
+			return capBytes(rv.Bytes())
+		}
+		return sanitizeList(rv, depth)
+	case reflect.Array:
+		return sanitizeList(rv, depth)
+	case reflect.Map:
+		if rv.IsNil() {
+			return nil
+		}
+		return sanitizeMapValue(rv, depth)
+	case reflect.Struct:
+		return sanitizeStruct(rv, depth)
+	default:
+		return placeholder(rv.Type())
+	}
+}
+
+// FormatValue renders a rejected value (see WithValue) for error messages.
+// It is the one place values are turned into text, so Error() agrees with
+// the JSON forms: times are RFC 3339, NaN and infinite floats read "NaN",
+// "+Inf" or "-Inf", integers keep every digit, and the result is capped at
+// 1024 bytes like SanitizeValue's strings.
+//
+// Example:
+//
+//	errors.FormatValue(uint64(math.MaxUint64)) // "18446744073709551615"
+func FormatValue(v any) string {
+	switch t := v.(type) {
+	case nil:
+		return "<nil>"
+	case time.Time:
+		return t.Format(time.RFC3339Nano)
+	case *time.Time:
+		if t != nil {
+			return t.Format(time.RFC3339Nano)
+		}
+	}
+	return capString(fmt.Sprint(v))
+}
+
+// encodeValue converts a rejected value for JSON: SanitizeValue's form,
+// with integers JSON parsers can't hold exactly (beyond ±2^53) as decimal
+// strings. stringEncoded reports the conversion, so receivers can tell
+// 9007199254740993 from "9007199254740993".
+func encodeValue(v any) (value any, stringEncoded bool) {
+	switch n := SanitizeValue(v).(type) {
+	case int64:
+		if n > maxExactJSONInteger || n < -maxExactJSONInteger {
+			return strconv.FormatInt(n, 10), true
+		}
+		return n, false
+	case uint64:
+		if n > maxExactJSONInteger {
+			return strconv.FormatUint(n, 10), true
+		}
+		return n, false
+	default:
+		return n, false
+	}
+}
+
+// decodeValue reverses encodeValue's string encoding of large integers.
+func decodeValue(v any, stringEncoded bool) any {
+	s, ok := v.(string)
+	if !stringEncoded || !ok {
+		return v
+	}
+	if n, err := strconv.ParseInt(s, 10, 64); err == nil {
+		return n
+	}
+	if n, err := strconv.ParseUint(s, 10, 64); err == nil {
+		return n
+	}
+	return v
+}
+
+// sanitizeMap applies SanitizeValue to every value in m.
+func sanitizeMap(m map[string]any) map[string]any {
+	if m == nil {
+		return nil
+	}
+	result := make(map[string]any, len(m))
+	for k, v := range m {
+		result[k] = SanitizeValue(v)
+	}
+	return result
+}
+
+func sanitizeFloat(f float64) any {
+	switch {
+	case math.IsNaN(f):
+		return "NaN"
+	case math.IsInf(f, 1):
+		return "+Inf"
+	case math.IsInf(f, -1):
+		return "-Inf"
+	}
+	return f
+}
+
+func sanitizeList(rv reflect.Value, depth int) any {
+	n := min(rv.Len(), maxSanitizeItems)
+	items := make([]any, 0, n+1)
+	for i := range n {
+		items = append(items, sanitizeElem(rv.Index(i), depth))
+	}
+	if rv.Len() > n {
+		items = append(items, truncatedCause)
+	}
+	return items
+}
+
+func sanitizeMapValue(rv reflect.Value, depth int) any {
+	type entry struct {
+		key   string
+		value reflect.Value
+	}
+
+	entries := make([]entry, 0, rv.Len())
+	iter := rv.MapRange()
+	for iter.Next() {
+		key := capString(fmt.Sprint(sanitizeElem(iter.Key(), depth)))
+		entries = append(entries, entry{key: key, value: iter.Value()})
+	}
+	sort.Slice(entries, func(i, j int) bool { return entries[i].key < entries[j].key })
+
+	result := make(map[string]any, min(len(entries), maxSanitizeItems))
+	for i, e := range entries {
+		if i == maxSanitizeItems {
+			result[truncatedCause] = len(entries) - maxSanitizeItems
+			break
+		}
+		result[e.key] = sanitizeElem(e.value, depth)
+	}
+	return result
+}
+
+func sanitizeStruct(rv reflect.Value, depth int) any {
+	rt := rv.Type()
+	result := make(map[string]any)
+	for i := range rt.NumField() {
+		field := rt.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+
+		name := field.Name
+		if tag, ok := field.Tag.Lookup("json"); ok {
+			tagName, _, _ := strings.Cut(tag, ",")
+			if tagName == "-" {
+				continue
+			}
+			if tagName != "" {
+				name = tagName
+			}
+		}
+		result[name] = sanitizeElem(rv.Field(i), depth)
+		if len(result) == maxSanitizeItems {
+			break
+		}
+	}
+
+	if len(result) == 0 {
+		// Only unexported fields: fall back to the formatted value.
+		return capString(fmt.Sprintf("%+v", rv.Interface()))
+	}
+	return result
+}
+
+// sanitizeElem sanitizes a nested value one level deeper.
+func sanitizeElem(rv reflect.Value, depth int) any {
+	if !rv.CanInterface() {
+		return placeholder(rv.Type())
+	}
+	return sanitize(rv.Interface(), depth+1)
+}
+
+func placeholder(t reflect.Type) string {
+	if t == nil {
+		return "<nil>"
+	}
+	return "<" + t.String() + ">"
+}
+
+func capString(s string) string {
+	return truncateString(s, maxSanitizeString)
+}
+
+// truncateString cuts s to at most limit bytes on a rune boundary, marking
+// the cut with "…".
+func truncateString(s string, limit int) string {
+	if len(s) <= limit {
+		return s
+	}
+	cut := limit
+	for cut > 0 && !utf8.RuneStart(s[cut]) {
+		cut--
+	}
+	return s[:cut] + truncatedCause
+}
+
+func capBytes(b []byte) string {
+	if len(b) <= maxSanitizeBytes {
+		return hex.EncodeToString(b)
+	}
+	return hex.EncodeToString(b[:maxSanitizeBytes]) + truncatedCause
+}
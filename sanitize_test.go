@@ -0,0 +1,322 @@
+package errors
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"math/rand/v2"
+	"strings"
+	"testing"
+	"time"
+)
+
+type sanitizeStringer struct{ id int }
+
+func (s sanitizeStringer) String() string { return fmt.Sprintf("item-%d", s.id) }
+
+type sanitizePanicky struct{}
+
+func (*sanitizePanicky) String() string { panic("boom") }
+
+type sanitizeBadMarshaler struct{}
+
+func (sanitizeBadMarshaler) MarshalJSON() ([]byte, error) { return nil, fmt.Errorf("cannot marshal") }
+
+type sanitizeRecord struct {
+	Name    string `json:"name"`
+	Skipped string `json:"-"`
+	Count   int
+	secret  string
+	Ch      chan int
+}
+
+type sanitizeNode struct {
+	Next *sanitizeNode
+}
+
+// TestSanitizeValue tests conversion of values to JSON-safe forms
+func TestSanitizeValue(t *testing.T) {
+	when := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name  string
+		value any
+		want  string
+	}{
+		{name: "nil", value: nil, want: `null`},
+		{name: "string", value: "hello", want: `"hello"`},
+		{name: "int", value: 42, want: `42`},
+		{name: "time", value: when, want: `"2024-05-01T12:00:00Z"`},
+		{name: "duration stringer", value: 1500 * time.Millisecond, want: `"1.5s"`},
+		{name: "stringer", value: sanitizeStringer{id: 7}, want: `"item-7"`},
+		{name: "panicking stringer", value: &sanitizePanicky{}, want: `"<*errors.sanitizePanicky>"`},
+		{name: "failing marshaler", value: sanitizeBadMarshaler{}, want: `"{}"`},
+		{name: "error", value: fmt.Errorf("boom"), want: `"boom"`},
+		{name: "bytes", value: []byte{0xde, 0xad}, want: `"dead"`},
+		{name: "NaN", value: math.NaN(), want: `"NaN"`},
+		{name: "inf", value: math.Inf(-1), want: `"-Inf"`},
+		{name: "func", value: func() {}, want: `"<func()>"`},
+		{name: "channel", value: make(chan int), want: `"<chan int>"`},
+		{name: "complex", value: complex(1, 2), want: `"(1+2i)"`},
+		{
+			name:  "struct",
+			value: sanitizeRecord{Name: "a", Skipped: "x", Count: 2, secret: "s", Ch: make(chan int)},
+			want:  `{"Ch":"<chan int>","Count":2,"name":"a"}`,
+		},
+		{name: "unexported-only struct", value: struct{ n int }{n: 3}, want: `"{n:3}"`},
+		{name: "map with non-string keys", value: map[int]float64{1: math.Inf(1)}, want: `{"1":"+Inf"}`},
+		{name: "nil pointer", value: (*sanitizeRecord)(nil), want: `null`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf strings.Builder
+			enc := json.NewEncoder(&buf)
+			enc.SetEscapeHTML(false)
+			if err := enc.Encode(SanitizeValue(tt.value)); err != nil {
+				t.Fatalf("Encode() error = %v", err)
+			}
+			if data := strings.TrimSpace(buf.String()); data != tt.want {
+				t.Errorf("got %s, want %s", data, tt.want)
+			}
+		})
+	}
+
+	t.Run("caps", func(t *testing.T) {
+		long := strings.Repeat("é", maxSanitizeString)
+		if s := SanitizeValue(long).(string); len(s) > maxSanitizeString+len(truncatedCause) || !strings.HasSuffix(s, truncatedCause) {
+			t.Errorf("string not capped: %d bytes", len(s))
+		}
+		if s := SanitizeValue(make([]byte, 1000)).(string); len(s) != 2*maxSanitizeBytes+len(truncatedCause) {
+			t.Errorf("bytes not capped: %d chars", len(s))
+		}
+		if items := SanitizeValue(make([]int, 1000)).([]any); len(items) != maxSanitizeItems+1 {
+			t.Errorf("slice not capped: %d items", len(items))
+		}
+	})
+
+	t.Run("cyclic structures terminate", func(t *testing.T) {
+		node := &sanitizeNode{}
+		node.Next = node
+		m := map[string]any{}
+		m["self"] = m
+
+		for _, v := range []any{node, m} {
+			if _, err := json.Marshal(SanitizeValue(v)); err != nil {
+				t.Errorf("json.Marshal() error = %v", err)
+			}
+		}
+	})
+}
+
+// TestSanitizedErrorMarshalling tests that errors carrying hostile values always marshal
+func TestSanitizedErrorMarshalling(t *testing.T) {
+	hostile := map[string]any{"ch": make(chan int), "nan": math.NaN(), "fn": func() {}}
+	err := NewValidationError("bad value", "amount",
+		WithValue(hostile),
+		WithMetadata("attempted_at", time.Now()),
+		WithMetadata("raw", math.Inf(1)))
+
+	if _, marshalErr := json.Marshal(ExtractErrorInfo(err)); marshalErr != nil {
+		t.Errorf("ExtractErrorInfo() did not marshal: %v", marshalErr)
+	}
+	if _, marshalErr := MarshalError(Wrap(err, "outer")); marshalErr != nil {
+		t.Errorf("MarshalError() error = %v", marshalErr)
+	}
+
+	problem := ToProblemDetails(err)
+	problem.Extensions = map[string]any{"value": hostile}
+	if _, marshalErr := json.Marshal(problem); marshalErr != nil {
+		t.Errorf("ProblemDetails did not marshal: %v", marshalErr)
+	}
+
+	info := ExtractErrorInfo(err)
+	if metadata, ok := info["metadata"].(map[string]any); !ok || metadata["raw"] != "+Inf" {
+		t.Errorf("metadata not surfaced: %v", info["metadata"])
+	}
+}
+
+// TestSanitizeValueRandom tests random nested values never fail to marshal
+func TestSanitizeValueRandom(t *testing.T) {
+	r := rand.New(rand.NewPCG(1, 2))
+	for i := range 2000 {
+		v := randomValue(r, 0)
+		if _, err := json.Marshal(SanitizeValue(v)); err != nil {
+			t.Fatalf("iteration %d: json.Marshal(%#v) error = %v", i, v, err)
+		}
+	}
+}
+
+// FuzzSanitizeValue tests that values derived from arbitrary input never panic or fail to marshal
+func FuzzSanitizeValue(f *testing.F) {
+	f.Add(uint64(1), uint64(2))
+	f.Add(uint64(0), uint64(0))
+	f.Add(uint64(math.MaxUint64), uint64(42))
+
+	f.Fuzz(func(t *testing.T, seed1, seed2 uint64) {
+		v := randomValue(rand.New(rand.NewPCG(seed1, seed2)), 0)
+		if _, err := json.Marshal(SanitizeValue(v)); err != nil {
+			t.Fatalf("json.Marshal(%#v) error = %v", v, err)
+		}
+	})
+}
+
+// randomValue builds a random value mixing JSON-hostile kinds.
+func randomValue(r *rand.Rand, depth int) any {
+	kinds := 14
+	if depth > 12 {
+		kinds = 8
+	}
+
+	switch r.IntN(kinds) {
+	case 0:
+		return nil
+	case 1:
+		return math.Float64frombits(r.Uint64())
+	case 2:
+		b := make([]byte, r.IntN(200))
+		for i := range b {
+			b[i] = byte(r.Uint32())
+		}
+		return string(b)
+	case 3:
+		b := make([]byte, r.IntN(200))
+		for i := range b {
+			b[i] = byte(r.Uint32())
+		}
+		return b
+	case 4:
+		return make(chan struct{})
+	case 5:
+		return func(int) error { return nil }
+	case 6:
+		return time.Unix(r.Int64N(1<<40), 0)
+	case 7:
+		return &sanitizePanicky{}
+	case 8:
+		items := make([]any, r.IntN(5))
+		for i := range items {
+			items[i] = randomValue(r, depth+1)
+		}
+		return items
+	case 9:
+		m := map[any]any{}
+		for range r.IntN(5) {
+			m[r.Float64()] = randomValue(r, depth+1)
+		}
+		return m
+	case 10:
+		return sanitizeRecord{Name: fmt.Sprint(r.Int()), Count: r.Int(), Ch: make(chan int)}
+	case 11:
+		v := randomValue(r, depth+1)
+		return &v
+	case 12:
+		return complex(math.Float64frombits(r.Uint64()), 0)
+	default:
+		return uint64(r.Uint64())
+	}
+}
+
+// TestFormatValue tests rendering of rejected values in messages
+func TestFormatValue(t *testing.T) {
+	at := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name  string
+		value any
+		want  string
+	}{
+		{name: "nil", value: nil, want: "<nil>"},
+		{name: "int64 max", value: int64(math.MaxInt64), want: "9223372036854775807"},
+		{name: "uint64 max", value: uint64(math.MaxUint64), want: "18446744073709551615"},
+		{name: "NaN", value: math.NaN(), want: "NaN"},
+		{name: "negative infinity", value: math.Inf(-1), want: "-Inf"},
+		{name: "time", value: at, want: "2024-03-01T12:00:00Z"},
+		{name: "time pointer", value: &at, want: "2024-03-01T12:00:00Z"},
+		{name: "long string", value: strings.Repeat("x", 2000), want: strings.Repeat("x", maxSanitizeString) + truncatedCause},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := FormatValue(tt.value); got != tt.want {
+				t.Errorf("FormatValue() = %q, want %q", got, tt.want)
+			}
+			err := NewValidationError("out of range", "n", WithValue(tt.value))
+			if !strings.Contains(err.Error(), "(value: "+tt.want+")") {
+				t.Errorf("Error() = %q, want the value as FormatValue renders it", err)
+			}
+		})
+	}
+}
+
+// TestValidationValueJSON tests that hard-to-encode values produce parseable JSON everywhere and survive a round trip
+func TestValidationValueJSON(t *testing.T) {
+	tests := []struct {
+		name          string
+		value         any
+		wantJSON      any // value after decoding the JSON with UseNumber
+		stringEncoded bool
+		wantDecoded   any // Value after Decode
+	}{
+		{name: "int64 max", value: int64(math.MaxInt64), wantJSON: "9223372036854775807", stringEncoded: true, wantDecoded: int64(math.MaxInt64)},
+		{name: "int64 min", value: int64(math.MinInt64), wantJSON: "-9223372036854775808", stringEncoded: true, wantDecoded: int64(math.MinInt64)},
+		{name: "uint64 max", value: uint64(math.MaxUint64), wantJSON: "18446744073709551615", stringEncoded: true, wantDecoded: uint64(math.MaxUint64)},
+		{name: "exact integer", value: 1 << 53, wantJSON: json.Number("9007199254740992"), wantDecoded: float64(1 << 53)},
+		{name: "NaN", value: math.NaN(), wantJSON: "NaN", wantDecoded: "NaN"},
+		{name: "infinity", value: math.Inf(1), wantJSON: "+Inf", wantDecoded: "+Inf"},
+		{name: "time", value: time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC), wantJSON: "2024-03-01T12:00:00Z", wantDecoded: "2024-03-01T12:00:00Z"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := NewValidationError("out of range", "n", WithValue(tt.value))
+
+			outputs := map[string][]byte{}
+			var marshalErr error
+			if outputs["ExtractErrorInfo"], marshalErr = json.Marshal(ExtractErrorInfo(err)); marshalErr != nil {
+				t.Fatalf("ExtractErrorInfo() did not marshal: %v", marshalErr)
+			}
+			if outputs["MarshalJSON"], marshalErr = json.Marshal(err); marshalErr != nil {
+				t.Fatalf("MarshalJSON() error = %v", marshalErr)
+			}
+			if outputs["problem"], marshalErr = json.Marshal(ToProblemDetails(err)); marshalErr != nil {
+				t.Fatalf("ProblemDetails did not marshal: %v", marshalErr)
+			}
+			if validateErr := ValidateWirePayload(outputs["problem"]); validateErr != nil {
+				t.Errorf("problem details invalid: %v", validateErr)
+			}
+
+			for name, data := range outputs {
+				decoder := json.NewDecoder(strings.NewReader(string(data)))
+				decoder.UseNumber()
+				var doc map[string]any
+				if decodeErr := decoder.Decode(&doc); decodeErr != nil {
+					t.Fatalf("%s: %s is not parseable: %v", name, data, decodeErr)
+				}
+				if doc["value"] != tt.wantJSON {
+					t.Errorf("%s: value = %#v, want %#v", name, doc["value"], tt.wantJSON)
+				}
+				if got := doc["is_string_encoded"] == true; got != tt.stringEncoded {
+					t.Errorf("%s: is_string_encoded = %v, want %v", name, got, tt.stringEncoded)
+				}
+			}
+
+			var decoded, fromJSON *ValidationError
+			if !As(Decode(Encode(err)), &decoded) {
+				t.Fatalf("Decode() did not restore a ValidationError")
+			}
+			var envelope *Envelope
+			if unmarshalErr := json.Unmarshal(outputs["MarshalJSON"], &envelope); unmarshalErr != nil {
+				t.Fatalf("unmarshal envelope: %v", unmarshalErr)
+			}
+			if !As(Decode(envelope), &fromJSON) {
+				t.Fatalf("Decode() of the JSON did not restore a ValidationError")
+			}
+			if fromJSON.Value != tt.wantDecoded {
+				t.Errorf("decoded Value = %#v, want %#v", fromJSON.Value, tt.wantDecoded)
+			}
+			if tt.stringEncoded && decoded.Value != tt.value {
+				t.Errorf("Decode(Encode()) Value = %#v, want the original %#v", decoded.Value, tt.value)
+			}
+		})
+	}
+}
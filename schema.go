@@ -0,0 +1,113 @@
+package errors
+
+import (
+	"encoding/json"
+	"io"
+)
+
+// SchemaVersion is the layout version of SchemaExport's output. It is
+// bumped when members are renamed or change meaning, not when codes or
+// types are added.
+const SchemaVersion = 1
+
+// problemMembers documents the RFC 7807 members of ProblemDetails.
+var problemMembers = []ProblemExtension{
+	{Name: ProblemType, Type: "string", Description: "Problem type URI; always about:blank"},
+	{Name: ProblemTitle, Type: "string", Description: "HTTP status text"},
+	{Name: ProblemStatus, Type: "integer", Description: "HTTP status code"},
+	{Name: ProblemDetail, Type: "string", Description: "Error message, for client errors (4xx) only"},
+	{Name: ProblemInstance, Type: "string", Description: "URI of the failing request, when set"},
+}
+
+type schemaDocument struct {
+	Version     int                `json:"version"`
+	ContentType string             `json:"content_type"`
+	Members     []ProblemExtension `json:"members"`
+	Extensions  []ProblemExtension `json:"extensions"`
+	Codes       []schemaCode       `json:"codes"`
+	Types       []schemaType       `json:"types"`
+}
+
+type schemaCode struct {
+	Code       string    `json:"code"`
+	HTTPStatus int       `json:"http_status,omitempty"`
+	GRPCCode   *GRPCCode `json:"grpc_code,omitempty"`
+	ExitCode   *int      `json:"exit_code,omitempty"`
+	Retryable  *bool     `json:"retryable,omitempty"`
+}
+
+type schemaType struct {
+	Name        string             `json:"name"`
+	Description string             `json:"description"`
+	Status      int                `json:"status"`
+	Class       ErrorClass         `json:"class"`
+	Extensions  []ProblemExtension `json:"extensions,omitempty"`
+}
+
+// SchemaExport describes the application/problem+json responses this
+// package produces, for API documentation: the standard and common
+// extension members, every code in the catalog (RegisterCodes and the
+// installed MappingTable) with its transport mappings, and every error type
+// (see TypeDescriptors) with its status, class and extension members.
+// Output is indented and deterministic, so it can be committed and diffed.
+func SchemaExport() ([]byte, error) {
+	doc := schemaDocument{
+		Version:     SchemaVersion,
+		ContentType: ProblemContentType,
+		Members:     problemMembers,
+		Extensions:  commonExtensions,
+		Codes:       catalogCodes(),
+	}
+
+	for _, d := range TypeDescriptors() {
+		doc.Types = append(doc.Types, schemaType{
+			Name:        d.Name,
+			Description: d.Description,
+			Status:      d.Status(),
+			Class:       d.Class(),
+			Extensions:  d.Extensions,
+		})
+	}
+
+	data, err := json.MarshalIndent(doc, "", "  ")
+	if err != nil {
+		return nil, Wrap(err, "exporting error schema")
+	}
+	return append(data, '\n'), nil
+}
+
+// catalogCodes returns the code catalog with each code's mappings.
+func catalogCodes() []schemaCode {
+	codes := []schemaCode{}
+	for _, entry := range codeCatalog() {
+		code := schemaCode{Code: entry.code}
+		if m := entry.mapping; m != nil {
+			code.HTTPStatus, code.GRPCCode, code.ExitCode, code.Retryable = m.httpStatus, m.grpcCode, m.exitCode, m.retryable
+		}
+		codes = append(codes, code)
+	}
+	return codes
+}
+
+// WriteSchema writes SchemaExport's output to w, for services dumping it
+// into their docs pipeline at build time.
+//
+// Example:
+//
+//	//go:generate go run ./cmd/errschema
+//	func main() {
+//	    registerErrorCodes()
+//	    if err := errors.WriteSchema(os.Stdout); err != nil {
+//	        log.Fatal(err)
+//	    }
+//	}
+func WriteSchema(w io.Writer) error {
+	data, err := SchemaExport()
+	if err != nil {
+		return err
+	}
+	if _, err := w.Write(data); err != nil {
+		return Wrap(err, "writing error schema")
+	}
+	return nil
+}
@@ -0,0 +1,182 @@
+package errors
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+var updateGolden = flag.Bool("update", false, "rewrite golden files in testdata")
+
+// TestSchemaExport tests the exported schema against the committed golden file
+func TestSchemaExport(t *testing.T) {
+	t.Cleanup(ResetMappings)
+	t.Cleanup(ResetTypeDescriptors)
+
+	RegisterCodes("ORDERS_NOT_FOUND", "PAYMENT_DECLINED", "INVENTORY_SYNCING")
+	SetMappingTable(NewMappingTable(
+		Code("ORDERS_NOT_FOUND").HTTP(404).GRPC(GRPCNotFound),
+		Code("PAYMENT_DECLINED").HTTP(402).Exit(ExitDataErr).Retryable(false),
+		Code("INVENTORY_SYNCING").HTTP(503).GRPC(GRPCUnavailable).Retryable(true),
+	))
+	RegisterTypeDescriptor(TypeDescriptor{
+		Name:        "QuotaError",
+		Description: "Monthly quota exhausted",
+		Example:     Permanent(NewHTTPError(403, "Quota exhausted", nil)),
+	})
+
+	got, err := SchemaExport()
+	if err != nil {
+		t.Fatalf("SchemaExport() error = %v", err)
+	}
+
+	golden := filepath.Join("testdata", "schema.golden.json")
+	if *updateGolden {
+		if err := os.WriteFile(golden, got, 0o644); err != nil {
+			t.Fatalf("writing golden file: %v", err)
+		}
+	}
+	want, err := os.ReadFile(golden)
+	if err != nil {
+		t.Fatalf("reading golden file: %v", err)
+	}
+	if !bytes.Equal(got, want) {
+		t.Errorf("schema differs from %s; review the change and run go test -run TestSchemaExport -update\n%s", golden, got)
+	}
+
+	var buf bytes.Buffer
+	if err := WriteSchema(&buf); err != nil || !bytes.Equal(buf.Bytes(), got) {
+		t.Errorf("WriteSchema() = %v, should write SchemaExport's output", err)
+	}
+}
+
+// TestTypeDescriptors tests built-in and registered descriptors
+func TestTypeDescriptors(t *testing.T) {
+	t.Cleanup(ResetTypeDescriptors)
+
+	tests := []struct {
+		name       string
+		wantStatus int
+		wantClass  ErrorClass
+	}{
+		{name: "ValidationError", wantStatus: 400, wantClass: ClassPermanent},
+		{name: "RateLimitError", wantStatus: 429, wantClass: ClassTransient},
+		{name: "CircuitBreakerError", wantStatus: 503, wantClass: ClassPermanent},
+	}
+
+	byName := make(map[string]TypeDescriptor)
+	for _, d := range TypeDescriptors() {
+		byName[d.Name] = d
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d, ok := byName[tt.name]
+			if !ok {
+				t.Fatalf("no descriptor for %s", tt.name)
+			}
+			if d.Status() != tt.wantStatus || d.Class() != tt.wantClass {
+				t.Errorf("got status %d class %q, want %d %q", d.Status(), d.Class(), tt.wantStatus, tt.wantClass)
+			}
+		})
+	}
+
+	t.Run("registering a name replaces it", func(t *testing.T) {
+		RegisterTypeDescriptor(TypeDescriptor{Name: "QuotaError", Description: "old"})
+		RegisterTypeDescriptor(TypeDescriptor{Name: "QuotaError", Description: "new"})
+
+		count := 0
+		for _, d := range TypeDescriptors() {
+			if d.Name == "QuotaError" {
+				count++
+				if d.Description != "new" {
+					t.Errorf("Description = %q, want the later registration", d.Description)
+				}
+			}
+		}
+		if count != 1 {
+			t.Errorf("got %d QuotaError descriptors, want 1", count)
+		}
+	})
+}
+
+// TestProblemExtensions tests the documented extension members appear in problem details
+func TestProblemExtensions(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want map[string]any
+		omit []string
+	}{
+		{
+			name: "validation field and code",
+			err:  NewValidationError("Invalid email", "email", WithCode("SIGNUP_INVALID")),
+			want: map[string]any{"field": "email", "code": "SIGNUP_INVALID"},
+			omit: []string{"retry_after"},
+		},
+		{
+			name: "retry after",
+			err:  NewRateLimitError("Too many requests", "Search", 1500*time.Millisecond),
+			want: map[string]any{"retry_after": float64(2)},
+			omit: []string{"field", "code"},
+		},
+		{
+			name: "validation value",
+			err:  NewValidationError("Price must be positive", "price", WithValue(-10)),
+			want: map[string]any{"field": "price", "value": float64(-10)},
+		},
+		{
+			name: "sensitive value",
+			err:  NewValidationError("Password too short", "new_password", WithValue("hunter2")),
+			want: map[string]any{"field": "new_password"},
+			omit: []string{"value"},
+		},
+		{
+			name: "value hidden for server errors",
+			err:  NewHTTPError(500, "Internal Server Error", NewValidationError("bad config", "region", WithValue("mars"))),
+			omit: []string{"field", "value"},
+		},
+		{
+			name: "circuit state and counts",
+			err: NewCircuitBreakerError("circuit open", "Call", "open",
+				WithCounts(CircuitCounts{Requests: 10, TotalFailures: 6, ConsecutiveFailures: 6})),
+			want: map[string]any{"state": "open", "counts": map[string]any{
+				"Requests": float64(10), "TotalSuccesses": float64(0), "TotalFailures": float64(6),
+				"ConsecutiveSuccesses": float64(0), "ConsecutiveFailures": float64(6),
+			}},
+		},
+		{
+			name: "circuit without counts",
+			err:  NewCircuitBreakerError("circuit open", "Call", "half-open"),
+			want: map[string]any{"state": "half-open"},
+			omit: []string{"counts"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data, err := json.Marshal(ToProblemDetails(tt.err))
+			if err != nil {
+				t.Fatalf("json.Marshal() error = %v", err)
+			}
+			var members map[string]any
+			if err := json.Unmarshal(data, &members); err != nil {
+				t.Fatalf("json.Unmarshal() error = %v", err)
+			}
+			for k, v := range tt.want {
+				if !reflect.DeepEqual(members[k], v) {
+					t.Errorf("%s = %v, want %v", k, members[k], v)
+				}
+			}
+			for _, k := range tt.omit {
+				if _, ok := members[k]; ok {
+					t.Errorf("unexpected member %s in %s", k, data)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,165 @@
+// Package sentryerrors reports jp-go-errors errors to Sentry with severity,
+// classification and grouping derived from the error chain.
+package sentryerrors
+
+import (
+	"context"
+
+	"github.com/getsentry/sentry-go"
+
+	errors "github.com/JohnPlummer/jp-go-errors"
+)
+
+// maxExceptionDepth caps how many causes are attached as Sentry exceptions.
+const maxExceptionDepth = 10
+
+// Grouping selects the key Sentry uses to group events into issues.
+type Grouping int
+
+const (
+	// GroupByFingerprint groups by errors.Fingerprint: same failure at the
+	// same code path within one build.
+	GroupByFingerprint Grouping = iota
+
+	// GroupByOriginKey groups by errors.OriginKey: same originating
+	// function, stable across rebuilds and services.
+	GroupByOriginKey
+)
+
+type config struct {
+	grouping Grouping
+	filter   errors.HookFilter
+}
+
+// Option configures how events are built.
+type Option func(*config)
+
+// WithGrouping selects the grouping key. Defaults to GroupByFingerprint.
+//
+// Example:
+//
+//	sentryerrors.CaptureError(hub, err,
+//	    sentryerrors.WithGrouping(sentryerrors.GroupByOriginKey))
+func WithGrouping(g Grouping) Option {
+	return func(c *config) {
+		c.grouping = g
+	}
+}
+
+// WithFilter drops errors that don't match filter, using the same rules as
+// reporting hooks. Defaults to sending every error.
+//
+// Example:
+//
+//	sentryerrors.CaptureError(hub, err, sentryerrors.WithFilter(errors.HookFilter{
+//	    MinSeverity: errors.SeverityError,
+//	}))
+func WithFilter(filter errors.HookFilter) Option {
+	return func(c *config) {
+		c.filter = filter
+	}
+}
+
+// NewEvent builds a Sentry event for err, titled with errors.Summarize
+// rather than the full message. The level follows errors.GetSeverity, tags
+// carry the metric labels, component, owning team (see errors.GetOwner)
+// and upstream request ID (see errors.GetUpstreamRequestID), the structured error information is
+// attached as the "error" context, request parameters recorded with
+// errors.WithParams go in a "params" context (the successor to Sentry's
+// extra data), and the event fingerprint is set from the selected grouping
+// key.
+// Returns nil for a nil error or one rejected by the filter.
+func NewEvent(err error, opts ...Option) *sentry.Event {
+	if err == nil {
+		return nil
+	}
+
+	cfg := config{}
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+	if !cfg.filter.Match(err) {
+		return nil
+	}
+
+	event := sentry.NewEvent()
+	event.Level = level(errors.GetSeverity(err))
+	event.Message = errors.Summarize(err)
+	event.SetException(err, maxExceptionDepth)
+
+	event.Tags = errors.MetricLabels(err)
+	if component := errors.GetComponent(err); component != "" {
+		event.Tags["component"] = component
+	}
+	if owner := errors.GetOwner(err); owner != "" {
+		event.Tags["team"] = owner
+	}
+	if id := errors.GetUpstreamRequestID(err); id != "" {
+		event.Tags["upstream_request_id"] = id
+	}
+	event.Contexts["error"] = errors.ExtractErrorInfo(err)
+	if params, ok := errors.GetMetadata(err, errors.MetadataParams); ok {
+		if params, ok := params.(map[string]any); ok {
+			event.Contexts[errors.MetadataParams] = params
+		}
+	}
+
+	key := errors.Fingerprint(err)
+	if cfg.grouping == GroupByOriginKey {
+		key = errors.OriginKey(err)
+	}
+	event.Fingerprint = []string{key}
+
+	return event
+}
+
+// CaptureError sends err to Sentry through hub, or the current hub if hub
+// is nil. Returns the event ID, or nil if nothing was sent.
+//
+// Example:
+//
+//	if err != nil {
+//	    sentryerrors.CaptureError(sentry.GetHubFromContext(ctx), err)
+//	}
+func CaptureError(hub *sentry.Hub, err error, opts ...Option) *sentry.EventID {
+	event := NewEvent(err, opts...)
+	if event == nil {
+		return nil
+	}
+	if hub == nil {
+		hub = sentry.CurrentHub()
+	}
+	return hub.CaptureEvent(event)
+}
+
+// Hook returns an errors.Hook that captures reported errors through hub, or
+// the hub attached to the reporting context (falling back to the current
+// hub) if hub is nil. Filtering is usually configured at registration.
+//
+// Example:
+//
+//	errors.RegisterHook(sentryerrors.Hook(nil), errors.HookFilter{
+//	    MinSeverity: errors.SeverityError,
+//	})
+func Hook(hub *sentry.Hub, opts ...Option) errors.Hook {
+	return func(ctx context.Context, err error) {
+		target := hub
+		if target == nil && ctx != nil {
+			target = sentry.GetHubFromContext(ctx)
+		}
+		CaptureError(target, err, opts...)
+	}
+}
+
+func level(s errors.Severity) sentry.Level {
+	switch s {
+	case errors.SeverityInfo:
+		return sentry.LevelInfo
+	case errors.SeverityWarning:
+		return sentry.LevelWarning
+	case errors.SeverityCritical:
+		return sentry.LevelFatal
+	default:
+		return sentry.LevelError
+	}
+}
@@ -0,0 +1,144 @@
+package sentryerrors
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/getsentry/sentry-go"
+
+	errors "github.com/JohnPlummer/jp-go-errors"
+	"github.com/JohnPlummer/jp-go-errors/errtest"
+)
+
+// TestNewEvent tests building events from typed errors
+func TestNewEvent(t *testing.T) {
+	err := errtest.Fixture().Processing().
+		WithComponent("billing").
+		WithCause(errors.New("card declined")).
+		Build()
+
+	tests := []struct {
+		name string
+		opts []Option
+		want string
+	}{
+		{name: "default groups by fingerprint", want: errors.Fingerprint(err)},
+		{name: "group by origin key", opts: []Option{WithGrouping(GroupByOriginKey)}, want: errors.OriginKey(err)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			event := NewEvent(err, tt.opts...)
+			if len(event.Fingerprint) != 1 || event.Fingerprint[0] != tt.want {
+				t.Errorf("Fingerprint = %v, want [%s]", event.Fingerprint, tt.want)
+			}
+			if event.Level != sentry.LevelError {
+				t.Errorf("Level = %v, want error", event.Level)
+			}
+			if event.Message != errors.Summarize(err) {
+				t.Errorf("Message = %q, want the summary %q", event.Message, errors.Summarize(err))
+			}
+			if event.Tags["component"] != "billing" || event.Tags[errors.LabelErrorClass] != string(errors.ClassUnknown) {
+				t.Errorf("unexpected tags: %v", event.Tags)
+			}
+			if event.Contexts["error"]["type"] != "ProcessingError" {
+				t.Errorf("unexpected error context: %v", event.Contexts["error"])
+			}
+		})
+	}
+
+	t.Run("upstream request ID tag", func(t *testing.T) {
+		event := NewEvent(errors.Wrap(errors.NewHTTPError(502, "Bad Gateway", nil,
+			errors.WithUpstreamRequestID("req-123")), "charging card"))
+		if event.Tags["upstream_request_id"] != "req-123" {
+			t.Errorf("tags = %v, want upstream_request_id req-123", event.Tags)
+		}
+	})
+
+	t.Run("team tag", func(t *testing.T) {
+		event := NewEvent(errors.NewProcessingError("Ledger out of balance", "Reconcile",
+			errors.WithOwner("payments")))
+		if event.Tags["team"] != "payments" {
+			t.Errorf("tags = %v, want team payments", event.Tags)
+		}
+	})
+
+	t.Run("validation errors are warnings", func(t *testing.T) {
+		event := NewEvent(errtest.Fixture().Validation("email").Build())
+		if event.Level != sentry.LevelWarning {
+			t.Errorf("Level = %v, want warning", event.Level)
+		}
+	})
+
+	t.Run("nil error", func(t *testing.T) {
+		if NewEvent(nil) != nil {
+			t.Error("NewEvent(nil) should return nil")
+		}
+		if CaptureError(nil, nil) != nil {
+			t.Error("CaptureError(nil) should not send anything")
+		}
+	})
+}
+
+// TestNewEventParams tests that request parameters land in their own context without sensitive keys
+func TestNewEventParams(t *testing.T) {
+	err := errtest.Fixture().Validation("limit").
+		With(errors.WithParams(map[string]any{"limit": 500, "password": "hunter2"})).
+		Build()
+
+	event := NewEvent(err)
+	params := event.Contexts[errors.MetadataParams]
+	if params["limit"] == nil {
+		t.Errorf("params context = %v, want limit", params)
+	}
+	data, _ := json.Marshal(event)
+	if strings.Contains(string(data), "hunter2") {
+		t.Errorf("event leaks the password: %s", data)
+	}
+}
+
+// TestNewEventEveryType tests that every error type becomes an event tagged with its type
+func TestNewEventEveryType(t *testing.T) {
+	for name, err := range errtest.Examples() {
+		t.Run(name, func(t *testing.T) {
+			event := NewEvent(err)
+			if event == nil {
+				t.Fatal("NewEvent() = nil")
+			}
+			if got := event.Contexts["error"]["type"]; got != name {
+				t.Errorf("error context type = %v, want %s", got, name)
+			}
+		})
+	}
+}
+
+// TestFilterAndHook tests that filtered errors are never sent
+func TestFilterAndHook(t *testing.T) {
+	var sent []*sentry.Event
+	client, err := sentry.NewClient(sentry.ClientOptions{
+		BeforeSend: func(event *sentry.Event, _ *sentry.EventHint) *sentry.Event {
+			sent = append(sent, event)
+			return nil
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	hub := sentry.NewHub(client, sentry.NewScope())
+
+	filter := errors.HookFilter{MinSeverity: errors.SeverityError}
+	if NewEvent(errors.NewValidationError("Invalid email", "email"), WithFilter(filter)) != nil {
+		t.Error("filtered error should not produce an event")
+	}
+
+	defer errors.ResetHooks()
+	errors.RegisterHook(Hook(hub), filter)
+	errors.Report(context.Background(), errors.NewValidationError("Invalid email", "email"))
+	errors.Report(context.Background(), errors.NewProcessingError("failed", "Charge"))
+
+	if len(sent) != 1 || sent[0].Contexts["error"]["type"] != "ProcessingError" {
+		t.Errorf("unexpected events sent: %d", len(sent))
+	}
+}
@@ -0,0 +1,99 @@
+package errors
+
+// Severity indicates how urgently an error needs attention.
+// The zero value means no severity has been determined.
+type Severity int
+
+const (
+	// SeverityInfo is for expected failures that need no action,
+	// such as a caller disconnecting mid-request.
+	SeverityInfo Severity = iota + 1
+
+	// SeverityWarning is for failures caused by the caller or by
+	// abandoned operations, such as validation errors.
+	SeverityWarning
+
+	// SeverityError is for failures that indicate something is wrong.
+	SeverityError
+
+	// SeverityCritical is for failures that need immediate attention.
+	SeverityCritical
+)
+
+// String returns the lowercase name of the severity.
+func (s Severity) String() string {
+	switch s {
+	case SeverityInfo:
+		return "info"
+	case SeverityWarning:
+		return "warning"
+	case SeverityError:
+		return "error"
+	case SeverityCritical:
+		return "critical"
+	}
+	return "unknown"
+}
+
+// GetSeverity returns the severity for err: SeverityWarning for stale
+// values served after a failure (see ServedStale), else the one set with
+// WithSeverity on the outermost typed error that has one, else a default.
+// Returns zero for a nil error.
+//
+// Defaults:
+//   - Recovered panics (see Recover) - SeverityCritical
+//   - Caller disconnects (see IsCallerDisconnect) - SeverityInfo
+//   - Other context errors and validation errors - SeverityWarning
+//   - Outbound serialization errors (see NewEncodeError) - SeverityCritical
+//   - Everything else - SeverityError
+func GetSeverity(err error) Severity {
+	if isServedStale(err) {
+		return SeverityWarning
+	}
+	if severity := severityOverride(err); severity != 0 {
+		return severity
+	}
+	switch {
+	case err == nil:
+		return 0
+	case isPanic(err):
+		return SeverityCritical
+	case IsCallerDisconnect(err):
+		return SeverityInfo
+	case IsContextError(err), IsValidation(err):
+		return SeverityWarning
+	case serializationDirection(err) == DirectionOutbound:
+		return SeverityCritical
+	default:
+		return SeverityError
+	}
+}
+
+// WithSeverity overrides the default severity GetSeverity reports for the
+// error, such as marking an error that should page someone as
+// SeverityCritical. Applies to all typed errors.
+//
+// Example:
+//
+//	err := NewHTTPError(500, "Ledger out of balance", cause,
+//	    WithSeverity(SeverityCritical))
+func WithSeverity(severity Severity) Option {
+	return func(err any) {
+		if state := stateField(err); state != nil {
+			state.severity = severity
+		}
+	}
+}
+
+// severityOverride returns the severity set with WithSeverity on the
+// outermost typed error that has one, or 0.
+func severityOverride(err error) Severity {
+	var severity Severity
+	walkChain(err, func(node error, _ int) bool {
+		if state := stateField(node); state != nil {
+			severity = state.severity
+		}
+		return severity == 0
+	})
+	return severity
+}
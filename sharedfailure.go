@@ -0,0 +1,148 @@
+package errors
+
+import (
+	"sync"
+	"time"
+)
+
+// sharedFailureError marks an error a caller received from another
+// caller's execution in a SharedFailure, or from a held failure, rather
+// than from running fn itself. It is transparent: message, errors.Is,
+// errors.As and classification are those of the shared error.
+type sharedFailureError struct {
+	err error
+}
+
+func (e *sharedFailureError) Error() string {
+	return e.err.Error()
+}
+
+func (e *sharedFailureError) Unwrap() error {
+	return e.err
+}
+
+// SharedFailure collapses concurrent calls for the same key into one
+// execution, in the manner of singleflight, so 200 goroutines hitting the
+// same dead dependency build one error with one stack capture instead of
+// 200. With WithHoldFailure, a failure is also held for a short time so
+// calls arriving just after it short-circuit too. Successes are never held.
+// Safe for concurrent use.
+type SharedFailure struct {
+	holdFor time.Duration
+	clock   Clock
+
+	mu    sync.Mutex
+	calls map[string]*sharedCall
+	held  map[string]heldFailure
+}
+
+type sharedCall struct {
+	done chan struct{}
+	err  error
+}
+
+type heldFailure struct {
+	err   error
+	until time.Time
+}
+
+// NewSharedFailure creates a SharedFailure. Supports the WithHoldFailure
+// and WithClock options.
+//
+// Example:
+//
+//	var inventory = errors.NewSharedFailure(errors.WithHoldFailure(100 * time.Millisecond))
+//
+//	err := inventory.Do(sku, func() error {
+//	    return client.Reserve(ctx, sku)
+//	})
+//	if err != nil && !errors.IsSharedFailure(err) {
+//	    errors.LogError(ctx, logger, "reserving stock", err) // logged once per execution
+//	}
+func NewSharedFailure(opts ...Option) *SharedFailure {
+	s := &SharedFailure{
+		clock: packageClock{},
+		calls: make(map[string]*sharedCall),
+		held:  make(map[string]heldFailure),
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
+}
+
+// Do runs fn unless a call for key is already running, in which case it
+// waits for that call and returns its result, or unless a failure for key
+// is held, which it returns without running fn. The caller that ran fn gets
+// its error unchanged; every other caller gets the same error wrapped in
+// its own marker (see IsSharedFailure), so MarkHandled and logging apply
+// per caller. A panic in fn is recovered into a PanicError returned to all
+// waiting callers.
+func (s *SharedFailure) Do(key string, fn func() error) error {
+	s.mu.Lock()
+	if held, ok := s.held[key]; ok {
+		if s.clock.Now().Before(held.until) {
+			s.mu.Unlock()
+			return &sharedFailureError{err: held.err}
+		}
+		delete(s.held, key)
+	}
+	if call, ok := s.calls[key]; ok {
+		s.mu.Unlock()
+		<-call.done
+		if call.err == nil {
+			return nil
+		}
+		return &sharedFailureError{err: call.err}
+	}
+	call := &sharedCall{done: make(chan struct{})}
+	s.calls[key] = call
+	s.mu.Unlock()
+
+	defer func() {
+		s.mu.Lock()
+		delete(s.calls, key)
+		if call.err != nil && s.holdFor > 0 {
+			s.pruneHeld()
+			s.held[key] = heldFailure{err: call.err, until: s.clock.Now().Add(s.holdFor)}
+		}
+		s.mu.Unlock()
+		close(call.done)
+	}()
+	func() {
+		defer func() {
+			if r := recover(); r != nil {
+				call.err = NewPanicError(r)
+			}
+		}()
+		call.err = fn()
+	}()
+	return call.err
+}
+
+// Forget drops any failure held for key, so the next Do runs fn.
+func (s *SharedFailure) Forget(key string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.held, key)
+}
+
+// pruneHeld drops expired held failures, so keys that stop failing don't
+// accumulate. Must be called with s.mu held.
+func (s *SharedFailure) pruneHeld() {
+	now := s.clock.Now()
+	for key, held := range s.held {
+		if !now.Before(held.until) {
+			delete(s.held, key)
+		}
+	}
+}
+
+// IsSharedFailure reports whether err came from another caller's execution
+// in a SharedFailure, or from a held failure, rather than from the
+// caller's own call, such as to log a failure once per execution instead of
+// once per caller.
+func IsSharedFailure(err error) bool {
+	var shared *sharedFailureError
+	return As(err, &shared)
+}
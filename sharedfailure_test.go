@@ -0,0 +1,113 @@
+package errors
+
+import (
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// TestSharedFailureConcurrent tests that concurrent callers share one execution and its error
+func TestSharedFailureConcurrent(t *testing.T) {
+	const callers = 200
+	clock := newFakeClock()
+	shared := NewSharedFailure(WithHoldFailure(time.Second), WithClock(clock))
+
+	var executions atomic.Int32
+	release := make(chan struct{})
+	fn := func() error {
+		executions.Add(1)
+		<-release
+		return NewNetworkError("connection refused", "Reserve")
+	}
+
+	errs := make([]error, callers)
+	var started, finished sync.WaitGroup
+	for i := range errs {
+		started.Add(1)
+		finished.Add(1)
+		go func() {
+			defer finished.Done()
+			started.Done()
+			errs[i] = shared.Do("inventory", fn)
+		}()
+	}
+	started.Wait()
+	close(release)
+	finished.Wait()
+
+	if got := executions.Load(); got != 1 {
+		t.Errorf("fn ran %d times, want once with the failure held", got)
+	}
+	var owner error
+	sharedCount := 0
+	for _, err := range errs {
+		if !IsNetworkError(err) || !IsRetryable(err) {
+			t.Fatalf("Do() = %v, want the shared NetworkError", err)
+		}
+		if IsSharedFailure(err) {
+			sharedCount++
+			continue
+		}
+		owner = err
+	}
+	if owner == nil || sharedCount != callers-1 {
+		t.Fatalf("%d callers got a shared failure, want all but the one that ran fn", sharedCount)
+	}
+	for _, err := range errs {
+		if IsSharedFailure(err) && (err == owner || Unwrap(err) != owner) {
+			t.Fatal("followers should get their own marker around the same error value")
+		}
+	}
+
+	handled := MarkHandled(errs[0])
+	if !IsHandled(handled) || IsHandled(errs[1]) {
+		t.Error("MarkHandled should apply to one caller's error only")
+	}
+}
+
+// TestSharedFailureHold tests the negative TTL, success passthrough and panics
+func TestSharedFailureHold(t *testing.T) {
+	clock := newFakeClock()
+	shared := NewSharedFailure(WithHoldFailure(100*time.Millisecond), WithClock(clock))
+	calls := 0
+	failing := func() error { calls++; return NewTimeoutError("too slow", "Quote", time.Second) }
+
+	first := shared.Do("quote", failing)
+	clock.Advance(99 * time.Millisecond)
+	second := shared.Do("quote", failing)
+	if calls != 1 || IsSharedFailure(first) || !IsSharedFailure(second) || Unwrap(second) != first {
+		t.Errorf("calls = %d, want the held failure returned within the hold", calls)
+	}
+	clock.Advance(time.Millisecond)
+	if shared.Do("quote", failing); calls != 2 {
+		t.Errorf("calls = %d, want fn to run again once the hold expires", calls)
+	}
+	shared.Forget("quote")
+	if shared.Do("quote", failing); calls != 3 {
+		t.Errorf("calls = %d, want fn to run again after Forget", calls)
+	}
+
+	succeeding := func() error { calls++; return nil }
+	if shared.Do("ok", succeeding) != nil || shared.Do("ok", succeeding) != nil || calls != 5 {
+		t.Error("successes should not be held")
+	}
+
+	err := NewSharedFailure().Do("boom", func() error { panic("index out of range") })
+	if _, ok := IsPanic(err); !ok {
+		t.Errorf("Do() = %v, want the panic as a PanicError", err)
+	}
+}
+
+// TestSharedFailureAllocs tests that short-circuited calls allocate less than constructing the failure
+func TestSharedFailureAllocs(t *testing.T) {
+	shared := NewSharedFailure(WithHoldFailure(time.Hour))
+	newFailure := func() error { return NewNetworkError("connection refused", "Reserve") }
+	shared.Do("inventory", newFailure)
+
+	sharedAllocs := testing.AllocsPerRun(100, func() { fastSink = shared.Do("inventory", newFailure) })
+	naiveAllocs := testing.AllocsPerRun(100, func() { fastSink = newFailure() })
+	if sharedAllocs > 1 || sharedAllocs >= naiveAllocs {
+		t.Errorf("held failure allocated %.1f times per call, naive %.1f; want at most 1 and fewer", sharedAllocs, naiveAllocs)
+	}
+}
@@ -0,0 +1,101 @@
+package errors
+
+import (
+	"context"
+	"log/slog"
+	"sort"
+)
+
+// LogValue returns err's structured information (see ExtractErrorInfo) as a
+// slog group value. Returns an empty value for a nil error.
+//
+// Example:
+//
+//	logger.Error("sync failed", slog.Any("error", errors.LogValue(err)))
+func LogValue(err error) slog.Value {
+	return infoValue(ExtractErrorInfo(err))
+}
+
+// LogError logs err at a level derived from its severity, escalated when an
+// Escalator is installed (see SetEscalator), with its
+// structured information under an "error" key and any warnings collected on
+// ctx (see CollectWarnings) under a "warnings" key. With a nil err, only
+// collected warnings are logged, at warn level; nothing is logged if there
+// are none. An empty msg is replaced by Summarize(err). An err marked with
+// MarkHandled is treated as nil, since it was logged where it was handled,
+// unless EnableHandledLogging was called.
+//
+// Example:
+//
+//	errors.LogError(ctx, logger, "import finished", err)
+func LogError(ctx context.Context, logger *slog.Logger, msg string, err error) {
+	if err != nil && !logHandled.Load() && IsHandled(err) {
+		err = nil
+	}
+	warnings := WarningsFromContext(ctx)
+	if err == nil && len(warnings) == 0 {
+		return
+	}
+
+	level := slog.LevelWarn
+	var attrs []slog.Attr
+	if err != nil {
+		level = severityLevel(observeSeverity(ctx, err))
+		attrs = append(attrs, slog.Any("error", LogValue(err)))
+	}
+	if len(warnings) > 0 {
+		attrs = append(attrs, slog.Any("warnings", warnings.Info()))
+	}
+
+	if msg == "" {
+		msg = Summarize(err)
+	}
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	logger.LogAttrs(ctx, level, msg, attrs...)
+}
+
+// LogHook returns a Hook that logs each reported error with logger, in the
+// same shape as LogError; an empty msg logs each error under its summary.
+// Register it with a HookFilter to log only the errors that matter.
+//
+// Example:
+//
+//	errors.RegisterHook(errors.LogHook(logger, "request failed"),
+//	    errors.HookFilter{MinSeverity: errors.SeverityWarning})
+func LogHook(logger *slog.Logger, msg string) Hook {
+	return func(ctx context.Context, err error) {
+		LogError(ctx, logger, msg, err)
+	}
+}
+
+// severityLevel maps a Severity to the closest slog level.
+func severityLevel(s Severity) slog.Level {
+	switch s {
+	case SeverityInfo:
+		return slog.LevelInfo
+	case SeverityWarning:
+		return slog.LevelWarn
+	case SeverityCritical:
+		return slog.LevelError + 4
+	default:
+		return slog.LevelError
+	}
+}
+
+// infoValue renders an ExtractErrorInfo map as a slog group with keys in a
+// stable order.
+func infoValue(info map[string]any) slog.Value {
+	keys := make([]string, 0, len(info))
+	for k := range info {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	attrs := make([]slog.Attr, 0, len(keys))
+	for _, k := range keys {
+		attrs = append(attrs, slog.Any(k, info[k]))
+	}
+	return slog.GroupValue(attrs...)
+}
@@ -0,0 +1,246 @@
+package errors
+
+import (
+	"context"
+	"runtime"
+	"strconv"
+	"sync/atomic"
+
+	"github.com/cockroachdb/errors"
+)
+
+// Span attribute keys returned by SpanAttributes.
+const (
+	AttrErrorType      = "error.type"
+	AttrErrorClass     = "error.class"
+	AttrErrorRetryable = "error.retryable"
+	AttrErrorCode      = "error.code"
+)
+
+// Span attribute keys set by SpanRecordFor in addition to AttrErrorType and
+// AttrErrorRetryable. They are part of the contract with dashboards, so
+// they don't change between releases.
+const (
+	AttrHTTPStatusCode  = "http.status_code"
+	AttrRetryAfterMS    = "rate_limit.retry_after_ms"
+	AttrCircuitState    = "circuit.state"
+	AttrValidationField = "validation.field"
+)
+
+// Names of the event SpanRecordFor describes for the frame that created the
+// error, and of its attributes, following the OpenTelemetry code.*
+// conventions.
+const (
+	SpanEventErrorOrigin = "error.origin"
+	AttrCodeFunction     = "code.function"
+	AttrCodeFilepath     = "code.filepath"
+	AttrCodeLineno       = "code.lineno"
+)
+
+// SpanStatus is a span status. Its values equal those of OpenTelemetry's
+// codes.Code, so adapters convert with codes.Code(status).
+type SpanStatus uint32
+
+// Span statuses.
+const (
+	SpanStatusUnset SpanStatus = 0
+	SpanStatusError SpanStatus = 1
+	SpanStatusOK    SpanStatus = 2
+)
+
+// SpanRecord is what a tracing adapter puts on a span for an error: see
+// SpanRecordFor.
+type SpanRecord struct {
+	Status      SpanStatus
+	Description string         // status description; empty unless Status is SpanStatusError
+	Attributes  map[string]any // values are string, bool, int or int64
+	Origin      runtime.Frame  // frame that created the error; zero without a stack trace
+}
+
+// SpanRecordFor describes err for a tracing span, so adapters record its
+// structure instead of only its message.
+//   - A nil error has status OK.
+//   - An error caused by context.Canceled leaves the status unset, since the
+//     caller gave up rather than the operation failing.
+//   - Any other error has status Error and its message as description.
+//
+// Attributes come from ExtractErrorInfo: AttrErrorType and
+// AttrErrorRetryable always, and AttrHTTPStatusCode, AttrRetryAfterMS,
+// AttrCircuitState and AttrValidationField when the error has them. Origin
+// is the top frame of the stack recorded closest to the failure (see
+// GetOriginStackTrace), which adapters add as a SpanEventErrorOrigin event.
+//
+// Example (OpenTelemetry adapter):
+//
+//	func RecordError(span trace.Span, err error) {
+//	    rec := errors.SpanRecordFor(err)
+//	    if err != nil {
+//	        span.RecordError(err, trace.WithAttributes(toOTel(rec.Attributes)...))
+//	    }
+//	    if rec.Status != errors.SpanStatusUnset {
+//	        span.SetStatus(codes.Code(rec.Status), rec.Description)
+//	    }
+//	}
+func SpanRecordFor(err error) SpanRecord {
+	if err == nil {
+		return SpanRecord{Status: SpanStatusOK}
+	}
+
+	rec := SpanRecord{Status: SpanStatusError, Description: err.Error()}
+	if errors.Is(err, context.Canceled) {
+		rec = SpanRecord{Status: SpanStatusUnset}
+	}
+
+	info := ExtractErrorInfo(err)
+	rec.Attributes = map[string]any{
+		AttrErrorType:      signatureType(err),
+		AttrErrorRetryable: info["retryable"],
+	}
+	if status, ok := info["status_code"].(int); ok && status != 0 {
+		rec.Attributes[AttrHTTPStatusCode] = status
+	}
+	if rateLimit, ok := outermostTyped(err).(*RateLimitError); ok {
+		rec.Attributes[AttrRetryAfterMS] = rateLimit.RetryAfter.Milliseconds()
+	}
+	if state, ok := info["state"].(string); ok && info["type"] == "CircuitBreakerError" {
+		rec.Attributes[AttrCircuitState] = state
+	}
+	if field, ok := info["field"].(string); ok && field != "" {
+		rec.Attributes[AttrValidationField] = field
+	}
+	rec.Origin = originFrame(err)
+	return rec
+}
+
+// originFrame returns the top frame of err's origin stack.
+func originFrame(err error) runtime.Frame {
+	origin := originStack(err)
+	if origin == nil {
+		return runtime.Frame{}
+	}
+	trace := origin.StackTrace()
+	frame, _ := runtime.CallersFrames([]uintptr{uintptr(trace[0])}).Next()
+	return frame
+}
+
+// SpanRecorder records err as an event on the span carried by ctx, with
+// attrs from SpanAttributes. Tracing adapters provide one; it must be fast
+// and must not block, as it runs on the error path.
+type SpanRecorder func(ctx context.Context, err error, attrs map[string]string)
+
+var (
+	spanRecorder  atomic.Pointer[SpanRecorder]
+	spanRecording atomic.Bool
+)
+
+// RegisterSpanRecorder installs the recorder used once span recording is
+// enabled. Tracing adapters call it when they are linked in; without one,
+// EnableSpanRecording has no effect, so this package needs no tracing
+// dependency.
+func RegisterSpanRecorder(recorder SpanRecorder) {
+	if recorder == nil {
+		spanRecorder.Store(nil)
+		return
+	}
+	spanRecorder.Store(&recorder)
+}
+
+// EnableSpanRecording makes the ctx-aware constructors (NewHTTPErrorCtx,
+// WrapCtx and any constructor given WithContext) record the new error on
+// the span in their context, so spans don't show OK while logs show
+// failures. Each error is recorded once: wrapping an error that was already
+// recorded, even with a different context, records nothing.
+//
+// Example:
+//
+//	func main() {
+//	    errors.RegisterSpanRecorder(recordOnOTelSpan)
+//	    errors.EnableSpanRecording()
+//	    ...
+//	}
+func EnableSpanRecording() {
+	spanRecording.Store(true)
+}
+
+// DisableSpanRecording turns span recording off again.
+func DisableSpanRecording() {
+	spanRecording.Store(false)
+}
+
+// ResetSpanRecording disables span recording and removes the recorder.
+// Intended for tests.
+func ResetSpanRecording() {
+	DisableSpanRecording()
+	RegisterSpanRecorder(nil)
+}
+
+// SpanAttributes returns the standard attributes describing err on a span:
+// its type, class, retryability and, when set, its code. Values are
+// low-cardinality, as with MetricLabels. Returns nil for a nil error.
+//
+// Example:
+//
+//	attrs := SpanAttributes(NewHTTPError(503, "Unavailable", nil))
+//	// attrs = map[string]string{
+//	//     "error.type":      "HTTPError",
+//	//     "error.class":     "transient",
+//	//     "error.retryable": "true",
+//	// }
+func SpanAttributes(err error) map[string]string {
+	if err == nil {
+		return nil
+	}
+
+	attrs := map[string]string{
+		AttrErrorType:      signatureType(err),
+		AttrErrorClass:     string(Classify(err)),
+		AttrErrorRetryable: strconv.FormatBool(IsRetryable(err)),
+	}
+	if code := GetCode(err); code != "" {
+		attrs[AttrErrorCode] = code
+	}
+	return attrs
+}
+
+// spanRecordingActive reports whether new errors should be recorded.
+func spanRecordingActive() bool {
+	return spanRecording.Load() && spanRecorder.Load() != nil
+}
+
+// recordOnSpan records err on the span in ctx unless its chain was already
+// recorded. Reports whether it recorded; the caller marks err.
+func recordOnSpan(ctx context.Context, err error) bool {
+	recorder := spanRecorder.Load()
+	if ctx == nil || recorder == nil || !spanRecording.Load() || spanRecordedIn(err) {
+		return false
+	}
+	(*recorder)(ctx, err, SpanAttributes(err))
+	return true
+}
+
+// recordTypedOnSpan records a typed error on the span in ctx and marks it.
+func recordTypedOnSpan(ctx context.Context, err error) {
+	if recordOnSpan(ctx, err) {
+		if state := stateField(err); state != nil {
+			state.spanRecorded = true
+		}
+	}
+}
+
+// spanRecordedIn reports whether any error in err's chain has been
+// recorded on a span.
+func spanRecordedIn(err error) bool {
+	recorded := false
+	walkChain(err, func(node error, _ int) bool {
+		switch e := node.(type) {
+		case *metadataError:
+			recorded = e.spanRecorded
+		default:
+			if state := stateField(node); state != nil {
+				recorded = state.spanRecorded
+			}
+		}
+		return !recorded
+	})
+	return recorded
+}
@@ -0,0 +1,211 @@
+package errors
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+// spanEvent is an error recorded by the test span recorder
+type spanEvent struct {
+	span  any
+	err   error
+	attrs map[string]string
+}
+
+type spanKey struct{}
+
+// recordSpans enables span recording into the returned slice for the duration of the test
+func recordSpans(t *testing.T) *[]spanEvent {
+	t.Helper()
+	t.Cleanup(ResetSpanRecording)
+
+	var events []spanEvent
+	RegisterSpanRecorder(func(ctx context.Context, err error, attrs map[string]string) {
+		events = append(events, spanEvent{span: ctx.Value(spanKey{}), err: err, attrs: attrs})
+	})
+	EnableSpanRecording()
+	return &events
+}
+
+// TestSpanRecording tests that ctx-aware constructors record each error once
+func TestSpanRecording(t *testing.T) {
+	ctx := context.WithValue(context.Background(), spanKey{}, "span-1")
+	other := context.WithValue(context.Background(), spanKey{}, "span-2")
+	cause := fmt.Errorf("connection reset")
+
+	tests := []struct {
+		name     string
+		build    func() error
+		wantSpan any
+	}{
+		{
+			name:     "NewHTTPErrorCtx",
+			build:    func() error { return NewHTTPErrorCtx(ctx, 503, "Unavailable", cause) },
+			wantSpan: "span-1",
+		},
+		{
+			name:     "WrapCtx",
+			build:    func() error { return WrapCtx(ctx, cause, "calling billing") },
+			wantSpan: "span-1",
+		},
+		{
+			name: "WithContext records the finished error",
+			build: func() error {
+				return NewProcessingError("Failed to charge", "Charge", WithContext(ctx), WithCode("BILLING_DOWN"))
+			},
+			wantSpan: "span-1",
+		},
+		{
+			name:     "wrapping a recorded error with another context",
+			build:    func() error { return WrapCtx(other, NewHTTPErrorCtx(ctx, 503, "Unavailable", nil), "outer") },
+			wantSpan: "span-1",
+		},
+		{
+			name: "typed wrapper of a recorded error",
+			build: func() error {
+				return NewHTTPErrorCtx(other, 502, "Bad Gateway", WrapCtx(ctx, cause, "inner"))
+			},
+			wantSpan: "span-1",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			events := recordSpans(t)
+			err := tt.build()
+
+			if len(*events) != 1 {
+				t.Fatalf("recorded %d events, want 1", len(*events))
+			}
+			if got := (*events)[0]; got.span != tt.wantSpan {
+				t.Errorf("recorded on %v, want %v", got.span, tt.wantSpan)
+			}
+			if !spanRecordedIn(err) {
+				t.Error("returned error should be marked as recorded")
+			}
+		})
+	}
+
+	t.Run("attributes describe the finished error", func(t *testing.T) {
+		events := recordSpans(t)
+		NewProcessingError("Failed to charge", "Charge", WithContext(ctx), WithCode("BILLING_DOWN"), WithRetryable(true))
+
+		want := map[string]string{
+			AttrErrorType: "ProcessingError", AttrErrorClass: "transient", AttrErrorRetryable: "true", AttrErrorCode: "BILLING_DOWN",
+		}
+		for k, v := range want {
+			if got := (*events)[0].attrs[k]; got != v {
+				t.Errorf("%s = %q, want %q", k, got, v)
+			}
+		}
+	})
+
+	t.Run("disabled", func(t *testing.T) {
+		events := recordSpans(t)
+		DisableSpanRecording()
+		NewHTTPErrorCtx(ctx, 503, "Unavailable", nil)
+		if len(*events) != 0 {
+			t.Errorf("recorded %d events while disabled", len(*events))
+		}
+	})
+
+	t.Run("no recorder is a no-op", func(t *testing.T) {
+		t.Cleanup(ResetSpanRecording)
+		EnableSpanRecording()
+		if err := WrapCtx(ctx, cause, "calling billing"); spanRecordedIn(err) {
+			t.Error("error marked as recorded without a recorder")
+		}
+	})
+
+	t.Run("decoded errors can be recorded again", func(t *testing.T) {
+		events := recordSpans(t)
+		decoded := Decode(Encode(WrapCtx(ctx, NewHTTPErrorCtx(ctx, 503, "Unavailable", nil), "outer")))
+		if spanRecordedIn(decoded) {
+			t.Error("recorded marker should not cross the envelope")
+		}
+		WrapCtx(other, decoded, "receiver")
+		if len(*events) != 2 {
+			t.Errorf("recorded %d events, want 2", len(*events))
+		}
+	})
+}
+
+// TestSpanRecordFor tests the status and attributes recorded for each kind of error
+func TestSpanRecordFor(t *testing.T) {
+	tests := []struct {
+		name       string
+		err        error
+		wantStatus SpanStatus
+		wantAttrs  map[string]any
+	}{
+		{name: "nil", err: nil, wantStatus: SpanStatusOK},
+		{
+			name: "http", err: Wrap(NewHTTPError(503, "Unavailable", nil), "calling billing"), wantStatus: SpanStatusError,
+			wantAttrs: map[string]any{AttrErrorType: "HTTPError", AttrErrorRetryable: true, AttrHTTPStatusCode: 503},
+		},
+		{
+			name: "rate limit", err: NewRateLimitError("slow down", "Quote", 1500*time.Millisecond), wantStatus: SpanStatusError,
+			wantAttrs: map[string]any{AttrErrorType: "RateLimitError", AttrErrorRetryable: true, AttrRetryAfterMS: int64(1500)},
+		},
+		{
+			name: "circuit", err: NewCircuitBreakerError("tripped", "Charge", "open"), wantStatus: SpanStatusError,
+			wantAttrs: map[string]any{AttrErrorType: "CircuitBreakerError", AttrErrorRetryable: false, AttrCircuitState: "open"},
+		},
+		{
+			name: "validation", err: NewValidationError("bad", "email"), wantStatus: SpanStatusError,
+			wantAttrs: map[string]any{AttrErrorType: "ValidationError", AttrErrorRetryable: false, AttrValidationField: "email"},
+		},
+		{
+			name: "untyped", err: fmt.Errorf("plain"), wantStatus: SpanStatusError,
+			wantAttrs: map[string]any{AttrErrorType: "Error", AttrErrorRetryable: false},
+		},
+		{
+			name: "canceled", err: Wrap(context.Canceled, "client went away"), wantStatus: SpanStatusUnset,
+			wantAttrs: map[string]any{AttrErrorType: "Error", AttrErrorRetryable: false},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := SpanRecordFor(tt.err)
+			if rec.Status != tt.wantStatus {
+				t.Errorf("Status = %d, want %d", rec.Status, tt.wantStatus)
+			}
+			if (rec.Description != "") != (tt.wantStatus == SpanStatusError) {
+				t.Errorf("Description = %q with status %d", rec.Description, rec.Status)
+			}
+			if !reflect.DeepEqual(rec.Attributes, tt.wantAttrs) {
+				t.Errorf("Attributes = %v, want %v", rec.Attributes, tt.wantAttrs)
+			}
+		})
+	}
+
+	if got := SpanRecordFor(NewNetworkError("down", "Dial")).Origin; !strings.HasSuffix(got.Function, "TestSpanRecordFor") || got.Line == 0 {
+		t.Errorf("Origin = %+v, want the frame that created the error", got)
+	}
+}
+
+// TestSpanAttributeNames tests that the attribute names dashboards depend on don't change
+func TestSpanAttributeNames(t *testing.T) {
+	names := map[string]string{
+		AttrErrorType:        "error.type",
+		AttrErrorRetryable:   "error.retryable",
+		AttrHTTPStatusCode:   "http.status_code",
+		AttrRetryAfterMS:     "rate_limit.retry_after_ms",
+		AttrCircuitState:     "circuit.state",
+		AttrValidationField:  "validation.field",
+		SpanEventErrorOrigin: "error.origin",
+		AttrCodeFunction:     "code.function",
+		AttrCodeFilepath:     "code.filepath",
+		AttrCodeLineno:       "code.lineno",
+	}
+	for got, want := range names {
+		if got != want {
+			t.Errorf("attribute name %q, want %q", got, want)
+		}
+	}
+}
@@ -2,13 +2,21 @@ package errors
 
 import (
 	"fmt"
+	"runtime"
+	"sort"
 	"strings"
+	"time"
 
 	"github.com/cockroachdb/errors"
+	"github.com/cockroachdb/errors/errbase"
 )
 
+// maxStackFrames caps the stack recorded when a typed error is created.
+const maxStackFrames = 32
+
 // GetStackTrace returns a formatted stack trace for the error.
-// Returns empty string if the error has no stack trace.
+// Returns empty string if the error has no stack trace. Typed errors carry
+// the stack of the code that created them (see captureStack).
 //
 // Example output:
 //
@@ -24,9 +32,97 @@ func GetStackTrace(err error) string {
 	}
 
 	// Use cockroachdb/errors' stack trace formatting
+	return formatVerbose(err)
+}
+
+// formatVerbose formats err with %+v. This package's typed errors don't
+// implement fmt.Formatter, so they are formatted through cockroachdb/errors
+// to include their stacks and their causes'.
+func formatVerbose(err error) string {
+	if stateField(err) != nil {
+		return fmt.Sprintf("%+v", errbase.Formattable(err))
+	}
 	return fmt.Sprintf("%+v", err)
 }
 
+// captureStack records on a newly created typed error the stack of the
+// code creating it, starting at the first frame outside this package so
+// constructors that delegate to one another don't show up. Nothing is
+// recorded when the error already has a stack, such as a PanicError's, or
+// when its cause carries one: the cause's stack already leads to the
+// creation site and formatting both would repeat it.
+func captureStack(err error) {
+	state := stateField(err)
+	if state == nil || state.stack != nil {
+		return
+	}
+	if cf, ok := err.(chainFormatter); ok && originStack(cf.causeError()) != nil {
+		return
+	}
+	state.stack = callerStack()
+}
+
+// callerStack returns the calling goroutine's stack with this package's own
+// frames trimmed from the top. Frames from this package's tests are kept.
+func callerStack() []uintptr {
+	pcs := make([]uintptr, maxStackFrames)
+	pcs = pcs[:runtime.Callers(2, pcs)]
+	for i := range pcs {
+		if !ownFrame(pcs[i : i+1]) {
+			return pcs[i:]
+		}
+	}
+	return pcs
+}
+
+// ownFrame reports whether every function at pc, including any inlined
+// into it, belongs to this package's non-test code.
+func ownFrame(pc []uintptr) bool {
+	frames := runtime.CallersFrames(pc)
+	for {
+		frame, more := frames.Next()
+		if pkg, _ := splitFunction(frame.Function); pkg != thisPackage || strings.HasSuffix(frame.File, "_test.go") {
+			return false
+		}
+		if !more {
+			return true
+		}
+	}
+}
+
+// stackTrace converts the recorded stack for the stack trace provider
+// interface.
+func (s *errorState) stackTrace() errbase.StackTrace {
+	if len(s.stack) == 0 {
+		return nil
+	}
+	trace := make(errbase.StackTrace, len(s.stack))
+	for i, pc := range s.stack {
+		trace[i] = errbase.StackFrame(pc)
+	}
+	return trace
+}
+
+// GetOriginStackTrace returns the stack trace recorded closest to where the
+// failure originated: the innermost error in the chain that carries its own
+// stack. This is the cause's stack when a wrapper such as an HTTPError was
+// created by shared middleware, rather than the middleware's own stack.
+// Returns empty string if no error in the chain has a stack trace.
+//
+// Example output:
+//
+//	github.com/myorg/myapp/db.(*Client).Query
+//		/path/to/db/client.go:88
+//	github.com/myorg/myapp.loadUser
+//		/path/to/user.go:42
+func GetOriginStackTrace(err error) string {
+	origin := originStack(err)
+	if origin == nil {
+		return ""
+	}
+	return strings.TrimPrefix(fmt.Sprintf("%+v", origin.StackTrace()), "\n")
+}
+
 // GetStackTraceLines returns the stack trace as individual lines.
 // Returns empty slice if the error has no stack trace.
 func GetStackTraceLines(err error) []string {
@@ -68,20 +164,28 @@ func GetSafeDetails(err error) string {
 }
 
 // FormatError returns a formatted error string with type information.
-// Useful for structured logging and debugging.
+// Useful for structured logging and debugging. The type is that of the
+// outermost typed error in the chain, so wrapping doesn't hide it; the
+// message is the outermost one, and names the component (see WithComponent)
+// like every typed error's message does. A typed-nil error (see NotNil) formats as
+// its Error() string, e.g. "<nil HTTPError>".
 //
 // Example output:
 //
 //	HTTPError(500): Internal Server Error: database connection failed
+//	HTTPError(503): calling billing: HTTP 503: down
 func FormatError(err error) string {
 	if err == nil {
 		return ""
 	}
+	if isTypedNil(err) {
+		return err.Error()
+	}
 
 	var parts []string
 
 	// Add type information
-	switch e := err.(type) {
+	switch e := outermostTyped(err).(type) {
 	case *HTTPError:
 		parts = append(parts, fmt.Sprintf("HTTPError(%d)", e.StatusCode))
 	case *ValidationError:
@@ -90,6 +194,8 @@ func FormatError(err error) string {
 		parts = append(parts, fmt.Sprintf("TimeoutError(%v)", e.Duration))
 	case *RateLimitError:
 		parts = append(parts, fmt.Sprintf("RateLimitError(%v)", e.RetryAfter))
+	case *RetryableError:
+		parts = append(parts, fmt.Sprintf("RetryableError(%v)", e.RetryAfter))
 	case *ProcessingError:
 		retryable := "not retryable"
 		if e.IsRetryable() {
@@ -102,8 +208,30 @@ func FormatError(err error) string {
 			transient = "transient"
 		}
 		parts = append(parts, fmt.Sprintf("NetworkError(%s)", transient))
+	case *SerializationError:
+		parts = append(parts, fmt.Sprintf("SerializationError(%s)", e.Format))
 	case *CircuitBreakerError:
 		parts = append(parts, fmt.Sprintf("CircuitBreakerError(%s)", e.State))
+	case *NotImplementedError:
+		parts = append(parts, fmt.Sprintf("NotImplementedError(%s)", e.Feature))
+	case *UnsupportedError:
+		parts = append(parts, fmt.Sprintf("UnsupportedError(%s)", e.What))
+	case *ConsistencyError:
+		parts = append(parts, fmt.Sprintf("ConsistencyError(%s)", e.Resource))
+	case *NotFoundError:
+		parts = append(parts, fmt.Sprintf("NotFoundError(%s)", e.Resource))
+	case *ConflictError:
+		parts = append(parts, fmt.Sprintf("ConflictError(%s)", e.Resource))
+	case *DatabaseError:
+		parts = append(parts, fmt.Sprintf("DatabaseError(%s)", e.SQLState))
+	case *ProviderError:
+		parts = append(parts, fmt.Sprintf("ProviderError(%s)", e.Provider))
+	case *ConfigError:
+		parts = append(parts, fmt.Sprintf("ConfigError(%s)", e.Key))
+	case *PanicError:
+		parts = append(parts, "PanicError")
+	case *RemoteError:
+		parts = append(parts, fmt.Sprintf("RemoteError(%s)", e.Type))
 	default:
 		parts = append(parts, "Error")
 	}
@@ -114,8 +242,51 @@ func FormatError(err error) string {
 	return strings.Join(parts, ": ")
 }
 
+// DebugString returns FormatError(err) followed by the metadata recorded
+// across err's chain, sorted by key and sanitized, for debugging output
+// that Error() deliberately leaves out, such as captured goroutine
+// information (see WithGoroutineInfo).
+//
+// Example output:
+//
+//	ProcessingError(not retryable): Failed to resize [goroutine_id=42 pprof_labels=map[worker:3]]
+func DebugString(err error) string {
+	if err == nil {
+		return ""
+	}
+
+	metadata := sanitizeMap(allMetadata(err))
+	if len(metadata) == 0 {
+		return FormatError(err)
+	}
+
+	keys := make([]string, 0, len(metadata))
+	for k := range metadata {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	pairs := make([]string, len(keys))
+	for i, k := range keys {
+		pairs[i] = fmt.Sprintf("%s=%v", k, metadata[k])
+	}
+	return FormatError(err) + " [" + strings.Join(pairs, " ") + "]"
+}
+
 // ExtractErrorInfo returns structured information about the error.
-// Returns a map with error type, retryability, and extracted fields.
+// Returns a map with error type, retryability, grouping keys (see
+// Fingerprint, OriginKey and ReferenceCode), extracted fields, the
+// component, trace ID and provider request ID from anywhere in the chain
+// (see GetComponent, GetTraceID and GetUpstreamRequestID), every cause of an error with additional causes (see WithAdditionalCause), and the chain's
+// metadata.
+// The type and fields come from the outermost typed error in the chain, so
+// Wrap(NewHTTPError(503, ...), "calling billing") still reports the status;
+// the message is the outermost one, and the messages added by the wrappers
+// above the typed error are listed under "context", outermost first.
+// Values and metadata are passed through SanitizeValue, so the map always
+// marshals to JSON; rejected integers beyond ±2^53 become decimal strings
+// flagged by "is_string_encoded". A typed-nil error (see NotNil) yields only its message,
+// type and retryable=false.
 //
 // Example:
 //
@@ -125,38 +296,111 @@ func FormatError(err error) string {
 //	//     "retryable": true,
 //	//     "status_code": 503,
 //	//     "message": "Service Unavailable",
+//	//     "fingerprint": "9f2c4e1a7b3d5c60",
+//	//     "origin_key": "41d8e0c2aa9b7f13",
+//	//     "reference": "E7K2-9QXM",
 //	// }
 func ExtractErrorInfo(err error) map[string]any {
 	if err == nil {
 		return nil
 	}
+	if isTypedNil(err) {
+		return typedNilInfo(err)
+	}
 
 	info := make(map[string]any)
 	info["message"] = err.Error()
 	info["retryable"] = IsRetryable(err)
+	info["fingerprint"] = Fingerprint(err)
+	info["origin_key"] = OriginKey(err)
+	info["reference"] = ReferenceCode(err)
+	if code := GetCode(err); code != "" {
+		info["code"] = code
+	}
+	if component := GetComponent(err); component != "" {
+		info["component"] = component
+	}
+	if traceID := GetTraceID(err); traceID != "" {
+		info["trace_id"] = traceID
+	}
+	if owner := GetOwner(err); owner != "" {
+		info["owner"] = owner
+	}
+	if id := GetUpstreamRequestID(err); id != "" {
+		info["upstream_request_id"] = id
+	}
+	if dependency := GetDependency(err); dependency != "" {
+		info["dependency"] = dependency
+	}
+	if cleanup := CleanupErrors(err); len(cleanup) > 0 {
+		failures := make([]string, len(cleanup))
+		for i, c := range cleanup {
+			failures[i] = c.Error()
+		}
+		info["cleanup_errors"] = failures
+	}
+	if age, stale := IsServedStale(err); stale {
+		info["served_stale"] = true
+		info["stale_age"] = age.String()
+	}
+	if n, maxAttempts, ok := GetAttempt(err); ok {
+		info["attempt"] = n
+		if maxAttempts > 0 {
+			info["max_attempts"] = maxAttempts
+		}
+	}
 
 	// Extract type-specific information
-	switch e := err.(type) {
+	typed := outermostTyped(err)
+	if context := wrapContext(err, typed); len(context) > 0 {
+		info["context"] = context
+	}
+	switch e := typed.(type) {
 	case *HTTPError:
 		info["type"] = "HTTPError"
 		info["status_code"] = e.StatusCode
+		if e.OriginComponent != "" {
+			info["origin_component"] = e.OriginComponent
+		}
+		if e.Overloaded {
+			info["overloaded"] = true
+		}
 
 	case *ValidationError:
 		info["type"] = "ValidationError"
 		info["field"] = e.Field
 		if e.Value != nil {
-			info["value"] = e.Value
+			value, stringEncoded := encodeValue(e.Value)
+			info["value"] = value
+			if stringEncoded {
+				info["is_string_encoded"] = true
+			}
 		}
 
 	case *TimeoutError:
 		info["type"] = "TimeoutError"
 		info["operation"] = e.Operation
 		info["duration"] = e.Duration.String()
+		if e.Source != "" {
+			info["source"] = e.Source
+		}
 
 	case *RateLimitError:
 		info["type"] = "RateLimitError"
 		info["operation"] = e.Operation
 		info["retry_after"] = e.RetryAfter.String()
+		if e.Limit > 0 {
+			info["limit"] = e.Limit
+			info["remaining"] = e.Remaining
+			if !e.ResetAt.IsZero() {
+				info["reset_at"] = e.ResetAt.Format(time.RFC3339)
+			}
+		}
+
+	case *RetryableError:
+		info["type"] = "RetryableError"
+		info["operation"] = e.Operation
+		info["retry_after"] = e.RetryAfter.String()
 
 	case *ProcessingError:
 		info["type"] = "ProcessingError"
@@ -169,19 +413,180 @@ func ExtractErrorInfo(err error) map[string]any {
 		info["type"] = "NetworkError"
 		info["operation"] = e.Operation
 		info["transient"] = e.IsTransient
+		if e.Kind != "" {
+			info["kind"] = string(e.Kind)
+		}
+
+	case *SerializationError:
+		info["type"] = "SerializationError"
+		info["operation"] = e.Operation
+		info["format"] = e.Format
+		if e.Direction != "" {
+			info["direction"] = string(e.Direction)
+		}
 
 	case *CircuitBreakerError:
 		info["type"] = "CircuitBreakerError"
 		info["operation"] = e.Operation
 		info["state"] = e.State
+		if !e.ReopenAt.IsZero() {
+			info["reopen_at"] = e.ReopenAt.Format(time.RFC3339)
+		}
+
+	case *NotImplementedError:
+		info["type"] = "NotImplementedError"
+		info["operation"] = e.Operation
+		info["feature"] = e.Feature
+		if !e.AvailableFrom.IsZero() {
+			info["available_from"] = e.AvailableFrom.Format(time.RFC3339)
+		}
+
+	case *UnsupportedError:
+		info["type"] = "UnsupportedError"
+		info["operation"] = e.Operation
+		info["unsupported"] = e.What
+		if e.Alternative != "" {
+			info["alternative"] = e.Alternative
+		}
+
+	case *ConsistencyError:
+		info["type"] = "ConsistencyError"
+		info["operation"] = e.Operation
+		info["resource"] = e.Resource
+		info["min_version"] = e.MinVersion
+		info["observed_version"] = e.ObservedVersion
+		if e.LagEstimate > 0 {
+			info["lag_estimate"] = e.LagEstimate.String()
+		}
+		info["retry_against_primary"] = e.RetryAgainstPrimary
+
+	case *NotFoundError:
+		info["type"] = "NotFoundError"
+		info["resource"] = e.Resource
+		if e.ID != "" {
+			info["resource_id"] = e.ID
+		}
+
+	case *ConflictError:
+		info["type"] = "ConflictError"
+		info["resource"] = e.Resource
+		if e.ID != "" {
+			info["resource_id"] = e.ID
+		}
+		if e.ExpectedVersion != "" || e.ActualVersion != "" {
+			info["expected_version"] = e.ExpectedVersion
+			info["actual_version"] = e.ActualVersion
+		}
+		info["retryable"] = e.Retryable
+
+	case *DatabaseError:
+		info["type"] = "DatabaseError"
+		info["operation"] = e.Operation
+		if e.Table != "" {
+			info["table"] = e.Table
+		}
+		if e.SQLState != "" {
+			info["sqlstate"] = e.SQLState
+		}
+		info["retryable"] = e.IsRetryable()
+
+	case *ProviderError:
+		info["type"] = "ProviderError"
+		info["operation"] = e.Operation
+		info["provider"] = e.Provider
+		if e.HTTPStatus != 0 {
+			info["status_code"] = e.HTTPStatus
+		}
+		if e.RequestID != "" {
+			info["upstream_request_id"] = e.RequestID
+		}
+		info["retryable"] = e.IsRetryable()
+
+	case *ConfigError:
+		info["type"] = "ConfigError"
+		info["operation"] = e.Operation
+		info["key"] = e.Key
+		if e.Value != nil {
+			value, stringEncoded := encodeValue(e.Value)
+			info["value"] = value
+			if stringEncoded {
+				info["is_string_encoded"] = true
+			}
+		}
+		info["retryable"] = false
+
+	case *PanicError:
+		info["type"] = "PanicError"
+		info["operation"] = e.Operation
+
+	case *RemoteError:
+		info["type"] = "RemoteError"
+		info["remote_type"] = e.Type
+		info["operation"] = e.Operation
+		info["retryable"] = e.IsRetryable()
+
+	case *RetryError:
+		info["type"] = "RetryError"
+		info["operation"] = e.Operation
+		info["attempts"] = e.Attempts
+		info["max_attempts"] = e.MaxAttempts
+		if e.Reason != "" {
+			info["reason"] = e.Reason
+		}
+		if len(e.History) > 0 {
+			plans := make([]RetryPlan, len(e.History))
+			for i, attempt := range e.History {
+				plans[i] = attempt.Plan
+			}
+			info["retry_plans"] = plans
+		}
 
 	default:
 		info["type"] = "Error"
 	}
 
+	if f, ok := typed.(chainFormatter); ok {
+		if additional := additionalCausesField(typed); additional != nil && len(*additional) > 0 {
+			var causes []string
+			for _, c := range causeList(f.causeError(), *additional) {
+				causes = append(causes, c.Error())
+			}
+			info["causes"] = causes
+		}
+	}
+
+	if metadata := allMetadata(err); metadata != nil {
+		info["metadata"] = sanitizeMap(metadata)
+	}
+
 	return info
 }
 
+// wrapContext returns the messages the wrappers above typed added to err's
+// message, outermost first. Wrappers that add no message, such as stack
+// trace wrappers, are skipped.
+func wrapContext(err, typed error) []string {
+	if typed == nil {
+		return nil
+	}
+
+	var context []string
+	for node := err; node != nil && node != typed; {
+		next := errors.UnwrapOnce(node)
+		msg := node.Error()
+		if next != nil {
+			if prefix, ok := strings.CutSuffix(msg, next.Error()); ok {
+				msg = strings.TrimSuffix(strings.TrimSpace(prefix), ":")
+			}
+		}
+		if msg != "" {
+			context = append(context, msg)
+		}
+		node = next
+	}
+	return context
+}
+
 // HasStackTrace checks if the error has a stack trace.
 func HasStackTrace(err error) bool {
 	if err == nil {
@@ -189,6 +594,6 @@ func HasStackTrace(err error) bool {
 	}
 
 	// cockroachdb/errors adds stack traces, check if present
-	trace := fmt.Sprintf("%+v", err)
+	trace := formatVerbose(err)
 	return strings.Contains(trace, ".go:")
 }
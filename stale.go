@@ -0,0 +1,101 @@
+package errors
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// Headers set by StaleHeaders.
+const (
+	HeaderWarning = "Warning"
+	HeaderAge     = "Age"
+)
+
+// staleWarning is the RFC 7234 Warning header value for stale responses.
+const staleWarning = `110 - "Response is Stale"`
+
+// staleError marks a result served from a cache after the fresh fetch
+// failed. It unwraps to the fetch failure, so errors.Is and errors.As still
+// see it.
+type staleError struct {
+	err error
+	age time.Duration
+}
+
+func (e *staleError) Error() string {
+	if e.err == nil {
+		return fmt.Sprintf("served stale data (age %v)", e.age)
+	}
+	return fmt.Sprintf("served stale data (age %v): %v", e.age, e.err)
+}
+
+func (e *staleError) Unwrap() error {
+	return e.err
+}
+
+// ServedStale marks that a cached value of the given age was served
+// because the fresh fetch failed with err. The result is a degradation,
+// not a failure: callers detect it with IsServedStale and carry on with the
+// value. It is reported at SeverityWarning, so LogError logs it at warn
+// level, gets error_class="stale_served" in MetricLabels, and
+// httperrors.Middleware turns it into Warning and Age response headers (see
+// StaleHeaders) when reported with WarnCtx. err may be nil when the cause
+// isn't known.
+//
+// Example:
+//
+//	price, err := pricing.Get(ctx, sku)
+//	if err != nil {
+//	    cached, cachedAt, ok := cache.Get(sku)
+//	    if !ok {
+//	        return 0, err
+//	    }
+//	    errors.WarnCtx(ctx, errors.ServedStale(err, time.Since(cachedAt)))
+//	    return cached, nil
+//	}
+func ServedStale(err error, age time.Duration) error {
+	return &staleError{err: err, age: age}
+}
+
+// IsServedStale reports whether err marks a stale value served after a
+// failure (see ServedStale), and how old the value was.
+//
+// Example:
+//
+//	if age, stale := errors.IsServedStale(err); stale {
+//	    metrics.Observe("stale_age_seconds", age.Seconds())
+//	    return value, nil // still a success for control flow
+//	}
+func IsServedStale(err error) (time.Duration, bool) {
+	var stale *staleError
+	if As(err, &stale) {
+		return stale.age, true
+	}
+	return 0, false
+}
+
+// StaleHeaders sets the Warning header to 110 "Response is Stale" and Age
+// to the value's age in seconds when err marks a stale value (see
+// ServedStale). Returns true if the headers were set.
+//
+// Example:
+//
+//	errors.StaleHeaders(w.Header(), err)
+//	w.WriteHeader(http.StatusOK)
+func StaleHeaders(h http.Header, err error) bool {
+	age, stale := IsServedStale(err)
+	if !stale {
+		return false
+	}
+	h.Set(HeaderWarning, staleWarning)
+	h.Set(HeaderAge, strconv.FormatInt(int64(max(age, 0)/time.Second), 10))
+	return true
+}
+
+// isServedStale reports whether err marks a stale value (see ServedStale).
+func isServedStale(err error) bool {
+	_, stale := IsServedStale(err)
+	return stale
+}
@@ -0,0 +1,93 @@
+package errors
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestServedStale tests marking stale values served after a failure
+func TestServedStale(t *testing.T) {
+	fetchErr := NewHTTPError(503, "Service Unavailable", nil)
+
+	tests := []struct {
+		name       string
+		err        error
+		wantAge    time.Duration
+		wantStale  bool
+		wantString string
+	}{
+		{
+			name: "marked", err: ServedStale(fetchErr, 2*time.Minute), wantAge: 2 * time.Minute, wantStale: true,
+			wantString: "served stale data (age 2m0s): HTTP 503: Service Unavailable",
+		},
+		{
+			name: "wrapped", err: Wrap(ServedStale(fetchErr, time.Second), "loading price"), wantAge: time.Second, wantStale: true,
+			wantString: "loading price: served stale data (age 1s): HTTP 503: Service Unavailable",
+		},
+		{name: "unknown cause", err: ServedStale(nil, time.Minute), wantAge: time.Minute, wantStale: true, wantString: "served stale data (age 1m0s)"},
+		{name: "plain failure", err: fetchErr, wantString: "HTTP 503: Service Unavailable"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			age, stale := IsServedStale(tt.err)
+			if stale != tt.wantStale || age != tt.wantAge {
+				t.Errorf("IsServedStale() = %v, %v, want %v, %v", age, stale, tt.wantAge, tt.wantStale)
+			}
+			if got := tt.err.Error(); got != tt.wantString {
+				t.Errorf("Error() = %q, want %q", got, tt.wantString)
+			}
+			if !tt.wantStale {
+				return
+			}
+			if got := GetSeverity(tt.err); got != SeverityWarning {
+				t.Errorf("GetSeverity() = %v, want warning", got)
+			}
+			if got := MetricLabels(tt.err)[LabelErrorClass]; got != ClassStaleServed {
+				t.Errorf("error_class = %q, want %q", got, ClassStaleServed)
+			}
+		})
+	}
+
+	t.Run("cause stays visible", func(t *testing.T) {
+		err := ServedStale(Wrap(ErrConnectionError, "pricing"), time.Minute)
+		if !Is(err, ErrConnectionError) {
+			t.Error("errors.Is lost the fetch failure")
+		}
+	})
+}
+
+// TestServedStaleLogging tests that stale values are logged as warnings
+func TestServedStaleLogging(t *testing.T) {
+	var buf bytes.Buffer
+	logger := slog.New(slog.NewTextHandler(&buf, nil))
+
+	LogError(context.Background(), logger, "price lookup degraded",
+		ServedStale(NewHTTPError(503, "Service Unavailable", nil, WithSeverity(SeverityCritical)), 90*time.Second))
+
+	if out := buf.String(); !strings.Contains(out, "level=WARN") || !strings.Contains(out, "error.stale_age=1m30s") {
+		t.Errorf("log output = %q, want a warning with the stale age", out)
+	}
+}
+
+// TestStaleHeaders tests the Warning and Age headers for stale values
+func TestStaleHeaders(t *testing.T) {
+	h := http.Header{}
+	if StaleHeaders(h, NewHTTPError(503, "Service Unavailable", nil)) || len(h) != 0 {
+		t.Fatalf("set headers %v for a plain failure", h)
+	}
+	if !StaleHeaders(h, ServedStale(nil, 90*time.Second+500*time.Millisecond)) {
+		t.Fatal("StaleHeaders() = false for a stale value")
+	}
+	if got := h.Get(HeaderWarning); got != `110 - "Response is Stale"` {
+		t.Errorf("Warning = %q", got)
+	}
+	if got := h.Get(HeaderAge); got != "90" {
+		t.Errorf("Age = %q, want 90", got)
+	}
+}
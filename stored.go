@@ -0,0 +1,66 @@
+package errors
+
+import (
+	"database/sql/driver"
+	"fmt"
+)
+
+// StoredError is a database column holding an error, such as a job's last
+// failure in a Postgres jsonb column. It implements driver.Valuer and
+// sql.Scanner, so repositories can declare it as a field and pass it to
+// Exec and Scan directly. A nil Err is stored as NULL.
+//
+// The stored form is CompactJSON: types, messages, codes and
+// classification survive, and so do the reference code and UserMessage, as
+// the origin key is recorded. Types this version doesn't know scan as a
+// RemoteError keeping the class they were stored with.
+//
+// Example:
+//
+//	type Job struct {
+//	    ID        string
+//	    LastError errors.StoredError
+//	}
+//
+//	db.ExecContext(ctx, "UPDATE jobs SET last_error = $1 WHERE id = $2",
+//	    errors.StoredError{Err: jobErr}, job.ID)
+//	db.QueryRowContext(ctx, "SELECT last_error FROM jobs WHERE id = $1", id).
+//	    Scan(&job.LastError)
+//	if errors.IsRetryable(job.LastError.Err) { ... }
+type StoredError struct {
+	Err error
+}
+
+// Value returns the envelope JSON of Compact(Err) as a string, which drivers
+// accept for json, jsonb and text columns, or nil for a nil Err.
+func (s StoredError) Value() (driver.Value, error) {
+	if s.Err == nil {
+		return nil, nil
+	}
+	return string(CompactJSON(s.Err)), nil
+}
+
+// Scan decodes envelope JSON from a string or []byte column into Err. NULL
+// and JSON null scan as a nil Err. Malformed JSON is reported as a
+// SerializationError.
+func (s *StoredError) Scan(src any) error {
+	var data []byte
+	switch v := src.(type) {
+	case nil:
+		s.Err = nil
+		return nil
+	case []byte:
+		data = v
+	case string:
+		data = []byte(v)
+	default:
+		return NewSerializationError(fmt.Sprintf("cannot scan %T into StoredError", src), "Scan", "json")
+	}
+
+	stored, err := UnmarshalError(data)
+	if err != nil {
+		return err
+	}
+	s.Err = stored
+	return nil
+}
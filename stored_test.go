@@ -0,0 +1,111 @@
+package errors
+
+import (
+	"database/sql/driver"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// storeAndScan stores err the way a driver would and scans it back.
+func storeAndScan(t *testing.T, err error) error {
+	t.Helper()
+	value, valueErr := StoredError{Err: err}.Value()
+	if valueErr != nil {
+		t.Fatalf("Value() error = %v", valueErr)
+	}
+	var stored StoredError
+	if scanErr := stored.Scan(value); scanErr != nil {
+		t.Fatalf("Scan(%v) error = %v", value, scanErr)
+	}
+	return stored.Err
+}
+
+// TestStoredErrorRoundTrip tests that classification, code and user message survive storage
+func TestStoredErrorRoundTrip(t *testing.T) {
+	examples := wireExamples()
+	examples["wrapped sentinel"] = fmt.Errorf("saving order: %w", ErrDeadlock)
+	examples["foreign"] = New("disk full")
+
+	for name, err := range examples {
+		t.Run(name, func(t *testing.T) {
+			stored := storeAndScan(t, err)
+			if got, want := Classify(stored), Classify(err); got != want {
+				t.Errorf("Classify() = %q, want %q", got, want)
+			}
+			if got, want := GetCode(stored), GetCode(err); got != want {
+				t.Errorf("GetCode() = %q, want %q", got, want)
+			}
+			if got, want := UserMessage(stored), UserMessage(err); got != want {
+				t.Errorf("UserMessage() = %q, want %q", got, want)
+			}
+			if stored.Error() != Compact(err).Error() {
+				t.Errorf("Error() = %q, want %q", stored.Error(), Compact(err).Error())
+			}
+			if again := storeAndScan(t, stored); ReferenceCode(again) != ReferenceCode(err) {
+				t.Error("reference code should survive being stored twice")
+			}
+		})
+	}
+}
+
+// TestStoredErrorFixtures tests that stored envelope fixtures scan and survive being stored again
+func TestStoredErrorFixtures(t *testing.T) {
+	var paths []string
+	for _, pattern := range []string{"testdata/wire/envelope/*.json", "testdata/envelope/*.json"} {
+		matches, err := filepath.Glob(pattern)
+		if err != nil {
+			t.Fatal(err)
+		}
+		paths = append(paths, matches...)
+	}
+	if len(paths) == 0 {
+		t.Fatal("no envelope fixtures found")
+	}
+
+	for _, path := range paths {
+		t.Run(path, func(t *testing.T) {
+			data, err := os.ReadFile(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var stored StoredError
+			if err := stored.Scan(data); err != nil {
+				t.Fatalf("Scan() error = %v", err)
+			}
+			want, _ := UnmarshalError(data)
+			if Classify(stored.Err) != Classify(want) || GetCode(stored.Err) != GetCode(want) {
+				t.Errorf("scanned %v, want it to match UnmarshalError", stored.Err)
+			}
+
+			again := storeAndScan(t, stored.Err)
+			if Classify(again) != Classify(want) || GetCode(again) != GetCode(want) || UserMessage(again) != UserMessage(want) {
+				t.Errorf("stored again as %v, want classification, code and user message kept", again)
+			}
+		})
+	}
+}
+
+// TestStoredErrorNull tests NULL handling and invalid column values
+func TestStoredErrorNull(t *testing.T) {
+	if value, err := (StoredError{}).Value(); value != nil || err != nil {
+		t.Errorf("Value() of a nil Err = %v, %v; want NULL", value, err)
+	}
+
+	stored := StoredError{Err: New("stale")}
+	for _, src := range []any{nil, "null", []byte("null")} {
+		if err := stored.Scan(src); err != nil || stored.Err != nil {
+			t.Errorf("Scan(%v) = %v, Err = %v; want a nil Err", src, err, stored.Err)
+		}
+	}
+
+	for _, src := range []any{42, "{not json"} {
+		var serErr *SerializationError
+		if err := stored.Scan(src); !As(err, &serErr) {
+			t.Errorf("Scan(%v) error = %v, want a SerializationError", src, err)
+		}
+	}
+
+	var _ driver.Valuer = StoredError{}
+}
@@ -0,0 +1,322 @@
+package errors
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/cockroachdb/errors"
+)
+
+// maxSummaryLength caps a Summarize result, in bytes.
+const maxSummaryLength = 160
+
+// maxSummaryFields is how many invalid field names a summary lists.
+const maxSummaryFields = 3
+
+// Summarize returns a one-line summary of err for operators, such as
+// "Upstream payments-api unavailable (503), gave up after 5 attempts over
+// 42s". The phrasing is chosen by error type and built from fields rather
+// than messages, so the same failure always reads the same: the dependency
+// or component, the HTTP status, a RetryError's attempts and recorded
+// backoff, any retry-after hint and the number of invalid fields. Errors
+// with no structured context fall back to the first line of their message.
+//
+// The result is at most 160 bytes. Returns "" for a nil error.
+//
+// Example:
+//
+//	alert.Title = errors.Summarize(err)
+func Summarize(err error) string {
+	if err == nil {
+		return ""
+	}
+
+	failure := err
+	var retry *RetryError
+	if errors.As(err, &retry) && retry != nil && retry.LastError != nil {
+		failure = retry.LastError
+	}
+
+	subject := summarySubject(failure)
+	if subject == "" && retry != nil {
+		subject = retry.Component
+		if subject == "" {
+			subject = retry.Operation
+		}
+	}
+
+	parts := []string{summaryHead(failure, subject)}
+	if retry != nil {
+		parts = append(parts, retrySummary(retry))
+	}
+	if wait, ok := GetRetryAfter(failure); ok {
+		parts = append(parts, "retry after "+summaryDuration(wait))
+	}
+
+	summary := strings.Join(strings.Fields(strings.Join(parts, ", ")), " ")
+	if len(summary) > maxSummaryLength {
+		summary = truncateString(summary, maxSummaryLength-len(truncatedCause))
+	}
+	return summary
+}
+
+// summarySubject returns the name of what failed: the dependency, the
+// origin component, the component or the outermost operation.
+func summarySubject(err error) string {
+	if dependency := GetDependency(err); dependency != "" {
+		return dependency
+	}
+	if httpErr, ok := IsHTTPError(err); ok && httpErr.OriginComponent != "" {
+		return httpErr.OriginComponent
+	}
+	if component := GetComponent(err); component != "" {
+		return component
+	}
+	var operation string
+	walkChain(err, func(node error, _ int) bool {
+		operation = operationOf(node)
+		return operation == ""
+	})
+	return operation
+}
+
+// summaryHead returns the phrase describing err's failure, falling back to
+// the first line of its message.
+func summaryHead(err error, subject string) string {
+	var (
+		rateErr        *RateLimitError
+		circuitErr     *CircuitBreakerError
+		timeoutErr     *TimeoutError
+		serErr         *SerializationError
+		notImplErr     *NotImplementedError
+		unsupportedErr *UnsupportedError
+		notFoundErr    *NotFoundError
+		conflictErr    *ConflictError
+		dbErr          *DatabaseError
+		providerErr    *ProviderError
+		configErr      *ConfigError
+		remoteErr      *RemoteError
+		processingErr  *ProcessingError
+		batchErr       *BatchError
+	)
+	panicErr, isPanic := IsPanic(err)
+	consistencyErr, isStale := IsConsistencyError(err)
+	httpErr, isHTTP := IsHTTPError(err)
+
+	switch {
+	case isPanic:
+		value := firstLine(fmt.Sprint(panicErr.Value))
+		if subject != "" {
+			return fmt.Sprintf("Panic in %s: %s", subject, value)
+		}
+		return "Panic: " + value
+	case IsCallerDisconnect(err):
+		return withSubject("Caller disconnected", " during ", subject)
+	case errors.As(err, &rateErr) && rateErr != nil,
+		isHTTP && httpErr.StatusCode == http.StatusTooManyRequests:
+		head := withSubject("Rate limited", " by ", subject)
+		if isHTTP {
+			head += fmt.Sprintf(" (%d)", httpErr.StatusCode)
+		}
+		return head
+	case errors.As(err, &circuitErr) && circuitErr != nil:
+		state := circuitErr.State
+		if state == "" {
+			state = "open"
+		}
+		return withSubject("Circuit "+state, " for ", subject)
+	case isStale:
+		return fmt.Sprintf("Stale read of %s from a lagging replica", consistencyErr.Resource)
+	case errors.As(err, &timeoutErr) && timeoutErr != nil:
+		head := withSubject("Timeout", " calling ", subject)
+		if timeoutErr.Duration > 0 {
+			head += " after " + summaryDuration(timeoutErr.Duration)
+		}
+		return head
+	case errors.Is(err, context.DeadlineExceeded):
+		return withSubject("Deadline exceeded", " calling ", subject)
+	case errors.Is(err, context.Canceled):
+		return withSubject("Canceled", " calling ", subject)
+	case IsValidation(err):
+		return validationSummary(err)
+	case errors.As(err, &serErr) && serErr != nil:
+		format := serErr.Format
+		if format == "" {
+			format = "data"
+		}
+		switch serErr.Direction {
+		case DirectionInbound:
+			return withSubject("Malformed "+format, " from ", subject)
+		case DirectionOutbound:
+			return withSubject("Failed to encode "+format, " for ", subject)
+		}
+		return withSubject("Failed to serialize "+format, " in ", subject)
+	case errors.As(err, &notImplErr) && notImplErr != nil:
+		return "Not implemented yet: " + notImplErr.Feature
+	case errors.As(err, &unsupportedErr) && unsupportedErr != nil:
+		return "Unsupported: " + unsupportedErr.What
+	case errors.As(err, &notFoundErr) && notFoundErr != nil:
+		what := notFoundErr.Resource
+		if notFoundErr.ID != "" {
+			what += " " + notFoundErr.ID
+		}
+		return withSubject("Not found", " in ", subject) + ": " + what
+	case errors.As(err, &conflictErr) && conflictErr != nil:
+		what := conflictErr.Resource
+		if conflictErr.ID != "" {
+			what += " " + conflictErr.ID
+		}
+		return withSubject("Conflict", " in ", subject) + ": " + what
+	case errors.As(err, &dbErr) && dbErr != nil:
+		head := withSubject("Database error", " in ", subject)
+		if dbErr.Table != "" {
+			head += " on " + dbErr.Table
+		}
+		if dbErr.SQLState != "" {
+			head += " (SQLSTATE " + dbErr.SQLState + ")"
+		}
+		return head
+	case errors.As(err, &providerErr) && providerErr != nil:
+		var details []string
+		if providerErr.Code != "" {
+			details = append(details, providerErr.Code)
+		}
+		if providerErr.HTTPStatus != 0 {
+			details = append(details, strconv.Itoa(providerErr.HTTPStatus))
+		}
+		head := withSubject(providerErr.Provider+" error", " in ", subject)
+		if len(details) > 0 {
+			head += " (" + strings.Join(details, ", ") + ")"
+		}
+		return head
+	case errors.As(err, &configErr) && configErr != nil:
+		head := withSubject("Invalid configuration", " in ", subject)
+		if configErr.Key != "" {
+			head += ": " + configErr.Key
+		}
+		return head
+	case IsNetworkError(err):
+		return withSubject("Network failure", " reaching ", subject)
+	case isHTTP:
+		return httpSummary(httpErr, subject)
+	case errors.As(err, &remoteErr) && remoteErr != nil:
+		head := withSubject("Remote "+remoteErr.Type, " from ", subject)
+		if remoteErr.StatusCode > 0 {
+			head += fmt.Sprintf(" (%d)", remoteErr.StatusCode)
+		}
+		return head
+	case errors.As(err, &processingErr) && processingErr != nil:
+		if processingErr.ItemID != "" {
+			return withSubject("Failed to process "+processingErr.ItemID, " in ", subject)
+		}
+		return withSubject("Processing failed", " in ", subject)
+	case errors.As(err, &batchErr) && batchErr != nil:
+		return fmt.Sprintf("%d of %d items failed", len(batchErr.Failed), batchErr.Total)
+	}
+	return firstLine(err.Error())
+}
+
+// httpSummary phrases an HTTP failure by its status.
+func httpSummary(httpErr *HTTPError, subject string) string {
+	status := httpErr.StatusCode
+	switch {
+	case httpErr.Overloaded:
+		return fmt.Sprintf("Upstream %soverloaded (%d)", withTrailingSpace(subject), status)
+	case status == http.StatusBadGateway || status == http.StatusServiceUnavailable ||
+		status == http.StatusGatewayTimeout:
+		return fmt.Sprintf("Upstream %sunavailable (%d)", withTrailingSpace(subject), status)
+	case status >= 500:
+		return withSubject("Request failed", " in ", subject) + fmt.Sprintf(" (%d)", status)
+	case status == http.StatusNotFound:
+		return withSubject("Not found", " in ", subject) + fmt.Sprintf(" (%d)", status)
+	}
+	return withSubject("Request rejected", " by ", subject) + fmt.Sprintf(" (%d)", status)
+}
+
+// validationSummary counts the validation errors in err's chain and lists
+// the first few fields.
+func validationSummary(err error) string {
+	count := 0
+	var fields []string
+	walkChain(err, func(node error, _ int) bool {
+		if validationErr, ok := node.(*ValidationError); ok {
+			count++
+			if validationErr.Field != "" {
+				fields = append(fields, validationErr.Field)
+			}
+		}
+		return true
+	})
+	if count == 0 {
+		// only the sentinel
+		return "Invalid input"
+	}
+
+	noun := "fields"
+	if count == 1 {
+		noun = "field"
+	}
+	head := fmt.Sprintf("Invalid input: %d %s", count, noun)
+	if len(fields) > maxSummaryFields {
+		fields = append(fields[:maxSummaryFields], "…")
+	}
+	if len(fields) > 0 {
+		head += " (" + strings.Join(fields, ", ") + ")"
+	}
+	return head
+}
+
+// retrySummary describes how retrying ended. The span is the backoff
+// recorded in History, a lower bound on the time spent.
+func retrySummary(retry *RetryError) string {
+	noun := "attempts"
+	if retry.Attempts == 1 {
+		noun = "attempt"
+	}
+	summary := fmt.Sprintf("gave up after %d %s", retry.Attempts, noun)
+
+	var waited time.Duration
+	for _, attempt := range retry.History {
+		waited += attempt.Plan.Delay
+	}
+	if waited > 0 {
+		summary += " over " + summaryDuration(waited)
+	}
+	if retry.Reason != "" {
+		summary += " (" + retry.Reason + ")"
+	}
+	return summary
+}
+
+// withSubject returns head followed by joiner and subject, or head alone
+// when subject is empty.
+func withSubject(head, joiner, subject string) string {
+	if subject == "" {
+		return head
+	}
+	return head + joiner + subject
+}
+
+func withTrailingSpace(s string) string {
+	if s == "" {
+		return ""
+	}
+	return s + " "
+}
+
+// summaryDuration rounds d to whole seconds, or milliseconds below a second.
+func summaryDuration(d time.Duration) string {
+	if d >= time.Second {
+		return d.Round(time.Second).String()
+	}
+	return d.Round(time.Millisecond).String()
+}
+
+func firstLine(s string) string {
+	line, _, _ := strings.Cut(strings.TrimSpace(s), "\n")
+	return line
+}
@@ -0,0 +1,125 @@
+package errors
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// summaryChains are the representative chains pinned by summarize.golden.
+var summaryChains = []struct {
+	name string
+	err  error
+}{
+	{"retry exhausted against unavailable upstream", NewRetryError(5, 5,
+		NewHTTPError(503, "Service Unavailable", nil, WithComponent("payments-api")), nil,
+		WithOperation("GET payments.internal"),
+		WithAttemptHistory([]Attempt{
+			{Number: 1, Plan: RetryPlan{Delay: 6 * time.Second}},
+			{Number: 2, Plan: RetryPlan{Delay: 12 * time.Second}},
+			{Number: 3, Plan: RetryPlan{Delay: 24 * time.Second}},
+		}))},
+	{"retry stopped by budget", NewRetryError(2, 5,
+		NewNetworkError("connection refused", "GET inventory.internal"), nil,
+		WithReason(RetryReasonBudgetExhausted))},
+	{"rate limited", NewRateLimitError("quota exceeded", "ListOrders", 30*time.Second, WithComponent("orders"))},
+	{"rate limited response", NewHTTPError(429, "Too Many Requests", nil, WithComponent("github"))},
+	{"timeout", NewTimeoutError("quote took too long", "FetchQuote", 2*time.Second, WithComponent("pricing"))},
+	{"validation", NewValidationError("invalid email", "email",
+		WithAdditionalCause(NewValidationError("too short", "name")))},
+	{"validation with many fields", NewValidationError("invalid", "email",
+		WithAdditionalCause(NewValidationError("missing", "name")),
+		WithAdditionalCause(NewValidationError("missing", "street")),
+		WithAdditionalCause(NewValidationError("missing", "city")))},
+	{"decode from dependency", NewDecodeError("bad body", "GetUser", "json", WithDependency("users-api"))},
+	{"circuit open", NewCircuitBreakerError("breaker open", "Charge", "open", WithComponent("stripe"))},
+	{"panic", NewPanicError("index out of range", WithOperation("ProcessJob"))},
+	{"wrapped network failure", Wrap(NewNetworkError("connection reset", "GET search.internal"), "loading results")},
+	{"overloaded upstream", NewHTTPError(503, "Service Unavailable", nil, WithComponent("search"), WithOverloaded())},
+	{"not found", NewNotFoundError("order", "42")},
+	{"conflict", NewConflictError("order", "42", WithVersions("7", "8"))},
+	{"deadlock", NewDatabaseError("UpdateOrder", "orders", WithSQLState(SQLStateDeadlockDetected))},
+	{"declined card", NewProviderError("stripe", WithCode("card_declined"), WithStatusCode(402), WithOperation("CreateCharge"))},
+	{"bad config", NewConfigError("must be at least 1", "max", WithOperation("ParseRetrySpec"))},
+	{"stale replica read", NewConsistencyError("order/42", "17", "15", 1500*time.Millisecond)},
+	{"unsupported", NewUnsupportedError("HEIC uploads", "JPEG or PNG")},
+	{"deadline", Wrap(context.DeadlineExceeded, "querying ledger")},
+	{"foreign multi-line", fmt.Errorf("disk full\n  at /var/lib/data")},
+}
+
+// TestSummarize tests summaries of representative chains against the committed golden file
+func TestSummarize(t *testing.T) {
+	var got bytes.Buffer
+	for _, chain := range summaryChains {
+		fmt.Fprintf(&got, "%s: %s\n", chain.name, Summarize(chain.err))
+	}
+
+	golden := filepath.Join("testdata", "summarize.golden")
+	if *updateGolden {
+		if err := os.WriteFile(golden, got.Bytes(), 0o644); err != nil {
+			t.Fatalf("writing golden file: %v", err)
+		}
+	}
+	want, err := os.ReadFile(golden)
+	if err != nil {
+		t.Fatalf("reading golden file: %v", err)
+	}
+	if !bytes.Equal(got.Bytes(), want) {
+		t.Errorf("summaries differ from %s; review the change and run go test -run TestSummarize -update\n%s", golden, got.String())
+	}
+}
+
+// TestSummarizeBounds tests nil errors, the length cap and determinism
+func TestSummarizeBounds(t *testing.T) {
+	if got := Summarize(nil); got != "" {
+		t.Errorf("Summarize(nil) = %q, want empty", got)
+	}
+
+	long := fmt.Errorf("%s", strings.Repeat("é", 200))
+	got := Summarize(long)
+	if len(got) > maxSummaryLength || !strings.HasSuffix(got, truncatedCause) {
+		t.Errorf("Summarize() = %d bytes %q, want at most %d ending in %q", len(got), got, maxSummaryLength, truncatedCause)
+	}
+
+	for _, chain := range summaryChains {
+		if first, second := Summarize(chain.err), Summarize(chain.err); first != second {
+			t.Errorf("%s: Summarize() = %q then %q, want the same", chain.name, first, second)
+		}
+	}
+}
+
+// TestLogErrorSummary tests that LogError uses the summary when no message is given
+func TestLogErrorSummary(t *testing.T) {
+	err := NewTimeoutError("quote took too long", "FetchQuote", 2*time.Second, WithComponent("pricing"))
+
+	tests := []struct {
+		name string
+		msg  string
+		want string
+	}{
+		{name: "empty message", msg: "", want: Summarize(err)},
+		{name: "explicit message", msg: "quote failed", want: "quote failed"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			LogError(context.Background(), slog.New(slog.NewJSONHandler(&buf, nil)), tt.msg, err)
+
+			var record map[string]any
+			if err := json.Unmarshal(buf.Bytes(), &record); err != nil {
+				t.Fatal(err)
+			}
+			if record["msg"] != tt.want {
+				t.Errorf("msg = %v, want %q", record["msg"], tt.want)
+			}
+		})
+	}
+}
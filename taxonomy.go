@@ -0,0 +1,126 @@
+package errors
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"reflect"
+	"strings"
+)
+
+// GenerateTaxonomy writes a Markdown reference of every error the package
+// can produce: each type (see TypeDescriptors) with its class, HTTP status,
+// gRPC code, exit code, fields and problem details members; each sentinel
+// that travels on the wire; and each code in the catalog with its
+// mappings. Everything is derived by running the classifier and mappers on
+// the descriptors' examples, so the reference can't disagree with the code.
+// Output is deterministic, for committing and diffing.
+//
+// Services generate it with go:generate and assert in a test that the
+// committed file still matches, so the taxonomy can't drift silently.
+//
+// Example:
+//
+//	//go:generate go run ./cmd/errtaxonomy -o ERRORS.md
+//	func main() {
+//	    registerErrorCodes()
+//	    if err := errors.GenerateTaxonomy(os.Stdout); err != nil {
+//	        log.Fatal(err)
+//	    }
+//	}
+func GenerateTaxonomy(w io.Writer) error {
+	var b bytes.Buffer
+	fmt.Fprintf(&b, "# Error Taxonomy\n\n")
+	fmt.Fprintf(&b, "Generated by errors.GenerateTaxonomy. Do not edit.\n\n")
+	fmt.Fprintf(&b, "Classification rules version %d, schema version %d.\n", RulesVersion, SchemaVersion)
+
+	fmt.Fprintf(&b, "\n## Types\n\n")
+	fmt.Fprintf(&b, "| Type | Class | HTTP | gRPC | Exit | Description |\n")
+	fmt.Fprintf(&b, "|------|-------|------|------|------|-------------|\n")
+	descriptors := TypeDescriptors()
+	for _, d := range descriptors {
+		fmt.Fprintf(&b, "| %s | %s | %d | %s | %d | %s |\n", d.Name, d.Class(), d.Status(),
+			ToGRPCStatus(d.Example), ExitCode(d.Example), markdownCell(d.Description))
+	}
+	for _, d := range descriptors {
+		fmt.Fprintf(&b, "\n### %s\n\n", d.Name)
+		if fields := exampleFields(d); len(fields) > 0 {
+			fmt.Fprintf(&b, "Fields: %s.\n", strings.Join(fields, ", "))
+		} else {
+			fmt.Fprintf(&b, "No exported fields.\n")
+		}
+		if len(d.Extensions) > 0 {
+			names := make([]string, len(d.Extensions))
+			for i, ext := range d.Extensions {
+				names[i] = "`" + ext.Name + "`"
+			}
+			fmt.Fprintf(&b, "\nProblem details members: %s.\n", strings.Join(names, ", "))
+		}
+	}
+
+	fmt.Fprintf(&b, "\n## Sentinels\n\n")
+	fmt.Fprintf(&b, "| Message | Class | HTTP | gRPC |\n")
+	fmt.Fprintf(&b, "|---------|-------|------|------|\n")
+	for _, sentinel := range wireSentinels {
+		fmt.Fprintf(&b, "| %s | %s | %d | %s |\n", markdownCell(sentinel.Error()), Classify(sentinel),
+			HTTPStatus(sentinel), ToGRPCStatus(sentinel))
+	}
+
+	fmt.Fprintf(&b, "\n## Codes\n\n")
+	codes := catalogCodes()
+	if len(codes) == 0 {
+		fmt.Fprintf(&b, "No codes registered.\n")
+	} else {
+		fmt.Fprintf(&b, "| Code | HTTP | gRPC | Exit | Retryable |\n")
+		fmt.Fprintf(&b, "|------|------|------|------|-----------|\n")
+		for _, c := range codes {
+			status := "-"
+			if c.HTTPStatus != 0 {
+				status = fmt.Sprint(c.HTTPStatus)
+			}
+			fmt.Fprintf(&b, "| %s | %s | %s | %s | %s |\n", c.Code, status,
+				taxonomyPointer(c.GRPCCode), taxonomyPointer(c.ExitCode), taxonomyPointer(c.Retryable))
+		}
+	}
+
+	if _, err := w.Write(b.Bytes()); err != nil {
+		return Wrap(err, "writing error taxonomy")
+	}
+	return nil
+}
+
+// exampleFields lists the exported fields of the descriptor's type, found
+// by name in its example's chain, as "`Name type`".
+func exampleFields(d TypeDescriptor) []string {
+	var fields []string
+	walkChain(d.Example, func(node error, _ int) bool {
+		t := reflect.TypeOf(node)
+		if t.Kind() == reflect.Pointer {
+			t = t.Elem()
+		}
+		if t.Kind() != reflect.Struct || t.Name() != d.Name {
+			return true
+		}
+		for i := range t.NumField() {
+			if f := t.Field(i); f.IsExported() {
+				typ := strings.ReplaceAll(f.Type.String(), "interface {}", "any")
+				fields = append(fields, fmt.Sprintf("`%s %s`", f.Name, typ))
+			}
+		}
+		return false
+	})
+	return fields
+}
+
+// markdownCell escapes s for use in a Markdown table cell.
+func markdownCell(s string) string {
+	return strings.ReplaceAll(s, "|", `\|`)
+}
+
+// taxonomyPointer renders an optional mapping, or "-" when it isn't set.
+func taxonomyPointer[T any](p *T) string {
+	if p == nil {
+		return "-"
+	}
+	return fmt.Sprint(*p)
+}
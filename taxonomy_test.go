@@ -0,0 +1,55 @@
+package errors
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// TestGenerateTaxonomy tests the generated taxonomy against the committed golden file
+func TestGenerateTaxonomy(t *testing.T) {
+	t.Cleanup(ResetMappings)
+	t.Cleanup(ResetTypeDescriptors)
+
+	RegisterCodes("ORDERS_NOT_FOUND", "INVENTORY_SYNCING")
+	SetMappingTable(NewMappingTable(
+		Code("INVENTORY_SYNCING").HTTP(503).GRPC(GRPCUnavailable).Retryable(true),
+	))
+	RegisterTypeDescriptor(TypeDescriptor{
+		Name:        "QuotaError",
+		Description: "Monthly quota exhausted | per tenant",
+		Example:     Permanent(NewHTTPError(403, "Quota exhausted", nil)),
+	})
+
+	var buf bytes.Buffer
+	if err := GenerateTaxonomy(&buf); err != nil {
+		t.Fatalf("GenerateTaxonomy() error = %v", err)
+	}
+	got := buf.Bytes()
+
+	golden := filepath.Join("testdata", "taxonomy.golden.md")
+	if *updateGolden {
+		if err := os.WriteFile(golden, got, 0o644); err != nil {
+			t.Fatalf("writing golden file: %v", err)
+		}
+	}
+	want, err := os.ReadFile(golden)
+	if err != nil {
+		t.Fatalf("reading golden file: %v", err)
+	}
+	if !bytes.Equal(got, want) {
+		t.Errorf("taxonomy differs from %s; review the change and run go test -run TestGenerateTaxonomy -update\n%s", golden, got)
+	}
+
+	for _, d := range TypeDescriptors() {
+		if !strings.Contains(buf.String(), "\n### "+d.Name+"\n") {
+			t.Errorf("taxonomy is missing a section for %s", d.Name)
+		}
+	}
+	var again bytes.Buffer
+	if GenerateTaxonomy(&again); !bytes.Equal(again.Bytes(), got) {
+		t.Error("GenerateTaxonomy() output should be deterministic")
+	}
+}
@@ -0,0 +1,343 @@
+package errors
+
+import (
+	"context"
+	"time"
+)
+
+// TypedOption is a functional option for the type-checked constructors,
+// one for each typed error constructor that takes options, such as
+// NewHTTPErrorT and NewProcessingErrorT. Unlike Option, it names the
+// error type it configures, so passing an option to a constructor whose
+// type doesn't have the field it sets is a compile error rather than a
+// silent no-op:
+//
+//	errors.NewHTTPErrorT(404, "Not found",
+//	    errors.WithItemIDT("order-1")) // compile error: not a TypedOption[*HTTPError]
+//
+// Options shared by several types, such as WithCauseT, are generic over the
+// types that have the field. Go can't infer their type argument from the
+// constructor, so it is given explicitly:
+//
+//	err := errors.NewProcessingErrorT("Failed to load user", "LoadUser",
+//	    errors.WithCauseT[*errors.ProcessingError](dbErr),
+//	    errors.WithItemIDT("user-42"))
+//
+// Typed options are applied in order with the same last-wins rules as
+// Option, together with the default options (see SetDefaultOptions). The
+// Option-based constructors are unchanged.
+type TypedOption[T error] struct {
+	apply Option
+}
+
+// typedOption returns a TypedOption running set when the error being
+// constructed is a T.
+func typedOption[T error](set func(T)) TypedOption[T] {
+	return TypedOption[T]{apply: func(err any) {
+		if e, ok := err.(T); ok {
+			set(e)
+		}
+	}}
+}
+
+// untyped converts typed options for a constructor taking Option.
+func untyped[T error](opts []TypedOption[T]) []Option {
+	converted := make([]Option, len(opts))
+	for i, opt := range opts {
+		converted[i] = opt.apply
+	}
+	return converted
+}
+
+// The setters below are the constraints of the shared typed options: an
+// error type accepts WithCauseT, for example, only if it has a cause.
+
+type causeSetter interface {
+	error
+	setCause(cause error)
+}
+
+type messageSetter interface {
+	error
+	setMessage(message string)
+}
+
+type componentSetter interface {
+	error
+	setComponent(component string)
+}
+
+type operationSetter interface {
+	error
+	setOperation(operation string)
+}
+
+func (e *HTTPError) setCause(cause error)           { e.Err = cause }
+func (e *ValidationError) setCause(cause error)     { e.Err = cause }
+func (e *TimeoutError) setCause(cause error)        { e.Err = cause }
+func (h *RetryHint) setCause(cause error)           { h.Err = cause }
+func (e *ProcessingError) setCause(cause error)     { e.Err = cause }
+func (e *NetworkError) setCause(cause error)        { e.Err = cause }
+func (e *SerializationError) setCause(cause error)  { e.Err = cause }
+func (e *CircuitBreakerError) setCause(cause error) { e.Err = cause }
+func (e *RetryError) setCause(cause error)          { e.LastError = cause }
+func (e *NotFoundError) setCause(cause error)       { e.Err = cause }
+func (e *ConflictError) setCause(cause error)       { e.Err = cause }
+func (e *DatabaseError) setCause(cause error)       { e.Err = cause }
+func (e *ProviderError) setCause(cause error)       { e.Err = cause }
+
+func (e *HTTPError) setMessage(message string)           { e.Message = message }
+func (e *ValidationError) setMessage(message string)     { e.Message = message }
+func (e *TimeoutError) setMessage(message string)        { e.Message = message }
+func (h *RetryHint) setMessage(message string)           { h.Message = message }
+func (e *ProcessingError) setMessage(message string)     { e.Message = message }
+func (e *NetworkError) setMessage(message string)        { e.Message = message }
+func (e *SerializationError) setMessage(message string)  { e.Message = message }
+func (e *CircuitBreakerError) setMessage(message string) { e.Message = message }
+func (e *NotFoundError) setMessage(message string)       { e.Message = message }
+func (e *ConflictError) setMessage(message string)       { e.Message = message }
+func (e *DatabaseError) setMessage(message string)       { e.Message = message }
+func (e *ProviderError) setMessage(message string)       { e.Message = message }
+
+func (e *HTTPError) setComponent(component string)           { e.Component = component }
+func (e *ValidationError) setComponent(component string)     { e.Component = component }
+func (e *TimeoutError) setComponent(component string)        { e.Component = component }
+func (h *RetryHint) setComponent(component string)           { h.Component = component }
+func (e *ProcessingError) setComponent(component string)     { e.Component = component }
+func (e *NetworkError) setComponent(component string)        { e.Component = component }
+func (e *SerializationError) setComponent(component string)  { e.Component = component }
+func (e *CircuitBreakerError) setComponent(component string) { e.Component = component }
+func (e *RetryError) setComponent(component string)          { e.Component = component }
+func (e *NotFoundError) setComponent(component string)       { e.Component = component }
+func (e *ConflictError) setComponent(component string)       { e.Component = component }
+func (e *DatabaseError) setComponent(component string)       { e.Component = component }
+func (e *ProviderError) setComponent(component string)       { e.Component = component }
+
+func (e *TimeoutError) setOperation(operation string)        { e.Operation = operation }
+func (h *RetryHint) setOperation(operation string)           { h.Operation = operation }
+func (e *ProcessingError) setOperation(operation string)     { e.Operation = operation }
+func (e *NetworkError) setOperation(operation string)        { e.Operation = operation }
+func (e *SerializationError) setOperation(operation string)  { e.Operation = operation }
+func (e *CircuitBreakerError) setOperation(operation string) { e.Operation = operation }
+func (e *RetryError) setOperation(operation string)          { e.Operation = operation }
+func (e *DatabaseError) setOperation(operation string)       { e.Operation = operation }
+func (e *ProviderError) setOperation(operation string)       { e.Operation = operation }
+
+// WithCauseT is the type-checked WithCause. For a RetryError it sets
+// LastError.
+//
+// Example:
+//
+//	err := errors.NewNetworkErrorT("Dial failed", "Connect",
+//	    errors.WithCauseT[*errors.NetworkError](dialErr))
+func WithCauseT[T causeSetter](cause error) TypedOption[T] {
+	return typedOption(func(e T) { e.setCause(cause) })
+}
+
+// WithMessageT is the type-checked WithMessage.
+func WithMessageT[T messageSetter](message string) TypedOption[T] {
+	return typedOption(func(e T) { e.setMessage(message) })
+}
+
+// WithComponentT is the type-checked WithComponent.
+func WithComponentT[T componentSetter](component string) TypedOption[T] {
+	return typedOption(func(e T) { e.setComponent(component) })
+}
+
+// WithOperationT is the type-checked WithOperation. HTTPError,
+// ValidationError, NotFoundError and ConflictError have no operation, so it
+// doesn't compile for them.
+func WithOperationT[T operationSetter](operation string) TypedOption[T] {
+	return typedOption(func(e T) { e.setOperation(operation) })
+}
+
+// WithMetadataT is the type-checked WithMetadata. Every typed error has
+// metadata.
+func WithMetadataT[T error](key string, value any) TypedOption[T] {
+	return TypedOption[T]{apply: WithMetadata(key, value)}
+}
+
+// WithContextT is the type-checked WithContext.
+func WithContextT[T error](ctx context.Context) TypedOption[T] {
+	return TypedOption[T]{apply: WithContext(ctx)}
+}
+
+// WithCodeT is the type-checked WithCode.
+func WithCodeT[T error](code string) TypedOption[T] {
+	return TypedOption[T]{apply: WithCode(code)}
+}
+
+// WithOwnerT is the type-checked WithOwner.
+func WithOwnerT[T error](owner string) TypedOption[T] {
+	return TypedOption[T]{apply: WithOwner(owner)}
+}
+
+// Unchecked passes Option-based options to a type-checked constructor, for
+// options without a typed form yet. They are applied like any other option
+// but, as with the Option-based constructors, ignored if they don't apply
+// to T.
+//
+// Example:
+//
+//	err := errors.NewHTTPErrorT(502, "Bad Gateway",
+//	    errors.Unchecked[*errors.HTTPError](errors.WithUpstreamRequestID(id)))
+func Unchecked[T error](opts ...Option) TypedOption[T] {
+	return TypedOption[T]{apply: func(err any) {
+		for _, opt := range opts {
+			opt(err)
+		}
+	}}
+}
+
+// WithStatusCodeT is the type-checked WithStatusCode.
+func WithStatusCodeT(statusCode int) TypedOption[*HTTPError] {
+	return typedOption(func(e *HTTPError) { e.StatusCode = statusCode })
+}
+
+// WithFieldT is the type-checked WithField.
+func WithFieldT(field string) TypedOption[*ValidationError] {
+	return typedOption(func(e *ValidationError) { e.Field = field })
+}
+
+// WithValueT is the type-checked WithValue.
+func WithValueT(value any) TypedOption[*ValidationError] {
+	return typedOption(func(e *ValidationError) { e.Value = value })
+}
+
+// WithItemIDT is the type-checked WithItemID.
+func WithItemIDT(itemID string) TypedOption[*ProcessingError] {
+	return typedOption(func(e *ProcessingError) { e.ItemID = itemID })
+}
+
+// WithRetryableT is the type-checked WithRetryable.
+func WithRetryableT(retryable bool) TypedOption[*ProcessingError] {
+	return typedOption(func(e *ProcessingError) { e.Retryable = retryable })
+}
+
+// WithTransientT is the type-checked WithTransient.
+func WithTransientT(transient bool) TypedOption[*NetworkError] {
+	return typedOption(func(e *NetworkError) { e.IsTransient = transient })
+}
+
+// WithNetworkKindT is the type-checked WithNetworkKind.
+func WithNetworkKindT(kind NetworkKind) TypedOption[*NetworkError] {
+	return typedOption(func(e *NetworkError) { e.Kind = kind })
+}
+
+// WithRateLimitPolicyT is the type-checked WithRateLimitPolicy.
+func WithRateLimitPolicyT(limit, remaining int, resetAt time.Time) TypedOption[*RateLimitError] {
+	return TypedOption[*RateLimitError]{apply: WithRateLimitPolicy(limit, remaining, resetAt)}
+}
+
+// WithStateT is the type-checked WithState.
+func WithStateT(state string) TypedOption[*CircuitBreakerError] {
+	return typedOption(func(e *CircuitBreakerError) { e.State = state })
+}
+
+// WithCountsT is the type-checked WithCounts.
+func WithCountsT(counts CircuitCounts) TypedOption[*CircuitBreakerError] {
+	return typedOption(func(e *CircuitBreakerError) { e.Counts = counts })
+}
+
+// WithReopenAtT is the type-checked WithReopenAt.
+func WithReopenAtT(t time.Time) TypedOption[*CircuitBreakerError] {
+	return typedOption(func(e *CircuitBreakerError) { e.ReopenAt = t })
+}
+
+// WithReasonT is the type-checked WithReason.
+func WithReasonT(reason string) TypedOption[*RetryError] {
+	return typedOption(func(e *RetryError) { e.Reason = reason })
+}
+
+// WithAttemptHistoryT is the type-checked WithAttemptHistory.
+func WithAttemptHistoryT(history []Attempt) TypedOption[*RetryError] {
+	return typedOption(func(e *RetryError) { e.History = history })
+}
+
+// WithVersionsT is the type-checked WithVersions.
+func WithVersionsT(expected, actual string) TypedOption[*ConflictError] {
+	return typedOption(func(e *ConflictError) { e.ExpectedVersion, e.ActualVersion = expected, actual })
+}
+
+// WithSQLStateT is the type-checked WithSQLState.
+func WithSQLStateT(state string) TypedOption[*DatabaseError] {
+	return typedOption(func(e *DatabaseError) { e.SQLState = state })
+}
+
+// NewHTTPErrorT is NewHTTPError with type-checked options; the cause is set
+// with WithCauseT.
+//
+// Example:
+//
+//	err := errors.NewHTTPErrorT(503, "Service Unavailable",
+//	    errors.WithCauseT[*errors.HTTPError](upstreamErr),
+//	    errors.WithComponentT[*errors.HTTPError]("billing"))
+func NewHTTPErrorT(statusCode int, message string, opts ...TypedOption[*HTTPError]) error {
+	return NewHTTPError(statusCode, message, nil, untyped(opts)...)
+}
+
+// NewValidationErrorT is NewValidationError with type-checked options.
+func NewValidationErrorT(message, field string, opts ...TypedOption[*ValidationError]) error {
+	return NewValidationError(message, field, untyped(opts)...)
+}
+
+// NewTimeoutErrorT is NewTimeoutError with type-checked options.
+func NewTimeoutErrorT(message, operation string, duration time.Duration, opts ...TypedOption[*TimeoutError]) error {
+	return NewTimeoutError(message, operation, duration, untyped(opts)...)
+}
+
+// NewProcessingErrorT is NewProcessingError with type-checked options.
+func NewProcessingErrorT(message, operation string, opts ...TypedOption[*ProcessingError]) error {
+	return NewProcessingError(message, operation, untyped(opts)...)
+}
+
+// NewNetworkErrorT is NewNetworkError with type-checked options.
+func NewNetworkErrorT(message, operation string, opts ...TypedOption[*NetworkError]) error {
+	return NewNetworkError(message, operation, untyped(opts)...)
+}
+
+// NewRateLimitErrorT is NewRateLimitError with type-checked options.
+func NewRateLimitErrorT(message, operation string, retryAfter time.Duration, opts ...TypedOption[*RateLimitError]) error {
+	return NewRateLimitError(message, operation, retryAfter, untyped(opts)...)
+}
+
+// NewRetryableErrorT is NewRetryableError with type-checked options.
+func NewRetryableErrorT(message, operation string, retryAfter time.Duration, opts ...TypedOption[*RetryableError]) error {
+	return NewRetryableError(message, operation, retryAfter, untyped(opts)...)
+}
+
+// NewSerializationErrorT is NewSerializationError with type-checked options.
+func NewSerializationErrorT(message, operation, format string, opts ...TypedOption[*SerializationError]) error {
+	return NewSerializationError(message, operation, format, untyped(opts)...)
+}
+
+// NewCircuitBreakerErrorT is NewCircuitBreakerError with type-checked
+// options.
+func NewCircuitBreakerErrorT(message, operation, state string, opts ...TypedOption[*CircuitBreakerError]) *CircuitBreakerError {
+	return NewCircuitBreakerError(message, operation, state, untyped(opts)...)
+}
+
+// NewRetryErrorT is NewRetryError with type-checked options.
+func NewRetryErrorT(attempts, maxAttempts int, lastError error, allErrors []error, opts ...TypedOption[*RetryError]) *RetryError {
+	return NewRetryError(attempts, maxAttempts, lastError, allErrors, untyped(opts)...)
+}
+
+// NewNotFoundErrorT is NewNotFoundError with type-checked options.
+func NewNotFoundErrorT(resource, id string, opts ...TypedOption[*NotFoundError]) error {
+	return NewNotFoundError(resource, id, untyped(opts)...)
+}
+
+// NewConflictErrorT is NewConflictError with type-checked options.
+func NewConflictErrorT(resource, id string, opts ...TypedOption[*ConflictError]) error {
+	return NewConflictError(resource, id, untyped(opts)...)
+}
+
+// NewDatabaseErrorT is NewDatabaseError with type-checked options.
+func NewDatabaseErrorT(operation, table string, opts ...TypedOption[*DatabaseError]) error {
+	return NewDatabaseError(operation, table, untyped(opts)...)
+}
+
+// NewProviderErrorT is NewProviderError with type-checked options.
+func NewProviderErrorT(provider string, opts ...TypedOption[*ProviderError]) error {
+	return NewProviderError(provider, untyped(opts)...)
+}
@@ -0,0 +1,145 @@
+package errors
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// TestTypedConstructors tests that typed options build the same errors as their Option counterparts
+func TestTypedConstructors(t *testing.T) {
+	cause := fmt.Errorf("db unavailable")
+	ctx := ContextWithTrace(context.Background(), "trace-1", "span-1")
+	resetAt := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name  string
+		typed error
+		want  error
+	}{
+		{
+			name: "HTTPError",
+			typed: NewHTTPErrorT(500, "Internal",
+				WithCauseT[*HTTPError](cause),
+				WithStatusCodeT(503),
+				WithComponentT[*HTTPError]("billing"),
+				WithCodeT[*HTTPError]("BILLING_DOWN"),
+				Unchecked[*HTTPError](WithUpstreamRequestID("req-9"))),
+			want: NewHTTPError(500, "Internal", cause,
+				WithStatusCode(503), WithComponent("billing"), WithCode("BILLING_DOWN"), WithUpstreamRequestID("req-9")),
+		},
+		{
+			name: "ValidationError",
+			typed: NewValidationErrorT("invalid", "email",
+				WithFieldT("phone"), WithValueT(42), WithMessageT[*ValidationError]("bad phone")),
+			want: NewValidationError("invalid", "email", WithField("phone"), WithValue(42), WithMessage("bad phone")),
+		},
+		{
+			name: "TimeoutError",
+			typed: NewTimeoutErrorT("timed out", "Fetch", time.Second,
+				WithOperationT[*TimeoutError]("Load"), WithContextT[*TimeoutError](ctx)),
+			want: NewTimeoutError("timed out", "Fetch", time.Second, WithOperation("Load"), WithContext(ctx)),
+		},
+		{
+			name: "ProcessingError",
+			typed: NewProcessingErrorT("failed", "Charge",
+				WithCauseT[*ProcessingError](cause), WithItemIDT("order-1"), WithRetryableT(true),
+				WithMetadataT[*ProcessingError]("tenant", "acme"), WithOwnerT[*ProcessingError]("payments")),
+			want: NewProcessingError("failed", "Charge",
+				WithCause(cause), WithItemID("order-1"), WithRetryable(true), WithMetadata("tenant", "acme"), WithOwner("payments")),
+		},
+		{
+			name: "NetworkError",
+			typed: NewNetworkErrorT("dial failed", "Connect",
+				WithTransientT(false), WithOperationT[*NetworkError]("Dial"), WithComponentT[*NetworkError]("edge")),
+			want: NewNetworkError("dial failed", "Connect", WithTransient(false), WithOperation("Dial"), WithComponent("edge")),
+		},
+		{
+			name: "RateLimitError",
+			typed: NewRateLimitErrorT("slow down", "Search", time.Minute,
+				WithRateLimitPolicyT(100, 0, resetAt), WithCauseT[*RateLimitError](cause), WithComponentT[*RateLimitError]("search")),
+			want: NewRateLimitError("slow down", "Search", time.Minute,
+				WithRateLimitPolicy(100, 0, resetAt), WithCause(cause), WithComponent("search")),
+		},
+		{
+			name: "RetryableError",
+			typed: NewRetryableErrorT("busy", "Lock", time.Second,
+				WithMessageT[*RetryableError]("locked"), WithOperationT[*RetryableError]("Acquire")),
+			want: NewRetryableError("busy", "Lock", time.Second, WithMessage("locked"), WithOperation("Acquire")),
+		},
+		{
+			name: "SerializationError",
+			typed: NewSerializationErrorT("bad json", "Decode", "json",
+				WithCauseT[*SerializationError](cause), Unchecked[*SerializationError](WithDependency("pricing-api"))),
+			want: NewSerializationError("bad json", "Decode", "json", WithCause(cause), WithDependency("pricing-api")),
+		},
+		{
+			name: "CircuitBreakerError",
+			typed: NewCircuitBreakerErrorT("tripped", "Charge", "closed",
+				WithStateT("open"), WithCountsT(CircuitCounts{ConsecutiveFailures: 5}), WithReopenAtT(resetAt)),
+			want: NewCircuitBreakerError("tripped", "Charge", "closed",
+				WithState("open"), WithCounts(CircuitCounts{ConsecutiveFailures: 5}), WithReopenAt(resetAt)),
+		},
+		{
+			name: "RetryError",
+			typed: NewRetryErrorT(1, 3, nil, nil,
+				WithCauseT[*RetryError](cause), WithReasonT(RetryReasonBudgetExhausted),
+				WithAttemptHistoryT([]Attempt{{Number: 1}}), WithOperationT[*RetryError]("Fetch")),
+			want: NewRetryError(1, 3, nil, nil,
+				WithCause(cause), WithReason(RetryReasonBudgetExhausted), WithAttemptHistory([]Attempt{{Number: 1}}), WithOperation("Fetch")),
+		},
+		{
+			name: "NotFoundError",
+			typed: NewNotFoundErrorT("order", "42",
+				WithMessageT[*NotFoundError]("no such order"), WithCodeT[*NotFoundError]("ORDERS_NOT_FOUND")),
+			want: NewNotFoundError("order", "42", WithMessage("no such order"), WithCode("ORDERS_NOT_FOUND")),
+		},
+		{
+			name: "ConflictError",
+			typed: NewConflictErrorT("order", "42",
+				WithVersionsT("7", "8"), WithCauseT[*ConflictError](cause)),
+			want: NewConflictError("order", "42", WithVersions("7", "8"), WithCause(cause)),
+		},
+		{
+			name: "DatabaseError",
+			typed: NewDatabaseErrorT("Reserve", "stock",
+				WithSQLStateT(SQLStateLockNotAvailable), WithOperationT[*DatabaseError]("Release")),
+			want: NewDatabaseError("Reserve", "stock", WithSQLState(SQLStateLockNotAvailable), WithOperation("Release")),
+		},
+		{
+			name: "ProviderError",
+			typed: NewProviderErrorT("stripe",
+				WithCauseT[*ProviderError](cause), WithComponentT[*ProviderError]("payments"),
+				Unchecked[*ProviderError](WithUpstreamRequestID("req-9"))),
+			want: NewProviderError("stripe", WithCause(cause), WithComponent("payments"), WithUpstreamRequestID("req-9")),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, want := withoutStack(tt.typed), withoutStack(tt.want)
+			if !reflect.DeepEqual(got, want) {
+				t.Errorf("typed constructor built %#v, want %#v", got, want)
+			}
+			if IsRetryable(got) != IsRetryable(want) || Classify(got) != Classify(want) {
+				t.Error("typed constructor should classify like the Option-based one")
+			}
+		})
+	}
+}
+
+// TestTypedOptionsLastWins tests that typed options follow the Option ordering rules
+func TestTypedOptionsLastWins(t *testing.T) {
+	err := NewProcessingErrorT("failed", "Charge",
+		WithRetryableT(true), WithRetryableT(false),
+		WithOperationT[*ProcessingError]("Refund"))
+	procErr := err.(*ProcessingError)
+	if procErr.Retryable || procErr.Operation != "Refund" {
+		t.Errorf("got %+v, want the last WithRetryableT and WithOperationT to win", procErr)
+	}
+	if procErr.StackTrace() == nil {
+		t.Error("typed constructors should capture a stack")
+	}
+}
@@ -0,0 +1,138 @@
+package errors
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+)
+
+// Warnings is a list of non-fatal issues encountered by an operation that
+// still succeeded, such as records skipped during an import. Entries are
+// typically ProcessingError or ValidationError values and are reported at
+// SeverityWarning.
+type Warnings []error
+
+// AsError combines the warnings into a single error, for callers that decide
+// the issues are fatal after all. errors.Is and errors.As see every warning.
+// Returns nil when there are no warnings.
+//
+// Example:
+//
+//	if warnings := WarningsFromContext(ctx); len(warnings) > strictLimit {
+//	    return warnings.AsError()
+//	}
+func (w Warnings) AsError() error {
+	if len(w) == 0 {
+		return nil
+	}
+	return &warningsError{warnings: append(Warnings(nil), w...)}
+}
+
+// Info returns structured information for each warning, as produced by
+// ExtractErrorInfo, with severity set to "warning".
+func (w Warnings) Info() []map[string]any {
+	if len(w) == 0 {
+		return nil
+	}
+
+	infos := make([]map[string]any, 0, len(w))
+	for _, warning := range w {
+		info := ExtractErrorInfo(warning)
+		info["severity"] = SeverityWarning.String()
+		infos = append(infos, info)
+	}
+	return infos
+}
+
+// warningsError is the error returned by Warnings.AsError.
+type warningsError struct {
+	warnings Warnings
+}
+
+func (e *warningsError) Error() string {
+	messages := make([]string, len(e.warnings))
+	for i, warning := range e.warnings {
+		messages[i] = warning.Error()
+	}
+
+	noun := "warnings"
+	if len(e.warnings) == 1 {
+		noun = "warning"
+	}
+	return fmt.Sprintf("%d %s: %s", len(e.warnings), noun, strings.Join(messages, "; "))
+}
+
+func (e *warningsError) Unwrap() []error {
+	return e.warnings
+}
+
+type warningsContextKey struct{}
+
+// warningCollector accumulates warnings for one operation.
+type warningCollector struct {
+	mu       sync.Mutex
+	warnings Warnings
+}
+
+// CollectWarnings returns a copy of ctx that collects warnings reported with
+// WarnCtx. HTTP middleware or job runners typically install it once per
+// request or job.
+//
+// Example:
+//
+//	ctx = errors.CollectWarnings(ctx)
+//	result, err := importRecords(ctx, rows)
+//	for _, w := range errors.WarningsFromContext(ctx) {
+//	    log.Printf("import warning: %v", w)
+//	}
+func CollectWarnings(ctx context.Context) context.Context {
+	return context.WithValue(ctx, warningsContextKey{}, &warningCollector{})
+}
+
+// WarnCtx records a non-fatal issue on ctx's warning collector.
+// Returns false (and drops the warning) if ctx has no collector or err is nil.
+// Safe for concurrent use.
+//
+// Example:
+//
+//	if row.Date.IsZero() {
+//	    errors.WarnCtx(ctx, errors.NewValidationError("invalid date", "date",
+//	        errors.WithValue(row.RawDate)))
+//	    continue
+//	}
+func WarnCtx(ctx context.Context, err error) bool {
+	collector := warningsCollector(ctx)
+	if collector == nil || err == nil {
+		return false
+	}
+
+	collector.mu.Lock()
+	defer collector.mu.Unlock()
+	collector.warnings = append(collector.warnings, err)
+	return true
+}
+
+// WarningsFromContext returns the warnings recorded on ctx so far, in the
+// order they were reported. Returns nil if ctx has no collector.
+func WarningsFromContext(ctx context.Context) Warnings {
+	collector := warningsCollector(ctx)
+	if collector == nil {
+		return nil
+	}
+
+	collector.mu.Lock()
+	defer collector.mu.Unlock()
+	if len(collector.warnings) == 0 {
+		return nil
+	}
+	return append(Warnings(nil), collector.warnings...)
+}
+
+func warningsCollector(ctx context.Context) *warningCollector {
+	if ctx == nil {
+		return nil
+	}
+	collector, _ := ctx.Value(warningsContextKey{}).(*warningCollector)
+	return collector
+}
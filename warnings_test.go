@@ -0,0 +1,177 @@
+package errors
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+)
+
+// TestWarnings tests collecting non-fatal issues on a context
+func TestWarnings(t *testing.T) {
+	t.Run("collects in order", func(t *testing.T) {
+		ctx := CollectWarnings(context.Background())
+		first := NewValidationError("invalid date", "date")
+		second := NewProcessingError("record skipped", "Import", WithItemID("row-7"))
+
+		if !WarnCtx(ctx, first) || !WarnCtx(ctx, second) {
+			t.Fatal("WarnCtx() should accept warnings when a collector is installed")
+		}
+
+		warnings := WarningsFromContext(ctx)
+		if len(warnings) != 2 || warnings[0] != first || warnings[1] != second {
+			t.Errorf("WarningsFromContext() = %v", warnings)
+		}
+	})
+
+	t.Run("without collector", func(t *testing.T) {
+		ctx := context.Background()
+		if WarnCtx(ctx, fmt.Errorf("ignored")) {
+			t.Error("WarnCtx() should report false without a collector")
+		}
+		if warnings := WarningsFromContext(ctx); warnings != nil {
+			t.Errorf("WarningsFromContext() = %v, want nil", warnings)
+		}
+	})
+
+	t.Run("nil warning is dropped", func(t *testing.T) {
+		ctx := CollectWarnings(context.Background())
+		if WarnCtx(ctx, nil) {
+			t.Error("WarnCtx(nil) should report false")
+		}
+		if warnings := WarningsFromContext(ctx); warnings != nil {
+			t.Errorf("WarningsFromContext() = %v, want nil", warnings)
+		}
+	})
+
+	t.Run("concurrent reporting", func(t *testing.T) {
+		ctx := CollectWarnings(context.Background())
+		var wg sync.WaitGroup
+		for i := range 50 {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				WarnCtx(ctx, fmt.Errorf("warning %d", i))
+			}()
+		}
+		wg.Wait()
+
+		if n := len(WarningsFromContext(ctx)); n != 50 {
+			t.Errorf("got %d warnings, want 50", n)
+		}
+	})
+
+	t.Run("AsError", func(t *testing.T) {
+		if err := Warnings(nil).AsError(); err != nil {
+			t.Errorf("AsError() on no warnings = %v, want nil", err)
+		}
+
+		validation := NewValidationError("invalid date", "date")
+		err := Warnings{validation, fmt.Errorf("row 9 truncated")}.AsError()
+
+		want := "2 warnings: validation failed for field 'date' (value: <nil>): invalid date; row 9 truncated"
+		if err.Error() != want {
+			t.Errorf("got %q, want %q", err.Error(), want)
+		}
+		if !IsValidation(err) {
+			t.Error("AsError() should expose each warning to errors.As")
+		}
+	})
+}
+
+// TestWarningsEmission tests that warnings reach the problem+json and slog adapters
+func TestWarningsEmission(t *testing.T) {
+	newCtx := func() context.Context {
+		ctx := CollectWarnings(context.Background())
+		WarnCtx(ctx, NewProcessingError("record skipped", "Import", WithItemID("row-7")))
+		return ctx
+	}
+
+	t.Run("problem+json", func(t *testing.T) {
+		rec := httptest.NewRecorder()
+		WriteProblemCtx(newCtx(), rec, NewValidationError("Invalid email", "email"))
+
+		if ct := rec.Header().Get("Content-Type"); ct != ProblemContentType {
+			t.Errorf("Content-Type = %q", ct)
+		}
+		if rec.Code != http.StatusBadRequest {
+			t.Errorf("status = %d, want 400", rec.Code)
+		}
+
+		var body struct {
+			Status   int              `json:"status"`
+			Detail   string           `json:"detail"`
+			Warnings []map[string]any `json:"warnings"`
+		}
+		if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+			t.Fatal(err)
+		}
+		if body.Status != 400 || body.Detail != "Invalid email" {
+			t.Errorf("unexpected problem: %+v", body)
+		}
+		if len(body.Warnings) != 1 || body.Warnings[0]["item_id"] != "row-7" || body.Warnings[0]["severity"] != "warning" {
+			t.Errorf("unexpected warnings: %v", body.Warnings)
+		}
+	})
+
+	t.Run("problem+json without warnings", func(t *testing.T) {
+		rec := httptest.NewRecorder()
+		WriteProblem(rec, NewHTTPError(500, "Internal Server Error", fmt.Errorf("db password: hunter2")))
+
+		if bytes.Contains(rec.Body.Bytes(), []byte("hunter2")) || bytes.Contains(rec.Body.Bytes(), []byte("warnings")) {
+			t.Errorf("unexpected body: %s", rec.Body.String())
+		}
+	})
+
+	t.Run("slog", func(t *testing.T) {
+		var buf bytes.Buffer
+		logger := slog.New(slog.NewJSONHandler(&buf, nil))
+		LogError(newCtx(), logger, "import finished", nil)
+
+		var record map[string]any
+		if err := json.Unmarshal(buf.Bytes(), &record); err != nil {
+			t.Fatalf("invalid log output %q: %v", buf.String(), err)
+		}
+		if record["level"] != "WARN" {
+			t.Errorf("level = %v, want WARN", record["level"])
+		}
+		warnings, ok := record["warnings"].([]any)
+		if !ok || len(warnings) != 1 {
+			t.Fatalf("unexpected warnings: %v", record["warnings"])
+		}
+		if _, hasErr := record["error"]; hasErr {
+			t.Error("no error key expected for a successful operation")
+		}
+	})
+
+	t.Run("slog with error", func(t *testing.T) {
+		var buf bytes.Buffer
+		logger := slog.New(slog.NewJSONHandler(&buf, nil))
+		LogError(newCtx(), logger, "import failed", NewProcessingError("disk full", "Import"))
+
+		var record map[string]any
+		if err := json.Unmarshal(buf.Bytes(), &record); err != nil {
+			t.Fatal(err)
+		}
+		errInfo, ok := record["error"].(map[string]any)
+		if record["level"] != "ERROR" || !ok || errInfo["type"] != "ProcessingError" {
+			t.Errorf("unexpected record: %v", record)
+		}
+		if _, ok := record["warnings"]; !ok {
+			t.Error("warnings should be logged alongside the error")
+		}
+	})
+
+	t.Run("slog with nothing to report", func(t *testing.T) {
+		var buf bytes.Buffer
+		LogError(CollectWarnings(context.Background()), slog.New(slog.NewJSONHandler(&buf, nil)), "ok", nil)
+		if buf.Len() != 0 {
+			t.Errorf("expected no output, got %s", buf.String())
+		}
+	})
+}
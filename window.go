@@ -0,0 +1,137 @@
+package errors
+
+import (
+	"container/list"
+	"time"
+)
+
+// windowBuckets is how many slices a sliding window is counted in.
+const windowBuckets = 10
+
+// windowCounts counts events of up to two kinds over a sliding window, in
+// windowBuckets slices so old events fall out a slice at a time.
+type windowCounts struct {
+	buckets     [windowBuckets][2]int
+	head        int       // index of the bucket being filled
+	bucketStart time.Time // when the head bucket started
+	lastSeen    time.Time // when an event was last added
+}
+
+// total returns the events of kind still in the window.
+func (w *windowCounts) total(kind int) int {
+	total := 0
+	for _, bucket := range w.buckets {
+		total += bucket[kind]
+	}
+	return total
+}
+
+// windowEntry is one key's counts and its owner's state.
+type windowEntry[S any] struct {
+	key    string
+	counts windowCounts
+	state  S
+}
+
+// windowedKeys keeps windowCounts per key for Escalator and HealthTracker.
+// Keys idle for a whole window are forgotten, and the least recently seen
+// are evicted past maxKeys (no limit when zero). Not safe for concurrent
+// use; owners hold their own lock.
+type windowedKeys[S any] struct {
+	window  time.Duration
+	maxKeys int
+	keys    map[string]*list.Element
+	order   *list.List // front = most recently seen
+}
+
+func newWindowedKeys[S any](window time.Duration, maxKeys int) *windowedKeys[S] {
+	return &windowedKeys[S]{
+		window:  window,
+		maxKeys: maxKeys,
+		keys:    make(map[string]*list.Element),
+		order:   list.New(),
+	}
+}
+
+// add records an event of kind for key at now and returns key's entry,
+// created with state initial if key wasn't tracked.
+func (k *windowedKeys[S]) add(key string, kind int, now time.Time, initial S) *windowEntry[S] {
+	k.evictIdle(now)
+	entry := k.touch(key, initial)
+	k.advance(&entry.counts, now)
+	entry.counts.buckets[entry.counts.head][kind]++
+	entry.counts.lastSeen = now
+	return entry
+}
+
+// each calls fn with every tracked entry, its counts advanced to now.
+func (k *windowedKeys[S]) each(now time.Time, fn func(*windowEntry[S])) {
+	k.evictIdle(now)
+	for _, elem := range k.keys {
+		entry := elem.Value.(*windowEntry[S])
+		k.advance(&entry.counts, now)
+		fn(entry)
+	}
+}
+
+// len returns the number of keys tracked.
+func (k *windowedKeys[S]) len() int {
+	return len(k.keys)
+}
+
+// bucketWidth returns the span of one bucket of the window.
+func (k *windowedKeys[S]) bucketWidth() time.Duration {
+	return max(k.window/windowBuckets, 1)
+}
+
+// advance rotates counts' buckets forward to now, clearing those that fell
+// out of the window.
+func (k *windowedKeys[S]) advance(counts *windowCounts, now time.Time) {
+	width := k.bucketWidth()
+	if counts.bucketStart.IsZero() {
+		counts.bucketStart = now
+		return
+	}
+	steps := int(now.Sub(counts.bucketStart) / width)
+	if steps <= 0 {
+		return
+	}
+	for i := 0; i < min(steps, windowBuckets); i++ {
+		counts.head = (counts.head + 1) % windowBuckets
+		counts.buckets[counts.head] = [2]int{}
+	}
+	counts.bucketStart = counts.bucketStart.Add(time.Duration(steps) * width)
+}
+
+// touch returns the entry for key, creating it if needed, and marks it
+// most recently seen.
+func (k *windowedKeys[S]) touch(key string, initial S) *windowEntry[S] {
+	if elem, ok := k.keys[key]; ok {
+		k.order.MoveToFront(elem)
+		return elem.Value.(*windowEntry[S])
+	}
+
+	entry := &windowEntry[S]{key: key, state: initial}
+	k.keys[key] = k.order.PushFront(entry)
+	for k.maxKeys > 0 && len(k.keys) > k.maxKeys {
+		k.removeOldest()
+	}
+	return entry
+}
+
+// evictIdle drops keys with nothing left in their window; an event's
+// bucket rotates out at most a window after it was added.
+func (k *windowedKeys[S]) evictIdle(now time.Time) {
+	for oldest := k.order.Back(); oldest != nil; oldest = k.order.Back() {
+		if now.Sub(oldest.Value.(*windowEntry[S]).counts.lastSeen) < k.window {
+			return
+		}
+		k.removeOldest()
+	}
+}
+
+func (k *windowedKeys[S]) removeOldest() {
+	oldest := k.order.Back()
+	k.order.Remove(oldest)
+	delete(k.keys, oldest.Value.(*windowEntry[S]).key)
+}
@@ -0,0 +1,42 @@
+package errors
+
+import (
+	"testing"
+	"time"
+)
+
+// TestWindowedKeys tests that counts slide out of the window and idle or excess keys are evicted
+func TestWindowedKeys(t *testing.T) {
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	t.Run("sliding counts", func(t *testing.T) {
+		keys := newWindowedKeys[struct{}](10*time.Second, 0)
+		keys.add("a", 0, start, struct{}{})
+		keys.add("a", 1, start.Add(5*time.Second), struct{}{})
+		entry := keys.add("a", 0, start.Add(9*time.Second), struct{}{})
+		if got := entry.counts.total(0); got != 2 {
+			t.Errorf("total(0) = %d, want 2", got)
+		}
+
+		keys.each(start.Add(12*time.Second), func(entry *windowEntry[struct{}]) {
+			if got0, got1 := entry.counts.total(0), entry.counts.total(1); got0 != 1 || got1 != 1 {
+				t.Errorf("totals after the first event slid out = %d, %d, want 1, 1", got0, got1)
+			}
+		})
+	})
+
+	t.Run("eviction", func(t *testing.T) {
+		keys := newWindowedKeys[int](10*time.Second, 2)
+		keys.add("a", 0, start, -1)
+		keys.add("b", 0, start.Add(time.Second), -1)
+		keys.add("a", 0, start.Add(2*time.Second), -1)
+		keys.add("c", 0, start.Add(3*time.Second), -1)
+		if _, ok := keys.keys["b"]; ok || keys.len() != 2 {
+			t.Errorf("least recently seen key kept; %d keys", keys.len())
+		}
+
+		if entry := keys.add("c", 0, start.Add(12*time.Second), -1); entry.state != -1 || keys.len() != 1 {
+			t.Errorf("idle key kept; %d keys", keys.len())
+		}
+	})
+}
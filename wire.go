@@ -0,0 +1,348 @@
+package errors
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"sort"
+
+	"github.com/cockroachdb/errors"
+)
+
+// Envelope member names. These are the wire contract shared with services
+// written in other languages: renaming one breaks them, so a change here
+// must come with updated testdata/wire fixtures and a new EnvelopeVersion.
+const (
+	WireVersion         = "version"
+	WireOriginKey       = "origin_key"
+	WireType            = "type"
+	WireMessage         = "message"
+	WireOperation       = "operation"
+	WireComponent       = "component"
+	WireCode            = "code"
+	WireOwner           = "owner"
+	WireStatusCode      = "status_code"
+	WireOriginComponent = "origin_component"
+	WireUpstreamID      = "upstream_request_id"
+	WireField           = "field"
+	WireValue           = "value"
+	WireValueString     = "is_string_encoded"
+	WireItemID          = "item_id"
+	WireRetryable       = "retryable"
+	WireTransient       = "transient"
+	WireOverloaded      = "overloaded"
+	WireRetryAfterMS    = "retry_after_ms"
+	WireLimit           = "limit"
+	WireRemaining       = "remaining"
+	WireResetAt         = "reset_at"
+	WireReopenAt        = "reopen_at"
+	WireDurationMS      = "duration_ms"
+	WireSource          = "source"
+	WireFormat          = "format"
+	WireDirection       = "direction"
+	WireDependency      = "dependency"
+	WireNetworkKind     = "network_kind"
+	WireClass           = "class"
+	WireState           = "state"
+	WireCounts          = "counts"
+	WireFeature         = "feature"
+	WireAvailableFrom   = "available_from"
+	WireUnsupported     = "unsupported"
+	WireAlternative     = "alternative"
+	WireResource        = "resource"
+	WireResourceID      = "resource_id"
+	WireMinVersion      = "min_version"
+	WireObserved        = "observed_version"
+	WireExpected        = "expected_version"
+	WireActual          = "actual_version"
+	WireTable           = "table"
+	WireSQLState        = "sqlstate"
+	WireProvider        = "provider"
+	WireLagMS           = "lag_ms"
+	WireRetryPrimary    = "retry_against_primary"
+	WireAttempts        = "attempts"
+	WireMaxAttempts     = "max_attempts"
+	WireReason          = "reason"
+	WirePlans           = "plans"
+	WireMetadata        = "metadata"
+	WireCause           = "cause"
+	WireCauses          = "causes"
+	WireAllErrors       = "all_errors"
+)
+
+// Problem details member names written by WriteProblem, standard RFC 7807
+// members first, then this package's extensions.
+const (
+	ProblemType          = "type"
+	ProblemTitle         = "title"
+	ProblemStatus        = "status"
+	ProblemDetail        = "detail"
+	ProblemInstance      = "instance"
+	ProblemReference     = "reference"
+	ProblemCode          = "code"
+	ProblemField         = "field"
+	ProblemRetryAfter    = "retry_after"
+	ProblemWarnings      = "warnings"
+	ProblemFeature       = "feature"
+	ProblemAvailableFrom = "available_from"
+	ProblemUnsupported   = "unsupported"
+	ProblemAlternative   = "alternative"
+	ProblemValue         = "value"
+	ProblemState         = "state"
+	ProblemCounts        = "counts"
+
+	ProblemValueStringEncoded = "is_string_encoded"
+)
+
+// wireKind is the JSON type a wire member must have.
+type wireKind int
+
+const (
+	wireString wireKind = iota
+	wireInteger
+	wireNumber
+	wireBool
+	wireObject
+	wireArray
+	wireAny
+	wireNode     // a nested envelope
+	wireNodeList // an array of nested envelopes
+)
+
+func (k wireKind) String() string {
+	switch k {
+	case wireString:
+		return "a string"
+	case wireInteger:
+		return "an integer"
+	case wireNumber:
+		return "a number"
+	case wireBool:
+		return "a boolean"
+	case wireObject, wireNode:
+		return "an object"
+	case wireArray, wireNodeList:
+		return "an array"
+	}
+	return "any value"
+}
+
+// envelopeWire lists every envelope member and its kind.
+var envelopeWire = map[string]wireKind{
+	WireVersion:         wireInteger,
+	WireOriginKey:       wireString,
+	WireType:            wireString,
+	WireMessage:         wireString,
+	WireOperation:       wireString,
+	WireComponent:       wireString,
+	WireCode:            wireString,
+	WireOwner:           wireString,
+	WireStatusCode:      wireInteger,
+	WireOriginComponent: wireString,
+	WireUpstreamID:      wireString,
+	WireField:           wireString,
+	WireValue:           wireAny,
+	WireValueString:     wireBool,
+	WireItemID:          wireString,
+	WireRetryable:       wireBool,
+	WireTransient:       wireBool,
+	WireOverloaded:      wireBool,
+	WireRetryAfterMS:    wireNumber,
+	WireLimit:           wireInteger,
+	WireRemaining:       wireInteger,
+	WireResetAt:         wireString,
+	WireReopenAt:        wireString,
+	WireDurationMS:      wireNumber,
+	WireSource:          wireString,
+	WireFormat:          wireString,
+	WireDirection:       wireString,
+	WireDependency:      wireString,
+	WireNetworkKind:     wireString,
+	WireClass:           wireString,
+	WireState:           wireString,
+	WireCounts:          wireObject,
+	WireFeature:         wireString,
+	WireAvailableFrom:   wireString,
+	WireUnsupported:     wireString,
+	WireAlternative:     wireString,
+	WireResource:        wireString,
+	WireResourceID:      wireString,
+	WireMinVersion:      wireString,
+	WireObserved:        wireString,
+	WireExpected:        wireString,
+	WireActual:          wireString,
+	WireTable:           wireString,
+	WireSQLState:        wireString,
+	WireProvider:        wireString,
+	WireLagMS:           wireNumber,
+	WireRetryPrimary:    wireBool,
+	WireAttempts:        wireInteger,
+	WireMaxAttempts:     wireInteger,
+	WireReason:          wireString,
+	WirePlans:           wireArray,
+	WireMetadata:        wireObject,
+	WireCause:           wireNode,
+	WireCauses:          wireNodeList,
+	WireAllErrors:       wireNodeList,
+}
+
+// problemWire lists the problem details members and their kinds.
+var problemWire = map[string]wireKind{
+	ProblemType:          wireString,
+	ProblemTitle:         wireString,
+	ProblemStatus:        wireInteger,
+	ProblemDetail:        wireString,
+	ProblemInstance:      wireString,
+	ProblemReference:     wireString,
+	ProblemCode:          wireString,
+	ProblemField:         wireString,
+	ProblemRetryAfter:    wireInteger,
+	ProblemWarnings:      wireArray,
+	ProblemFeature:       wireString,
+	ProblemAvailableFrom: wireString,
+	ProblemUnsupported:   wireString,
+	ProblemAlternative:   wireString,
+	ProblemValue:         wireAny,
+	ProblemState:         wireString,
+	ProblemCounts:        wireObject,
+
+	ProblemValueStringEncoded: wireBool,
+}
+
+// ValidateWirePayload checks that data is an error envelope (as written by
+// MarshalError) or a problem details body (as written by WriteProblem)
+// matching this version's wire contract, so test suites of services in
+// other languages can check what they produce. Envelopes may only use
+// known members, at any depth; problem details may carry extra extensions,
+// as RFC 7807 allows, but known members must have the right types.
+// Returns nil for a valid payload; otherwise each problem is a
+// ValidationError whose Field is the JSON path of the member.
+//
+// Example:
+//
+//	if err := errors.ValidateWirePayload(body); err != nil {
+//	    t.Errorf("payload breaks the error contract: %v", err)
+//	}
+func ValidateWirePayload(data []byte) error {
+	var root map[string]any
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	if err := decoder.Decode(&root); err != nil || root == nil {
+		return NewSerializationError("payload is not a JSON object", "ValidateWirePayload", "json", WithCause(err))
+	}
+
+	var problems []error
+	report := func(path, format string, args ...any) {
+		problems = append(problems, NewValidationError(fmt.Sprintf(format, args...), path))
+	}
+
+	if _, ok := root[ProblemTitle]; ok {
+		validateProblemPayload(root, report)
+	} else {
+		validateEnvelopeNode(root, "$", true, report)
+	}
+	return errors.Join(problems...)
+}
+
+// validateProblemPayload checks a problem details body.
+func validateProblemPayload(root map[string]any, report func(path, format string, args ...any)) {
+	for _, name := range []string{ProblemType, ProblemTitle, ProblemStatus} {
+		if _, ok := root[name]; !ok {
+			report("$."+name, "required member %q is missing", name)
+		}
+	}
+	for _, name := range sortedKeys(root) {
+		if kind, known := problemWire[name]; known {
+			checkWireKind(root[name], kind, "$."+name, report)
+		}
+	}
+	if status, ok := root[ProblemStatus].(json.Number); ok {
+		if n, err := status.Int64(); err == nil && (n < 100 || n > 599) {
+			report("$."+ProblemStatus, "status %d is not an HTTP status code", n)
+		}
+	}
+}
+
+// validateEnvelopeNode checks one envelope node and its nested nodes.
+func validateEnvelopeNode(node map[string]any, path string, root bool, report func(path, format string, args ...any)) {
+	if _, ok := node[WireType]; !ok {
+		report(path+"."+WireType, "required member %q is missing", WireType)
+	}
+	for _, rootOnly := range []string{WireVersion, WireOriginKey} {
+		if _, ok := node[rootOnly]; ok && !root {
+			report(path+"."+rootOnly, "%q is only allowed on the root node", rootOnly)
+		}
+	}
+
+	for _, name := range sortedKeys(node) {
+		memberPath := path + "." + name
+		kind, known := envelopeWire[name]
+		if !known {
+			report(memberPath, "unknown member %q is not in wire contract version %d", name, EnvelopeVersion)
+			continue
+		}
+		if !checkWireKind(node[name], kind, memberPath, report) {
+			continue
+		}
+
+		switch kind {
+		case wireNode:
+			validateEnvelopeNode(node[name].(map[string]any), memberPath, false, report)
+		case wireNodeList:
+			for i, item := range node[name].([]any) {
+				itemPath := fmt.Sprintf("%s[%d]", memberPath, i)
+				if child, ok := item.(map[string]any); ok {
+					validateEnvelopeNode(child, itemPath, false, report)
+				} else {
+					report(itemPath, "must be an object")
+				}
+			}
+		}
+	}
+
+	if class, ok := node[WireClass].(string); ok {
+		switch ErrorClass(class) {
+		case ClassTransient, ClassPermanent, ClassContext, ClassUnknown:
+		default:
+			report(path+"."+WireClass, "unknown class %q", class)
+		}
+	}
+}
+
+// checkWireKind reports whether value has the JSON type kind requires.
+func checkWireKind(value any, kind wireKind, path string, report func(path, format string, args ...any)) bool {
+	var ok bool
+	switch kind {
+	case wireString:
+		_, ok = value.(string)
+	case wireInteger:
+		var n json.Number
+		if n, ok = value.(json.Number); ok {
+			_, err := n.Int64()
+			ok = err == nil
+		}
+	case wireNumber:
+		_, ok = value.(json.Number)
+	case wireBool:
+		_, ok = value.(bool)
+	case wireObject, wireNode:
+		_, ok = value.(map[string]any)
+	case wireArray, wireNodeList:
+		_, ok = value.([]any)
+	default:
+		ok = true
+	}
+	if !ok {
+		report(path, "must be %s", kind)
+	}
+	return ok
+}
+
+func sortedKeys(m map[string]any) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,157 @@
+package errors
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sort"
+	"testing"
+	"time"
+)
+
+// wireExamples returns the canonical example of each typed error written to testdata/wire
+func wireExamples() map[string]error {
+	resetAt := time.Date(2026, 1, 2, 15, 4, 5, 0, time.UTC)
+	return map[string]error{
+		"http_error": NewHTTPError(502, "Bad Gateway", NewNetworkError("connection refused", "Connect"),
+			WithComponent("gateway"), WithCode("UPSTREAM_DOWN")),
+		"validation_error": NewValidationError("Invalid email", "email",
+			WithValue("x@"), WithCode("SIGNUP_INVALID"), WithMetadata("form", "signup")),
+		"timeout_error": NewTimeoutError("timed out", "Fetch", 2*time.Second, WithComponent("catalog")),
+		"headroom_timeout_error": &TimeoutError{
+			Message:   "20ms left before the deadline, 100ms needed",
+			Operation: "GetQuote", Duration: 20 * time.Millisecond, Source: TimeoutSourceHeadroom,
+		},
+		"rate_limit_error": NewRateLimitError("Too many requests", "Search", 30*time.Second,
+			WithRateLimitPolicy(100, 0, resetAt)),
+		"retryable_error":  NewRetryableError("Lock held", "Lock", time.Second),
+		"processing_error": NewRetryableProcessingError("Failed to charge", "Charge", WithItemID("order-1")),
+		"network_error": NewNetworkError("dial failed", "Connect", WithTransient(false),
+			WithNetworkKind(NetworkKindDial)),
+		"serialization_error": NewSerializationError("unexpected EOF", "Decode", "json",
+			WithCause(New("truncated body"))),
+		"circuit_breaker_error": NewCircuitBreakerError("circuit open", "Call", "open",
+			WithCounts(CircuitCounts{Requests: 10, TotalFailures: 6, ConsecutiveFailures: 6})),
+		"retry_error": NewRetryError(3, 3, NewTimeoutError("slow", "Fetch", time.Second),
+			[]error{NewTimeoutError("slow", "Fetch", time.Second)}, WithOperation("Fetch")),
+		"not_implemented_error": NewNotImplementedError("bulk export", WithAvailableFrom(resetAt),
+			WithCode("EXPORT_ROLLOUT")),
+		"unsupported_error": NewUnsupportedError("HEIC uploads", "JPEG or PNG", WithOperation("Upload")),
+		"consistency_error": NewConsistencyError("order/42", "17", "15", 1500*time.Millisecond,
+			WithRetryAgainstPrimary(), WithOperation("GetOrder")),
+		"not_found_error": NewNotFoundError("order", "42", WithComponent("orders"), WithCode("ORDERS_NOT_FOUND")),
+		"conflict_error":  NewConflictError("order", "42", WithVersions("7", "8"), WithRetryable(true)),
+		"database_error":  NewDatabaseError("UpdateOrder", "orders", WithSQLState(SQLStateDeadlockDetected)),
+		"provider_error": NewProviderError("stripe", WithCode("card_declined"), WithStatusCode(402),
+			WithUpstreamRequestID("req_123"), WithOperation("CreateCharge")),
+		"config_error": NewConfigError("must be at least 1", "max", WithValue(0), WithOperation("ParseRetrySpec")),
+		"panic_error":  NewPanicError("assignment to entry in nil map", WithOperation("ProcessJob")),
+	}
+}
+
+// TestWireFixtures tests that serialized examples match the committed wire contract fixtures
+func TestWireFixtures(t *testing.T) {
+	examples := wireExamples()
+	names := make([]string, 0, len(examples))
+	for name := range examples {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		err := examples[name]
+		t.Run(name, func(t *testing.T) {
+			envelope, marshalErr := json.MarshalIndent(Encode(err), "", "  ")
+			if marshalErr != nil {
+				t.Fatalf("MarshalIndent() error = %v", marshalErr)
+			}
+			checkWireFixture(t, filepath.Join("testdata", "wire", "envelope", name+".json"), envelope)
+
+			rec := httptest.NewRecorder()
+			WriteProblem(rec, err)
+			var problem bytes.Buffer
+			if indentErr := json.Indent(&problem, rec.Body.Bytes(), "", "  "); indentErr != nil {
+				t.Fatalf("json.Indent() error = %v", indentErr)
+			}
+			checkWireFixture(t, filepath.Join("testdata", "wire", "problem", name+".json"), problem.Bytes())
+		})
+	}
+}
+
+// checkWireFixture compares got with the fixture at path, rewriting it with -update
+func checkWireFixture(t *testing.T, path string, got []byte) {
+	t.Helper()
+
+	got = append(got, '\n')
+	if *updateGolden {
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatalf("creating fixture directory: %v", err)
+		}
+		if err := os.WriteFile(path, got, 0o644); err != nil {
+			t.Fatalf("writing fixture: %v", err)
+		}
+	}
+
+	want, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("reading fixture: %v", err)
+	}
+	if !bytes.Equal(got, want) {
+		t.Errorf("wire shape changed for %s; review the change and run go test -run TestWireFixtures -update\n%s", path, got)
+	}
+	if err := ValidateWirePayload(want); err != nil {
+		t.Errorf("ValidateWirePayload(%s) = %v", path, err)
+	}
+}
+
+// TestWireConstants tests that the member constants cover every envelope JSON member
+func TestWireConstants(t *testing.T) {
+	for name := range envelopeMembers {
+		if _, ok := envelopeWire[name]; !ok {
+			t.Errorf("envelope member %q has no Wire constant", name)
+		}
+	}
+	for name := range envelopeWire {
+		if !envelopeMembers[name] {
+			t.Errorf("Wire constant %q is not an envelope member", name)
+		}
+	}
+}
+
+// TestValidateWirePayload tests rejection of payloads that break the wire contract
+func TestValidateWirePayload(t *testing.T) {
+	tests := []struct {
+		name      string
+		payload   string
+		wantError bool
+		wantField string
+	}{
+		{name: "envelope", payload: `{"version":2,"type":"HTTPError","message":"Bad Gateway","status_code":502,"retryable":true}`},
+		{name: "problem", payload: `{"type":"about:blank","title":"Bad Request","status":400,"field":"email","custom":1}`},
+		{name: "not an object", payload: `[1]`, wantError: true},
+		{name: "missing type", payload: `{"message":"x"}`, wantError: true, wantField: "$.type"},
+		{name: "renamed member", payload: `{"type":"HTTPError","statusCode":502}`, wantError: true, wantField: "$.statusCode"},
+		{name: "wrong type", payload: `{"type":"HTTPError","status_code":"502"}`, wantError: true, wantField: "$.status_code"},
+		{name: "nested cause", payload: `{"type":"HTTPError","cause":{"type":"Error","retryable":"no"}}`, wantError: true, wantField: "$.cause.retryable"},
+		{name: "nested list", payload: `{"type":"RetryError","all_errors":[{"message":"x"}]}`, wantError: true, wantField: "$.all_errors[0].type"},
+		{name: "version on inner node", payload: `{"type":"HTTPError","cause":{"type":"Error","version":2}}`, wantError: true, wantField: "$.cause.version"},
+		{name: "unknown class", payload: `{"type":"Error","class":"flaky"}`, wantError: true, wantField: "$.class"},
+		{name: "problem status out of range", payload: `{"type":"about:blank","title":"x","status":42}`, wantError: true, wantField: "$.status"},
+		{name: "problem missing status", payload: `{"type":"about:blank","title":"x"}`, wantError: true, wantField: "$.status"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateWirePayload([]byte(tt.payload))
+			if (err != nil) != tt.wantError {
+				t.Fatalf("ValidateWirePayload() = %v, wantError %v", err, tt.wantError)
+			}
+			var validationErr *ValidationError
+			if tt.wantField != "" && (!As(err, &validationErr) || validationErr.Field != tt.wantField) {
+				t.Errorf("ValidateWirePayload() = %v, want a problem at %s", err, tt.wantField)
+			}
+		})
+	}
+}